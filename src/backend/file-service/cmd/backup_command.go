@@ -0,0 +1,216 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+const backupUsage = `Usage: file-service backup --dir DIR [flags]
+
+Writes a backup for disaster recovery to DIR: a dump of the metadata
+tables and a manifest of the objects they reference, files' content and
+derived objects, with their sizes and checksums, all read from one
+consistent snapshot. Objects stay in the bucket, which should be replicated
+or versioned.
+
+Tables backed up: files, blobs, file_shares, attachments, derived_objects,
+file_locks, file_requests, tenant_settings, notification_preferences.
+
+Tables left out:
+  audit_events, audit_export_checkpoint
+        the audit log is exported and signed on its own
+  upload_sessions, upload_session_parts
+        in-flight uploads, whose scratch objects are not copied
+  extraction_jobs
+        progress of archive extractions; the files extracted are kept
+  pending_actions
+        approvals expire; request them again after a restore
+  used_nonces
+        only matter until the tokens they were issued with expire
+
+Flags:
+`
+
+const restoreUsage = `Usage: file-service restore --dir DIR [flags]
+
+Restores the backup in DIR into the configured environment. The backup is
+verified against its manifest, then every object it lists is checked in the
+bucket, and copied from --source-bucket if missing or damaged. The metadata
+is inserted only once every object is intact; rows already present are
+kept, so an interrupted restore can be rerun.
+
+Flags:
+`
+
+// runBackupCommand writes a backup and returns the process exit code. The
+// exit code is 1 if an object failed verification.
+func runBackupCommand(args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    flags.Usage = func() {
+        fmt.Fprint(stderr, backupUsage)
+        flags.PrintDefaults()
+    }
+    dir := flags.String("dir", "", "directory the backup is written to")
+    verify := flags.Bool("verify", false, "check every object's size and checksum while backing up")
+    batch := flags.Int("batch", 1000, "files read per query")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+    if *dir == "" {
+        fmt.Fprintln(stderr, "--dir is required")
+        return 2
+    }
+
+    backups, err := openBackupService(stderr)
+    if err != nil {
+        return 1
+    }
+    result, err := backups.Backup(context.Background(), service.BackupOptions{
+        Dir:       *dir,
+        Verify:    *verify,
+        BatchSize: *batch,
+    })
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+
+    for _, table := range result.Manifest.Tables {
+        fmt.Fprintf(stdout, "%s: %d rows\n", table.Name, table.Rows)
+    }
+    fmt.Fprintf(stdout, "objects: %d\n", result.Manifest.Objects.Rows)
+    if *verify {
+        printBackupFailures(stdout, result.Failed)
+        fmt.Fprintf(stdout, "verified %d objects, %d unchecked\n", result.Verified, result.Unchecked)
+        if len(result.Failed) > 0 {
+            fmt.Fprintf(stdout, "backup verification failed: %d problems\n", len(result.Failed))
+            return 1
+        }
+    }
+    fmt.Fprintf(stdout, "backup written to %s\n", *dir)
+    return 0
+}
+
+// runRestoreCommand restores a backup and returns the process exit code.
+// The exit code is 1 if an object is missing or damaged, in which case no
+// metadata is restored.
+func runRestoreCommand(args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    flags.Usage = func() {
+        fmt.Fprint(stderr, restoreUsage)
+        flags.PrintDefaults()
+    }
+    dir := flags.String("dir", "", "directory holding the backup")
+    source := flags.String("source-bucket", "", "bucket missing or damaged objects are copied from")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+    if *dir == "" {
+        fmt.Fprintln(stderr, "--dir is required")
+        return 2
+    }
+
+    backups, err := openBackupService(stderr)
+    if err != nil {
+        return 1
+    }
+    result, err := backups.Restore(context.Background(), service.RestoreOptions{
+        Dir:          *dir,
+        SourceBucket: *source,
+        Checked: func(object *models.BackupObject, copied bool, err error) {
+            if copied && err == nil {
+                fmt.Fprintf(stdout, "%s: copied from %s\n", object.Key, *source)
+            }
+        },
+    })
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+
+    printBackupFailures(stdout, result.Failed)
+    fmt.Fprintf(stdout, "verified %d objects, copied %d, %d unchecked\n", result.Verified, result.Copied, result.Unchecked)
+    if len(result.Failed) > 0 {
+        fmt.Fprintf(stdout, "restore failed: %d problems, no metadata restored\n", len(result.Failed))
+        return 1
+    }
+    for _, table := range result.Manifest.Tables {
+        fmt.Fprintf(stdout, "%s: restored %d rows, %d already present\n",
+            table.Name, result.Restored[table.Name], result.Existing[table.Name])
+    }
+    fmt.Fprintf(stdout, "restored backup of %s\n", result.Manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+    return 0
+}
+
+// openBackupService connects the backup service to the configured database
+// and bucket, reporting failures to stderr. The database stays open until
+// the process exits.
+func openBackupService(stderr io.Writer) (service.BackupService, error) {
+    cfg, err := config.ParseConfig()
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return nil, err
+    }
+    db, err := sql.Open("postgres", cfg.Database.DSN)
+    if err != nil {
+        fmt.Fprintln(stderr, "failed to open database: "+err.Error())
+        return nil, err
+    }
+    backupRepo, err := repository.NewBackupRepository(db)
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return nil, err
+    }
+    files, err := repository.NewFileRepository(db)
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return nil, err
+    }
+    s3Storage, err := storage.NewS3Storage(cfg, nil)
+    if err != nil {
+        fmt.Fprintln(stderr, "failed to initialize storage: "+err.Error())
+        return nil, err
+    }
+
+    backups, err := service.NewBackupService(backupRepo, files, s3Storage)
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return nil, err
+    }
+    return backups, nil
+}
+
+// printBackupFailures lists the objects that failed verification by key
+func printBackupFailures(stdout io.Writer, failed map[string]error) {
+    keys := make([]string, 0, len(failed))
+    for key := range failed {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        fmt.Fprintf(stdout, "%s: %v\n", key, failed[key])
+    }
+}
+
+// isBackupCommand reports whether the process was invoked as `file-service backup ...`
+func isBackupCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "backup"
+}
+
+// isRestoreCommand reports whether the process was invoked as `file-service restore ...`
+func isRestoreCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "restore"
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "time"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/storage"
+)
+
+// probeTimeout bounds each connectivity check run by config validate --probe
+const probeTimeout = 10 * time.Second
+
+const configUsage = `Usage: file-service config <command> [flags]
+
+Commands:
+  validate [--probe]      validate the configuration; --probe also connects to S3 and the database
+  print [--redacted]      print the effective configuration as JSON
+  schema                  print every configuration variable as JSON
+`
+
+// runConfigCommand runs a config subcommand and returns the process exit code
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+    if len(args) == 0 {
+        fmt.Fprint(stderr, configUsage)
+        return 2
+    }
+
+    switch args[0] {
+    case "validate":
+        return configValidate(args[1:], stdout, stderr)
+    case "print":
+        return configPrint(args[1:], stdout, stderr)
+    case "schema":
+        return writeConfigJSON(stdout, stderr, config.Schema())
+    default:
+        fmt.Fprintf(stderr, "unknown config command %q\n\n%s", args[0], configUsage)
+        return 2
+    }
+}
+
+// configValidate loads and validates the configuration, optionally probing dependencies
+func configValidate(args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    probe := flags.Bool("probe", false, "check S3 and database connectivity")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+
+    cfg, err := config.ParseConfig()
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+    if err := cfg.Validate(); err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+
+    if *probe {
+        if err := probeDatabase(cfg); err != nil {
+            fmt.Fprintln(stderr, "database probe failed: "+err.Error())
+            return 1
+        }
+        if _, err := storage.NewS3Storage(cfg, nil); err != nil {
+            fmt.Fprintln(stderr, "S3 probe failed: "+err.Error())
+            return 1
+        }
+    }
+
+    fmt.Fprintln(stdout, "configuration is valid")
+    return 0
+}
+
+// configPrint prints the effective configuration. Secrets are masked unless
+// --redacted=false is given.
+func configPrint(args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    redacted := flags.Bool("redacted", true, "mask sensitive values")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+
+    cfg, err := config.ParseConfig()
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+
+    if *redacted {
+        return writeConfigJSON(stdout, stderr, cfg.Redacted())
+    }
+    return writeConfigJSON(stdout, stderr, cfg)
+}
+
+// probeDatabase opens a connection to the metadata database and pings it
+func probeDatabase(cfg *config.Config) error {
+    db, err := sql.Open("postgres", cfg.Database.DSN)
+    if err != nil {
+        return err
+    }
+    defer db.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+    defer cancel()
+    return db.PingContext(ctx)
+}
+
+// writeConfigJSON writes v as indented JSON
+func writeConfigJSON(stdout, stderr io.Writer, v interface{}) int {
+    encoder := json.NewEncoder(stdout)
+    encoder.SetIndent("", "  ")
+    if err := encoder.Encode(v); err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+    return 0
+}
+
+// isConfigCommand reports whether the process was invoked as `file-service config ...`
+func isConfigCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "config"
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+const importUsage = `Usage: file-service import --prefix PREFIX [flags]
+
+Creates a file for every object under PREFIX in the bucket, so existing data
+can be adopted. Each object's content is copied to the service's key layout
+and its size, content type and SHA-256 are recorded. Objects already stored
+by the service, or whose name is taken in their folder, are skipped, so an
+interrupted import can be rerun.
+
+Flags:
+`
+
+// runImportCommand imports the objects under a bucket prefix as files and
+// returns the process exit code. Every failure is reported; the exit code
+// is 1 if there was any.
+func runImportCommand(args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("import", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    flags.Usage = func() {
+        fmt.Fprint(stderr, importUsage)
+        flags.PrintDefaults()
+    }
+    prefix := flags.String("prefix", "", "key prefix of the objects to import")
+    folder := flags.String("folder", "", "folder the imported files are placed under")
+    owner := flags.String("owner", "", "ID of the user charged for the imported files")
+    move := flags.Bool("move", false, "delete each original once its file is created")
+    dryRun := flags.Bool("dry-run", false, "list the files that would be created without creating them")
+    batch := flags.Int("batch", 100, "objects listed per request")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+    if *prefix == "" {
+        fmt.Fprintln(stderr, "--prefix is required")
+        return 2
+    }
+
+    cfg, err := config.ParseConfig()
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+    db, err := sql.Open("postgres", cfg.Database.DSN)
+    if err != nil {
+        fmt.Fprintln(stderr, "failed to open database: "+err.Error())
+        return 1
+    }
+    defer db.Close()
+    files, err := repository.NewFileRepository(db)
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+    s3Storage, err := storage.NewS3Storage(cfg, nil)
+    if err != nil {
+        fmt.Fprintln(stderr, "failed to initialize storage: "+err.Error())
+        return 1
+    }
+
+    importer, err := service.NewImporter(files, s3Storage, service.ImportOptions{
+        Prefix:    *prefix,
+        Folder:    *folder,
+        OwnerID:   *owner,
+        Move:      *move,
+        DryRun:    *dryRun,
+        BatchSize: *batch,
+        Imported: func(key string, file *models.File) {
+            fmt.Fprintf(stdout, "%s: %s %s (%s, %d bytes)\n", key, file.ID, displayPath(file), file.ContentType, file.Size)
+        },
+    })
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 2
+    }
+
+    result, err := importer.Import(context.Background())
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+
+    keys := make([]string, 0, len(result.Failed))
+    for key := range result.Failed {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        fmt.Fprintf(stdout, "%s: %v\n", key, result.Failed[key])
+    }
+
+    verb := "imported"
+    if *dryRun {
+        verb = "would import"
+    }
+    fmt.Fprintf(stdout, "scanned %d objects, %s %d, skipped %d\n", result.Scanned, verb, result.Imported, result.Skipped)
+    if len(result.Failed) > 0 {
+        fmt.Fprintf(stdout, "import failed: %d problems\n", len(result.Failed))
+        return 1
+    }
+    return 0
+}
+
+// displayPath returns the file's folder and name
+func displayPath(file *models.File) string {
+    if file.Folder == "" {
+        return file.FileName
+    }
+    return file.Folder + "/" + file.FileName
+}
+
+// isImportCommand reports whether the process was invoked as `file-service import ...`
+func isImportCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "import"
+}
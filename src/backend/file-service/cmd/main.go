@@ -5,58 +5,55 @@ package main
 import (
     "context"
     "fmt"
-    "net/http"
     "os"
     "os/signal"
     "syscall"
-    "time"
 
-    "github.com/prometheus/client_golang/prometheus" // v1.15.0
-    "github.com/prometheus/client_golang/prometheus/promhttp" // v1.15.0
-    "go.uber.org/zap" // v1.24.0
-    "golang.org/x/crypto/acme/autocert" // latest
+    _ "github.com/lib/pq" // v1.10.9
 
     "src/backend/file-service/internal/config"
-    "src/backend/file-service/internal/handlers"
-    "src/backend/file-service/internal/service"
-    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/internal/server"
+    "src/backend/file-service/pkg/buildinfo"
+    "src/backend/file-service/pkg/errtrack"
     "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/profiling"
 )
 
-const (
-    shutdownTimeout    = 30 * time.Second
-    healthCheckPath    = "/health"
-    metricsPath       = "/metrics"
-    maxHeaderBytes    = 1 << 20 // 1MB
-    readHeaderTimeout = 5 * time.Second
-)
-
-// Prometheus metrics
-var (
-    requestDuration = prometheus.NewHistogramVec(
-        prometheus.HistogramOpts{
-            Name: "http_request_duration_seconds",
-            Help: "Duration of HTTP requests in seconds",
-            Buckets: prometheus.DefBuckets,
-        },
-        []string{"handler", "method", "status"},
-    )
-    
-    activeRequests = prometheus.NewGauge(
-        prometheus.GaugeOpts{
-            Name: "http_requests_active",
-            Help: "Number of active HTTP requests",
-        },
-    )
-)
+// recentLogEntries bounds the warnings and errors kept for support bundles
+const recentLogEntries = 500
 
 func main() {
-    // Initialize structured logging
+    // Configuration tooling: file-service config validate|print|schema
+    if isConfigCommand() {
+        os.Exit(runConfigCommand(os.Args[2:], os.Stdout, os.Stderr))
+    }
+    if isAuditCommand() {
+        os.Exit(runAuditCommand(os.Args[2:], os.Stdout, os.Stderr))
+    }
+    if isStorageKeysCommand() {
+        os.Exit(runStorageKeysCommand(os.Args[2:], os.Stdout, os.Stderr))
+    }
+    if isImportCommand() {
+        os.Exit(runImportCommand(os.Args[2:], os.Stdout, os.Stderr))
+    }
+    if isBackupCommand() {
+        os.Exit(runBackupCommand(os.Args[2:], os.Stdout, os.Stderr))
+    }
+    if isRestoreCommand() {
+        os.Exit(runRestoreCommand(os.Args[2:], os.Stdout, os.Stderr))
+    }
+    if isSupportBundleCommand() {
+        os.Exit(runSupportBundleCommand(os.Args[2:], os.Stdout, os.Stderr))
+    }
+
+    // Initialize structured logging, keeping recent warnings and errors
+    recorder := logger.NewRecorder(recentLogEntries)
     log, err := logger.InitLogger(&logger.LogConfig{
         Level:         "info",
         Development:   false,
         EnableConsole: true,
         Encoding:      "json",
+        Recorder:      recorder,
     })
     if err != nil {
         fmt.Printf("Failed to initialize logger: %v\n", err)
@@ -68,141 +65,111 @@ func main() {
     cfg, err := config.LoadConfig()
     if err != nil {
         log.Fatal("Failed to load configuration",
-            zap.Error(err))
+            logger.Error(err))
     }
-
-    // Initialize metrics registry
-    registry := prometheus.NewRegistry()
-    registry.MustRegister(
-        requestDuration,
-        activeRequests,
-        prometheus.NewGoCollector(),
-        prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
-    )
-
-    // Initialize storage
-    s3Storage, err := storage.NewS3Storage(cfg)
-    if err != nil {
-        log.Fatal("Failed to initialize storage",
-            zap.Error(err))
-    }
-
-    // Initialize file service
-    fileService, err := service.NewFileService(s3Storage, service.WorkerPoolConfig{
-        MaxWorkers:  10,
-        QueueSize:   100,
-        BufferSize:  32 * 1024,
-    })
-    if err != nil {
-        log.Fatal("Failed to initialize file service",
-            zap.Error(err))
+    logConfigChanges(log, cfg)
+
+    // Report errors and panics to the error tracker
+    if cfg.Errors.Enabled {
+        reporter, err := errtrack.NewSentryReporter(errtrack.SentryConfig{
+            DSN:         cfg.Errors.DSN,
+            Environment: cfg.Errors.Environment,
+            Release:     buildinfo.Version,
+            SampleRate:  cfg.Errors.SampleRate,
+            ScrubPII:    cfg.Errors.ScrubPII,
+        })
+        if err != nil {
+            log.Fatal("Failed to initialize error tracking",
+                logger.Error(err))
+        }
+        errtrack.SetReporter(reporter)
+        defer reporter.Flush(cfg.Server.ShutdownTimeout)
     }
 
-    // Initialize HTTP handlers
-    fileHandler := handlers.NewFileHandler(fileService, registry)
-
-    // Configure and start HTTP server
-    server := setupSecureServer(cfg, fileHandler, registry)
-
-    // Start server in a goroutine
-    go func() {
-        log.Info("Starting server",
-            zap.String("address", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)))
-        
-        var err error
-        if cfg.Server.TLSEnabled {
-            // Configure automatic TLS certificate management
-            certManager := autocert.Manager{
-                Prompt:     autocert.AcceptTOS,
-                Cache:      autocert.DirCache("certs"),
-                HostPolicy: autocert.HostWhitelist(cfg.Server.Host),
-            }
-            server.TLSConfig = certManager.TLSConfig()
-            err = server.ListenAndServeTLS("", "")
-        } else {
-            err = server.ListenAndServe()
+    // Start continuous profiling
+    var profiler *profiling.Profiler
+    if cfg.Profiling.Enabled {
+        profiler, err = profiling.New(profiling.Config{
+            Backend:        cfg.Profiling.Backend,
+            ServerAddress:  cfg.Profiling.ServerAddress,
+            AuthToken:      cfg.Profiling.AuthToken,
+            ListenAddress:  cfg.Profiling.ListenAddress,
+            ServiceName:    cfg.Metrics.ServiceName,
+            ServiceVersion: buildinfo.Version,
+        })
+        if err != nil {
+            log.Fatal("Failed to initialize profiler",
+                logger.Error(err))
         }
-
-        if err != nil && err != http.ErrServerClosed {
-            log.Fatal("Server failed",
-                zap.Error(err))
+        if err := profiler.Start(); err != nil {
+            log.Fatal("Failed to start profiler",
+                logger.Error(err))
         }
-    }()
-
-    // Wait for interrupt signal
-    quit := make(chan os.Signal, 1)
-    signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-    <-quit
-
-    log.Info("Shutting down server...")
+    }
 
-    // Create shutdown context with timeout
-    ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
-    defer cancel()
+    // Serve until interrupted, then shut down gracefully
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+    runErr := server.Run(ctx, cfg, server.WithLogRecorder(recorder))
 
-    // Attempt graceful shutdown
-    if err := server.Shutdown(ctx); err != nil {
-        log.Error("Server forced to shutdown",
-            zap.Error(err))
+    if profiler != nil {
+        stopCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+        if err := profiler.Stop(stopCtx); err != nil {
+            log.Error("Failed to stop profiler",
+                logger.Error(err))
+        }
+        cancel()
     }
 
-    log.Info("Server stopped")
+    if runErr != nil {
+        log.Fatal("Server failed",
+            logger.Error(runErr))
+    }
 }
 
-// setupSecureServer configures the HTTP server with security features
-func setupSecureServer(cfg *config.Config, handler *handlers.FileHandler, registry *prometheus.Registry) *http.Server {
-    mux := http.NewServeMux()
-
-    // Add security middleware
-    secureMiddleware := func(next http.Handler) http.Handler {
-        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-            // Security headers
-            w.Header().Set("X-Content-Type-Options", "nosniff")
-            w.Header().Set("X-Frame-Options", "DENY")
-            w.Header().Set("X-XSS-Protection", "1; mode=block")
-            w.Header().Set("Content-Security-Policy", "default-src 'self'")
-            w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
-
-            // Request tracking
-            activeRequests.Inc()
-            defer activeRequests.Dec()
-
-            start := time.Now()
-            next.ServeHTTP(w, r)
-            
-            // Record request duration
-            duration := time.Since(start).Seconds()
-            requestDuration.WithLabelValues(
-                r.URL.Path,
-                r.Method,
-                fmt.Sprint(http.StatusOK),
-            ).Observe(duration)
-        })
+// logConfigChanges logs the redacted configuration settings that differ from
+// the compiled defaults and, when a snapshot file is configured, from the
+// previous start, then saves the current configuration as the new snapshot
+func logConfigChanges(log *logger.Logger, cfg *config.Config) {
+    current := cfg.Redacted()
+
+    defaults, err := config.Defaults()
+    if err == nil {
+        var changes []config.Change
+        changes, err = config.Diff(defaults, current)
+        if err == nil {
+            log.Info("Configuration differs from defaults",
+                logger.Int("count", len(changes)),
+                logger.Any("changes", changes))
+        }
+    }
+    if err != nil {
+        log.Warn("Failed to compare configuration with defaults",
+            logger.Error(err))
     }
 
-    // Register handlers with security middleware
-    mux.Handle("/upload", secureMiddleware(http.HandlerFunc(handler.UploadHandler)))
-    mux.Handle("/download", secureMiddleware(http.HandlerFunc(handler.DownloadHandler)))
-    mux.Handle("/delete", secureMiddleware(http.HandlerFunc(handler.DeleteHandler)))
-    
-    // Health check endpoint
-    mux.HandleFunc(healthCheckPath, func(w http.ResponseWriter, r *http.Request) {
-        w.WriteHeader(http.StatusOK)
-        w.Write([]byte("OK"))
-    })
-
-    // Metrics endpoint
-    mux.Handle(metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{
-        EnableOpenMetrics: true,
-    }))
-
-    return &http.Server{
-        Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-        Handler:           mux,
-        ReadTimeout:       cfg.Server.ReadTimeout,
-        WriteTimeout:      cfg.Server.WriteTimeout,
-        IdleTimeout:       cfg.Server.IdleTimeout,
-        ReadHeaderTimeout: readHeaderTimeout,
-        MaxHeaderBytes:    maxHeaderBytes,
-    }
-}
\ No newline at end of file
+    if cfg.SnapshotFile == "" {
+        return
+    }
+    previous, err := config.LoadSnapshot(cfg.SnapshotFile)
+    if err != nil {
+        log.Warn("Failed to load configuration snapshot",
+            logger.String("path", cfg.SnapshotFile),
+            logger.Error(err))
+    } else if previous != nil {
+        changes, err := config.Diff(previous, current)
+        if err != nil {
+            log.Warn("Failed to compare configuration with snapshot",
+                logger.Error(err))
+        } else if len(changes) > 0 {
+            log.Info("Configuration changed since last start",
+                logger.Int("count", len(changes)),
+                logger.Any("changes", changes))
+        }
+    }
+    if err := config.SaveSnapshot(cfg.SnapshotFile, current); err != nil {
+        log.Warn("Failed to save configuration snapshot",
+            logger.String("path", cfg.SnapshotFile),
+            logger.Error(err))
+    }
+}
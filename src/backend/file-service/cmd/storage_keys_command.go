@@ -0,0 +1,151 @@
+package main
+
+import (
+    "context"
+    "database/sql"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+const storageKeysUsage = `Usage: file-service storage-keys <command> [flags]
+
+Commands:
+  migrate [--dry-run] [--batch N]   move content stored under legacy unsharded keys to the current key layout
+`
+
+// runStorageKeysCommand runs a storage-keys subcommand and returns the process exit code
+func runStorageKeysCommand(args []string, stdout, stderr io.Writer) int {
+    if len(args) == 0 {
+        fmt.Fprint(stderr, storageKeysUsage)
+        return 2
+    }
+
+    switch args[0] {
+    case "migrate":
+        return storageKeysMigrate(args[1:], stdout, stderr)
+    default:
+        fmt.Fprintf(stderr, "unknown storage-keys command %q\n\n%s", args[0], storageKeysUsage)
+        return 2
+    }
+}
+
+// storageKeysMigrate moves the content of every file stored under a legacy
+// key to its canonical key: the object is copied, the files and blobs
+// referencing the old key are pointed at the new one, and the old object is
+// deleted. Content shared by several files moves once. Every failure is
+// reported; the exit code is 1 if there was any, and rerunning the command
+// retries them.
+func storageKeysMigrate(args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("storage-keys migrate", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    dryRun := flags.Bool("dry-run", false, "list the keys that would move without moving them")
+    batch := flags.Int("batch", 100, "files read per query")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+    if *batch <= 0 {
+        fmt.Fprintln(stderr, "--batch must be positive")
+        return 2
+    }
+
+    cfg, err := config.ParseConfig()
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+    db, err := sql.Open("postgres", cfg.Database.DSN)
+    if err != nil {
+        fmt.Fprintln(stderr, "failed to open database: "+err.Error())
+        return 1
+    }
+    defer db.Close()
+    files, err := repository.NewFileRepository(db)
+    if err != nil {
+        fmt.Fprintln(stderr, err)
+        return 1
+    }
+    s3Storage, err := storage.NewS3Storage(cfg, nil)
+    if err != nil {
+        fmt.Fprintln(stderr, "failed to initialize storage: "+err.Error())
+        return 1
+    }
+
+    ctx := context.Background()
+    moved := make(map[string]bool)
+    problems := 0
+    afterID := ""
+    for {
+        page, err := files.ListLegacyStoragePaths(ctx, afterID, *batch)
+        if err != nil {
+            fmt.Fprintln(stderr, err)
+            return 1
+        }
+        if len(page) == 0 {
+            break
+        }
+        afterID = page[len(page)-1].ID
+
+        for _, file := range page {
+            from := file.StoragePath
+            to, legacy := storagekey.Default.Canonical(from)
+            if !legacy || moved[from] {
+                continue
+            }
+            if *dryRun {
+                fmt.Fprintf(stdout, "%s: %s -> %s\n", file.ID, from, to)
+                moved[from] = true
+                continue
+            }
+            if err := migrateStorageKey(ctx, files, s3Storage, s3Storage, file, to); err != nil {
+                fmt.Fprintf(stdout, "%s: %s: %v\n", file.ID, from, err)
+                problems++
+                continue
+            }
+            fmt.Fprintf(stdout, "%s: moved %s -> %s\n", file.ID, from, to)
+            moved[from] = true
+        }
+    }
+
+    if *dryRun {
+        fmt.Fprintf(stdout, "%d legacy keys would move\n", len(moved))
+        return 0
+    }
+    fmt.Fprintf(stdout, "moved %d legacy keys\n", len(moved))
+    if problems > 0 {
+        fmt.Fprintf(stdout, "storage key migration failed: %d problems\n", problems)
+        return 1
+    }
+    return 0
+}
+
+// migrateStorageKey moves one file's content to key. The old object is only
+// deleted once the new key is recorded, so a failure leaves the content
+// readable under whichever key the file references.
+func migrateStorageKey(ctx context.Context, files repository.FileRepository, content storage.KeyMigrationStorage,
+    objects storage.ObjectStore, file *models.File, key string) error {
+    from := file.StoragePath
+    if err := content.MoveContent(ctx, file, key); err != nil {
+        return err
+    }
+    if err := files.UpdateStoragePath(ctx, from, file); err != nil {
+        // The copy is overwritten when the migration is rerun
+        return fmt.Errorf("content copied to %s but not recorded: %w", key, err)
+    }
+    if err := objects.DeleteObject(ctx, from); err != nil {
+        return fmt.Errorf("moved, but failed to delete the old object: %w", err)
+    }
+    return nil
+}
+
+// isStorageKeysCommand reports whether the process was invoked as `file-service storage-keys ...`
+func isStorageKeysCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "storage-keys"
+}
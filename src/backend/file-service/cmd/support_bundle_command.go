@@ -0,0 +1,151 @@
+package main
+
+import (
+    "archive/zip"
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/pkg/supportbundle"
+)
+
+// supportBundleTimeout bounds downloading a support bundle
+const supportBundleTimeout = 2 * time.Minute
+
+const supportBundleUsage = `Usage: file-service support-bundle [flags]
+
+Downloads a support bundle from a running instance's operations server: a
+zip archive of its redacted configuration, recent warnings and errors, a
+metric snapshot, the health of its dependencies and a goroutine dump, to
+attach to support tickets. The token must carry the admin role; it is read
+from FILE_SERVICE_TOKEN unless --token is given.
+
+Flags:
+`
+
+// runSupportBundleCommand downloads a support bundle and returns the process
+// exit code. Sections the instance failed to collect are reported but do
+// not fail the command.
+func runSupportBundleCommand(args []string, stdout, stderr io.Writer) int {
+    flags := flag.NewFlagSet("support-bundle", flag.ContinueOnError)
+    flags.SetOutput(stderr)
+    flags.Usage = func() {
+        fmt.Fprint(stderr, supportBundleUsage)
+        flags.PrintDefaults()
+    }
+    url := flags.String("url", "", "support bundle endpoint; defaults to the configured operations port on localhost")
+    token := flags.String("token", os.Getenv("FILE_SERVICE_TOKEN"), "bearer token of an administrator")
+    output := flags.String("output", "", "file the bundle is written to; defaults to the name the instance gives it")
+    if err := flags.Parse(args); err != nil {
+        return 2
+    }
+    if *token == "" {
+        fmt.Fprintln(stderr, "--token or FILE_SERVICE_TOKEN is required")
+        return 2
+    }
+
+    endpoint := *url
+    if endpoint == "" {
+        cfg, err := config.ParseConfig()
+        if err != nil {
+            fmt.Fprintln(stderr, err)
+            return 1
+        }
+        endpoint = fmt.Sprintf("http://localhost:%d/admin/support-bundle", cfg.Server.InternalPort)
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), supportBundleTimeout)
+    defer cancel()
+    path, err := downloadSupportBundle(ctx, endpoint, *token, *output)
+    if err != nil {
+        fmt.Fprintln(stderr, "failed to download support bundle: "+err.Error())
+        return 1
+    }
+
+    manifest, err := readSupportBundleManifest(path)
+    if err != nil {
+        fmt.Fprintln(stderr, "support bundle is incomplete: "+err.Error())
+        return 1
+    }
+    for _, section := range manifest.Sections {
+        if section.Error != "" {
+            fmt.Fprintf(stdout, "%s: %s\n", section.Name, section.Error)
+        }
+    }
+    fmt.Fprintf(stdout, "support bundle written to %s\n", path)
+    return 0
+}
+
+// downloadSupportBundle saves the bundle served at endpoint to output, or
+// to the file name the instance gives it, and returns the path written
+func downloadSupportBundle(ctx context.Context, endpoint, token, output string) (string, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+    if err != nil {
+        return "", err
+    }
+    req.Header.Set("Authorization", "Bearer "+token)
+
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+        return "", fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+    }
+
+    if output == "" {
+        output = "file-service-support.zip"
+        if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+            output = filepath.Base(params["filename"])
+        }
+    }
+
+    file, err := os.Create(output)
+    if err != nil {
+        return "", err
+    }
+    if _, err := io.Copy(file, resp.Body); err != nil {
+        file.Close()
+        os.Remove(output)
+        return "", err
+    }
+    return output, file.Close()
+}
+
+// readSupportBundleManifest reads the manifest of the bundle at path, which
+// the instance writes last, so its absence means the bundle was cut short
+func readSupportBundleManifest(path string) (*supportbundle.Manifest, error) {
+    archive, err := zip.OpenReader(path)
+    if err != nil {
+        return nil, err
+    }
+    defer archive.Close()
+
+    entry, err := archive.Open(supportbundle.ManifestName)
+    if err != nil {
+        return nil, err
+    }
+    defer entry.Close()
+
+    var manifest supportbundle.Manifest
+    if err := json.NewDecoder(entry).Decode(&manifest); err != nil {
+        return nil, err
+    }
+    return &manifest, nil
+}
+
+// isSupportBundleCommand reports whether the process was invoked as `file-service support-bundle ...`
+func isSupportBundleCommand() bool {
+    return len(os.Args) > 1 && os.Args[1] == "support-bundle"
+}
@@ -5,12 +5,18 @@ package config
 import (
 	"crypto/tls"
 	"errors"
+	"net/url"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/caarlos0/env/v6" // v6.10.0
+	"github.com/caarlos0/env/v6"              // v6.10.0
+	"github.com/spiffe/go-spiffe/v2/spiffeid" // v2.1.6
+	"src/backend/file-service/pkg/auditlog"
 	"src/backend/file-service/pkg/logger"
+	"src/backend/file-service/pkg/storagekey"
+	"src/backend/file-service/pkg/validator"
 )
 
 const (
@@ -25,10 +31,44 @@ var (
 
 // Config represents the complete service configuration with enhanced security
 type Config struct {
-	S3      S3Config         `env:"S3_"`
-	Server  ServerConfig     `env:"SERVER_"`
-	Logger  logger.LogConfig `env:"LOG_"`
-	Metrics MetricsConfig    `env:"METRICS_"`
+	Env        string              `env:"ENV" envDefault:"default"`
+	S3         S3Config            `env:"S3_"`
+	Server     ServerConfig        `env:"SERVER_"`
+	Database   DatabaseConfig      `env:"DB_"`
+	Upload     UploadConfig        `env:"UPLOAD_"`
+	Encryption EncryptionConfig    `env:"ENCRYPTION_"`
+	Download   DownloadConfig      `env:"DOWNLOAD_"`
+	Validation ValidationConfig    `env:"VALIDATION_"`
+	JWT        JWTConfig           `env:"JWT_"`
+	Quota      QuotaConfig         `env:"QUOTA_"`
+	Lock       LockConfig          `env:"LOCK_"`
+	Notify     NotifyConfig        `env:"NOTIFY_"`
+	Request    FileRequestConfig   `env:"FILE_REQUEST_"`
+	Cost       CostConfig          `env:"COST_"`
+	Logger     logger.LogConfig    `env:"LOG_"`
+	Metrics    MetricsConfig       `env:"METRICS_"`
+	Profiling  ProfilingConfig     `env:"PROFILING_"`
+	Errors     ErrorTrackingConfig `env:"ERROR_TRACKING_"`
+	Discovery  DiscoveryConfig     `env:"DISCOVERY_"`
+	Jobs       JobsConfig          `env:"JOBS_"`
+	Audit      AuditConfig         `env:"AUDIT_"`
+	SPIFFE     SPIFFEConfig        `env:"SPIFFE_"`
+	Authz      AuthzConfig         `env:"AUTHZ_"`
+	RateLimit  RateLimitConfig     `env:"RATE_LIMIT_"`
+	API        APIConfig           `env:"API_"`
+
+	AccessReview AccessReviewConfig `env:"ACCESS_REVIEW_"`
+	CSRF         CSRFConfig         `env:"CSRF_"`
+	Replication  ReplicationConfig  `env:"REPLICATION_"`
+	Archive      ArchiveConfig      `env:"ARCHIVE_"`
+	Tiering      TieringConfig      `env:"TIERING_"`
+	Reconcile    ReconcileConfig    `env:"RECONCILE_"`
+	Scrub        ScrubConfig        `env:"SCRUB_"`
+	Tenant       TenantConfig       `env:"TENANT_"`
+
+	// SnapshotFile persists the redacted configuration between starts so the
+	// startup log shows what changed since the last run; empty disables it
+	SnapshotFile string `env:"CONFIG_SNAPSHOT_FILE"`
 }
 
 // S3Config holds AWS S3 storage configuration with security features
@@ -42,6 +82,87 @@ type S3Config struct {
 	UseSSL         bool   `env:"USE_SSL" envDefault:"true"`
 	ForcePathStyle bool   `env:"FORCE_PATH_STYLE" envDefault:"false"`
 	RetryMax       int    `env:"RETRY_MAX" envDefault:"3"`
+
+	// Bootstrap creates missing buckets on startup, for MinIO and other
+	// development environments, rather than failing the bucket check. New
+	// buckets are versioned, encrypted by default as SSEAlgorithm says and
+	// under a baseline bucket policy; existing buckets are left as they are.
+	Bootstrap bool `env:"BOOTSTRAP" envDefault:"false"`
+
+	// ShardBuckets spreads file content over several buckets by a hash of
+	// the file ID, to get around per-bucket request rate limits. Bucket
+	// keeps the objects that belong to no file, and may be one of the
+	// shards. Buckets may be appended, which moves the content of a share
+	// of files to the new bucket; existing content must be copied to the
+	// bucket it resolves to before the list changes.
+	ShardBuckets []string `env:"SHARD_BUCKETS" envSeparator:","`
+
+	// TenantIsolation stores the content uploaded for each tenant, taken
+	// from the tenant_id claim, under tenants/{tenantID}/ and refuses
+	// requests access to content scoped to another tenant. TenantBuckets
+	// gives tenants a dedicated bucket for their content instead of the
+	// shared ones. Content stored before isolation was enabled stays
+	// readable by every tenant unless TenantKeysRequired is set.
+	TenantIsolation    bool              `env:"TENANT_ISOLATION" envDefault:"false"`
+	TenantKeysRequired bool              `env:"TENANT_KEYS_REQUIRED" envDefault:"false"`
+	TenantBuckets      map[string]string `env:"TENANT_BUCKETS" envSeparator:"," envKeyValSeparator:"="`
+
+	// HealthWindow is the period of recent requests the storage health
+	// scoreboard judges the bucket by; the bucket is probed every
+	// HealthProbeInterval, each probe bounded by HealthProbeTimeout
+	HealthWindow        time.Duration `env:"HEALTH_WINDOW" envDefault:"5m"`
+	HealthProbeInterval time.Duration `env:"HEALTH_PROBE_INTERVAL" envDefault:"30s"`
+	HealthProbeTimeout  time.Duration `env:"HEALTH_PROBE_TIMEOUT" envDefault:"5s"`
+
+	// Uploads larger than UploadPartSize bytes are split into parts sent
+	// UploadConcurrency at a time; a failed part is attempted up to
+	// UploadPartRetryMax times before the whole upload is aborted
+	UploadPartSize     int64 `env:"UPLOAD_PART_SIZE" envDefault:"16777216"` // 16MB
+	UploadConcurrency  int   `env:"UPLOAD_CONCURRENCY" envDefault:"5"`
+	UploadPartRetryMax int   `env:"UPLOAD_PART_RETRY_MAX" envDefault:"5"`
+
+	// Downloads are read in ranged requests of DownloadPartSize bytes,
+	// DownloadConcurrency at a time; a concurrency of 1 reads each object
+	// with a single request
+	DownloadPartSize    int64 `env:"DOWNLOAD_PART_SIZE" envDefault:"16777216"` // 16MB
+	DownloadConcurrency int   `env:"DOWNLOAD_CONCURRENCY" envDefault:"5"`
+
+	// Objects over the 5GB a single CopyObject takes are copied in ranges
+	// of CopyPartSize bytes, UploadConcurrency at a time
+	CopyPartSize int64 `env:"COPY_PART_SIZE" envDefault:"536870912"` // 512MB
+
+	// SoftDeletePrefix is the top-level prefix the content of soft-deleted
+	// files is moved under, within its tenant's scope
+	SoftDeletePrefix string `env:"SOFT_DELETE_PREFIX" envDefault:"archive"`
+
+	// ObjectLambdaAccessPoint is the ARN of an S3 Object Lambda access point
+	// downloads are read through, so transformations run in AWS;
+	// ObjectLambdaTenants overrides it per tenant, "none" reading the bucket
+	ObjectLambdaAccessPoint string            `env:"OBJECT_LAMBDA_ACCESS_POINT"`
+	ObjectLambdaTenants     map[string]string `env:"OBJECT_LAMBDA_TENANTS" envSeparator:"," envKeyValSeparator:"="`
+
+	// SSEAlgorithm is the server-side encryption of stored objects: AES256
+	// with S3-managed keys, or aws:kms with the key SSEKMSKeyID (a key ID,
+	// ARN or alias), using S3 Bucket Keys unless SSEBucketKey is false
+	SSEAlgorithm string `env:"SSE_ALGORITHM" envDefault:"AES256"`
+	SSEKMSKeyID  string `env:"SSE_KMS_KEY_ID"`
+	SSEBucketKey bool   `env:"SSE_BUCKET_KEY" envDefault:"true"`
+
+	// AdditionalChecksums has S3 validate uploads against SHA-256 checksums
+	// (x-amz-checksum-sha256) the SDK computes as they are sent, and the
+	// checksum S3 records is verified against the service's own hash before
+	// a file is marked uploaded. Downloads read with a single request are
+	// validated against it as they are read.
+	AdditionalChecksums bool `env:"ADDITIONAL_CHECKSUMS" envDefault:"false"`
+
+	// ObjectLockMode enables compliance mode: file content is stored under
+	// an S3 Object Lock retention of this mode (COMPLIANCE or GOVERNANCE)
+	// for ObjectLockRetention, and under a legal hold if ObjectLockLegalHold
+	// is set. Retained files cannot be hard deleted. The bucket must have
+	// Object Lock enabled.
+	ObjectLockMode      string        `env:"OBJECT_LOCK_MODE"`
+	ObjectLockRetention time.Duration `env:"OBJECT_LOCK_RETENTION"`
+	ObjectLockLegalHold bool          `env:"OBJECT_LOCK_LEGAL_HOLD" envDefault:"false"`
 }
 
 // ServerConfig holds HTTP server configuration with TLS support
@@ -56,22 +177,475 @@ type ServerConfig struct {
 	TLSEnabled      bool         `env:"TLS_ENABLED" envDefault:"false"`
 	TLSCertFile     string       `env:"TLS_CERT_FILE"`
 	TLSKeyFile      string       `env:"TLS_KEY_FILE"`
+
+	// DrainDelay is how long the instance reports not ready before its
+	// listeners close, so load balancers stop routing to it first. The pod's
+	// terminationGracePeriodSeconds must exceed DrainDelay plus ShutdownTimeout.
+	DrainDelay time.Duration `env:"DRAIN_DELAY" envDefault:"5s"`
+
+	// RetryAfter is the Retry-After sent with retriable errors, so clients
+	// and mesh sidecars back off before retrying
+	RetryAfter time.Duration `env:"RETRY_AFTER" envDefault:"1s"`
+
+	// Internal listener for metrics, health, pprof and admin routes
+	InternalHost string `env:"INTERNAL_HOST" envDefault:"0.0.0.0"`
+	InternalPort int    `env:"INTERNAL_PORT" envDefault:"9090"`
+	PprofEnabled bool   `env:"PPROF_ENABLED" envDefault:"false"`
+}
+
+// DatabaseConfig holds PostgreSQL connection settings for metadata persistence
+type DatabaseConfig struct {
+	DSN             string        `env:"DSN,required,unset"`
+	MaxOpenConns    int           `env:"MAX_OPEN_CONNS" envDefault:"20"`
+	MaxIdleConns    int           `env:"MAX_IDLE_CONNS" envDefault:"5"`
+	ConnMaxLifetime time.Duration `env:"CONN_MAX_LIFETIME" envDefault:"30m"`
+}
+
+// UploadConfig holds settings for the chunked upload protocol, upload policy,
+// draft uploads, upload bandwidth sharing and deduplication
+type UploadConfig struct {
+	ChunkSize  int64         `env:"CHUNK_SIZE" envDefault:"8388608"` // 8MB
+	MaxChunks  int           `env:"MAX_CHUNKS" envDefault:"10000"`
+	SessionTTL time.Duration `env:"SESSION_TTL" envDefault:"24h"`
+	// AdaptiveChunks sizes the chunks of new sessions by the throughput each
+	// client achieved in its chunk uploads of the last ThroughputTTL, so a
+	// chunk takes about ChunkTargetDuration to send, between MinChunkSize
+	// and MaxChunkSize. Clients without recent uploads get ChunkSize.
+	AdaptiveChunks      bool          `env:"ADAPTIVE_CHUNKS" envDefault:"false"`
+	ChunkTargetDuration time.Duration `env:"CHUNK_TARGET_DURATION" envDefault:"10s"`
+	MinChunkSize        int64         `env:"MIN_CHUNK_SIZE" envDefault:"5242880"`   // 5MB
+	MaxChunkSize        int64         `env:"MAX_CHUNK_SIZE" envDefault:"134217728"` // 128MB
+	ThroughputTTL       time.Duration `env:"THROUGHPUT_TTL" envDefault:"1h"`
+	// SessionSweepInterval is how often expired sessions and multipart
+	// uploads abandoned in the bucket are cleaned up
+	SessionSweepInterval time.Duration `env:"SESSION_SWEEP_INTERVAL" envDefault:"15m"`
+	PolicyFile           string        `env:"POLICY_FILE"`
+	DraftTTL             time.Duration `env:"DRAFT_TTL" envDefault:"1h"`
+	DraftPurgeInterval   time.Duration `env:"DRAFT_PURGE_INTERVAL" envDefault:"5m"`
+	// BandwidthLimit is the aggregate upload bandwidth in bytes per second
+	// shared fairly between users; zero disables throttling
+	BandwidthLimit             int64         `env:"BANDWIDTH_LIMIT" envDefault:"0"`
+	BandwidthBurst             int           `env:"BANDWIDTH_BURST" envDefault:"65536"` // 64KB
+	BandwidthRebalanceInterval time.Duration `env:"BANDWIDTH_REBALANCE_INTERVAL" envDefault:"1s"`
+
+	// DedupEnabled shares the stored content of identical uploads through
+	// reference counted blobs, collected once unreferenced for the grace period
+	DedupEnabled      bool          `env:"DEDUP_ENABLED" envDefault:"false"`
+	BlobGCInterval    time.Duration `env:"BLOB_GC_INTERVAL" envDefault:"10m"`
+	BlobGCGracePeriod time.Duration `env:"BLOB_GC_GRACE_PERIOD" envDefault:"24h"`
+
+	// DirectEnabled lets clients upload straight to S3 with presigned URLs,
+	// each valid for DirectURLTTL; with DirectSingleUse an upload is
+	// confirmed once, and repeated confirmations are rejected
+	DirectEnabled   bool          `env:"DIRECT_ENABLED" envDefault:"false"`
+	DirectURLTTL    time.Duration `env:"DIRECT_URL_TTL" envDefault:"15m"`
+	DirectSingleUse bool          `env:"DIRECT_SINGLE_USE" envDefault:"false"`
+
+	// AsyncEnabled lets clients upload with ?async=true, which returns 202
+	// once the content is staged and processes it in the background.
+	// Uploads still pending after AsyncTimeout are failed by a sweep every
+	// AsyncSweepInterval.
+	AsyncEnabled       bool          `env:"ASYNC_ENABLED" envDefault:"false"`
+	AsyncTimeout       time.Duration `env:"ASYNC_TIMEOUT" envDefault:"1h"`
+	AsyncSweepInterval time.Duration `env:"ASYNC_SWEEP_INTERVAL" envDefault:"5m"`
+
+	// NameConflict is what an upload does when its folder already holds a
+	// file of the same name, unless the request chooses: "keep" stores it
+	// as another file, "rename" stores it under a numbered name,
+	// "overwrite" replaces the existing file's content and "reject"
+	// refuses it
+	NameConflict string `env:"NAME_CONFLICT" envDefault:"keep"`
+
+	// ExtractEnabled lets users expand uploaded zip and tar archives into
+	// files. An archive may hold at most ExtractMaxEntries entries, expand
+	// to ExtractMaxSize bytes and ExtractMaxRatio times its own size, and
+	// each zip entry at most ExtractMaxRatio times its compressed size.
+	ExtractEnabled    bool    `env:"EXTRACT_ENABLED" envDefault:"false"`
+	ExtractMaxEntries int     `env:"EXTRACT_MAX_ENTRIES" envDefault:"1000"`
+	ExtractMaxSize    int64   `env:"EXTRACT_MAX_SIZE" envDefault:"1073741824"` // 1GB
+	ExtractMaxRatio   float64 `env:"EXTRACT_MAX_RATIO" envDefault:"100"`
+}
+
+// EncryptionConfig holds client-side encryption and key escrow settings
+type EncryptionConfig struct {
+	ClientSideEnabled bool   `env:"CLIENT_SIDE_ENABLED" envDefault:"true"`
+	EscrowEnabled     bool   `env:"ESCROW_ENABLED" envDefault:"false"`
+	EscrowRequired    bool   `env:"ESCROW_REQUIRED" envDefault:"false"`
+	EscrowKMSKeyID    string `env:"ESCROW_KMS_KEY_ID"`
 }
 
-// MetricsConfig holds monitoring and metrics configuration
+// DownloadConfig holds browser-facing protections applied to downloads
+type DownloadConfig struct {
+	InlinePreviewEnabled bool     `env:"INLINE_PREVIEW_ENABLED" envDefault:"false"`
+	ForceOctetStream     bool     `env:"FORCE_OCTET_STREAM" envDefault:"true"`
+	RiskyContentTypes    []string `env:"RISKY_CONTENT_TYPES" envSeparator:"," envDefault:"image/svg+xml,text/html,application/xhtml+xml,text/xml,application/xml,application/javascript,text/javascript"`
+	PreviewCSP           string   `env:"PREVIEW_CSP" envDefault:"default-src 'none'; img-src 'self' data:; media-src 'self'; style-src 'unsafe-inline'; sandbox"`
+	PreviewMaxSize       int64    `env:"PREVIEW_MAX_SIZE" envDefault:"5242880"` // 5MB
+
+	// Capability URLs for embedding previews without an Authorization header
+	PreviewTokenSecret    string        `env:"PREVIEW_TOKEN_SECRET,unset"`
+	PreviewTokenTTL       time.Duration `env:"PREVIEW_TOKEN_TTL" envDefault:"5m"`
+	PreviewFrameAncestors string        `env:"PREVIEW_FRAME_ANCESTORS" envDefault:"'self'"`
+
+	// PreviewTokenSingleUse accepts each preview token once, recording used
+	// tokens until they expire and purging them every NoncePurgeInterval.
+	// Viewers that re-request content, such as PDF viewers fetching ranges,
+	// need a fresh URL for each request.
+	PreviewTokenSingleUse bool          `env:"PREVIEW_TOKEN_SINGLE_USE" envDefault:"false"`
+	NoncePurgeInterval    time.Duration `env:"NONCE_PURGE_INTERVAL" envDefault:"10m"`
+
+	// Per-tenant watermarking of downloaded PDFs and images
+	WatermarkPolicyFile string `env:"WATERMARK_POLICY_FILE"`
+	WatermarkMaxSize    int64  `env:"WATERMARK_MAX_SIZE" envDefault:"52428800"` // 50MB
+
+	// DerivedMaxSourceSize bounds the files thumbnails and other derived objects are generated from
+	DerivedMaxSourceSize int64 `env:"DERIVED_MAX_SOURCE_SIZE" envDefault:"20971520"` // 20MB
+
+	// Conversion of downloads requested with ?format=, through a Gotenberg
+	// sidecar for office documents, rsvg-convert for SVG images and
+	// pdftocairo for the first page of PDFs, which also previews them; each
+	// is disabled when unset
+	ConvertGotenbergURL   string        `env:"CONVERT_GOTENBERG_URL"`
+	ConvertRsvgPath       string        `env:"CONVERT_RSVG_PATH"`
+	ConvertPdftocairoPath string        `env:"CONVERT_PDFTOCAIRO_PATH"`
+	ConvertTimeout        time.Duration `env:"CONVERT_TIMEOUT" envDefault:"60s"`
+
+	// CachePolicyFile sets Cache-Control and Expires per endpoint and content type
+	CachePolicyFile string `env:"CACHE_POLICY_FILE"`
+
+	// Presigned URLs downloading straight from the bucket, each valid for PresignTTL
+	PresignEnabled bool          `env:"PRESIGN_ENABLED" envDefault:"false"`
+	PresignTTL     time.Duration `env:"PRESIGN_TTL" envDefault:"5m"`
+}
+
+// ValidationConfig holds upload validation overrides
+type ValidationConfig struct {
+	MasqueradeFlagOnly     bool     `env:"MASQUERADE_FLAG_ONLY" envDefault:"false"`
+	MasqueradeAllowedCodes []string `env:"MASQUERADE_ALLOWED_CODES" envSeparator:","`
+
+	// ContentChecks lists the checks run on uploaded content as it streams
+	// to storage: magic, signatures and archive; empty disables them
+	ContentChecks []string `env:"CONTENT_CHECKS" envSeparator:"," envDefault:"magic,signatures,archive"`
+	// ContentFailOpen accepts content a check rejects or cannot decide,
+	// only recording the verdict
+	ContentFailOpen bool `env:"CONTENT_FAIL_OPEN" envDefault:"false"`
+}
+
+// JWTConfig holds settings for validating caller tokens
+type JWTConfig struct {
+	SigningKey string `env:"SIGNING_KEY,required,unset"`
+}
+
+// LockConfig holds file checkout lock settings
+type LockConfig struct {
+	DefaultTTL time.Duration `env:"DEFAULT_TTL" envDefault:"15m"`
+	MaxTTL     time.Duration `env:"MAX_TTL" envDefault:"8h"`
+}
+
+// FileRequestConfig holds the lifetime of upload request links and the rate
+// of anonymous uploads through them
+type FileRequestConfig struct {
+	DefaultTTL time.Duration `env:"DEFAULT_TTL" envDefault:"168h"`
+	MaxTTL     time.Duration `env:"MAX_TTL" envDefault:"720h"`
+
+	// UploadRequests is the number of uploads each client address may send
+	// through request links per UploadWindow, counted per instance
+	UploadRequests int           `env:"UPLOAD_REQUESTS" envDefault:"30"`
+	UploadWindow   time.Duration `env:"UPLOAD_WINDOW" envDefault:"1m"`
+}
+
+// NotifyConfig holds user notification delivery settings. EmailProvider is
+// "smtp", "ses", or empty to disable email; webhooks are always available.
+type NotifyConfig struct {
+	EmailProvider       string        `env:"EMAIL_PROVIDER"`
+	EmailFrom           string        `env:"EMAIL_FROM"`
+	SMTPHost            string        `env:"SMTP_HOST"`
+	SMTPPort            int           `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername        string        `env:"SMTP_USERNAME"`
+	SMTPPassword        string        `env:"SMTP_PASSWORD,unset"`
+	SESRegion           string        `env:"SES_REGION" envDefault:"us-west-2"`
+	WebhookSecret       string        `env:"WEBHOOK_SECRET,unset"`
+	Timeout             time.Duration `env:"TIMEOUT" envDefault:"10s"`
+	DigestInterval      time.Duration `env:"DIGEST_INTERVAL" envDefault:"24h"`
+	DigestCheckInterval time.Duration `env:"DIGEST_CHECK_INTERVAL" envDefault:"15m"`
+}
+
+// QuotaConfig holds per-user storage quota settings. A zero limit disables quotas.
+type QuotaConfig struct {
+	Limit          int64         `env:"LIMIT" envDefault:"0"`
+	Thresholds     []int         `env:"THRESHOLDS" envSeparator:"," envDefault:"80,95,100"`
+	WebhookURL     string        `env:"WEBHOOK_URL"`
+	WebhookSecret  string        `env:"WEBHOOK_SECRET,unset"`
+	WebhookTimeout time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"5s"`
+}
+
+// CostConfig holds the S3 unit prices, in USD, used to estimate storage costs
+type CostConfig struct {
+	StorageGBMonth           float64 `env:"STORAGE_GB_MONTH" envDefault:"0.023"`
+	Tier1RequestsPerThousand float64 `env:"TIER1_REQUESTS_PER_THOUSAND" envDefault:"0.005"`
+	Tier2RequestsPerThousand float64 `env:"TIER2_REQUESTS_PER_THOUSAND" envDefault:"0.0004"`
+}
+
+// MetricsConfig holds monitoring and metrics configuration. The exporter is
+// either "prometheus" (scrape endpoint at Path) or "otlp" (push to OTLPEndpoint).
 type MetricsConfig struct {
-	Enabled     bool   `env:"ENABLED" envDefault:"true"`
-	Path        string `env:"PATH" envDefault:"/metrics"`
-	ServiceName string `env:"SERVICE_NAME" envDefault:"file-service"`
+	Enabled      bool              `env:"ENABLED" envDefault:"true"`
+	Path         string            `env:"PATH" envDefault:"/metrics"`
+	ServiceName  string            `env:"SERVICE_NAME" envDefault:"file-service"`
+	Exporter     string            `env:"EXPORTER" envDefault:"prometheus"`
+	OTLPEndpoint string            `env:"OTLP_ENDPOINT"`
+	OTLPHeaders  map[string]string `env:"OTLP_HEADERS,unset" envSeparator:"," envKeyValSeparator:"="`
+	OTLPInterval time.Duration     `env:"OTLP_INTERVAL" envDefault:"60s"`
+	OTLPTimeout  time.Duration     `env:"OTLP_TIMEOUT" envDefault:"10s"`
+
+	// SLOs: the share of uploads and downloads that must succeed within
+	// their latency targets
+	SLOObjective       float64       `env:"SLO_OBJECTIVE" envDefault:"0.999"`
+	SLOUploadLatency   time.Duration `env:"SLO_UPLOAD_LATENCY" envDefault:"10s"`
+	SLODownloadLatency time.Duration `env:"SLO_DOWNLOAD_LATENCY" envDefault:"2s"`
+
+	// Requests slower than SlowRequestThreshold, or reading or writing more
+	// than LargeTransferThreshold bytes, are logged and counted; zero
+	// disables either check
+	SlowRequestThreshold   time.Duration `env:"SLOW_REQUEST_THRESHOLD" envDefault:"30s"`
+	LargeTransferThreshold int64         `env:"LARGE_TRANSFER_THRESHOLD" envDefault:"1073741824"` // 1GB
+
+	// With TenantLabels, operation, download and upload counters are
+	// labelled by tenant. The TenantLabelLimit busiest tenants of the
+	// previous TenantLabelWindow get labels of their own and the rest are
+	// counted as "other", bounding the series per counter.
+	TenantLabels      bool          `env:"TENANT_LABELS" envDefault:"false"`
+	TenantLabelLimit  int           `env:"TENANT_LABEL_LIMIT" envDefault:"20"`
+	TenantLabelWindow time.Duration `env:"TENANT_LABEL_WINDOW" envDefault:"1h"`
+
+	// Every StorageStatsInterval the stored bytes and files are aggregated
+	// by storage state and class for the storage gauges; zero disables it
+	StorageStatsInterval time.Duration `env:"STORAGE_STATS_INTERVAL" envDefault:"5m"`
+}
+
+// ProfilingConfig holds continuous profiling settings. The backend is either
+// "pyroscope" (push to ServerAddress) or "parca" (pprof served on ListenAddress).
+type ProfilingConfig struct {
+	Enabled       bool   `env:"ENABLED" envDefault:"false"`
+	Backend       string `env:"BACKEND" envDefault:"pyroscope"`
+	ServerAddress string `env:"SERVER_ADDRESS"`
+	AuthToken     string `env:"AUTH_TOKEN,unset"`
+	ListenAddress string `env:"LISTEN_ADDRESS" envDefault:"127.0.0.1:6060"`
+}
+
+// DiscoveryConfig holds settings for self-registration with Consul or etcd,
+// for deployments without a platform service registry
+type DiscoveryConfig struct {
+	// Backend is "consul", "etcd", or empty to disable registration
+	Backend   string   `env:"BACKEND"`
+	Endpoints []string `env:"ENDPOINTS" envSeparator:","`
+	ACLToken  string   `env:"ACL_TOKEN,unset"`
+	Username  string   `env:"USERNAME"`
+	Password  string   `env:"PASSWORD,unset"`
+
+	ServiceName string   `env:"SERVICE_NAME" envDefault:"file-service"`
+	ServiceID   string   `env:"SERVICE_ID"`        // defaults to <hostname>-<port>
+	Address     string   `env:"ADVERTISE_ADDRESS"` // defaults to the hostname
+	Tags        []string `env:"TAGS" envSeparator:","`
+
+	CheckInterval   time.Duration `env:"CHECK_INTERVAL" envDefault:"10s"`
+	CheckTimeout    time.Duration `env:"CHECK_TIMEOUT" envDefault:"5s"`
+	DeregisterAfter time.Duration `env:"DEREGISTER_AFTER" envDefault:"1m"`
+	KeyPrefix       string        `env:"KEY_PREFIX" envDefault:"/services"`
+}
+
+// JobsConfig holds settings for coordinating scheduled background jobs
+// across replicas
+type JobsConfig struct {
+	// LockBackend is "postgres" to take advisory locks shared by all
+	// replicas, or "local" for single-instance deployments
+	LockBackend   string        `env:"LOCK_BACKEND" envDefault:"postgres"`
+	LockKeepAlive time.Duration `env:"LOCK_KEEP_ALIVE" envDefault:"10s"`
+}
+
+// AuditConfig holds settings for exporting audit events to S3 as signed,
+// hash-chained batches under Object Lock
+type AuditConfig struct {
+	ExportEnabled  bool          `env:"EXPORT_ENABLED" envDefault:"false"`
+	ExportInterval time.Duration `env:"EXPORT_INTERVAL" envDefault:"1h"`
+	Prefix         string        `env:"PREFIX" envDefault:"audit/"`
+	BatchSize      int           `env:"BATCH_SIZE" envDefault:"1000"`
+	// SigningKey is a base64 Ed25519 seed or private key; VerifyKey is the
+	// base64 public key auditors verify with, derived from SigningKey if unset
+	SigningKey string        `env:"SIGNING_KEY,unset"`
+	VerifyKey  string        `env:"VERIFY_KEY"`
+	LockMode   string        `env:"LOCK_MODE" envDefault:"COMPLIANCE"`
+	Retention  time.Duration `env:"RETENTION" envDefault:"61320h"`
+}
+
+// SPIFFEConfig holds settings for authenticating mesh workloads by the
+// X.509 SVID they present as mTLS client certificate
+type SPIFFEConfig struct {
+	Enabled bool `env:"ENABLED" envDefault:"false"`
+	// WorkloadSocket is the Workload API address trust bundles are read
+	// from; empty uses SPIFFE_ENDPOINT_SOCKET
+	WorkloadSocket string        `env:"WORKLOAD_SOCKET"`
+	StartTimeout   time.Duration `env:"START_TIMEOUT" envDefault:"30s"`
+	// Roles maps accepted SPIFFE IDs to their roles, separated by "|", e.g.
+	// spiffe://example.org/ns/billing/sa/api=reader|writer
+	Roles map[string]string `env:"ROLES" envSeparator:"," envKeyValSeparator:"="`
+}
+
+// AuthzConfig holds settings for delegating file access decisions to the
+// embedded policy engine
+type AuthzConfig struct {
+	// Mode is "roles" for the built-in role checks or "opa" to evaluate Rego
+	// policies for downloads, deletes and shares
+	Mode string `env:"MODE" envDefault:"roles"`
+	// PolicyDir holds the Rego modules to load; empty uses the bundled policy
+	PolicyDir string `env:"POLICY_DIR"`
+}
+
+// RateLimitConfig holds per-caller API request limits, counted per instance
+type RateLimitConfig struct {
+	Enabled  bool          `env:"ENABLED" envDefault:"true"`
+	Requests int           `env:"REQUESTS" envDefault:"600"`
+	Window   time.Duration `env:"WINDOW" envDefault:"1m"`
+}
+
+// CSRFConfig holds the cross-site request forgery defense for browsers
+// authenticated by a session cookie, typically set by a gateway in front of
+// the service. State-changing requests carrying SessionCookie must come from
+// the service's own or a trusted origin and echo the Cookie token in Header.
+type CSRFConfig struct {
+	Enabled        bool     `env:"ENABLED" envDefault:"false"`
+	SessionCookie  string   `env:"SESSION_COOKIE" envDefault:"session"`
+	Cookie         string   `env:"COOKIE" envDefault:"csrf_token"`
+	Header         string   `env:"HEADER" envDefault:"X-CSRF-Token"`
+	TokenSecret    string   `env:"TOKEN_SECRET,unset"`
+	TrustedOrigins []string `env:"TRUSTED_ORIGINS" envSeparator:","`
+}
+
+// AccessReviewConfig holds settings for the periodic review of shares and
+// file request links that went unused for StaleAfter
+type AccessReviewConfig struct {
+	Enabled    bool          `env:"ENABLED" envDefault:"false"`
+	Interval   time.Duration `env:"INTERVAL" envDefault:"24h"`
+	StaleAfter time.Duration `env:"STALE_AFTER" envDefault:"720h"`
+	AutoRevoke bool          `env:"AUTO_REVOKE" envDefault:"false"`
+}
+
+// ReplicationConfig holds settings for copying uploaded files to a secondary
+// bucket, typically in another region. Every Interval up to BatchSize files
+// are copied; a file failing MaxAttempts times is marked failed.
+type ReplicationConfig struct {
+	Enabled     bool          `env:"ENABLED" envDefault:"false"`
+	Bucket      string        `env:"BUCKET"`
+	Region      string        `env:"REGION"`
+	Interval    time.Duration `env:"INTERVAL" envDefault:"1m"`
+	BatchSize   int           `env:"BATCH_SIZE" envDefault:"100"`
+	MaxAttempts int           `env:"MAX_ATTEMPTS" envDefault:"5"`
+}
+
+// ArchiveConfig holds settings for moving idle files to archival storage
+// classes. Every Interval up to BatchSize files not accessed for
+// GlacierAfter are moved to GLACIER, or to DEEP_ARCHIVE once idle for
+// DeepArchiveAfter; zero disables a class. Restored copies stay readable for
+// RestoreDays and are retrieved at RestoreTier: Expedited, Standard or Bulk.
+type ArchiveConfig struct {
+	Enabled          bool          `env:"ENABLED" envDefault:"false"`
+	GlacierAfter     time.Duration `env:"GLACIER_AFTER" envDefault:"2160h"`
+	DeepArchiveAfter time.Duration `env:"DEEP_ARCHIVE_AFTER"`
+	Interval         time.Duration `env:"INTERVAL" envDefault:"1h"`
+	BatchSize        int           `env:"BATCH_SIZE" envDefault:"100"`
+	RestoreDays      int           `env:"RESTORE_DAYS" envDefault:"7"`
+	RestoreTier      string        `env:"RESTORE_TIER" envDefault:"Standard"`
+}
+
+// TieringConfig holds settings for moving cold files to an infrequent access
+// storage class. Every Interval up to BatchSize files not accessed for
+// ColdAfter are moved to ColdStorageClass: STANDARD_IA or ONEZONE_IA. With
+// PromoteOnAccess, cold files are moved back to STANDARD when downloaded.
+type TieringConfig struct {
+	Enabled          bool          `env:"ENABLED" envDefault:"false"`
+	ColdAfter        time.Duration `env:"COLD_AFTER" envDefault:"720h"`
+	ColdStorageClass string        `env:"COLD_STORAGE_CLASS" envDefault:"STANDARD_IA"`
+	PromoteOnAccess  bool          `env:"PROMOTE_ON_ACCESS" envDefault:"true"`
+	Interval         time.Duration `env:"INTERVAL" envDefault:"1h"`
+	BatchSize        int           `env:"BATCH_SIZE" envDefault:"100"`
+}
+
+// ReconcileConfig holds settings for reconciling the bucket with the files
+// table. Every Interval objects no file references and uploaded files whose
+// object is missing are reported and, unless Mode is "dry-run", quarantined
+// or cleaned, up to MaxActions per run. Objects and files changed within
+// MinAge are skipped, as they may belong to uploads in flight.
+type ReconcileConfig struct {
+	Enabled    bool          `env:"ENABLED" envDefault:"false"`
+	Mode       string        `env:"MODE" envDefault:"dry-run"`
+	Interval   time.Duration `env:"INTERVAL" envDefault:"24h"`
+	MinAge     time.Duration `env:"MIN_AGE" envDefault:"24h"`
+	BatchSize  int           `env:"BATCH_SIZE" envDefault:"1000"`
+	MaxActions int           `env:"MAX_ACTIONS" envDefault:"1000"`
+}
+
+// ScrubConfig holds settings for the integrity scrubber. Every Interval
+// SampleSize uploaded files picked at random are verified against their
+// checksum, by Method: "download" reads the content back, "s3-checksum"
+// compares the checksum S3 recorded and reads the content back only when
+// there is none. Content larger than MaxDownloadSize is never read back.
+type ScrubConfig struct {
+	Enabled         bool          `env:"ENABLED" envDefault:"false"`
+	Method          string        `env:"METHOD" envDefault:"download"`
+	Interval        time.Duration `env:"INTERVAL" envDefault:"1h"`
+	SampleSize      int           `env:"SAMPLE_SIZE" envDefault:"20"`
+	MaxDownloadSize int64         `env:"MAX_DOWNLOAD_SIZE" envDefault:"1073741824"` // 1GB
+
+	// VerifyDownloads checks whole-file downloads against their checksum
+	// as they are sent, independently of the sampled scrub. Content that
+	// does not match is cut short, its file frozen as corrupted, compared
+	// with its replica and repaired from it if the replica is intact, and
+	// the incident audited.
+	VerifyDownloads bool `env:"VERIFY_DOWNLOADS" envDefault:"false"`
+}
+
+// TenantConfig holds settings for the per-tenant overrides stored in the
+// database. Each replica caches a tenant's settings for SettingsCacheTTL.
+type TenantConfig struct {
+	SettingsEnabled  bool          `env:"SETTINGS_ENABLED" envDefault:"false"`
+	SettingsCacheTTL time.Duration `env:"SETTINGS_CACHE_TTL" envDefault:"1m"`
+
+	// RegulatedTenants must have hard deletes approved by two admins other
+	// than the requester; requests not approved within DeleteApprovalTTL
+	// expire, checked every DeleteApprovalSweepInterval
+	RegulatedTenants            []string      `env:"REGULATED" envSeparator:","`
+	DeleteApprovalTTL           time.Duration `env:"DELETE_APPROVAL_TTL" envDefault:"72h"`
+	DeleteApprovalSweepInterval time.Duration `env:"DELETE_APPROVAL_SWEEP_INTERVAL" envDefault:"15m"`
+}
+
+// APIConfig holds the retirement schedule of the unversioned legacy routes,
+// announced in Deprecation and Sunset headers as RFC 3339 timestamps
+type APIConfig struct {
+	LegacyDeprecatedAt time.Time `env:"LEGACY_DEPRECATED_AT"`
+	LegacySunset       time.Time `env:"LEGACY_SUNSET"`
+}
+
+// ErrorTrackingConfig holds settings for reporting errors and panics to Sentry
+type ErrorTrackingConfig struct {
+	Enabled     bool    `env:"ENABLED" envDefault:"false"`
+	DSN         string  `env:"DSN,unset"`
+	Environment string  `env:"ENVIRONMENT" envDefault:"production"`
+	SampleRate  float64 `env:"SAMPLE_RATE" envDefault:"1.0"`
+	ScrubPII    bool    `env:"SCRUB_PII" envDefault:"true"`
 }
 
 // LoadConfig loads configuration from environment variables with enhanced validation
 func LoadConfig() (*Config, error) {
 	cfg := &Config{}
 
-	// Parse environment variables
+	// Parse environment variables over the selected profile's defaults
+	vars, err := environment()
+	if err != nil {
+		return nil, err
+	}
 	opts := env.Options{
-		Prefix: "APP_",
+		Prefix:      envPrefix,
+		Environment: vars,
 		OnSet: func(tag string, value interface{}, isDefault bool) {
 			// Log configuration changes but mask sensitive values
 			if isSensitive(tag) {
@@ -116,6 +690,11 @@ func GetConfig() *Config {
 
 // validate performs comprehensive configuration validation
 func (cfg *Config) validate() error {
+	// Reject unsafe combinations for the selected profile
+	if err := cfg.validateProfile(); err != nil {
+		return errors.New("profile configuration error: " + err.Error())
+	}
+
 	// Validate S3 configuration
 	if err := cfg.validateS3Config(); err != nil {
 		return errors.New("S3 configuration error: " + err.Error())
@@ -126,6 +705,162 @@ func (cfg *Config) validate() error {
 		return errors.New("server configuration error: " + err.Error())
 	}
 
+	// Validate database configuration
+	if err := cfg.validateDatabaseConfig(); err != nil {
+		return errors.New("database configuration error: " + err.Error())
+	}
+
+	// Validate upload configuration
+	if err := cfg.validateUploadConfig(); err != nil {
+		return errors.New("upload configuration error: " + err.Error())
+	}
+
+	// Validate encryption configuration
+	if err := cfg.validateEncryptionConfig(); err != nil {
+		return errors.New("encryption configuration error: " + err.Error())
+	}
+
+	// Validate download configuration
+	if err := cfg.validateDownloadConfig(); err != nil {
+		return errors.New("download configuration error: " + err.Error())
+	}
+
+	// Validate upload validation overrides
+	if err := cfg.validateValidationConfig(); err != nil {
+		return errors.New("validation configuration error: " + err.Error())
+	}
+
+	// Validate JWT configuration
+	if err := cfg.validateJWTConfig(); err != nil {
+		return errors.New("JWT configuration error: " + err.Error())
+	}
+
+	// Validate quota configuration
+	if err := cfg.validateQuotaConfig(); err != nil {
+		return errors.New("quota configuration error: " + err.Error())
+	}
+
+	// Validate file lock TTLs
+	if cfg.Lock.DefaultTTL <= 0 || cfg.Lock.MaxTTL < cfg.Lock.DefaultTTL {
+		return errors.New("lock configuration error: default TTL must be positive and at most the max TTL")
+	}
+
+	// Validate file request link lifetimes
+	if cfg.Request.DefaultTTL <= 0 || cfg.Request.MaxTTL < cfg.Request.DefaultTTL {
+		return errors.New("file request configuration error: default TTL must be positive and at most the max TTL")
+	}
+	if cfg.Request.UploadRequests <= 0 || cfg.Request.UploadWindow <= 0 {
+		return errors.New("file request configuration error: upload requests and window must be positive")
+	}
+
+	// Validate notification delivery configuration
+	if err := cfg.validateNotifyConfig(); err != nil {
+		return errors.New("notification configuration error: " + err.Error())
+	}
+
+	// Validate cost estimation prices
+	if cfg.Cost.StorageGBMonth < 0 || cfg.Cost.Tier1RequestsPerThousand < 0 || cfg.Cost.Tier2RequestsPerThousand < 0 {
+		return errors.New("cost configuration error: unit prices cannot be negative")
+	}
+
+	// Validate metrics export configuration
+	if err := cfg.validateMetricsConfig(); err != nil {
+		return errors.New("metrics configuration error: " + err.Error())
+	}
+
+	// Validate profiling configuration
+	if err := cfg.validateProfilingConfig(); err != nil {
+		return errors.New("profiling configuration error: " + err.Error())
+	}
+
+	// Validate error tracking configuration
+	if cfg.Errors.Enabled {
+		if cfg.Errors.DSN == "" {
+			return errors.New("error tracking configuration error: DSN is required")
+		}
+		if cfg.Errors.SampleRate < 0 || cfg.Errors.SampleRate > 1 {
+			return errors.New("error tracking configuration error: sample rate must be between 0 and 1")
+		}
+	}
+
+	// Validate service discovery configuration
+	if err := cfg.validateDiscoveryConfig(); err != nil {
+		return errors.New("discovery configuration error: " + err.Error())
+	}
+
+	// Validate background job coordination configuration
+	if err := cfg.validateJobsConfig(); err != nil {
+		return errors.New("jobs configuration error: " + err.Error())
+	}
+
+	// Validate SPIFFE authentication configuration
+	if err := cfg.validateSPIFFEConfig(); err != nil {
+		return errors.New("SPIFFE configuration error: " + err.Error())
+	}
+
+	// Validate rate limit configuration
+	if cfg.RateLimit.Enabled && (cfg.RateLimit.Requests <= 0 || cfg.RateLimit.Window <= 0) {
+		return errors.New("rate limit configuration error: requests and window must be positive")
+	}
+
+	// Validate access review configuration
+	if cfg.AccessReview.StaleAfter <= 0 || (cfg.AccessReview.Enabled && cfg.AccessReview.Interval <= 0) {
+		return errors.New("access review configuration error: stale period and interval must be positive")
+	}
+
+	// Validate CSRF protection configuration
+	if err := cfg.validateCSRFConfig(); err != nil {
+		return errors.New("CSRF configuration error: " + err.Error())
+	}
+
+	// Validate replication configuration
+	if err := cfg.validateReplicationConfig(); err != nil {
+		return errors.New("replication configuration error: " + err.Error())
+	}
+
+	// Validate archive configuration
+	if err := cfg.validateArchiveConfig(); err != nil {
+		return errors.New("archive configuration error: " + err.Error())
+	}
+
+	// Validate tiering configuration
+	if err := cfg.validateTieringConfig(); err != nil {
+		return errors.New("tiering configuration error: " + err.Error())
+	}
+
+	// Validate storage reconciliation
+	if err := cfg.validateReconcileConfig(); err != nil {
+		return errors.New("reconcile configuration error: " + err.Error())
+	}
+
+	// Validate integrity scrubbing
+	if err := cfg.validateScrubConfig(); err != nil {
+		return errors.New("scrub configuration error: " + err.Error())
+	}
+
+	// Validate tenant settings caching
+	if cfg.Tenant.SettingsEnabled && cfg.Tenant.SettingsCacheTTL <= 0 {
+		return errors.New("tenant configuration error: settings cache TTL must be positive")
+	}
+	if len(cfg.Tenant.RegulatedTenants) > 0 && (cfg.Tenant.DeleteApprovalTTL <= 0 || cfg.Tenant.DeleteApprovalSweepInterval <= 0) {
+		return errors.New("tenant configuration error: delete approval TTL and sweep interval must be positive")
+	}
+
+	// Validate legacy route retirement schedule
+	if !cfg.API.LegacySunset.IsZero() && cfg.API.LegacySunset.Before(cfg.API.LegacyDeprecatedAt) {
+		return errors.New("API configuration error: legacy sunset must not precede deprecation")
+	}
+
+	// Validate authorization configuration
+	if err := cfg.validateAuthzConfig(); err != nil {
+		return errors.New("authorization configuration error: " + err.Error())
+	}
+
+	// Validate audit export configuration
+	if err := cfg.validateAuditConfig(); err != nil {
+		return errors.New("audit configuration error: " + err.Error())
+	}
+
 	// Validate logger configuration
 	if err := cfg.Logger.Validate(); err != nil {
 		return errors.New("logger configuration error: " + err.Error())
@@ -148,6 +883,88 @@ func (cfg *Config) validateS3Config() error {
 		return errors.New("invalid retry max value")
 	}
 
+	seen := make(map[string]bool, len(cfg.S3.ShardBuckets))
+	for _, bucket := range cfg.S3.ShardBuckets {
+		if bucket == "" || seen[bucket] {
+			return errors.New("S3 shard buckets must be named and distinct")
+		}
+		seen[bucket] = true
+	}
+	// An Object Lambda access point reads a single bucket
+	if len(cfg.S3.ShardBuckets) > 0 && (cfg.S3.ObjectLambdaAccessPoint != "" || len(cfg.S3.ObjectLambdaTenants) > 0) {
+		return errors.New("S3 object lambda access points cannot be used with shard buckets")
+	}
+
+	if (cfg.S3.TenantKeysRequired || len(cfg.S3.TenantBuckets) > 0) && !cfg.S3.TenantIsolation {
+		return errors.New("S3 tenant buckets and required tenant keys need tenant isolation")
+	}
+	for tenant, bucket := range cfg.S3.TenantBuckets {
+		if tenant == "" || bucket == "" || bucket == cfg.S3.Bucket || seen[bucket] {
+			return errors.New("S3 tenant buckets must be named and distinct from the other buckets")
+		}
+		seen[bucket] = true
+	}
+	// An Object Lambda access point reads a single bucket
+	if len(cfg.S3.TenantBuckets) > 0 && (cfg.S3.ObjectLambdaAccessPoint != "" || len(cfg.S3.ObjectLambdaTenants) > 0) {
+		return errors.New("S3 object lambda access points cannot be used with tenant buckets")
+	}
+
+	if cfg.S3.HealthWindow <= 0 || cfg.S3.HealthProbeInterval <= 0 || cfg.S3.HealthProbeTimeout <= 0 {
+		return errors.New("S3 health window, probe interval and probe timeout must be positive")
+	}
+
+	// S3 rejects multipart parts smaller than 5MB, other than the last
+	if cfg.S3.UploadPartSize < 5*1024*1024 {
+		return errors.New("S3 upload part size must be at least 5MB")
+	}
+
+	if cfg.S3.UploadConcurrency <= 0 || cfg.S3.UploadPartRetryMax <= 0 {
+		return errors.New("S3 upload concurrency and part retry max must be positive")
+	}
+
+	if cfg.S3.DownloadPartSize <= 0 || cfg.S3.DownloadConcurrency <= 0 {
+		return errors.New("S3 download part size and concurrency must be positive")
+	}
+
+	// Copied parts take the same bounds as uploaded ones
+	if cfg.S3.CopyPartSize < 5*1024*1024 || cfg.S3.CopyPartSize > 5*1024*1024*1024 {
+		return errors.New("S3 copy part size must be between 5MB and 5GB")
+	}
+
+	// Soft-deleted content must not land under a prefix whose objects are
+	// swept or served as something else
+	if _, err := storagekey.Default.WithPrefix(cfg.S3.SoftDeletePrefix); err != nil {
+		return errors.New("S3 soft delete prefix: " + err.Error())
+	}
+	for _, prefix := range storagekey.Default.Prefixes {
+		if prefix == cfg.S3.SoftDeletePrefix && prefix != storagekey.SoftDeleted {
+			return errors.New("S3 soft delete prefix is already used for " + prefix + " objects")
+		}
+	}
+
+	switch cfg.S3.SSEAlgorithm {
+	case "AES256":
+	case "aws:kms":
+		if cfg.S3.SSEKMSKeyID == "" {
+			return errors.New("S3 SSE-KMS key ID is required when the encryption algorithm is aws:kms")
+		}
+	default:
+		return errors.New("S3 encryption algorithm must be AES256 or aws:kms")
+	}
+
+	switch cfg.S3.ObjectLockMode {
+	case "":
+		if cfg.S3.ObjectLockRetention != 0 {
+			return errors.New("S3 object lock mode is required with an object lock retention")
+		}
+	case "COMPLIANCE", "GOVERNANCE":
+		if cfg.S3.ObjectLockRetention <= 0 {
+			return errors.New("S3 object lock retention must be positive")
+		}
+	default:
+		return errors.New("unsupported S3 object lock mode: " + cfg.S3.ObjectLockMode)
+	}
+
 	// Validate credentials
 	if cfg.S3.AccessKey == "" || cfg.S3.SecretKey == "" {
 		return errors.New("S3 credentials are required")
@@ -162,6 +979,13 @@ func (cfg *Config) validateServerConfig() error {
 		return errors.New("invalid port number")
 	}
 
+	if cfg.Server.InternalPort < 1 || cfg.Server.InternalPort > 65535 {
+		return errors.New("invalid internal port number")
+	}
+	if cfg.Server.InternalPort == cfg.Server.Port {
+		return errors.New("internal port must differ from the public port")
+	}
+
 	if cfg.Server.MaxFileSize < 0 {
 		return errors.New("invalid max file size")
 	}
@@ -171,6 +995,9 @@ func (cfg *Config) validateServerConfig() error {
 	   cfg.Server.IdleTimeout <= 0 || cfg.Server.ShutdownTimeout <= 0 {
 		return errors.New("invalid timeout values")
 	}
+	if cfg.Server.DrainDelay < 0 || cfg.Server.RetryAfter < 0 {
+		return errors.New("drain delay and retry after cannot be negative")
+	}
 
 	// Validate TLS configuration if enabled
 	if cfg.Server.TLSEnabled {
@@ -182,6 +1009,565 @@ func (cfg *Config) validateServerConfig() error {
 	return nil
 }
 
+// validateDatabaseConfig validates database connection settings
+func (cfg *Config) validateDatabaseConfig() error {
+	if cfg.Database.DSN == "" {
+		return errors.New("database DSN is required")
+	}
+
+	if cfg.Database.MaxOpenConns <= 0 || cfg.Database.MaxIdleConns < 0 {
+		return errors.New("invalid connection pool size")
+	}
+
+	if cfg.Database.MaxIdleConns > cfg.Database.MaxOpenConns {
+		return errors.New("max idle connections cannot exceed max open connections")
+	}
+
+	return nil
+}
+
+// validateUploadConfig validates chunked upload settings
+func (cfg *Config) validateUploadConfig() error {
+	// S3 multipart uploads require every part except the last to be at least 5MB
+	if cfg.Upload.ChunkSize < 5*1024*1024 {
+		return errors.New("chunk size must be at least 5MB")
+	}
+
+	if cfg.Upload.AdaptiveChunks {
+		if cfg.Upload.MinChunkSize < 5*1024*1024 || cfg.Upload.MaxChunkSize < cfg.Upload.MinChunkSize {
+			return errors.New("adaptive chunk sizes must be at least 5MB, with the maximum at least the minimum")
+		}
+		// S3 parts are at most 5GB
+		if cfg.Upload.MaxChunkSize > 5*1024*1024*1024 {
+			return errors.New("max chunk size must be at most 5GB")
+		}
+		if cfg.Upload.ChunkTargetDuration <= 0 || cfg.Upload.ThroughputTTL <= 0 {
+			return errors.New("invalid chunk target duration or throughput TTL")
+		}
+	}
+
+	// S3 supports at most 10,000 parts per multipart upload
+	if cfg.Upload.MaxChunks <= 0 || cfg.Upload.MaxChunks > 10000 {
+		return errors.New("max chunks must be between 1 and 10000")
+	}
+
+	if cfg.Upload.SessionTTL <= 0 || cfg.Upload.SessionSweepInterval <= 0 {
+		return errors.New("invalid session TTL or sweep interval")
+	}
+
+	if cfg.Upload.DraftTTL <= 0 || cfg.Upload.DraftPurgeInterval <= 0 {
+		return errors.New("invalid draft TTL or purge interval")
+	}
+
+	if cfg.Upload.BandwidthLimit < 0 {
+		return errors.New("invalid bandwidth limit")
+	}
+
+	if cfg.Upload.BandwidthLimit > 0 && (cfg.Upload.BandwidthBurst <= 0 || cfg.Upload.BandwidthRebalanceInterval <= 0) {
+		return errors.New("invalid bandwidth burst or rebalance interval")
+	}
+
+	if cfg.Upload.DedupEnabled && (cfg.Upload.BlobGCInterval <= 0 || cfg.Upload.BlobGCGracePeriod <= 0) {
+		return errors.New("invalid blob GC interval or grace period")
+	}
+	// Deduplicated content is shared by the files of every tenant
+	if cfg.Upload.DedupEnabled && cfg.S3.TenantIsolation {
+		return errors.New("upload deduplication cannot be used with tenant isolation")
+	}
+
+	// S3 rejects presigned URLs valid for longer than seven days
+	if cfg.Upload.DirectEnabled && (cfg.Upload.DirectURLTTL <= 0 || cfg.Upload.DirectURLTTL > 7*24*time.Hour) {
+		return errors.New("direct upload URL TTL must be positive and at most 7 days")
+	}
+
+	if cfg.Upload.AsyncEnabled && (cfg.Upload.AsyncTimeout <= 0 || cfg.Upload.AsyncSweepInterval <= 0) {
+		return errors.New("invalid asynchronous upload timeout or sweep interval")
+	}
+
+	switch cfg.Upload.NameConflict {
+	case "keep", "rename", "overwrite", "reject":
+	default:
+		return errors.New("unknown name conflict strategy: " + cfg.Upload.NameConflict)
+	}
+
+	if cfg.Upload.ExtractEnabled && (cfg.Upload.ExtractMaxEntries <= 0 || cfg.Upload.ExtractMaxSize <= 0 ||
+		cfg.Upload.ExtractMaxRatio <= 0) {
+		return errors.New("archive extraction limits must be positive")
+	}
+
+	return nil
+}
+
+// validateEncryptionConfig validates client-side encryption and escrow settings
+func (cfg *Config) validateEncryptionConfig() error {
+	if cfg.Encryption.EscrowEnabled && cfg.Encryption.EscrowKMSKeyID == "" {
+		return errors.New("escrow KMS key ID is required when escrow is enabled")
+	}
+
+	if cfg.Encryption.EscrowRequired && !cfg.Encryption.EscrowEnabled {
+		return errors.New("escrow cannot be required when it is disabled")
+	}
+
+	if cfg.Encryption.EscrowEnabled && !cfg.Encryption.ClientSideEnabled {
+		return errors.New("escrow requires client-side encryption to be enabled")
+	}
+
+	return nil
+}
+
+// validateDownloadConfig validates download protection settings
+func (cfg *Config) validateDownloadConfig() error {
+	if cfg.Download.InlinePreviewEnabled && !strings.Contains(cfg.Download.PreviewCSP, "sandbox") {
+		return errors.New("preview CSP must include the sandbox directive when inline previews are enabled")
+	}
+
+	if cfg.Download.PreviewMaxSize <= 0 {
+		return errors.New("invalid preview max size")
+	}
+
+	if cfg.Download.PreviewTokenSecret != "" && len(cfg.Download.PreviewTokenSecret) < 32 {
+		return errors.New("preview token secret must be at least 32 bytes")
+	}
+	if cfg.Download.PreviewTokenTTL <= 0 {
+		return errors.New("invalid preview token TTL")
+	}
+	if cfg.Download.PreviewTokenSingleUse && cfg.Download.NoncePurgeInterval <= 0 {
+		return errors.New("invalid nonce purge interval")
+	}
+
+	if cfg.Download.WatermarkPolicyFile != "" && cfg.Download.WatermarkMaxSize <= 0 {
+		return errors.New("invalid watermark max size")
+	}
+
+	if cfg.Download.DerivedMaxSourceSize <= 0 {
+		return errors.New("invalid derived object max source size")
+	}
+
+	if cfg.Download.ConvertTimeout <= 0 {
+		return errors.New("invalid conversion timeout")
+	}
+
+	if cfg.Download.PresignEnabled && (cfg.Download.PresignTTL <= 0 || cfg.Download.PresignTTL > 7*24*time.Hour) {
+		return errors.New("presigned download URL TTL must be positive and at most 7 days")
+	}
+
+	return nil
+}
+
+// validateValidationConfig validates upload validation overrides
+func (cfg *Config) validateValidationConfig() error {
+	for _, code := range cfg.Validation.MasqueradeAllowedCodes {
+		switch strings.ToUpper(strings.TrimSpace(code)) {
+		case validator.CodeDoubleExtension, validator.CodeBidiControl,
+			validator.CodeExecutableExtension, validator.CodeExecutableContent:
+		default:
+			return errors.New("unknown masquerade code: " + code)
+		}
+	}
+
+	if err := (validator.ContentPolicy{Checks: cfg.Validation.ContentChecks}).Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateJWTConfig validates token signing settings
+func (cfg *Config) validateJWTConfig() error {
+	if len(cfg.JWT.SigningKey) < 32 {
+		return errors.New("signing key must be at least 32 bytes")
+	}
+
+	return nil
+}
+
+// validateQuotaConfig validates storage quota thresholds and the usage webhook
+func (cfg *Config) validateQuotaConfig() error {
+	if cfg.Quota.Limit < 0 {
+		return errors.New("invalid quota limit")
+	}
+
+	for _, threshold := range cfg.Quota.Thresholds {
+		if threshold < 1 || threshold > 100 {
+			return errors.New("quota thresholds must be percentages between 1 and 100")
+		}
+	}
+
+	if cfg.Quota.WebhookURL != "" {
+		u, err := url.Parse(cfg.Quota.WebhookURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("invalid quota webhook URL")
+		}
+		if cfg.Quota.WebhookTimeout <= 0 {
+			return errors.New("invalid quota webhook timeout")
+		}
+	}
+
+	return nil
+}
+
+// validateNotifyConfig validates the email provider and digest schedule
+func (cfg *Config) validateNotifyConfig() error {
+	switch cfg.Notify.EmailProvider {
+	case "":
+	case "smtp":
+		if cfg.Notify.SMTPHost == "" || cfg.Notify.SMTPPort <= 0 {
+			return errors.New("SMTP host and port are required for the smtp provider")
+		}
+	case "ses":
+		if cfg.Notify.SESRegion == "" {
+			return errors.New("SES region is required for the ses provider")
+		}
+	default:
+		return errors.New("unsupported email provider: " + cfg.Notify.EmailProvider)
+	}
+
+	if cfg.Notify.EmailProvider != "" && cfg.Notify.EmailFrom == "" {
+		return errors.New("sender address is required to send email")
+	}
+	if cfg.Notify.Timeout <= 0 {
+		return errors.New("invalid delivery timeout")
+	}
+	if cfg.Notify.DigestInterval <= 0 || cfg.Notify.DigestCheckInterval <= 0 {
+		return errors.New("invalid digest interval")
+	}
+
+	return nil
+}
+
+// validateMetricsConfig validates the metrics exporter settings
+func (cfg *Config) validateMetricsConfig() error {
+	switch cfg.Metrics.Exporter {
+	case "prometheus":
+		if cfg.Metrics.Enabled && !strings.HasPrefix(cfg.Metrics.Path, "/") {
+			return errors.New("metrics path must start with /")
+		}
+	case "otlp":
+		u, err := url.Parse(cfg.Metrics.OTLPEndpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("a valid OTLP endpoint URL is required for the otlp exporter")
+		}
+		if cfg.Metrics.OTLPInterval <= 0 || cfg.Metrics.OTLPTimeout <= 0 {
+			return errors.New("invalid OTLP export interval or timeout")
+		}
+	default:
+		return errors.New("unsupported metrics exporter: " + cfg.Metrics.Exporter)
+	}
+
+	if cfg.Metrics.SLOObjective <= 0 || cfg.Metrics.SLOObjective >= 1 {
+		return errors.New("SLO objective must be between 0 and 1")
+	}
+	if cfg.Metrics.SLOUploadLatency <= 0 || cfg.Metrics.SLODownloadLatency <= 0 {
+		return errors.New("invalid SLO latency targets")
+	}
+	if cfg.Metrics.SlowRequestThreshold < 0 || cfg.Metrics.LargeTransferThreshold < 0 {
+		return errors.New("slow request and large transfer thresholds must not be negative")
+	}
+	if cfg.Metrics.TenantLabels && (cfg.Metrics.TenantLabelLimit <= 0 || cfg.Metrics.TenantLabelWindow <= 0) {
+		return errors.New("tenant label limit and window must be positive")
+	}
+	if cfg.Metrics.StorageStatsInterval < 0 {
+		return errors.New("storage stats interval must not be negative")
+	}
+
+	return nil
+}
+
+// validateProfilingConfig validates continuous profiling settings
+func (cfg *Config) validateProfilingConfig() error {
+	if !cfg.Profiling.Enabled {
+		return nil
+	}
+
+	switch cfg.Profiling.Backend {
+	case "pyroscope":
+		u, err := url.Parse(cfg.Profiling.ServerAddress)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("a valid server address is required for the pyroscope backend")
+		}
+	case "parca":
+		if cfg.Profiling.ListenAddress == "" {
+			return errors.New("listen address is required for the parca backend")
+		}
+	default:
+		return errors.New("unsupported profiling backend: " + cfg.Profiling.Backend)
+	}
+
+	return nil
+}
+
+// validateDiscoveryConfig validates service registration settings
+func (cfg *Config) validateDiscoveryConfig() error {
+	switch cfg.Discovery.Backend {
+	case "":
+		return nil
+	case "consul", "etcd":
+	default:
+		return errors.New("unsupported discovery backend: " + cfg.Discovery.Backend)
+	}
+
+	if len(cfg.Discovery.Endpoints) == 0 {
+		return errors.New("at least one registry endpoint is required")
+	}
+	if cfg.Discovery.ServiceName == "" {
+		return errors.New("service name is required")
+	}
+	if cfg.Discovery.CheckInterval <= 0 || cfg.Discovery.CheckTimeout <= 0 {
+		return errors.New("invalid health check interval or timeout")
+	}
+	if cfg.Discovery.CheckTimeout > cfg.Discovery.CheckInterval {
+		return errors.New("health check timeout must not exceed the interval")
+	}
+
+	return nil
+}
+
+// validateJobsConfig validates background job coordination settings
+func (cfg *Config) validateJobsConfig() error {
+	switch cfg.Jobs.LockBackend {
+	case "postgres", "local":
+	default:
+		return errors.New("unsupported job lock backend: " + cfg.Jobs.LockBackend)
+	}
+	if cfg.Jobs.LockKeepAlive <= 0 {
+		return errors.New("lock keep-alive interval must be positive")
+	}
+	return nil
+}
+
+// validateAuditConfig validates audit export settings
+func (cfg *Config) validateAuditConfig() error {
+	if cfg.Audit.VerifyKey != "" {
+		if _, err := auditlog.ParsePublicKey(cfg.Audit.VerifyKey); err != nil {
+			return err
+		}
+	}
+	if !cfg.Audit.ExportEnabled {
+		return nil
+	}
+
+	if cfg.Audit.SigningKey == "" {
+		return errors.New("signing key is required when export is enabled")
+	}
+	if _, err := auditlog.ParsePrivateKey(cfg.Audit.SigningKey); err != nil {
+		return err
+	}
+	if cfg.Audit.ExportInterval <= 0 {
+		return errors.New("export interval must be positive")
+	}
+	if cfg.Audit.Prefix == "" || strings.HasPrefix(cfg.Audit.Prefix, "/") {
+		return errors.New("prefix must be a non-empty relative key prefix")
+	}
+	if cfg.Audit.BatchSize <= 0 {
+		return errors.New("batch size must be positive")
+	}
+	switch cfg.Audit.LockMode {
+	case "COMPLIANCE", "GOVERNANCE":
+	default:
+		return errors.New("unsupported object lock mode: " + cfg.Audit.LockMode)
+	}
+	if cfg.Audit.Retention <= 0 {
+		return errors.New("retention must be positive")
+	}
+	return nil
+}
+
+// validateSPIFFEConfig validates workload identity authentication settings
+func (cfg *Config) validateSPIFFEConfig() error {
+	if !cfg.SPIFFE.Enabled {
+		return nil
+	}
+	if !cfg.Server.TLSEnabled {
+		return errors.New("TLS must be enabled to accept SVIDs")
+	}
+	if cfg.SPIFFE.StartTimeout <= 0 {
+		return errors.New("start timeout must be positive")
+	}
+	if len(cfg.SPIFFE.Roles) == 0 {
+		return errors.New("at least one SPIFFE ID role mapping is required")
+	}
+	for id, roles := range cfg.SPIFFE.Roles {
+		if _, err := spiffeid.FromString(id); err != nil {
+			return errors.New("invalid SPIFFE ID " + id + ": " + err.Error())
+		}
+		if strings.Trim(roles, "| ") == "" {
+			return errors.New("SPIFFE ID " + id + " has no roles")
+		}
+	}
+	return nil
+}
+
+// validateCSRFConfig validates CSRF protection settings
+func (cfg *Config) validateCSRFConfig() error {
+	if !cfg.CSRF.Enabled {
+		return nil
+	}
+	if cfg.CSRF.SessionCookie == "" || cfg.CSRF.Cookie == "" || cfg.CSRF.Header == "" {
+		return errors.New("session cookie, CSRF cookie and header names are required")
+	}
+	if cfg.CSRF.SessionCookie == cfg.CSRF.Cookie {
+		return errors.New("CSRF cookie must differ from the session cookie")
+	}
+	if len(cfg.CSRF.TokenSecret) < 32 {
+		return errors.New("token secret must be at least 32 characters")
+	}
+	for _, origin := range cfg.CSRF.TrustedOrigins {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return errors.New("invalid trusted origin: " + origin)
+		}
+	}
+	return nil
+}
+
+// validateReplicationConfig validates the secondary bucket and replication schedule
+func (cfg *Config) validateReplicationConfig() error {
+	if !cfg.Replication.Enabled {
+		return nil
+	}
+
+	if cfg.Replication.Bucket == "" || cfg.Replication.Region == "" {
+		return errors.New("replica bucket and region are required when replication is enabled")
+	}
+	if cfg.Replication.Bucket == cfg.S3.Bucket {
+		return errors.New("replica bucket must differ from the primary bucket")
+	}
+	for _, bucket := range cfg.S3.ShardBuckets {
+		if cfg.Replication.Bucket == bucket {
+			return errors.New("replica bucket must differ from the shard buckets")
+		}
+	}
+	for _, bucket := range cfg.S3.TenantBuckets {
+		if cfg.Replication.Bucket == bucket {
+			return errors.New("replica bucket must differ from the tenant buckets")
+		}
+	}
+	if cfg.Replication.Interval <= 0 || cfg.Replication.BatchSize <= 0 || cfg.Replication.MaxAttempts <= 0 {
+		return errors.New("interval, batch size and max attempts must be positive")
+	}
+
+	return nil
+}
+
+// validateArchiveConfig validates the archive policy and restore settings
+func (cfg *Config) validateArchiveConfig() error {
+	if !cfg.Archive.Enabled {
+		return nil
+	}
+
+	glacier, deep := cfg.Archive.GlacierAfter, cfg.Archive.DeepArchiveAfter
+	if glacier < 0 || deep < 0 || (glacier == 0 && deep == 0) {
+		return errors.New("an idle period is required for GLACIER or DEEP_ARCHIVE, and neither may be negative")
+	}
+	if glacier > 0 && deep > 0 && deep <= glacier {
+		return errors.New("DEEP_ARCHIVE idle period must be longer than the GLACIER one")
+	}
+	if cfg.Archive.Interval <= 0 || cfg.Archive.BatchSize <= 0 || cfg.Archive.RestoreDays <= 0 {
+		return errors.New("interval, batch size and restore days must be positive")
+	}
+
+	switch cfg.Archive.RestoreTier {
+	case "Standard", "Bulk":
+	case "Expedited":
+		if deep > 0 {
+			return errors.New("DEEP_ARCHIVE does not support Expedited restores")
+		}
+	default:
+		return errors.New("unknown restore tier: " + cfg.Archive.RestoreTier)
+	}
+
+	return nil
+}
+
+// validateTieringConfig validates the tiering policy
+func (cfg *Config) validateTieringConfig() error {
+	if !cfg.Tiering.Enabled {
+		return nil
+	}
+
+	if cfg.Tiering.ColdAfter <= 0 || cfg.Tiering.Interval <= 0 || cfg.Tiering.BatchSize <= 0 {
+		return errors.New("cold period, interval and batch size must be positive")
+	}
+	switch cfg.Tiering.ColdStorageClass {
+	case "STANDARD_IA", "ONEZONE_IA":
+	default:
+		return errors.New("unknown cold storage class: " + cfg.Tiering.ColdStorageClass)
+	}
+	if cfg.Archive.Enabled {
+		// Files are archived before they would ever be moved to a cold class
+		archiveAfter := cfg.Archive.GlacierAfter
+		if archiveAfter <= 0 {
+			archiveAfter = cfg.Archive.DeepArchiveAfter
+		}
+		if cfg.Tiering.ColdAfter >= archiveAfter {
+			return errors.New("cold period must be shorter than the archive idle period")
+		}
+	}
+
+	return nil
+}
+
+// validateReconcileConfig validates the storage reconciliation settings
+func (cfg *Config) validateReconcileConfig() error {
+	if !cfg.Reconcile.Enabled {
+		return nil
+	}
+
+	switch cfg.Reconcile.Mode {
+	case "dry-run", "quarantine", "clean":
+	default:
+		return errors.New("unknown mode: " + cfg.Reconcile.Mode)
+	}
+	if cfg.Reconcile.Interval <= 0 || cfg.Reconcile.MinAge < 0 || cfg.Reconcile.MaxActions < 0 {
+		return errors.New("interval must be positive, and min age and max actions not negative")
+	}
+	if cfg.Reconcile.BatchSize <= 0 || cfg.Reconcile.BatchSize > 1000 {
+		return errors.New("batch size must be between 1 and 1000")
+	}
+
+	return nil
+}
+
+// validateScrubConfig validates the integrity scrubber settings
+func (cfg *Config) validateScrubConfig() error {
+	if !cfg.Scrub.Enabled {
+		return nil
+	}
+
+	switch cfg.Scrub.Method {
+	case "download", "s3-checksum":
+	default:
+		return errors.New("unknown method: " + cfg.Scrub.Method)
+	}
+	if cfg.Scrub.Interval <= 0 || cfg.Scrub.SampleSize <= 0 || cfg.Scrub.MaxDownloadSize <= 0 {
+		return errors.New("interval, sample size and max download size must be positive")
+	}
+
+	return nil
+}
+
+// validateAuthzConfig validates authorization mode settings
+func (cfg *Config) validateAuthzConfig() error {
+	switch cfg.Authz.Mode {
+	case "roles":
+		return nil
+	case "opa":
+	default:
+		return errors.New("unsupported authorization mode: " + cfg.Authz.Mode)
+	}
+
+	if cfg.Authz.PolicyDir != "" {
+		info, err := os.Stat(cfg.Authz.PolicyDir)
+		if err != nil {
+			return errors.New("policy directory not found: " + cfg.Authz.PolicyDir)
+		}
+		if !info.IsDir() {
+			return errors.New("policy path is not a directory: " + cfg.Authz.PolicyDir)
+		}
+	}
+	return nil
+}
+
 // validateTLSConfig validates TLS configuration settings
 func (cfg *Config) validateTLSConfig() error {
 	if cfg.Server.TLSCertFile == "" || cfg.Server.TLSKeyFile == "" {
@@ -213,6 +1599,15 @@ func isSensitive(tag string) bool {
 		"SESSION_TOKEN",
 		"PASSWORD",
 		"KEY",
+		"DSN",
+		"SIGNING_KEY",
+		"WEBHOOK_SECRET",
+		"SMTP_PASSWORD",
+		"OTLP_HEADERS",
+		"AUTH_TOKEN",
+		"PREVIEW_TOKEN_SECRET",
+		"TOKEN_SECRET",
+		"ACL_TOKEN",
 	}
 
 	for _, field := range sensitiveFields {
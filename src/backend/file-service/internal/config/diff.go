@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/caarlos0/env/v6" // v6.10.0
+)
+
+// Change is one configuration variable whose effective value differs from a
+// baseline. Sensitive values are redacted on both sides, so secrets only
+// show up as being set or unset.
+type Change struct {
+	Name     string      `json:"name"`
+	Value    interface{} `json:"value"`
+	Baseline interface{} `json:"baseline"`
+}
+
+// Defaults returns the redacted configuration built from the compiled
+// defaults alone, without the environment or profile defaults
+func Defaults() (map[string]interface{}, error) {
+	// Required variables have no default; present them as empty so parsing
+	// succeeds and they compare as unset
+	vars := make(map[string]string)
+	for _, entry := range Schema() {
+		if entry.Required {
+			vars[entry.Name] = ""
+		}
+	}
+
+	cfg := &Config{}
+	if err := env.Parse(cfg, env.Options{Prefix: envPrefix, Environment: vars}); err != nil {
+		return nil, errors.New("failed to parse configuration defaults: " + err.Error())
+	}
+	return cfg.Redacted(), nil
+}
+
+// Diff lists the variables whose values in current differ from baseline,
+// sorted by name. Both sides are compared in their JSON form so a baseline
+// read back from a snapshot compares equal to the values it was saved from.
+func Diff(baseline, current map[string]interface{}) ([]Change, error) {
+	base, err := normalize(baseline)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := normalize(current)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for name := range base {
+		names[name] = true
+	}
+	for name := range cur {
+		names[name] = true
+	}
+
+	var changes []Change
+	for name := range names {
+		if !reflect.DeepEqual(base[name], cur[name]) {
+			changes = append(changes, Change{Name: name, Value: cur[name], Baseline: base[name]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, nil
+}
+
+// LoadSnapshot reads a configuration snapshot saved by SaveSnapshot. A
+// missing snapshot returns nil without error.
+func LoadSnapshot(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to read configuration snapshot: " + err.Error())
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, errors.New("failed to parse configuration snapshot: " + err.Error())
+	}
+	return values, nil
+}
+
+// SaveSnapshot writes redacted configuration values to path, replacing any
+// previous snapshot atomically
+func SaveSnapshot(path string, values map[string]interface{}) error {
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-snapshot-*")
+	if err != nil {
+		return errors.New("failed to write configuration snapshot: " + err.Error())
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.New("failed to write configuration snapshot: " + err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.New("failed to write configuration snapshot: " + err.Error())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.New("failed to write configuration snapshot: " + err.Error())
+	}
+	return nil
+}
+
+// normalize round-trips values through JSON so typed values and decoded
+// snapshot values share one representation
+func normalize(values map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make(map[string]interface{})
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
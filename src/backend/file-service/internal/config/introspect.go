@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v6" // v6.10.0
+)
+
+const (
+	// envPrefix is prepended to every configuration environment variable
+	envPrefix = "APP_"
+	// redactedValue replaces sensitive values in printed configuration
+	redactedValue = "****"
+)
+
+// SchemaEntry describes one configuration environment variable
+type SchemaEntry struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Default   string `json:"default,omitempty"`
+	Required  bool   `json:"required"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// ParseConfig reads the configuration from the environment without
+// validating it or installing it as the global configuration
+func ParseConfig() (*Config, error) {
+	cfg := &Config{}
+	vars, err := environment()
+	if err != nil {
+		return nil, err
+	}
+	if err := env.Parse(cfg, env.Options{Prefix: envPrefix, Environment: vars}); err != nil {
+		return nil, errors.New("failed to parse environment variables: " + err.Error())
+	}
+	return cfg, nil
+}
+
+// Validate runs the full configuration validation
+func (cfg *Config) Validate() error {
+	return cfg.validate()
+}
+
+// Redacted returns the effective configuration keyed by environment variable
+// name, with sensitive values masked
+func (cfg *Config) Redacted() map[string]interface{} {
+	values := make(map[string]interface{})
+	walkEnv(reflect.ValueOf(cfg).Elem(), envPrefix, func(name string, field reflect.StructField, value reflect.Value) {
+		if fieldSensitive(field) {
+			if !value.IsZero() {
+				values[name] = redactedValue
+			}
+			return
+		}
+
+		if d, ok := value.Interface().(time.Duration); ok {
+			values[name] = d.String()
+			return
+		}
+		values[name] = value.Interface()
+	})
+	return values
+}
+
+// Schema describes every configuration environment variable
+func Schema() []SchemaEntry {
+	var entries []SchemaEntry
+	walkEnv(reflect.ValueOf(&Config{}).Elem(), envPrefix, func(name string, field reflect.StructField, value reflect.Value) {
+		entries = append(entries, SchemaEntry{
+			Name:      name,
+			Type:      field.Type.String(),
+			Default:   field.Tag.Get("envDefault"),
+			Required:  hasEnvOption(field, "required"),
+			Sensitive: fieldSensitive(field),
+		})
+	})
+	return entries
+}
+
+// walkEnv calls fn for every environment-backed field under v, descending
+// into nested configuration sections the same way the env parser does
+func walkEnv(v reflect.Value, prefix string, fn func(name string, field reflect.StructField, value reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("env"), ",")[0]
+		value := v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			walkEnv(value, prefix+tag, fn)
+			continue
+		}
+		if tag == "" {
+			continue
+		}
+		fn(prefix+tag, field, value)
+	}
+}
+
+// fieldSensitive reports whether a field holds a secret
+func fieldSensitive(field reflect.StructField) bool {
+	tag := strings.Split(field.Tag.Get("env"), ",")[0]
+	return isSensitive(tag) || hasEnvOption(field, "unset")
+}
+
+// hasEnvOption reports whether the field's env tag carries option
+func hasEnvOption(field reflect.StructField, option string) bool {
+	for _, opt := range strings.Split(field.Tag.Get("env"), ",")[1:] {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
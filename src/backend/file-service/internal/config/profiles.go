@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+)
+
+// Configuration profiles selected by APP_ENV. The default profile, used when
+// APP_ENV is unset, applies no defaults of its own and no prod checks, so
+// deployments that predate profiles keep their behaviour.
+const (
+	ProfileDefault = "default"
+	ProfileDev     = "dev"
+	ProfileStaging = "staging"
+	ProfileProd    = "prod"
+)
+
+// profileVar selects the configuration profile
+const profileVar = envPrefix + "ENV"
+
+// profileDefaults holds the defaults each profile applies, keyed by
+// environment variable name without the APP_ prefix. Explicitly set
+// variables always win over profile defaults.
+var profileDefaults = map[string]map[string]string{
+	ProfileDefault: {},
+	ProfileDev: {
+		"ERROR_TRACKING_ENVIRONMENT": "dev",
+		"SERVER_TLS_ENABLED":         "false",
+		"S3_USE_SSL":                 "false",
+		"PROFILING_ENABLED":          "true",
+		"PROFILING_BACKEND":          "parca",
+		"SERVER_PPROF_ENABLED":       "true",
+		"ERROR_TRACKING_SCRUB_PII":   "false",
+	},
+	ProfileStaging: {
+		"ERROR_TRACKING_ENVIRONMENT": "staging",
+		"SERVER_TLS_ENABLED":         "true",
+		"S3_USE_SSL":                 "true",
+		"PROFILING_ENABLED":          "false",
+		"ERROR_TRACKING_SCRUB_PII":   "true",
+	},
+	ProfileProd: {
+		"ERROR_TRACKING_ENVIRONMENT": "prod",
+		"SERVER_TLS_ENABLED":         "true",
+		"S3_USE_SSL":                 "true",
+		"PROFILING_ENABLED":          "false",
+		"ERROR_TRACKING_SCRUB_PII":   "true",
+	},
+}
+
+// environment returns the process environment with the selected profile's
+// defaults filled in for variables that are not set
+func environment() (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			vars[key] = value
+		}
+	}
+
+	profile := vars[profileVar]
+	if profile == "" {
+		profile = ProfileDefault
+	}
+	defaults, ok := profileDefaults[profile]
+	if !ok {
+		return nil, errors.New("unknown configuration profile: " + profile)
+	}
+
+	for key, value := range defaults {
+		if _, set := vars[envPrefix+key]; !set {
+			vars[envPrefix+key] = value
+		}
+	}
+	return vars, nil
+}
+
+// validateProfile rejects settings that are unsafe in production
+func (cfg *Config) validateProfile() error {
+	if cfg.Env != ProfileProd {
+		return nil
+	}
+
+	if !cfg.Server.TLSEnabled {
+		return errors.New("TLS must be enabled in prod")
+	}
+	if !cfg.S3.UseSSL {
+		return errors.New("S3 SSL must be enabled in prod")
+	}
+	if cfg.S3.Bootstrap {
+		return errors.New("S3 bucket bootstrap must not be enabled in prod")
+	}
+	if cfg.Errors.Enabled && !cfg.Errors.ScrubPII {
+		return errors.New("error reports must be scrubbed of PII in prod")
+	}
+	if cfg.Profiling.Enabled && cfg.Profiling.Backend == "parca" && !isLoopback(cfg.Profiling.ListenAddress) {
+		return errors.New("pprof debug endpoints must only listen on loopback in prod")
+	}
+	return nil
+}
+
+// isLoopback reports whether a listen address binds only to the loopback interface
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
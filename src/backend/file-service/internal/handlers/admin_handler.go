@@ -0,0 +1,119 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/supportbundle"
+)
+
+// maxStaleDays bounds the stale period of an access review report
+const maxStaleDays = 3650
+
+// AdminHandler serves operational endpoints restricted to administrators
+type AdminHandler struct {
+    costEstimator *service.CostEstimator
+    accessReview  service.AccessReview
+    storageHealth *storage.HealthBoard
+    supportBundle *supportbundle.Bundle
+    logger        *zap.Logger
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(costEstimator *service.CostEstimator, accessReview service.AccessReview,
+    storageHealth *storage.HealthBoard, supportBundle *supportbundle.Bundle) *AdminHandler {
+    return &AdminHandler{
+        costEstimator: costEstimator,
+        accessReview:  accessReview,
+        storageHealth: storageHealth,
+        supportBundle: supportBundle,
+        logger:        zap.L().Named("admin-handler"),
+    }
+}
+
+// StorageCostsHandler returns the projected monthly storage bill
+func (h *AdminHandler) StorageCostsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    report, err := h.costEstimator.Estimate(r.Context())
+    if err != nil {
+        h.logger.Error("Failed to estimate storage costs", zap.Error(err))
+        reportError(r, "Failed to estimate storage costs", err)
+        writeError(w, r, http.StatusInternalServerError, "Failed to estimate storage costs")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, report)
+}
+
+// StorageHealthHandler returns the health scoreboard of the storage
+// backends: recent error rates and latencies, and the last probe of each
+func (h *AdminHandler) StorageHealthHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, h.storageHealth.Report())
+}
+
+// AccessReviewHandler returns the shares and open file requests nobody used
+// for the configured stale period, or for the number of days given by the
+// staleDays query parameter
+func (h *AdminHandler) AccessReviewHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    var staleAfter time.Duration
+    if raw := r.URL.Query().Get("staleDays"); raw != "" {
+        days, err := strconv.Atoi(raw)
+        if err != nil || days <= 0 || days > maxStaleDays {
+            writeError(w, r, http.StatusBadRequest, "Invalid stale period")
+            return
+        }
+        staleAfter = time.Duration(days) * 24 * time.Hour
+    }
+
+    report, err := h.accessReview.Report(r.Context(), staleAfter)
+    if err != nil {
+        h.logger.Error("Failed to generate access review", zap.Error(err))
+        reportError(r, "Failed to generate access review", err)
+        writeError(w, r, http.StatusInternalServerError, "Failed to generate access review")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, report)
+}
+
+// SupportBundleHandler returns a zip archive of the instance's diagnostics
+// to attach to support tickets: its redacted configuration, recent warnings
+// and errors, a metric snapshot, dependency health and a goroutine dump
+func (h *AdminHandler) SupportBundleHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    fileName := "file-service-support-" + time.Now().UTC().Format("20060102T150405Z") + ".zip"
+    w.Header().Set("Content-Type", "application/zip")
+    w.Header().Set("Content-Disposition", contentDisposition("attachment", fileName))
+    w.Header().Set("Cache-Control", "no-store")
+
+    // The archive is streamed, so a failure can only cut it short
+    manifest, err := h.supportBundle.Write(r.Context(), w)
+    if err != nil {
+        h.logger.Warn("Failed to write support bundle", zap.Error(err))
+        return
+    }
+    h.logger.Info("Support bundle generated", zap.Int("sections", len(manifest.Sections)))
+}
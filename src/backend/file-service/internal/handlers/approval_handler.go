@@ -0,0 +1,107 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "strings"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// approvalsPrefix addresses a single pending action
+const approvalsPrefix = "/admin/approvals/"
+
+// ApprovalHandler serves the approval of actions held for multi-party
+// approval, such as hard deletes in regulated tenants:
+//
+//    GET  /admin/approvals               list the actions awaiting approval
+//    GET  /admin/approvals/{id}          get an action
+//    POST /admin/approvals/{id}/approve  approve an action as the caller
+type ApprovalHandler struct {
+    approvals service.DeleteApprovals
+    logger    *zap.Logger
+}
+
+// NewApprovalHandler creates a new ApprovalHandler instance. approvals may
+// be nil, in which case no actions are held for approval.
+func NewApprovalHandler(approvals service.DeleteApprovals) *ApprovalHandler {
+    return &ApprovalHandler{
+        approvals: approvals,
+        logger:    zap.L().Named("approval-handler"),
+    }
+}
+
+// ListHandler handles GET /admin/approvals
+func (h *ApprovalHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+    if h.approvals == nil {
+        writeError(w, r, http.StatusNotFound, "Delete approvals are not enabled")
+        return
+    }
+
+    actions, err := h.approvals.List(r.Context())
+    if err != nil {
+        h.handleError(w, r, err, "Failed to list pending actions")
+        return
+    }
+    writeJSON(w, http.StatusOK, actions)
+}
+
+// ActionHandler handles GET /admin/approvals/{id} and
+// POST /admin/approvals/{id}/approve
+func (h *ApprovalHandler) ActionHandler(w http.ResponseWriter, r *http.Request) {
+    if h.approvals == nil {
+        writeError(w, r, http.StatusNotFound, "Delete approvals are not enabled")
+        return
+    }
+    actionID, approve := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, approvalsPrefix), "/approve")
+    if actionID == "" || strings.Contains(actionID, "/") {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+
+    switch {
+    case approve && r.Method == http.MethodPost:
+        action, err := h.approvals.Approve(r.Context(), actionID, requestctx.UserID(r.Context()))
+        if err != nil {
+            h.handleError(w, r, err, "Failed to approve action")
+            return
+        }
+        writeJSON(w, http.StatusOK, action)
+
+    case !approve && r.Method == http.MethodGet:
+        action, err := h.approvals.Get(r.Context(), actionID)
+        if err != nil {
+            h.handleError(w, r, err, "Failed to get pending action")
+            return
+        }
+        writeJSON(w, http.StatusOK, action)
+
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+    }
+}
+
+// handleError maps approval errors to HTTP responses
+func (h *ApprovalHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    switch {
+    case errors.Is(err, service.ErrInvalidInput):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+    case errors.Is(err, service.ErrPendingActionNotFound):
+        writeError(w, r, http.StatusNotFound, "Pending action not found")
+    case errors.Is(err, service.ErrPendingActionClosed):
+        writeError(w, r, http.StatusConflict, "Action is no longer awaiting approval")
+    case errors.Is(err, service.ErrApprovalNotPermitted):
+        writeError(w, r, http.StatusForbidden, "Actions cannot be approved by their requester or twice by the same admin")
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
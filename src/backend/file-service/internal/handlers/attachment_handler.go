@@ -0,0 +1,150 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+)
+
+const (
+    entitiesPath = "/entities"
+    maxPageSize  = 100
+)
+
+// attachedFilesResponse is a page of the files attached to an entity
+type attachedFilesResponse struct {
+    Files  []*models.File `json:"files"`
+    Total  int64          `json:"total"`
+    Offset int            `json:"offset"`
+    Limit  int            `json:"limit"`
+}
+
+// AttachmentHandler links files to records owned by other services:
+//
+//    GET    /entities/{type}/{id}/files            list the files attached to an entity
+//    PUT    /entities/{type}/{id}/files/{fileId}   attach a file
+//    DELETE /entities/{type}/{id}/files/{fileId}   detach a file
+type AttachmentHandler struct {
+    attachmentService service.AttachmentService
+    logger            *zap.Logger
+}
+
+// NewAttachmentHandler creates a new AttachmentHandler instance
+func NewAttachmentHandler(attachmentService service.AttachmentService) *AttachmentHandler {
+    return &AttachmentHandler{
+        attachmentService: attachmentService,
+        logger:            zap.L().Named("attachment-handler"),
+    }
+}
+
+// ServeHTTP routes requests under /entities to the attachment operations
+func (h *AttachmentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, entitiesPath), "/"), "/")
+    if len(segments) < 3 || segments[2] != "files" {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+    entityType, entityID := segments[0], segments[1]
+
+    switch {
+    case len(segments) == 3 && r.Method == http.MethodGet:
+        h.list(w, r, entityType, entityID)
+    case len(segments) == 4 && r.Method == http.MethodPut:
+        h.attach(w, r, entityType, entityID, segments[3])
+    case len(segments) == 4 && r.Method == http.MethodDelete:
+        h.detach(w, r, entityType, entityID, segments[3])
+    default:
+        writeError(w, r, http.StatusNotFound, "Not found")
+    }
+}
+
+func (h *AttachmentHandler) list(w http.ResponseWriter, r *http.Request, entityType, entityID string) {
+    offset, limit, ok := pageFromRequest(r)
+    if !ok {
+        writeError(w, r, http.StatusBadRequest, "Invalid pagination parameters")
+        return
+    }
+
+    files, total, err := h.attachmentService.ListFiles(r.Context(), entityType, entityID, offset, limit)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to list attached files")
+        return
+    }
+    if files == nil {
+        files = []*models.File{}
+    }
+
+    writeJSON(w, http.StatusOK, attachedFilesResponse{
+        Files:  files,
+        Total:  total,
+        Offset: offset,
+        Limit:  limit,
+    })
+}
+
+func (h *AttachmentHandler) attach(w http.ResponseWriter, r *http.Request, entityType, entityID, fileID string) {
+    attachment, err := h.attachmentService.Attach(r.Context(), entityType, entityID, fileID)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to attach file")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, attachment)
+}
+
+func (h *AttachmentHandler) detach(w http.ResponseWriter, r *http.Request, entityType, entityID, fileID string) {
+    if err := h.attachmentService.Detach(r.Context(), entityType, entityID, fileID); err != nil {
+        h.handleError(w, r, err, "Failed to detach file")
+        return
+    }
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// handleError maps service errors to HTTP responses
+func (h *AttachmentHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    switch {
+    case errors.Is(err, service.ErrInvalidInput):
+        writeError(w, r, http.StatusBadRequest, "Invalid entity or file reference")
+    case errors.Is(err, service.ErrFileNotFound):
+        writeError(w, r, http.StatusNotFound, "File not found")
+    case errors.Is(err, service.ErrAttachmentNotFound):
+        writeError(w, r, http.StatusNotFound, "Attachment not found")
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
+
+// pageFromRequest reads the offset and limit query parameters, applying
+// defaultPageSize and capping the limit at maxPageSize
+func pageFromRequest(r *http.Request) (offset, limit int, ok bool) {
+    query := r.URL.Query()
+    offset, limit = 0, defaultPageSize
+
+    if raw := query.Get("offset"); raw != "" {
+        value, err := strconv.Atoi(raw)
+        if err != nil || value < 0 {
+            return 0, 0, false
+        }
+        offset = value
+    }
+    if raw := query.Get("limit"); raw != "" {
+        value, err := strconv.Atoi(raw)
+        if err != nil || value <= 0 {
+            return 0, 0, false
+        }
+        limit = value
+    }
+    if limit > maxPageSize {
+        limit = maxPageSize
+    }
+    return offset, limit, true
+}
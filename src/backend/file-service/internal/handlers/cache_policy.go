@@ -0,0 +1,246 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "mime"
+    "net/http"
+    "os"
+    "path"
+    "strconv"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+)
+
+// Cache-Control values of the default cache policy
+const (
+    // cacheImmutable lets browsers and CDNs keep content named by its
+    // checksum for a year without revalidating
+    cacheImmutable = "private, max-age=31536000, immutable"
+    // cacheRevalidate lets a cached download be reused once its ETag is
+    // confirmed current
+    cacheRevalidate = "private, no-cache"
+    // cacheNoStore keeps metadata and errors out of every cache
+    cacheNoStore = "no-store"
+)
+
+// contentChecksumParam names the content a download expects by its checksum
+const contentChecksumParam = "checksum"
+
+// CacheRule sets the caching headers of successful responses to requests for
+// any of Endpoints returning any of ContentTypes. Endpoints are path patterns,
+// e.g. "/files/*/content", and content types may use a "type/*" wildcard;
+// an empty list matches everything.
+type CacheRule struct {
+    Endpoints    []string `json:"endpoints"`
+    ContentTypes []string `json:"contentTypes"`
+    CacheControl string   `json:"cacheControl"`
+    // ExpiresIn sets an Expires header this many seconds ahead, for caches
+    // that ignore Cache-Control; zero omits it
+    ExpiresIn int64 `json:"expiresIn"`
+}
+
+// CachePolicy controls the Cache-Control and Expires headers of responses,
+// so browsers and CDNs cache each kind of content correctly. The first
+// matching rule applies. Downloads naming their content by checksum are
+// content-addressed, never change, and use ContentAddressed instead.
+// Responses whose handler set Cache-Control itself, and error responses,
+// are left to the handler and not stored respectively.
+type CachePolicy struct {
+    ContentAddressed string      `json:"contentAddressed"`
+    Rules            []CacheRule `json:"rules"`
+}
+
+// DefaultCachePolicy caches content-addressed downloads for good, keeps JSON
+// metadata out of caches, and revalidates every other download by its ETag
+func DefaultCachePolicy() *CachePolicy {
+    return &CachePolicy{
+        ContentAddressed: cacheImmutable,
+        Rules: []CacheRule{
+            {ContentTypes: []string{"application/json"}, CacheControl: cacheNoStore},
+            {CacheControl: cacheRevalidate},
+        },
+    }
+}
+
+// LoadCachePolicy reads a cache policy from a JSON file
+func LoadCachePolicy(path string) (*CachePolicy, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read cache policy: %w", err)
+    }
+
+    var policy CachePolicy
+    if err := json.Unmarshal(data, &policy); err != nil {
+        return nil, fmt.Errorf("failed to parse cache policy: %w", err)
+    }
+    if err := policy.Validate(); err != nil {
+        return nil, err
+    }
+
+    return &policy, nil
+}
+
+// Validate checks every rule's endpoints, content types and headers,
+// applying the default for content-addressed downloads where none is set
+func (p *CachePolicy) Validate() error {
+    if p.ContentAddressed == "" {
+        p.ContentAddressed = cacheImmutable
+    }
+    if len(p.Rules) == 0 {
+        return errors.New("cache policy has no rules")
+    }
+
+    for i, rule := range p.Rules {
+        if strings.TrimSpace(rule.CacheControl) == "" {
+            return fmt.Errorf("cache rule %d has no cache control", i)
+        }
+        if rule.ExpiresIn < 0 {
+            return fmt.Errorf("cache rule %d has negative expiry", i)
+        }
+        for _, endpoint := range rule.Endpoints {
+            if _, err := path.Match(endpoint, "/"); err != nil || !strings.HasPrefix(endpoint, "/") {
+                return fmt.Errorf("cache rule %d has invalid endpoint %q", i, endpoint)
+            }
+        }
+        for _, contentType := range rule.ContentTypes {
+            if _, _, err := mime.ParseMediaType(contentType); err != nil && !strings.HasSuffix(contentType, "/*") {
+                return fmt.Errorf("cache rule %d has invalid content type %q", i, contentType)
+            }
+        }
+    }
+    return nil
+}
+
+// ruleFor returns the first rule matching the endpoint and content type, or nil
+func (p *CachePolicy) ruleFor(endpoint, contentType string) *CacheRule {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        mediaType = ""
+    }
+
+    for i := range p.Rules {
+        rule := &p.Rules[i]
+        if matchesEndpoint(rule.Endpoints, endpoint) && matchesContentType(rule.ContentTypes, mediaType) {
+            return rule
+        }
+    }
+    return nil
+}
+
+func matchesEndpoint(patterns []string, endpoint string) bool {
+    if len(patterns) == 0 {
+        return true
+    }
+    for _, pattern := range patterns {
+        if matched, _ := path.Match(pattern, endpoint); matched {
+            return true
+        }
+    }
+    return false
+}
+
+func matchesContentType(allowed []string, mediaType string) bool {
+    if len(allowed) == 0 {
+        return true
+    }
+    for _, contentType := range allowed {
+        contentType = strings.ToLower(strings.TrimSpace(contentType))
+        if contentType == mediaType || contentType == "*/*" {
+            return true
+        }
+        if prefix, ok := strings.CutSuffix(contentType, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+            return true
+        }
+    }
+    return false
+}
+
+// CacheHeaders sets the Cache-Control and Expires headers of every response
+// from policy when the response header is written
+func CacheHeaders(policy *CachePolicy) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        if policy == nil {
+            return next
+        }
+
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            next.ServeHTTP(&cacheResponseWriter{ResponseWriter: w, r: r, policy: policy}, r)
+        })
+    }
+}
+
+// cacheResponseWriter applies the cache policy once the handler has chosen
+// the status and content type of its response
+type cacheResponseWriter struct {
+    http.ResponseWriter
+    r           *http.Request
+    policy      *CachePolicy
+    wroteHeader bool
+}
+
+func (w *cacheResponseWriter) WriteHeader(status int) {
+    if !w.wroteHeader {
+        w.wroteHeader = true
+        w.annotate(status)
+    }
+    w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheResponseWriter) Write(b []byte) (int, error) {
+    if !w.wroteHeader {
+        w.WriteHeader(http.StatusOK)
+    }
+    return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *cacheResponseWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+// annotate sets the caching headers for a response with status
+func (w *cacheResponseWriter) annotate(status int) {
+    header := w.Header()
+    if header.Get("Cache-Control") != "" {
+        return
+    }
+    if status >= http.StatusBadRequest {
+        header.Set("Cache-Control", cacheNoStore)
+        return
+    }
+
+    if w.contentAddressed() {
+        header.Set("Cache-Control", w.policy.ContentAddressed)
+        return
+    }
+
+    rule := w.policy.ruleFor(w.r.URL.Path, header.Get("Content-Type"))
+    if rule == nil {
+        return
+    }
+    header.Set("Cache-Control", rule.CacheControl)
+    if rule.ExpiresIn > 0 {
+        expires := time.Now().Add(time.Duration(rule.ExpiresIn) * time.Second)
+        header.Set("Expires", expires.UTC().Format(http.TimeFormat))
+    }
+}
+
+// contentAddressed reports whether the response serves exactly the content
+// the request named by checksum, which its URL then always refers to.
+// Transformed content, such as a watermarked copy, carries no ETag and so
+// is never content-addressed.
+func (w *cacheResponseWriter) contentAddressed() bool {
+    checksum := w.r.URL.Query().Get(contentChecksumParam)
+    return checksum != "" && w.Header().Get("ETag") == strconv.Quote(checksum)
+}
+
+// contentChanged reports whether the request names content by checksum that
+// is no longer the file's, so a cached URL never serves other content
+func contentChanged(r *http.Request, file *models.File) bool {
+    checksum := r.URL.Query().Get(contentChecksumParam)
+    return checksum != "" && checksum != file.Checksum
+}
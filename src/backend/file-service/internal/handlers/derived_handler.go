@@ -0,0 +1,149 @@
+package handlers
+
+import (
+    "errors"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/authz"
+)
+
+// derivedSegment separates a file ID from the derived object kind in a path
+const derivedSegment = "derived"
+
+// contentsSegment addresses the listing of an archive, the contents derived
+// object, in /files/{id}/contents
+const contentsSegment = "contents"
+
+// DerivedHandler serves GET /files/{id}/derived/{kind}, such as
+// /files/{id}/derived/thumbnail?size=128. Query parameters select the
+// variant and are validated by the kind's generator. It also serves
+// GET /files/{id}/contents, the entry listing of a zip or tar archive.
+// Converted copies are only served by downloads with ?format=, which refuse
+// them where downloads must be watermarked or read through an access point.
+type DerivedHandler struct {
+    derived        service.DerivedObjectService
+    files          fileLookup
+    downloadPolicy DownloadSecurityPolicy
+    authorizer     authz.Authorizer
+    shares         service.AccessReview
+    logger         *zap.Logger
+}
+
+// NewDerivedHandler creates a new DerivedHandler instance. Derived objects
+// reveal their file's content, so they are authorized as downloads of it:
+// authorizer may be nil, in which case any authenticated caller may read
+// them, otherwise files describes their files to the policy, and shares may
+// be nil, in which case share recipients are not recognized by the policy.
+func NewDerivedHandler(derived service.DerivedObjectService, files fileLookup, downloadPolicy DownloadSecurityPolicy,
+    authorizer authz.Authorizer, shares service.AccessReview) *DerivedHandler {
+    return &DerivedHandler{
+        derived:        derived,
+        files:          files,
+        downloadPolicy: downloadPolicy,
+        authorizer:     authorizer,
+        shares:         shares,
+        logger:         zap.L().Named("derived-handler"),
+    }
+}
+
+// IsDerivedPath reports whether path addresses a derived object of a file
+func IsDerivedPath(path string) bool {
+    _, _, ok := derivedFromPath(path)
+    return ok
+}
+
+// ServeHTTP streams a derived object inline, generating it on first request
+func (h *DerivedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    fileID, kind, ok := derivedFromPath(r.URL.Path)
+    if !ok {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+    if kind == models.DerivedKindConverted {
+        writeError(w, r, http.StatusNotFound, "Derived object not available for this file")
+        return
+    }
+
+    ctx := r.Context()
+    attributes := shareAttributes(ctx, r, h.shares, h.logger, fileID)
+    if !authorizeFile(ctx, w, r, h.authorizer, h.files, authz.ActionDownload, fileID, attributes) {
+        return
+    }
+
+    params := make(map[string]string)
+    for key, values := range r.URL.Query() {
+        params[key] = values[0]
+    }
+
+    object, reader, err := h.derived.Get(ctx, fileID, kind, params)
+    if err != nil {
+        h.handleError(w, r, err)
+        return
+    }
+    defer reader.Close()
+
+    w.Header().Set("Content-Type", object.ContentType)
+    w.Header().Set("Content-Disposition", contentDisposition("inline", kind))
+    w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
+    w.Header().Set("Content-Security-Policy", h.downloadPolicy.PreviewCSP)
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.Header().Set("Cross-Origin-Resource-Policy", "same-origin")
+    w.Header().Set("Cache-Control", "private, no-cache")
+
+    if _, err := io.Copy(w, reader); err != nil {
+        h.logger.Error("Failed to stream derived object",
+            zap.String("fileId", fileID),
+            zap.String("kind", kind),
+            zap.Error(err))
+    }
+}
+
+// handleError maps derived object errors to HTTP responses
+func (h *DerivedHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+    switch {
+    case errors.Is(err, service.ErrFileNotFound):
+        writeError(w, r, http.StatusNotFound, "File not found")
+    case errors.Is(err, service.ErrDerivedNotSupported):
+        writeError(w, r, http.StatusNotFound, "Derived object not available for this file")
+    case errors.Is(err, service.ErrInvalidInput):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+    case errors.Is(err, service.ErrDerivedFailed):
+        writeError(w, r, http.StatusUnprocessableEntity, "Derived object could not be generated from this file")
+    case errors.Is(err, service.ErrFileArchived):
+        writeError(w, r, http.StatusConflict, archivedMessage)
+    default:
+        h.logger.Error("Failed to load derived object", zap.Error(err))
+        reportError(r, "Failed to load derived object", err)
+        writeError(w, r, http.StatusInternalServerError, "Failed to load derived object")
+    }
+}
+
+// derivedFromPath extracts {id} and {kind} from /files/{id}/derived/{kind},
+// or {id} and the contents kind from /files/{id}/contents
+func derivedFromPath(path string) (string, string, bool) {
+    rest := strings.TrimPrefix(path, "/files/")
+    if rest == path {
+        return "", "", false
+    }
+
+    parts := strings.Split(rest, "/")
+    if len(parts) == 2 && parts[0] != "" && parts[1] == contentsSegment {
+        return parts[0], models.DerivedKindContents, true
+    }
+    if len(parts) != 3 || parts[0] == "" || parts[1] != derivedSegment || parts[2] == "" {
+        return "", "", false
+    }
+    return parts[0], parts[2], true
+}
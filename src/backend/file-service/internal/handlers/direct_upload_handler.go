@@ -0,0 +1,146 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/url"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// Direct upload paths under /files
+const (
+    presignUploadPath    = "/files/presign-upload"
+    uploadCompleteSuffix = "/upload-complete"
+)
+
+// presignUploadRequest is the body of POST /files/presign-upload
+type presignUploadRequest struct {
+    FileName    string `json:"fileName"`
+    ContentType string `json:"contentType"`
+    Size        int64  `json:"size"`
+    // Checksum is the hex SHA-256 of the content to be uploaded
+    Checksum string `json:"checksum"`
+}
+
+// presignUploadResponse is the pending file and the request uploading its content
+type presignUploadResponse struct {
+    File   *models.File             `json:"file"`
+    Upload *storage.PresignedUpload `json:"upload"`
+}
+
+// DirectUploadHandler serves uploads straight to storage:
+//
+//    POST /files/presign-upload        record a pending file and presign its upload
+//    POST /files/{id}/upload-complete  mark the file uploaded once its content is stored
+type DirectUploadHandler struct {
+    uploads service.DirectUploadService
+    logger  *zap.Logger
+    events  metrics.Counter
+}
+
+// NewDirectUploadHandler creates a new DirectUploadHandler instance. uploads
+// may be nil, in which case direct uploads are not enabled.
+func NewDirectUploadHandler(uploads service.DirectUploadService, metricsProvider metrics.Provider) *DirectUploadHandler {
+    return &DirectUploadHandler{
+        uploads: uploads,
+        logger:  zap.L().Named("direct-upload-handler"),
+        events:  newDirectUploadMetrics(metricsProvider),
+    }
+}
+
+// IsDirectUploadPath reports whether path addresses a direct upload operation
+func IsDirectUploadPath(path string) bool {
+    if path == presignUploadPath {
+        return true
+    }
+    _, ok := fileIDFromPath(path, uploadCompleteSuffix)
+    return ok
+}
+
+// ServeHTTP routes direct upload requests
+func (h *DirectUploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+    if h.uploads == nil {
+        writeError(w, r, http.StatusNotFound, "Direct uploads are not enabled")
+        return
+    }
+
+    if r.URL.Path == presignUploadPath {
+        h.presign(w, r)
+        return
+    }
+    if fileID, ok := fileIDFromPath(r.URL.Path, uploadCompleteSuffix); ok {
+        h.complete(w, r, fileID)
+        return
+    }
+    writeError(w, r, http.StatusNotFound, "Not found")
+}
+
+func (h *DirectUploadHandler) presign(w http.ResponseWriter, r *http.Request) {
+    var req presignUploadRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&req); err != nil {
+        writeError(w, r, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+
+    file, upload, err := h.uploads.Presign(r.Context(), req.FileName, req.ContentType, req.Size, req.Checksum,
+        requestctx.UserID(r.Context()), requestctx.Roles(r.Context()))
+    if err != nil {
+        h.handleError(w, r, err, "Failed to presign upload")
+        return
+    }
+
+    h.events.Inc("presigned", requestctx.Tenant(r.Context()))
+    w.Header().Set("Location", "/files/"+url.PathEscape(file.ID)+uploadCompleteSuffix)
+    writeJSON(w, http.StatusCreated, presignUploadResponse{File: file, Upload: upload})
+}
+
+func (h *DirectUploadHandler) complete(w http.ResponseWriter, r *http.Request, fileID string) {
+    file, err := h.uploads.Complete(r.Context(), fileID, requestctx.UserID(r.Context()))
+    if err != nil {
+        h.handleError(w, r, err, "Failed to complete upload")
+        return
+    }
+
+    h.events.Inc("completed", requestctx.Tenant(r.Context()))
+    writeJSON(w, http.StatusOK, file)
+}
+
+// handleError maps direct upload errors to HTTP responses
+func (h *DirectUploadHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    if status, ok := validationStatus(err); ok {
+        writeValidationError(w, r, status, err)
+        return
+    }
+    switch {
+    case errors.Is(err, service.ErrFileNotFound):
+        writeError(w, r, http.StatusNotFound, "File not found")
+    case errors.Is(err, service.ErrNotAwaitingUpload):
+        writeError(w, r, http.StatusConflict, "File is not awaiting an upload")
+    case errors.Is(err, service.ErrUploadNotReceived):
+        writeError(w, r, http.StatusConflict, "File content has not been uploaded")
+    case errors.Is(err, service.ErrTokenReplayed):
+        writeError(w, r, http.StatusConflict, "Upload was already confirmed")
+    case errors.Is(err, service.ErrVersionConflict):
+        writeError(w, r, http.StatusConflict, "File was modified concurrently; reload it and retry")
+    case errors.Is(err, models.ErrChecksumMismatch):
+        writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+    case errors.Is(err, service.ErrUploadMismatch):
+        writeError(w, r, http.StatusUnprocessableEntity, "Uploaded content type does not match the presigned upload")
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// Outcomes of a streamed download response, counted by download_responses_total
+const (
+    downloadCompleted = "completed"
+    // downloadAborted is a response the client stopped reading mid-stream
+    downloadAborted = "aborted"
+    // downloadFailed is a response the service cut short, such as when
+    // reading the content from storage failed
+    downloadFailed = "failed"
+)
+
+// downloadRecorder wraps the response writer of a download to count the
+// content bytes sent and time the first of them
+type downloadRecorder struct {
+    http.ResponseWriter
+    start     time.Time
+    firstByte time.Duration
+    n         int64
+    // writeErr is the first error writing to the client
+    writeErr error
+}
+
+// newDownloadRecorder wraps w for a download request received at start
+func newDownloadRecorder(w http.ResponseWriter, start time.Time) *downloadRecorder {
+    return &downloadRecorder{ResponseWriter: w, start: start}
+}
+
+func (d *downloadRecorder) Write(p []byte) (int, error) {
+    if d.firstByte == 0 && len(p) > 0 {
+        d.firstByte = time.Since(d.start)
+    }
+    n, err := d.ResponseWriter.Write(p)
+    d.n += int64(n)
+    if err != nil && d.writeErr == nil {
+        d.writeErr = err
+    }
+    return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (d *downloadRecorder) Unwrap() http.ResponseWriter {
+    return d.ResponseWriter
+}
+
+// recordDownload records a download response once its content was
+// streamed, or streaming failed with err, and returns its outcome. expected
+// is the content length the response announced, or -1 if unknown. Clients
+// going away mid-stream are logged here; callers log failures.
+func (h *FileHandler) recordDownload(w http.ResponseWriter, r *http.Request, fileID string, mode string,
+    expected int64, err error) string {
+    outcome := downloadCompleted
+    switch {
+    case err == nil:
+    case errors.Is(r.Context().Err(), context.Canceled):
+        outcome = downloadAborted
+    default:
+        outcome = downloadFailed
+    }
+
+    recorder, ok := w.(*downloadRecorder)
+    if !ok {
+        h.metrics.downloadResponses.Inc(mode, outcome)
+        return outcome
+    }
+    if err != nil && recorder.writeErr != nil {
+        outcome = downloadAborted
+    }
+
+    h.metrics.downloadResponses.Inc(mode, outcome)
+    h.metrics.downloadBytesSent.Add(float64(recorder.n), mode, requestctx.Tenant(r.Context()))
+    if expected >= 0 {
+        h.metrics.downloadBytesExpected.Add(float64(expected), mode)
+    }
+    if recorder.firstByte > 0 {
+        h.metrics.timeToFirstByte.Observe(r.Context(), recorder.firstByte.Seconds(), mode)
+    }
+
+    if outcome == downloadAborted {
+        h.logger.Info("Download aborted by client",
+            zap.String("fileId", fileID),
+            zap.String("mode", mode),
+            zap.Int64("bytesSent", recorder.n),
+            zap.Int64("bytesExpected", expected))
+    }
+    return outcome
+}
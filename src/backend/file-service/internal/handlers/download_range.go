@@ -0,0 +1,154 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// errUnsatisfiableRange is returned for a range outside the file's content
+var errUnsatisfiableRange = errors.New("requested range not satisfiable")
+
+// byteRange is an inclusive range of byte offsets into a file's content
+type byteRange struct {
+    start int64
+    end   int64
+}
+
+func (b byteRange) length() int64 {
+    return b.end - b.start + 1
+}
+
+// contentETag is the strong validator of a file's content, derived from its
+// checksum; files without a checksum have none
+func contentETag(file *models.File) string {
+    if file.Checksum == "" {
+        return ""
+    }
+    return strconv.Quote(file.Checksum)
+}
+
+// requestedRange returns the single byte range requested for file, or nil to
+// serve the whole content. The range is only honoured when If-Range, if
+// given, names the current content, so resuming a download of a file that
+// has since changed restarts it instead of splicing two versions together.
+// Multi-range requests are served whole.
+func requestedRange(r *http.Request, file *models.File) (*byteRange, error) {
+    header := r.Header.Get("Range")
+    if header == "" || !strings.HasPrefix(header, "bytes=") {
+        return nil, nil
+    }
+    if ifRange := r.Header.Get("If-Range"); ifRange != "" {
+        if etag := contentETag(file); etag == "" || ifRange != etag {
+            return nil, nil
+        }
+    }
+
+    spec := strings.TrimPrefix(header, "bytes=")
+    if strings.Contains(spec, ",") {
+        return nil, nil
+    }
+    first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+    if !ok {
+        return nil, errUnsatisfiableRange
+    }
+
+    size := file.Size
+    var rng byteRange
+    if first == "" {
+        // Suffix range: the last n bytes
+        n, err := strconv.ParseInt(last, 10, 64)
+        if err != nil || n <= 0 {
+            return nil, errUnsatisfiableRange
+        }
+        if n > size {
+            n = size
+        }
+        rng = byteRange{start: size - n, end: size - 1}
+    } else {
+        start, err := strconv.ParseInt(first, 10, 64)
+        if err != nil || start < 0 {
+            return nil, errUnsatisfiableRange
+        }
+        end := size - 1
+        if last != "" {
+            if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+                return nil, errUnsatisfiableRange
+            }
+            if end > size-1 {
+                end = size - 1
+            }
+        }
+        rng = byteRange{start: start, end: end}
+    }
+
+    if rng.start >= size || rng.length() <= 0 {
+        return nil, errUnsatisfiableRange
+    }
+    return &rng, nil
+}
+
+// storageRange returns the range of a file's content to read from storage
+// for the request, or nil to read the whole content. Watermarked and
+// transformed downloads are made from the whole content, so their ranges are
+// cut from it as they are served.
+func (h *FileHandler) storageRange(ctx context.Context, r *http.Request, fileID,
+    accessPoint string) *storage.ContentRange {
+    if r.Header.Get("Range") == "" || accessPoint != "" {
+        return nil
+    }
+    file, err := h.fileService.Stat(ctx, fileID)
+    if err != nil || h.watermarkRule(r, file) != nil {
+        return nil
+    }
+    rng, err := requestedRange(r, file)
+    if err != nil || rng == nil {
+        return nil
+    }
+    return &storage.ContentRange{Offset: rng.start, Length: rng.length()}
+}
+
+// serveRange streams one byte range of the content as a 206 response. The
+// reader holds the range alone when storage served it; otherwise it streams
+// the content from the start, and the bytes before the range are read and
+// discarded here rather than sent to the client.
+func (h *FileHandler) serveRange(w http.ResponseWriter, r *http.Request, file *models.File, reader io.Reader,
+    rng byteRange, served bool) {
+    skip := rng.start
+    if served {
+        skip = 0
+    }
+    if _, err := io.CopyN(io.Discard, reader, skip); err != nil {
+        h.logger.Error("Failed to seek to requested range",
+            zap.String("fileId", file.ID),
+            zap.Error(err))
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to download file")
+        return
+    }
+
+    w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", rng.start, rng.end, file.Size))
+    w.Header().Set("Content-Length", strconv.FormatInt(rng.length(), 10))
+    w.WriteHeader(http.StatusPartialContent)
+
+    _, err := io.CopyN(w, reader, rng.length())
+    if h.recordDownload(w, r, file.ID, downloadModeRanged, rng.length(), err) == downloadFailed {
+        h.logger.Error("Failed to stream file range",
+            zap.String("fileId", file.ID),
+            zap.Error(err))
+    }
+    if err != nil {
+        return
+    }
+
+    h.metrics.downloads.Inc(downloadModeRanged, requestctx.Tenant(r.Context()))
+}
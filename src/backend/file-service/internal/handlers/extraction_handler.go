@@ -0,0 +1,158 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// extractSegment follows the file ID in extraction paths
+const extractSegment = "extract"
+
+// extractRequest is the optional body of POST /files/{id}/extract
+type extractRequest struct {
+    // Folder is where the archive's entries are extracted to; empty uses
+    // the archive's own folder
+    Folder string `json:"folder"`
+}
+
+// ExtractionHandler serves archive extraction:
+//
+//    POST /files/{id}/extract          expand a zip or tar archive into files
+//    GET  /files/{id}/extract/{jobId}  progress of an extraction
+type ExtractionHandler struct {
+    extractions service.ExtractionService
+    files       fileLookup
+    authorizer  authz.Authorizer
+    logger      *zap.Logger
+}
+
+// NewExtractionHandler creates a new ExtractionHandler instance. extractions
+// may be nil, in which case archive extraction is not enabled.
+func NewExtractionHandler(extractions service.ExtractionService, files fileLookup, authorizer authz.Authorizer) *ExtractionHandler {
+    return &ExtractionHandler{
+        extractions: extractions,
+        files:       files,
+        authorizer:  authorizer,
+        logger:      zap.L().Named("extraction-handler"),
+    }
+}
+
+// IsExtractionPath reports whether path addresses an archive extraction
+func IsExtractionPath(path string) bool {
+    _, _, ok := extractionFromPath(path)
+    return ok
+}
+
+// ServeHTTP routes extraction requests
+func (h *ExtractionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    fileID, jobID, ok := extractionFromPath(r.URL.Path)
+    if !ok {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+    if h.extractions == nil {
+        writeError(w, r, http.StatusNotFound, "Archive extraction is not enabled")
+        return
+    }
+
+    switch {
+    case jobID == "" && r.Method == http.MethodPost:
+        h.start(w, r, fileID)
+    case jobID != "" && r.Method == http.MethodGet:
+        h.get(w, r, fileID, jobID)
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+    }
+}
+
+func (h *ExtractionHandler) start(w http.ResponseWriter, r *http.Request, fileID string) {
+    var req extractRequest
+    err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&req)
+    if err != nil && !errors.Is(err, io.EOF) {
+        writeError(w, r, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+
+    ctx := r.Context()
+    if !authorizeFile(ctx, w, r, h.authorizer, h.files, authz.ActionDownload, fileID, nil) {
+        return
+    }
+
+    job, err := h.extractions.Start(ctx, fileID, service.UploadOptions{
+        Roles:   requestctx.Roles(ctx),
+        OwnerID: requestctx.UserID(ctx),
+        Folder:  req.Folder,
+    })
+    if err != nil {
+        h.handleError(w, r, err, "Failed to start extraction")
+        return
+    }
+
+    w.Header().Set("Location", "/files/"+url.PathEscape(fileID)+"/"+extractSegment+"/"+url.PathEscape(job.ID))
+    writeJSON(w, http.StatusAccepted, job)
+}
+
+func (h *ExtractionHandler) get(w http.ResponseWriter, r *http.Request, fileID, jobID string) {
+    ctx := r.Context()
+    if !authorizeFile(ctx, w, r, h.authorizer, h.files, authz.ActionDownload, fileID, nil) {
+        return
+    }
+
+    job, err := h.extractions.Get(ctx, fileID, jobID)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to load extraction job")
+        return
+    }
+    writeJSON(w, http.StatusOK, job)
+}
+
+// handleError maps extraction errors to HTTP responses
+func (h *ExtractionHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    switch {
+    case errors.Is(err, service.ErrFileNotFound):
+        writeError(w, r, http.StatusNotFound, "File not found")
+    case errors.Is(err, service.ErrExtractionJobNotFound):
+        writeError(w, r, http.StatusNotFound, "Extraction job not found")
+    case errors.Is(err, service.ErrExtractionNotSupported):
+        writeError(w, r, http.StatusUnprocessableEntity, "File is not an archive that can be extracted")
+    case errors.Is(err, service.ErrInvalidInput):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+    case errors.Is(err, service.ErrFileArchived):
+        writeError(w, r, http.StatusConflict, archivedMessage)
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
+
+// extractionFromPath extracts {id} from /files/{id}/extract, and {id} and
+// {jobId} from /files/{id}/extract/{jobId}
+func extractionFromPath(path string) (string, string, bool) {
+    rest := strings.TrimPrefix(path, "/files/")
+    if rest == path {
+        return "", "", false
+    }
+
+    parts := strings.Split(rest, "/")
+    if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] != extractSegment {
+        return "", "", false
+    }
+    if len(parts) == 3 {
+        if parts[2] == "" {
+            return "", "", false
+        }
+        return parts[0], parts[2], true
+    }
+    return parts[0], "", true
+}
@@ -10,42 +10,135 @@ import (
     "io"
     "mime/multipart"
     "net/http"
-    "path/filepath"
+    "net/url"
+    "path"
     "strconv"
+    "strings"
     "time"
 
     "go.uber.org/ratelimit" // v0.2.0
     "go.uber.org/zap"       // v1.24.0
-    "go.uber.org/metrics"   // v0.3.0
 
+    "src/backend/file-service/internal/models"
     "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/buildinfo"
+    "src/backend/file-service/pkg/errtrack"
+    "src/backend/file-service/pkg/i18n"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/thumbnail"
+    "src/backend/file-service/pkg/tracing"
     "src/backend/file-service/pkg/validator"
 )
 
 // Global constants for file handling
 const (
-    maxFileSize           = int64(100 * 1024 * 1024) // 100MB
+    maxMultipartMemory   = int64(32 * 1024 * 1024) // 32MB, larger uploads spill to disk
     defaultPageSize      = 20
     maxRequestsPerSecond = 100
 )
 
-var allowedFileTypes = []string{".pdf", ".doc", ".docx", ".txt"}
+// downloadNotifyJob names the background job telling an owner about a download
+const downloadNotifyJob = "download-notify"
+
+// thumbnailJob names the background job rendering a new file's thumbnails
+const thumbnailJob = "thumbnail-render"
+
+// asyncParam requests an asynchronous upload, answered with 202 Accepted
+// once the content is staged; clients poll /files/{id} for its status
+const asyncParam = "async"
+
+// Client-side encryption headers, accepted on upload and returned on download
+const (
+    encryptionAlgorithmHeader  = "X-Encryption-Algorithm"
+    encryptionWrappedKeyHeader = "X-Encryption-Wrapped-Key"
+    encryptionKeyIDHeader      = "X-Encryption-Key-Id"
+    encryptionIVHeader         = "X-Encryption-Iv"
+    encryptionEscrowHeader     = "X-Encryption-Escrow"
+)
+
+// Customer-provided key (SSE-C) headers, accepted on uploads, downloads and
+// soft deletes. The key is passed on to S3 and never stored.
+const (
+    customerKeyAlgorithmHeader = "X-Encryption-Customer-Algorithm"
+    customerKeyHeader          = "X-Encryption-Customer-Key"
+    customerKeyMD5Header       = "X-Encryption-Customer-Key-Md5"
+)
+
+// tagsHeader carries the tags of an upload, URL query encoded like S3's
+// x-amz-tagging header: key1=value1&key2=value2
+const tagsHeader = "X-File-Tags"
+
+// Integrity headers returned on downloads so clients can verify content end
+// to end. Uploads may declare their content's checksum in X-Checksum-Sha256.
+const (
+    checksumSHA256Header       = "X-Checksum-Sha256"
+    checksumTypeHeader         = "X-Checksum-Type"
+    encryptionServerSideHeader = "X-Encryption-Server-Side"
+    encryptionEnvelopeHeader   = "X-Encryption-Envelope"
+    encryptionKMSKeyIDHeader   = "X-Encryption-Kms-Key-Id"
+)
 
 // FileHandler handles HTTP requests for file operations
 type FileHandler struct {
     fileService     service.FileService
     logger          *zap.Logger
     rateLimiter     ratelimit.Limiter
-    metricsCollector metrics.Collector
+    metrics         fileMetrics
+    downloadPolicy  DownloadSecurityPolicy
+    locks           service.LockService
+    watermarker     *service.Watermarker
+    authorizer      authz.Authorizer
+    shares          service.AccessReview
+    notifications   service.NotificationService
+    objectLambda    *storage.ObjectLambdaRoutes
+    derived         service.DerivedObjectService
+    downloadLinks   service.DirectDownloadService
+    deleteApprovals service.DeleteApprovals
+    archive         service.ArchiveService
+    previewLinks    *service.PreviewLinks
 }
 
-// NewFileHandler creates a new FileHandler instance
-func NewFileHandler(fileService service.FileService, metricsCollector metrics.Collector) *FileHandler {
+// NewFileHandler creates a new FileHandler instance. locks may be nil, in
+// which case file locks are not enforced, watermarker may be nil, in which
+// case downloads are never watermarked, and authorizer may be nil, in which
+// case any authenticated caller may download and delete files. shares may
+// be nil, in which case downloads by share recipients are not tracked,
+// notifications may be nil, in which case owners are not told about downloads,
+// objectLambda may be nil, in which case downloads are read from the bucket,
+// derived may be nil, in which case downloads are never converted and
+// thumbnails are not rendered ahead of their first request, downloadLinks
+// may be nil, in which case presigned downloads are not enabled,
+// deleteApprovals may be nil, in which case hard deletes never wait for
+// approval, archive may be nil, in which case archived files cannot be
+// restored, and previewLinks may be nil, in which case file metadata lists
+// no thumbnails.
+func NewFileHandler(fileService service.FileService, metricsProvider metrics.Provider, downloadPolicy DownloadSecurityPolicy,
+    locks service.LockService, watermarker *service.Watermarker, authorizer authz.Authorizer,
+    shares service.AccessReview, notifications service.NotificationService,
+    objectLambda *storage.ObjectLambdaRoutes, derived service.DerivedObjectService,
+    downloadLinks service.DirectDownloadService, deleteApprovals service.DeleteApprovals,
+    archive service.ArchiveService, previewLinks *service.PreviewLinks) *FileHandler {
     return &FileHandler{
         fileService:      fileService,
         logger:          zap.L().Named("file-handler"),
         rateLimiter:     ratelimit.New(maxRequestsPerSecond),
-        metricsCollector: metricsCollector,
+        metrics:         newFileMetrics(metricsProvider),
+        downloadPolicy:  downloadPolicy,
+        locks:           locks,
+        watermarker:     watermarker,
+        authorizer:      authorizer,
+        shares:          shares,
+        notifications:   notifications,
+        objectLambda:    objectLambda,
+        derived:         derived,
+        downloadLinks:   downloadLinks,
+        deleteApprovals: deleteApprovals,
+        archive:         archive,
+        previewLinks:    previewLinks,
     }
 }
 
@@ -57,20 +150,20 @@ func (h *FileHandler) UploadHandler(w http.ResponseWriter, r *http.Request) {
     // Start metrics tracking
     start := time.Now()
     defer func() {
-        h.metricsCollector.Timing("file.upload.duration", time.Since(start))
+        h.metrics.durations.Observe(r.Context(), time.Since(start).Seconds(), "upload")
     }()
 
     // Validate request method
     if r.Method != http.MethodPost {
-        h.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
         return
     }
 
-    // Parse multipart form with size limit
-    if err := r.ParseMultipartForm(maxFileSize); err != nil {
+    // Parse multipart form, buffering large files on disk
+    if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
         h.logger.Error("Failed to parse multipart form",
             zap.Error(err))
-        h.sendError(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+        h.sendError(w, r, http.StatusBadRequest, "Invalid request: "+err.Error())
         return
     }
 
@@ -78,49 +171,105 @@ func (h *FileHandler) UploadHandler(w http.ResponseWriter, r *http.Request) {
     if err != nil {
         h.logger.Error("Failed to get file from form",
             zap.Error(err))
-        h.sendError(w, http.StatusBadRequest, "Failed to get file from request")
+        h.sendError(w, r, http.StatusBadRequest, "Failed to get file from request")
         return
     }
     defer file.Close()
 
-    // Validate file size
-    if header.Size > maxFileSize {
-        h.logger.Warn("File size exceeds limit",
-            zap.Int64("size", header.Size),
-            zap.Int64("maxSize", maxFileSize))
-        h.sendError(w, http.StatusBadRequest, "File size exceeds maximum allowed size")
+    // Create context with timeout
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+    ctx, ok := h.withCustomerKey(ctx, w, r)
+    if !ok {
         return
     }
 
-    // Validate file type
-    ext := filepath.Ext(header.Filename)
-    if !isAllowedFileType(ext) {
-        h.logger.Warn("Invalid file type",
-            zap.String("filename", header.Filename),
-            zap.String("extension", ext))
-        h.sendError(w, http.StatusBadRequest, "File type not allowed")
+    opts := uploadOptionsFromRequest(r)
+    if opts.Tags, err = parseTags(r.Header.Get(tagsHeader)); err != nil {
+        h.sendError(w, r, http.StatusBadRequest, "Invalid tags header")
         return
     }
 
-    // Create context with timeout
-    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-    defer cancel()
-
-    // Upload file
-    uploadedFile, err := h.fileService.Upload(ctx, header.Filename, header.Header.Get("Content-Type"), header.Size, file)
+    // Upload file; type and size limits come from the caller's upload policy
+    upload := h.fileService.Upload
+    async := r.URL.Query().Get(asyncParam) == "true"
+    if async {
+        upload = h.fileService.UploadAsync
+    }
+    uploadedFile, err := upload(ctx, header.Filename, header.Header.Get("Content-Type"), header.Size, file, opts)
     if err != nil {
+        if sendNameConflictError(w, r, err) {
+            return
+        }
+        if status, ok := validationStatus(err); ok {
+            writeValidationError(w, r, status, err)
+            return
+        }
+        if errors.Is(err, service.ErrUploadInterrupted) {
+            h.sendError(w, r, http.StatusBadRequest, "Upload interrupted")
+            return
+        }
         h.logger.Error("Failed to upload file",
             zap.String("filename", header.Filename),
             zap.Error(err))
-        h.sendError(w, http.StatusInternalServerError, "Failed to upload file")
+        reportError(r, "Failed to upload file", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to upload file")
         return
     }
 
     // Increment upload counter
-    h.metricsCollector.Counter("file.upload.count").Inc(1)
+    h.metrics.operations.Inc("upload", requestctx.Tenant(r.Context()))
+    if async {
+        w.Header().Set("Location", "/files/"+url.PathEscape(uploadedFile.ID))
+        h.sendJSON(w, http.StatusAccepted, uploadedFile)
+        return
+    }
+    h.renderThumbnails(r, uploadedFile)
 
     // Send success response
-    h.sendJSON(w, http.StatusCreated, uploadedFile)
+    h.sendJSON(w, http.StatusCreated, h.withThumbnails(uploadedFile))
+}
+
+// IsFilePath reports whether path addresses a file itself, /files/{id}
+func IsFilePath(path string) bool {
+    _, ok := fileIDFromPath(path, "")
+    return ok
+}
+
+// FileStatusHandler handles GET /files/{id}, returning the file's metadata
+// and status. Clients poll it after an asynchronous upload until the status
+// is uploaded or failed.
+func (h *FileHandler) FileStatusHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+    fileID, ok := fileIDFromPath(r.URL.Path, "")
+    if !ok {
+        h.sendError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+
+    file, err := h.fileService.UploadStatus(r.Context(), fileID, requestctx.UserID(r.Context()))
+    if err != nil {
+        if errors.Is(err, service.ErrFileNotFound) || errors.Is(err, service.ErrInvalidInput) {
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+            return
+        }
+        h.logger.Error("Failed to get file status",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+        reportError(r, "Failed to get file status", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to get file status")
+        return
+    }
+    // Pending files are authorized on their metadata too, which Stat hides
+    attributes := shareAttributes(r.Context(), r, h.shares, h.logger, fileID)
+    if !authorize(w, r, h.authorizer, authz.ActionDownload, fileResource(file), attributes) {
+        return
+    }
+
+    h.sendJSON(w, http.StatusOK, h.withThumbnails(file))
 }
 
 // DownloadHandler handles file download requests
@@ -129,51 +278,502 @@ func (h *FileHandler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
 
     start := time.Now()
     defer func() {
-        h.metricsCollector.Timing("file.download.duration", time.Since(start))
+        h.metrics.durations.Observe(r.Context(), time.Since(start).Seconds(), "download")
     }()
+    // Count the content bytes sent, so clients aborting downloads show
+    w = newDownloadRecorder(w, start)
 
-    if r.Method != http.MethodGet {
-        h.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+    if r.Method != http.MethodGet && r.Method != http.MethodHead {
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
         return
     }
 
     fileID := r.URL.Query().Get("id")
     if fileID == "" {
-        h.sendError(w, http.StatusBadRequest, "File ID is required")
+        h.sendError(w, r, http.StatusBadRequest, "File ID is required")
         return
     }
 
     ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
     defer cancel()
+    ctx, ok := h.withCustomerKey(ctx, w, r)
+    if !ok {
+        return
+    }
+
+    attributes := shareAttributes(ctx, r, h.shares, h.logger, fileID)
+    if !authorizeFile(ctx, w, r, h.authorizer, h.fileService, authz.ActionDownload, fileID, attributes) {
+        return
+    }
+
+    // Downloads of tenants with an Object Lambda access point are
+    // transformed in AWS on the way out
+    accessPoint := h.objectLambda.AccessPointFor(requestctx.Tenant(r.Context()))
+
+    // ?format= converts the content to a canonical format, such as PDF
+    if format := r.URL.Query().Get("format"); format != "" {
+        h.serveConverted(ctx, w, r, fileID, format, accessPoint)
+        return
+    }
+
+    // HEAD returns the download headers, including integrity metadata, without content
+    if r.Method == http.MethodHead {
+        file, err := h.fileService.Stat(ctx, fileID)
+        if err != nil {
+            if errors.Is(err, service.ErrFileNotFound) {
+                w.WriteHeader(http.StatusNotFound)
+                return
+            }
+            h.logger.Error("Failed to read file metadata",
+                zap.String("fileId", fileID),
+                zap.Error(err))
+            reportError(r, "Failed to read file metadata", err)
+            w.WriteHeader(http.StatusInternalServerError)
+            return
+        }
+        if contentChanged(r, file) {
+            w.WriteHeader(http.StatusPreconditionFailed)
+            return
+        }
+        h.setDownloadHeaders(w, file, false)
+        if h.watermarkRule(r, file) != nil || accessPoint != "" {
+            // The stamped or transformed content is produced on GET, so its
+            // size and checksum are unknown here
+            clearContentHeaders(w)
+        }
+        w.WriteHeader(http.StatusOK)
+        return
+    }
+
+    // The sanitized preview is made from the stored content, so tenants whose
+    // downloads are transformed never get it
+    inline := r.URL.Query().Get("inline") == "true"
+    if inline && h.downloadPolicy.InlinePreviewEnabled && accessPoint == "" && h.servePreview(ctx, w, fileID) {
+        return
+    }
+
+    // A requested range is read from storage alone, so seeking in a video or
+    // PDF reads only what is shown
+    downloadCtx := storage.WithObjectLambda(ctx, accessPoint)
+    contentRange := h.storageRange(ctx, r, fileID, accessPoint)
+    if contentRange != nil {
+        downloadCtx = storage.WithContentRange(downloadCtx, contentRange)
+    }
 
-    file, reader, err := h.fileService.Download(ctx, fileID)
+    file, reader, err := h.fileService.Download(downloadCtx, fileID)
     if err != nil {
         if errors.Is(err, service.ErrFileNotFound) {
-            h.sendError(w, http.StatusNotFound, "File not found")
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+            return
+        }
+        if errors.Is(err, service.ErrFileArchived) {
+            h.sendError(w, r, http.StatusConflict, archivedMessage)
+            return
+        }
+        if h.sendCustomerKeyError(w, r, err) {
             return
         }
         h.logger.Error("Failed to download file",
             zap.String("fileId", fileID),
             zap.Error(err))
-        h.sendError(w, http.StatusInternalServerError, "Failed to download file")
+        reportError(r, "Failed to download file", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to download file")
         return
     }
     defer reader.Close()
 
+    // A download naming its content by checksum may be cached for good, so it
+    // must never be answered with other content
+    if contentChanged(r, file) {
+        h.sendError(w, r, http.StatusPreconditionFailed, "File content has changed")
+        return
+    }
+
+    if rule := h.watermarkRule(r, file); rule != nil {
+        h.notifyDownload(r, file)
+        h.serveWatermarked(w, r, rule, file, reader, inline)
+        return
+    }
+
+    if accessPoint != "" {
+        h.notifyDownload(r, file)
+        h.serveTransformed(w, r, file, reader, inline)
+        return
+    }
+
+    rng, err := requestedRange(r, file)
+    if err != nil {
+        w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", file.Size))
+        h.sendError(w, r, http.StatusRequestedRangeNotSatisfiable, "Requested range not satisfiable")
+        return
+    }
+    // The range read from storage was resolved against the content before
+    // the download; if that content has since changed, it is another range
+    served := contentRange != nil && contentRange.Served
+    if served && (rng == nil || rng.start != contentRange.Offset || rng.length() != contentRange.Length) {
+        h.sendError(w, r, http.StatusPreconditionFailed, "File content has changed")
+        return
+    }
+
+    // Resuming an interrupted download is not another download
+    if rng == nil || rng.start == 0 {
+        h.notifyDownload(r, file)
+    }
+
     // Set response headers
-    w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", file.FileName))
-    w.Header().Set("Content-Type", file.ContentType)
-    w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+    h.setDownloadHeaders(w, file, inline)
+
+    // Resume an interrupted download from the requested offset
+    if rng != nil {
+        h.serveRange(w, r, file, reader, *rng, served)
+        return
+    }
 
     // Stream file content
-    if _, err := io.Copy(w, reader); err != nil {
+    _, err = io.Copy(w, reader)
+    if h.recordDownload(w, r, fileID, downloadModeFull, file.Size, err) == downloadFailed {
         h.logger.Error("Failed to stream file content",
             zap.String("fileId", fileID),
             zap.Error(err))
+    }
+    if err != nil {
         return
     }
 
-    h.metricsCollector.Counter("file.download.count").Inc(1)
+    h.metrics.downloads.Inc(downloadModeFull, requestctx.Tenant(r.Context()))
+}
+
+// DownloadURLHandler handles GET /download-url?id=, returning a short-lived
+// presigned URL downloading the file straight from storage. Downloads the
+// service must transform, by watermarking or through an Object Lambda access
+// point, are refused, as the URL would bypass the transformation.
+func (h *FileHandler) DownloadURLHandler(w http.ResponseWriter, r *http.Request) {
+    h.rateLimiter.Take()
+
+    if r.Method != http.MethodGet {
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+    if h.downloadLinks == nil {
+        h.sendError(w, r, http.StatusNotFound, "Presigned downloads are not enabled")
+        return
+    }
+
+    fileID := r.URL.Query().Get("id")
+    if fileID == "" {
+        h.sendError(w, r, http.StatusBadRequest, "File ID is required")
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    attributes := shareAttributes(ctx, r, h.shares, h.logger, fileID)
+    if !authorizeFile(ctx, w, r, h.authorizer, h.fileService, authz.ActionDownload, fileID, attributes) {
+        return
+    }
+
+    file, err := h.fileService.Stat(ctx, fileID)
+    if err != nil {
+        if errors.Is(err, service.ErrFileNotFound) {
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+            return
+        }
+        h.logger.Error("Failed to read file metadata",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+        reportError(r, "Failed to read file metadata", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to presign download")
+        return
+    }
+
+    if h.watermarkRule(r, file) != nil || file.UsesCustomerKey() ||
+        h.objectLambda.AccessPointFor(requestctx.Tenant(r.Context())) != "" {
+        h.sendError(w, r, http.StatusConflict, "File must be downloaded through the service")
+        return
+    }
+
+    download, err := h.downloadLinks.Presign(ctx, file, service.DownloadLinkRequest{
+        RequestedBy: requestctx.UserID(r.Context()),
+        IP:          clientIP(r),
+        ContentType: h.downloadPolicy.contentType(file),
+        Disposition: contentDisposition("attachment", file.FileName),
+    })
+    if err != nil {
+        h.logger.Error("Failed to presign download",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+        reportError(r, "Failed to presign download", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to presign download")
+        return
+    }
+
+    // Issuing the URL is the last the service sees of the download
+    h.notifyDownload(r, file)
+    h.metrics.downloads.Inc(downloadModePresigned, requestctx.Tenant(r.Context()))
+
+    w.Header().Set("Cache-Control", "no-store")
+    h.sendJSON(w, http.StatusOK, download)
+}
+
+// notifyDownload tells the owner of file about its download by the caller in
+// the background, as a job linked to the trace of the request
+func (h *FileHandler) notifyDownload(r *http.Request, file *models.File) {
+    if h.notifications == nil || file.OwnerID == "" {
+        return
+    }
+
+    jobCtx, job := tracing.StartJob(context.WithoutCancel(r.Context()), downloadNotifyJob)
+    done := telemetry.TrackJob(jobCtx, job.Name)
+    event := service.DownloadEvent{
+        FileID:       file.ID,
+        FileName:     file.FileName,
+        Folder:       file.Folder,
+        OwnerID:      file.OwnerID,
+        DownloadedBy: requestctx.UserID(r.Context()),
+        IP:           clientIP(r),
+        DownloadedAt: time.Now().UTC(),
+    }
+    go func() {
+        err := h.notifications.NotifyDownload(jobCtx, event)
+        if err != nil {
+            h.logger.Warn("Failed to notify file owner of download",
+                append(job.Fields(), zap.String("fileId", file.ID), zap.Error(err))...)
+        }
+        done(err)
+    }()
+}
+
+// withThumbnails returns a copy of file describing its thumbnails with
+// preview links, for callers allowed to download it. Metadata is still
+// returned when the links cannot be issued.
+func (h *FileHandler) withThumbnails(file *models.File) *models.File {
+    srcset, err := thumbnailSrcset(h.previewLinks, h.downloadPolicy, file)
+    if err != nil {
+        h.logger.Warn("Failed to issue thumbnail preview links",
+            zap.String("fileId", file.ID),
+            zap.Error(err))
+        return file
+    }
+    described := *file
+    described.ThumbnailSrcset = srcset
+    return &described
+}
+
+// renderThumbnails renders the sizes listed in file's thumbnail srcset in the
+// background, as a job linked to the trace of the request, so clients picking
+// one find it cached
+func (h *FileHandler) renderThumbnails(r *http.Request, file *models.File) {
+    if h.derived == nil || !file.HasThumbnails() {
+        return
+    }
+
+    jobCtx, job := tracing.StartJob(context.WithoutCancel(r.Context()), thumbnailJob)
+    done := telemetry.TrackJob(jobCtx, job.Name)
+    go func() {
+        var err error
+        for _, size := range thumbnail.Sizes {
+            var reader io.ReadCloser
+            _, reader, err = h.derived.Get(jobCtx, file.ID, models.DerivedKindThumbnail, map[string]string{"size": strconv.Itoa(size)})
+            if err != nil {
+                break
+            }
+            reader.Close()
+        }
+        if errors.Is(err, service.ErrDerivedNotSupported) {
+            // Files too large to derive from simply have no thumbnails
+            err = nil
+        }
+        if err != nil {
+            h.logger.Warn("Failed to render thumbnails",
+                append(job.Fields(), zap.String("fileId", file.ID), zap.Error(err))...)
+        }
+        done(err)
+    }()
+}
+
+// watermarkRule returns the watermark the caller's tenant applies to file, or nil
+func (h *FileHandler) watermarkRule(r *http.Request, file *models.File) *service.WatermarkRule {
+    if h.watermarker == nil {
+        return nil
+    }
+    return h.watermarker.RuleFor(file, watermarkViewer(r))
+}
+
+// serveWatermarked stamps the downloader's identity onto the content before
+// sending it. Files that cannot be stamped are refused rather than served
+// unmarked.
+func (h *FileHandler) serveWatermarked(w http.ResponseWriter, r *http.Request, rule *service.WatermarkRule,
+    file *models.File, reader io.Reader, inline bool) {
+    content, err := h.watermarker.Stamp(rule, file, reader, watermarkViewer(r))
+    if err != nil {
+        if errors.Is(err, service.ErrWatermarkTooLarge) {
+            h.sendError(w, r, http.StatusUnprocessableEntity, "File is too large to watermark")
+            return
+        }
+        h.logger.Error("Failed to watermark file",
+            zap.String("fileId", file.ID),
+            zap.Error(err))
+        reportError(r, "Failed to watermark file", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to download file")
+        return
+    }
+
+    h.setDownloadHeaders(w, file, inline)
+    clearContentHeaders(w)
+    w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+    _, err = w.Write(content)
+    if h.recordDownload(w, r, file.ID, downloadModeWatermarked, int64(len(content)), err) == downloadFailed {
+        h.logger.Error("Failed to stream watermarked content",
+            zap.String("fileId", file.ID),
+            zap.Error(err))
+    }
+    if err != nil {
+        return
+    }
+
+    h.metrics.downloads.Inc(downloadModeWatermarked, requestctx.Tenant(r.Context()))
+}
+
+// serveTransformed streams content transformed by an Object Lambda access
+// point. Its size and checksum differ from the stored content's, so it is
+// served whole, without the headers describing the stored content.
+func (h *FileHandler) serveTransformed(w http.ResponseWriter, r *http.Request, file *models.File, reader io.Reader, inline bool) {
+    h.setDownloadHeaders(w, file, inline)
+    clearContentHeaders(w)
+    _, err := io.Copy(w, reader)
+    if h.recordDownload(w, r, file.ID, downloadModeTransformed, -1, err) == downloadFailed {
+        h.logger.Error("Failed to stream transformed content",
+            zap.String("fileId", file.ID),
+            zap.Error(err))
+    }
+    if err != nil {
+        return
+    }
+
+    h.metrics.downloads.Inc(downloadModeTransformed, requestctx.Tenant(r.Context()))
+}
+
+// serveConverted serves the file converted to format, converting it on first
+// request and caching the result as a derived object. Conversions read the
+// stored content, so downloads that must be transformed by an access point
+// or stamped with a watermark are never converted.
+func (h *FileHandler) serveConverted(ctx context.Context, w http.ResponseWriter, r *http.Request,
+    fileID, format, accessPoint string) {
+    if h.derived == nil || accessPoint != "" {
+        h.sendError(w, r, http.StatusNotFound, "Conversion not available for this file")
+        return
+    }
+
+    file, err := h.fileService.Stat(ctx, fileID)
+    if err != nil {
+        if errors.Is(err, service.ErrFileNotFound) {
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+            return
+        }
+        h.logger.Error("Failed to read file metadata",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+        reportError(r, "Failed to read file metadata", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to download file")
+        return
+    }
+    if contentChanged(r, file) {
+        h.sendError(w, r, http.StatusPreconditionFailed, "File content has changed")
+        return
+    }
+    if h.watermarkRule(r, file) != nil {
+        h.sendError(w, r, http.StatusNotFound, "Conversion not available for this file")
+        return
+    }
+
+    object, reader, err := h.derived.Get(ctx, fileID, models.DerivedKindConverted, map[string]string{"format": format})
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrFileNotFound):
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+        case errors.Is(err, service.ErrDerivedNotSupported):
+            h.sendError(w, r, http.StatusNotFound, "Conversion not available for this file")
+        case errors.Is(err, service.ErrInvalidInput):
+            h.sendError(w, r, http.StatusBadRequest, "Unsupported conversion format")
+        case errors.Is(err, service.ErrDerivedFailed):
+            h.sendError(w, r, http.StatusUnprocessableEntity, "File could not be converted")
+        case errors.Is(err, service.ErrFileArchived):
+            h.sendError(w, r, http.StatusConflict, archivedMessage)
+        default:
+            h.logger.Error("Failed to convert file",
+                zap.String("fileId", fileID),
+                zap.String("format", format),
+                zap.Error(err))
+            reportError(r, "Failed to convert file", err)
+            h.sendError(w, r, http.StatusInternalServerError, "Failed to download file")
+        }
+        return
+    }
+    defer reader.Close()
+
+    // The converted copy is served under the downloader's protections as a
+    // file of its own type and name
+    converted := *file
+    converted.ContentType = object.ContentType
+    converted.FileName = strings.TrimSuffix(file.FileName, path.Ext(file.FileName)) + "." + strings.ToLower(format)
+    h.downloadPolicy.apply(w, &converted, r.URL.Query().Get("inline") == "true")
+    w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
+    if r.Method == http.MethodHead {
+        w.WriteHeader(http.StatusOK)
+        return
+    }
+
+    h.notifyDownload(r, file)
+    _, err = io.Copy(w, reader)
+    if h.recordDownload(w, r, fileID, downloadModeConverted, object.Size, err) == downloadFailed {
+        h.logger.Error("Failed to stream converted content",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+    }
+    if err != nil {
+        return
+    }
+
+    h.metrics.downloads.Inc(downloadModeConverted, requestctx.Tenant(ctx))
+}
+
+// watermarkViewer identifies the authenticated downloader
+func watermarkViewer(r *http.Request) service.WatermarkViewer {
+    viewer := service.WatermarkViewer{}
+    if principal, ok := requestctx.PrincipalFrom(r.Context()); ok {
+        viewer.UserID = principal.UserID
+        viewer.Email = principal.Email
+        viewer.TenantID = principal.TenantID
+    }
+    return viewer
+}
+
+// servePreview streams the sanitized preview of a file inline. It returns
+// false without writing anything when no preview exists, so the caller can
+// fall back to the original under the regular download policy.
+func (h *FileHandler) servePreview(ctx context.Context, w http.ResponseWriter, fileID string) bool {
+    file, reader, err := h.fileService.DownloadPreview(ctx, fileID)
+    if err != nil {
+        if !errors.Is(err, service.ErrPreviewNotAvailable) && !errors.Is(err, service.ErrFileNotFound) {
+            h.logger.Warn("Failed to load sanitized preview",
+                zap.String("fileId", fileID),
+                zap.Error(err))
+        }
+        return false
+    }
+    defer reader.Close()
+
+    h.downloadPolicy.applyPreview(w, file)
+    if _, err := io.Copy(w, reader); err != nil {
+        h.logger.Error("Failed to stream preview content",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+        return true
+    }
+
+    h.metrics.operations.Inc("preview", requestctx.Tenant(ctx))
+    return true
 }
 
 // DeleteHandler handles file deletion requests
@@ -182,17 +782,17 @@ func (h *FileHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 
     start := time.Now()
     defer func() {
-        h.metricsCollector.Timing("file.delete.duration", time.Since(start))
+        h.metrics.durations.Observe(r.Context(), time.Since(start).Seconds(), "delete")
     }()
 
     if r.Method != http.MethodDelete {
-        h.sendError(w, http.StatusMethodNotAllowed, "Method not allowed")
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
         return
     }
 
     fileID := r.URL.Query().Get("id")
     if fileID == "" {
-        h.sendError(w, http.StatusBadRequest, "File ID is required")
+        h.sendError(w, r, http.StatusBadRequest, "File ID is required")
         return
     }
 
@@ -201,42 +801,301 @@ func (h *FileHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 
     ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
     defer cancel()
+    // Archiving SSE-C content copies it, which needs the key
+    ctx, ok := h.withCustomerKey(ctx, w, r)
+    if !ok {
+        return
+    }
+
+    if !authorizeFile(ctx, w, r, h.authorizer, h.fileService, authz.ActionDelete, fileID, nil) {
+        return
+    }
+    if !h.checkLock(w, r, fileID) {
+        return
+    }
+
+    // Hard deletes in regulated tenants wait for two admins to approve them
+    if !softDelete && h.deleteApprovals != nil && h.deleteApprovals.Required(ctx) {
+        action, err := h.deleteApprovals.Request(ctx, fileID, requestctx.UserID(ctx))
+        if err != nil {
+            if errors.Is(err, service.ErrFileNotFound) {
+                h.sendError(w, r, http.StatusNotFound, "File not found")
+                return
+            }
+            if errors.Is(err, service.ErrFileRetained) {
+                h.sendError(w, r, http.StatusConflict, "File is under retention and cannot be deleted")
+                return
+            }
+            h.logger.Error("Failed to request delete approval",
+                zap.String("fileId", fileID),
+                zap.Error(err))
+            reportError(r, "Failed to request delete approval", err)
+            h.sendError(w, r, http.StatusInternalServerError, "Failed to request delete approval")
+            return
+        }
+        writeJSON(w, http.StatusAccepted, action)
+        return
+    }
 
     if err := h.fileService.Delete(ctx, fileID, softDelete); err != nil {
         if errors.Is(err, service.ErrFileNotFound) {
-            h.sendError(w, http.StatusNotFound, "File not found")
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+            return
+        }
+        if errors.Is(err, service.ErrFileRetained) {
+            h.sendError(w, r, http.StatusConflict, "File is under retention and cannot be deleted")
+            return
+        }
+        if h.sendCustomerKeyError(w, r, err) {
             return
         }
         h.logger.Error("Failed to delete file",
             zap.String("fileId", fileID),
             zap.Error(err))
-        h.sendError(w, http.StatusInternalServerError, "Failed to delete file")
+        reportError(r, "Failed to delete file", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to delete file")
         return
     }
 
-    h.metricsCollector.Counter("file.delete.count").Inc(1)
+    h.metrics.operations.Inc("delete", requestctx.Tenant(r.Context()))
     w.WriteHeader(http.StatusNoContent)
 }
 
+// CommitHandler promotes a draft upload once the owning record is saved
+func (h *FileHandler) CommitHandler(w http.ResponseWriter, r *http.Request) {
+    h.rateLimiter.Take()
+
+    if r.Method != http.MethodPost {
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    fileID := r.URL.Query().Get("id")
+    if fileID == "" {
+        h.sendError(w, r, http.StatusBadRequest, "File ID is required")
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    file, err := h.fileService.Commit(ctx, fileID)
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrFileNotFound):
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+        case errors.Is(err, models.ErrNotDraft):
+            h.sendError(w, r, http.StatusConflict, "File is not a draft")
+        case errors.Is(err, service.ErrVersionConflict):
+            h.sendError(w, r, http.StatusConflict, "File was modified concurrently; reload it and retry")
+        case errors.Is(err, models.ErrDraftExpired):
+            h.sendError(w, r, http.StatusGone, "Draft has expired")
+        case errors.Is(err, service.ErrDraftsNotEnabled):
+            h.sendError(w, r, http.StatusBadRequest, err.Error())
+        default:
+            h.logger.Error("Failed to commit draft",
+                zap.String("fileId", fileID),
+                zap.Error(err))
+            reportError(r, "Failed to commit draft", err)
+            h.sendError(w, r, http.StatusInternalServerError, "Failed to commit draft")
+        }
+        return
+    }
+
+    h.metrics.operations.Inc("commit", requestctx.Tenant(r.Context()))
+    h.renderThumbnails(r, file)
+    h.sendJSON(w, http.StatusOK, h.withThumbnails(file))
+}
+
 // Helper functions
 
-func (h *FileHandler) sendError(w http.ResponseWriter, status int, message string) {
-    w.Header().Set("Content-Type", "application/json")
-    w.WriteHeader(status)
-    json.NewEncoder(w).Encode(map[string]string{"error": message})
+func (h *FileHandler) sendError(w http.ResponseWriter, r *http.Request, status int, message string) {
+    writeError(w, r, status, message)
 }
 
 func (h *FileHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
+    writeJSON(w, status, data)
+}
+
+// reportError forwards an unexpected handler error to the error tracker
+func reportError(r *http.Request, message string, err error) {
+    errtrack.CaptureError(r.Context(), err, map[string]string{
+        "message": message,
+        "method":  r.Method,
+    })
+}
+
+// writeError writes a JSON error body with the given status. error keeps the
+// English message clients may match on; message is its translation into the
+// language negotiated from Accept-Language, for display.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+    language := responseLanguage(w, r)
+    writeJSON(w, status, map[string]string{
+        "error":   message,
+        "message": i18n.Translate(language, message),
+        "version": buildinfo.Version,
+    })
+}
+
+// responseLanguage negotiates the language of an error response and
+// announces it in the response headers
+func responseLanguage(w http.ResponseWriter, r *http.Request) string {
+    language := i18n.Negotiate(r.Header.Get("Accept-Language"))
+    w.Header().Set("Content-Language", language)
+    w.Header().Add("Vary", "Accept-Language")
+    return language
+}
+
+// validationStatus maps upload validation and policy errors to HTTP statuses
+func validationStatus(err error) (int, bool) {
+    switch {
+    case errors.Is(err, service.ErrInvalidInput):
+        return http.StatusBadRequest, true
+    case errors.Is(err, service.ErrUploadNotPermitted):
+        return http.StatusForbidden, true
+    case errors.Is(err, service.ErrUploadTooLarge), errors.Is(err, service.ErrQuotaExceeded):
+        return http.StatusRequestEntityTooLarge, true
+    case errors.Is(err, service.ErrInvalidChecksum):
+        return http.StatusUnprocessableEntity, true
+    }
+    return 0, false
+}
+
+// writeValidationError writes an error response, including the validation
+// code, which is never translated, when the error carries one
+func writeValidationError(w http.ResponseWriter, r *http.Request, status int, err error) {
+    var validationErr *validator.ValidationError
+    if errors.As(err, &validationErr) {
+        language := responseLanguage(w, r)
+        writeJSON(w, status, map[string]string{
+            "error":   validationErr.Message,
+            "message": i18n.Translate(language, validationErr.Message),
+            "code":    validationErr.Code,
+            "version": buildinfo.Version,
+        })
+        return
+    }
+    writeError(w, r, status, err.Error())
+}
+
+// writeJSON writes data as a JSON body with the given status
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(status)
     json.NewEncoder(w).Encode(data)
 }
 
-func isAllowedFileType(ext string) bool {
-    for _, allowed := range allowedFileTypes {
-        if ext == allowed {
-            return true
+// uploadOptionsFromRequest reads optional upload settings from request
+// headers and query parameters
+func uploadOptionsFromRequest(r *http.Request) service.UploadOptions {
+    opts := service.UploadOptions{
+        Roles:        requestctx.Roles(r.Context()),
+        Draft:        r.URL.Query().Get("draft") == "true",
+        OwnerID:      requestctx.UserID(r.Context()),
+        Checksum:     strings.ToLower(r.Header.Get(checksumSHA256Header)),
+        NameConflict: r.URL.Query().Get(nameConflictParam),
+    }
+
+    if algorithm := r.Header.Get(encryptionAlgorithmHeader); algorithm != "" {
+        opts.Encryption = &models.EncryptionMetadata{
+            Algorithm:  algorithm,
+            WrappedKey: r.Header.Get(encryptionWrappedKeyHeader),
+            KeyID:      r.Header.Get(encryptionKeyIDHeader),
+            IV:         r.Header.Get(encryptionIVHeader),
+        }
+        opts.EscrowKey = r.Header.Get(encryptionEscrowHeader) == "true"
+    }
+
+    return opts
+}
+
+// withCustomerKey attaches the request's customer-provided key, if any, to
+// ctx. It writes a 400 and reports false when the key headers are malformed.
+func (h *FileHandler) withCustomerKey(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, bool) {
+    algorithm, encoded := r.Header.Get(customerKeyAlgorithmHeader), r.Header.Get(customerKeyHeader)
+    if algorithm == "" && encoded == "" {
+        return ctx, true
+    }
+
+    key, err := storage.ParseCustomerKey(algorithm, encoded, r.Header.Get(customerKeyMD5Header))
+    if err != nil {
+        h.sendError(w, r, http.StatusBadRequest, "Invalid customer-provided key")
+        return ctx, false
+    }
+    return storage.WithCustomerKey(ctx, key), true
+}
+
+// sendCustomerKeyError answers errors about customer-provided keys, reporting
+// whether err was one
+func (h *FileHandler) sendCustomerKeyError(w http.ResponseWriter, r *http.Request, err error) bool {
+    switch {
+    case errors.Is(err, service.ErrCustomerKeyRequired):
+        h.sendError(w, r, http.StatusBadRequest, "File is encrypted with a customer-provided key")
+    case errors.Is(err, service.ErrCustomerKeyMismatch):
+        h.sendError(w, r, http.StatusForbidden, "Customer-provided key does not match")
+    default:
+        return false
+    }
+    return true
+}
+
+// setDownloadHeaders sets the headers describing a file's content
+func (h *FileHandler) setDownloadHeaders(w http.ResponseWriter, file *models.File, inline bool) {
+    h.downloadPolicy.apply(w, file, inline)
+    w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+    w.Header().Set("Accept-Ranges", "bytes")
+    if etag := contentETag(file); etag != "" {
+        w.Header().Set("ETag", etag)
+    }
+    setIntegrityHeaders(w, file)
+    setEncryptionHeaders(w, file)
+}
+
+// clearContentHeaders removes the headers describing the stored content, which
+// no longer match once the content is transformed for the downloader
+func clearContentHeaders(w http.ResponseWriter) {
+    w.Header().Del("Content-Length")
+    w.Header().Del("Accept-Ranges")
+    w.Header().Del("ETag")
+    w.Header().Del(checksumSHA256Header)
+    w.Header().Del(checksumTypeHeader)
+}
+
+// setIntegrityHeaders returns the stored checksum and the encryption applied
+// to the content. Chunked uploads carry an S3-style composite checksum,
+// suffixed with the part count, rather than a digest of the whole object.
+func setIntegrityHeaders(w http.ResponseWriter, file *models.File) {
+    if file.Checksum != "" {
+        w.Header().Set(checksumSHA256Header, file.Checksum)
+        checksumType := "FULL_OBJECT"
+        if strings.Contains(file.Checksum, "-") {
+            checksumType = "COMPOSITE"
         }
+        w.Header().Set(checksumTypeHeader, checksumType)
     }
-    return false
-}
\ No newline at end of file
+
+    sse := file.ServerSideEncryptionOrDefault()
+    w.Header().Set(encryptionServerSideHeader, sse.Algorithm)
+    if sse.KMSKeyID != "" {
+        w.Header().Set(encryptionKMSKeyIDHeader, sse.KMSKeyID)
+    }
+    w.Header().Set(encryptionEnvelopeHeader, strconv.FormatBool(file.IsClientEncrypted()))
+}
+
+// setEncryptionHeaders returns the client encryption metadata needed to decrypt
+// the content, including the wrapping key ID for key rotation audits
+func setEncryptionHeaders(w http.ResponseWriter, file *models.File) {
+    if !file.IsClientEncrypted() {
+        return
+    }
+
+    w.Header().Set(encryptionAlgorithmHeader, file.Encryption.Algorithm)
+    w.Header().Set(encryptionWrappedKeyHeader, file.Encryption.WrappedKey)
+    if file.Encryption.KeyID != "" {
+        w.Header().Set(encryptionKeyIDHeader, file.Encryption.KeyID)
+    }
+    if file.Encryption.IV != "" {
+        w.Header().Set(encryptionIVHeader, file.Encryption.IV)
+    }
+    w.Header().Set(encryptionEscrowHeader, strconv.FormatBool(file.Encryption.IsEscrowed()))
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strings"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+const (
+    fileRequestsPath  = "/file-requests"
+    fileRequestUpload = "upload"
+    // maxUploaderNameLength bounds the name an external uploader may give
+    maxUploaderNameLength = 100
+    // multipartOverhead allows for form fields and part headers around the file
+    multipartOverhead = int64(1 << 20)
+)
+
+// createFileRequestRequest is the body of POST /file-requests
+type createFileRequestRequest struct {
+    service.FileRequestOptions
+    // TTLSeconds is how long the link accepts uploads; zero uses the configured default
+    TTLSeconds int64 `json:"ttlSeconds"`
+}
+
+// fileRequestInfo is what an external uploader is shown about a file request
+type fileRequestInfo struct {
+    Title          string    `json:"title"`
+    MaxFileSize    int64     `json:"maxFileSize"`
+    AllowedTypes   []string  `json:"allowedTypes"`
+    RemainingFiles int       `json:"remainingFiles"`
+    ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// receivedFile acknowledges an external upload without exposing the stored file
+type receivedFile struct {
+    FileName   string    `json:"fileName"`
+    Size       int64     `json:"size"`
+    ReceivedAt time.Time `json:"receivedAt"`
+}
+
+// FileRequestHandler serves upload inboxes. Owners manage them with:
+//
+//    GET    /file-requests          list the caller's file requests
+//    POST   /file-requests          create a file request
+//    DELETE /file-requests/{id}     revoke a file request
+//
+// and anyone holding the link uploads without authentication through:
+//
+//    GET    /file-requests/{id}/upload   describe the request's limits
+//    POST   /file-requests/{id}/upload   upload a file (multipart "file", optional "name")
+type FileRequestHandler struct {
+    requests service.FileRequestService
+    logger   *zap.Logger
+}
+
+// NewFileRequestHandler creates a new FileRequestHandler instance
+func NewFileRequestHandler(requests service.FileRequestService) *FileRequestHandler {
+    return &FileRequestHandler{
+        requests: requests,
+        logger:   zap.L().Named("file-request-handler"),
+    }
+}
+
+// IsFileRequestUploadPath reports whether path is the public upload endpoint of a file request
+func IsFileRequestUploadPath(path string) bool {
+    _, ok := fileRequestUploadID(path)
+    return ok
+}
+
+// ServeHTTP routes the owner's file request management
+func (h *FileRequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    id := strings.Trim(strings.TrimPrefix(r.URL.Path, fileRequestsPath), "/")
+
+    switch {
+    case id == "":
+        switch r.Method {
+        case http.MethodGet:
+            h.list(w, r)
+        case http.MethodPost:
+            h.create(w, r)
+        default:
+            writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        }
+    case strings.Contains(id, "/"):
+        writeError(w, r, http.StatusNotFound, "Not found")
+    case r.Method == http.MethodDelete:
+        h.revoke(w, r, id)
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+    }
+}
+
+// PublicUploadHandler serves GET and POST /file-requests/{id}/upload for
+// external uploaders; the request ID in the link is the only credential
+func (h *FileRequestHandler) PublicUploadHandler(w http.ResponseWriter, r *http.Request) {
+    id, ok := fileRequestUploadID(r.URL.Path)
+    if !ok {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+
+    switch r.Method {
+    case http.MethodGet:
+        h.describe(w, r, id)
+    case http.MethodPost:
+        h.upload(w, r, id)
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+    }
+}
+
+func (h *FileRequestHandler) list(w http.ResponseWriter, r *http.Request) {
+    requests, err := h.requests.List(r.Context(), requestctx.UserID(r.Context()))
+    if err != nil {
+        h.handleError(w, r, err, "Failed to list file requests")
+        return
+    }
+    if requests == nil {
+        requests = []*models.FileRequest{}
+    }
+    writeJSON(w, http.StatusOK, requests)
+}
+
+func (h *FileRequestHandler) create(w http.ResponseWriter, r *http.Request) {
+    var req createFileRequestRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&req); err != nil {
+        writeError(w, r, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+    opts := req.FileRequestOptions
+    opts.TTL = time.Duration(req.TTLSeconds) * time.Second
+
+    request, err := h.requests.Create(r.Context(), requestctx.UserID(r.Context()),
+        requestctx.Roles(r.Context()), opts)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to create file request")
+        return
+    }
+    writeJSON(w, http.StatusCreated, request)
+}
+
+func (h *FileRequestHandler) revoke(w http.ResponseWriter, r *http.Request, id string) {
+    if err := h.requests.Revoke(r.Context(), id, requestctx.UserID(r.Context())); err != nil {
+        h.handleError(w, r, err, "Failed to revoke file request")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *FileRequestHandler) describe(w http.ResponseWriter, r *http.Request, id string) {
+    request, err := h.requests.Get(r.Context(), id)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to get file request")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, fileRequestInfo{
+        Title:          request.Title,
+        MaxFileSize:    request.MaxFileSize,
+        AllowedTypes:   request.AllowedTypes,
+        RemainingFiles: request.MaxFiles - request.UploadCount,
+        ExpiresAt:      request.ExpiresAt,
+    })
+}
+
+func (h *FileRequestHandler) upload(w http.ResponseWriter, r *http.Request, id string) {
+    // Fail fast on dead links before reading the body, which is bounded by
+    // the request's size limit since the uploader is anonymous
+    request, err := h.requests.Get(r.Context(), id)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to get file request")
+        return
+    }
+    r.Body = http.MaxBytesReader(w, r.Body, request.MaxFileSize+multipartOverhead)
+
+    if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+        writeError(w, r, http.StatusBadRequest, "Invalid request: "+err.Error())
+        return
+    }
+    file, header, err := r.FormFile("file")
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, "Failed to get file from request")
+        return
+    }
+    defer file.Close()
+
+    uploader := strings.TrimSpace(r.FormValue("name"))
+    if len(uploader) > maxUploaderNameLength {
+        writeError(w, r, http.StatusBadRequest, "Name is too long")
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    uploaded, err := h.requests.Upload(ctx, id, header.Filename, header.Header.Get("Content-Type"), header.Size,
+        file, uploader)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to upload file")
+        return
+    }
+
+    writeJSON(w, http.StatusCreated, receivedFile{
+        FileName:   uploaded.FileName,
+        Size:       uploaded.Size,
+        ReceivedAt: uploaded.CreatedAt,
+    })
+}
+
+// handleError maps file request errors to HTTP responses
+func (h *FileRequestHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    if status, ok := validationStatus(err); ok {
+        writeValidationError(w, r, status, err)
+        return
+    }
+
+    switch {
+    case errors.Is(err, service.ErrFileRequestNotFound):
+        writeError(w, r, http.StatusNotFound, "File request not found")
+    case errors.Is(err, models.ErrFileRequestClosed):
+        writeError(w, r, http.StatusGone, "File request is closed")
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
+
+// fileRequestUploadID extracts {id} from /file-requests/{id}/upload
+func fileRequestUploadID(path string) (string, bool) {
+    rest := strings.TrimPrefix(path, fileRequestsPath+"/")
+    if rest == path {
+        return "", false
+    }
+
+    id, suffix, ok := strings.Cut(rest, "/")
+    if !ok || id == "" || suffix != fileRequestUpload {
+        return "", false
+    }
+    return id, true
+}
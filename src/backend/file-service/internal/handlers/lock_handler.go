@@ -0,0 +1,172 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "strings"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// Lock path suffixes under /files/{id}
+const (
+    lockSuffix   = "/lock"
+    unlockSuffix = "/unlock"
+)
+
+// LockHandler serves the file checkout API:
+//
+//    GET  /files/{id}/lock               current lock
+//    POST /files/{id}/lock               take or extend the caller's lock
+//    POST /files/{id}/unlock             release the caller's lock
+//    POST /files/{id}/unlock?force=true  break anyone's lock (admin)
+type LockHandler struct {
+    locks  service.LockService
+    logger *zap.Logger
+}
+
+// lockRequest is the optional body of POST /files/{id}/lock
+type lockRequest struct {
+    // TTLSeconds is the lock duration; zero uses the configured default
+    TTLSeconds int64 `json:"ttlSeconds"`
+}
+
+// NewLockHandler creates a new LockHandler instance
+func NewLockHandler(locks service.LockService) *LockHandler {
+    return &LockHandler{
+        locks:  locks,
+        logger: zap.L().Named("lock-handler"),
+    }
+}
+
+// IsLockPath reports whether path addresses the lock of a file
+func IsLockPath(path string) bool {
+    return strings.HasSuffix(path, lockSuffix) || strings.HasSuffix(path, unlockSuffix)
+}
+
+// ServeHTTP routes lock requests
+func (h *LockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if fileID, ok := fileIDFromPath(r.URL.Path, lockSuffix); ok {
+        switch r.Method {
+        case http.MethodGet:
+            h.get(w, r, fileID)
+        case http.MethodPost:
+            h.lock(w, r, fileID)
+        default:
+            writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        }
+        return
+    }
+
+    if fileID, ok := fileIDFromPath(r.URL.Path, unlockSuffix); ok {
+        if r.Method != http.MethodPost {
+            writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+            return
+        }
+        h.unlock(w, r, fileID)
+        return
+    }
+
+    writeError(w, r, http.StatusNotFound, "Not found")
+}
+
+func (h *LockHandler) get(w http.ResponseWriter, r *http.Request, fileID string) {
+    lock, err := h.locks.Get(r.Context(), fileID)
+    if err != nil {
+        h.handleError(w, r, err, nil, "Failed to get lock")
+        return
+    }
+    writeJSON(w, http.StatusOK, lock)
+}
+
+func (h *LockHandler) lock(w http.ResponseWriter, r *http.Request, fileID string) {
+    var req lockRequest
+    err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&req)
+    if err != nil && !errors.Is(err, io.EOF) {
+        writeError(w, r, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+
+    ttl := time.Duration(req.TTLSeconds) * time.Second
+    lock, err := h.locks.Lock(r.Context(), fileID, requestctx.UserID(r.Context()), ttl)
+    if err != nil {
+        h.handleError(w, r, err, lock, "Failed to lock file")
+        return
+    }
+    writeJSON(w, http.StatusOK, lock)
+}
+
+func (h *LockHandler) unlock(w http.ResponseWriter, r *http.Request, fileID string) {
+    force := r.URL.Query().Get("force") == "true"
+    if force && !requestctx.HasRole(r.Context(), middleware.AdminRole) {
+        writeError(w, r, http.StatusForbidden, "Only administrators can break locks")
+        return
+    }
+
+    if err := h.locks.Unlock(r.Context(), fileID, requestctx.UserID(r.Context()), force); err != nil {
+        lock, _ := h.locks.Get(r.Context(), fileID)
+        h.handleError(w, r, err, lock, "Failed to unlock file")
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// handleError maps lock service errors to HTTP responses
+func (h *LockHandler) handleError(w http.ResponseWriter, r *http.Request, err error, lock *models.FileLock, message string) {
+    switch {
+    case errors.Is(err, service.ErrFileLocked):
+        writeLocked(w, r, lock)
+    case errors.Is(err, service.ErrLockNotFound):
+        writeError(w, r, http.StatusNotFound, "File is not locked")
+    case errors.Is(err, service.ErrFileNotFound):
+        writeError(w, r, http.StatusNotFound, "File not found")
+    case errors.Is(err, service.ErrInvalidInput):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
+
+// checkLock responds 423 Locked and returns false if another user holds the
+// file's lock
+func (h *FileHandler) checkLock(w http.ResponseWriter, r *http.Request, fileID string) bool {
+    if h.locks == nil {
+        return true
+    }
+
+    lock, err := h.locks.CheckWrite(r.Context(), fileID, requestctx.UserID(r.Context()))
+    if errors.Is(err, service.ErrFileLocked) {
+        writeLocked(w, r, lock)
+        return false
+    }
+    if err != nil {
+        h.logger.Error("Failed to check file lock",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+        reportError(r, "Failed to check file lock", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to check file lock")
+        return false
+    }
+    return true
+}
+
+// writeLocked responds 423 Locked, naming the lock holder when known
+func writeLocked(w http.ResponseWriter, r *http.Request, lock *models.FileLock) {
+    if lock == nil {
+        writeError(w, r, http.StatusLocked, "File is locked by another user")
+        return
+    }
+    writeError(w, r, http.StatusLocked, fmt.Sprintf("File is locked by %s until %s",
+        lock.OwnerID, lock.ExpiresAt.UTC().Format(time.RFC3339)))
+}
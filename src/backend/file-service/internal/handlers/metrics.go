@@ -0,0 +1,131 @@
+package handlers
+
+import (
+    "src/backend/file-service/pkg/metrics"
+)
+
+// Ways a download is served, counted by file_downloads_total
+const (
+    downloadModeFull        = "full"
+    downloadModeRanged      = "ranged"
+    downloadModeWatermarked = "watermarked"
+    downloadModeTransformed = "transformed"
+    downloadModeConverted   = "converted"
+    downloadModePresigned   = "presigned"
+)
+
+// fileMetrics are the instruments of FileHandler. Operations, downloads
+// and bytes sent take the tenant ID last, so they can be labelled by tenant.
+type fileMetrics struct {
+    // operations counts completed file operations, such as upload or delete
+    operations metrics.Counter
+    // durations observes the handling time of uploads, downloads and deletes
+    durations metrics.Histogram
+    // downloads counts downloads by how they were served
+    downloads metrics.Counter
+    // downloadResponses counts streamed download responses by mode and
+    // outcome: completed, aborted by the client, or failed
+    downloadResponses metrics.Counter
+    // downloadBytesSent and downloadBytesExpected count the content bytes
+    // sent against those the responses should have carried, by mode
+    downloadBytesSent     metrics.Counter
+    downloadBytesExpected metrics.Counter
+    // timeToFirstByte observes the time from receiving a download request
+    // to sending the first content byte, by mode
+    timeToFirstByte metrics.Histogram
+}
+
+// newFileMetrics creates the instruments of FileHandler
+func newFileMetrics(provider metrics.Provider) fileMetrics {
+    provider = metrics.OrNop(provider)
+    return fileMetrics{
+        operations: provider.Counter(metrics.Opts{
+            Name:   "file_operations_total",
+            Help:   "Completed file operations by operation",
+            Labels: []string{"operation"},
+            Tenant: true,
+        }),
+        durations: provider.Histogram(metrics.HistogramOpts{
+            Opts: metrics.Opts{
+                Name:   "file_operation_duration_seconds",
+                Help:   "Time taken to handle file operations by operation",
+                Labels: []string{"operation"},
+            },
+        }),
+        downloads: provider.Counter(metrics.Opts{
+            Name:   "file_downloads_total",
+            Help:   "Completed downloads by how they were served",
+            Labels: []string{"mode"},
+            Tenant: true,
+        }),
+        downloadResponses: provider.Counter(metrics.Opts{
+            Name:   "download_responses_total",
+            Help:   "Streamed download responses by mode and outcome",
+            Labels: []string{"mode", "outcome"},
+        }),
+        downloadBytesSent: provider.Counter(metrics.Opts{
+            Name:   "download_bytes_sent_total",
+            Help:   "Content bytes sent in download responses by mode",
+            Labels: []string{"mode"},
+            Tenant: true,
+        }),
+        downloadBytesExpected: provider.Counter(metrics.Opts{
+            Name:   "download_bytes_expected_total",
+            Help:   "Content bytes download responses of a known length should have sent, by mode",
+            Labels: []string{"mode"},
+        }),
+        timeToFirstByte: provider.Histogram(metrics.HistogramOpts{
+            Opts: metrics.Opts{
+                Name:   "download_time_to_first_byte_seconds",
+                Help:   "Time from receiving a download request to sending the first content byte by mode",
+                Labels: []string{"mode"},
+            },
+            Buckets: metrics.ExponentialBuckets(0.005, 2, 12),
+        }),
+    }
+}
+
+// uploadSessionMetrics are the instruments of UploadSessionHandler
+type uploadSessionMetrics struct {
+    // sessions counts session lifecycle events: initiated, completed,
+    // aborted, and tenant
+    sessions metrics.Counter
+    // chunks counts the chunks received
+    chunks metrics.Counter
+    // chunkDurations observes the handling time of chunk uploads
+    chunkDurations metrics.Histogram
+}
+
+// newUploadSessionMetrics creates the instruments of UploadSessionHandler
+func newUploadSessionMetrics(provider metrics.Provider) uploadSessionMetrics {
+    provider = metrics.OrNop(provider)
+    return uploadSessionMetrics{
+        sessions: provider.Counter(metrics.Opts{
+            Name:   "upload_sessions_total",
+            Help:   "Resumable upload sessions by event",
+            Labels: []string{"event"},
+            Tenant: true,
+        }),
+        chunks: provider.Counter(metrics.Opts{
+            Name: "upload_session_chunks_total",
+            Help: "Chunks received by resumable upload sessions",
+        }),
+        chunkDurations: provider.Histogram(metrics.HistogramOpts{
+            Opts: metrics.Opts{
+                Name: "upload_session_chunk_duration_seconds",
+                Help: "Time taken to receive a chunk of a resumable upload",
+            },
+        }),
+    }
+}
+
+// newDirectUploadMetrics creates the counter of DirectUploadHandler, which
+// counts presigned direct uploads by event: presigned, completed, and tenant
+func newDirectUploadMetrics(provider metrics.Provider) metrics.Counter {
+    return metrics.OrNop(provider).Counter(metrics.Opts{
+        Name:   "direct_uploads_total",
+        Help:   "Presigned direct uploads by event",
+        Labels: []string{"event"},
+        Tenant: true,
+    })
+}
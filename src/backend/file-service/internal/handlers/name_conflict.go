@@ -0,0 +1,45 @@
+package handlers
+
+import (
+    "errors"
+    "net/http"
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/buildinfo"
+    "src/backend/file-service/pkg/i18n"
+)
+
+// nameConflictParam chooses what an upload does when its folder already
+// holds a file of the same name: keep, rename, overwrite or reject
+const nameConflictParam = "conflict"
+
+// nameConflictMessage is the error of uploads rejected by name
+const nameConflictMessage = "A file with this name already exists"
+
+// sendNameConflictError answers uploads refused by their name conflict
+// strategy, reporting whether err was such a refusal. Rejected uploads get
+// 409 Conflict with the metadata of the file holding the name.
+func sendNameConflictError(w http.ResponseWriter, r *http.Request, err error) bool {
+    var conflict *service.NameConflictError
+    switch {
+    case errors.As(err, &conflict):
+        language := responseLanguage(w, r)
+        writeJSON(w, http.StatusConflict, map[string]interface{}{
+            "error":   nameConflictMessage,
+            "message": i18n.Translate(language, nameConflictMessage),
+            "file":    conflict.Existing,
+            "version": buildinfo.Version,
+        })
+    case errors.Is(err, service.ErrFileLocked):
+        writeLocked(w, r, nil)
+    case errors.Is(err, service.ErrFileRetained):
+        writeError(w, r, http.StatusConflict, "File is under retention and cannot be overwritten")
+    case errors.Is(err, service.ErrFileArchived):
+        writeError(w, r, http.StatusConflict, "File is archived and cannot be overwritten")
+    case errors.Is(err, service.ErrVersionConflict):
+        writeError(w, r, http.StatusConflict, "File was modified concurrently; reload it and retry")
+    default:
+        return false
+    }
+    return true
+}
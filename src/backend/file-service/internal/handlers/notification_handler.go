@@ -0,0 +1,115 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// NotificationHandler serves notification preferences to users and accepts
+// share events from the services that share files
+type NotificationHandler struct {
+    notifications service.NotificationService
+    authorizer    authz.Authorizer
+    files         fileLookup
+    shares        service.AccessReview
+    logger        *zap.Logger
+}
+
+// NewNotificationHandler creates a new NotificationHandler instance.
+// authorizer may be nil, in which case share events are only guarded by the
+// route's role checks; otherwise files describes shared files to the policy.
+// shares records every share for access review.
+func NewNotificationHandler(notifications service.NotificationService, authorizer authz.Authorizer,
+    files service.FileService, shares service.AccessReview) *NotificationHandler {
+    return &NotificationHandler{
+        notifications: notifications,
+        authorizer:    authorizer,
+        files:         files,
+        shares:        shares,
+        logger:        zap.L().Named("notification-handler"),
+    }
+}
+
+// PreferencesHandler handles GET and PUT /notifications/preferences for the caller
+func (h *NotificationHandler) PreferencesHandler(w http.ResponseWriter, r *http.Request) {
+    userID := requestctx.UserID(r.Context())
+
+    switch r.Method {
+    case http.MethodGet:
+        prefs, err := h.notifications.GetPreferences(r.Context(), userID)
+        if err != nil {
+            h.handleError(w, r, err, "Failed to get notification preferences")
+            return
+        }
+        writeJSON(w, http.StatusOK, prefs)
+
+    case http.MethodPut:
+        var prefs models.NotificationPreferences
+        if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&prefs); err != nil {
+            writeError(w, r, http.StatusBadRequest, "Invalid request body")
+            return
+        }
+        prefs.UserID = userID
+        prefs.LastDigestAt = nil
+
+        updated, err := h.notifications.UpdatePreferences(r.Context(), &prefs)
+        if err != nil {
+            h.handleError(w, r, err, "Failed to update notification preferences")
+            return
+        }
+        writeJSON(w, http.StatusOK, updated)
+
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+    }
+}
+
+// ShareEventHandler handles POST /notifications/share, recording the share
+// and notifying the recipient of the shared file on the channels they chose
+func (h *NotificationHandler) ShareEventHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    var event service.ShareEvent
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&event); err != nil {
+        writeError(w, r, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+
+    attributes := map[string]string{"sharedBy": event.SharedBy, "sharedWith": event.SharedWith}
+    if !authorizeFile(r.Context(), w, r, h.authorizer, h.files, authz.ActionShare, event.FileID, attributes) {
+        return
+    }
+
+    if err := h.shares.RecordShare(r.Context(), event); err != nil {
+        h.handleError(w, r, err, "Failed to record share")
+        return
+    }
+    if err := h.notifications.NotifyShare(r.Context(), event); err != nil {
+        h.handleError(w, r, err, "Failed to deliver share notification")
+        return
+    }
+    w.WriteHeader(http.StatusAccepted)
+}
+
+// handleError maps notification service errors to HTTP responses
+func (h *NotificationHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    if errors.Is(err, service.ErrInvalidInput) {
+        writeError(w, r, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    h.logger.Error(message, zap.Error(err))
+    reportError(r, message, err)
+    writeError(w, r, http.StatusInternalServerError, message)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+    "net/http"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// Upload modes a client may choose between
+const (
+    uploadModeMultipart = "multipart"
+    uploadModeChunked   = "chunked"
+    uploadModeDirect    = "direct"
+)
+
+// uploadPolicyResponse describes what the caller may upload
+type uploadPolicyResponse struct {
+    Roles []string             `json:"roles"`
+    Rules []service.UploadRule `json:"rules"`
+}
+
+// UploadHints are the service-wide upload settings reported to clients
+type UploadHints struct {
+    // ChunkSize is the part size of chunked uploads, and the size above which
+    // clients should prefer them
+    ChunkSize int64
+    // MaxChunks bounds the parts of a chunked upload
+    MaxChunks int
+    // ClientEncryption accepts content encrypted by the client before upload
+    ClientEncryption bool
+    // EscrowRequired rejects client-encrypted uploads without an escrowed key
+    EscrowRequired bool
+    // DirectUpload offers presigned URLs for uploading straight to storage
+    DirectUpload bool
+}
+
+// uploadHintsResponse is the body of GET /upload/policy: the effective
+// constraints for the caller and the upload modes available to it
+type uploadHintsResponse struct {
+    MaxSize      int64                `json:"maxSize"`
+    AllowedTypes []string             `json:"allowedTypes"`
+    Rules        []service.UploadRule `json:"rules"`
+    Modes        []string             `json:"modes"`
+    // DirectUpload reports whether presigned URLs for uploading straight to
+    // storage are available through POST /files/presign-upload
+    DirectUpload     bool               `json:"directUpload"`
+    Chunked          chunkedUploadHints `json:"chunked"`
+    ClientEncryption encryptionHints    `json:"clientEncryption"`
+    Quota            *quotaHints        `json:"quota,omitempty"`
+}
+
+type chunkedUploadHints struct {
+    ChunkSize int64 `json:"chunkSize"`
+    MaxChunks int   `json:"maxChunks"`
+    // Threshold is the file size above which chunked uploads are recommended
+    Threshold int64 `json:"threshold"`
+}
+
+type encryptionHints struct {
+    Enabled        bool `json:"enabled"`
+    EscrowRequired bool `json:"escrowRequired"`
+}
+
+type quotaHints struct {
+    Used      int64 `json:"used"`
+    Limit     int64 `json:"limit"`
+    Remaining int64 `json:"remaining"`
+}
+
+// PolicyHandler exposes the upload rules that apply to the caller so the
+// frontend can filter file pickers and validate before uploading
+type PolicyHandler struct {
+    uploadPolicy *service.UploadPolicy
+    hints        UploadHints
+    quota        *service.QuotaTracker
+    logger       *zap.Logger
+}
+
+// NewPolicyHandler creates a new PolicyHandler instance. quota may be nil, in
+// which case no remaining quota is reported.
+func NewPolicyHandler(uploadPolicy *service.UploadPolicy, hints UploadHints, quota *service.QuotaTracker) *PolicyHandler {
+    return &PolicyHandler{
+        uploadPolicy: uploadPolicy,
+        hints:        hints,
+        quota:        quota,
+        logger:       zap.L().Named("policy-handler"),
+    }
+}
+
+// UploadPolicyHandler returns the upload rules for the caller's roles
+func (h *PolicyHandler) UploadPolicyHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    roles := requestctx.Roles(r.Context())
+    if roles == nil {
+        roles = []string{}
+    }
+
+    writeJSON(w, http.StatusOK, uploadPolicyResponse{
+        Roles: roles,
+        Rules: h.uploadPolicy.RulesFor(roles),
+    })
+}
+
+// UploadHintsHandler handles GET /upload/policy, returning the effective
+// upload constraints for the caller so client SDKs can validate files and
+// pick an upload mode before sending any bytes
+func (h *PolicyHandler) UploadHintsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    roles := requestctx.Roles(r.Context())
+    maxSize, allowedTypes := h.uploadPolicy.Limits(roles)
+
+    hints := uploadHintsResponse{
+        MaxSize:      maxSize,
+        AllowedTypes: allowedTypes,
+        Rules:        h.uploadPolicy.RulesFor(roles),
+        Modes:        []string{uploadModeMultipart, uploadModeChunked},
+        DirectUpload: h.hints.DirectUpload,
+        Chunked: chunkedUploadHints{
+            ChunkSize: h.hints.ChunkSize,
+            MaxChunks: h.hints.MaxChunks,
+            Threshold: h.hints.ChunkSize,
+        },
+        ClientEncryption: encryptionHints{
+            Enabled:        h.hints.ClientEncryption,
+            EscrowRequired: h.hints.EscrowRequired,
+        },
+    }
+
+    if h.hints.DirectUpload {
+        hints.Modes = append(hints.Modes, uploadModeDirect)
+    }
+
+    // A failed quota lookup leaves the quota out rather than failing the request
+    if userID := requestctx.UserID(r.Context()); h.quota != nil && userID != "" {
+        usage, err := h.quota.Usage(r.Context(), userID)
+        if err != nil {
+            h.logger.Warn("Failed to look up storage quota",
+                zap.String("ownerId", userID),
+                zap.Error(err))
+        } else {
+            hints.Quota = &quotaHints{
+                Used:      usage.Used,
+                Limit:     usage.Limit,
+                Remaining: usage.Remaining(),
+            }
+        }
+    }
+
+    writeJSON(w, http.StatusOK, hints)
+}
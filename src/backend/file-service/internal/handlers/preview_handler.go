@@ -0,0 +1,302 @@
+package handlers
+
+import (
+    "errors"
+    "io"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/thumbnail"
+)
+
+// previewTokenParam carries the capability token of a preview URL
+const previewTokenParam = "token"
+
+// previewSizeParam selects one of thumbnail.Sizes for previews of images
+const previewSizeParam = "size"
+
+// PreviewHandler serves file previews for the web UI
+type PreviewHandler struct {
+    fileService    service.FileService
+    derived        service.DerivedObjectService
+    links          *service.PreviewLinks
+    downloadPolicy DownloadSecurityPolicy
+    frameAncestors string
+    authorizer     authz.Authorizer
+    shares         service.AccessReview
+    logger         *zap.Logger
+}
+
+// previewLinkResponse is the body returned for a capability URL request
+type previewLinkResponse struct {
+    URL       string    `json:"url"`
+    ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewPreviewHandler creates a new PreviewHandler instance. derived previews
+// images and PDFs, which have no sanitized copy, and may be nil, in which
+// case only sanitized copies are previewed. links may be nil, in which case
+// capability URLs are not issued. frameAncestors lists the origins allowed
+// to embed previews in an iframe. authorizer may be nil, in which case any
+// authenticated caller may preview files, and shares may be nil, in which
+// case share recipients are not recognized by the policy.
+func NewPreviewHandler(fileService service.FileService, derived service.DerivedObjectService, links *service.PreviewLinks,
+    downloadPolicy DownloadSecurityPolicy, frameAncestors string, authorizer authz.Authorizer,
+    shares service.AccessReview) *PreviewHandler {
+    return &PreviewHandler{
+        fileService:    fileService,
+        derived:        derived,
+        links:          links,
+        downloadPolicy: downloadPolicy,
+        frameAncestors: frameAncestors,
+        authorizer:     authorizer,
+        shares:         shares,
+        logger:         zap.L().Named("preview-handler"),
+    }
+}
+
+// IsContentPath reports whether path is a capability URL, which is
+// authorized by its token rather than the caller's credentials
+func IsContentPath(path string) bool {
+    return strings.HasSuffix(path, "/preview/content")
+}
+
+// FilePreviewHandler handles GET /files/{id}/preview. It streams the preview
+// inline, or with ?format=url returns a short-lived capability URL for
+// embedding without the Authorization header. Both are a download of the
+// file, so capability URLs are only issued to callers the policy allows.
+func (h *PreviewHandler) FilePreviewHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    fileID, ok := fileIDFromPath(r.URL.Path, "/preview")
+    if !ok {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+
+    ctx := r.Context()
+    attributes := shareAttributes(ctx, r, h.shares, h.logger, fileID)
+    if !authorizeFile(ctx, w, r, h.authorizer, h.fileService, authz.ActionDownload, fileID, attributes) {
+        return
+    }
+
+    if r.URL.Query().Get("format") != "url" {
+        h.streamPreview(w, r, fileID)
+        return
+    }
+
+    if h.links == nil {
+        writeError(w, r, http.StatusNotFound, "Preview links are not enabled")
+        return
+    }
+    if _, err := h.fileService.Stat(ctx, fileID); err != nil {
+        h.handleError(w, r, err)
+        return
+    }
+
+    token, expiresAt, err := h.links.Issue(fileID)
+    if err != nil {
+        h.logger.Error("Failed to issue preview link", zap.Error(err))
+        reportError(r, "Failed to issue preview link", err)
+        writeError(w, r, http.StatusInternalServerError, "Failed to issue preview link")
+        return
+    }
+    writeJSON(w, http.StatusOK, previewLinkResponse{
+        URL:       previewContentURL(fileID, token, 0),
+        ExpiresAt: expiresAt,
+    })
+}
+
+// previewContentURL returns the capability URL redeeming token for fileID's
+// preview, at a thumbnail size when size is positive
+func previewContentURL(fileID, token string, size int) string {
+    query := url.Values{previewTokenParam: {token}}
+    if size > 0 {
+        query.Set(previewSizeParam, strconv.Itoa(size))
+    }
+    return "/files/" + url.PathEscape(fileID) + "/preview/content?" + query.Encode()
+}
+
+// thumbnailSrcset describes file's thumbnails with a preview link for each
+// size, so <img> elements load them without the Authorization header, or
+// returns "" when the file has no thumbnails or they cannot be previewed.
+// Each candidate has its own token, since tokens may be single-use.
+func thumbnailSrcset(links *service.PreviewLinks, policy DownloadSecurityPolicy, file *models.File) (string, error) {
+    if links == nil || !policy.InlinePreviewEnabled || !file.HasThumbnails() {
+        return "", nil
+    }
+    return thumbnail.Srcset(func(size int) (string, error) {
+        token, _, err := links.Issue(file.ID)
+        if err != nil {
+            return "", err
+        }
+        return previewContentURL(file.ID, token, size), nil
+    })
+}
+
+// PreviewContentHandler handles GET /files/{id}/preview/content?token=..., the
+// capability URL issued by FilePreviewHandler and in thumbnail srcsets
+func (h *PreviewHandler) PreviewContentHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+
+    fileID, ok := fileIDFromPath(r.URL.Path, "/preview/content")
+    if !ok || h.links == nil {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+    if err := h.links.Redeem(r.Context(), fileID, r.URL.Query().Get(previewTokenParam)); err != nil {
+        switch {
+        case errors.Is(err, service.ErrInvalidPreviewToken):
+            writeError(w, r, http.StatusForbidden, "Invalid or expired preview link")
+        case errors.Is(err, service.ErrTokenReplayed):
+            writeError(w, r, http.StatusForbidden, "Preview link was already used")
+        default:
+            h.logger.Error("Failed to redeem preview link", zap.Error(err))
+            reportError(r, "Failed to redeem preview link", err)
+            writeError(w, r, http.StatusInternalServerError, "Failed to redeem preview link")
+        }
+        return
+    }
+
+    // Tokens must not leak through the Referer of resources the preview loads
+    w.Header().Set("Referrer-Policy", "no-referrer")
+    w.Header().Set("Cache-Control", "private, no-store")
+    h.streamPreview(w, r, fileID)
+}
+
+// streamPreview writes the file's preview inline, embeddable only by the
+// configured frame ancestors: its sanitized copy, or for images and PDFs
+// the derived object service.PreviewDerivative names, with images scaled
+// to ?size= when it is one of thumbnail.Sizes. Callers authorize the
+// preview, by the policy or by a capability token issued after it.
+func (h *PreviewHandler) streamPreview(w http.ResponseWriter, r *http.Request, fileID string) {
+    if !h.downloadPolicy.InlinePreviewEnabled {
+        writeError(w, r, http.StatusForbidden, "Inline previews are disabled")
+        return
+    }
+    size, ok := previewSize(r)
+    if !ok {
+        writeError(w, r, http.StatusBadRequest, "Invalid preview size")
+        return
+    }
+
+    file, reader, err := h.fileService.DownloadPreview(r.Context(), fileID)
+    if errors.Is(err, service.ErrPreviewNotAvailable) && h.derived != nil {
+        file, reader, err = h.derivedPreview(r, fileID, size)
+    }
+    if err != nil {
+        h.handleError(w, r, err)
+        return
+    }
+    defer reader.Close()
+
+    h.downloadPolicy.applyPreview(w, file)
+    w.Header().Del("X-Frame-Options")
+    w.Header().Set("Content-Security-Policy", h.downloadPolicy.PreviewCSP+"; frame-ancestors "+h.frameAncestors)
+    w.Header().Set("Cross-Origin-Resource-Policy", "same-site")
+
+    if _, err := io.Copy(w, reader); err != nil {
+        h.logger.Error("Failed to stream preview content",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+    }
+}
+
+// previewSize returns the thumbnail size a request selects, 0 when it
+// selects none, or false when it is not one of thumbnail.Sizes
+func previewSize(r *http.Request) (int, bool) {
+    value := r.URL.Query().Get(previewSizeParam)
+    if value == "" {
+        return 0, true
+    }
+    size, err := strconv.Atoi(value)
+    if err != nil {
+        return 0, false
+    }
+    for _, allowed := range thumbnail.Sizes {
+        if size == allowed {
+            return size, true
+        }
+    }
+    return 0, false
+}
+
+// derivedPreview opens the derived object previewing the file, described
+// by a copy of the file with the derived object's content type. Images are
+// scaled to size when it is positive.
+func (h *PreviewHandler) derivedPreview(r *http.Request, fileID string, size int) (*models.File, io.ReadCloser, error) {
+    ctx := r.Context()
+    file, err := h.fileService.Stat(ctx, fileID)
+    if err != nil {
+        return nil, nil, err
+    }
+    kind, params, ok := service.PreviewDerivative(file)
+    if !ok {
+        return nil, nil, service.ErrPreviewNotAvailable
+    }
+    if kind == models.DerivedKindThumbnail && size > 0 {
+        params["size"] = strconv.Itoa(size)
+    }
+
+    object, reader, err := h.derived.Get(ctx, fileID, kind, params)
+    switch {
+    case errors.Is(err, service.ErrDerivedNotSupported):
+        return nil, nil, service.ErrPreviewNotAvailable
+    case errors.Is(err, service.ErrDerivedFailed):
+        h.logger.Warn("Failed to derive preview",
+            zap.String("fileId", fileID),
+            zap.String("kind", kind),
+            zap.Error(err))
+        return nil, nil, service.ErrPreviewNotAvailable
+    case err != nil:
+        return nil, nil, err
+    }
+
+    preview := *file
+    preview.ContentType = object.ContentType
+    return &preview, reader, nil
+}
+
+// handleError maps service errors to HTTP responses
+func (h *PreviewHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+    switch {
+    case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrFileNotFound):
+        writeError(w, r, http.StatusNotFound, "File not found")
+    case errors.Is(err, service.ErrPreviewNotAvailable):
+        writeError(w, r, http.StatusNotFound, "Preview not available")
+    case errors.Is(err, service.ErrFileArchived):
+        writeError(w, r, http.StatusConflict, archivedMessage)
+    default:
+        h.logger.Error("Failed to load preview", zap.Error(err))
+        reportError(r, "Failed to load preview", err)
+        writeError(w, r, http.StatusInternalServerError, "Failed to load preview")
+    }
+}
+
+// fileIDFromPath extracts {id} from /files/{id}<suffix>
+func fileIDFromPath(path, suffix string) (string, bool) {
+    rest := strings.TrimPrefix(path, "/files/")
+    if rest == path || !strings.HasSuffix(rest, suffix) {
+        return "", false
+    }
+
+    fileID := strings.TrimSuffix(rest, suffix)
+    if fileID == "" || strings.Contains(fileID, "/") {
+        return "", false
+    }
+    return fileID, true
+}
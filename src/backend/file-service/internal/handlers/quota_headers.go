@@ -0,0 +1,86 @@
+package handlers
+
+import (
+    "context"
+    "net/http"
+    "strconv"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// Quota headers, set on responses to authenticated callers
+const (
+    quotaUsedHeader      = "X-Quota-Used"
+    quotaLimitHeader     = "X-Quota-Limit"
+    quotaRemainingHeader = "X-Quota-Remaining"
+)
+
+// QuotaHeaders annotates responses to authenticated callers with their storage
+// used, limit and remaining bytes, so clients can warn before uploads start
+// failing.
+// It must run inside middleware.Authenticate.
+func QuotaHeaders(tracker *service.QuotaTracker) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        if tracker == nil {
+            return next
+        }
+
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            ownerID := requestctx.UserID(r.Context())
+            if ownerID == "" {
+                next.ServeHTTP(w, r)
+                return
+            }
+
+            next.ServeHTTP(&quotaResponseWriter{
+                ResponseWriter: w,
+                ctx:            r.Context(),
+                ownerID:        ownerID,
+                tracker:        tracker,
+            }, r)
+        })
+    }
+}
+
+// quotaResponseWriter looks up usage when the response header is written, so
+// that the headers reflect the upload or delete the request just performed
+type quotaResponseWriter struct {
+    http.ResponseWriter
+    ctx         context.Context
+    ownerID     string
+    tracker     *service.QuotaTracker
+    wroteHeader bool
+}
+
+func (w *quotaResponseWriter) WriteHeader(status int) {
+    if !w.wroteHeader {
+        w.wroteHeader = true
+        w.annotate()
+    }
+    w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *quotaResponseWriter) Write(b []byte) (int, error) {
+    if !w.wroteHeader {
+        w.WriteHeader(http.StatusOK)
+    }
+    return w.ResponseWriter.Write(b)
+}
+
+// annotate sets the quota headers; a failed lookup leaves them unset
+func (w *quotaResponseWriter) annotate() {
+    usage, err := w.tracker.Usage(w.ctx, w.ownerID)
+    if err != nil {
+        zap.L().Warn("Failed to look up storage quota",
+            zap.String("ownerId", w.ownerID),
+            zap.Error(err))
+        return
+    }
+
+    w.Header().Set(quotaUsedHeader, strconv.FormatInt(usage.Used, 10))
+    w.Header().Set(quotaLimitHeader, strconv.FormatInt(usage.Limit, 10))
+    w.Header().Set(quotaRemainingHeader, strconv.FormatInt(usage.Remaining(), 10))
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+    "net/http"
+    "strconv"
+    "time"
+
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/throttle"
+)
+
+// Rate limit headers, set on every response to limited routes
+const (
+    rateLimitLimitHeader     = "X-RateLimit-Limit"
+    rateLimitRemainingHeader = "X-RateLimit-Remaining"
+    rateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimit counts requests against the caller's allowance and annotates
+// every response with the limit, the requests remaining and when the window
+// resets as Unix seconds, so clients can slow down before being rejected.
+// Callers over their limit get 429 with Retry-After. Authenticated callers
+// are limited by user and others by remote address, so it should run inside
+// middleware.Authenticate.
+func RateLimit(limiter *throttle.Limiter) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        if limiter == nil {
+            return next
+        }
+
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            result := limiter.Allow(rateLimitKey(r))
+
+            w.Header().Set(rateLimitLimitHeader, strconv.Itoa(result.Limit))
+            w.Header().Set(rateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+            w.Header().Set(rateLimitResetHeader, strconv.FormatInt(result.Reset.Unix(), 10))
+
+            if !result.Allowed {
+                retryAfter := int(time.Until(result.Reset).Seconds() + 1)
+                w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+                writeError(w, r, http.StatusTooManyRequests, "Rate limit exceeded")
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// rateLimitKey identifies the caller a request is counted against
+func rateLimitKey(r *http.Request) string {
+    if userID := requestctx.UserID(r.Context()); userID != "" {
+        return "user:" + userID
+    }
+    return "addr:" + clientIP(r)
+}
+
+// clientIP returns the address of the client that sent r
+func clientIP(r *http.Request) string {
+    if ip := requestctx.ClientIP(r.Context()); ip != "" {
+        return ip
+    }
+    return requestctx.RemoteIP(r)
+}
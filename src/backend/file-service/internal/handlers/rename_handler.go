@@ -0,0 +1,124 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// renameRequest is the body of POST /rename
+type renameRequest struct {
+    Name    string `json:"name"`
+    Version *int64 `json:"version"`
+}
+
+// moveRequest is the body of POST /move
+type moveRequest struct {
+    Folder  string `json:"folder"`
+    Version *int64 `json:"version"`
+}
+
+// RenameHandler handles POST /rename?id=..., changing a file's logical name.
+// The body carries the metadata version the caller last read; a file
+// modified since then is reported as 409 Conflict.
+func (h *FileHandler) RenameHandler(w http.ResponseWriter, r *http.Request) {
+    var req renameRequest
+    fileID, ok := h.decodeRelocation(w, r, &req)
+    if !ok {
+        return
+    }
+    if req.Version == nil {
+        h.sendError(w, r, http.StatusPreconditionRequired, "File version is required")
+        return
+    }
+
+    if !h.checkLock(w, r, fileID) {
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    file, err := h.fileService.Rename(ctx, fileID, req.Name, *req.Version)
+    h.sendMetadataChange(w, r, fileID, file, err, "relocate", "Failed to rename file")
+}
+
+// MoveHandler handles POST /move?id=..., changing a file's logical folder
+// with the same version semantics as RenameHandler
+func (h *FileHandler) MoveHandler(w http.ResponseWriter, r *http.Request) {
+    var req moveRequest
+    fileID, ok := h.decodeRelocation(w, r, &req)
+    if !ok {
+        return
+    }
+    if req.Version == nil {
+        h.sendError(w, r, http.StatusPreconditionRequired, "File version is required")
+        return
+    }
+
+    if !h.checkLock(w, r, fileID) {
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    file, err := h.fileService.Move(ctx, fileID, req.Folder, *req.Version)
+    h.sendMetadataChange(w, r, fileID, file, err, "relocate", "Failed to move file")
+}
+
+// decodeRelocation validates a rename, move or tags request and decodes its body
+func (h *FileHandler) decodeRelocation(w http.ResponseWriter, r *http.Request, req interface{}) (string, bool) {
+    h.rateLimiter.Take()
+
+    if r.Method != http.MethodPost {
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return "", false
+    }
+
+    fileID := r.URL.Query().Get("id")
+    if fileID == "" {
+        h.sendError(w, r, http.StatusBadRequest, "File ID is required")
+        return "", false
+    }
+
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(req); err != nil {
+        h.sendError(w, r, http.StatusBadRequest, "Invalid request body")
+        return "", false
+    }
+    return fileID, true
+}
+
+// sendMetadataChange writes the result of a rename, move or tags change and
+// counts it as operation
+func (h *FileHandler) sendMetadataChange(w http.ResponseWriter, r *http.Request, fileID string, file *models.File, err error,
+    operation, message string) {
+    if err != nil {
+        switch {
+        case errors.Is(err, service.ErrFileNotFound):
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+        case errors.Is(err, service.ErrVersionConflict):
+            h.sendError(w, r, http.StatusConflict, "File was modified concurrently; reload it and retry")
+        case errors.Is(err, service.ErrInvalidInput):
+            h.sendError(w, r, http.StatusBadRequest, err.Error())
+        default:
+            h.logger.Error(message,
+                zap.String("fileId", fileID),
+                zap.Error(err))
+            reportError(r, message, err)
+            h.sendError(w, r, http.StatusInternalServerError, message)
+        }
+        return
+    }
+
+    h.metrics.operations.Inc(operation, requestctx.Tenant(r.Context()))
+    h.sendJSON(w, http.StatusOK, file)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// archivedMessage is the error of reads of archived content without a restored copy
+const archivedMessage = "File is archived; restore it before downloading"
+
+// RestoreHandler handles POST /restore?id=..., requesting a readable copy of
+// an archived file. It responds 202 with the file while the restore is in
+// progress, and 200 once the content can be downloaded. Callers that may
+// download a file may restore it.
+func (h *FileHandler) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+    h.rateLimiter.Take()
+
+    if r.Method != http.MethodPost {
+        h.sendError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+    if h.archive == nil {
+        h.sendError(w, r, http.StatusNotFound, "Archiving is not enabled")
+        return
+    }
+
+    fileID := r.URL.Query().Get("id")
+    if fileID == "" {
+        h.sendError(w, r, http.StatusBadRequest, "File ID is required")
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    if !authorizeFile(ctx, w, r, h.authorizer, h.fileService, authz.ActionDownload, fileID, nil) {
+        return
+    }
+
+    file, err := h.archive.Restore(ctx, fileID)
+    if err != nil {
+        if errors.Is(err, service.ErrFileNotFound) {
+            h.sendError(w, r, http.StatusNotFound, "File not found")
+            return
+        }
+        h.logger.Error("Failed to restore file",
+            zap.String("fileId", fileID),
+            zap.Error(err))
+        reportError(r, "Failed to restore file", err)
+        h.sendError(w, r, http.StatusInternalServerError, "Failed to restore file")
+        return
+    }
+
+    h.metrics.operations.Inc("restore", requestctx.Tenant(r.Context()))
+    status := http.StatusOK
+    if file.NeedsRestore(time.Now()) {
+        status = http.StatusAccepted
+    }
+    h.sendJSON(w, status, file)
+}
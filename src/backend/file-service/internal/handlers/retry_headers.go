@@ -0,0 +1,125 @@
+package handlers
+
+import (
+    "context"
+    "errors"
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// Retry and health headers understood by clients and Envoy-based meshes
+const (
+    // retryableHeader tells clients and retry policies matching on response
+    // headers whether the request may be sent again
+    retryableHeader = "X-Retryable"
+    // idempotentHeader reports whether the request's method is idempotent
+    idempotentHeader      = "X-Idempotent"
+    envoyHealthFailHeader = "X-Envoy-Immediate-Health-Check-Fail"
+)
+
+// statusClientClosedRequest is logged for requests whose client went away
+// before a response, as nginx and Envoy do
+const statusClientClosedRequest = 499
+
+// RetryHints annotates error responses so service meshes retry and eject
+// instances correctly:
+//
+//   - 429 and 5xx responses carry X-Retryable and X-Idempotent. Rejections
+//     (429, 503) may always be retried; other server errors only when the
+//     request was idempotent. Retriable responses carry Retry-After.
+//   - Server errors for requests whose client went away are written as 499,
+//     so outlier detection does not count the client's disconnect against
+//     the instance.
+//   - While draining, responses ask Envoy to fail its health check of the
+//     instance at once rather than waiting for the next probe.
+//
+// draining may be nil.
+func RetryHints(retryAfter time.Duration, draining func() bool) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            next.ServeHTTP(&retryResponseWriter{
+                ResponseWriter: w,
+                r:              r,
+                retryAfter:     retryAfter,
+                draining:       draining,
+            }, r)
+        })
+    }
+}
+
+// retryResponseWriter adds the retry headers once the handler has chosen the
+// status of its response
+type retryResponseWriter struct {
+    http.ResponseWriter
+    r           *http.Request
+    retryAfter  time.Duration
+    draining    func() bool
+    wroteHeader bool
+}
+
+func (w *retryResponseWriter) WriteHeader(status int) {
+    if !w.wroteHeader {
+        w.wroteHeader = true
+        status = w.annotate(status)
+    }
+    w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *retryResponseWriter) Write(b []byte) (int, error) {
+    if !w.wroteHeader {
+        w.WriteHeader(http.StatusOK)
+    }
+    return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *retryResponseWriter) Unwrap() http.ResponseWriter {
+    return w.ResponseWriter
+}
+
+// annotate sets the retry headers for a response with status and returns
+// the status to write
+func (w *retryResponseWriter) annotate(status int) int {
+    header := w.Header()
+    if w.draining != nil && w.draining() {
+        header.Set(envoyHealthFailHeader, "true")
+    }
+
+    if status < http.StatusInternalServerError && status != http.StatusTooManyRequests {
+        return status
+    }
+    if status >= http.StatusInternalServerError && errors.Is(w.r.Context().Err(), context.Canceled) {
+        return statusClientClosedRequest
+    }
+
+    idempotent := isIdempotent(w.r)
+    retryable := false
+    switch status {
+    case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+        // The request was turned away before it had any effect
+        retryable = true
+    case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+        // Retrying cannot succeed
+    default:
+        retryable = idempotent
+    }
+
+    header.Set(idempotentHeader, strconv.FormatBool(idempotent))
+    header.Set(retryableHeader, strconv.FormatBool(retryable))
+    if retryable && header.Get("Retry-After") == "" {
+        header.Set("Retry-After", strconv.Itoa(int(math.Ceil(w.retryAfter.Seconds()))))
+    }
+    return status
+}
+
+// isIdempotent reports whether sending r again has the same effect as
+// sending it once
+func isIdempotent(r *http.Request) bool {
+    switch r.Method {
+    case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+        return true
+    }
+    return false
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+    "io"
+    "net/http"
+    "strings"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// Reasons a request is reported by SlowRequests
+const (
+    slowReasonDuration = "duration"
+    slowReasonSize     = "size"
+)
+
+// SlowRequests logs a warning with the route, caller and file for every
+// request that takes longer than slowAfter or reads or writes more than
+// largeBytes of body, and counts it by route and reason in
+// slow_requests_total. A zero threshold disables its check.
+// It must run inside middleware.Authenticate to log the caller.
+func SlowRequests(provider metrics.Provider, slowAfter time.Duration, largeBytes int64) func(http.Handler) http.Handler {
+    log := zap.L().Named("slow-requests")
+    slowRequests := metrics.OrNop(provider).Counter(metrics.Opts{
+        Name:   "slow_requests_total",
+        Help:   "Number of requests exceeding the slow request duration or large transfer size threshold",
+        Labels: []string{"route", "reason"},
+    })
+
+    return func(next http.Handler) http.Handler {
+        if slowAfter <= 0 && largeBytes <= 0 {
+            return next
+        }
+
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            body := &countingReader{ReadCloser: r.Body}
+            if r.Body != nil && r.Body != http.NoBody {
+                r.Body = body
+            }
+            recorder := &transferRecorder{ResponseWriter: w, status: http.StatusOK}
+
+            start := time.Now()
+            next.ServeHTTP(recorder, r)
+            duration := time.Since(start)
+
+            var reasons []string
+            if slowAfter > 0 && duration > slowAfter {
+                reasons = append(reasons, slowReasonDuration)
+            }
+            if largeBytes > 0 && (body.n > largeBytes || recorder.n > largeBytes) {
+                reasons = append(reasons, slowReasonSize)
+            }
+            if len(reasons) == 0 {
+                return
+            }
+
+            route := routeLabel(r.URL.Path)
+            for _, reason := range reasons {
+                slowRequests.Inc(route, reason)
+            }
+            log.Warn("Slow or large request",
+                zap.Strings("reasons", reasons),
+                zap.String("route", route),
+                zap.String("method", r.Method),
+                zap.String("path", r.URL.Path),
+                zap.Int("status", recorder.status),
+                zap.String("userId", requestctx.UserID(r.Context())),
+                zap.String("fileId", requestFileID(r)),
+                zap.Duration("duration", duration),
+                zap.Int64("bytesIn", body.n),
+                zap.Int64("bytesOut", recorder.n),
+                zap.String("traceId", tracing.TraceID(r.Context())),
+                zap.String("requestId", requestctx.RequestID(r.Context())),
+            )
+        })
+    }
+}
+
+// routeLabel returns the first segment of path, which names the route
+// without the IDs that follow it
+func routeLabel(path string) string {
+    segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+    return "/" + segment
+}
+
+// requestFileID returns the file a request is about, from its id query
+// parameter or its /files/{id}/... path, or ""
+func requestFileID(r *http.Request) string {
+    if fileID := r.URL.Query().Get("id"); fileID != "" {
+        return fileID
+    }
+    rest := strings.TrimPrefix(r.URL.Path, "/files/")
+    if rest == r.URL.Path {
+        return ""
+    }
+    fileID, _, _ := strings.Cut(rest, "/")
+    return fileID
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+    io.ReadCloser
+    n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+    n, err := c.ReadCloser.Read(p)
+    c.n += int64(n)
+    return n, err
+}
+
+// transferRecorder captures the status and counts the bytes of a response
+type transferRecorder struct {
+    http.ResponseWriter
+    status int
+    n      int64
+}
+
+func (t *transferRecorder) WriteHeader(status int) {
+    t.status = status
+    t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *transferRecorder) Write(p []byte) (int, error) {
+    n, err := t.ResponseWriter.Write(p)
+    t.n += int64(n)
+    return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (t *transferRecorder) Unwrap() http.ResponseWriter {
+    return t.ResponseWriter
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "net/url"
+    "time"
+
+    "src/backend/file-service/internal/models"
+)
+
+// tagsRequest is the body of POST /tags
+type tagsRequest struct {
+    Tags    models.Tags `json:"tags"`
+    Version *int64      `json:"version"`
+}
+
+// TagsHandler handles POST /tags?id=..., replacing a file's tags, which are
+// mirrored to its S3 object. An empty object removes all tags. The version
+// semantics are those of RenameHandler.
+func (h *FileHandler) TagsHandler(w http.ResponseWriter, r *http.Request) {
+    var req tagsRequest
+    fileID, ok := h.decodeRelocation(w, r, &req)
+    if !ok {
+        return
+    }
+    if req.Version == nil {
+        h.sendError(w, r, http.StatusPreconditionRequired, "File version is required")
+        return
+    }
+
+    if !h.checkLock(w, r, fileID) {
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    file, err := h.fileService.SetTags(ctx, fileID, req.Tags, *req.Version)
+    h.sendMetadataChange(w, r, fileID, file, err, "tag", "Failed to update file tags")
+}
+
+// parseTags decodes the tags header; each key may appear once
+func parseTags(header string) (models.Tags, error) {
+    if header == "" {
+        return nil, nil
+    }
+    values, err := url.ParseQuery(header)
+    if err != nil {
+        return nil, err
+    }
+
+    tags := make(models.Tags, len(values))
+    for key, value := range values {
+        if len(value) > 1 {
+            return nil, fmt.Errorf("tag %q is repeated", key)
+        }
+        tags[key] = value[0]
+    }
+    return tags, nil
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strings"
+
+    "go.uber.org/zap" // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// tenantSettingsPrefix addresses the settings of a single tenant
+const tenantSettingsPrefix = "/admin/tenants/"
+
+// TenantSettingsHandler serves the administration of per-tenant overrides:
+//
+//    GET    /admin/tenants       list every tenant's settings
+//    GET    /admin/tenants/{id}  get a tenant's settings
+//    PUT    /admin/tenants/{id}  replace a tenant's settings
+//    DELETE /admin/tenants/{id}  restore the configured defaults for a tenant
+type TenantSettingsHandler struct {
+    tenants *service.TenantSettings
+    logger  *zap.Logger
+}
+
+// NewTenantSettingsHandler creates a new TenantSettingsHandler instance.
+// tenants may be nil, in which case tenant settings are not enabled.
+func NewTenantSettingsHandler(tenants *service.TenantSettings) *TenantSettingsHandler {
+    return &TenantSettingsHandler{
+        tenants: tenants,
+        logger:  zap.L().Named("tenant-settings-handler"),
+    }
+}
+
+// ListHandler handles GET /admin/tenants
+func (h *TenantSettingsHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+    if h.tenants == nil {
+        writeError(w, r, http.StatusNotFound, "Tenant settings are not enabled")
+        return
+    }
+
+    tenants, err := h.tenants.List(r.Context())
+    if err != nil {
+        h.handleError(w, r, err, "Failed to list tenant settings")
+        return
+    }
+    writeJSON(w, http.StatusOK, tenants)
+}
+
+// TenantHandler handles GET, PUT and DELETE /admin/tenants/{id}
+func (h *TenantSettingsHandler) TenantHandler(w http.ResponseWriter, r *http.Request) {
+    if h.tenants == nil {
+        writeError(w, r, http.StatusNotFound, "Tenant settings are not enabled")
+        return
+    }
+    tenantID := strings.TrimPrefix(r.URL.Path, tenantSettingsPrefix)
+    if tenantID == "" || strings.Contains(tenantID, "/") {
+        writeError(w, r, http.StatusNotFound, "Not found")
+        return
+    }
+    adminID := requestctx.UserID(r.Context())
+
+    switch r.Method {
+    case http.MethodGet:
+        settings, err := h.tenants.Get(r.Context(), tenantID)
+        if err != nil {
+            h.handleError(w, r, err, "Failed to get tenant settings")
+            return
+        }
+        writeJSON(w, http.StatusOK, settings)
+
+    case http.MethodPut:
+        var settings models.TenantSettings
+        if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&settings); err != nil {
+            writeError(w, r, http.StatusBadRequest, "Invalid request body")
+            return
+        }
+        settings.TenantID = tenantID
+
+        updated, err := h.tenants.Update(r.Context(), &settings, adminID)
+        if err != nil {
+            h.handleError(w, r, err, "Failed to update tenant settings")
+            return
+        }
+        writeJSON(w, http.StatusOK, updated)
+
+    case http.MethodDelete:
+        if err := h.tenants.Delete(r.Context(), tenantID, adminID); err != nil {
+            h.handleError(w, r, err, "Failed to delete tenant settings")
+            return
+        }
+        w.WriteHeader(http.StatusNoContent)
+
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+    }
+}
+
+// handleError maps tenant settings errors to HTTP responses
+func (h *TenantSettingsHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    switch {
+    case errors.Is(err, service.ErrInvalidInput):
+        writeError(w, r, http.StatusBadRequest, err.Error())
+    case errors.Is(err, service.ErrTenantSettingsNotFound):
+        writeError(w, r, http.StatusNotFound, "Tenant settings not found")
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "go.uber.org/ratelimit" // v0.2.0
+    "go.uber.org/zap"       // v1.24.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+const (
+    uploadsPath         = "/uploads"
+    chunkChecksumHeader = "X-Chunk-Checksum"
+    maxJSONBodyBytes    = 1 << 20 // 1MB
+)
+
+// initiateUploadRequest is the body of POST /uploads
+type initiateUploadRequest struct {
+    FileName    string `json:"fileName"`
+    ContentType string `json:"contentType"`
+    Size        int64  `json:"size"`
+}
+
+// completeUploadRequest is the body of POST /uploads/{id}/complete
+type completeUploadRequest struct {
+    Checksums []string `json:"checksums"`
+}
+
+// uploadSessionResponse describes a session and what is still missing from it
+type uploadSessionResponse struct {
+    *models.UploadSession
+    MissingChunks []int `json:"missingChunks"`
+}
+
+// UploadSessionHandler serves the chunked upload protocol:
+//
+//    POST   /uploads                   initiate a session
+//    GET    /uploads/{id}              inspect a session to resume it
+//    PUT    /uploads/{id}/chunks/{n}   upload chunk n (1-based)
+//    POST   /uploads/{id}/complete     verify checksums and assemble the file
+//    DELETE /uploads/{id}              abort the session
+type UploadSessionHandler struct {
+    sessionService service.UploadSessionService
+    logger         *zap.Logger
+    rateLimiter    ratelimit.Limiter
+    metrics        uploadSessionMetrics
+}
+
+// NewUploadSessionHandler creates a new UploadSessionHandler instance
+func NewUploadSessionHandler(sessionService service.UploadSessionService, metricsProvider metrics.Provider) *UploadSessionHandler {
+    return &UploadSessionHandler{
+        sessionService: sessionService,
+        logger:         zap.L().Named("upload-session-handler"),
+        rateLimiter:    ratelimit.New(maxRequestsPerSecond),
+        metrics:        newUploadSessionMetrics(metricsProvider),
+    }
+}
+
+// ServeHTTP routes requests under /uploads to the protocol operations
+func (h *UploadSessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    h.rateLimiter.Take()
+
+    segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, uploadsPath), "/"), "/")
+
+    switch {
+    case len(segments) == 1 && segments[0] == "" && r.Method == http.MethodPost:
+        h.initiate(w, r)
+    case len(segments) == 1 && segments[0] != "" && r.Method == http.MethodGet:
+        h.get(w, r, segments[0])
+    case len(segments) == 1 && segments[0] != "" && r.Method == http.MethodDelete:
+        h.abort(w, r, segments[0])
+    case len(segments) == 3 && segments[1] == "chunks" && r.Method == http.MethodPut:
+        h.uploadChunk(w, r, segments[0], segments[2])
+    case len(segments) == 2 && segments[1] == "complete" && r.Method == http.MethodPost:
+        h.complete(w, r, segments[0])
+    default:
+        writeError(w, r, http.StatusNotFound, "Not found")
+    }
+}
+
+func (h *UploadSessionHandler) initiate(w http.ResponseWriter, r *http.Request) {
+    var req initiateUploadRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&req); err != nil {
+        writeError(w, r, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+
+    session, err := h.sessionService.Initiate(r.Context(), req.FileName, req.ContentType, req.Size,
+        requestctx.UserID(r.Context()), requestctx.Roles(r.Context()))
+    if err != nil {
+        h.handleError(w, r, err, "Failed to initiate upload")
+        return
+    }
+
+    h.metrics.sessions.Inc("initiated", requestctx.Tenant(r.Context()))
+    w.Header().Set("Location", uploadsPath+"/"+session.ID)
+    writeJSON(w, http.StatusCreated, newUploadSessionResponse(session))
+}
+
+func (h *UploadSessionHandler) get(w http.ResponseWriter, r *http.Request, sessionID string) {
+    session, err := h.sessionService.Get(r.Context(), sessionID)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to get upload session")
+        return
+    }
+
+    writeJSON(w, http.StatusOK, newUploadSessionResponse(session))
+}
+
+func (h *UploadSessionHandler) uploadChunk(w http.ResponseWriter, r *http.Request, sessionID string, rawNumber string) {
+    start := time.Now()
+    defer func() {
+        h.metrics.chunkDurations.Observe(r.Context(), time.Since(start).Seconds())
+    }()
+
+    number, err := strconv.Atoi(rawNumber)
+    if err != nil || number < 1 {
+        writeError(w, r, http.StatusBadRequest, "Invalid chunk number")
+        return
+    }
+
+    if r.ContentLength < 0 {
+        writeError(w, r, http.StatusLengthRequired, "Content-Length is required")
+        return
+    }
+
+    part, err := h.sessionService.UploadChunk(r.Context(), sessionID, number, r.ContentLength,
+        r.Header.Get(chunkChecksumHeader), r.Body)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to upload chunk")
+        return
+    }
+
+    h.metrics.chunks.Inc()
+    writeJSON(w, http.StatusOK, part)
+}
+
+func (h *UploadSessionHandler) complete(w http.ResponseWriter, r *http.Request, sessionID string) {
+    var req completeUploadRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodyBytes)).Decode(&req); err != nil {
+        writeError(w, r, http.StatusBadRequest, "Invalid request body")
+        return
+    }
+
+    file, err := h.sessionService.Complete(r.Context(), sessionID, req.Checksums)
+    if err != nil {
+        h.handleError(w, r, err, "Failed to complete upload")
+        return
+    }
+
+    h.metrics.sessions.Inc("completed", requestctx.Tenant(r.Context()))
+    writeJSON(w, http.StatusCreated, file)
+}
+
+func (h *UploadSessionHandler) abort(w http.ResponseWriter, r *http.Request, sessionID string) {
+    if err := h.sessionService.Abort(r.Context(), sessionID); err != nil {
+        h.handleError(w, r, err, "Failed to abort upload")
+        return
+    }
+
+    h.metrics.sessions.Inc("aborted", requestctx.Tenant(r.Context()))
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// handleError maps service and model errors to HTTP responses
+func (h *UploadSessionHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    switch {
+    case errors.Is(err, service.ErrSessionNotFound):
+        writeError(w, r, http.StatusNotFound, "Upload session not found")
+    case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrUploadNotPermitted),
+        errors.Is(err, service.ErrUploadTooLarge), errors.Is(err, service.ErrQuotaExceeded):
+        status, _ := validationStatus(err)
+        writeValidationError(w, r, status, err)
+    case errors.Is(err, models.ErrChecksumMismatch):
+        writeError(w, r, http.StatusUnprocessableEntity, err.Error())
+    case errors.Is(err, models.ErrChunkMissing):
+        writeError(w, r, http.StatusConflict, err.Error())
+    case errors.Is(err, models.ErrSessionExpired):
+        writeError(w, r, http.StatusGone, "Upload session has expired")
+    case errors.Is(err, models.ErrSessionClosed):
+        writeError(w, r, http.StatusConflict, "Upload session is not open")
+    case errors.Is(err, service.ErrUploadInterrupted):
+        writeError(w, r, http.StatusBadRequest, "Upload interrupted")
+    default:
+        h.logger.Error(message, zap.Error(err))
+        reportError(r, message, err)
+        writeError(w, r, http.StatusInternalServerError, message)
+    }
+}
+
+func newUploadSessionResponse(session *models.UploadSession) uploadSessionResponse {
+    return uploadSessionResponse{
+        UploadSession: session,
+        MissingChunks: session.MissingChunks(),
+    }
+}
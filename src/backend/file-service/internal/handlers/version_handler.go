@@ -0,0 +1,16 @@
+package handlers
+
+import (
+    "net/http"
+
+    "src/backend/file-service/pkg/buildinfo"
+)
+
+// VersionHandler handles GET /version, reporting the running build
+func VersionHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+        return
+    }
+    writeJSON(w, http.StatusOK, buildinfo.Get())
+}
@@ -3,8 +3,11 @@
 package middleware
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -13,13 +16,17 @@ import (
 	"github.com/patrickmn/go-cache" // v2.1.0
 
 	"src/backend/file-service/internal/config"
+	"src/backend/file-service/pkg/buildinfo"
 	"src/backend/file-service/pkg/logger"
+	"src/backend/file-service/pkg/requestctx"
 )
 
 const (
-	bearerSchema   = "Bearer "
-	authHeader     = "Authorization"
-	userContextKey = "user"
+	bearerSchema = "Bearer "
+	authHeader   = "Authorization"
+
+	// AdminRole grants access to administrative endpoints
+	AdminRole = "admin"
 )
 
 var (
@@ -44,6 +51,12 @@ type Claims struct {
 	Permissions  []string  `json:"permissions"`
 	IssuedAt     time.Time `json:"iat"`
 	DeviceID     string    `json:"device_id,omitempty"`
+	TenantID     string    `json:"tenant_id,omitempty"`
+
+	// SPIFFEID is set for workloads authenticated by their X.509 SVID
+	// rather than a token
+	SPIFFEID string `json:"-"`
+
 	jwt.RegisteredClaims
 }
 
@@ -60,12 +73,12 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Request = c.Request.WithContext(ctx)
 
 		// Extract token
-		tokenString, err := extractToken(c)
+		tokenString, err := extractToken(c.GetHeader(authHeader))
 		if err != nil {
 			log.Error("Token extraction failed",
-				zap.Error(err),
-				zap.String("path", c.Request.URL.Path),
-				zap.String("ip", c.ClientIP()),
+				logger.Error(err),
+				logger.String("path", c.Request.URL.Path),
+				logger.String("ip", c.ClientIP()),
 			)
 			c.AbortWithStatusJSON(401, gin.H{"error": err.Error()})
 			return
@@ -73,7 +86,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Check token cache
 		if cachedClaims, found := tokenCache.Get(tokenString); found {
-			c.Set(userContextKey, cachedClaims)
+			c.Request = c.Request.WithContext(ContextWithClaims(c.Request.Context(), cachedClaims.(*Claims)))
 			c.Next()
 			return
 		}
@@ -82,9 +95,9 @@ func AuthMiddleware() gin.HandlerFunc {
 		claims, err := validateToken(tokenString)
 		if err != nil {
 			log.Error("Token validation failed",
-				zap.Error(err),
-				zap.String("path", c.Request.URL.Path),
-				zap.String("ip", c.ClientIP()),
+				logger.Error(err),
+				logger.String("path", c.Request.URL.Path),
+				logger.String("ip", c.ClientIP()),
 			)
 			c.AbortWithStatusJSON(401, gin.H{"error": errTokenValidation.Error()})
 			return
@@ -93,8 +106,8 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Validate token age
 		if time.Since(claims.IssuedAt) > maxTokenAge {
 			log.Warn("Token exceeded maximum age",
-				zap.String("user_id", claims.UserID),
-				zap.Time("issued_at", claims.IssuedAt),
+				logger.String("user_id", claims.UserID),
+				logger.Time("issued_at", claims.IssuedAt),
 			)
 			c.AbortWithStatusJSON(401, gin.H{"error": "token expired"})
 			return
@@ -102,24 +115,23 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Log successful authentication
 		log.Info("Authentication successful",
-			zap.String("user_id", claims.UserID),
-			zap.String("email", claims.Email),
-			zap.Strings("roles", claims.Roles),
-			zap.String("path", c.Request.URL.Path),
+			logger.String("user_id", claims.UserID),
+			logger.String("email", claims.Email),
+			logger.Strings("roles", claims.Roles),
+			logger.String("path", c.Request.URL.Path),
 		)
 
 		// Cache validated claims
 		tokenCache.Set(tokenString, claims, cache.DefaultExpiration)
 
 		// Set claims in context
-		c.Set(userContextKey, claims)
+		c.Request = c.Request.WithContext(ContextWithClaims(c.Request.Context(), claims))
 		c.Next()
 	}
 }
 
-// extractToken extracts the JWT token from the Authorization header
-func extractToken(c *gin.Context) (string, error) {
-	header := c.GetHeader(authHeader)
+// extractToken extracts the JWT token from the Authorization header value
+func extractToken(header string) (string, error) {
 	if header == "" {
 		return "", errMissingToken
 	}
@@ -171,50 +183,31 @@ func validateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-// GetUserFromContext extracts the user claims from the Gin context
-func GetUserFromContext(c *gin.Context) (*Claims, error) {
-	value, exists := c.Get(userContextKey)
-	if !exists {
-		return nil, errors.New("user not found in context")
-	}
-
-	claims, ok := value.(*Claims)
+// GetUserFromContext extracts the authenticated principal from the Gin context
+func GetUserFromContext(c *gin.Context) (*requestctx.Principal, error) {
+	principal, ok := requestctx.PrincipalFrom(c.Request.Context())
 	if !ok {
-		return nil, errors.New("invalid user claims type")
+		return nil, errors.New("user not found in context")
 	}
-
-	return claims, nil
+	return principal, nil
 }
 
 // RequireRoles creates middleware to enforce role-based access control
 func RequireRoles(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claims, err := GetUserFromContext(c)
+		principal, err := GetUserFromContext(c)
 		if err != nil {
 			c.AbortWithStatusJSON(401, gin.H{"error": "authentication required"})
 			return
 		}
 
 		// Check if user has any of the required roles
-		hasRole := false
-		for _, requiredRole := range roles {
-			for _, userRole := range claims.Roles {
-				if requiredRole == userRole {
-					hasRole = true
-					break
-				}
-			}
-			if hasRole {
-				break
-			}
-		}
-
-		if !hasRole {
+		if !principal.HasRole(roles...) {
 			logger.GetLogger().Warn("Insufficient permissions",
-				zap.String("user_id", claims.UserID),
-				zap.Strings("user_roles", claims.Roles),
-				zap.Strings("required_roles", roles),
-				zap.String("path", c.Request.URL.Path),
+				logger.String("user_id", principal.UserID),
+				logger.Strings("user_roles", principal.Roles),
+				logger.Strings("required_roles", roles),
+				logger.String("path", c.Request.URL.Path),
 			)
 			c.AbortWithStatusJSON(403, gin.H{"error": errInsufficientRole.Error()})
 			return
@@ -222,4 +215,115 @@ func RequireRoles(roles ...string) gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// Authenticate creates net/http middleware for JWT authentication. The
+// principal of validated claims is stored in the request context for
+// requestctx.PrincipalFrom. When
+// SPIFFE authentication is enabled, requests without an Authorization header
+// are authenticated by the SVID presented as their mTLS client certificate.
+func Authenticate(next http.Handler) http.Handler {
+	log := logger.GetLogger()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if spiffe := spiffeAuth.Load(); spiffe != nil && r.Header.Get(authHeader) == "" && r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			claims, err := spiffe.Authenticate(r.TLS)
+			if err != nil {
+				log.Warn("SVID authentication failed",
+					logger.Error(err),
+					logger.String("path", r.URL.Path),
+				)
+				writeAuthError(w, http.StatusUnauthorized, errTokenValidation.Error())
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ContextWithClaims(r.Context(), claims)))
+			return
+		}
+
+		tokenString, err := extractToken(r.Header.Get(authHeader))
+		if err != nil {
+			log.Warn("Token extraction failed",
+				logger.Error(err),
+				logger.String("path", r.URL.Path),
+			)
+			writeAuthError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		if cachedClaims, found := tokenCache.Get(tokenString); found {
+			next.ServeHTTP(w, r.WithContext(ContextWithClaims(r.Context(), cachedClaims.(*Claims))))
+			return
+		}
+
+		claims, err := validateToken(tokenString)
+		if err != nil {
+			log.Warn("Token validation failed",
+				logger.Error(err),
+				logger.String("path", r.URL.Path),
+			)
+			writeAuthError(w, http.StatusUnauthorized, errTokenValidation.Error())
+			return
+		}
+
+		tokenCache.Set(tokenString, claims, cache.DefaultExpiration)
+		next.ServeHTTP(w, r.WithContext(ContextWithClaims(r.Context(), claims)))
+	})
+}
+
+// ContextWithClaims returns a copy of ctx carrying the principal the
+// authenticated claims identify
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return requestctx.WithPrincipal(ctx, claims.Principal())
+}
+
+// Principal returns the principal the claims identify
+func (c *Claims) Principal() *requestctx.Principal {
+	return &requestctx.Principal{
+		UserID:      c.UserID,
+		Email:       c.Email,
+		Roles:       c.Roles,
+		Permissions: c.Permissions,
+		TenantID:    c.TenantID,
+		DeviceID:    c.DeviceID,
+		SPIFFEID:    c.SPIFFEID,
+	}
+}
+
+// Authorize returns net/http middleware that rejects callers without any of
+// the given roles. It must run inside Authenticate.
+func Authorize(roles ...string) func(http.Handler) http.Handler {
+	log := logger.GetLogger()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := requestctx.PrincipalFrom(r.Context())
+			if !ok {
+				writeAuthError(w, http.StatusUnauthorized, "authentication required")
+				return
+			}
+
+			if !principal.HasRole(roles...) {
+				log.Warn("Insufficient permissions",
+					logger.String("user_id", principal.UserID),
+					logger.Strings("user_roles", principal.Roles),
+					logger.Strings("required_roles", roles),
+					logger.String("path", r.URL.Path),
+				)
+				writeAuthError(w, http.StatusForbidden, errInsufficientRole.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeAuthError writes a JSON error response for a rejected request
+func writeAuthError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":   message,
+		"version": buildinfo.Version,
+	})
+}
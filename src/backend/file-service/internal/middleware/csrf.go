@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"src/backend/file-service/pkg/logger"
+)
+
+// csrfNonceBytes is the length of the random part of a CSRF token
+const csrfNonceBytes = 32
+
+// minCSRFSecretLength is the shortest accepted CSRF token signing secret
+const minCSRFSecretLength = 32
+
+var errCSRFRejected = errors.New("CSRF token missing or invalid")
+
+// CSRFOptions configure CSRF protection
+type CSRFOptions struct {
+	// SessionCookie is the cookie that authenticates browser sessions. Only
+	// requests carrying it are checked: bearer tokens and SVIDs are never
+	// sent by a browser on its own.
+	SessionCookie string
+	// Cookie holds the CSRF token; browsers' scripts read it and echo it in
+	// Header
+	Cookie string
+	Header string
+	// Secret signs tokens to the session they were issued for
+	Secret []byte
+	// TrustedOrigins are origins besides the request's own host that may
+	// send state-changing requests, e.g. https://app.example.com
+	TrustedOrigins []string
+}
+
+// CSRF defends cookie-authenticated browser sessions against cross-site
+// request forgery with signed double-submit tokens. Safe requests get a
+// token cookie bound to the session; state-changing requests must come from
+// a trusted origin and echo the token in a header, which a cross-site form or
+// script cannot do because it cannot read the cookie.
+type CSRF struct {
+	opts    CSRFOptions
+	origins map[string]bool
+	log     *logger.Logger
+}
+
+// NewCSRF creates CSRF protection with the given options
+func NewCSRF(opts CSRFOptions) (*CSRF, error) {
+	if opts.SessionCookie == "" || opts.Cookie == "" || opts.Header == "" {
+		return nil, errors.New("session cookie, CSRF cookie and CSRF header names are required")
+	}
+	if opts.SessionCookie == opts.Cookie {
+		return nil, errors.New("CSRF cookie must differ from the session cookie")
+	}
+	if len(opts.Secret) < minCSRFSecretLength {
+		return nil, errors.New("CSRF token secret must be at least 32 bytes")
+	}
+
+	origins := make(map[string]bool, len(opts.TrustedOrigins))
+	for _, origin := range opts.TrustedOrigins {
+		normalized, ok := normalizeOrigin(origin)
+		if !ok {
+			return nil, errors.New("invalid trusted origin: " + origin)
+		}
+		origins[normalized] = true
+	}
+
+	return &CSRF{
+		opts:    opts,
+		origins: origins,
+		log:     logger.GetLogger(),
+	}, nil
+}
+
+// Protect rejects state-changing requests of cookie-authenticated sessions
+// that fail the origin or token checks with 403, and issues a token cookie
+// on safe requests of sessions without a valid one. A nil CSRF protects
+// nothing.
+func (c *CSRF) Protect(next http.Handler) http.Handler {
+	if c == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, err := r.Cookie(c.opts.SessionCookie)
+		if err != nil || session.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := ""
+		if cookie, err := r.Cookie(c.opts.Cookie); err == nil {
+			token = cookie.Value
+		}
+
+		if isSafeMethod(r.Method) {
+			if !c.valid(token, session.Value) {
+				c.issue(w, session.Value)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if reason := c.check(r, token, session.Value); reason != "" {
+			c.log.Warn("Rejected cross-site request",
+				logger.String("reason", reason),
+				logger.String("method", r.Method),
+				logger.String("path", r.URL.Path),
+				logger.String("origin", r.Header.Get("Origin")),
+			)
+			writeAuthError(w, http.StatusForbidden, errCSRFRejected.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// check returns why a state-changing request fails CSRF protection, or ""
+func (c *CSRF) check(r *http.Request, token, session string) string {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !c.trustedOrigin(origin, r) {
+			return "untrusted origin"
+		}
+	} else if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		return "cross-site request"
+	}
+
+	header := r.Header.Get(c.opts.Header)
+	if token == "" || subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+		return "token mismatch"
+	}
+	if !c.valid(token, session) {
+		return "token not issued for session"
+	}
+	return ""
+}
+
+// trustedOrigin reports whether origin is the request's own host or one of
+// the trusted origins
+func (c *CSRF) trustedOrigin(origin string, r *http.Request) bool {
+	normalized, ok := normalizeOrigin(origin)
+	if !ok {
+		return false
+	}
+	if c.origins[normalized] {
+		return true
+	}
+	parsed, _ := url.Parse(normalized)
+	return strings.EqualFold(parsed.Host, r.Host)
+}
+
+// issue sets a new token cookie bound to session. The cookie is readable by
+// scripts so they can echo it, and SameSite=Strict so other sites' requests
+// do not carry it.
+func (c *CSRF) issue(w http.ResponseWriter, session string) {
+	nonce := make([]byte, csrfNonceBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		c.log.Error("Failed to generate CSRF token", logger.Error(err))
+		return
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(nonce)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.opts.Cookie,
+		Value:    encoded + "." + c.sign(encoded, session),
+		Path:     "/",
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// valid reports whether token was issued for session
+func (c *CSRF) valid(token, session string) bool {
+	nonce, signature, ok := strings.Cut(token, ".")
+	if !ok || nonce == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(c.sign(nonce, session)))
+}
+
+// sign returns the token signature binding nonce to session
+func (c *CSRF) sign(nonce, session string) string {
+	sessionHash := sha256.Sum256([]byte(session))
+	mac := hmac.New(sha256.New, c.opts.Secret)
+	mac.Write([]byte("csrf\n"))
+	mac.Write(sessionHash[:])
+	mac.Write([]byte("\n" + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// normalizeOrigin returns origin as lowercase scheme://host[:port]
+func normalizeOrigin(origin string) (string, bool) {
+	parsed, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return "", false
+	}
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host), true
+}
+
+// isSafeMethod reports whether method does not change state (RFC 9110)
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle" // v2.1.6
+	"github.com/spiffe/go-spiffe/v2/spiffeid"          // v2.1.6
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"     // v2.1.6
+)
+
+// spiffeRoleSeparator separates the roles mapped to one SPIFFE ID
+const spiffeRoleSeparator = "|"
+
+var (
+	errMissingSVID    = errors.New("no client certificate presented")
+	errUnmappedSPIFFE = errors.New("SPIFFE ID has no role mapping")
+)
+
+// spiffeAuth is the authenticator Authenticate consults for requests
+// without a bearer token, or nil when SPIFFE authentication is disabled
+var spiffeAuth atomic.Pointer[SPIFFEAuthenticator]
+
+// UseSPIFFE makes Authenticate accept X.509 SVIDs verified by a as an
+// alternative to bearer tokens; nil disables it again
+func UseSPIFFE(a *SPIFFEAuthenticator) {
+	spiffeAuth.Store(a)
+}
+
+// SPIFFEAuthenticator authenticates workloads by the X.509 SVID they present
+// as their mTLS client certificate. Only SPIFFE IDs with a role mapping are
+// accepted, and they act with exactly the mapped roles.
+type SPIFFEAuthenticator struct {
+	bundles x509bundle.Source
+	roles   map[spiffeid.ID][]string
+}
+
+// NewSPIFFEAuthenticator creates a SPIFFEAuthenticator verifying SVIDs
+// against the trust bundles from bundles. roleMappings maps SPIFFE IDs to
+// roles separated by "|".
+func NewSPIFFEAuthenticator(bundles x509bundle.Source, roleMappings map[string]string) (*SPIFFEAuthenticator, error) {
+	if bundles == nil {
+		return nil, errors.New("SPIFFE bundle source is required")
+	}
+	roles, err := ParseSPIFFERoles(roleMappings)
+	if err != nil {
+		return nil, err
+	}
+	return &SPIFFEAuthenticator{bundles: bundles, roles: roles}, nil
+}
+
+// ParseSPIFFERoles parses SPIFFE ID role mappings, rejecting malformed IDs
+// and IDs without roles
+func ParseSPIFFERoles(roleMappings map[string]string) (map[spiffeid.ID][]string, error) {
+	if len(roleMappings) == 0 {
+		return nil, errors.New("at least one SPIFFE ID role mapping is required")
+	}
+
+	roles := make(map[spiffeid.ID][]string, len(roleMappings))
+	for rawID, rawRoles := range roleMappings {
+		id, err := spiffeid.FromString(strings.TrimSpace(rawID))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPIFFE ID %q: %w", rawID, err)
+		}
+
+		var mapped []string
+		for _, role := range strings.Split(rawRoles, spiffeRoleSeparator) {
+			if role = strings.TrimSpace(role); role != "" {
+				mapped = append(mapped, role)
+			}
+		}
+		if len(mapped) == 0 {
+			return nil, fmt.Errorf("SPIFFE ID %s has no roles", id)
+		}
+		roles[id] = mapped
+	}
+	return roles, nil
+}
+
+// Authenticate verifies the client certificate chain of an mTLS connection
+// as an X.509 SVID and returns claims for its SPIFFE ID
+func (a *SPIFFEAuthenticator) Authenticate(state *tls.ConnectionState) (*Claims, error) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil, errMissingSVID
+	}
+
+	id, _, err := x509svid.Verify(state.PeerCertificates, a.bundles)
+	if err != nil {
+		return nil, fmt.Errorf("SVID verification failed: %w", err)
+	}
+
+	roles, ok := a.roles[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnmappedSPIFFE, id)
+	}
+
+	return &Claims{
+		UserID:   id.String(),
+		Roles:    roles,
+		IssuedAt: time.Now(),
+		SPIFFEID: id.String(),
+	}, nil
+}
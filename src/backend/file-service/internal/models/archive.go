@@ -0,0 +1,66 @@
+package models
+
+import (
+    "time"
+)
+
+// Archival storage classes idle files are moved to
+const (
+    StorageClassGlacier     = "GLACIER"
+    StorageClassDeepArchive = "DEEP_ARCHIVE"
+)
+
+// Archive statuses of a file's content. Files in a regular storage class
+// have none. Archived content must be restored before it can be read; a
+// restored copy is readable until RestoreExpiresAt, after which the file is
+// archived again.
+const (
+    ArchiveStatusArchived  = "archived"
+    ArchiveStatusRestoring = "restoring"
+    ArchiveStatusRestored  = "restored"
+)
+
+// IsArchived checks if the file's content is in an archival storage class
+func (f *File) IsArchived() bool {
+    return f.ArchiveStatus != ""
+}
+
+// NeedsRestore checks if the file's content is archived without a readable
+// copy at now
+func (f *File) NeedsRestore(now time.Time) bool {
+    switch f.ArchiveStatus {
+    case ArchiveStatusArchived, ArchiveStatusRestoring:
+        return true
+    case ArchiveStatusRestored:
+        return f.RestoreExpiresAt != nil && !now.Before(*f.RestoreExpiresAt)
+    default:
+        return false
+    }
+}
+
+// MarkArchived records that the content was moved to storageClass
+func (f *File) MarkArchived(storageClass string, now time.Time) {
+    f.StorageClass = storageClass
+    f.ArchiveStatus = ArchiveStatusArchived
+    f.ArchivedAt = &now
+    f.RestoreExpiresAt = nil
+}
+
+// MarkRestoring records that a restore of the archived content was requested
+func (f *File) MarkRestoring() {
+    f.ArchiveStatus = ArchiveStatusRestoring
+    f.RestoreExpiresAt = nil
+}
+
+// MarkRestored records that a restored copy is readable until expiresAt
+func (f *File) MarkRestored(expiresAt *time.Time) {
+    f.ArchiveStatus = ArchiveStatusRestored
+    f.RestoreExpiresAt = expiresAt
+}
+
+// ExpireRestore records that the restored copy is gone, leaving the content
+// archived
+func (f *File) ExpireRestore() {
+    f.ArchiveStatus = ArchiveStatusArchived
+    f.RestoreExpiresAt = nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+    "errors"
+    "regexp"
+    "time"
+)
+
+// ErrInvalidAttachment is returned when an entity reference is malformed
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+var (
+    // entityTypePattern restricts entity types to short lower-case names such as "order"
+    entityTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+    // entityIDPattern allows the identifiers of other services without whitespace
+    entityIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.:@-]{1,128}$`)
+)
+
+// Attachment links a file to a record owned by another service, such as
+// "order" 123, so that service needs no join table of its own
+type Attachment struct {
+    EntityType string    `json:"entityType"`
+    EntityID   string    `json:"entityId"`
+    FileID     string    `json:"fileId"`
+    CreatedAt  time.Time `json:"createdAt"`
+}
+
+// NewAttachment creates a validated attachment of a file to an entity
+func NewAttachment(entityType, entityID, fileID string) (*Attachment, error) {
+    if err := ValidateEntity(entityType, entityID); err != nil {
+        return nil, err
+    }
+    if fileID == "" {
+        return nil, ErrInvalidAttachment
+    }
+
+    return &Attachment{
+        EntityType: entityType,
+        EntityID:   entityID,
+        FileID:     fileID,
+        CreatedAt:  time.Now().UTC(),
+    }, nil
+}
+
+// ValidateEntity checks an entity type and ID reference
+func ValidateEntity(entityType, entityID string) error {
+    if !entityTypePattern.MatchString(entityType) || !entityIDPattern.MatchString(entityID) {
+        return ErrInvalidAttachment
+    }
+    return nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+    "strconv"
+    "time"
+)
+
+// Audit event types
+const (
+    AuditFileStatus        = "file.status"
+    AuditDownloadLink      = "file.download_link"
+    AuditIntegrityIncident = "file.integrity_incident"
+
+    AuditActionRequested = "action.requested"
+    AuditActionApproved  = "action.approved"
+    AuditActionResolved  = "action.resolved"
+)
+
+// AuditEvent is an audited occurrence recorded for export to the signed
+// audit log. ID orders events in the order they were recorded.
+type AuditEvent struct {
+    ID         int64             `json:"id"`
+    Type       string            `json:"type"`
+    FileID     string            `json:"fileId,omitempty"`
+    Detail     map[string]string `json:"detail,omitempty"`
+    OccurredAt time.Time         `json:"occurredAt"`
+    RecordedAt time.Time         `json:"recordedAt"`
+}
+
+// AuditCheckpoint records how far audit events have been exported: the last
+// exported event and the head of the signed batch chain
+type AuditCheckpoint struct {
+    LastEventID int64     `json:"lastEventId"`
+    BatchNumber int64     `json:"batchNumber"`
+    LastSeq     int64     `json:"lastSeq"`
+    BatchHash   string    `json:"batchHash"`
+    UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// NewStatusAuditEvent records a file status transition
+func NewStatusAuditEvent(change StatusChange) *AuditEvent {
+    return &AuditEvent{
+        Type:       AuditFileStatus,
+        FileID:     change.FileID,
+        Detail:     map[string]string{"from": change.From, "to": change.To},
+        OccurredAt: change.At,
+    }
+}
+
+// NewDownloadLinkAuditEvent records a presigned download URL handed out to
+// requestedBy, valid until expiresAt
+func NewDownloadLinkAuditEvent(fileID, requestedBy, ip string, expiresAt, at time.Time) *AuditEvent {
+    return &AuditEvent{
+        Type:   AuditDownloadLink,
+        FileID: fileID,
+        Detail: map[string]string{
+            "requestedBy": requestedBy,
+            "ip":          ip,
+            "expiresAt":   expiresAt.UTC().Format(time.RFC3339),
+        },
+        OccurredAt: at,
+    }
+}
+
+// NewPendingActionAuditEvent records a step in the approval of a pending
+// action: its request, an approval, or its execution, failure or expiry.
+// actor is who took the step, empty for expiry.
+func NewPendingActionAuditEvent(eventType string, action *PendingAction, actor string, at time.Time) *AuditEvent {
+    return &AuditEvent{
+        Type:   eventType,
+        FileID: action.FileID,
+        Detail: map[string]string{
+            "actionId":    action.ID,
+            "kind":        action.Kind,
+            "tenantId":    action.TenantID,
+            "requestedBy": action.RequestedBy,
+            "actor":       actor,
+            "status":      action.Status,
+            "approvals":   strconv.Itoa(len(action.ApprovedBy)),
+        },
+        OccurredAt: at,
+    }
+}
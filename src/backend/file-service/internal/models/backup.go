@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// BackupFormatVersion is the version of the backup layout written, which a
+// restore must know to read a backup
+const BackupFormatVersion = 1
+
+// How a backed-up object is checked on restore
+const (
+    // BackupCheckChecksum compares the object's size and SHA-256 with the file's
+    BackupCheckChecksum = "checksum"
+    // BackupCheckSize only compares the object's size, for content stored
+    // without a checksum
+    BackupCheckSize = "size"
+    // BackupCheckNone skips the object: content encrypted with a customer
+    // key can only be read, or copied, with the key, which is not kept
+    BackupCheckNone = "none"
+)
+
+// BackupManifest describes a backup: a logical dump of the metadata tables
+// and a manifest of the objects they reference, taken from one consistent
+// snapshot. Each part is a file of JSON values, one per line, whose row
+// count and SHA-256 the manifest records so a restore can verify it.
+type BackupManifest struct {
+    Version   int          `json:"version"`
+    CreatedAt time.Time    `json:"createdAt"`
+    Tables    []BackupPart `json:"tables"`
+    Objects   BackupPart   `json:"objects"`
+}
+
+// BackupPart is a file of a backup
+type BackupPart struct {
+    // Name is the table dumped, or "objects" for the object manifest
+    Name     string `json:"name"`
+    File     string `json:"file"`
+    Rows     int64  `json:"rows"`
+    Checksum string `json:"checksum"`
+}
+
+// BackupObject is an object listed in a backup's object manifest, stored
+// under the same key in the environment it is restored to
+type BackupObject struct {
+    Key  string `json:"key"`
+    Size int64  `json:"size"`
+    // Checksum is the file's SHA-256, or a checksum of its parts'
+    // checksums suffixed with -N for content uploaded in N parts
+    Checksum string `json:"checksum"`
+    // Check is how the object is checked: BackupCheckChecksum,
+    // BackupCheckSize or BackupCheckNone
+    Check string `json:"check"`
+}
+
+// NewBackupObject lists the object holding the file's content
+func NewBackupObject(file *File) *BackupObject {
+    check := BackupCheckChecksum
+    switch {
+    case file.UsesCustomerKey():
+        check = BackupCheckNone
+    case file.Checksum == "":
+        check = BackupCheckSize
+    }
+    return &BackupObject{
+        Key:      file.StoragePath,
+        Size:     file.Size,
+        Checksum: file.Checksum,
+        Check:    check,
+    }
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+    "time"
+)
+
+// Blob is a stored object shared by every file with the same content in the
+// same tenant scope. Files hold references to blobs; a blob's object is only
+// deleted once no file references it and a grace period has passed.
+type Blob struct {
+    StorageKey string `json:"storageKey"`
+    // TenantID is the tenant the object's key is scoped to, "" for unscoped
+    // keys. Content is never shared across tenant scopes.
+    TenantID string `json:"tenantId,omitempty"`
+    Checksum string `json:"checksum"`
+    Size     int64  `json:"size"`
+    RefCount int    `json:"refCount"`
+    // UnreferencedAt is when the last reference was released
+    UnreferencedAt *time.Time `json:"unreferencedAt,omitempty"`
+    CreatedAt      time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "sort"
+    "time"
+)
+
+// Derived object kinds
+const (
+    DerivedKindThumbnail   = "thumbnail"
+    DerivedKindPreview     = "preview"
+    DerivedKindSanitized   = "sanitized"
+    DerivedKindWatermarked = "watermarked"
+    DerivedKindConverted   = "converted"
+    DerivedKindContents    = "contents"
+)
+
+// Derived object status constants
+const (
+    DerivedStatusPending = "pending"
+    DerivedStatusReady   = "ready"
+    DerivedStatusFailed  = "failed"
+)
+
+// DerivedObject is content generated from an uploaded file, such as a
+// thumbnail or a sanitized copy. Each parent has at most one object per kind
+// and parameter set, identified by ParamsHash.
+type DerivedObject struct {
+    ID          string            `json:"id"`
+    FileID      string            `json:"fileId"`
+    Kind        string            `json:"kind"`
+    Params      map[string]string `json:"params,omitempty"`
+    ParamsHash  string            `json:"paramsHash"`
+    StorageKey  string            `json:"-"`
+    ContentType string            `json:"contentType,omitempty"`
+    Size        int64             `json:"size"`
+    Status      string            `json:"status"`
+    Error       string            `json:"error,omitempty"`
+    // SourceChecksum is the parent's checksum when the object was generated
+    SourceChecksum string    `json:"-"`
+    CreatedAt      time.Time `json:"createdAt"`
+    UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// DerivedParamsHash returns a stable hash of a kind and its parameters,
+// independent of map order
+func DerivedParamsHash(kind string, params map[string]string) string {
+    keys := make([]string, 0, len(params))
+    for key := range params {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+
+    hash := sha256.New()
+    hash.Write([]byte(kind))
+    for _, key := range keys {
+        hash.Write([]byte{0})
+        hash.Write([]byte(key))
+        hash.Write([]byte{'='})
+        hash.Write([]byte(params[key]))
+    }
+    return hex.EncodeToString(hash.Sum(nil))
+}
+
+// IsReady checks if the derived content has been generated and stored
+func (o *DerivedObject) IsReady() bool {
+    return o.Status == DerivedStatusReady
+}
+
+// IsStaleFor checks if the object was generated from different content than
+// the parent currently holds
+func (o *DerivedObject) IsStaleFor(parent *File) bool {
+    return o.FileID != parent.ID || o.SourceChecksum != parent.Checksum
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+)
+
+// Supported client-side encryption algorithms
+const (
+    EncryptionAlgorithmAES256GCM        = "AES-256-GCM"
+    EncryptionAlgorithmChaCha20Poly1305 = "CHACHA20-POLY1305"
+)
+
+// maxWrappedKeyLength bounds the size of the base64 wrapped key accepted from clients
+const maxWrappedKeyLength = 4096
+
+// ErrInvalidEncryption is returned when client encryption metadata is malformed
+var ErrInvalidEncryption = errors.New("invalid encryption metadata")
+
+// EncryptionMetadata describes content encrypted by the client before upload.
+// The service stores the wrapped data key verbatim and never holds the key
+// needed to unwrap it; EscrowKeyID and EscrowedKey are only set when the
+// wrapped key was additionally encrypted under a KMS escrow key for recovery.
+type EncryptionMetadata struct {
+    Algorithm   string `json:"algorithm"`
+    WrappedKey  string `json:"wrappedKey"`
+    KeyID       string `json:"keyId,omitempty"`
+    IV          string `json:"iv,omitempty"`
+    EscrowKeyID string `json:"-"`
+    EscrowedKey string `json:"-"`
+}
+
+// storedEncryptionMetadata is the persisted form, which includes escrow fields
+// that are never returned to clients
+type storedEncryptionMetadata struct {
+    Algorithm   string `json:"algorithm"`
+    WrappedKey  string `json:"wrappedKey"`
+    KeyID       string `json:"keyId,omitempty"`
+    IV          string `json:"iv,omitempty"`
+    EscrowKeyID string `json:"escrowKeyId,omitempty"`
+    EscrowedKey string `json:"escrowedKey,omitempty"`
+}
+
+// Validate checks the algorithm and the encoding of the key material
+func (m *EncryptionMetadata) Validate() error {
+    switch m.Algorithm {
+    case EncryptionAlgorithmAES256GCM, EncryptionAlgorithmChaCha20Poly1305:
+    default:
+        return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidEncryption, m.Algorithm)
+    }
+
+    if m.WrappedKey == "" || len(m.WrappedKey) > maxWrappedKeyLength {
+        return fmt.Errorf("%w: wrapped key is required and must not exceed %d bytes", ErrInvalidEncryption, maxWrappedKeyLength)
+    }
+    if _, err := base64.StdEncoding.DecodeString(m.WrappedKey); err != nil {
+        return fmt.Errorf("%w: wrapped key must be base64 encoded", ErrInvalidEncryption)
+    }
+    if m.IV != "" {
+        if _, err := base64.StdEncoding.DecodeString(m.IV); err != nil {
+            return fmt.Errorf("%w: iv must be base64 encoded", ErrInvalidEncryption)
+        }
+    }
+
+    return nil
+}
+
+// IsEscrowed reports whether the wrapped key has been escrowed
+func (m *EncryptionMetadata) IsEscrowed() bool {
+    return m != nil && m.EscrowedKey != ""
+}
+
+// Value implements driver.Valuer, storing the metadata as JSON
+func (m *EncryptionMetadata) Value() (driver.Value, error) {
+    if m == nil {
+        return nil, nil
+    }
+    return json.Marshal(storedEncryptionMetadata(*m))
+}
+
+// Scan implements sql.Scanner for metadata stored as JSON
+func (m *EncryptionMetadata) Scan(src interface{}) error {
+    var data []byte
+    switch v := src.(type) {
+    case []byte:
+        data = v
+    case string:
+        data = []byte(v)
+    default:
+        return fmt.Errorf("unsupported encryption metadata type %T", src)
+    }
+
+    var stored storedEncryptionMetadata
+    if err := json.Unmarshal(data, &stored); err != nil {
+        return err
+    }
+    *m = EncryptionMetadata(stored)
+    return nil
+}
+
+// Server-side encryption algorithms S3 applies to stored objects. SSE-C
+// objects are encrypted with a key the client supplies with each request.
+const (
+    SSEAlgorithmAES256   = "AES256"
+    SSEAlgorithmKMS      = "aws:kms"
+    SSEAlgorithmCustomer = "SSE-C"
+)
+
+// ServerSideEncryption records how S3 encrypted a stored object. KMSKeyID
+// is the ARN of the key under aws:kms, kept so objects can be found by key
+// when one is rotated or disabled.
+type ServerSideEncryption struct {
+    Algorithm string `json:"algorithm"`
+    KMSKeyID  string `json:"kmsKeyId,omitempty"`
+}
+
+// Value implements driver.Valuer, storing the encryption as JSON
+func (e *ServerSideEncryption) Value() (driver.Value, error) {
+    if e == nil {
+        return nil, nil
+    }
+    return json.Marshal(*e)
+}
+
+// Scan implements sql.Scanner for encryption stored as JSON
+func (e *ServerSideEncryption) Scan(src interface{}) error {
+    switch v := src.(type) {
+    case []byte:
+        return json.Unmarshal(v, e)
+    case string:
+        return json.Unmarshal([]byte(v), e)
+    default:
+        return fmt.Errorf("unsupported server-side encryption type %T", src)
+    }
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid" // v1.3.0
+)
+
+// Extraction job statuses
+const (
+    ExtractionPending   = "pending"
+    ExtractionRunning   = "running"
+    ExtractionCompleted = "completed"
+    ExtractionFailed    = "failed"
+)
+
+// MaxExtractionErrors bounds the entry errors a job records; further
+// failures are only counted
+const MaxExtractionErrors = 100
+
+// ExtractionEntryError is an archive entry that was not extracted, and why
+type ExtractionEntryError struct {
+    Name  string `json:"name"`
+    Error string `json:"error"`
+}
+
+// ExtractionJob expands an uploaded archive into a file per entry under
+// Folder. It runs in the background, recording its progress as it goes.
+type ExtractionJob struct {
+    ID      string `json:"id"`
+    FileID  string `json:"fileId"`
+    Folder  string `json:"folder"`
+    OwnerID string `json:"ownerId,omitempty"`
+    Status  string `json:"status"`
+    // TotalEntries is the number of entries in the archive, 0 until known;
+    // tar archives are only counted once read to their end
+    TotalEntries     int                    `json:"totalEntries"`
+    ProcessedEntries int                    `json:"processedEntries"`
+    ExtractedFiles   int                    `json:"extractedFiles"`
+    FailedEntries    int                    `json:"failedEntries"`
+    ExtractedBytes   int64                  `json:"extractedBytes"`
+    FileIDs          []string               `json:"fileIds"`
+    Errors           []ExtractionEntryError `json:"errors,omitempty"`
+    // Error is why the job as a whole failed
+    Error       string     `json:"error,omitempty"`
+    CreatedAt   time.Time  `json:"createdAt"`
+    UpdatedAt   time.Time  `json:"updatedAt"`
+    CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// NewExtractionJob creates a pending job extracting fileID into folder
+func NewExtractionJob(fileID, folder, ownerID string) *ExtractionJob {
+    now := time.Now().UTC()
+    return &ExtractionJob{
+        ID:        uuid.New().String(),
+        FileID:    fileID,
+        Folder:    folder,
+        OwnerID:   ownerID,
+        Status:    ExtractionPending,
+        FileIDs:   []string{},
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+}
+
+// IsDone checks if the job has completed or failed
+func (j *ExtractionJob) IsDone() bool {
+    return j.Status == ExtractionCompleted || j.Status == ExtractionFailed
+}
+
+// Extracted records an entry extracted into a file
+func (j *ExtractionJob) Extracted(fileID string, size int64) {
+    j.ProcessedEntries++
+    j.ExtractedFiles++
+    j.ExtractedBytes += size
+    j.FileIDs = append(j.FileIDs, fileID)
+}
+
+// Skipped records an entry that needs no file, such as a directory
+func (j *ExtractionJob) Skipped() {
+    j.ProcessedEntries++
+}
+
+// EntryFailed records an entry that could not be extracted
+func (j *ExtractionJob) EntryFailed(name string, err error) {
+    j.ProcessedEntries++
+    j.FailedEntries++
+    if len(j.Errors) < MaxExtractionErrors {
+        j.Errors = append(j.Errors, ExtractionEntryError{Name: name, Error: err.Error()})
+    }
+}
+
+// Finish ends the job, failed with err if it is not nil
+func (j *ExtractionJob) Finish(err error) {
+    now := time.Now().UTC()
+    j.Status = ExtractionCompleted
+    if err != nil {
+        j.Status = ExtractionFailed
+        j.Error = err.Error()
+    }
+    j.UpdatedAt = now
+    j.CompletedAt = &now
+}
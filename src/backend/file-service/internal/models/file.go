@@ -8,6 +8,7 @@ import (
     "github.com/google/uuid" // v1.3.0
     "src/backend/file-service/pkg/validator"
     "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/thumbnail"
 )
 
 // File status constants
@@ -16,27 +17,79 @@ const (
     FileStatusUploaded = "uploaded"
     FileStatusFailed   = "failed"
     FileStatusDeleted  = "deleted"
+    FileStatusDraft    = "draft"
+)
+
+// Replication statuses of a file's copy in the secondary bucket. Files not
+// yet copied have none; pending files failed to copy and are retried.
+// Skipped files are never copied, since the service cannot read them.
+const (
+    ReplicationPending    = "pending"
+    ReplicationReplicated = "replicated"
+    ReplicationFailed     = "failed"
+    ReplicationSkipped    = "skipped"
 )
 
 // Error definitions
 var (
     ErrInvalidStatus = errors.New("invalid file status")
     ErrInvalidPath   = errors.New("invalid storage path")
+    ErrNotDraft      = errors.New("file is not a draft")
+    ErrDraftExpired  = errors.New("draft has expired")
     MaxFileSize      = int64(100 * 1024 * 1024) // 100MB
 )
 
 // File represents a secure file entity with comprehensive metadata
 type File struct {
-    ID            string    `json:"id" bson:"_id"`
-    FileName      string    `json:"fileName" bson:"fileName"`
-    Size          int64     `json:"size" bson:"size"`
-    ContentType   string    `json:"contentType" bson:"contentType"`
-    Status        string    `json:"status" bson:"status"`
-    StoragePath   string    `json:"storagePath" bson:"storagePath"`
-    Checksum      string    `json:"checksum" bson:"checksum"`
-    CreatedAt     time.Time `json:"createdAt" bson:"createdAt"`
-    UpdatedAt     time.Time `json:"updatedAt" bson:"updatedAt"`
-    LastAccessedAt time.Time `json:"lastAccessedAt" bson:"lastAccessedAt"`
+    ID                 string              `json:"id" bson:"_id"`
+    FileName           string              `json:"fileName" bson:"fileName"`
+    Folder             string              `json:"folder" bson:"folder"`
+    Size               int64               `json:"size" bson:"size"`
+    ContentType        string              `json:"contentType" bson:"contentType"`
+    Status             string              `json:"status" bson:"status"`
+    StoragePath        string              `json:"storagePath" bson:"storagePath"`
+    Checksum           string              `json:"checksum" bson:"checksum"`
+    Encryption         *EncryptionMetadata `json:"encryption,omitempty" bson:"encryption,omitempty"`
+    PreviewStoragePath string              `json:"-" bson:"previewStoragePath,omitempty"`
+    DraftExpiresAt     *time.Time          `json:"draftExpiresAt,omitempty" bson:"draftExpiresAt,omitempty"`
+    OwnerID            string              `json:"ownerId,omitempty" bson:"ownerId,omitempty"`
+    Tags               Tags                `json:"tags,omitempty" bson:"tags,omitempty"`
+    Version            int64               `json:"version" bson:"version"`
+    CreatedAt          time.Time           `json:"createdAt" bson:"createdAt"`
+    UpdatedAt          time.Time           `json:"updatedAt" bson:"updatedAt"`
+    LastAccessedAt     time.Time           `json:"lastAccessedAt" bson:"lastAccessedAt"`
+
+    // ServerSideEncryption is the encryption S3 applied to the content;
+    // files stored before it was recorded have none and use AES256
+    ServerSideEncryption *ServerSideEncryption `json:"serverSideEncryption,omitempty" bson:"serverSideEncryption,omitempty"`
+
+    // S3 Object Lock protection of the content in compliance mode: a
+    // retention of RetentionMode until RetainUntil, a legal hold, or both
+    RetentionMode string     `json:"retentionMode,omitempty" bson:"retentionMode,omitempty"`
+    RetainUntil   *time.Time `json:"retainUntil,omitempty" bson:"retainUntil,omitempty"`
+    LegalHold     bool       `json:"legalHold,omitempty" bson:"legalHold,omitempty"`
+
+    // Replication of the content to the secondary bucket
+    ReplicationStatus   string     `json:"replicationStatus,omitempty" bson:"replicationStatus,omitempty"`
+    ReplicationAttempts int        `json:"-" bson:"replicationAttempts,omitempty"`
+    ReplicatedAt        *time.Time `json:"replicatedAt,omitempty" bson:"replicatedAt,omitempty"`
+
+    // Tiering and archival of idle content: the storage class it was moved
+    // to, when it was archived and the state of its restore; StorageClass
+    // is empty for content in the bucket's default class
+    StorageClass     string     `json:"storageClass,omitempty" bson:"storageClass,omitempty"`
+    ArchiveStatus    string     `json:"archiveStatus,omitempty" bson:"archiveStatus,omitempty"`
+    ArchivedAt       *time.Time `json:"archivedAt,omitempty" bson:"archivedAt,omitempty"`
+    RestoreExpiresAt *time.Time `json:"restoreExpiresAt,omitempty" bson:"restoreExpiresAt,omitempty"`
+
+    // ThumbnailSrcset describes the file's thumbnails as an HTML srcset
+    // attribute value of preview links, set on metadata returned to callers
+    // who may download the file and never stored
+    ThumbnailSrcset string `json:"thumbnailSrcset,omitempty" bson:"-"`
+
+    // statusChanges are the transitions applied since the file was last
+    // saved, which the repository audits in the same transaction
+    statusChanges []StatusChange
 }
 
 // NewFile creates a new File instance with comprehensive validation
@@ -46,22 +99,22 @@ func NewFile(fileName string, size int64, contentType string) (*File, error) {
     // Validate file attributes
     if err := validator.ValidateFileName(fileName); err != nil {
         log.Error("File name validation failed",
-            logger.zap.String("fileName", fileName),
-            logger.zap.Error(err))
+            logger.String("fileName", fileName),
+            logger.Error(err))
         return nil, err
     }
 
-    if err := validator.ValidateFileSize(size); err != nil {
+    if err := validator.ValidateFileSizeLimit(size, validator.MaxObjectSize); err != nil {
         log.Error("File size validation failed",
-            logger.zap.Int64("size", size),
-            logger.zap.Error(err))
+            logger.Int64("size", size),
+            logger.Error(err))
         return nil, err
     }
 
-    if err := validator.ValidateFileType(contentType, nil); err != nil {
+    if err := validator.ValidateContentType(contentType); err != nil {
         log.Error("Content type validation failed",
-            logger.zap.String("contentType", contentType),
-            logger.zap.Error(err))
+            logger.String("contentType", contentType),
+            logger.Error(err))
         return nil, err
     }
 
@@ -81,43 +134,52 @@ func NewFile(fileName string, size int64, contentType string) (*File, error) {
     }
 
     log.Info("Created new file instance",
-        logger.zap.String("fileId", fileID),
-        logger.zap.String("fileName", fileName))
+        logger.String("fileId", fileID),
+        logger.String("fileName", fileName))
 
     return file, nil
 }
 
-// UpdateStatus updates the file status with validation
+// UpdateStatus moves the file to status if FileLifecycle allows the transition
 func (f *File) UpdateStatus(status string) error {
     log := logger.GetLogger()
 
     // Validate status transition
-    validStatuses := map[string]bool{
-        FileStatusPending:  true,
-        FileStatusUploaded: true,
-        FileStatusFailed:   true,
-        FileStatusDeleted:  true,
+    from := f.Status
+    if !FileLifecycle.IsValid(status) {
+        log.Error("Invalid file status",
+            logger.String("fileId", f.ID),
+            logger.String("currentStatus", from),
+            logger.String("newStatus", status))
+        return ErrInvalidStatus
     }
-
-    if !validStatuses[status] {
+    if err := FileLifecycle.Transition(f, status); err != nil {
         log.Error("Invalid status transition",
-            logger.zap.String("fileId", f.ID),
-            logger.zap.String("currentStatus", f.Status),
-            logger.zap.String("newStatus", status))
-        return ErrInvalidStatus
+            logger.String("fileId", f.ID),
+            logger.String("currentStatus", from),
+            logger.String("newStatus", status))
+        return err
     }
 
-    // Update status and timestamp
-    f.Status = status
-    f.UpdatedAt = time.Now().UTC()
-
     log.Info("Updated file status",
-        logger.zap.String("fileId", f.ID),
-        logger.zap.String("status", status))
+        logger.String("fileId", f.ID),
+        logger.String("status", status))
 
     return nil
 }
 
+// StatusChanges returns the status transitions applied since the file was
+// last saved
+func (f *File) StatusChanges() []StatusChange {
+    return f.statusChanges
+}
+
+// ResetStatusChanges forgets the transitions returned by StatusChanges, once
+// they are saved
+func (f *File) ResetStatusChanges() {
+    f.statusChanges = nil
+}
+
 // SetStoragePath sets the validated storage path
 func (f *File) SetStoragePath(path string) error {
     log := logger.GetLogger()
@@ -125,9 +187,9 @@ func (f *File) SetStoragePath(path string) error {
     // Validate storage path
     if err := validator.ValidateStoragePath(path); err != nil {
         log.Error("Storage path validation failed",
-            logger.zap.String("fileId", f.ID),
-            logger.zap.String("path", path),
-            logger.zap.Error(err))
+            logger.String("fileId", f.ID),
+            logger.String("path", path),
+            logger.Error(err))
         return ErrInvalidPath
     }
 
@@ -136,8 +198,8 @@ func (f *File) SetStoragePath(path string) error {
     f.UpdatedAt = time.Now().UTC()
 
     log.Info("Updated file storage path",
-        logger.zap.String("fileId", f.ID),
-        logger.zap.String("path", path))
+        logger.String("fileId", f.ID),
+        logger.String("path", path))
 
     return nil
 }
@@ -148,7 +210,7 @@ func (f *File) UpdateChecksum(checksum string) error {
 
     if checksum == "" {
         log.Error("Empty checksum provided",
-            logger.zap.String("fileId", f.ID))
+            logger.String("fileId", f.ID))
         return errors.New("checksum cannot be empty")
     }
 
@@ -156,8 +218,8 @@ func (f *File) UpdateChecksum(checksum string) error {
     f.UpdatedAt = time.Now().UTC()
 
     log.Info("Updated file checksum",
-        logger.zap.String("fileId", f.ID),
-        logger.zap.String("checksum", checksum))
+        logger.String("fileId", f.ID),
+        logger.String("checksum", checksum))
 
     return nil
 }
@@ -172,6 +234,103 @@ func (f *File) IsUploaded() bool {
     return f.Status == FileStatusUploaded
 }
 
+// IsClientEncrypted checks if the content was encrypted by the client before upload
+func (f *File) IsClientEncrypted() bool {
+    return f.Encryption != nil
+}
+
+// ServerSideEncryptionOrDefault returns the encryption S3 applied to the
+// content, which is AES256 for files stored before it was recorded
+func (f *File) ServerSideEncryptionOrDefault() ServerSideEncryption {
+    if f.ServerSideEncryption == nil {
+        return ServerSideEncryption{Algorithm: SSEAlgorithmAES256}
+    }
+    return *f.ServerSideEncryption
+}
+
+// UsesCustomerKey checks if S3 encrypted the content with a key supplied by
+// the client, which every read of the content must present again
+func (f *File) UsesCustomerKey() bool {
+    return f.ServerSideEncryption != nil && f.ServerSideEncryption.Algorithm == SSEAlgorithmCustomer
+}
+
+// IsRetained checks if Object Lock protection keeps the content from being
+// deleted at now
+func (f *File) IsRetained(now time.Time) bool {
+    return f.LegalHold || (f.RetainUntil != nil && now.Before(*f.RetainUntil))
+}
+
+// AwaitsReplication checks if the file's content still has to be copied to
+// the secondary bucket
+func (f *File) AwaitsReplication() bool {
+    return f.IsUploaded() && (f.ReplicationStatus == "" || f.ReplicationStatus == ReplicationPending)
+}
+
+// HasSanitizedPreview checks if a sanitized copy exists for inline previews
+func (f *File) HasSanitizedPreview() bool {
+    return f.PreviewStoragePath != ""
+}
+
+// HasThumbnails checks if thumbnails can be rendered for the file
+func (f *File) HasThumbnails() bool {
+    return f.IsUploaded() && !f.IsClientEncrypted() && !f.UsesCustomerKey() && thumbnail.Supports(f.ContentType)
+}
+
+// IsDraft checks if the file is an uncommitted draft
+func (f *File) IsDraft() bool {
+    return f.Status == FileStatusDraft
+}
+
+// IsDraftExpired checks if a draft was not committed before its expiry
+func (f *File) IsDraftExpired() bool {
+    return f.IsDraft() && f.DraftExpiresAt != nil && time.Now().UTC().After(*f.DraftExpiresAt)
+}
+
+// MarkDraft makes the file a draft that expires unless committed within ttl
+func (f *File) MarkDraft(ttl time.Duration) error {
+    if err := f.UpdateStatus(FileStatusDraft); err != nil {
+        return err
+    }
+    expiresAt := f.UpdatedAt.Add(ttl)
+    f.DraftExpiresAt = &expiresAt
+    return nil
+}
+
+// CommitDraft promotes a draft to a regular uploaded file
+func (f *File) CommitDraft() error {
+    if !f.IsDraft() {
+        return ErrNotDraft
+    }
+    if f.IsDraftExpired() {
+        return ErrDraftExpired
+    }
+    if err := f.UpdateStatus(FileStatusUploaded); err != nil {
+        return err
+    }
+    f.DraftExpiresAt = nil
+    return nil
+}
+
+// Rename changes the file's logical name. The storage key is unaffected.
+func (f *File) Rename(fileName string) error {
+    if err := validator.ValidateFileName(fileName); err != nil {
+        return err
+    }
+    f.FileName = fileName
+    f.UpdatedAt = time.Now().UTC()
+    return nil
+}
+
+// MoveTo changes the file's logical folder. The storage key is unaffected.
+func (f *File) MoveTo(folder string) error {
+    if err := validator.ValidateFolder(folder); err != nil {
+        return err
+    }
+    f.Folder = folder
+    f.UpdatedAt = time.Now().UTC()
+    return nil
+}
+
 // IsDeleted checks if the file is in deleted status
 func (f *File) IsDeleted() bool {
     return f.Status == FileStatusDeleted
@@ -182,10 +341,13 @@ func (f *File) Validate() error {
     if err := validator.ValidateFileName(f.FileName); err != nil {
         return err
     }
-    if err := validator.ValidateFileSize(f.Size); err != nil {
+    if err := validator.ValidateFolder(f.Folder); err != nil {
+        return err
+    }
+    if err := validator.ValidateFileSizeLimit(f.Size, validator.MaxObjectSize); err != nil {
         return err
     }
-    if err := validator.ValidateFileType(f.ContentType, nil); err != nil {
+    if err := validator.ValidateContentType(f.ContentType); err != nil {
         return err
     }
     return nil
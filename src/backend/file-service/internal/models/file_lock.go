@@ -0,0 +1,24 @@
+package models
+
+import (
+    "time"
+)
+
+// FileLock grants one user the exclusive right to modify a file until it
+// expires, so collaborators editing a shared document don't clobber each other
+type FileLock struct {
+    FileID    string    `json:"fileId"`
+    OwnerID   string    `json:"ownerId"`
+    ExpiresAt time.Time `json:"expiresAt"`
+    CreatedAt time.Time `json:"createdAt"`
+}
+
+// IsExpired checks if the lock has lapsed at the given time
+func (l *FileLock) IsExpired(now time.Time) bool {
+    return !now.Before(l.ExpiresAt)
+}
+
+// IsHeldBy checks if the lock is held by the given user at the given time
+func (l *FileLock) IsHeldBy(userID string, now time.Time) bool {
+    return l.OwnerID == userID && !l.IsExpired(now)
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+    "errors"
+    "mime"
+    "time"
+
+    "github.com/google/uuid" // v1.3.0
+    "src/backend/file-service/pkg/validator"
+)
+
+// File request errors
+var (
+    ErrInvalidFileRequest = errors.New("invalid file request")
+    ErrFileRequestClosed  = errors.New("file request is closed")
+)
+
+const (
+    // MaxFileRequestFiles bounds the files a single request can receive
+    MaxFileRequestFiles = 1000
+    // MaxFileRequestTitleLength bounds the title shown to uploaders
+    MaxFileRequestTitleLength = 200
+)
+
+// FileRequest is an upload inbox: a link that lets people without an account
+// upload files into the owner's folder, within the request's limits, until
+// it expires or is revoked. The ID is the capability embedded in the link.
+type FileRequest struct {
+    ID           string     `json:"id"`
+    OwnerID      string     `json:"ownerId"`
+    Title        string     `json:"title"`
+    Folder       string     `json:"folder"`
+    MaxFileSize  int64      `json:"maxFileSize"`
+    AllowedTypes []string   `json:"allowedTypes"`
+    MaxFiles     int        `json:"maxFiles"`
+    UploadCount  int        `json:"uploadCount"`
+    ExpiresAt    time.Time  `json:"expiresAt"`
+    RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+    CreatedAt    time.Time  `json:"createdAt"`
+    LastUploadAt *time.Time `json:"lastUploadAt,omitempty"`
+
+    // OwnerRoles are the owner's roles when the request was created; uploads
+    // are held to the owner's upload policy
+    OwnerRoles []string `json:"-"`
+    // OwnerTenant is the tenant the owner acted for when the request was
+    // created; uploads are stored in that tenant's scope
+    OwnerTenant string `json:"-"`
+}
+
+// NewFileRequest creates a validated file request expiring after ttl
+func NewFileRequest(ownerID, title, folder string, maxFileSize int64, allowedTypes []string,
+    maxFiles int, ttl time.Duration) (*FileRequest, error) {
+    if ttl <= 0 {
+        return nil, ErrInvalidFileRequest
+    }
+    if allowedTypes == nil {
+        allowedTypes = []string{}
+    }
+
+    now := time.Now().UTC()
+    request := &FileRequest{
+        ID:           uuid.New().String(),
+        OwnerID:      ownerID,
+        Title:        title,
+        Folder:       folder,
+        MaxFileSize:  maxFileSize,
+        AllowedTypes: allowedTypes,
+        MaxFiles:     maxFiles,
+        ExpiresAt:    now.Add(ttl),
+        CreatedAt:    now,
+    }
+    if err := request.Validate(); err != nil {
+        return nil, err
+    }
+    return request, nil
+}
+
+// Validate checks the request's owner, title, folder and limits
+func (r *FileRequest) Validate() error {
+    if r.OwnerID == "" || r.Title == "" || len(r.Title) > MaxFileRequestTitleLength {
+        return ErrInvalidFileRequest
+    }
+    if err := validator.ValidateFolder(r.Folder); err != nil {
+        return ErrInvalidFileRequest
+    }
+    if r.MaxFileSize <= 0 || r.MaxFileSize > validator.MaxObjectSize {
+        return ErrInvalidFileRequest
+    }
+    if r.MaxFiles <= 0 || r.MaxFiles > MaxFileRequestFiles {
+        return ErrInvalidFileRequest
+    }
+    for _, contentType := range r.AllowedTypes {
+        if _, _, err := mime.ParseMediaType(contentType); err != nil {
+            return ErrInvalidFileRequest
+        }
+    }
+    return nil
+}
+
+// IsOpen checks if the request still accepts uploads at the given time
+func (r *FileRequest) IsOpen(now time.Time) bool {
+    return r.RevokedAt == nil && now.Before(r.ExpiresAt) && r.UploadCount < r.MaxFiles
+}
+
+// LastActivity is when a file was last uploaded through the request, or
+// when it was created if nothing was uploaded yet
+func (r *FileRequest) LastActivity() time.Time {
+    if r.LastUploadAt != nil {
+        return *r.LastUploadAt
+    }
+    return r.CreatedAt
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+    "errors"
+    "sync"
+    "time"
+)
+
+// File statuses added by content scanning and integrity checks
+const (
+    FileStatusScanning      = "scanning"
+    FileStatusInfected      = "infected"
+    FileStatusPendingReview = "pending_review"
+    FileStatusCorrupted     = "corrupted"
+)
+
+// ErrInvalidTransition is returned for status changes the lifecycle does not allow
+var ErrInvalidTransition = errors.New("invalid file status transition")
+
+// StatusChange describes a status transition applied to a file
+type StatusChange struct {
+    FileID string
+    From   string
+    To     string
+    At     time.Time
+}
+
+// TransitionHook is called after a file changes status, for events and
+// metrics. It runs before the change is saved, so the audit log records
+// changes from the file's StatusChanges as it is saved instead.
+type TransitionHook func(change StatusChange)
+
+// StatusMachine enforces a declarative set of allowed status transitions
+type StatusMachine struct {
+    transitions map[string]map[string]bool
+
+    mu       sync.RWMutex
+    hooks    []registeredHook
+    lastHook uint64
+}
+
+// registeredHook is a hook with the ID it is unregistered by
+type registeredHook struct {
+    id   uint64
+    hook TransitionHook
+}
+
+// NewStatusMachine creates a state machine allowing, for each status, the
+// listed target statuses. Every status must appear as a key, even terminal ones.
+func NewStatusMachine(transitions map[string][]string) *StatusMachine {
+    m := &StatusMachine{transitions: make(map[string]map[string]bool, len(transitions))}
+    for from, targets := range transitions {
+        allowed := make(map[string]bool, len(targets))
+        for _, to := range targets {
+            allowed[to] = true
+        }
+        m.transitions[from] = allowed
+    }
+    return m
+}
+
+// IsValid reports whether status is a known status
+func (m *StatusMachine) IsValid(status string) bool {
+    _, ok := m.transitions[status]
+    return ok
+}
+
+// CanTransition reports whether a file may move from one status to another.
+// Staying in a known status is always allowed.
+func (m *StatusMachine) CanTransition(from, to string) bool {
+    if !m.IsValid(from) || !m.IsValid(to) {
+        return false
+    }
+    return from == to || m.transitions[from][to]
+}
+
+// OnTransition registers a hook called after every status change, until
+// the returned function unregisters it
+func (m *StatusMachine) OnTransition(hook TransitionHook) (unregister func()) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.lastHook++
+    id := m.lastHook
+    m.hooks = append(m.hooks, registeredHook{id: id, hook: hook})
+
+    return func() {
+        m.mu.Lock()
+        defer m.mu.Unlock()
+        // Transitions in progress keep iterating the slice they read, so it
+        // is replaced rather than changed in place
+        hooks := make([]registeredHook, 0, len(m.hooks))
+        for _, registered := range m.hooks {
+            if registered.id != id {
+                hooks = append(hooks, registered)
+            }
+        }
+        m.hooks = hooks
+    }
+}
+
+// Transition moves the file to status, recording the change on the file and
+// notifying hooks unless the status is unchanged
+func (m *StatusMachine) Transition(f *File, status string) error {
+    from := f.Status
+    if !m.CanTransition(from, status) {
+        return ErrInvalidTransition
+    }
+
+    f.Status = status
+    f.UpdatedAt = time.Now().UTC()
+    if from == status {
+        return nil
+    }
+
+    change := StatusChange{FileID: f.ID, From: from, To: status, At: f.UpdatedAt}
+    f.statusChanges = append(f.statusChanges, change)
+    m.mu.RLock()
+    hooks := m.hooks
+    m.mu.RUnlock()
+    for _, registered := range hooks {
+        registered.hook(change)
+    }
+    return nil
+}
+
+// FileLifecycle is the state machine governing File.Status. Content is
+// scanned before it becomes available; infected and failed files can only
+// be deleted, and deleted is terminal.
+var FileLifecycle = NewStatusMachine(map[string][]string{
+    FileStatusPending:       {FileStatusScanning, FileStatusUploaded, FileStatusDraft, FileStatusFailed, FileStatusDeleted},
+    FileStatusDraft:         {FileStatusScanning, FileStatusUploaded, FileStatusFailed, FileStatusDeleted},
+    FileStatusScanning:      {FileStatusUploaded, FileStatusInfected, FileStatusPendingReview, FileStatusFailed, FileStatusDeleted},
+    FileStatusPendingReview: {FileStatusUploaded, FileStatusInfected, FileStatusDeleted},
+    FileStatusUploaded:      {FileStatusPendingReview, FileStatusCorrupted, FileStatusDeleted},
+    FileStatusCorrupted:     {FileStatusUploaded, FileStatusDeleted},
+    FileStatusInfected:      {FileStatusDeleted},
+    FileStatusFailed:        {FileStatusDeleted},
+    FileStatusDeleted:       {},
+})
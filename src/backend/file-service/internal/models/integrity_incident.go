@@ -0,0 +1,74 @@
+package models
+
+import (
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Outcomes of an integrity incident
+const (
+    // IntegrityTransient is a mismatch a second read of the content did not
+    // repeat, so the file was released again
+    IntegrityTransient = "transient"
+    // IntegrityRepaired is damaged content replaced with its intact replica
+    IntegrityRepaired = "repaired"
+    // IntegrityUnrepaired is damaged content left frozen, for want of an
+    // intact replica or because the repair failed
+    IntegrityUnrepaired = "unrepaired"
+)
+
+// IntegrityIncident records the investigation of content found not to match
+// its checksum: what was read, what each copy held and what became of the
+// file. Checksums are "" for copies that could not be read.
+type IntegrityIncident struct {
+    FileID      string
+    StoragePath string
+    Size        int64
+    // DetectedBy is how the mismatch was found, such as "download"
+    DetectedBy string
+    // Expected is the checksum taken on upload; Actual that of the content
+    // read when the mismatch was found, BytesRead long
+    Expected  string
+    Actual    string
+    BytesRead int64
+    // Reread is the checksum of a second read of the stored content
+    Reread string
+    // ReplicationStatus is the file's replication status when detected;
+    // Replica is the checksum of its secondary copy
+    ReplicationStatus string
+    Replica           string
+    Outcome           string
+    // Errors are the failures met while investigating
+    Errors     []string
+    DetectedAt time.Time
+    ResolvedAt time.Time
+}
+
+// Fail records a failure met while investigating
+func (i *IntegrityIncident) Fail(step string, err error) {
+    i.Errors = append(i.Errors, step+": "+err.Error())
+}
+
+// NewIntegrityAuditEvent records an integrity incident with its diagnostics
+func NewIntegrityAuditEvent(incident *IntegrityIncident) *AuditEvent {
+    return &AuditEvent{
+        Type:   AuditIntegrityIncident,
+        FileID: incident.FileID,
+        Detail: map[string]string{
+            "storagePath":       incident.StoragePath,
+            "size":              strconv.FormatInt(incident.Size, 10),
+            "detectedBy":        incident.DetectedBy,
+            "expectedChecksum":  incident.Expected,
+            "actualChecksum":    incident.Actual,
+            "bytesRead":         strconv.FormatInt(incident.BytesRead, 10),
+            "rereadChecksum":    incident.Reread,
+            "replicationStatus": incident.ReplicationStatus,
+            "replicaChecksum":   incident.Replica,
+            "outcome":           incident.Outcome,
+            "errors":            strings.Join(incident.Errors, "; "),
+            "resolvedAt":        incident.ResolvedAt.UTC().Format(time.RFC3339),
+        },
+        OccurredAt: incident.DetectedAt,
+    }
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+    "errors"
+    "net/mail"
+    "net/url"
+    "strings"
+    "time"
+
+    "src/backend/file-service/pkg/validator"
+)
+
+// ErrInvalidPreferences is returned when notification preferences are malformed
+var ErrInvalidPreferences = errors.New("invalid notification preferences")
+
+// Notification channels
+const (
+    ChannelEmail   = "email"
+    ChannelWebhook = "webhook"
+)
+
+// MaxDigestFolders bounds the folders a user can follow in their digest
+const MaxDigestFolders = 50
+
+// MaxDownloadWatches bounds the files and the folders a user can watch for
+// downloads
+const MaxDownloadWatches = 100
+
+// NotificationPreferences are a user's notification settings: which channels
+// are told immediately when a file is shared with them, uploaded through one
+// of their file requests or downloaded from their watched files and folders,
+// and which folders are summarized in their daily upload digest
+type NotificationPreferences struct {
+    UserID           string     `json:"userId"`
+    Email            string     `json:"email,omitempty"`
+    WebhookURL       string     `json:"webhookUrl,omitempty"`
+    ShareChannels    []string   `json:"shareChannels"`
+    RequestChannels  []string   `json:"requestChannels"`
+    DownloadChannels []string   `json:"downloadChannels"`
+    DownloadFiles    []string   `json:"downloadFiles"`
+    DownloadFolders  []string   `json:"downloadFolders"`
+    DigestEnabled    bool       `json:"digestEnabled"`
+    DigestFolders    []string   `json:"digestFolders"`
+    LastDigestAt     *time.Time `json:"lastDigestAt,omitempty"`
+    UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+// DefaultNotificationPreferences returns the settings of a user who never
+// changed them: nothing is sent
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+    return &NotificationPreferences{
+        UserID:           userID,
+        ShareChannels:    []string{},
+        RequestChannels:  []string{},
+        DownloadChannels: []string{},
+        DownloadFiles:    []string{},
+        DownloadFolders:  []string{},
+        DigestFolders:    []string{},
+    }
+}
+
+// Validate checks that every enabled channel has a usable address
+func (p *NotificationPreferences) Validate() error {
+    if p.UserID == "" {
+        return ErrInvalidPreferences
+    }
+    if p.Email != "" {
+        if _, err := mail.ParseAddress(p.Email); err != nil {
+            return ErrInvalidPreferences
+        }
+    }
+    if p.WebhookURL != "" {
+        u, err := url.Parse(p.WebhookURL)
+        if err != nil || u.Scheme != "https" || u.Host == "" {
+            return ErrInvalidPreferences
+        }
+    }
+
+    channels := append(append([]string(nil), p.ShareChannels...), p.RequestChannels...)
+    for _, channel := range append(channels, p.DownloadChannels...) {
+        if !p.HasAddress(channel) {
+            return ErrInvalidPreferences
+        }
+    }
+    if p.DigestEnabled && (p.Email == "" || len(p.DigestFolders) == 0) {
+        return ErrInvalidPreferences
+    }
+    if len(p.DigestFolders) > MaxDigestFolders {
+        return ErrInvalidPreferences
+    }
+    for _, folder := range p.DigestFolders {
+        if folder == "" || validator.ValidateFolder(folder) != nil {
+            return ErrInvalidPreferences
+        }
+    }
+    if len(p.DownloadFiles) > MaxDownloadWatches || len(p.DownloadFolders) > MaxDownloadWatches {
+        return ErrInvalidPreferences
+    }
+    for _, fileID := range p.DownloadFiles {
+        if fileID == "" {
+            return ErrInvalidPreferences
+        }
+    }
+    for _, folder := range p.DownloadFolders {
+        if folder == "" || validator.ValidateFolder(folder) != nil {
+            return ErrInvalidPreferences
+        }
+    }
+    return nil
+}
+
+// WatchesDownload reports whether downloads of the file with the given ID in
+// folder are to be notified: the file itself is watched, or it lies in a
+// watched folder or one of its subfolders
+func (p *NotificationPreferences) WatchesDownload(fileID, folder string) bool {
+    if len(p.DownloadChannels) == 0 {
+        return false
+    }
+    for _, watched := range p.DownloadFiles {
+        if watched == fileID {
+            return true
+        }
+    }
+    for _, watched := range p.DownloadFolders {
+        if folder == watched || strings.HasPrefix(folder, watched+"/") {
+            return true
+        }
+    }
+    return false
+}
+
+// HasAddress reports whether the preferences hold an address for channel
+func (p *NotificationPreferences) HasAddress(channel string) bool {
+    switch channel {
+    case ChannelEmail:
+        return p.Email != ""
+    case ChannelWebhook:
+        return p.WebhookURL != ""
+    default:
+        return false
+    }
+}
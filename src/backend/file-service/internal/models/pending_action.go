@@ -0,0 +1,92 @@
+package models
+
+import (
+    "errors"
+    "time"
+
+    "github.com/google/uuid" // v1.3.0
+)
+
+// Pending action errors
+var (
+    ErrInvalidPendingAction = errors.New("invalid pending action")
+    ErrPendingActionClosed  = errors.New("pending action is no longer awaiting approval")
+    ErrApprovalNotPermitted = errors.New("approver has already approved or requested the action")
+)
+
+// Pending action kinds
+const (
+    PendingHardDelete = "file.hard_delete"
+)
+
+// Pending action statuses
+const (
+    PendingActionPending  = "pending"
+    PendingActionExecuted = "executed"
+    PendingActionFailed   = "failed"
+    PendingActionExpired  = "expired"
+)
+
+// RequiredApprovals is the number of distinct admins, other than the
+// requester, who must approve a pending action before it executes
+const RequiredApprovals = 2
+
+// PendingAction is a destructive action held until enough admins approve
+// it. Actions not approved before ExpiresAt expire and are never executed.
+type PendingAction struct {
+    ID          string     `json:"id"`
+    Kind        string     `json:"kind"`
+    FileID      string     `json:"fileId"`
+    TenantID    string     `json:"tenantId"`
+    RequestedBy string     `json:"requestedBy"`
+    ApprovedBy  []string   `json:"approvedBy"`
+    Status      string     `json:"status"`
+    CreatedAt   time.Time  `json:"createdAt"`
+    ExpiresAt   time.Time  `json:"expiresAt"`
+    ResolvedAt  *time.Time `json:"resolvedAt,omitempty"`
+}
+
+// NewPendingAction creates a pending action on a file awaiting approval
+// until ttl elapses
+func NewPendingAction(kind, fileID, tenantID, requestedBy string, ttl time.Duration) (*PendingAction, error) {
+    if kind != PendingHardDelete || fileID == "" || requestedBy == "" || ttl <= 0 {
+        return nil, ErrInvalidPendingAction
+    }
+
+    now := time.Now().UTC()
+    return &PendingAction{
+        ID:          uuid.New().String(),
+        Kind:        kind,
+        FileID:      fileID,
+        TenantID:    tenantID,
+        RequestedBy: requestedBy,
+        ApprovedBy:  []string{},
+        Status:      PendingActionPending,
+        CreatedAt:   now,
+        ExpiresAt:   now.Add(ttl),
+    }, nil
+}
+
+// IsPending checks if the action still awaits approval at the given time
+func (a *PendingAction) IsPending(now time.Time) bool {
+    return a.Status == PendingActionPending && now.Before(a.ExpiresAt)
+}
+
+// IsApproved checks if enough admins approved the action for it to execute
+func (a *PendingAction) IsApproved() bool {
+    return len(a.ApprovedBy) >= RequiredApprovals
+}
+
+// CanApprove checks if approverID may approve the action: the requester
+// cannot approve their own action, and each admin approves at most once
+func (a *PendingAction) CanApprove(approverID string) bool {
+    if approverID == "" || approverID == a.RequestedBy {
+        return false
+    }
+    for _, id := range a.ApprovedBy {
+        if id == approverID {
+            return false
+        }
+    }
+    return true
+}
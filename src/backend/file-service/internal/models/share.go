@@ -0,0 +1,42 @@
+package models
+
+import (
+    "time"
+
+    "github.com/google/uuid" // v1.3.0
+)
+
+// Share records that a file was shared with a user, so the share can be
+// reviewed and revoked once the recipient stops using it
+type Share struct {
+    ID         string     `json:"id"`
+    FileID     string     `json:"fileId"`
+    SharedBy   string     `json:"sharedBy"`
+    SharedWith string     `json:"sharedWith"`
+    CreatedAt  time.Time  `json:"createdAt"`
+    LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+    RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+
+    // OwnerID is the owner of the shared file, who is asked to review the
+    // share; it is empty for files without an owner
+    OwnerID string `json:"ownerId,omitempty"`
+}
+
+// NewShare creates a share of fileID from sharedBy to sharedWith
+func NewShare(fileID, sharedBy, sharedWith string) *Share {
+    return &Share{
+        ID:         uuid.New().String(),
+        FileID:     fileID,
+        SharedBy:   sharedBy,
+        SharedWith: sharedWith,
+        CreatedAt:  time.Now().UTC(),
+    }
+}
+
+// LastActivity is when the share was last used, or created if never used
+func (s *Share) LastActivity() time.Time {
+    if s.LastUsedAt != nil {
+        return *s.LastUsedAt
+    }
+    return s.CreatedAt
+}
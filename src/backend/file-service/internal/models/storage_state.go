@@ -0,0 +1,47 @@
+package models
+
+// Storage states of a file's content, by whether the space it takes can be
+// reclaimed. Quarantined content is held for review or removal; content
+// pending purge belongs to failed uploads and expired drafts, which are
+// removed without anyone's action.
+const (
+    StorageStateActive       = "active"
+    StorageStateArchived     = "archived"
+    StorageStateQuarantined  = "quarantined"
+    StorageStatePendingPurge = "pending_purge"
+)
+
+// StorageUsage is the size and number of the files in one storage state and
+// storage class
+type StorageUsage struct {
+    State        string
+    StorageClass string
+    Bytes        int64
+    Objects      int64
+}
+
+// StorageState returns the storage state of the file's content; deleted
+// files have none
+func (f *File) StorageState() string {
+    switch {
+    case f.IsDeleted():
+        return ""
+    case f.Status == FileStatusInfected || f.Status == FileStatusPendingReview:
+        return StorageStateQuarantined
+    case f.Status == FileStatusFailed || f.IsDraftExpired():
+        return StorageStatePendingPurge
+    case f.IsArchived():
+        return StorageStateArchived
+    default:
+        return StorageStateActive
+    }
+}
+
+// EffectiveStorageClass returns the storage class the content is stored
+// in, STANDARD when none was recorded
+func (f *File) EffectiveStorageClass() string {
+    if f.StorageClass == "" {
+        return StorageClassStandard
+    }
+    return f.StorageClass
+}
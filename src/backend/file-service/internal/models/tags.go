@@ -0,0 +1,96 @@
+package models
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "regexp"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// Limits S3 places on object tags, which file tags are mirrored to
+const (
+    MaxTags           = 10
+    maxTagKeyLength   = 128
+    maxTagValueLength = 256
+)
+
+// ErrInvalidTags is returned when file tags could not be stored as S3 object tags
+var ErrInvalidTags = errors.New("invalid tags")
+
+// tagPattern matches the characters S3 allows in tag keys and values
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+// Tags are key-value labels on a file. They are mirrored to the file's S3
+// object as object tags, so bucket lifecycle rules and cost allocation
+// reports can select on them.
+type Tags map[string]string
+
+// Validate checks the tags against the S3 object tag limits
+func (t Tags) Validate() error {
+    if len(t) > MaxTags {
+        return fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidTags, MaxTags)
+    }
+    for key, value := range t {
+        switch {
+        case key == "":
+            return fmt.Errorf("%w: tag key is empty", ErrInvalidTags)
+        case utf8.RuneCountInString(key) > maxTagKeyLength:
+            return fmt.Errorf("%w: tag key %q is longer than %d characters", ErrInvalidTags, key, maxTagKeyLength)
+        case utf8.RuneCountInString(value) > maxTagValueLength:
+            return fmt.Errorf("%w: value of tag %q is longer than %d characters", ErrInvalidTags, key, maxTagValueLength)
+        case strings.HasPrefix(strings.ToLower(key), "aws:"):
+            return fmt.Errorf("%w: tag key %q uses the reserved aws: prefix", ErrInvalidTags, key)
+        case !tagPattern.MatchString(key) || !tagPattern.MatchString(value):
+            return fmt.Errorf("%w: tag %q contains unsupported characters", ErrInvalidTags, key)
+        }
+    }
+    return nil
+}
+
+// Value implements driver.Valuer, storing the tags as JSON
+func (t Tags) Value() (driver.Value, error) {
+    if len(t) == 0 {
+        return nil, nil
+    }
+    return json.Marshal(map[string]string(t))
+}
+
+// Scan implements sql.Scanner for tags stored as JSON
+func (t *Tags) Scan(src interface{}) error {
+    var data []byte
+    switch v := src.(type) {
+    case nil:
+        *t = nil
+        return nil
+    case []byte:
+        data = v
+    case string:
+        data = []byte(v)
+    default:
+        return fmt.Errorf("unsupported tags type %T", src)
+    }
+
+    var tags map[string]string
+    if err := json.Unmarshal(data, &tags); err != nil {
+        return err
+    }
+    *t = tags
+    return nil
+}
+
+// SetTags replaces the file's tags; empty tags remove them all
+func (f *File) SetTags(tags Tags) error {
+    if err := tags.Validate(); err != nil {
+        return err
+    }
+    if len(tags) == 0 {
+        tags = nil
+    }
+    f.Tags = tags
+    f.UpdatedAt = time.Now().UTC()
+    return nil
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+    "errors"
+    "mime"
+    "time"
+)
+
+// ErrInvalidTenantSettings is returned when tenant settings are malformed
+var ErrInvalidTenantSettings = errors.New("invalid tenant settings")
+
+// MaxTenantDraftTTL bounds how long a tenant may keep uncommitted drafts
+const MaxTenantDraftTTL = 90 * 24 * time.Hour
+
+// TenantSettings override the service configuration for the users of one
+// tenant, so tenant-level changes need no redeploy. Zero values keep the
+// configured defaults.
+type TenantSettings struct {
+    TenantID string `json:"tenantId"`
+    // QuotaLimit replaces the per-user storage quota, in bytes
+    QuotaLimit int64 `json:"quotaLimit,omitempty"`
+    // AllowedTypes restricts the content types the upload policy permits;
+    // types may use a "type/*" wildcard
+    AllowedTypes []string `json:"allowedTypes"`
+    // DraftTTLSeconds replaces how long drafts are kept before being purged
+    DraftTTLSeconds int64     `json:"draftTtlSeconds,omitempty"`
+    UpdatedBy       string    `json:"updatedBy,omitempty"`
+    UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// DraftTTL returns the draft retention override, or zero if there is none
+func (s *TenantSettings) DraftTTL() time.Duration {
+    return time.Duration(s.DraftTTLSeconds) * time.Second
+}
+
+// Validate checks that every override is usable
+func (s *TenantSettings) Validate() error {
+    if s.TenantID == "" {
+        return ErrInvalidTenantSettings
+    }
+    if s.QuotaLimit < 0 {
+        return ErrInvalidTenantSettings
+    }
+    if s.DraftTTLSeconds < 0 || s.DraftTTL() > MaxTenantDraftTTL {
+        return ErrInvalidTenantSettings
+    }
+    for _, contentType := range s.AllowedTypes {
+        if _, _, err := mime.ParseMediaType(contentType); err != nil {
+            return ErrInvalidTenantSettings
+        }
+    }
+    return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+// Infrequent access storage classes cold files are moved to, and the
+// default class they are promoted back to
+const (
+    StorageClassStandard   = "STANDARD"
+    StorageClassStandardIA = "STANDARD_IA"
+    StorageClassOneZoneIA  = "ONEZONE_IA"
+)
+
+// IsCold checks if the file's content is in an infrequent access storage
+// class
+func (f *File) IsCold() bool {
+    return !f.IsArchived() &&
+        (f.StorageClass == StorageClassStandardIA || f.StorageClass == StorageClassOneZoneIA)
+}
+
+// MarkTiered records that the content was moved to storageClass. Content
+// moved back to STANDARD records none, like content never moved.
+func (f *File) MarkTiered(storageClass string) {
+    if storageClass == StorageClassStandard {
+        storageClass = ""
+    }
+    f.StorageClass = storageClass
+}
@@ -0,0 +1,194 @@
+package models
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/google/uuid" // v1.3.0
+    "src/backend/file-service/pkg/validator"
+)
+
+// Upload session status constants
+const (
+    UploadSessionStatusOpen      = "open"
+    UploadSessionStatusCompleted = "completed"
+    UploadSessionStatusAborted   = "aborted"
+    UploadSessionStatusExpired   = "expired"
+)
+
+// Upload session errors
+var (
+    ErrSessionClosed      = errors.New("upload session is not open")
+    ErrSessionExpired     = errors.New("upload session has expired")
+    ErrInvalidChunkNumber = errors.New("invalid chunk number")
+    ErrInvalidChunkSize   = errors.New("invalid chunk size")
+    ErrChunkMissing       = errors.New("upload session has missing chunks")
+    ErrChecksumMismatch   = errors.New("chunk checksum mismatch")
+)
+
+// UploadPart records a single chunk persisted as an S3 multipart part
+type UploadPart struct {
+    Number     int       `json:"number"`
+    Size       int64     `json:"size"`
+    Checksum   string    `json:"checksum"`
+    ETag       string    `json:"-"`
+    UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// UploadSession tracks the state of a resumable chunked upload backed by an
+// S3 multipart upload
+type UploadSession struct {
+    ID                string       `json:"id"`
+    FileID            string       `json:"fileId"`
+    FileName          string       `json:"fileName"`
+    ContentType       string       `json:"contentType"`
+    TotalSize         int64        `json:"totalSize"`
+    ChunkSize         int64        `json:"chunkSize"`
+    ChunkCount        int          `json:"chunkCount"`
+    StorageKey        string       `json:"-"`
+    MultipartUploadID string       `json:"-"`
+    Status            string       `json:"status"`
+    OwnerID           string       `json:"ownerId,omitempty"`
+    Parts             []UploadPart `json:"parts"`
+    CreatedAt         time.Time    `json:"createdAt"`
+    UpdatedAt         time.Time    `json:"updatedAt"`
+    ExpiresAt         time.Time    `json:"expiresAt"`
+    // Throughput is the client's recent upload throughput in bytes per
+    // second, and RecommendedChunkSize the chunk size it suits; neither is
+    // stored
+    Throughput           int64 `json:"throughput,omitempty"`
+    RecommendedChunkSize int64 `json:"recommendedChunkSize,omitempty"`
+}
+
+// NewUploadSession creates a new open upload session for a file of the given
+// total size split into chunks of chunkSize bytes
+func NewUploadSession(fileName, contentType string, totalSize, chunkSize int64, ttl time.Duration) (*UploadSession, error) {
+    if err := validator.ValidateFileName(fileName); err != nil {
+        return nil, err
+    }
+    if err := validator.ValidateContentType(contentType); err != nil {
+        return nil, err
+    }
+    if err := validator.ValidateFileSizeLimit(totalSize, validator.MaxObjectSize); err != nil {
+        return nil, err
+    }
+    if chunkSize <= 0 {
+        return nil, ErrInvalidChunkSize
+    }
+
+    now := time.Now().UTC()
+    session := &UploadSession{
+        ID:          uuid.New().String(),
+        FileID:      uuid.New().String(),
+        FileName:    fileName,
+        ContentType: contentType,
+        TotalSize:   totalSize,
+        ChunkSize:   chunkSize,
+        ChunkCount:  int((totalSize + chunkSize - 1) / chunkSize),
+        Status:      UploadSessionStatusOpen,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+        ExpiresAt:   now.Add(ttl),
+    }
+
+    return session, nil
+}
+
+// ExpectedChunkSize returns the exact size in bytes chunk n must have
+func (s *UploadSession) ExpectedChunkSize(n int) (int64, error) {
+    if n < 1 || n > s.ChunkCount {
+        return 0, ErrInvalidChunkNumber
+    }
+    if n < s.ChunkCount {
+        return s.ChunkSize, nil
+    }
+    return s.TotalSize - int64(s.ChunkCount-1)*s.ChunkSize, nil
+}
+
+// CheckWritable verifies the session still accepts chunks
+func (s *UploadSession) CheckWritable() error {
+    if s.Status != UploadSessionStatusOpen {
+        return ErrSessionClosed
+    }
+    if time.Now().UTC().After(s.ExpiresAt) {
+        return ErrSessionExpired
+    }
+    return nil
+}
+
+// RecordPart stores or replaces the record for an uploaded chunk
+func (s *UploadSession) RecordPart(part UploadPart) error {
+    expected, err := s.ExpectedChunkSize(part.Number)
+    if err != nil {
+        return err
+    }
+    if part.Size != expected {
+        return fmt.Errorf("%w: chunk %d must be %d bytes, got %d", ErrInvalidChunkSize, part.Number, expected, part.Size)
+    }
+
+    for i := range s.Parts {
+        if s.Parts[i].Number == part.Number {
+            s.Parts[i] = part
+            s.UpdatedAt = time.Now().UTC()
+            return nil
+        }
+    }
+
+    s.Parts = append(s.Parts, part)
+    sort.Slice(s.Parts, func(i, j int) bool { return s.Parts[i].Number < s.Parts[j].Number })
+    s.UpdatedAt = time.Now().UTC()
+    return nil
+}
+
+// MissingChunks returns the chunk numbers that have not been uploaded yet
+func (s *UploadSession) MissingChunks() []int {
+    received := make(map[int]bool, len(s.Parts))
+    for _, part := range s.Parts {
+        received[part.Number] = true
+    }
+
+    missing := []int{}
+    for n := 1; n <= s.ChunkCount; n++ {
+        if !received[n] {
+            missing = append(missing, n)
+        }
+    }
+    return missing
+}
+
+// VerifyChecksums compares the client-supplied per-chunk checksums with the
+// checksums recorded when each chunk was received
+func (s *UploadSession) VerifyChecksums(checksums []string) error {
+    if missing := s.MissingChunks(); len(missing) > 0 {
+        return fmt.Errorf("%w: %v", ErrChunkMissing, missing)
+    }
+    if len(checksums) != len(s.Parts) {
+        return fmt.Errorf("%w: expected %d checksums, got %d", ErrChecksumMismatch, len(s.Parts), len(checksums))
+    }
+
+    for i, part := range s.Parts {
+        if !strings.EqualFold(part.Checksum, checksums[i]) {
+            return fmt.Errorf("%w: chunk %d", ErrChecksumMismatch, part.Number)
+        }
+    }
+    return nil
+}
+
+// CompositeChecksum returns the SHA-256 of the concatenated chunk digests
+// suffixed with the chunk count, mirroring the S3 multipart checksum format
+func (s *UploadSession) CompositeChecksum() string {
+    hash := sha256.New()
+    for _, part := range s.Parts {
+        digest, err := hex.DecodeString(part.Checksum)
+        if err != nil {
+            digest = []byte(part.Checksum)
+        }
+        hash.Write(digest)
+    }
+    return fmt.Sprintf("%s-%d", hex.EncodeToString(hash.Sum(nil)), len(s.Parts))
+}
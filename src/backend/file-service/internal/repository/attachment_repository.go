@@ -0,0 +1,143 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrAttachmentNotFound is returned when a file is not attached to an entity
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// AttachmentRepository defines persistence operations for file attachments
+type AttachmentRepository interface {
+    Attach(ctx context.Context, attachment *models.Attachment) error
+    Detach(ctx context.Context, entityType, entityID, fileID string) error
+    ListFiles(ctx context.Context, entityType, entityID string, offset, limit int) ([]*models.File, int64, error)
+}
+
+// attachmentRepository implements AttachmentRepository using PostgreSQL
+type attachmentRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// NewAttachmentRepository creates a new instance of attachmentRepository
+func NewAttachmentRepository(db *sql.DB) (AttachmentRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &attachmentRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// Attach links a file to an entity. Attaching an already attached file is a no-op.
+func (r *attachmentRepository) Attach(ctx context.Context, attachment *models.Attachment) error {
+    if attachment == nil {
+        return errors.New("attachment cannot be nil")
+    }
+
+    const query = `
+        INSERT INTO attachments (entity_type, entity_id, file_id, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (entity_type, entity_id, file_id) DO NOTHING
+    `
+
+    _, err := conn(ctx, r.db).ExecContext(ctx, query,
+        attachment.EntityType, attachment.EntityID, attachment.FileID, attachment.CreatedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to insert attachment: %w", err)
+    }
+
+    r.log.Info("Attached file",
+        logger.String("entityType", attachment.EntityType),
+        logger.String("entityId", attachment.EntityID),
+        logger.String("fileId", attachment.FileID))
+
+    return nil
+}
+
+// Detach removes the link between a file and an entity
+func (r *attachmentRepository) Detach(ctx context.Context, entityType, entityID, fileID string) error {
+    const query = `
+        DELETE FROM attachments
+        WHERE entity_type = $1 AND entity_id = $2 AND file_id = $3
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, entityType, entityID, fileID)
+    if err != nil {
+        return fmt.Errorf("failed to delete attachment: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrAttachmentNotFound
+    }
+
+    r.log.Info("Detached file",
+        logger.String("entityType", entityType),
+        logger.String("entityId", entityID),
+        logger.String("fileId", fileID))
+
+    return nil
+}
+
+// ListFiles retrieves a paginated list of the files attached to an entity,
+// excluding deleted files and uncommitted drafts
+func (r *attachmentRepository) ListFiles(ctx context.Context, entityType, entityID string, offset, limit int) ([]*models.File, int64, error) {
+    if offset < 0 || limit <= 0 {
+        return nil, 0, errors.New("invalid pagination parameters")
+    }
+
+    const fromClause = `
+        FROM files
+        WHERE id IN (
+            SELECT file_id FROM attachments
+            WHERE entity_type = $1 AND entity_id = $2
+        )
+        AND status NOT IN ($3, $4)
+    `
+    args := []interface{}{entityType, entityID, models.FileStatusDeleted, models.FileStatusDraft}
+
+    var total int64
+    if err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*)"+fromClause, args...).Scan(&total); err != nil {
+        return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+    }
+
+    query := `SELECT ` + fileColumns + fromClause + `
+        ORDER BY created_at DESC
+        LIMIT $5 OFFSET $6
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, append(args, limit, offset)...)
+    if err != nil {
+        return nil, 0, fmt.Errorf("failed to list attached files: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, 0, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, 0, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, total, nil
+}
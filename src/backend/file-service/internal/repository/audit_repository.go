@@ -0,0 +1,202 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrCheckpointConflict is returned when the audit export checkpoint was
+// already advanced past the batch being saved
+var ErrCheckpointConflict = errors.New("audit checkpoint was advanced concurrently")
+
+// AuditRepository defines persistence operations for audit events awaiting
+// export and the export checkpoint
+type AuditRepository interface {
+    Append(ctx context.Context, event *models.AuditEvent) error
+    ListAfter(ctx context.Context, afterID int64, recordedBefore time.Time, limit int) ([]*models.AuditEvent, error)
+    GetCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error)
+    SaveCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error
+}
+
+// auditRepository implements AuditRepository using PostgreSQL
+type auditRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// auditEventColumns lists the audit_events columns in the order scanned by
+// scanAuditEvent
+const auditEventColumns = `id, type, file_id, detail, occurred_at, recorded_at`
+
+// NewAuditRepository creates a new instance of auditRepository
+func NewAuditRepository(db *sql.DB) (AuditRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &auditRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanAuditEvent scans a row selected with auditEventColumns
+func scanAuditEvent(row rowScanner) (*models.AuditEvent, error) {
+    event := &models.AuditEvent{}
+    var detail []byte
+    err := row.Scan(
+        &event.ID, &event.Type, &event.FileID, &detail, &event.OccurredAt, &event.RecordedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    if len(detail) > 0 {
+        if err := json.Unmarshal(detail, &event.Detail); err != nil {
+            return nil, fmt.Errorf("invalid audit event detail: %w", err)
+        }
+    }
+    return event, nil
+}
+
+// Append records an audit event, assigning its ID and recording time
+func (r *auditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+    return appendAuditEvent(ctx, conn(ctx, r.db), event)
+}
+
+// appendAuditEvent inserts an audit event with q, so other repositories can
+// record events in the transaction making the change they describe
+func appendAuditEvent(ctx context.Context, q querier, event *models.AuditEvent) error {
+    if event == nil || event.Type == "" {
+        return errors.New("audit event type is required")
+    }
+
+    detail, err := json.Marshal(event.Detail)
+    if err != nil {
+        return fmt.Errorf("failed to encode audit event detail: %w", err)
+    }
+
+    const query = `
+        INSERT INTO audit_events (type, file_id, detail, occurred_at, recorded_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        RETURNING id, recorded_at
+    `
+
+    err = q.QueryRowContext(ctx, query,
+        event.Type, event.FileID, detail, event.OccurredAt,
+    ).Scan(&event.ID, &event.RecordedAt)
+    if err != nil {
+        return fmt.Errorf("failed to append audit event: %w", err)
+    }
+    return nil
+}
+
+// ListAfter returns up to limit events with IDs above afterID, in ID order.
+// Only events recorded before recordedBefore are returned: IDs are assigned
+// before inserts commit, so a recent event with a lower ID may still become
+// visible after one with a higher ID.
+func (r *auditRepository) ListAfter(ctx context.Context, afterID int64, recordedBefore time.Time, limit int) ([]*models.AuditEvent, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + auditEventColumns + `
+        FROM audit_events
+        WHERE id > $1 AND recorded_at < $2
+        ORDER BY id
+        LIMIT $3
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, afterID, recordedBefore, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list audit events: %w", err)
+    }
+    defer rows.Close()
+
+    var events []*models.AuditEvent
+    for rows.Next() {
+        event, err := scanAuditEvent(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan audit event: %w", err)
+        }
+        events = append(events, event)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return events, nil
+}
+
+// GetCheckpoint returns the export checkpoint, or an empty checkpoint before
+// the first export
+func (r *auditRepository) GetCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+    const query = `
+        SELECT last_event_id, batch_number, last_seq, batch_hash, updated_at
+        FROM audit_export_checkpoint
+        WHERE id = 1
+    `
+
+    checkpoint := &models.AuditCheckpoint{}
+    err := conn(ctx, r.db).QueryRowContext(ctx, query).Scan(
+        &checkpoint.LastEventID, &checkpoint.BatchNumber, &checkpoint.LastSeq,
+        &checkpoint.BatchHash, &checkpoint.UpdatedAt,
+    )
+    if err == sql.ErrNoRows {
+        return &models.AuditCheckpoint{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get audit checkpoint: %w", err)
+    }
+    return checkpoint, nil
+}
+
+// SaveCheckpoint advances the export checkpoint. The checkpoint only moves
+// forward, so a stale exporter cannot rewind the chain.
+func (r *auditRepository) SaveCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+    if checkpoint == nil {
+        return errors.New("audit checkpoint is required")
+    }
+
+    const query = `
+        INSERT INTO audit_export_checkpoint (id, last_event_id, batch_number, last_seq, batch_hash, updated_at)
+        VALUES (1, $1, $2, $3, $4, $5)
+        ON CONFLICT (id) DO UPDATE
+        SET last_event_id = EXCLUDED.last_event_id,
+            batch_number = EXCLUDED.batch_number,
+            last_seq = EXCLUDED.last_seq,
+            batch_hash = EXCLUDED.batch_hash,
+            updated_at = EXCLUDED.updated_at
+        WHERE audit_export_checkpoint.batch_number < EXCLUDED.batch_number
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query,
+        checkpoint.LastEventID, checkpoint.BatchNumber, checkpoint.LastSeq,
+        checkpoint.BatchHash, checkpoint.UpdatedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to save audit checkpoint: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrCheckpointConflict
+    }
+
+    r.log.Info("Advanced audit export checkpoint",
+        logger.Int64("batch", checkpoint.BatchNumber),
+        logger.Int64("lastEventId", checkpoint.LastEventID))
+
+    return nil
+}
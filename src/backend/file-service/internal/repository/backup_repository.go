@@ -0,0 +1,147 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "src/backend/file-service/pkg/logger"
+)
+
+// BackupTables are the tables a backup dumps, in the order they are
+// restored: files, which also hold the folder tree, the blobs their
+// content is counted in, then what refers to files: shares, attachments,
+// derived objects and locks, and the file requests, tenant settings and
+// notification preferences configured alongside them.
+//
+// Left out are the audit log and its export checkpoint, which are exported
+// and signed on their own; upload sessions and their parts, whose scratch
+// objects a restore does not copy; archive extraction jobs, whose
+// extracted files are backed up as files; pending actions, which expire
+// and are requested again; and used nonces, which only matter until the
+// tokens they were issued with expire.
+var BackupTables = []string{
+    "files",
+    "blobs",
+    "file_shares",
+    "attachments",
+    "derived_objects",
+    "file_locks",
+    "file_requests",
+    "tenant_settings",
+    "notification_preferences",
+}
+
+// BackupRepository dumps and restores the metadata tables as rows of JSON,
+// so a backup does not depend on the schema beyond the table names
+type BackupRepository interface {
+    // Snapshot runs fn in a read-only transaction, so every repository
+    // call made with the context passed to fn reads the same snapshot
+    Snapshot(ctx context.Context, fn func(ctx context.Context) error) error
+    // DumpTable calls fn with each row of one of BackupTables as a JSON
+    // object keyed by column
+    DumpTable(ctx context.Context, table string, fn func(row json.RawMessage) error) error
+    // RestoreRow inserts a row dumped from table, reporting false if it
+    // conflicts with an existing row, as when a restore is rerun
+    RestoreRow(ctx context.Context, table string, row json.RawMessage) (bool, error)
+}
+
+// backupRepository implements BackupRepository using PostgreSQL
+type backupRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// NewBackupRepository creates a new instance of backupRepository
+func NewBackupRepository(db *sql.DB) (BackupRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &backupRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// Snapshot runs fn in a repeatable read transaction, in which PostgreSQL
+// reads every table as of its first query. Nothing is written, so the
+// transaction is rolled back.
+func (r *backupRepository) Snapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+    tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
+        Isolation: sql.LevelRepeatableRead,
+        ReadOnly:  true,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to start snapshot: %w", err)
+    }
+    defer tx.Rollback()
+
+    return fn(context.WithValue(ctx, txKey{}, tx))
+}
+
+// DumpTable selects every row of table as JSON
+func (r *backupRepository) DumpTable(ctx context.Context, table string, fn func(row json.RawMessage) error) error {
+    if !isBackupTable(table) {
+        return fmt.Errorf("%s is not a backed up table", table)
+    }
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT row_to_json(t) FROM `+table+` t`)
+    if err != nil {
+        return fmt.Errorf("failed to dump %s: %w", table, err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var row []byte
+        if err := rows.Scan(&row); err != nil {
+            return fmt.Errorf("failed to scan %s row: %w", table, err)
+        }
+        if err := fn(row); err != nil {
+            return err
+        }
+    }
+
+    if err = rows.Err(); err != nil {
+        return fmt.Errorf("error iterating rows: %w", err)
+    }
+    return nil
+}
+
+// RestoreRow inserts the row with its columns populated from the JSON
+// object. Columns the row lacks are left NULL, and keys that are not
+// columns are ignored.
+func (r *backupRepository) RestoreRow(ctx context.Context, table string, row json.RawMessage) (bool, error) {
+    if !isBackupTable(table) {
+        return false, fmt.Errorf("%s is not a backed up table", table)
+    }
+
+    query := `
+        INSERT INTO ` + table + `
+        SELECT * FROM json_populate_record(NULL::` + table + `, $1::json)
+        ON CONFLICT DO NOTHING
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, string(row))
+    if err != nil {
+        return false, fmt.Errorf("failed to restore %s row: %w", table, err)
+    }
+    inserted, err := result.RowsAffected()
+    if err != nil {
+        return false, fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    return inserted == 1, nil
+}
+
+// isBackupTable checks table is one of BackupTables, the only names
+// interpolated into backup queries
+func isBackupTable(table string) bool {
+    for _, name := range BackupTables {
+        if name == table {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrBlobNotFound is returned when a storage key is not tracked as a blob
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobRepository defines persistence operations for reference counted blobs.
+// Blobs are keyed by storage key and unique by (tenant, checksum, size).
+type BlobRepository interface {
+    Acquire(ctx context.Context, blob *models.Blob) (*models.Blob, error)
+    Lookup(ctx context.Context, tenantID, checksum string, size int64) (*models.Blob, error)
+    Release(ctx context.Context, storageKey string, at time.Time) error
+    ListUnreferenced(ctx context.Context, before time.Time, limit int) ([]*models.Blob, error)
+    Remove(ctx context.Context, storageKey string, before time.Time) (bool, error)
+}
+
+// blobRepository implements BlobRepository using PostgreSQL
+type blobRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// NewBlobRepository creates a new instance of blobRepository
+func NewBlobRepository(db *sql.DB) (BlobRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &blobRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// Acquire adds a reference to the blob with the same tenant, checksum and
+// size as blob, creating it if none exists, and returns the referenced blob.
+// A blob awaiting collection is revived rather than duplicated.
+func (r *blobRepository) Acquire(ctx context.Context, blob *models.Blob) (*models.Blob, error) {
+    if blob == nil || blob.StorageKey == "" || blob.Checksum == "" {
+        return nil, errors.New("blob storage key and checksum are required")
+    }
+
+    const query = `
+        INSERT INTO blobs (storage_key, tenant_id, checksum, size, ref_count, unreferenced_at, created_at)
+        VALUES ($1, $2, $3, $4, 1, NULL, $5)
+        ON CONFLICT (tenant_id, checksum, size) DO UPDATE
+        SET ref_count = blobs.ref_count + 1, unreferenced_at = NULL
+        RETURNING storage_key, tenant_id, checksum, size, ref_count, unreferenced_at, created_at
+    `
+
+    acquired := &models.Blob{}
+    err := conn(ctx, r.db).QueryRowContext(ctx, query,
+        blob.StorageKey, blob.TenantID, blob.Checksum, blob.Size, time.Now().UTC(),
+    ).Scan(
+        &acquired.StorageKey, &acquired.TenantID, &acquired.Checksum, &acquired.Size,
+        &acquired.RefCount, &acquired.UnreferencedAt, &acquired.CreatedAt,
+    )
+    if err != nil {
+        return nil, fmt.Errorf("failed to acquire blob: %w", err)
+    }
+
+    return acquired, nil
+}
+
+// Lookup returns the blob of the tenant scope holding content with the given
+// checksum and size, or ErrBlobNotFound
+func (r *blobRepository) Lookup(ctx context.Context, tenantID, checksum string, size int64) (*models.Blob, error) {
+    const query = `
+        SELECT storage_key, tenant_id, checksum, size, ref_count, unreferenced_at, created_at
+        FROM blobs
+        WHERE tenant_id = $1 AND checksum = $2 AND size = $3
+    `
+
+    blob := &models.Blob{}
+    err := conn(ctx, r.db).QueryRowContext(ctx, query, tenantID, checksum, size).Scan(
+        &blob.StorageKey, &blob.TenantID, &blob.Checksum, &blob.Size,
+        &blob.RefCount, &blob.UnreferencedAt, &blob.CreatedAt,
+    )
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, ErrBlobNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to look up blob: %w", err)
+    }
+
+    return blob, nil
+}
+
+// Release drops a reference to the blob stored under storageKey, recording
+// when the last reference went away
+func (r *blobRepository) Release(ctx context.Context, storageKey string, at time.Time) error {
+    const query = `
+        UPDATE blobs
+        SET ref_count = ref_count - 1,
+            unreferenced_at = CASE WHEN ref_count = 1 THEN $2 ELSE unreferenced_at END
+        WHERE storage_key = $1 AND ref_count > 0
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, storageKey, at)
+    if err != nil {
+        return fmt.Errorf("failed to release blob: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrBlobNotFound
+    }
+
+    return nil
+}
+
+// ListUnreferenced returns up to limit blobs whose last reference was
+// released before the given time
+func (r *blobRepository) ListUnreferenced(ctx context.Context, before time.Time, limit int) ([]*models.Blob, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT storage_key, tenant_id, checksum, size, ref_count, unreferenced_at, created_at
+        FROM blobs
+        WHERE ref_count = 0 AND unreferenced_at < $1
+        ORDER BY unreferenced_at
+        LIMIT $2
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, before, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list unreferenced blobs: %w", err)
+    }
+    defer rows.Close()
+
+    var blobs []*models.Blob
+    for rows.Next() {
+        blob := &models.Blob{}
+        if err := rows.Scan(
+            &blob.StorageKey, &blob.TenantID, &blob.Checksum, &blob.Size,
+            &blob.RefCount, &blob.UnreferencedAt, &blob.CreatedAt,
+        ); err != nil {
+            return nil, fmt.Errorf("failed to scan blob: %w", err)
+        }
+        blobs = append(blobs, blob)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return blobs, nil
+}
+
+// Remove deletes the blob record if it is still unreferenced since before
+// the given time. It reports false when the blob was re-acquired in the
+// meantime, in which case its object must be kept.
+func (r *blobRepository) Remove(ctx context.Context, storageKey string, before time.Time) (bool, error) {
+    const query = `
+        DELETE FROM blobs
+        WHERE storage_key = $1 AND ref_count = 0 AND unreferenced_at < $2
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, storageKey, before)
+    if err != nil {
+        return false, fmt.Errorf("failed to remove blob: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return false, fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return false, nil
+    }
+
+    r.log.Info("Removed unreferenced blob",
+        logger.String("storageKey", storageKey))
+
+    return true, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrDerivedObjectNotFound is returned when no derived object matches
+var ErrDerivedObjectNotFound = errors.New("derived object not found")
+
+// DerivedObjectRepository defines persistence operations for derived objects
+type DerivedObjectRepository interface {
+    Get(ctx context.Context, fileID, kind, paramsHash string) (*models.DerivedObject, error)
+    Save(ctx context.Context, object *models.DerivedObject) error
+    ListByFile(ctx context.Context, fileID string) ([]*models.DerivedObject, error)
+    Delete(ctx context.Context, id string) error
+}
+
+// derivedObjectRepository implements DerivedObjectRepository using PostgreSQL
+type derivedObjectRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// derivedObjectColumns lists the derived_objects columns in the order scanned by scanDerivedObject
+const derivedObjectColumns = `id, file_id, kind, params, params_hash, storage_key, content_type, size,
+               status, error, source_checksum, created_at, updated_at`
+
+// NewDerivedObjectRepository creates a new instance of derivedObjectRepository
+func NewDerivedObjectRepository(db *sql.DB) (DerivedObjectRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &derivedObjectRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanDerivedObject scans a row selected with derivedObjectColumns
+func scanDerivedObject(row rowScanner) (*models.DerivedObject, error) {
+    object := &models.DerivedObject{}
+    var params []byte
+    err := row.Scan(
+        &object.ID, &object.FileID, &object.Kind, &params, &object.ParamsHash, &object.StorageKey,
+        &object.ContentType, &object.Size, &object.Status, &object.Error, &object.SourceChecksum,
+        &object.CreatedAt, &object.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    if len(params) > 0 {
+        if err := json.Unmarshal(params, &object.Params); err != nil {
+            return nil, fmt.Errorf("invalid derived object params: %w", err)
+        }
+    }
+    return object, nil
+}
+
+// Get retrieves the derived object of a file for a kind and parameter set
+func (r *derivedObjectRepository) Get(ctx context.Context, fileID, kind, paramsHash string) (*models.DerivedObject, error) {
+    const query = `
+        SELECT ` + derivedObjectColumns + `
+        FROM derived_objects
+        WHERE file_id = $1 AND kind = $2 AND params_hash = $3
+    `
+
+    object, err := scanDerivedObject(conn(ctx, r.db).QueryRowContext(ctx, query, fileID, kind, paramsHash))
+    if err == sql.ErrNoRows {
+        return nil, ErrDerivedObjectNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get derived object: %w", err)
+    }
+    return object, nil
+}
+
+// Save inserts a derived object, or replaces the one with the same file,
+// kind and parameters
+func (r *derivedObjectRepository) Save(ctx context.Context, object *models.DerivedObject) error {
+    if object == nil {
+        return errors.New("derived object is required")
+    }
+
+    params, err := json.Marshal(object.Params)
+    if err != nil {
+        return fmt.Errorf("failed to encode derived object params: %w", err)
+    }
+
+    const query = `
+        INSERT INTO derived_objects (
+            id, file_id, kind, params, params_hash, storage_key, content_type, size,
+            status, error, source_checksum, created_at, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        ON CONFLICT (file_id, kind, params_hash) DO UPDATE
+        SET storage_key = EXCLUDED.storage_key,
+            content_type = EXCLUDED.content_type,
+            size = EXCLUDED.size,
+            status = EXCLUDED.status,
+            error = EXCLUDED.error,
+            source_checksum = EXCLUDED.source_checksum,
+            updated_at = EXCLUDED.updated_at
+        RETURNING id, created_at
+    `
+
+    err = conn(ctx, r.db).QueryRowContext(ctx, query,
+        object.ID, object.FileID, object.Kind, params, object.ParamsHash, object.StorageKey,
+        object.ContentType, object.Size, object.Status, object.Error, object.SourceChecksum,
+        object.CreatedAt, object.UpdatedAt,
+    ).Scan(&object.ID, &object.CreatedAt)
+    if err != nil {
+        return fmt.Errorf("failed to save derived object: %w", err)
+    }
+    return nil
+}
+
+// ListByFile returns every derived object of a file
+func (r *derivedObjectRepository) ListByFile(ctx context.Context, fileID string) ([]*models.DerivedObject, error) {
+    const query = `
+        SELECT ` + derivedObjectColumns + `
+        FROM derived_objects
+        WHERE file_id = $1
+        ORDER BY created_at
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, fileID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list derived objects: %w", err)
+    }
+    defer rows.Close()
+
+    var objects []*models.DerivedObject
+    for rows.Next() {
+        object, err := scanDerivedObject(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan derived object: %w", err)
+        }
+        objects = append(objects, object)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return objects, nil
+}
+
+// Delete removes a derived object record
+func (r *derivedObjectRepository) Delete(ctx context.Context, id string) error {
+    const query = `DELETE FROM derived_objects WHERE id = $1`
+
+    if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+        return fmt.Errorf("failed to delete derived object: %w", err)
+    }
+
+    r.log.Debug("Deleted derived object", logger.String("derivedId", id))
+    return nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "github.com/lib/pq" // v1.10.9
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrExtractionJobNotFound is returned when an extraction job does not exist
+var ErrExtractionJobNotFound = errors.New("extraction job not found")
+
+// ExtractionJobRepository defines persistence operations for archive
+// extraction jobs
+type ExtractionJobRepository interface {
+    Create(ctx context.Context, job *models.ExtractionJob) error
+    Get(ctx context.Context, id string) (*models.ExtractionJob, error)
+    Update(ctx context.Context, job *models.ExtractionJob) error
+}
+
+// extractionJobRepository implements ExtractionJobRepository using PostgreSQL
+type extractionJobRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// extractionJobColumns lists the extraction_jobs columns in the order scanned by scanExtractionJob
+const extractionJobColumns = `id, file_id, folder, owner_id, status, total_entries, processed_entries,
+               extracted_files, failed_entries, extracted_bytes, file_ids, errors, error,
+               created_at, updated_at, completed_at`
+
+// NewExtractionJobRepository creates a new instance of extractionJobRepository
+func NewExtractionJobRepository(db *sql.DB) (ExtractionJobRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &extractionJobRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanExtractionJob scans a row selected with extractionJobColumns
+func scanExtractionJob(row rowScanner) (*models.ExtractionJob, error) {
+    job := &models.ExtractionJob{}
+    var entryErrors []byte
+    err := row.Scan(
+        &job.ID, &job.FileID, &job.Folder, &job.OwnerID, &job.Status, &job.TotalEntries,
+        &job.ProcessedEntries, &job.ExtractedFiles, &job.FailedEntries, &job.ExtractedBytes,
+        pq.Array(&job.FileIDs), &entryErrors, &job.Error, &job.CreatedAt, &job.UpdatedAt,
+        &job.CompletedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    if len(entryErrors) > 0 {
+        if err := json.Unmarshal(entryErrors, &job.Errors); err != nil {
+            return nil, fmt.Errorf("invalid extraction job errors: %w", err)
+        }
+    }
+    return job, nil
+}
+
+// Create inserts a new extraction job
+func (r *extractionJobRepository) Create(ctx context.Context, job *models.ExtractionJob) error {
+    if job == nil {
+        return errors.New("extraction job is required")
+    }
+
+    entryErrors, err := json.Marshal(job.Errors)
+    if err != nil {
+        return fmt.Errorf("failed to encode extraction job errors: %w", err)
+    }
+
+    const query = `
+        INSERT INTO extraction_jobs (` + extractionJobColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+    `
+
+    _, err = conn(ctx, r.db).ExecContext(ctx, query,
+        job.ID, job.FileID, job.Folder, job.OwnerID, job.Status, job.TotalEntries,
+        job.ProcessedEntries, job.ExtractedFiles, job.FailedEntries, job.ExtractedBytes,
+        pq.Array(job.FileIDs), entryErrors, job.Error, job.CreatedAt, job.UpdatedAt,
+        job.CompletedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to create extraction job: %w", err)
+    }
+
+    r.log.Info("Created extraction job",
+        logger.String("jobId", job.ID),
+        logger.String("fileId", job.FileID),
+        logger.String("folder", job.Folder))
+
+    return nil
+}
+
+// Get retrieves an extraction job by ID
+func (r *extractionJobRepository) Get(ctx context.Context, id string) (*models.ExtractionJob, error) {
+    const query = `
+        SELECT ` + extractionJobColumns + `
+        FROM extraction_jobs
+        WHERE id = $1
+    `
+
+    job, err := scanExtractionJob(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, ErrExtractionJobNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get extraction job: %w", err)
+    }
+    return job, nil
+}
+
+// Update records an extraction job's progress and status
+func (r *extractionJobRepository) Update(ctx context.Context, job *models.ExtractionJob) error {
+    if job == nil {
+        return errors.New("extraction job is required")
+    }
+
+    entryErrors, err := json.Marshal(job.Errors)
+    if err != nil {
+        return fmt.Errorf("failed to encode extraction job errors: %w", err)
+    }
+
+    const query = `
+        UPDATE extraction_jobs
+        SET status = $2, total_entries = $3, processed_entries = $4, extracted_files = $5,
+            failed_entries = $6, extracted_bytes = $7, file_ids = $8, errors = $9, error = $10,
+            updated_at = $11, completed_at = $12
+        WHERE id = $1
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query,
+        job.ID, job.Status, job.TotalEntries, job.ProcessedEntries, job.ExtractedFiles,
+        job.FailedEntries, job.ExtractedBytes, pq.Array(job.FileIDs), entryErrors, job.Error,
+        job.UpdatedAt, job.CompletedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to update extraction job: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrExtractionJobNotFound
+    }
+    return nil
+}
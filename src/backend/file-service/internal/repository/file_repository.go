@@ -8,8 +8,11 @@ import (
     "fmt"
     "time"
 
+    "github.com/lib/pq" // v1.10.9
+
     "src/backend/file-service/internal/models"
     "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
 )
 
 // Common errors
@@ -17,15 +20,76 @@ var (
     ErrNotFound = errors.New("file not found")
     ErrInvalidID = errors.New("invalid file ID")
     ErrInvalidTransaction = errors.New("invalid transaction")
+    ErrVersionConflict = errors.New("file was modified concurrently")
 )
 
+// maxCopySize is the largest content tiered, archived or moved to a new key,
+// as all of them copy the object and S3 limits a single copy to 5GB
+const maxCopySize = 5 << 30
+
+// minColdSize is the smallest content moved to an infrequent access class,
+// which bills smaller objects as 128KB
+const minColdSize = 128 << 10
+
+// fileColumns lists the files table columns in the order scanned by scanFile
+const fileColumns = `id, file_name, folder, size, content_type, status, storage_path,
+               checksum, encryption, preview_storage_path, draft_expires_at,
+               owner_id, version, created_at, updated_at, last_accessed_at,
+               replication_status, replication_attempts, replicated_at,
+               server_side_encryption, tags, retention_mode, retain_until,
+               legal_hold, storage_class, archive_status, archived_at,
+               restore_expires_at`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+// scanFile scans a row selected with fileColumns into a File
+func scanFile(row rowScanner) (*models.File, error) {
+    file := &models.File{}
+    err := row.Scan(
+        &file.ID, &file.FileName, &file.Folder, &file.Size, &file.ContentType,
+        &file.Status, &file.StoragePath, &file.Checksum, &file.Encryption,
+        &file.PreviewStoragePath, &file.DraftExpiresAt, &file.OwnerID,
+        &file.Version, &file.CreatedAt, &file.UpdatedAt, &file.LastAccessedAt,
+        &file.ReplicationStatus, &file.ReplicationAttempts, &file.ReplicatedAt,
+        &file.ServerSideEncryption, &file.Tags, &file.RetentionMode, &file.RetainUntil,
+        &file.LegalHold, &file.StorageClass, &file.ArchiveStatus, &file.ArchivedAt,
+        &file.RestoreExpiresAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return file, nil
+}
+
 // FileRepository defines the interface for file metadata persistence operations
 type FileRepository interface {
     Create(ctx context.Context, file *models.File) error
     GetByID(ctx context.Context, id string) (*models.File, error)
+    FindByName(ctx context.Context, folder, fileName string) (*models.File, error)
     Update(ctx context.Context, file *models.File) error
     Delete(ctx context.Context, id string) error
     List(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*models.File, int64, error)
+    ListExpiredDrafts(ctx context.Context, before time.Time, limit int) ([]*models.File, error)
+    ListStaleStaged(ctx context.Context, before time.Time, limit int) ([]*models.File, error)
+    ListUploadedInFolders(ctx context.Context, folders []string, since, until time.Time, limit int) ([]*models.File, error)
+    UsageByOwner(ctx context.Context, ownerID string) (int64, error)
+    TotalUsage(ctx context.Context) (int64, error)
+    UsageByState(ctx context.Context, now time.Time) ([]models.StorageUsage, error)
+    ListAwaitingReplication(ctx context.Context, limit int) ([]*models.File, error)
+    UpdateReplication(ctx context.Context, file *models.File) error
+    ListIdle(ctx context.Context, before time.Time, limit int) ([]*models.File, error)
+    ListPendingRestores(ctx context.Context, now time.Time, limit int) ([]*models.File, error)
+    UpdateArchive(ctx context.Context, file *models.File) error
+    ListCold(ctx context.Context, before time.Time, limit int) ([]*models.File, error)
+    RecordAccess(ctx context.Context, id string, at time.Time) error
+    ListLegacyStoragePaths(ctx context.Context, afterID string, limit int) ([]*models.File, error)
+    UpdateStoragePath(ctx context.Context, from string, file *models.File) error
+    ListStoragePaths(ctx context.Context, afterPath, afterID string, limit int) ([]*models.File, error)
+    StoragePathReferenced(ctx context.Context, storagePath string) (bool, error)
+    SampleUploaded(ctx context.Context, limit int) ([]*models.File, error)
 }
 
 // fileRepository implements FileRepository interface using PostgreSQL
@@ -53,9 +117,7 @@ func (r *fileRepository) Create(ctx context.Context, file *models.File) error {
     }
 
     // Start transaction with high isolation level
-    tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-        Isolation: sql.LevelSerializable,
-    })
+    tx, err := beginTx(ctx, r.db)
     if err != nil {
         return fmt.Errorf("failed to start transaction: %w", err)
     }
@@ -65,32 +127,41 @@ func (r *fileRepository) Create(ctx context.Context, file *models.File) error {
     now := time.Now().UTC()
     file.CreatedAt = now
     file.UpdatedAt = now
+    file.Version = 1
 
     // Insert file record with parameterized query
     const query = `
-        INSERT INTO files (
-            id, file_name, size, content_type, status, 
-            storage_path, checksum, created_at, updated_at, last_accessed_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        INSERT INTO files (` + fileColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+                $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
     `
 
     _, err = tx.ExecContext(ctx, query,
-        file.ID, file.FileName, file.Size, file.ContentType,
-        file.Status, file.StoragePath, file.Checksum,
-        file.CreatedAt, file.UpdatedAt, file.LastAccessedAt,
+        file.ID, file.FileName, file.Folder, file.Size, file.ContentType,
+        file.Status, file.StoragePath, file.Checksum, file.Encryption,
+        file.PreviewStoragePath, file.DraftExpiresAt, file.OwnerID,
+        file.Version, file.CreatedAt, file.UpdatedAt, file.LastAccessedAt,
+        file.ReplicationStatus, file.ReplicationAttempts, file.ReplicatedAt,
+        file.ServerSideEncryption, file.Tags, file.RetentionMode, file.RetainUntil,
+        file.LegalHold, file.StorageClass, file.ArchiveStatus, file.ArchivedAt,
+        file.RestoreExpiresAt,
     )
     if err != nil {
         return fmt.Errorf("failed to insert file: %w", err)
     }
+    if err = appendStatusChanges(ctx, tx, file); err != nil {
+        return err
+    }
 
     // Commit transaction
     if err = tx.Commit(); err != nil {
         return fmt.Errorf("failed to commit transaction: %w", err)
     }
+    file.ResetStatusChanges()
 
     r.log.Info("Created new file record",
-        logger.zap.String("fileId", file.ID),
-        logger.zap.String("fileName", file.FileName))
+        logger.String("fileId", file.ID),
+        logger.String("fileName", file.FileName))
 
     return nil
 }
@@ -102,21 +173,15 @@ func (r *fileRepository) GetByID(ctx context.Context, id string) (*models.File,
     }
 
     const query = `
-        SELECT id, file_name, size, content_type, status,
-               storage_path, checksum, created_at, updated_at, last_accessed_at
+        SELECT ` + fileColumns + `
         FROM files
         WHERE id = $1 AND status != $2
     `
 
-    file := &models.File{}
-    err := r.db.QueryRowContext(ctx, query, id, models.FileStatusDeleted).Scan(
-        &file.ID, &file.FileName, &file.Size, &file.ContentType,
-        &file.Status, &file.StoragePath, &file.Checksum,
-        &file.CreatedAt, &file.UpdatedAt, &file.LastAccessedAt,
-    )
+    file, err := scanFile(conn(ctx, r.db).QueryRowContext(ctx, query, id, models.FileStatusDeleted))
 
     if err == sql.ErrNoRows {
-        r.log.Warn("File not found", logger.zap.String("fileId", id))
+        r.log.Warn("File not found", logger.String("fileId", id))
         return nil, ErrNotFound
     }
     if err != nil {
@@ -124,32 +189,54 @@ func (r *fileRepository) GetByID(ctx context.Context, id string) (*models.File,
     }
 
     // Update last accessed timestamp
-    _, err = r.db.ExecContext(ctx,
+    _, err = conn(ctx, r.db).ExecContext(ctx,
         "UPDATE files SET last_accessed_at = $1 WHERE id = $2",
         time.Now().UTC(), id,
     )
     if err != nil {
         r.log.Error("Failed to update last accessed timestamp",
-            logger.zap.String("fileId", id),
-            logger.zap.Error(err))
+            logger.String("fileId", id),
+            logger.Error(err))
     }
 
     r.log.Info("Retrieved file record",
-        logger.zap.String("fileId", id),
-        logger.zap.String("fileName", file.FileName))
+        logger.String("fileId", id),
+        logger.String("fileName", file.FileName))
 
     return file, nil
 }
 
-// Update modifies an existing file record with audit trail
+// FindByName returns the most recent file named fileName in folder.
+// Deleted, draft and failed files do not hold their names.
+func (r *fileRepository) FindByName(ctx context.Context, folder, fileName string) (*models.File, error) {
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE folder = $1 AND file_name = $2 AND status NOT IN ($3, $4, $5)
+        ORDER BY created_at DESC
+        LIMIT 1
+    `
+
+    file, err := scanFile(conn(ctx, r.db).QueryRowContext(ctx, query, folder, fileName,
+        models.FileStatusDeleted, models.FileStatusDraft, models.FileStatusFailed))
+    if err == sql.ErrNoRows {
+        return nil, ErrNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to find file by name: %w", err)
+    }
+    return file, nil
+}
+
+// Update modifies an existing file record with audit trail. The update only
+// applies if the record is still at file.Version, which is then incremented;
+// otherwise ErrVersionConflict is returned.
 func (r *fileRepository) Update(ctx context.Context, file *models.File) error {
     if file == nil || file.ID == "" {
         return ErrInvalidID
     }
 
-    tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-        Isolation: sql.LevelSerializable,
-    })
+    tx, err := beginTx(ctx, r.db)
     if err != nil {
         return fmt.Errorf("failed to start transaction: %w", err)
     }
@@ -159,18 +246,29 @@ func (r *fileRepository) Update(ctx context.Context, file *models.File) error {
 
     const query = `
         UPDATE files 
-        SET file_name = $1, size = $2, content_type = $3,
-            status = $4, storage_path = $5, checksum = $6,
-            updated_at = $7
-        WHERE id = $8 AND status != $9
+        SET file_name = $1, folder = $2, size = $3, content_type = $4,
+            status = $5, storage_path = $6, checksum = $7,
+            encryption = $8, preview_storage_path = $9,
+            draft_expires_at = $10, updated_at = $11,
+            server_side_encryption = $12, tags = $13,
+            retention_mode = $14, retain_until = $15, legal_hold = $16,
+            version = version + 1
+        WHERE id = $17 AND version = $18 AND status != $19
     `
 
     result, err := tx.ExecContext(ctx, query,
-        file.FileName, file.Size, file.ContentType,
+        file.FileName, file.Folder, file.Size, file.ContentType,
         file.Status, file.StoragePath, file.Checksum,
-        file.UpdatedAt, file.ID, models.FileStatusDeleted,
+        file.Encryption, file.PreviewStoragePath,
+        file.DraftExpiresAt, file.UpdatedAt,
+        file.ServerSideEncryption, file.Tags,
+        file.RetentionMode, file.RetainUntil, file.LegalHold,
+        file.ID, file.Version, models.FileStatusDeleted,
     )
     if err != nil {
+        if isSerializationFailure(err) {
+            return ErrVersionConflict
+        }
         return fmt.Errorf("failed to update file: %w", err)
     }
 
@@ -179,29 +277,74 @@ func (r *fileRepository) Update(ctx context.Context, file *models.File) error {
         return fmt.Errorf("failed to get affected rows: %w", err)
     }
     if rows == 0 {
-        return ErrNotFound
+        return r.updateMissError(ctx, tx, file.ID)
+    }
+    if err = appendStatusChanges(ctx, tx, file); err != nil {
+        return err
     }
 
     if err = tx.Commit(); err != nil {
+        if isSerializationFailure(err) {
+            return ErrVersionConflict
+        }
         return fmt.Errorf("failed to commit transaction: %w", err)
     }
+    file.Version++
+    file.ResetStatusChanges()
 
     r.log.Info("Updated file record",
-        logger.zap.String("fileId", file.ID),
-        logger.zap.String("fileName", file.FileName))
+        logger.String("fileId", file.ID),
+        logger.String("fileName", file.FileName))
+
+    return nil
+}
 
+// appendStatusChanges records the file's status transitions in the audit
+// log with the transaction saving them, so the log never holds a change
+// that was rolled back, nor misses one that was committed
+func appendStatusChanges(ctx context.Context, tx querier, file *models.File) error {
+    for _, change := range file.StatusChanges() {
+        if err := appendAuditEvent(ctx, tx, models.NewStatusAuditEvent(change)); err != nil {
+            return err
+        }
+    }
     return nil
 }
 
+// updateMissError explains an update that matched no row: the file is either
+// gone or at another version than the caller read
+func (r *fileRepository) updateMissError(ctx context.Context, tx querier, id string) error {
+    const query = `
+        SELECT EXISTS (SELECT 1 FROM files WHERE id = $1 AND status != $2)
+    `
+
+    var exists bool
+    if err := tx.QueryRowContext(ctx, query, id, models.FileStatusDeleted).Scan(&exists); err != nil {
+        if isSerializationFailure(err) {
+            return ErrVersionConflict
+        }
+        return fmt.Errorf("failed to check file existence: %w", err)
+    }
+    if !exists {
+        return ErrNotFound
+    }
+    return ErrVersionConflict
+}
+
+// isSerializationFailure reports whether err is a PostgreSQL serialization
+// failure, raised when a concurrent transaction modified the same rows
+func isSerializationFailure(err error) bool {
+    var pqErr *pq.Error
+    return errors.As(err, &pqErr) && pqErr.Code == "40001"
+}
+
 // Delete performs a soft deletion of a file record
 func (r *fileRepository) Delete(ctx context.Context, id string) error {
     if id == "" {
         return ErrInvalidID
     }
 
-    tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-        Isolation: sql.LevelSerializable,
-    })
+    tx, err := beginTx(ctx, r.db)
     if err != nil {
         return fmt.Errorf("failed to start transaction: %w", err)
     }
@@ -235,21 +378,22 @@ func (r *fileRepository) Delete(ctx context.Context, id string) error {
         return fmt.Errorf("failed to commit transaction: %w", err)
     }
 
-    r.log.Info("Deleted file record", logger.zap.String("fileId", id))
+    r.log.Info("Deleted file record", logger.String("fileId", id))
 
     return nil
 }
 
-// List retrieves a paginated list of files with optional filters
+// List retrieves a paginated list of files with optional filters. Deleted
+// files and uncommitted drafts are excluded.
 func (r *fileRepository) List(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*models.File, int64, error) {
     if offset < 0 || limit <= 0 {
         return nil, 0, errors.New("invalid pagination parameters")
     }
 
     // Build query with filters
-    whereClause := "WHERE status != $1"
-    args := []interface{}{models.FileStatusDeleted}
-    argCount := 2
+    whereClause := "WHERE status NOT IN ($1, $2)"
+    args := []interface{}{models.FileStatusDeleted, models.FileStatusDraft}
+    argCount := 3
 
     if filters != nil {
         for key, value := range filters {
@@ -262,22 +406,21 @@ func (r *fileRepository) List(ctx context.Context, offset, limit int, filters ma
     // Get total count
     var total int64
     countQuery := fmt.Sprintf("SELECT COUNT(*) FROM files %s", whereClause)
-    err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+    err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total)
     if err != nil {
         return nil, 0, fmt.Errorf("failed to get total count: %w", err)
     }
 
     // Get paginated results
     query := fmt.Sprintf(`
-        SELECT id, file_name, size, content_type, status,
-               storage_path, checksum, created_at, updated_at, last_accessed_at
+        SELECT %s
         FROM files %s
         ORDER BY created_at DESC
         LIMIT $%d OFFSET $%d
-    `, whereClause, argCount, argCount+1)
+    `, fileColumns, whereClause, argCount, argCount+1)
 
     args = append(args, limit, offset)
-    rows, err := r.db.QueryContext(ctx, query, args...)
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
     if err != nil {
         return nil, 0, fmt.Errorf("failed to list files: %w", err)
     }
@@ -285,12 +428,7 @@ func (r *fileRepository) List(ctx context.Context, offset, limit int, filters ma
 
     var files []*models.File
     for rows.Next() {
-        file := &models.File{}
-        err := rows.Scan(
-            &file.ID, &file.FileName, &file.Size, &file.ContentType,
-            &file.Status, &file.StoragePath, &file.Checksum,
-            &file.CreatedAt, &file.UpdatedAt, &file.LastAccessedAt,
-        )
+        file, err := scanFile(rows)
         if err != nil {
             return nil, 0, fmt.Errorf("failed to scan file: %w", err)
         }
@@ -302,9 +440,657 @@ func (r *fileRepository) List(ctx context.Context, offset, limit int, filters ma
     }
 
     r.log.Info("Listed files",
-        logger.zap.Int("count", len(files)),
-        logger.zap.Int("offset", offset),
-        logger.zap.Int("limit", limit))
+        logger.Int("count", len(files)),
+        logger.Int("offset", offset),
+        logger.Int("limit", limit))
 
     return files, total, nil
-}
\ No newline at end of file
+}
+
+// ListExpiredDrafts returns up to limit drafts that expired before the given time
+func (r *fileRepository) ListExpiredDrafts(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE status = $1 AND draft_expires_at < $2
+        ORDER BY draft_expires_at
+        LIMIT $3
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusDraft, before, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list expired drafts: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// ListStaleStaged returns up to limit pending files whose content is still
+// staged for asynchronous processing and that were last updated before the
+// given time
+func (r *fileRepository) ListStaleStaged(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE status = $1 AND updated_at < $2
+            AND (storage_path LIKE $3 OR storage_path LIKE $4)
+        ORDER BY updated_at
+        LIMIT $5
+    `
+
+    staged := storagekey.Staging + "/%"
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusPending, before,
+        staged, storagekey.TenantPrefix+"/%/"+staged, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list stale staged files: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// ListUploadedInFolders returns up to limit files uploaded into any of the
+// folders in [since, until), oldest first. Deleted files and drafts are excluded.
+func (r *fileRepository) ListUploadedInFolders(ctx context.Context, folders []string, since, until time.Time, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+    if len(folders) == 0 {
+        return nil, nil
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE folder = ANY($1) AND status NOT IN ($2, $3)
+          AND created_at >= $4 AND created_at < $5
+        ORDER BY created_at
+        LIMIT $6
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(folders),
+        models.FileStatusDeleted, models.FileStatusDraft, since, until, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list folder uploads: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// UsageByOwner returns the total size of the files held by an owner, including drafts
+func (r *fileRepository) UsageByOwner(ctx context.Context, ownerID string) (int64, error) {
+    const query = `
+        SELECT COALESCE(SUM(size), 0)
+        FROM files
+        WHERE owner_id = $1 AND status != $2
+    `
+
+    var used int64
+    if err := conn(ctx, r.db).QueryRowContext(ctx, query, ownerID, models.FileStatusDeleted).Scan(&used); err != nil {
+        return 0, fmt.Errorf("failed to get storage usage: %w", err)
+    }
+    return used, nil
+}
+
+// TotalUsage returns the total size of all stored files, including drafts
+func (r *fileRepository) TotalUsage(ctx context.Context) (int64, error) {
+    const query = `
+        SELECT COALESCE(SUM(size), 0)
+        FROM files
+        WHERE status != $1
+    `
+
+    var used int64
+    if err := conn(ctx, r.db).QueryRowContext(ctx, query, models.FileStatusDeleted).Scan(&used); err != nil {
+        return 0, fmt.Errorf("failed to get storage usage: %w", err)
+    }
+    return used, nil
+}
+
+// UsageByState returns the size and number of stored files by storage state
+// and storage class, classified as File.StorageState does at now
+func (r *fileRepository) UsageByState(ctx context.Context, now time.Time) ([]models.StorageUsage, error) {
+    const query = `
+        SELECT state, storage_class, SUM(size), COUNT(*)
+        FROM (
+            SELECT size,
+                CASE
+                    WHEN status IN ($2, $3) THEN $4
+                    WHEN status = $5 OR (status = $6 AND draft_expires_at < $7) THEN $8
+                    WHEN archive_status != '' THEN $9
+                    ELSE $10
+                END AS state,
+                COALESCE(NULLIF(storage_class, ''), $11) AS storage_class
+            FROM files
+            WHERE status != $1
+        ) usage
+        GROUP BY state, storage_class
+        ORDER BY state, storage_class
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query,
+        models.FileStatusDeleted,
+        models.FileStatusInfected, models.FileStatusPendingReview, models.StorageStateQuarantined,
+        models.FileStatusFailed, models.FileStatusDraft, now, models.StorageStatePendingPurge,
+        models.StorageStateArchived, models.StorageStateActive, models.StorageClassStandard)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get storage usage by state: %w", err)
+    }
+    defer rows.Close()
+
+    var usage []models.StorageUsage
+    for rows.Next() {
+        var u models.StorageUsage
+        if err := rows.Scan(&u.State, &u.StorageClass, &u.Bytes, &u.Objects); err != nil {
+            return nil, fmt.Errorf("failed to scan storage usage: %w", err)
+        }
+        usage = append(usage, u)
+    }
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+    return usage, nil
+}
+
+// ListAwaitingReplication returns up to limit uploaded files whose content has
+// not been copied to the secondary bucket yet, oldest first
+func (r *fileRepository) ListAwaitingReplication(ctx context.Context, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE status = $1 AND replication_status IN ('', $2)
+        ORDER BY created_at
+        LIMIT $3
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusUploaded, models.ReplicationPending, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list files awaiting replication: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// UpdateReplication records the replication status, attempts and time of a
+// file. It leaves the version alone, as replication does not change the file
+// for its owner.
+func (r *fileRepository) UpdateReplication(ctx context.Context, file *models.File) error {
+    if file == nil || file.ID == "" {
+        return ErrInvalidID
+    }
+
+    const query = `
+        UPDATE files
+        SET replication_status = $1, replication_attempts = $2, replicated_at = $3
+        WHERE id = $4 AND status != $5
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query,
+        file.ReplicationStatus, file.ReplicationAttempts, file.ReplicatedAt,
+        file.ID, models.FileStatusDeleted,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to update replication status: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrNotFound
+    }
+
+    return nil
+}
+
+// ListIdle returns up to limit uploaded files last accessed before the given
+// time whose content can be archived, least recently accessed first.
+// Archiving copies the object, so content larger than a single copy allows,
+// shared with other files, encrypted with a customer-provided key or under
+// an Object Lock retention or legal hold is left in place.
+func (r *fileRepository) ListIdle(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files f
+        WHERE status = $1 AND archive_status = '' AND last_accessed_at < $2
+          AND COALESCE(server_side_encryption::jsonb->>'algorithm', '') != $3
+          AND NOT legal_hold AND (retain_until IS NULL OR retain_until < $2)
+          AND size <= $4
+          AND NOT EXISTS (
+              SELECT 1 FROM files o
+              WHERE o.storage_path = f.storage_path AND o.id != f.id AND o.status != $5
+          )
+        ORDER BY last_accessed_at
+        LIMIT $6
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusUploaded, before,
+        models.SSEAlgorithmCustomer, maxCopySize, models.FileStatusDeleted, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list idle files: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// ListPendingRestores returns up to limit files whose restore was requested
+// and has not completed, or whose restored copy expired at now, oldest
+// request first
+func (r *fileRepository) ListPendingRestores(ctx context.Context, now time.Time, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE status = $1
+          AND (archive_status = $2 OR (archive_status = $3 AND restore_expires_at <= $4))
+        ORDER BY updated_at
+        LIMIT $5
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusUploaded,
+        models.ArchiveStatusRestoring, models.ArchiveStatusRestored, now, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list pending restores: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// ListCold returns up to limit uploaded files in the default storage class
+// last accessed before the given time, least recently accessed first.
+// Content smaller than an infrequent access class bills, larger than a
+// single copy allows, shared with other files, encrypted with a
+// customer-provided key or under an Object Lock retention or legal hold is
+// left in place.
+func (r *fileRepository) ListCold(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files f
+        WHERE status = $1 AND archive_status = '' AND storage_class = '' AND last_accessed_at < $2
+          AND COALESCE(server_side_encryption::jsonb->>'algorithm', '') != $3
+          AND NOT legal_hold AND (retain_until IS NULL OR retain_until < $2)
+          AND size BETWEEN $4 AND $5
+          AND NOT EXISTS (
+              SELECT 1 FROM files o
+              WHERE o.storage_path = f.storage_path AND o.id != f.id AND o.status != $6
+          )
+        ORDER BY last_accessed_at
+        LIMIT $7
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusUploaded, before,
+        models.SSEAlgorithmCustomer, minColdSize, maxCopySize, models.FileStatusDeleted, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list cold files: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// RecordAccess records that a file was read at the given time. Earlier
+// times than the one recorded are ignored, and like UpdateArchive it leaves
+// the version alone.
+func (r *fileRepository) RecordAccess(ctx context.Context, id string, at time.Time) error {
+    if id == "" {
+        return ErrInvalidID
+    }
+
+    const query = `
+        UPDATE files
+        SET last_accessed_at = $1
+        WHERE id = $2 AND last_accessed_at < $1
+    `
+
+    if _, err := conn(ctx, r.db).ExecContext(ctx, query, at, id); err != nil {
+        return fmt.Errorf("failed to record access: %w", err)
+    }
+    return nil
+}
+
+// UpdateArchive records the storage class and archive status of a file. It
+// leaves the version alone, as tiering and archiving do not change the file
+// for its owner.
+func (r *fileRepository) UpdateArchive(ctx context.Context, file *models.File) error {
+    if file == nil || file.ID == "" {
+        return ErrInvalidID
+    }
+
+    const query = `
+        UPDATE files
+        SET storage_class = $1, archive_status = $2, archived_at = $3,
+            restore_expires_at = $4, server_side_encryption = $5
+        WHERE id = $6 AND status != $7
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query,
+        file.StorageClass, file.ArchiveStatus, file.ArchivedAt,
+        file.RestoreExpiresAt, file.ServerSideEncryption,
+        file.ID, models.FileStatusDeleted,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to update archive status: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrNotFound
+    }
+
+    return nil
+}
+
+// ListLegacyStoragePaths returns up to limit files after afterID, in ID
+// order, whose content is stored under a legacy unsharded key: a bare ID
+// without a slash. Moving content copies the object and deletes the old
+// one, so content larger than a single copy allows, encrypted with a
+// customer-provided key, archived or under an Object Lock retention or
+// legal hold is left in place.
+func (r *fileRepository) ListLegacyStoragePaths(ctx context.Context, afterID string, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE id > $1 AND status != $2 AND storage_path != '' AND storage_path NOT LIKE '%/%'
+          AND archive_status = ''
+          AND COALESCE(server_side_encryption::jsonb->>'algorithm', '') != $3
+          AND NOT legal_hold AND (retain_until IS NULL OR retain_until < $4)
+          AND size <= $5
+        ORDER BY id
+        LIMIT $6
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, afterID, models.FileStatusDeleted,
+        models.SSEAlgorithmCustomer, time.Now().UTC(), maxCopySize, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list legacy storage paths: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// UpdateStoragePath points every file and blob stored under from at the
+// file's storage path, recording the encryption and Object Lock protection
+// the file's content was written with. Content shared by deduplicated
+// uploads moves for all of them at once.
+func (r *fileRepository) UpdateStoragePath(ctx context.Context, from string, file *models.File) error {
+    if file == nil || file.ID == "" {
+        return ErrInvalidID
+    }
+    if from == "" || file.StoragePath == "" {
+        return models.ErrInvalidPath
+    }
+
+    tx, err := beginTx(ctx, r.db)
+    if err != nil {
+        return fmt.Errorf("failed to start transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    const filesQuery = `
+        UPDATE files
+        SET storage_path = $1, server_side_encryption = $2,
+            retention_mode = $3, retain_until = $4, legal_hold = $5, updated_at = $6
+        WHERE storage_path = $7
+    `
+
+    result, err := tx.ExecContext(ctx, filesQuery,
+        file.StoragePath, file.ServerSideEncryption,
+        file.RetentionMode, file.RetainUntil, file.LegalHold, time.Now().UTC(), from)
+    if err != nil {
+        return fmt.Errorf("failed to update storage path: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrNotFound
+    }
+
+    const blobsQuery = `UPDATE blobs SET storage_key = $1 WHERE storage_key = $2`
+    if _, err := tx.ExecContext(ctx, blobsQuery, file.StoragePath, from); err != nil {
+        return fmt.Errorf("failed to update blob storage key: %w", err)
+    }
+
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+
+    return nil
+}
+
+// ListStoragePaths returns up to limit files that are not deleted and have
+// a storage path, ordered by storage path then ID after (afterPath,
+// afterID). Paths are ordered by their bytes, as S3 lists keys, so the
+// files can be merged with a listing of the bucket.
+func (r *fileRepository) ListStoragePaths(ctx context.Context, afterPath, afterID string, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE status != $1 AND storage_path != ''
+          AND (storage_path COLLATE "C", id) > ($2 COLLATE "C", $3)
+        ORDER BY storage_path COLLATE "C", id
+        LIMIT $4
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusDeleted, afterPath, afterID, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list storage paths: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
+
+// StoragePathReferenced checks if a file that is not deleted, or a blob
+// awaiting collection, references the object under storagePath
+func (r *fileRepository) StoragePathReferenced(ctx context.Context, storagePath string) (bool, error) {
+    const query = `
+        SELECT EXISTS (SELECT 1 FROM files WHERE storage_path = $1 AND status != $2)
+            OR EXISTS (SELECT 1 FROM blobs WHERE storage_key = $1)
+    `
+
+    var referenced bool
+    if err := conn(ctx, r.db).QueryRowContext(ctx, query, storagePath, models.FileStatusDeleted).Scan(&referenced); err != nil {
+        return false, fmt.Errorf("failed to check storage path references: %w", err)
+    }
+    return referenced, nil
+}
+
+// SampleUploaded returns up to limit uploaded files picked at random whose
+// content the service can read back and verify: those with a checksum,
+// not archived and not encrypted with a customer-provided key
+func (r *fileRepository) SampleUploaded(ctx context.Context, limit int) ([]*models.File, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileColumns + `
+        FROM files
+        WHERE status = $1 AND checksum != '' AND archive_status = ''
+          AND COALESCE(server_side_encryption::jsonb->>'algorithm', '') != $2
+        ORDER BY random()
+        LIMIT $3
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.FileStatusUploaded, models.SSEAlgorithmCustomer, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to sample uploaded files: %w", err)
+    }
+    defer rows.Close()
+
+    var files []*models.File
+    for rows.Next() {
+        file, err := scanFile(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file: %w", err)
+        }
+        files = append(files, file)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return files, nil
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/lib/pq" // v1.10.9
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrFileRequestNotFound is returned when a file request does not exist
+var ErrFileRequestNotFound = errors.New("file request not found")
+
+// FileRequestRepository defines persistence operations for file requests
+type FileRequestRepository interface {
+    Create(ctx context.Context, request *models.FileRequest) error
+    GetByID(ctx context.Context, id string) (*models.FileRequest, error)
+    ListByOwner(ctx context.Context, ownerID string) ([]*models.FileRequest, error)
+    Reserve(ctx context.Context, id string, now time.Time) error
+    Unreserve(ctx context.Context, id string) error
+    Revoke(ctx context.Context, id, ownerID string, at time.Time) error
+}
+
+// fileRequestRepository implements FileRequestRepository using PostgreSQL
+type fileRequestRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// fileRequestColumns lists the file_requests columns in the order scanned by scanFileRequest
+const fileRequestColumns = `id, owner_id, title, folder, max_file_size, allowed_types, max_files,
+               upload_count, owner_roles, owner_tenant, expires_at, revoked_at, created_at, last_upload_at`
+
+// NewFileRequestRepository creates a new instance of fileRequestRepository
+func NewFileRequestRepository(db *sql.DB) (FileRequestRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &fileRequestRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanFileRequest scans a row selected with fileRequestColumns
+func scanFileRequest(row rowScanner) (*models.FileRequest, error) {
+    request := &models.FileRequest{}
+    err := row.Scan(
+        &request.ID, &request.OwnerID, &request.Title, &request.Folder, &request.MaxFileSize,
+        pq.Array(&request.AllowedTypes), &request.MaxFiles, &request.UploadCount,
+        pq.Array(&request.OwnerRoles), &request.OwnerTenant, &request.ExpiresAt, &request.RevokedAt,
+        &request.CreatedAt, &request.LastUploadAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return request, nil
+}
+
+// Create inserts a new file request
+func (r *fileRequestRepository) Create(ctx context.Context, request *models.FileRequest) error {
+    if request == nil {
+        return errors.New("file request is required")
+    }
+
+    const query = `
+        INSERT INTO file_requests (
+            id, owner_id, title, folder, max_file_size, allowed_types, max_files,
+            upload_count, owner_roles, owner_tenant, expires_at, created_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+    `
+
+    _, err := conn(ctx, r.db).ExecContext(ctx, query,
+        request.ID, request.OwnerID, request.Title, request.Folder, request.MaxFileSize,
+        pq.Array(request.AllowedTypes), request.MaxFiles, request.UploadCount,
+        pq.Array(request.OwnerRoles), request.OwnerTenant, request.ExpiresAt, request.CreatedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to create file request: %w", err)
+    }
+
+    r.log.Info("Created file request",
+        logger.String("requestId", request.ID),
+        logger.String("ownerId", request.OwnerID),
+        logger.Time("expiresAt", request.ExpiresAt))
+
+    return nil
+}
+
+// GetByID retrieves a file request, including revoked and expired ones
+func (r *fileRequestRepository) GetByID(ctx context.Context, id string) (*models.FileRequest, error) {
+    const query = `
+        SELECT ` + fileRequestColumns + `
+        FROM file_requests
+        WHERE id = $1
+    `
+
+    request, err := scanFileRequest(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, ErrFileRequestNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get file request: %w", err)
+    }
+    return request, nil
+}
+
+// ListByOwner returns an owner's file requests, newest first
+func (r *fileRequestRepository) ListByOwner(ctx context.Context, ownerID string) ([]*models.FileRequest, error) {
+    const query = `
+        SELECT ` + fileRequestColumns + `
+        FROM file_requests
+        WHERE owner_id = $1
+        ORDER BY created_at DESC
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, ownerID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list file requests: %w", err)
+    }
+    defer rows.Close()
+
+    var requests []*models.FileRequest
+    for rows.Next() {
+        request, err := scanFileRequest(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file request: %w", err)
+        }
+        requests = append(requests, request)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return requests, nil
+}
+
+// Reserve claims one upload slot of an open request and records it as the
+// request's last upload. It fails with models.ErrFileRequestClosed when the
+// request is revoked, expired or full.
+func (r *fileRequestRepository) Reserve(ctx context.Context, id string, now time.Time) error {
+    const query = `
+        UPDATE file_requests
+        SET upload_count = upload_count + 1, last_upload_at = $2
+        WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2
+          AND upload_count < max_files
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, id, now)
+    if err != nil {
+        return fmt.Errorf("failed to reserve upload: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return models.ErrFileRequestClosed
+    }
+    return nil
+}
+
+// Unreserve returns an upload slot claimed by Reserve for an upload that failed
+func (r *fileRequestRepository) Unreserve(ctx context.Context, id string) error {
+    const query = `
+        UPDATE file_requests
+        SET upload_count = upload_count - 1
+        WHERE id = $1 AND upload_count > 0
+    `
+
+    if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+        return fmt.Errorf("failed to release upload: %w", err)
+    }
+    return nil
+}
+
+// Revoke closes an owner's file request to further uploads
+func (r *fileRequestRepository) Revoke(ctx context.Context, id, ownerID string, at time.Time) error {
+    const query = `
+        UPDATE file_requests
+        SET revoked_at = COALESCE(revoked_at, $3)
+        WHERE id = $1 AND owner_id = $2
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, id, ownerID, at)
+    if err != nil {
+        return fmt.Errorf("failed to revoke file request: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrFileRequestNotFound
+    }
+
+    r.log.Info("Revoked file request",
+        logger.String("requestId", id),
+        logger.String("ownerId", ownerID))
+
+    return nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// Lock errors
+var (
+    ErrLockNotFound = errors.New("lock not found")
+    ErrLockHeld     = errors.New("lock is held by another user")
+)
+
+// LockRepository defines persistence operations for file locks
+type LockRepository interface {
+    Acquire(ctx context.Context, lock *models.FileLock) error
+    Get(ctx context.Context, fileID string) (*models.FileLock, error)
+    Release(ctx context.Context, fileID, ownerID string) error
+    Break(ctx context.Context, fileID string) error
+}
+
+// lockRepository implements LockRepository using PostgreSQL
+type lockRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// NewLockRepository creates a new instance of lockRepository
+func NewLockRepository(db *sql.DB) (LockRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &lockRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// Acquire takes the lock on lock.FileID, or extends it if the owner already
+// holds it. It fails with ErrLockHeld while another user holds an unexpired lock.
+func (r *lockRepository) Acquire(ctx context.Context, lock *models.FileLock) error {
+    if lock == nil || lock.FileID == "" || lock.OwnerID == "" {
+        return errors.New("lock file and owner are required")
+    }
+
+    const query = `
+        INSERT INTO file_locks (file_id, owner_id, expires_at, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (file_id) DO UPDATE
+        SET owner_id = EXCLUDED.owner_id,
+            expires_at = EXCLUDED.expires_at,
+            created_at = CASE WHEN file_locks.owner_id = EXCLUDED.owner_id
+                              THEN file_locks.created_at ELSE EXCLUDED.created_at END
+        WHERE file_locks.owner_id = EXCLUDED.owner_id OR file_locks.expires_at <= $4
+        RETURNING created_at
+    `
+
+    err := conn(ctx, r.db).QueryRowContext(ctx, query,
+        lock.FileID, lock.OwnerID, lock.ExpiresAt, lock.CreatedAt,
+    ).Scan(&lock.CreatedAt)
+    if err == sql.ErrNoRows {
+        return ErrLockHeld
+    }
+    if err != nil {
+        return fmt.Errorf("failed to acquire lock: %w", err)
+    }
+
+    r.log.Info("Acquired file lock",
+        logger.String("fileId", lock.FileID),
+        logger.String("ownerId", lock.OwnerID),
+        logger.Time("expiresAt", lock.ExpiresAt))
+
+    return nil
+}
+
+// Get retrieves the lock on a file, which may have expired
+func (r *lockRepository) Get(ctx context.Context, fileID string) (*models.FileLock, error) {
+    const query = `
+        SELECT file_id, owner_id, expires_at, created_at
+        FROM file_locks
+        WHERE file_id = $1
+    `
+
+    lock := &models.FileLock{}
+    err := conn(ctx, r.db).QueryRowContext(ctx, query, fileID).Scan(
+        &lock.FileID, &lock.OwnerID, &lock.ExpiresAt, &lock.CreatedAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrLockNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get lock: %w", err)
+    }
+    return lock, nil
+}
+
+// Release removes the lock on a file if it is held by ownerID
+func (r *lockRepository) Release(ctx context.Context, fileID, ownerID string) error {
+    const query = `
+        DELETE FROM file_locks
+        WHERE file_id = $1 AND owner_id = $2
+    `
+    if err := r.delete(ctx, query, fileID, ownerID); err != nil {
+        return err
+    }
+
+    r.log.Info("Released file lock",
+        logger.String("fileId", fileID),
+        logger.String("ownerId", ownerID))
+    return nil
+}
+
+// Break removes the lock on a file regardless of its owner
+func (r *lockRepository) Break(ctx context.Context, fileID string) error {
+    const query = `
+        DELETE FROM file_locks
+        WHERE file_id = $1
+    `
+    if err := r.delete(ctx, query, fileID); err != nil {
+        return err
+    }
+
+    r.log.Info("Broke file lock", logger.String("fileId", fileID))
+    return nil
+}
+
+// delete runs a lock deletion, mapping no affected rows to ErrLockNotFound
+func (r *lockRepository) delete(ctx context.Context, query string, args ...interface{}) error {
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+    if err != nil {
+        return fmt.Errorf("failed to delete lock: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrLockNotFound
+    }
+    return nil
+}
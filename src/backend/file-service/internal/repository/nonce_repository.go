@@ -0,0 +1,88 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrNonceUsed is returned when a nonce was already used
+var ErrNonceUsed = errors.New("nonce already used")
+
+// NonceRepository records the nonces of single-use tokens, shared by all
+// replicas. A nonce is kept until its token expires, after which the token
+// is rejected for its expiry alone.
+type NonceRepository interface {
+    Use(ctx context.Context, scope, nonce string, expiresAt time.Time) error
+    DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// nonceRepository implements NonceRepository using PostgreSQL
+type nonceRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// NewNonceRepository creates a new instance of nonceRepository
+func NewNonceRepository(db *sql.DB) (NonceRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &nonceRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// Use records the first use of a nonce within scope, failing with
+// ErrNonceUsed if it was used before. The insert is the check, so of
+// concurrent uses exactly one succeeds.
+func (r *nonceRepository) Use(ctx context.Context, scope, nonce string, expiresAt time.Time) error {
+    const query = `
+        INSERT INTO used_nonces (scope, nonce, expires_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (scope, nonce) DO NOTHING
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, scope, nonce, expiresAt)
+    if err != nil {
+        return fmt.Errorf("failed to record nonce: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrNonceUsed
+    }
+    return nil
+}
+
+// DeleteExpired removes the nonces of tokens that have expired
+func (r *nonceRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+    const query = `
+        DELETE FROM used_nonces
+        WHERE expires_at <= $1
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, now)
+    if err != nil {
+        return 0, fmt.Errorf("failed to delete expired nonces: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return 0, fmt.Errorf("failed to get affected rows: %w", err)
+    }
+
+    if rows > 0 {
+        r.log.Info("Deleted expired nonces", logger.Int64("count", rows))
+    }
+    return rows, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/lib/pq" // v1.10.9
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrPreferencesNotFound is returned when a user has never saved notification preferences
+var ErrPreferencesNotFound = errors.New("notification preferences not found")
+
+// NotificationRepository defines persistence operations for notification preferences
+type NotificationRepository interface {
+    Get(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+    Save(ctx context.Context, prefs *models.NotificationPreferences) error
+    ListDigestDue(ctx context.Context, before time.Time, limit int) ([]*models.NotificationPreferences, error)
+    MarkDigestSent(ctx context.Context, userID string, at time.Time) error
+}
+
+// notificationRepository implements NotificationRepository using PostgreSQL
+type notificationRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// notificationColumns lists the notification_preferences columns in the
+// order scanned by scanPreferences
+const notificationColumns = `user_id, email, webhook_url, share_channels, request_channels,
+               download_channels, download_files, download_folders, digest_enabled,
+               digest_folders, last_digest_at, updated_at`
+
+// NewNotificationRepository creates a new instance of notificationRepository
+func NewNotificationRepository(db *sql.DB) (NotificationRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &notificationRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanPreferences scans a row selected with notificationColumns
+func scanPreferences(row rowScanner) (*models.NotificationPreferences, error) {
+    prefs := &models.NotificationPreferences{}
+    err := row.Scan(
+        &prefs.UserID, &prefs.Email, &prefs.WebhookURL, pq.Array(&prefs.ShareChannels),
+        pq.Array(&prefs.RequestChannels), pq.Array(&prefs.DownloadChannels), pq.Array(&prefs.DownloadFiles),
+        pq.Array(&prefs.DownloadFolders), &prefs.DigestEnabled, pq.Array(&prefs.DigestFolders),
+        &prefs.LastDigestAt, &prefs.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return prefs, nil
+}
+
+// Get retrieves a user's notification preferences
+func (r *notificationRepository) Get(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+    const query = `
+        SELECT ` + notificationColumns + `
+        FROM notification_preferences
+        WHERE user_id = $1
+    `
+
+    prefs, err := scanPreferences(conn(ctx, r.db).QueryRowContext(ctx, query, userID))
+    if err == sql.ErrNoRows {
+        return nil, ErrPreferencesNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+    }
+    return prefs, nil
+}
+
+// Save creates or replaces a user's notification preferences. The time of
+// the last digest is kept, so re-enabling digests does not resend old uploads.
+func (r *notificationRepository) Save(ctx context.Context, prefs *models.NotificationPreferences) error {
+    if prefs == nil || prefs.UserID == "" {
+        return errors.New("preferences user is required")
+    }
+
+    const query = `
+        INSERT INTO notification_preferences (
+            user_id, email, webhook_url, share_channels, request_channels,
+            download_channels, download_files, download_folders, digest_enabled,
+            digest_folders, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        ON CONFLICT (user_id) DO UPDATE
+        SET email = EXCLUDED.email,
+            webhook_url = EXCLUDED.webhook_url,
+            share_channels = EXCLUDED.share_channels,
+            request_channels = EXCLUDED.request_channels,
+            download_channels = EXCLUDED.download_channels,
+            download_files = EXCLUDED.download_files,
+            download_folders = EXCLUDED.download_folders,
+            digest_enabled = EXCLUDED.digest_enabled,
+            digest_folders = EXCLUDED.digest_folders,
+            updated_at = EXCLUDED.updated_at
+        RETURNING last_digest_at
+    `
+
+    err := conn(ctx, r.db).QueryRowContext(ctx, query,
+        prefs.UserID, prefs.Email, prefs.WebhookURL, pq.Array(prefs.ShareChannels),
+        pq.Array(prefs.RequestChannels), pq.Array(prefs.DownloadChannels), pq.Array(prefs.DownloadFiles),
+        pq.Array(prefs.DownloadFolders), prefs.DigestEnabled, pq.Array(prefs.DigestFolders),
+        prefs.UpdatedAt,
+    ).Scan(&prefs.LastDigestAt)
+    if err != nil {
+        return fmt.Errorf("failed to save notification preferences: %w", err)
+    }
+
+    r.log.Info("Saved notification preferences",
+        logger.String("userId", prefs.UserID),
+        logger.Bool("digestEnabled", prefs.DigestEnabled))
+
+    return nil
+}
+
+// ListDigestDue returns up to limit users with digests enabled whose last
+// digest, if any, was sent before the given time
+func (r *notificationRepository) ListDigestDue(ctx context.Context, before time.Time, limit int) ([]*models.NotificationPreferences, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + notificationColumns + `
+        FROM notification_preferences
+        WHERE digest_enabled AND (last_digest_at IS NULL OR last_digest_at < $1)
+        ORDER BY last_digest_at NULLS FIRST
+        LIMIT $2
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, before, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list due digests: %w", err)
+    }
+    defer rows.Close()
+
+    var due []*models.NotificationPreferences
+    for rows.Next() {
+        prefs, err := scanPreferences(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan notification preferences: %w", err)
+        }
+        due = append(due, prefs)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return due, nil
+}
+
+// MarkDigestSent records that a user's digest covers uploads up to at
+func (r *notificationRepository) MarkDigestSent(ctx context.Context, userID string, at time.Time) error {
+    const query = `
+        UPDATE notification_preferences
+        SET last_digest_at = $2
+        WHERE user_id = $1
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, userID, at)
+    if err != nil {
+        return fmt.Errorf("failed to mark digest sent: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrPreferencesNotFound
+    }
+    return nil
+}
@@ -0,0 +1,253 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/lib/pq" // v1.10.9
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrPendingActionNotFound is returned when a pending action does not exist
+var ErrPendingActionNotFound = errors.New("pending action not found")
+
+// PendingActionRepository defines persistence operations for actions
+// awaiting approval
+type PendingActionRepository interface {
+    Create(ctx context.Context, action *models.PendingAction) error
+    GetByID(ctx context.Context, id string) (*models.PendingAction, error)
+    FindPending(ctx context.Context, kind, fileID string, now time.Time) (*models.PendingAction, error)
+    ListPending(ctx context.Context, now time.Time) ([]*models.PendingAction, error)
+    Approve(ctx context.Context, id, approverID string, now time.Time) (*models.PendingAction, error)
+    Resolve(ctx context.Context, id, status string, at time.Time) error
+    Expire(ctx context.Context, now time.Time) ([]*models.PendingAction, error)
+}
+
+// pendingActionRepository implements PendingActionRepository using PostgreSQL
+type pendingActionRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// pendingActionColumns lists the pending_actions columns in the order scanned by scanPendingAction
+const pendingActionColumns = `id, kind, file_id, tenant_id, requested_by, approved_by, status,
+               created_at, expires_at, resolved_at`
+
+// NewPendingActionRepository creates a new instance of pendingActionRepository
+func NewPendingActionRepository(db *sql.DB) (PendingActionRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &pendingActionRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanPendingAction scans a row selected with pendingActionColumns
+func scanPendingAction(row rowScanner) (*models.PendingAction, error) {
+    action := &models.PendingAction{}
+    err := row.Scan(
+        &action.ID, &action.Kind, &action.FileID, &action.TenantID, &action.RequestedBy,
+        pq.Array(&action.ApprovedBy), &action.Status, &action.CreatedAt, &action.ExpiresAt,
+        &action.ResolvedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return action, nil
+}
+
+// Create inserts a new pending action
+func (r *pendingActionRepository) Create(ctx context.Context, action *models.PendingAction) error {
+    if action == nil {
+        return errors.New("pending action is required")
+    }
+
+    const query = `
+        INSERT INTO pending_actions (` + pendingActionColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `
+
+    _, err := conn(ctx, r.db).ExecContext(ctx, query,
+        action.ID, action.Kind, action.FileID, action.TenantID, action.RequestedBy,
+        pq.Array(action.ApprovedBy), action.Status, action.CreatedAt, action.ExpiresAt,
+        action.ResolvedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to create pending action: %w", err)
+    }
+
+    r.log.Info("Created pending action",
+        logger.String("actionId", action.ID),
+        logger.String("kind", action.Kind),
+        logger.String("fileId", action.FileID),
+        logger.Time("expiresAt", action.ExpiresAt))
+
+    return nil
+}
+
+// GetByID retrieves a pending action, including resolved ones
+func (r *pendingActionRepository) GetByID(ctx context.Context, id string) (*models.PendingAction, error) {
+    const query = `
+        SELECT ` + pendingActionColumns + `
+        FROM pending_actions
+        WHERE id = $1
+    `
+
+    action, err := scanPendingAction(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, ErrPendingActionNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get pending action: %w", err)
+    }
+    return action, nil
+}
+
+// FindPending returns the action of the given kind on a file still awaiting
+// approval, if there is one
+func (r *pendingActionRepository) FindPending(ctx context.Context, kind, fileID string, now time.Time) (*models.PendingAction, error) {
+    const query = `
+        SELECT ` + pendingActionColumns + `
+        FROM pending_actions
+        WHERE kind = $1 AND file_id = $2 AND status = $3 AND expires_at > $4
+        ORDER BY created_at
+        LIMIT 1
+    `
+
+    action, err := scanPendingAction(conn(ctx, r.db).QueryRowContext(ctx, query,
+        kind, fileID, models.PendingActionPending, now))
+    if err == sql.ErrNoRows {
+        return nil, ErrPendingActionNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to find pending action: %w", err)
+    }
+    return action, nil
+}
+
+// ListPending returns the actions awaiting approval, oldest first
+func (r *pendingActionRepository) ListPending(ctx context.Context, now time.Time) ([]*models.PendingAction, error) {
+    const query = `
+        SELECT ` + pendingActionColumns + `
+        FROM pending_actions
+        WHERE status = $1 AND expires_at > $2
+        ORDER BY created_at
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.PendingActionPending, now)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list pending actions: %w", err)
+    }
+    defer rows.Close()
+
+    return scanPendingActions(rows)
+}
+
+// Approve records approverID's approval of an action awaiting approval and
+// returns the updated action. The check and the update are one statement,
+// so concurrent approvals are all counted and none is counted twice. It
+// fails with models.ErrPendingActionClosed when the action is resolved or
+// expired, and models.ErrApprovalNotPermitted when approverID requested
+// the action or already approved it.
+func (r *pendingActionRepository) Approve(ctx context.Context, id, approverID string, now time.Time) (*models.PendingAction, error) {
+    const query = `
+        UPDATE pending_actions
+        SET approved_by = array_append(approved_by, $2)
+        WHERE id = $1 AND status = $3 AND expires_at > $4
+          AND requested_by != $2 AND NOT ($2 = ANY(approved_by))
+        RETURNING ` + pendingActionColumns
+
+    action, err := scanPendingAction(conn(ctx, r.db).QueryRowContext(ctx, query,
+        id, approverID, models.PendingActionPending, now))
+    if err == nil {
+        return action, nil
+    }
+    if err != sql.ErrNoRows {
+        return nil, fmt.Errorf("failed to approve pending action: %w", err)
+    }
+
+    // Nothing was updated; report why
+    action, err = r.GetByID(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    if !action.IsPending(now) {
+        return nil, models.ErrPendingActionClosed
+    }
+    return nil, models.ErrApprovalNotPermitted
+}
+
+// Resolve closes an action awaiting approval with the given status. It
+// fails with models.ErrPendingActionClosed when the action was already
+// resolved, so an approved action is executed at most once.
+func (r *pendingActionRepository) Resolve(ctx context.Context, id, status string, at time.Time) error {
+    const query = `
+        UPDATE pending_actions
+        SET status = $2, resolved_at = $3
+        WHERE id = $1 AND status = $4
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, id, status, at, models.PendingActionPending)
+    if err != nil {
+        return fmt.Errorf("failed to resolve pending action: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return models.ErrPendingActionClosed
+    }
+
+    r.log.Info("Resolved pending action",
+        logger.String("actionId", id),
+        logger.String("status", status))
+
+    return nil
+}
+
+// Expire marks the actions whose approval window has passed as expired and
+// returns them
+func (r *pendingActionRepository) Expire(ctx context.Context, now time.Time) ([]*models.PendingAction, error) {
+    const query = `
+        UPDATE pending_actions
+        SET status = $2, resolved_at = $3
+        WHERE status = $1 AND expires_at <= $3
+        RETURNING ` + pendingActionColumns
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query,
+        models.PendingActionPending, models.PendingActionExpired, now)
+    if err != nil {
+        return nil, fmt.Errorf("failed to expire pending actions: %w", err)
+    }
+    defer rows.Close()
+
+    return scanPendingActions(rows)
+}
+
+// scanPendingActions scans every row selected with pendingActionColumns
+func scanPendingActions(rows *sql.Rows) ([]*models.PendingAction, error) {
+    var actions []*models.PendingAction
+    for rows.Next() {
+        action, err := scanPendingAction(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan pending action: %w", err)
+        }
+        actions = append(actions, action)
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return actions, nil
+}
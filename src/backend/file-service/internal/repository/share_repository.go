@@ -0,0 +1,259 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrShareNotFound is returned when a share does not exist or is revoked
+var ErrShareNotFound = errors.New("share not found")
+
+// ShareRepository defines persistence operations for file shares and the
+// access review of shares and file requests
+type ShareRepository interface {
+    Record(ctx context.Context, share *models.Share) error
+    Touch(ctx context.Context, fileID, userID string, at time.Time) (bool, error)
+    Revoke(ctx context.Context, id string, at time.Time) error
+    ListStale(ctx context.Context, before time.Time, includeNotified bool, limit int) ([]*models.Share, error)
+    MarkNotified(ctx context.Context, id string, at time.Time) error
+    ListStaleFileRequests(ctx context.Context, before, now time.Time, includeNotified bool, limit int) ([]*models.FileRequest, error)
+    MarkFileRequestNotified(ctx context.Context, id string, at time.Time) error
+}
+
+// shareRepository implements ShareRepository using PostgreSQL
+type shareRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// shareColumns lists the file_shares columns, joined with the owner of the
+// shared file, in the order scanned by scanShare. Shares of files without an
+// owner are reviewed by the user who shared them.
+const shareColumns = `s.id, s.file_id, s.shared_by, s.shared_with, s.created_at, s.last_used_at,
+               s.revoked_at, COALESCE(NULLIF(f.owner_id, ''), s.shared_by)`
+
+// NewShareRepository creates a new instance of shareRepository
+func NewShareRepository(db *sql.DB) (ShareRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &shareRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanShare scans a row selected with shareColumns
+func scanShare(row rowScanner) (*models.Share, error) {
+    share := &models.Share{}
+    err := row.Scan(
+        &share.ID, &share.FileID, &share.SharedBy, &share.SharedWith, &share.CreatedAt,
+        &share.LastUsedAt, &share.RevokedAt, &share.OwnerID,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return share, nil
+}
+
+// Record saves a share. Sharing a file again with the same user renews the
+// existing share, reinstating it if it was revoked.
+func (r *shareRepository) Record(ctx context.Context, share *models.Share) error {
+    if share == nil {
+        return errors.New("share is required")
+    }
+
+    const query = `
+        INSERT INTO file_shares (id, file_id, shared_by, shared_with, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (file_id, shared_with) DO UPDATE SET
+            shared_by = EXCLUDED.shared_by,
+            created_at = EXCLUDED.created_at,
+            last_used_at = NULL,
+            revoked_at = NULL,
+            notified_at = NULL
+        RETURNING id
+    `
+
+    err := conn(ctx, r.db).QueryRowContext(ctx, query,
+        share.ID, share.FileID, share.SharedBy, share.SharedWith, share.CreatedAt,
+    ).Scan(&share.ID)
+    if err != nil {
+        return fmt.Errorf("failed to record share: %w", err)
+    }
+
+    r.log.Info("Recorded file share",
+        logger.String("shareId", share.ID),
+        logger.String("fileId", share.FileID),
+        logger.String("sharedWith", share.SharedWith))
+
+    return nil
+}
+
+// Touch records that userID used their share of fileID and reports whether
+// they hold an active share of it
+func (r *shareRepository) Touch(ctx context.Context, fileID, userID string, at time.Time) (bool, error) {
+    const query = `
+        UPDATE file_shares
+        SET last_used_at = $3
+        WHERE file_id = $1 AND shared_with = $2 AND revoked_at IS NULL
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, fileID, userID, at)
+    if err != nil {
+        return false, fmt.Errorf("failed to touch share: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return false, fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    return rows > 0, nil
+}
+
+// Revoke withdraws an active share
+func (r *shareRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+    const query = `
+        UPDATE file_shares
+        SET revoked_at = $2
+        WHERE id = $1 AND revoked_at IS NULL
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, id, at)
+    if err != nil {
+        return fmt.Errorf("failed to revoke share: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrShareNotFound
+    }
+
+    r.log.Info("Revoked file share", logger.String("shareId", id))
+    return nil
+}
+
+// ListStale returns up to limit active shares of live files that were last
+// used, or created if never used, before the given time, least recently used
+// first. Unless includeNotified is set, shares whose owner was already told
+// about them since their last use are left out.
+func (r *shareRepository) ListStale(ctx context.Context, before time.Time, includeNotified bool,
+    limit int) ([]*models.Share, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + shareColumns + `
+        FROM file_shares s
+        JOIN files f ON f.id = s.file_id AND f.status != $4
+        WHERE s.revoked_at IS NULL
+          AND COALESCE(s.last_used_at, s.created_at) < $1
+          AND ($2 OR s.notified_at IS NULL OR s.notified_at < COALESCE(s.last_used_at, s.created_at))
+        ORDER BY COALESCE(s.last_used_at, s.created_at)
+        LIMIT $3
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, before, includeNotified, limit, models.FileStatusDeleted)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list stale shares: %w", err)
+    }
+    defer rows.Close()
+
+    var shares []*models.Share
+    for rows.Next() {
+        share, err := scanShare(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan share: %w", err)
+        }
+        shares = append(shares, share)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return shares, nil
+}
+
+// MarkNotified records that the owner was told about a stale share
+func (r *shareRepository) MarkNotified(ctx context.Context, id string, at time.Time) error {
+    const query = `
+        UPDATE file_shares
+        SET notified_at = $2
+        WHERE id = $1
+    `
+
+    if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, at); err != nil {
+        return fmt.Errorf("failed to mark share notified: %w", err)
+    }
+    return nil
+}
+
+// ListStaleFileRequests returns up to limit file requests still open at now
+// that last received an upload, or were created if they never did, before
+// the given time, least recently used first. Unless includeNotified is set,
+// requests whose owner was already told about them since their last upload
+// are left out.
+func (r *shareRepository) ListStaleFileRequests(ctx context.Context, before, now time.Time, includeNotified bool,
+    limit int) ([]*models.FileRequest, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT ` + fileRequestColumns + `
+        FROM file_requests
+        WHERE revoked_at IS NULL AND expires_at > $2 AND upload_count < max_files
+          AND COALESCE(last_upload_at, created_at) < $1
+          AND ($3 OR notified_at IS NULL OR notified_at < COALESCE(last_upload_at, created_at))
+        ORDER BY COALESCE(last_upload_at, created_at)
+        LIMIT $4
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, before, now, includeNotified, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list stale file requests: %w", err)
+    }
+    defer rows.Close()
+
+    var requests []*models.FileRequest
+    for rows.Next() {
+        request, err := scanFileRequest(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan file request: %w", err)
+        }
+        requests = append(requests, request)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return requests, nil
+}
+
+// MarkFileRequestNotified records that the owner was told about a stale
+// file request
+func (r *shareRepository) MarkFileRequestNotified(ctx context.Context, id string, at time.Time) error {
+    const query = `
+        UPDATE file_requests
+        SET notified_at = $2
+        WHERE id = $1
+    `
+
+    if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, at); err != nil {
+        return fmt.Errorf("failed to mark file request notified: %w", err)
+    }
+    return nil
+}
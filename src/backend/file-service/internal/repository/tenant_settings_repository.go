@@ -0,0 +1,164 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+
+    "github.com/lib/pq" // v1.10.9
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrTenantSettingsNotFound is returned when a tenant has no settings of its own
+var ErrTenantSettingsNotFound = errors.New("tenant settings not found")
+
+// TenantSettingsRepository defines persistence operations for tenant settings
+type TenantSettingsRepository interface {
+    Get(ctx context.Context, tenantID string) (*models.TenantSettings, error)
+    List(ctx context.Context) ([]*models.TenantSettings, error)
+    Save(ctx context.Context, settings *models.TenantSettings) error
+    Delete(ctx context.Context, tenantID string) error
+}
+
+// tenantSettingsRepository implements TenantSettingsRepository using PostgreSQL
+type tenantSettingsRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// tenantSettingsColumns lists the tenant_settings columns in the order
+// scanned by scanTenantSettings
+const tenantSettingsColumns = `tenant_id, quota_limit, allowed_types, draft_ttl_seconds, updated_by, updated_at`
+
+// NewTenantSettingsRepository creates a new instance of tenantSettingsRepository
+func NewTenantSettingsRepository(db *sql.DB) (TenantSettingsRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &tenantSettingsRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// scanTenantSettings scans a row selected with tenantSettingsColumns
+func scanTenantSettings(row rowScanner) (*models.TenantSettings, error) {
+    settings := &models.TenantSettings{}
+    err := row.Scan(
+        &settings.TenantID, &settings.QuotaLimit, pq.Array(&settings.AllowedTypes),
+        &settings.DraftTTLSeconds, &settings.UpdatedBy, &settings.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return settings, nil
+}
+
+// Get retrieves a tenant's settings
+func (r *tenantSettingsRepository) Get(ctx context.Context, tenantID string) (*models.TenantSettings, error) {
+    const query = `
+        SELECT ` + tenantSettingsColumns + `
+        FROM tenant_settings
+        WHERE tenant_id = $1
+    `
+
+    settings, err := scanTenantSettings(conn(ctx, r.db).QueryRowContext(ctx, query, tenantID))
+    if err == sql.ErrNoRows {
+        return nil, ErrTenantSettingsNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get tenant settings: %w", err)
+    }
+    return settings, nil
+}
+
+// List returns the settings of every tenant that has any, ordered by tenant
+func (r *tenantSettingsRepository) List(ctx context.Context) ([]*models.TenantSettings, error) {
+    const query = `
+        SELECT ` + tenantSettingsColumns + `
+        FROM tenant_settings
+        ORDER BY tenant_id
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list tenant settings: %w", err)
+    }
+    defer rows.Close()
+
+    var tenants []*models.TenantSettings
+    for rows.Next() {
+        settings, err := scanTenantSettings(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan tenant settings: %w", err)
+        }
+        tenants = append(tenants, settings)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return tenants, nil
+}
+
+// Save creates or replaces a tenant's settings
+func (r *tenantSettingsRepository) Save(ctx context.Context, settings *models.TenantSettings) error {
+    if settings == nil || settings.TenantID == "" {
+        return errors.New("settings tenant is required")
+    }
+
+    const query = `
+        INSERT INTO tenant_settings (
+            tenant_id, quota_limit, allowed_types, draft_ttl_seconds, updated_by, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (tenant_id) DO UPDATE
+        SET quota_limit = EXCLUDED.quota_limit,
+            allowed_types = EXCLUDED.allowed_types,
+            draft_ttl_seconds = EXCLUDED.draft_ttl_seconds,
+            updated_by = EXCLUDED.updated_by,
+            updated_at = EXCLUDED.updated_at
+    `
+
+    _, err := conn(ctx, r.db).ExecContext(ctx, query,
+        settings.TenantID, settings.QuotaLimit, pq.Array(settings.AllowedTypes),
+        settings.DraftTTLSeconds, settings.UpdatedBy, settings.UpdatedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to save tenant settings: %w", err)
+    }
+
+    r.log.Info("Saved tenant settings",
+        logger.String("tenantId", settings.TenantID),
+        logger.String("updatedBy", settings.UpdatedBy))
+
+    return nil
+}
+
+// Delete removes a tenant's settings, restoring the configured defaults
+func (r *tenantSettingsRepository) Delete(ctx context.Context, tenantID string) error {
+    const query = `
+        DELETE FROM tenant_settings
+        WHERE tenant_id = $1
+    `
+
+    result, err := conn(ctx, r.db).ExecContext(ctx, query, tenantID)
+    if err != nil {
+        return fmt.Errorf("failed to delete tenant settings: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrTenantSettingsNotFound
+    }
+
+    r.log.Info("Deleted tenant settings", logger.String("tenantId", tenantID))
+    return nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+)
+
+// querier is implemented by *sql.DB and *sql.Tx
+type querier interface {
+    ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+    QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+    QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txKey is the context key of the unit of work started by WithTx
+type txKey struct{}
+
+// TxManager composes repository operations into a unit of work. Every
+// repository call made with the context passed to fn runs in the same
+// transaction, so the operations commit or roll back together.
+type TxManager interface {
+    WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txManager implements TxManager using PostgreSQL transactions
+type txManager struct {
+    db *sql.DB
+}
+
+// NewTxManager creates a new instance of txManager
+func NewTxManager(db *sql.DB) (TxManager, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+    return &txManager{db: db}, nil
+}
+
+// WithTx runs fn in a serializable transaction, committing it if fn returns
+// nil and rolling it back otherwise. Calls nested in an existing unit of work
+// join it rather than starting their own.
+func (m *txManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+    if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+        return fn(ctx)
+    }
+
+    tx, err := m.db.BeginTx(ctx, &sql.TxOptions{
+        Isolation: sql.LevelSerializable,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to start transaction: %w", err)
+    }
+    defer func() {
+        if p := recover(); p != nil {
+            tx.Rollback()
+            panic(p)
+        }
+        if err != nil {
+            tx.Rollback()
+        }
+    }()
+
+    if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+        return err
+    }
+
+    if err = tx.Commit(); err != nil {
+        if isSerializationFailure(err) {
+            return ErrVersionConflict
+        }
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+    return nil
+}
+
+// conn returns the unit of work's transaction when ctx carries one, and
+// db otherwise
+func conn(ctx context.Context, db *sql.DB) querier {
+    if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+        return tx
+    }
+    return db
+}
+
+// scopedTx is a transaction for a single repository operation. When the
+// operation joins a unit of work, Commit and Rollback are left to WithTx.
+type scopedTx struct {
+    *sql.Tx
+    owned bool
+}
+
+// beginTx starts a serializable transaction for one operation, or joins the
+// unit of work carried by ctx
+func beginTx(ctx context.Context, db *sql.DB) (*scopedTx, error) {
+    if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+        return &scopedTx{Tx: tx}, nil
+    }
+
+    tx, err := db.BeginTx(ctx, &sql.TxOptions{
+        Isolation: sql.LevelSerializable,
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &scopedTx{Tx: tx, owned: true}, nil
+}
+
+// Commit commits an owned transaction
+func (t *scopedTx) Commit() error {
+    if !t.owned {
+        return nil
+    }
+    return t.Tx.Commit()
+}
+
+// Rollback rolls back an owned transaction
+func (t *scopedTx) Rollback() error {
+    if !t.owned {
+        return nil
+    }
+    return t.Tx.Rollback()
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrSessionNotFound is returned when an upload session does not exist
+var ErrSessionNotFound = errors.New("upload session not found")
+
+// UploadSessionRepository defines persistence operations for chunked upload sessions
+type UploadSessionRepository interface {
+    Create(ctx context.Context, session *models.UploadSession) error
+    GetByID(ctx context.Context, id string) (*models.UploadSession, error)
+    SavePart(ctx context.Context, sessionID string, part *models.UploadPart) error
+    UpdateStatus(ctx context.Context, id string, status string) error
+    ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.UploadSession, error)
+}
+
+// uploadSessionRepository implements UploadSessionRepository using PostgreSQL
+type uploadSessionRepository struct {
+    db  *sql.DB
+    log *logger.Logger
+}
+
+// NewUploadSessionRepository creates a new instance of uploadSessionRepository
+func NewUploadSessionRepository(db *sql.DB) (UploadSessionRepository, error) {
+    if db == nil {
+        return nil, errors.New("database connection is required")
+    }
+
+    return &uploadSessionRepository{
+        db:  db,
+        log: logger.GetLogger(),
+    }, nil
+}
+
+// Create inserts a new upload session record
+func (r *uploadSessionRepository) Create(ctx context.Context, session *models.UploadSession) error {
+    if session == nil {
+        return errors.New("session cannot be nil")
+    }
+
+    const query = `
+        INSERT INTO upload_sessions (
+            id, file_id, file_name, content_type, total_size, chunk_size,
+            chunk_count, storage_key, multipart_upload_id, status, owner_id,
+            created_at, updated_at, expires_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+    `
+
+    _, err := conn(ctx, r.db).ExecContext(ctx, query,
+        session.ID, session.FileID, session.FileName, session.ContentType,
+        session.TotalSize, session.ChunkSize, session.ChunkCount,
+        session.StorageKey, session.MultipartUploadID, session.Status, session.OwnerID,
+        session.CreatedAt, session.UpdatedAt, session.ExpiresAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to insert upload session: %w", err)
+    }
+
+    r.log.Info("Created upload session",
+        logger.String("sessionId", session.ID),
+        logger.String("fileId", session.FileID))
+
+    return nil
+}
+
+// GetByID retrieves an upload session together with its received parts
+func (r *uploadSessionRepository) GetByID(ctx context.Context, id string) (*models.UploadSession, error) {
+    if id == "" {
+        return nil, ErrInvalidID
+    }
+
+    const query = `
+        SELECT id, file_id, file_name, content_type, total_size, chunk_size,
+               chunk_count, storage_key, multipart_upload_id, status, owner_id,
+               created_at, updated_at, expires_at
+        FROM upload_sessions
+        WHERE id = $1
+    `
+
+    session := &models.UploadSession{}
+    err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+        &session.ID, &session.FileID, &session.FileName, &session.ContentType,
+        &session.TotalSize, &session.ChunkSize, &session.ChunkCount,
+        &session.StorageKey, &session.MultipartUploadID, &session.Status, &session.OwnerID,
+        &session.CreatedAt, &session.UpdatedAt, &session.ExpiresAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrSessionNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get upload session: %w", err)
+    }
+
+    const partsQuery = `
+        SELECT part_number, size, checksum, etag, uploaded_at
+        FROM upload_session_parts
+        WHERE session_id = $1
+        ORDER BY part_number
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, partsQuery, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get upload session parts: %w", err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var part models.UploadPart
+        if err := rows.Scan(&part.Number, &part.Size, &part.Checksum, &part.ETag, &part.UploadedAt); err != nil {
+            return nil, fmt.Errorf("failed to scan upload session part: %w", err)
+        }
+        session.Parts = append(session.Parts, part)
+    }
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return session, nil
+}
+
+// SavePart upserts a received part so that re-sent chunks replace earlier attempts
+func (r *uploadSessionRepository) SavePart(ctx context.Context, sessionID string, part *models.UploadPart) error {
+    if sessionID == "" {
+        return ErrInvalidID
+    }
+
+    tx, err := beginTx(ctx, r.db)
+    if err != nil {
+        return fmt.Errorf("failed to start transaction: %w", err)
+    }
+    defer tx.Rollback()
+
+    const query = `
+        INSERT INTO upload_session_parts (session_id, part_number, size, checksum, etag, uploaded_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (session_id, part_number)
+        DO UPDATE SET size = EXCLUDED.size, checksum = EXCLUDED.checksum,
+                      etag = EXCLUDED.etag, uploaded_at = EXCLUDED.uploaded_at
+    `
+
+    if _, err = tx.ExecContext(ctx, query,
+        sessionID, part.Number, part.Size, part.Checksum, part.ETag, part.UploadedAt,
+    ); err != nil {
+        return fmt.Errorf("failed to save upload session part: %w", err)
+    }
+
+    if _, err = tx.ExecContext(ctx,
+        "UPDATE upload_sessions SET updated_at = $1 WHERE id = $2",
+        time.Now().UTC(), sessionID,
+    ); err != nil {
+        return fmt.Errorf("failed to touch upload session: %w", err)
+    }
+
+    if err = tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+
+    return nil
+}
+
+// UpdateStatus changes the status of an upload session
+func (r *uploadSessionRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+    if id == "" {
+        return ErrInvalidID
+    }
+
+    result, err := conn(ctx, r.db).ExecContext(ctx,
+        "UPDATE upload_sessions SET status = $1, updated_at = $2 WHERE id = $3",
+        status, time.Now().UTC(), id,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to update upload session: %w", err)
+    }
+
+    rows, err := result.RowsAffected()
+    if err != nil {
+        return fmt.Errorf("failed to get affected rows: %w", err)
+    }
+    if rows == 0 {
+        return ErrSessionNotFound
+    }
+
+    r.log.Info("Updated upload session status",
+        logger.String("sessionId", id),
+        logger.String("status", status))
+
+    return nil
+}
+
+// ListExpired returns up to limit open sessions that expired before the
+// given time, oldest first, without their parts
+func (r *uploadSessionRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.UploadSession, error) {
+    if limit <= 0 {
+        return nil, errors.New("invalid limit")
+    }
+
+    const query = `
+        SELECT id, file_id, file_name, content_type, total_size, chunk_size,
+               chunk_count, storage_key, multipart_upload_id, status, owner_id,
+               created_at, updated_at, expires_at
+        FROM upload_sessions
+        WHERE status = $1 AND expires_at < $2
+        ORDER BY expires_at
+        LIMIT $3
+    `
+
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, models.UploadSessionStatusOpen, before, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list expired upload sessions: %w", err)
+    }
+    defer rows.Close()
+
+    var sessions []*models.UploadSession
+    for rows.Next() {
+        session := &models.UploadSession{}
+        if err := rows.Scan(
+            &session.ID, &session.FileID, &session.FileName, &session.ContentType,
+            &session.TotalSize, &session.ChunkSize, &session.ChunkCount,
+            &session.StorageKey, &session.MultipartUploadID, &session.Status, &session.OwnerID,
+            &session.CreatedAt, &session.UpdatedAt, &session.ExpiresAt,
+        ); err != nil {
+            return nil, fmt.Errorf("failed to scan upload session: %w", err)
+        }
+        sessions = append(sessions, session)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, fmt.Errorf("error iterating rows: %w", err)
+    }
+
+    return sessions, nil
+}
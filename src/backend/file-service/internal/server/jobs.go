@@ -0,0 +1,492 @@
+package server
+
+import (
+    "context"
+    "time"
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/errtrack"
+    "src/backend/file-service/pkg/joblock"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// runDraftPurge periodically removes expired drafts until ctx is cancelled
+func runDraftPurge(ctx context.Context, locker *joblock.Locker, fileService service.FileService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "draft-purge", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "draft-purge")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := fileService.PurgeExpiredDrafts(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Draft purge failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "draft-purge"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runAsyncUploadSweep periodically fails asynchronous uploads still pending
+// after their timeout until ctx is cancelled
+func runAsyncUploadSweep(ctx context.Context, locker *joblock.Locker, fileService service.FileService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "async-upload-sweep", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "async-upload-sweep")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := fileService.FailStaleUploads(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Asynchronous upload sweep failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "async-upload-sweep"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runUploadSweep periodically expires abandoned upload sessions and aborts
+// their multipart uploads until ctx is cancelled
+func runUploadSweep(ctx context.Context, locker *joblock.Locker, sessions service.UploadSessionService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "upload-sweep", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "upload-sweep")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := sessions.SweepAbandoned(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Upload sweep failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "upload-sweep"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runBlobCollector periodically deletes unreferenced blobs until ctx is cancelled
+func runBlobCollector(ctx context.Context, locker *joblock.Locker, fileService service.FileService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "blob-gc", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "blob-gc")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := fileService.CollectBlobs(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Blob collection failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "blob-gc"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runReplication periodically copies uploaded files to the secondary bucket
+// until ctx is cancelled
+func runReplication(ctx context.Context, locker *joblock.Locker, replicationService service.ReplicationService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "replication", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "replication")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := replicationService.ReplicatePending(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Replication failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "replication"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runArchive periodically records completed and expired restores, then
+// archives idle files, until ctx is cancelled
+func runArchive(ctx context.Context, locker *joblock.Locker, archiveService service.ArchiveService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "archive", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "archive")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := archiveService.PollRestores(jobCtx)
+                if err == nil {
+                    _, err = archiveService.ArchiveIdle(jobCtx)
+                }
+                done(err)
+                if err != nil {
+                    log.Error("Archiving failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "archive"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runTiering periodically moves cold files to the cold storage class until
+// ctx is cancelled
+func runTiering(ctx context.Context, locker *joblock.Locker, tieringService service.TieringService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "tiering", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "tiering")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := tieringService.DemoteCold(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Tiering failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "tiering"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runReconcile periodically reconciles the bucket with the files table
+// until ctx is cancelled
+func runReconcile(ctx context.Context, locker *joblock.Locker, reconciler service.Reconciler, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "reconcile", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "reconcile")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := reconciler.Reconcile(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Storage reconciliation failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "reconcile"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runScrub periodically verifies a sample of stored content until ctx is
+// cancelled
+func runScrub(ctx context.Context, locker *joblock.Locker, scrubber service.Scrubber, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "integrity-scrub", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "integrity-scrub")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := scrubber.Scrub(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Integrity scrub failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "integrity-scrub"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runDigests periodically emails upload digests to due users until ctx is cancelled
+func runDigests(ctx context.Context, locker *joblock.Locker, notificationService service.NotificationService, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "notification-digest", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "notification-digest")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := notificationService.SendDigests(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Digest delivery failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "notification-digest"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runNoncePurge periodically deletes the nonces of expired single-use
+// tokens until ctx is cancelled
+func runNoncePurge(ctx context.Context, locker *joblock.Locker, replay *service.ReplayGuard, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "nonce-purge", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "nonce-purge")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := replay.Purge(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Nonce purge failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "nonce-purge"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runApprovalExpiry periodically expires actions that were not approved in
+// time until ctx is cancelled
+func runApprovalExpiry(ctx context.Context, locker *joblock.Locker, approvals service.DeleteApprovals, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "approval-expiry", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "approval-expiry")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := approvals.Expire(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Approval expiry failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "approval-expiry"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runAccessReview periodically asks owners to review, or revokes, shares and
+// file requests that went unused until ctx is cancelled
+func runAccessReview(ctx context.Context, locker *joblock.Locker, accessReview service.AccessReview, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "access-review", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "access-review")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                _, err := accessReview.Review(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Access review failed",
+                        append(job.Fields(), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "access-review"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runAuditExport periodically exports recorded audit events to the signed
+// audit log until ctx is cancelled
+func runAuditExport(ctx context.Context, locker *joblock.Locker, exporter service.AuditExporter, interval time.Duration) {
+    log := logger.GetLogger()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            err := locker.Run(ctx, "audit-export", func(ctx context.Context) {
+                jobCtx, job := tracing.StartJob(ctx, "audit-export")
+                done := telemetry.TrackJob(jobCtx, job.Name)
+                exported, err := exporter.Export(jobCtx)
+                done(err)
+                if err != nil {
+                    log.Error("Audit export failed",
+                        append(job.Fields(), logger.Int("exported", exported), logger.Error(err))...)
+                    errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+                }
+            })
+            if err != nil {
+                log.Error("Failed to take job lock",
+                    logger.String("job", "audit-export"),
+                    logger.Error(err))
+            }
+        }
+    }
+}
+
+// runStorageStats refreshes the storage gauges on start and then
+// periodically until ctx is cancelled. Every instance refreshes its own, so
+// none exports stale totals.
+func runStorageStats(ctx context.Context, stats service.StorageStats, interval time.Duration) {
+    log := logger.GetLogger()
+    refresh := func() {
+        jobCtx, job := tracing.StartJob(ctx, "storage-stats")
+        done := telemetry.TrackJob(jobCtx, job.Name)
+        _, err := stats.Refresh(jobCtx)
+        done(err)
+        if err != nil && ctx.Err() == nil {
+            log.Error("Storage statistics refresh failed",
+                append(job.Fields(), logger.Error(err))...)
+            errtrack.CaptureError(jobCtx, err, map[string]string{"job": job.Name})
+        }
+    }
+
+    refresh()
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            refresh()
+        }
+    }
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "time"
+
+    "github.com/spiffe/go-spiffe/v2/workloadapi" // v2.1.6
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/apiversion"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/errtrack"
+    "src/backend/file-service/pkg/lifecycle"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/profiling"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/throttle"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// newSPIFFESource connects to the SPIFFE Workload API and waits for the
+// first trust bundle update
+func newSPIFFESource(cfg *config.Config) (*workloadapi.X509Source, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), cfg.SPIFFE.StartTimeout)
+    defer cancel()
+
+    var opts []workloadapi.X509SourceOption
+    if cfg.SPIFFE.WorkloadSocket != "" {
+        opts = append(opts, workloadapi.WithClientOptions(workloadapi.WithAddr(cfg.SPIFFE.WorkloadSocket)))
+    }
+    return workloadapi.NewX509Source(ctx, opts...)
+}
+
+// setupSecureServer configures the HTTP server with security features
+func setupSecureServer(cfg *config.Config, handler *handlers.FileHandler, sessionHandler *handlers.UploadSessionHandler,
+    directUploadHandler *handlers.DirectUploadHandler, policyHandler *handlers.PolicyHandler, attachmentHandler *handlers.AttachmentHandler,
+    previewHandler *handlers.PreviewHandler, derivedHandler *handlers.DerivedHandler, extractionHandler *handlers.ExtractionHandler,
+    lockHandler *handlers.LockHandler,
+    notificationHandler *handlers.NotificationHandler, fileRequestHandler *handlers.FileRequestHandler,
+    quotaTracker *service.QuotaTracker, sloMetrics *telemetry.SLOMetrics, instruments metrics.Provider, csrf *middleware.CSRF,
+    cachePolicy *handlers.CachePolicy, drainer *lifecycle.Drainer, routes []func(mux *http.ServeMux)) *http.Server {
+    mux := http.NewServeMux()
+
+    // Add security middleware
+    secureMiddleware := func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            // Security headers
+            w.Header().Set("X-Content-Type-Options", "nosniff")
+            w.Header().Set("X-Frame-Options", "DENY")
+            w.Header().Set("X-XSS-Protection", "1; mode=block")
+            w.Header().Set("Content-Security-Policy", "default-src 'self'")
+            w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+
+            // Request tracking
+            activeRequests.Inc()
+            defer activeRequests.Dec()
+
+            start := time.Now()
+            next.ServeHTTP(w, r)
+
+            // Record request duration with the trace ID as exemplar
+            duration := time.Since(start).Seconds()
+            tracing.Observe(r.Context(), requestDuration.WithLabelValues(
+                r.URL.Path,
+                r.Method,
+                fmt.Sprint(http.StatusOK),
+            ), duration)
+        })
+    }
+
+    // Authenticated API routes, protected against cross-site request forgery
+    // and annotated with the caller's rate limit and storage quota. Slow
+    // requests and large transfers are logged with the caller.
+    var limiter *throttle.Limiter
+    if cfg.RateLimit.Enabled {
+        limiter = throttle.New(cfg.RateLimit.Requests, cfg.RateLimit.Window)
+    }
+    rateLimit := handlers.RateLimit(limiter)
+    quotaHeaders := handlers.QuotaHeaders(quotaTracker)
+    slowRequests := handlers.SlowRequests(instruments, cfg.Metrics.SlowRequestThreshold, cfg.Metrics.LargeTransferThreshold)
+    authenticated := func(next http.Handler) http.Handler {
+        return secureMiddleware(csrf.Protect(middleware.Authenticate(slowRequests(rateLimit(quotaHeaders(next))))))
+    }
+
+    // Register handlers with security middleware
+    mux.Handle("/upload", sloMetrics.Middleware("upload", authenticated(http.HandlerFunc(handler.UploadHandler))))
+    mux.Handle("/download", sloMetrics.Middleware("download", authenticated(http.HandlerFunc(handler.DownloadHandler))))
+    mux.Handle("/download-url", authenticated(http.HandlerFunc(handler.DownloadURLHandler)))
+    mux.Handle("/delete", authenticated(http.HandlerFunc(handler.DeleteHandler)))
+    mux.Handle("/commit", authenticated(http.HandlerFunc(handler.CommitHandler)))
+    mux.Handle("/rename", authenticated(http.HandlerFunc(handler.RenameHandler)))
+    mux.Handle("/move", authenticated(http.HandlerFunc(handler.MoveHandler)))
+    mux.Handle("/tags", authenticated(http.HandlerFunc(handler.TagsHandler)))
+    mux.Handle("/restore", authenticated(http.HandlerFunc(handler.RestoreHandler)))
+
+    // Chunked upload protocol
+    mux.Handle("/uploads", sloMetrics.Middleware("upload", authenticated(sessionHandler)))
+    mux.Handle("/uploads/", sloMetrics.Middleware("upload", authenticated(sessionHandler)))
+
+    // Upload rules for the calling user
+    mux.Handle("/policies/upload", authenticated(http.HandlerFunc(policyHandler.UploadPolicyHandler)))
+    mux.Handle("/upload/policy", authenticated(http.HandlerFunc(policyHandler.UploadHintsHandler)))
+
+    // Notification settings of the calling user
+    mux.Handle("/notifications/preferences", authenticated(http.HandlerFunc(notificationHandler.PreferencesHandler)))
+
+    // Upload inboxes; the upload endpoint is authorized by the link itself
+    // and, being anonymous, limited per client address
+    fileRequests := authenticated(fileRequestHandler)
+    uploadLimit := handlers.RateLimit(throttle.New(cfg.Request.UploadRequests, cfg.Request.UploadWindow))
+    fileRequestUploads := secureMiddleware(slowRequests(uploadLimit(http.HandlerFunc(fileRequestHandler.PublicUploadHandler))))
+    mux.Handle("/file-requests", fileRequests)
+    mux.Handle("/file-requests/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if handlers.IsFileRequestUploadPath(r.URL.Path) {
+            fileRequestUploads.ServeHTTP(w, r)
+            return
+        }
+        fileRequests.ServeHTTP(w, r)
+    }))
+
+    // Files attached to records of other services
+    mux.Handle("/entities/", authenticated(attachmentHandler))
+
+    // File status, previews, checkout locks and direct uploads; capability
+    // URLs are authorized by their token so they can be embedded in <img>
+    // and <iframe> elements
+    previewContent := secureMiddleware(slowRequests(http.HandlerFunc(previewHandler.PreviewContentHandler)))
+    filePreview := authenticated(http.HandlerFunc(previewHandler.FilePreviewHandler))
+    fileLocks := authenticated(lockHandler)
+    derivedObjects := authenticated(derivedHandler)
+    extractions := authenticated(extractionHandler)
+    directUploads := authenticated(directUploadHandler)
+    fileStatus := authenticated(http.HandlerFunc(handler.FileStatusHandler))
+    mux.Handle("/files/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch {
+        case handlers.IsContentPath(r.URL.Path):
+            previewContent.ServeHTTP(w, r)
+        case handlers.IsLockPath(r.URL.Path):
+            fileLocks.ServeHTTP(w, r)
+        case handlers.IsDerivedPath(r.URL.Path):
+            derivedObjects.ServeHTTP(w, r)
+        case handlers.IsExtractionPath(r.URL.Path):
+            extractions.ServeHTTP(w, r)
+        case handlers.IsDirectUploadPath(r.URL.Path):
+            directUploads.ServeHTTP(w, r)
+        case handlers.IsFilePath(r.URL.Path):
+            fileStatus.ServeHTTP(w, r)
+        default:
+            filePreview.ServeHTTP(w, r)
+        }
+    }))
+
+    // Routes added by the embedder
+    for _, register := range routes {
+        register(mux)
+    }
+
+    // Routes are served under /api/v1; the original unversioned upload,
+    // download and delete routes remain until their sunset
+    legacy := apiversion.Legacy(apiversion.Policy{
+        DeprecatedAt: cfg.API.LegacyDeprecatedAt,
+        Sunset:       cfg.API.LegacySunset,
+    }, "/upload", "/download", "/delete")
+
+    // Cache-Control and Expires follow the cache policy unless a route sets its own
+    api := handlers.CacheHeaders(cachePolicy)(mux)
+
+    // Error responses tell clients and the mesh whether to retry, including
+    // the 500 written for recovered panics
+    retryHints := handlers.RetryHints(cfg.Server.RetryAfter, drainer.Draining)
+
+    return &http.Server{
+        Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+        Handler:           tracing.Middleware(requestctx.Middleware(retryHints(errtrack.Middleware(apiversion.Mount(api, legacy))))),
+        ReadTimeout:       cfg.Server.ReadTimeout,
+        WriteTimeout:      cfg.Server.WriteTimeout,
+        IdleTimeout:       cfg.Server.IdleTimeout,
+        ReadHeaderTimeout: readHeaderTimeout,
+        MaxHeaderBytes:    maxHeaderBytes,
+    }
+}
+
+// setupInternalServer configures the operations server for metrics, health,
+// build info, pprof and admin routes. It is kept off the public port so
+// operational data is only reachable from inside the network.
+func setupInternalServer(cfg *config.Config, adminHandler *handlers.AdminHandler,
+    tenantSettingsHandler *handlers.TenantSettingsHandler, approvalHandler *handlers.ApprovalHandler,
+    notificationHandler *handlers.NotificationHandler, metricsHandler http.Handler,
+    drainer *lifecycle.Drainer, routes []func(mux *http.ServeMux)) *http.Server {
+    mux := http.NewServeMux()
+
+    // Administrative endpoints
+    adminOnly := middleware.Authorize(middleware.AdminRole)
+    mux.Handle("/admin/storage/costs", middleware.Authenticate(adminOnly(http.HandlerFunc(adminHandler.StorageCostsHandler))))
+    mux.Handle("/admin/storage/health", middleware.Authenticate(adminOnly(http.HandlerFunc(adminHandler.StorageHealthHandler))))
+    mux.Handle("/admin/access-review", middleware.Authenticate(adminOnly(http.HandlerFunc(adminHandler.AccessReviewHandler))))
+    mux.Handle("/admin/support-bundle", middleware.Authenticate(adminOnly(http.HandlerFunc(adminHandler.SupportBundleHandler))))
+    mux.Handle("/admin/tenants", middleware.Authenticate(adminOnly(http.HandlerFunc(tenantSettingsHandler.ListHandler))))
+    mux.Handle("/admin/tenants/", middleware.Authenticate(adminOnly(http.HandlerFunc(tenantSettingsHandler.TenantHandler))))
+    mux.Handle("/admin/approvals", middleware.Authenticate(adminOnly(http.HandlerFunc(approvalHandler.ListHandler))))
+    mux.Handle("/admin/approvals/", middleware.Authenticate(adminOnly(http.HandlerFunc(approvalHandler.ActionHandler))))
+
+    // Share events from the services that share files; under policy
+    // authorization the policy decides who may share which files
+    shareHandler := http.Handler(http.HandlerFunc(notificationHandler.ShareEventHandler))
+    if cfg.Authz.Mode != authz.ModeOPA {
+        shareHandler = adminOnly(shareHandler)
+    }
+    mux.Handle("/notifications/share", middleware.Authenticate(shareHandler))
+
+    // Build version endpoint
+    mux.HandleFunc("/version", handlers.VersionHandler)
+
+    // Health check endpoint
+    mux.HandleFunc(healthCheckPath, func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte("OK"))
+    })
+
+    // Kubernetes probes and the preStop drain hook
+    mux.Handle("/healthz", drainer.LivenessHandler())
+    mux.Handle("/readyz", drainer.ReadinessHandler())
+    mux.Handle("/prestop", drainer.PreStopHandler())
+
+    // Metrics scrape endpoint, absent when metrics are pushed over OTLP
+    if metricsHandler != nil && cfg.Metrics.Enabled {
+        mux.Handle(cfg.Metrics.Path, metricsHandler)
+    }
+
+    // Runtime profiles
+    if cfg.Server.PprofEnabled {
+        mux.Handle("/debug/pprof/", profiling.PprofHandler())
+    }
+
+    // Routes added by the embedder
+    for _, register := range routes {
+        register(mux)
+    }
+
+    return &http.Server{
+        Addr:              fmt.Sprintf("%s:%d", cfg.Server.InternalHost, cfg.Server.InternalPort),
+        Handler:           tracing.Middleware(requestctx.Middleware(errtrack.Middleware(mux))),
+        ReadTimeout:       cfg.Server.ReadTimeout,
+        WriteTimeout:      cfg.Server.WriteTimeout,
+        IdleTimeout:       cfg.Server.IdleTimeout,
+        ReadHeaderTimeout: readHeaderTimeout,
+        MaxHeaderBytes:    maxHeaderBytes,
+    }
+}
@@ -0,0 +1,1129 @@
+// Package server builds the file service — its storage, services, routes,
+// middleware and scheduled jobs — and runs it until its context ends. The
+// file-service binary runs it from cmd; other binaries, such as tests, the
+// admin tool or a combined monolith, embed it through Run and its options.
+package server
+
+import (
+    "context"
+    "crypto/tls"
+    "database/sql"
+    "errors"
+    "fmt"
+    "net"
+    "net/http"
+    "os"
+    "time"
+
+    _ "github.com/lib/pq"                            // v1.10.9
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+    "golang.org/x/crypto/acme/autocert"              // latest
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/apiversion"
+    "src/backend/file-service/pkg/auditlog"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/bandwidth"
+    "src/backend/file-service/pkg/buildinfo"
+    "src/backend/file-service/pkg/convert"
+    "src/backend/file-service/pkg/discovery"
+    "src/backend/file-service/pkg/errtrack"
+    "src/backend/file-service/pkg/joblock"
+    "src/backend/file-service/pkg/lifecycle"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/notify"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/sanitizer"
+    "src/backend/file-service/pkg/storagekey"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/throughput"
+    "src/backend/file-service/pkg/validator"
+    "src/backend/file-service/pkg/webhook"
+)
+
+const (
+    healthCheckPath   = "/health"
+    maxHeaderBytes    = 1 << 20 // 1MB
+    readHeaderTimeout = 5 * time.Second
+)
+
+// Prometheus metrics
+var (
+    requestDuration = prometheus.NewHistogramVec(
+        prometheus.HistogramOpts{
+            Name:                            "http_request_duration_seconds",
+            Help:                            "Duration of HTTP requests in seconds",
+            Buckets:                         prometheus.DefBuckets,
+            NativeHistogramBucketFactor:     1.1,
+            NativeHistogramMaxBucketNumber:  160,
+            NativeHistogramMinResetDuration: time.Hour,
+        },
+        []string{"handler", "method", "status"},
+    )
+
+    activeRequests = prometheus.NewGauge(
+        prometheus.GaugeOpts{
+            Name: "http_requests_active",
+            Help: "Number of active HTTP requests",
+        },
+    )
+
+    statusTransitions = prometheus.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "file_status_transitions_total",
+            Help: "Number of file status transitions",
+        },
+        []string{"from", "to"},
+    )
+)
+
+// Option customizes the file service built by Run
+type Option func(*options)
+
+// options are the customizations applied by Run
+type options struct {
+    db               *sql.DB
+    listener         net.Listener
+    internalListener net.Listener
+    routes           []func(mux *http.ServeMux)
+    internalRoutes   []func(mux *http.ServeMux)
+    withoutJobs      bool
+    recorder         *logger.Recorder
+}
+
+// WithDB uses db for metadata instead of opening cfg.Database.DSN. The
+// caller keeps ownership of db, which Run does not close.
+func WithDB(db *sql.DB) Option {
+    return func(o *options) {
+        o.db = db
+    }
+}
+
+// WithListeners serves the public and internal servers on the given
+// listeners instead of the configured addresses, such as ports chosen by
+// the system in tests. Either may be nil to listen as configured.
+func WithListeners(public, internal net.Listener) Option {
+    return func(o *options) {
+        o.listener = public
+        o.internalListener = internal
+    }
+}
+
+// WithRoutes lets register add routes to the public API. They are served
+// under /api/v1 behind the outer middleware, such as tracing and retry
+// hints, but are not authenticated unless register wraps them with
+// middleware.Authenticate.
+func WithRoutes(register func(mux *http.ServeMux)) Option {
+    return func(o *options) {
+        o.routes = append(o.routes, register)
+    }
+}
+
+// WithInternalRoutes lets register add routes to the internal operations
+// server
+func WithInternalRoutes(register func(mux *http.ServeMux)) Option {
+    return func(o *options) {
+        o.internalRoutes = append(o.internalRoutes, register)
+    }
+}
+
+// WithoutJobs skips the scheduled jobs, such as replication and the blob
+// collector, for embedders that only serve requests. Per-instance work,
+// such as storage health probes, still runs.
+func WithoutJobs() Option {
+    return func(o *options) {
+        o.withoutJobs = true
+    }
+}
+
+// WithLogRecorder includes the warnings and errors kept by recorder, which
+// should record the global logger, in support bundles
+func WithLogRecorder(recorder *logger.Recorder) Option {
+    return func(o *options) {
+        o.recorder = recorder
+    }
+}
+
+// Run builds the file service from cfg and serves it until ctx is done,
+// then deregisters, drains and shuts it down gracefully. It returns an
+// error if the service could not be built or a server failed. The lifecycle
+// hooks and key layout it installs process-wide are removed when it
+// returns, so Run may be called again, but not concurrently.
+func Run(ctx context.Context, cfg *config.Config, opts ...Option) error {
+    var o options
+    for _, opt := range opts {
+        opt(&o)
+    }
+    log := logger.GetLogger()
+
+    // Initialize metrics registry
+    registry := prometheus.NewRegistry()
+    registry.MustRegister(
+        requestDuration,
+        activeRequests,
+        statusTransitions,
+        prometheus.NewGoCollector(),
+        prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+        buildinfo.NewCollector(),
+    )
+    registry.MustRegister(joblock.Collectors()...)
+    registry.MustRegister(authz.Collectors()...)
+    registry.MustRegister(apiversion.Collectors()...)
+
+    // Components create their instruments from the provider, which registers
+    // them with the registry
+    instruments := metrics.NewPrometheus(registry)
+    if cfg.Metrics.TenantLabels {
+        instruments = metrics.WithTenants(instruments,
+            metrics.NewTenantLimiter(cfg.Metrics.TenantLabelLimit, cfg.Metrics.TenantLabelWindow))
+    }
+    telemetry.InstrumentJobs(instruments)
+
+    // Count uploads and downloads against their availability SLOs
+    sloMetrics, err := telemetry.NewSLOMetrics(
+        telemetry.SLO{Operation: "upload", LatencyTarget: cfg.Metrics.SLOUploadLatency, Objective: cfg.Metrics.SLOObjective},
+        telemetry.SLO{Operation: "download", LatencyTarget: cfg.Metrics.SLODownloadLatency, Objective: cfg.Metrics.SLOObjective},
+    )
+    if err != nil {
+        return fmt.Errorf("failed to initialize SLO metrics: %w", err)
+    }
+    if err := sloMetrics.Register(registry); err != nil {
+        return fmt.Errorf("failed to register SLO metrics: %w", err)
+    }
+
+    // Count and log file lifecycle transitions while the service runs; the
+    // file repository records them in the audit log as they are saved
+    unregisterTransitions := models.FileLifecycle.OnTransition(func(change models.StatusChange) {
+        statusTransitions.WithLabelValues(change.From, change.To).Inc()
+        log.Info("File status transition",
+            logger.String("fileId", change.FileID),
+            logger.String("from", change.From),
+            logger.String("to", change.To))
+    })
+    defer unregisterTransitions()
+
+    // Initialize metadata database, unless the embedder shares its own
+    db := o.db
+    if db == nil {
+        db, err = sql.Open("postgres", cfg.Database.DSN)
+        if err != nil {
+            return fmt.Errorf("failed to open database: %w", err)
+        }
+        defer db.Close()
+        db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+        db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+        db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+    }
+
+    // Initialize repositories
+    fileRepo, err := repository.NewFileRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize file repository: %w", err)
+    }
+    sessionRepo, err := repository.NewUploadSessionRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize upload session repository: %w", err)
+    }
+    lockRepo, err := repository.NewLockRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize lock repository: %w", err)
+    }
+    notificationRepo, err := repository.NewNotificationRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize notification repository: %w", err)
+    }
+    tenantSettingsRepo, err := repository.NewTenantSettingsRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize tenant settings repository: %w", err)
+    }
+    shareRepo, err := repository.NewShareRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize share repository: %w", err)
+    }
+    fileRequestRepo, err := repository.NewFileRequestRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize file request repository: %w", err)
+    }
+    derivedRepo, err := repository.NewDerivedObjectRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize derived object repository: %w", err)
+    }
+    attachmentRepo, err := repository.NewAttachmentRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize attachment repository: %w", err)
+    }
+    auditRepo, err := repository.NewAuditRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize audit repository: %w", err)
+    }
+    pendingActionRepo, err := repository.NewPendingActionRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize pending action repository: %w", err)
+    }
+    extractionJobRepo, err := repository.NewExtractionJobRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize extraction job repository: %w", err)
+    }
+    nonceRepo, err := repository.NewNonceRepository(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize nonce repository: %w", err)
+    }
+
+    // Soft-deleted content is moved under the configured prefix, which the
+    // key layout must know to shard its keys like the content's. The layout
+    // is restored once the service stops, for the next Run in the process.
+    keys, err := storagekey.Default.WithPrefix(cfg.S3.SoftDeletePrefix)
+    if err != nil {
+        return fmt.Errorf("invalid soft delete prefix: %w", err)
+    }
+    defer func(previous storagekey.Policy) {
+        storagekey.Default = previous
+    }(storagekey.Default)
+    storagekey.Default = keys
+
+    // Initialize storage
+    s3Storage, err := storage.NewS3Storage(cfg, instruments)
+    if err != nil {
+        return fmt.Errorf("failed to initialize storage: %w", err)
+    }
+
+    // Route tenants' downloads through their S3 Object Lambda access points
+    objectLambda, err := storage.NewObjectLambdaRoutes(cfg.S3.ObjectLambdaAccessPoint, cfg.S3.ObjectLambdaTenants)
+    if err != nil {
+        return fmt.Errorf("failed to configure object lambda access points: %w", err)
+    }
+
+    // Copy uploaded files to a secondary bucket for disaster recovery
+    var replicationService service.ReplicationService
+    var replicaStorage storage.ReplicaStorage
+    if cfg.Replication.Enabled {
+        replica, err := storage.NewS3Replica(cfg, s3Storage)
+        if err != nil {
+            return fmt.Errorf("failed to initialize replica storage: %w", err)
+        }
+        replicaStorage = replica
+        replicationService, err = service.NewReplicationService(fileRepo, replica, service.ReplicationOptions{
+            BatchSize:   cfg.Replication.BatchSize,
+            MaxAttempts: cfg.Replication.MaxAttempts,
+            Metrics:     instruments,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize replication: %w", err)
+        }
+    }
+
+    // Move idle files to archival storage classes, restoring them on request
+    var archiveService service.ArchiveService
+    if cfg.Archive.Enabled {
+        archiveService, err = service.NewArchiveService(fileRepo, s3Storage, service.ArchiveOptions{
+            GlacierAfter:     cfg.Archive.GlacierAfter,
+            DeepArchiveAfter: cfg.Archive.DeepArchiveAfter,
+            BatchSize:        cfg.Archive.BatchSize,
+            RestoreDays:      cfg.Archive.RestoreDays,
+            RestoreTier:      cfg.Archive.RestoreTier,
+            Metrics:          instruments,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize archiving: %w", err)
+        }
+    }
+
+    // Move cold files to an infrequent access class, promoting them on access
+    var tieringService service.TieringService
+    if cfg.Tiering.Enabled {
+        tieringService, err = service.NewTieringService(fileRepo, s3Storage, service.TieringOptions{
+            ColdAfter:        cfg.Tiering.ColdAfter,
+            ColdStorageClass: cfg.Tiering.ColdStorageClass,
+            PromoteOnAccess:  cfg.Tiering.PromoteOnAccess,
+            BatchSize:        cfg.Tiering.BatchSize,
+            Metrics:          instruments,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize tiering: %w", err)
+        }
+    }
+
+    // Compare the bucket with the files table, leaving audit exports alone
+    var reconciler service.Reconciler
+    if cfg.Reconcile.Enabled {
+        reconciler, err = service.NewReconciler(fileRepo, s3Storage, service.ReconcileOptions{
+            Mode:       cfg.Reconcile.Mode,
+            MinAge:     cfg.Reconcile.MinAge,
+            Exclude:    []string{cfg.Audit.Prefix},
+            BatchSize:  cfg.Reconcile.BatchSize,
+            MaxActions: cfg.Reconcile.MaxActions,
+            Metrics:    instruments,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize storage reconciliation: %w", err)
+        }
+    }
+
+    // Verify a sample of stored content against upload checksums
+    var scrubber service.Scrubber
+    if cfg.Scrub.Enabled {
+        scrubber, err = service.NewScrubber(fileRepo, s3Storage, service.ScrubOptions{
+            Method:          cfg.Scrub.Method,
+            SampleSize:      cfg.Scrub.SampleSize,
+            MaxDownloadSize: cfg.Scrub.MaxDownloadSize,
+            Metrics:         instruments,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize integrity scrubber: %w", err)
+        }
+    }
+
+    // Configure client-side encryption and optional key escrow
+    var serviceOpts []service.Option
+    if cfg.Encryption.ClientSideEnabled {
+        var escrow service.KeyEscrow
+        if cfg.Encryption.EscrowEnabled {
+            kmsEscrow, err := storage.NewKMSKeyEscrow(cfg)
+            if err != nil {
+                return fmt.Errorf("failed to initialize key escrow: %w", err)
+            }
+            escrow = kmsEscrow
+        }
+        serviceOpts = append(serviceOpts, service.WithClientEncryption(escrow, cfg.Encryption.EscrowRequired))
+    }
+
+    // Apply masquerade detection overrides
+    masqueradePolicy := validator.MasqueradePolicy{
+        FlagOnly:     cfg.Validation.MasqueradeFlagOnly,
+        AllowedCodes: cfg.Validation.MasqueradeAllowedCodes,
+    }
+    serviceOpts = append(serviceOpts, service.WithMasqueradePolicy(masqueradePolicy))
+    serviceOpts = append(serviceOpts, service.WithContentValidation(validator.ContentPolicy{
+        Checks:   cfg.Validation.ContentChecks,
+        FailOpen: cfg.Validation.ContentFailOpen,
+    }, instruments))
+
+    // Sanitize previewable markup so it can be rendered inline
+    if cfg.Download.InlinePreviewEnabled {
+        serviceOpts = append(serviceOpts, service.WithPreviewSanitizer(sanitizer.New(), s3Storage, cfg.Download.PreviewMaxSize))
+    }
+
+    // Load the per-role upload policy, falling back to the built-in limits
+    uploadPolicy := service.DefaultUploadPolicy()
+    if cfg.Upload.PolicyFile != "" {
+        uploadPolicy, err = service.LoadUploadPolicy(cfg.Upload.PolicyFile)
+        if err != nil {
+            return fmt.Errorf("failed to load upload policy: %w", err)
+        }
+    }
+    serviceOpts = append(serviceOpts, service.WithUploadPolicy(uploadPolicy))
+    serviceOpts = append(serviceOpts, service.WithDrafts(s3Storage, cfg.Upload.DraftTTL))
+    if cfg.Upload.AsyncEnabled {
+        serviceOpts = append(serviceOpts, service.WithAsyncUploads(s3Storage, cfg.Upload.AsyncTimeout))
+    }
+    serviceOpts = append(serviceOpts, service.WithObjectTags(s3Storage))
+    serviceOpts = append(serviceOpts, service.WithBatchDelete(s3Storage))
+
+    // Check downloads against their checksums, repairing damaged content
+    // from the secondary bucket
+    if cfg.Scrub.VerifyDownloads {
+        integrityGuard, err := service.NewIntegrityGuard(fileRepo, s3Storage, replicaStorage, auditRepo, instruments)
+        if err != nil {
+            return fmt.Errorf("failed to initialize download verification: %w", err)
+        }
+        serviceOpts = append(serviceOpts, service.WithIntegrityGuard(integrityGuard))
+    }
+    if tieringService != nil {
+        serviceOpts = append(serviceOpts, service.WithTiering(tieringService))
+    }
+
+    // Apply per-tenant overrides of quotas, allowed types and draft retention
+    var tenantSettings *service.TenantSettings
+    if cfg.Tenant.SettingsEnabled {
+        tenantSettings, err = service.NewTenantSettings(tenantSettingsRepo, requestctx.Tenant, cfg.Tenant.SettingsCacheTTL)
+        if err != nil {
+            return fmt.Errorf("failed to initialize tenant settings: %w", err)
+        }
+        serviceOpts = append(serviceOpts, service.WithTenantSettings(tenantSettings))
+    }
+
+    // Enforce storage quotas, notifying the usage webhook as thresholds are crossed
+    var quotaTracker *service.QuotaTracker
+    if cfg.Quota.Limit > 0 {
+        var sender service.EventSender
+        if cfg.Quota.WebhookURL != "" {
+            client, err := webhook.New(cfg.Quota.WebhookURL, cfg.Quota.WebhookSecret, cfg.Quota.WebhookTimeout)
+            if err != nil {
+                return fmt.Errorf("failed to initialize quota webhook: %w", err)
+            }
+            sender = client
+        }
+        quotaTracker, err = service.NewQuotaTracker(fileRepo, cfg.Quota.Limit, cfg.Quota.Thresholds, sender)
+        if err != nil {
+            return fmt.Errorf("failed to initialize quota tracker: %w", err)
+        }
+        quotaTracker.UseTenantSettings(tenantSettings)
+        serviceOpts = append(serviceOpts, service.WithQuota(quotaTracker))
+    }
+
+    // Share upload bandwidth fairly between users
+    var uploadBandwidth *bandwidth.Scheduler
+    if cfg.Upload.BandwidthLimit > 0 {
+        uploadBandwidth, err = bandwidth.NewScheduler(cfg.Upload.BandwidthLimit, cfg.Upload.BandwidthBurst)
+        if err != nil {
+            return fmt.Errorf("failed to initialize bandwidth scheduler: %w", err)
+        }
+        if err := uploadBandwidth.Register(registry); err != nil {
+            return fmt.Errorf("failed to register bandwidth metrics: %w", err)
+        }
+        serviceOpts = append(serviceOpts, service.WithBandwidthScheduler(uploadBandwidth))
+    }
+
+    // Deduplicate uploads through reference counted blobs
+    if cfg.Upload.DedupEnabled {
+        blobRepo, err := repository.NewBlobRepository(db)
+        if err != nil {
+            return fmt.Errorf("failed to initialize blob repository: %w", err)
+        }
+        serviceOpts = append(serviceOpts,
+            service.WithBlobs(blobRepo, s3Storage, cfg.Upload.BlobGCGracePeriod),
+            service.WithSharedUploads(s3Storage))
+    }
+
+    // Generate thumbnails, archive listings and, where inline previews are
+    // allowed, sanitized copies on first request
+    derivedGenerators := []service.DerivedGenerator{
+        service.NewThumbnailGenerator(),
+        service.NewArchiveContentsGenerator(s3Storage),
+    }
+    if cfg.Download.InlinePreviewEnabled {
+        derivedGenerators = append(derivedGenerators, service.NewSanitizedGenerator(sanitizer.New()))
+    }
+
+    // Convert downloads to canonical formats through the configured converters
+    var converters []convert.Converter
+    if cfg.Download.ConvertGotenbergURL != "" {
+        gotenberg, err := convert.NewGotenberg(cfg.Download.ConvertGotenbergURL)
+        if err != nil {
+            return fmt.Errorf("failed to initialize document conversion: %w", err)
+        }
+        converters = append(converters, gotenberg)
+    }
+    if cfg.Download.ConvertRsvgPath != "" {
+        rsvg, err := convert.NewRsvg(cfg.Download.ConvertRsvgPath)
+        if err != nil {
+            return fmt.Errorf("failed to initialize image conversion: %w", err)
+        }
+        converters = append(converters, rsvg)
+    }
+    if cfg.Download.ConvertPdftocairoPath != "" {
+        pdftocairo, err := convert.NewPdftocairo(cfg.Download.ConvertPdftocairoPath)
+        if err != nil {
+            return fmt.Errorf("failed to initialize PDF rendering: %w", err)
+        }
+        converters = append(converters, pdftocairo)
+    }
+    if len(converters) > 0 {
+        pipeline, err := convert.NewPipeline(cfg.Download.ConvertTimeout, converters...)
+        if err != nil {
+            return fmt.Errorf("failed to initialize conversions: %w", err)
+        }
+        derivedGenerators = append(derivedGenerators, service.NewConversionGenerator(pipeline))
+    }
+    derivedService, err := service.NewDerivedObjectService(derivedRepo, fileRepo, s3Storage, s3Storage,
+        cfg.Download.DerivedMaxSourceSize, derivedGenerators...)
+    if err != nil {
+        return fmt.Errorf("failed to initialize derived objects: %w", err)
+    }
+    serviceOpts = append(serviceOpts, service.WithDerivedObjects(derivedService))
+
+    // Compose multi-step metadata changes into single transactions
+    txManager, err := repository.NewTxManager(db)
+    if err != nil {
+        return fmt.Errorf("failed to initialize transaction manager: %w", err)
+    }
+    serviceOpts = append(serviceOpts, service.WithTransactions(txManager))
+
+    // Uploads onto a taken name are resolved by strategy; overwrites honour
+    // file locks
+    lockService, err := service.NewLockService(lockRepo, fileRepo, cfg.Lock.DefaultTTL, cfg.Lock.MaxTTL)
+    if err != nil {
+        return fmt.Errorf("failed to initialize lock service: %w", err)
+    }
+    serviceOpts = append(serviceOpts, service.WithNameConflicts(cfg.Upload.NameConflict, lockService))
+
+    // Initialize file service
+    fileService, err := service.NewFileService(s3Storage, fileRepo, service.WorkerPoolConfig{
+        MaxWorkers: 10,
+        QueueSize:  100,
+        BufferSize: 32 * 1024,
+    }, serviceOpts...)
+    if err != nil {
+        return fmt.Errorf("failed to initialize file service: %w", err)
+    }
+
+    // Initialize chunked upload service, sizing chunks to each client's
+    // throughput when adaptive chunks are enabled
+    var chunkThroughput *throughput.Estimator
+    if cfg.Upload.AdaptiveChunks {
+        chunkThroughput = throughput.New(throughput.Options{
+            TargetDuration: cfg.Upload.ChunkTargetDuration,
+            MinChunkSize:   cfg.Upload.MinChunkSize,
+            MaxChunkSize:   cfg.Upload.MaxChunkSize,
+            TTL:            cfg.Upload.ThroughputTTL,
+        })
+    }
+    uploadSessionService, err := service.NewUploadSessionService(s3Storage, sessionRepo, fileRepo, service.ChunkedUploadConfig{
+        ChunkSize:  cfg.Upload.ChunkSize,
+        MaxChunks:  cfg.Upload.MaxChunks,
+        SessionTTL: cfg.Upload.SessionTTL,
+        Masquerade: masqueradePolicy,
+        Policy:     uploadPolicy,
+        Quota:      quotaTracker,
+        Tenants:    tenantSettings,
+        Bandwidth:  uploadBandwidth,
+        Throughput: chunkThroughput,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to initialize upload session service: %w", err)
+    }
+
+    // Let clients upload straight to the bucket with presigned URLs
+    var directUploadService service.DirectUploadService
+    if cfg.Upload.DirectEnabled {
+        directUploadService, err = service.NewDirectUploadService(s3Storage, fileRepo, service.DirectUploadConfig{
+            URLTTL:                cfg.Upload.DirectURLTTL,
+            Masquerade:            masqueradePolicy,
+            Policy:                uploadPolicy,
+            Quota:                 quotaTracker,
+            Tenants:               tenantSettings,
+            SingleUseConfirmation: cfg.Upload.DirectSingleUse,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize direct upload service: %w", err)
+        }
+    }
+
+    // Let clients download straight from the bucket with audited presigned URLs
+    var directDownloadService service.DirectDownloadService
+    if cfg.Download.PresignEnabled {
+        directDownloadService, err = service.NewDirectDownloadService(s3Storage, auditRepo, cfg.Download.PresignTTL)
+        if err != nil {
+            return fmt.Errorf("failed to initialize direct download service: %w", err)
+        }
+    }
+
+    // Expand uploaded archives into files in background jobs
+    var extractionService service.ExtractionService
+    if cfg.Upload.ExtractEnabled {
+        extractionService, err = service.NewExtractionService(extractionJobRepo, fileService, s3Storage, service.ExtractionOptions{
+            MaxEntries: cfg.Upload.ExtractMaxEntries,
+            MaxSize:    cfg.Upload.ExtractMaxSize,
+            MaxRatio:   cfg.Upload.ExtractMaxRatio,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize extraction service: %w", err)
+        }
+    }
+
+    // Hold hard deletes in regulated tenants until two admins approve them
+    var deleteApprovals service.DeleteApprovals
+    if len(cfg.Tenant.RegulatedTenants) > 0 {
+        deleteApprovals, err = service.NewDeleteApprovals(pendingActionRepo, auditRepo, fileService, txManager,
+            requestctx.Tenant, service.DeleteApprovalOptions{
+                RegulatedTenants: cfg.Tenant.RegulatedTenants,
+                TTL:              cfg.Tenant.DeleteApprovalTTL,
+            })
+        if err != nil {
+            return fmt.Errorf("failed to initialize delete approvals: %w", err)
+        }
+    }
+
+    // Initialize attachment service
+    attachmentService, err := service.NewAttachmentService(attachmentRepo, fileRepo)
+    if err != nil {
+        return fmt.Errorf("failed to initialize attachment service: %w", err)
+    }
+
+    // Deliver share notifications and upload digests by email and webhook
+    var emailNotifier notify.Notifier
+    switch cfg.Notify.EmailProvider {
+    case "smtp":
+        emailNotifier, err = notify.NewSMTP(cfg.Notify.SMTPHost, cfg.Notify.SMTPPort,
+            cfg.Notify.SMTPUsername, cfg.Notify.SMTPPassword, cfg.Notify.EmailFrom)
+    case "ses":
+        emailNotifier, err = notify.NewSES(context.Background(), cfg.Notify.SESRegion, cfg.Notify.EmailFrom)
+    }
+    if err != nil {
+        return fmt.Errorf("failed to initialize email notifier: %w", err)
+    }
+    webhookNotifier, err := notify.NewWebhook(cfg.Notify.WebhookSecret, cfg.Notify.Timeout)
+    if err != nil {
+        return fmt.Errorf("failed to initialize webhook notifier: %w", err)
+    }
+    notificationService, err := service.NewNotificationService(notificationRepo, fileRepo, emailNotifier,
+        webhookNotifier, cfg.Notify.DigestInterval, cfg.Notify.Timeout)
+    if err != nil {
+        return fmt.Errorf("failed to initialize notification service: %w", err)
+    }
+
+    // Upload inboxes for external parties, notifying owners on receipt
+    fileRequestService, err := service.NewFileRequestService(fileRequestRepo, fileService, notificationService,
+        cfg.Request.DefaultTTL, cfg.Request.MaxTTL)
+    if err != nil {
+        return fmt.Errorf("failed to initialize file request service: %w", err)
+    }
+
+    // Review shares and file request links nobody has used for a while
+    accessReview, err := service.NewAccessReview(shareRepo, fileRequestRepo, notificationService, service.AccessReviewOptions{
+        StaleAfter: cfg.AccessReview.StaleAfter,
+        AutoRevoke: cfg.AccessReview.AutoRevoke,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to initialize access review: %w", err)
+    }
+
+    // Estimate storage costs from S3 request counts and stored volume
+    s3Requests := s3Storage.Requests()
+    costEstimator, err := service.NewCostEstimator(fileRepo, s3Requests, service.StoragePrices{
+        StorageGBMonth:           cfg.Cost.StorageGBMonth,
+        Tier1RequestsPerThousand: cfg.Cost.Tier1RequestsPerThousand,
+        Tier2RequestsPerThousand: cfg.Cost.Tier2RequestsPerThousand,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to initialize cost estimator: %w", err)
+    }
+    if err := registry.Register(costEstimator); err != nil {
+        return fmt.Errorf("failed to register storage cost metrics: %w", err)
+    }
+
+    // Capability URLs for embedding previews are only issued when signed
+    var previewLinks *service.PreviewLinks
+    if cfg.Download.PreviewTokenSecret != "" {
+        previewLinks, err = service.NewPreviewLinks([]byte(cfg.Download.PreviewTokenSecret), cfg.Download.PreviewTokenTTL)
+        if err != nil {
+            return fmt.Errorf("failed to initialize preview links: %w", err)
+        }
+    }
+
+    // Single-use preview links record each token's nonce until it expires
+    var replayGuard *service.ReplayGuard
+    if previewLinks != nil && cfg.Download.PreviewTokenSingleUse {
+        replayGuard, err = service.NewReplayGuard(nonceRepo)
+        if err != nil {
+            return fmt.Errorf("failed to initialize replay protection: %w", err)
+        }
+        previewLinks.UseReplayGuard(replayGuard)
+    }
+
+    // Stamp downloads with the downloader's identity where the tenant requires it
+    var watermarker *service.Watermarker
+    if cfg.Download.WatermarkPolicyFile != "" {
+        watermarkPolicy, err := service.LoadWatermarkPolicy(cfg.Download.WatermarkPolicyFile)
+        if err != nil {
+            return fmt.Errorf("failed to load watermark policy: %w", err)
+        }
+        watermarker, err = service.NewWatermarker(watermarkPolicy, cfg.Download.WatermarkMaxSize)
+        if err != nil {
+            return fmt.Errorf("failed to initialize watermarking: %w", err)
+        }
+    }
+
+    // Delegate file access decisions to the policy engine when configured
+    var authorizer authz.Authorizer
+    if cfg.Authz.Mode == authz.ModeOPA {
+        policyEngine, err := authz.NewOPA(context.Background(), cfg.Authz.PolicyDir)
+        if err != nil {
+            return fmt.Errorf("failed to load authorization policies: %w", err)
+        }
+        authorizer = policyEngine
+    }
+
+    // Load the caching headers per endpoint and content type, falling back
+    // to the built-in policy
+    cachePolicy := handlers.DefaultCachePolicy()
+    if cfg.Download.CachePolicyFile != "" {
+        cachePolicy, err = handlers.LoadCachePolicy(cfg.Download.CachePolicyFile)
+        if err != nil {
+            return fmt.Errorf("failed to load cache policy: %w", err)
+        }
+    }
+
+    // Initialize HTTP handlers
+    downloadPolicy := handlers.DownloadSecurityPolicy{
+        InlinePreviewEnabled: cfg.Download.InlinePreviewEnabled,
+        ForceOctetStream:     cfg.Download.ForceOctetStream,
+        RiskyContentTypes:    cfg.Download.RiskyContentTypes,
+        PreviewCSP:           cfg.Download.PreviewCSP,
+    }
+    fileHandler := handlers.NewFileHandler(fileService, instruments, downloadPolicy, lockService, watermarker, authorizer,
+        accessReview, notificationService, objectLambda, derivedService, directDownloadService, deleteApprovals, archiveService, previewLinks)
+    previewHandler := handlers.NewPreviewHandler(fileService, derivedService, previewLinks, downloadPolicy, cfg.Download.PreviewFrameAncestors,
+        authorizer, accessReview)
+    derivedHandler := handlers.NewDerivedHandler(derivedService, fileService, downloadPolicy, authorizer, accessReview)
+    extractionHandler := handlers.NewExtractionHandler(extractionService, fileService, authorizer)
+    uploadSessionHandler := handlers.NewUploadSessionHandler(uploadSessionService, instruments)
+    directUploadHandler := handlers.NewDirectUploadHandler(directUploadService, instruments)
+    policyHandler := handlers.NewPolicyHandler(uploadPolicy, handlers.UploadHints{
+        ChunkSize:        cfg.Upload.ChunkSize,
+        MaxChunks:        cfg.Upload.MaxChunks,
+        ClientEncryption: cfg.Encryption.ClientSideEnabled,
+        EscrowRequired:   cfg.Encryption.EscrowRequired,
+        DirectUpload:     directUploadService != nil,
+    }, quotaTracker)
+    attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+    lockHandler := handlers.NewLockHandler(lockService)
+    notificationHandler := handlers.NewNotificationHandler(notificationService, authorizer, fileService, accessReview)
+    fileRequestHandler := handlers.NewFileRequestHandler(fileRequestService)
+    adminHandler := handlers.NewAdminHandler(costEstimator, accessReview, s3Storage.Health(),
+        newSupportBundle(cfg, registry, o.recorder, db, s3Storage.Health()))
+    tenantSettingsHandler := handlers.NewTenantSettingsHandler(tenantSettings)
+    approvalHandler := handlers.NewApprovalHandler(deleteApprovals)
+
+    // Initialize metrics export
+    metricsProvider, err := setupMetricsProvider(cfg, registry)
+    if err != nil {
+        return fmt.Errorf("failed to initialize metrics exporter: %w", err)
+    }
+
+    // Accept mesh workloads authenticated by their SVID alongside JWTs
+    if cfg.SPIFFE.Enabled {
+        bundles, err := newSPIFFESource(cfg)
+        if err != nil {
+            return fmt.Errorf("failed to connect to the SPIFFE Workload API: %w", err)
+        }
+        defer bundles.Close()
+        spiffeAuth, err := middleware.NewSPIFFEAuthenticator(bundles, cfg.SPIFFE.Roles)
+        if err != nil {
+            return fmt.Errorf("failed to initialize SPIFFE authentication: %w", err)
+        }
+        middleware.UseSPIFFE(spiffeAuth)
+    }
+
+    // Browsers authenticated by a gateway session cookie must prove that
+    // state-changing requests come from the frontend
+    var csrf *middleware.CSRF
+    if cfg.CSRF.Enabled {
+        csrf, err = middleware.NewCSRF(middleware.CSRFOptions{
+            SessionCookie:  cfg.CSRF.SessionCookie,
+            Cookie:         cfg.CSRF.Cookie,
+            Header:         cfg.CSRF.Header,
+            Secret:         []byte(cfg.CSRF.TokenSecret),
+            TrustedOrigins: cfg.CSRF.TrustedOrigins,
+        })
+        if err != nil {
+            return fmt.Errorf("failed to initialize CSRF protection: %w", err)
+        }
+    }
+
+    // Configure the public file API server and the internal operations server
+    drainer := lifecycle.NewDrainer(cfg.Server.DrainDelay, db.PingContext)
+    server := setupSecureServer(cfg, fileHandler, uploadSessionHandler, directUploadHandler, policyHandler, attachmentHandler, previewHandler, derivedHandler, extractionHandler, lockHandler, notificationHandler, fileRequestHandler, quotaTracker, sloMetrics, instruments, csrf, cachePolicy, drainer, o.routes)
+    internalServer := setupInternalServer(cfg, adminHandler, tenantSettingsHandler, approvalHandler, notificationHandler, metricsProvider.Handler(), drainer, o.internalRoutes)
+
+    // Background work stops when ctx is done or Run fails
+    jobsCtx, stopJobs := context.WithCancel(ctx)
+    defer stopJobs()
+
+    if !o.withoutJobs {
+        // Scheduled jobs run on one replica at a time
+        jobLocker, err := joblock.New(cfg.Jobs.LockBackend, db, cfg.Jobs.LockKeepAlive)
+        if err != nil {
+            return fmt.Errorf("failed to initialize job locks: %w", err)
+        }
+
+        // Purge drafts that were never committed
+        errtrack.Go(jobsCtx, "draft-purge", func() {
+            runDraftPurge(jobsCtx, jobLocker, fileService, cfg.Upload.DraftPurgeInterval)
+        })
+
+        // Fail asynchronous uploads whose processing never finished
+        if cfg.Upload.AsyncEnabled {
+            errtrack.Go(jobsCtx, "async-upload-sweep", func() {
+                runAsyncUploadSweep(jobsCtx, jobLocker, fileService, cfg.Upload.AsyncSweepInterval)
+            })
+        }
+
+        // Clean up expired upload sessions and abandoned multipart uploads
+        errtrack.Go(jobsCtx, "upload-sweep", func() {
+            runUploadSweep(jobsCtx, jobLocker, uploadSessionService, cfg.Upload.SessionSweepInterval)
+        })
+
+        // Delete blobs that are no longer referenced by any file
+        if cfg.Upload.DedupEnabled {
+            errtrack.Go(jobsCtx, "blob-gc", func() {
+                runBlobCollector(jobsCtx, jobLocker, fileService, cfg.Upload.BlobGCInterval)
+            })
+        }
+        if replicationService != nil {
+            errtrack.Go(jobsCtx, "replication", func() {
+                runReplication(jobsCtx, jobLocker, replicationService, cfg.Replication.Interval)
+            })
+        }
+        if archiveService != nil {
+            errtrack.Go(jobsCtx, "archive", func() {
+                runArchive(jobsCtx, jobLocker, archiveService, cfg.Archive.Interval)
+            })
+        }
+        if tieringService != nil {
+            errtrack.Go(jobsCtx, "tiering", func() {
+                runTiering(jobsCtx, jobLocker, tieringService, cfg.Tiering.Interval)
+            })
+        }
+        if reconciler != nil {
+            errtrack.Go(jobsCtx, "reconcile", func() {
+                runReconcile(jobsCtx, jobLocker, reconciler, cfg.Reconcile.Interval)
+            })
+        }
+        if scrubber != nil {
+            errtrack.Go(jobsCtx, "integrity-scrub", func() {
+                runScrub(jobsCtx, jobLocker, scrubber, cfg.Scrub.Interval)
+            })
+        }
+        if emailNotifier != nil {
+            errtrack.Go(jobsCtx, "notification-digest", func() {
+                runDigests(jobsCtx, jobLocker, notificationService, cfg.Notify.DigestCheckInterval)
+            })
+        }
+        if replayGuard != nil {
+            errtrack.Go(jobsCtx, "nonce-purge", func() {
+                runNoncePurge(jobsCtx, jobLocker, replayGuard, cfg.Download.NoncePurgeInterval)
+            })
+        }
+        if deleteApprovals != nil {
+            errtrack.Go(jobsCtx, "approval-expiry", func() {
+                runApprovalExpiry(jobsCtx, jobLocker, deleteApprovals, cfg.Tenant.DeleteApprovalSweepInterval)
+            })
+        }
+        if cfg.AccessReview.Enabled {
+            errtrack.Go(jobsCtx, "access-review", func() {
+                runAccessReview(jobsCtx, jobLocker, accessReview, cfg.AccessReview.Interval)
+            })
+        }
+        if cfg.Audit.ExportEnabled {
+            signingKey, err := auditlog.ParsePrivateKey(cfg.Audit.SigningKey)
+            if err != nil {
+                return fmt.Errorf("failed to load audit signing key: %w", err)
+            }
+            auditExporter, err := service.NewAuditExporter(auditRepo, s3Storage, signingKey, service.AuditExportOptions{
+                Prefix:    cfg.Audit.Prefix,
+                BatchSize: cfg.Audit.BatchSize,
+                LockMode:  cfg.Audit.LockMode,
+                Retention: cfg.Audit.Retention,
+            })
+            if err != nil {
+                return fmt.Errorf("failed to initialize audit exporter: %w", err)
+            }
+            errtrack.Go(jobsCtx, "audit-export", func() {
+                runAuditExport(jobsCtx, jobLocker, auditExporter, cfg.Audit.ExportInterval)
+            })
+        }
+    }
+
+    // Every instance probes storage for its own health scoreboard
+    errtrack.Go(jobsCtx, "storage-health-probe", func() {
+        s3Storage.Health().Run(jobsCtx, cfg.S3.HealthProbeInterval, cfg.S3.HealthProbeTimeout)
+    })
+    if cfg.Metrics.StorageStatsInterval > 0 {
+        storageStats, err := service.NewStorageStats(fileRepo, instruments)
+        if err != nil {
+            return fmt.Errorf("failed to initialize storage statistics: %w", err)
+        }
+        errtrack.Go(jobsCtx, "storage-stats", func() {
+            runStorageStats(jobsCtx, storageStats, cfg.Metrics.StorageStatsInterval)
+        })
+    }
+    if uploadBandwidth != nil {
+        errtrack.Go(jobsCtx, "bandwidth-rebalance", func() {
+            uploadBandwidth.Run(jobsCtx, cfg.Upload.BandwidthRebalanceInterval)
+        })
+    }
+    go metricsProvider.Start(jobsCtx)
+
+    // Serve until ctx is done; a failing server or registration shuts the
+    // service down early and is returned
+    failed := make(chan error, 3)
+    go func() {
+        build := buildinfo.Get()
+        log.Info("Starting server",
+            logger.String("address", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)),
+            logger.String("version", build.Version),
+            logger.String("gitSha", build.GitSHA),
+            logger.String("buildTime", build.BuildTime))
+
+        var err error
+        if cfg.Server.TLSEnabled {
+            // Configure automatic TLS certificate management
+            certManager := autocert.Manager{
+                Prompt:     autocert.AcceptTOS,
+                Cache:      autocert.DirCache("certs"),
+                HostPolicy: autocert.HostWhitelist(cfg.Server.Host),
+            }
+            server.TLSConfig = certManager.TLSConfig()
+            if cfg.SPIFFE.Enabled {
+                // Client certificates are optional and verified as SVIDs by
+                // the authentication middleware, so token clients still connect
+                server.TLSConfig.ClientAuth = tls.RequestClientCert
+            }
+            if o.listener != nil {
+                err = server.ServeTLS(o.listener, "", "")
+            } else {
+                err = server.ListenAndServeTLS("", "")
+            }
+        } else if o.listener != nil {
+            err = server.Serve(o.listener)
+        } else {
+            err = server.ListenAndServe()
+        }
+
+        if err != nil && !errors.Is(err, http.ErrServerClosed) {
+            failed <- fmt.Errorf("server failed: %w", err)
+        }
+    }()
+
+    // Start internal server in a goroutine
+    go func() {
+        log.Info("Starting internal server",
+            logger.String("address", internalServer.Addr))
+
+        var err error
+        if o.internalListener != nil {
+            err = internalServer.Serve(o.internalListener)
+        } else {
+            err = internalServer.ListenAndServe()
+        }
+        if err != nil && !errors.Is(err, http.ErrServerClosed) {
+            failed <- fmt.Errorf("internal server failed: %w", err)
+        }
+    }()
+
+    // Register with the service registry once the listeners are starting;
+    // the registry's health checks hold traffic back until they answer
+    var registrar discovery.Registrar
+    if cfg.Discovery.Backend != "" {
+        if registrar, err = register(ctx, cfg); err != nil {
+            failed <- err
+        }
+    }
+
+    var runErr error
+    select {
+    case <-ctx.Done():
+    case runErr = <-failed:
+        log.Error("Shutting down after failure",
+            logger.Error(runErr))
+    }
+
+    log.Info("Shutting down server...")
+    stopJobs()
+
+    // Leave the registry first so no new traffic arrives while draining
+    if registrar != nil {
+        deregisterCtx, cancelDeregister := context.WithTimeout(context.Background(), cfg.Discovery.CheckTimeout)
+        if err := registrar.Deregister(deregisterCtx); err != nil {
+            log.Error("Failed to deregister from service discovery",
+                logger.Error(err))
+        }
+        cancelDeregister()
+    }
+
+    // Fail readiness and wait out the drain delay before closing listeners.
+    // When the preStop hook already drained this returns immediately.
+    <-drainer.Drain()
+
+    // Create shutdown context with timeout; in-flight transfers get this long
+    // to complete once the listeners close
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+    defer cancel()
+
+    // Attempt graceful shutdown
+    if err := server.Shutdown(shutdownCtx); err != nil {
+        log.Error("Server forced to shutdown",
+            logger.Error(err))
+    }
+    if err := internalServer.Shutdown(shutdownCtx); err != nil {
+        log.Error("Internal server forced to shutdown",
+            logger.Error(err))
+    }
+
+    // Flush metrics that have not been pushed yet
+    if err := metricsProvider.Shutdown(shutdownCtx); err != nil {
+        log.Error("Failed to flush metrics",
+            logger.Error(err))
+    }
+
+    log.Info("Server stopped")
+    return runErr
+}
+
+// register adds this instance to the service registry
+func register(ctx context.Context, cfg *config.Config) (discovery.Registrar, error) {
+    registrar, err := setupRegistrar(cfg)
+    if err != nil {
+        return nil, fmt.Errorf("failed to initialize service discovery: %w", err)
+    }
+    registerCtx, cancel := context.WithTimeout(ctx, cfg.Discovery.CheckTimeout)
+    defer cancel()
+    if err := registrar.Register(registerCtx); err != nil {
+        return nil, fmt.Errorf("failed to register with service discovery: %w", err)
+    }
+    return registrar, nil
+}
+
+// setupMetricsProvider selects how registered metrics are exported: served for
+// Prometheus scrapes or pushed to an OTLP collector
+func setupMetricsProvider(cfg *config.Config, registry *prometheus.Registry) (telemetry.Provider, error) {
+    if cfg.Metrics.Exporter == telemetry.ExporterOTLP {
+        return telemetry.NewOTLPProvider(registry, telemetry.OTLPConfig{
+            Endpoint:    cfg.Metrics.OTLPEndpoint,
+            Headers:     cfg.Metrics.OTLPHeaders,
+            Interval:    cfg.Metrics.OTLPInterval,
+            Timeout:     cfg.Metrics.OTLPTimeout,
+            ServiceName: cfg.Metrics.ServiceName,
+        })
+    }
+    return telemetry.NewPrometheusProvider(registry), nil
+}
+
+// setupRegistrar builds the registry entry for this instance. The address and
+// ID default to the hostname, and the registry probes the internal health endpoint.
+func setupRegistrar(cfg *config.Config) (discovery.Registrar, error) {
+    address := cfg.Discovery.Address
+    if address == "" {
+        hostname, err := os.Hostname()
+        if err != nil {
+            return nil, fmt.Errorf("failed to determine advertise address: %w", err)
+        }
+        address = hostname
+    }
+
+    serviceID := cfg.Discovery.ServiceID
+    if serviceID == "" {
+        serviceID = fmt.Sprintf("%s-%d", address, cfg.Server.Port)
+    }
+
+    build := buildinfo.Get()
+    return discovery.New(discovery.Config{
+        Backend:     cfg.Discovery.Backend,
+        Endpoints:   cfg.Discovery.Endpoints,
+        Token:       cfg.Discovery.ACLToken,
+        Username:    cfg.Discovery.Username,
+        Password:    cfg.Discovery.Password,
+        ServiceName: cfg.Discovery.ServiceName,
+        ServiceID:   serviceID,
+        Address:     address,
+        Port:        cfg.Server.Port,
+        Tags:        cfg.Discovery.Tags,
+        Meta: map[string]string{
+            "version": build.Version,
+            "gitSha":  build.GitSHA,
+        },
+        HealthURL:       fmt.Sprintf("http://%s:%d%s", address, cfg.Server.InternalPort, healthCheckPath),
+        CheckInterval:   cfg.Discovery.CheckInterval,
+        CheckTimeout:    cfg.Discovery.CheckTimeout,
+        DeregisterAfter: cfg.Discovery.DeregisterAfter,
+        KeyPrefix:       cfg.Discovery.KeyPrefix,
+    })
+}
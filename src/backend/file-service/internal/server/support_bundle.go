@@ -0,0 +1,81 @@
+package server
+
+import (
+    "context"
+    "database/sql"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/supportbundle"
+)
+
+// databasePingTimeout bounds the database check of a support bundle
+const databasePingTimeout = 5 * time.Second
+
+// dependencyHealth is the health section of a support bundle
+type dependencyHealth struct {
+    Database databaseHealth        `json:"database"`
+    Storage  *storage.HealthReport `json:"storage"`
+}
+
+// databaseHealth is the outcome of pinging the metadata database
+type databaseHealth struct {
+    Status    string  `json:"status"`
+    LatencyMs float64 `json:"latencyMs"`
+    Error     string  `json:"error,omitempty"`
+}
+
+// newSupportBundle assembles the support bundle served to administrators:
+// the redacted configuration and how it differs from the defaults, the
+// health of the database and the storage backends, a snapshot of the
+// metrics in registry, a goroutine dump and, if recorder is set, the recent
+// warnings and errors
+func newSupportBundle(cfg *config.Config, registry prometheus.Gatherer, recorder *logger.Recorder,
+    db *sql.DB, storageHealth *storage.HealthBoard) *supportbundle.Bundle {
+    sections := []supportbundle.Section{
+        supportbundle.JSON("config.json", func(ctx context.Context) (interface{}, error) {
+            return cfg.Redacted(), nil
+        }),
+        supportbundle.JSON("config-changes.json", func(ctx context.Context) (interface{}, error) {
+            defaults, err := config.Defaults()
+            if err != nil {
+                return nil, err
+            }
+            return config.Diff(defaults, cfg.Redacted())
+        }),
+        supportbundle.JSON("health.json", func(ctx context.Context) (interface{}, error) {
+            return dependencyHealth{
+                Database: pingDatabase(ctx, db),
+                Storage:  storageHealth.Report(),
+            }, nil
+        }),
+        supportbundle.Metrics(registry),
+        supportbundle.Goroutines(),
+    }
+    if recorder != nil {
+        sections = append(sections, supportbundle.Logs(recorder))
+    }
+    return supportbundle.New(sections...)
+}
+
+// pingDatabase checks that the metadata database answers
+func pingDatabase(ctx context.Context, db *sql.DB) databaseHealth {
+    ctx, cancel := context.WithTimeout(ctx, databasePingTimeout)
+    defer cancel()
+
+    start := time.Now()
+    err := db.PingContext(ctx)
+    health := databaseHealth{
+        Status:    storage.HealthHealthy,
+        LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+    }
+    if err != nil {
+        health.Status = storage.HealthDown
+        health.Error = err.Error()
+    }
+    return health
+}
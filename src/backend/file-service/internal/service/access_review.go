@@ -0,0 +1,276 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/tracing"
+)
+
+const (
+    // accessReviewBatchSize bounds the shares and the file requests handled
+    // per review run
+    accessReviewBatchSize = 500
+    // maxAccessReportItems bounds the shares and the file requests listed in
+    // an access report
+    maxAccessReportItems = 1000
+)
+
+// AccessReviewOptions configure the access review
+type AccessReviewOptions struct {
+    // StaleAfter is how long a share or file request must go unused before
+    // its owner is asked to review it
+    StaleAfter time.Duration
+    // AutoRevoke revokes stale shares and file requests instead of only
+    // notifying their owners
+    AutoRevoke bool
+}
+
+// AccessReport lists the shares and open file requests unused for at least
+// StaleAfter, least recently used first
+type AccessReport struct {
+    GeneratedAt  time.Time             `json:"generatedAt"`
+    StaleAfter   string                `json:"staleAfter"`
+    Shares       []*models.Share       `json:"shares"`
+    FileRequests []*models.FileRequest `json:"fileRequests"`
+}
+
+// AccessReview keeps sharing sprawl under control. It records shares and
+// their use, and periodically asks owners to review shares and public file
+// request links nobody used for a while, optionally revoking them.
+type AccessReview interface {
+    RecordShare(ctx context.Context, event ShareEvent) error
+    TouchShare(ctx context.Context, fileID, userID string) (bool, error)
+    Report(ctx context.Context, staleAfter time.Duration) (*AccessReport, error)
+    Review(ctx context.Context) (int, error)
+}
+
+// accessReview implements AccessReview
+type accessReview struct {
+    shares        repository.ShareRepository
+    requests      repository.FileRequestRepository
+    notifications NotificationService
+    opts          AccessReviewOptions
+    now           func() time.Time
+    logger        *logger.Logger
+}
+
+// NewAccessReview creates a new instance of accessReview. notifications may
+// be nil, in which case owners are not told about stale access.
+func NewAccessReview(shares repository.ShareRepository, requests repository.FileRequestRepository,
+    notifications NotificationService, opts AccessReviewOptions) (AccessReview, error) {
+    if shares == nil || requests == nil {
+        return nil, errors.New("share and file request repositories are required")
+    }
+    if opts.StaleAfter <= 0 {
+        return nil, errors.New("stale period must be positive")
+    }
+
+    return &accessReview{
+        shares:        shares,
+        requests:      requests,
+        notifications: notifications,
+        opts:          opts,
+        now:           time.Now,
+        logger:        logger.GetLogger(),
+    }, nil
+}
+
+// RecordShare records that a file was shared with a user
+func (a *accessReview) RecordShare(ctx context.Context, event ShareEvent) error {
+    if event.FileID == "" || event.SharedWith == "" {
+        return ErrInvalidInput
+    }
+
+    share := models.NewShare(event.FileID, event.SharedBy, event.SharedWith)
+    if err := a.shares.Record(ctx, share); err != nil {
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return nil
+}
+
+// TouchShare records that userID used their share of fileID and reports
+// whether they hold an active share of it
+func (a *accessReview) TouchShare(ctx context.Context, fileID, userID string) (bool, error) {
+    if fileID == "" || userID == "" {
+        return false, nil
+    }
+
+    shared, err := a.shares.Touch(ctx, fileID, userID, a.now().UTC())
+    if err != nil {
+        return false, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return shared, nil
+}
+
+// Report lists shares and open file requests unused for staleAfter, or for
+// the configured period if staleAfter is zero
+func (a *accessReview) Report(ctx context.Context, staleAfter time.Duration) (*AccessReport, error) {
+    if staleAfter == 0 {
+        staleAfter = a.opts.StaleAfter
+    }
+    if staleAfter < 0 {
+        return nil, ErrInvalidInput
+    }
+
+    now := a.now().UTC()
+    cutoff := now.Add(-staleAfter)
+    shares, err := a.shares.ListStale(ctx, cutoff, true, maxAccessReportItems)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    requests, err := a.shares.ListStaleFileRequests(ctx, cutoff, now, true, maxAccessReportItems)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    report := &AccessReport{
+        GeneratedAt:  now,
+        StaleAfter:   staleAfter.String(),
+        Shares:       shares,
+        FileRequests: requests,
+    }
+    if report.Shares == nil {
+        report.Shares = []*models.Share{}
+    }
+    if report.FileRequests == nil {
+        report.FileRequests = []*models.FileRequest{}
+    }
+    return report, nil
+}
+
+// Review handles shares and file requests that went stale since the last
+// run and returns how many it handled. With AutoRevoke they are revoked and
+// their owners told; otherwise owners are asked to review them, once per
+// stale period.
+func (a *accessReview) Review(ctx context.Context) (int, error) {
+    log := a.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    now := a.now().UTC()
+    cutoff := now.Add(-a.opts.StaleAfter)
+    shares, err := a.shares.ListStale(ctx, cutoff, a.opts.AutoRevoke, accessReviewBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    requests, err := a.shares.ListStaleFileRequests(ctx, cutoff, now, a.opts.AutoRevoke, accessReviewBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if a.opts.AutoRevoke {
+        shares, requests = a.revoke(ctx, log, shares, requests, now)
+    }
+
+    handled := 0
+    for _, event := range groupStaleAccess(shares, requests) {
+        event.StaleAfter = a.opts.StaleAfter
+        event.Revoked = a.opts.AutoRevoke
+        if a.notifications != nil {
+            if err := a.notifications.NotifyStaleAccess(ctx, *event); err != nil {
+                log.Warn("Failed to notify owner of stale access",
+                    logger.String("ownerId", event.OwnerID),
+                    logger.Error(err))
+                if !a.opts.AutoRevoke {
+                    continue
+                }
+            }
+        } else if !a.opts.AutoRevoke {
+            continue
+        }
+        a.markNotified(ctx, log, event, now)
+        handled += len(event.Shares) + len(event.FileRequests)
+    }
+
+    if handled > 0 {
+        log.Info("Reviewed stale access",
+            logger.Int("count", handled),
+            logger.Bool("revoked", a.opts.AutoRevoke))
+    }
+    return handled, nil
+}
+
+// revoke revokes stale shares and file requests and returns those revoked
+func (a *accessReview) revoke(ctx context.Context, log *logger.Logger, shares []*models.Share,
+    requests []*models.FileRequest, now time.Time) ([]*models.Share, []*models.FileRequest) {
+    revokedShares := shares[:0]
+    for _, share := range shares {
+        if err := a.shares.Revoke(ctx, share.ID, now); err != nil {
+            log.Warn("Failed to revoke stale share",
+                logger.String("shareId", share.ID),
+                logger.Error(err))
+            continue
+        }
+        share.RevokedAt = &now
+        revokedShares = append(revokedShares, share)
+    }
+
+    revokedRequests := requests[:0]
+    for _, request := range requests {
+        if err := a.requests.Revoke(ctx, request.ID, request.OwnerID, now); err != nil {
+            log.Warn("Failed to revoke stale file request",
+                logger.String("requestId", request.ID),
+                logger.Error(err))
+            continue
+        }
+        request.RevokedAt = &now
+        revokedRequests = append(revokedRequests, request)
+    }
+    return revokedShares, revokedRequests
+}
+
+// markNotified records that the owner was told about the event's items, so
+// they are not reported again until they are used and go stale again
+func (a *accessReview) markNotified(ctx context.Context, log *logger.Logger, event *StaleAccess, now time.Time) {
+    for _, share := range event.Shares {
+        if err := a.shares.MarkNotified(ctx, share.ID, now); err != nil {
+            log.Warn("Failed to record stale share notice",
+                logger.String("shareId", share.ID),
+                logger.Error(err))
+        }
+    }
+    for _, request := range event.FileRequests {
+        if err := a.shares.MarkFileRequestNotified(ctx, request.ID, now); err != nil {
+            log.Warn("Failed to record stale file request notice",
+                logger.String("requestId", request.ID),
+                logger.Error(err))
+        }
+    }
+}
+
+// groupStaleAccess groups stale shares and file requests by owner, in the
+// order owners first appear
+func groupStaleAccess(shares []*models.Share, requests []*models.FileRequest) []*StaleAccess {
+    var events []*StaleAccess
+    byOwner := make(map[string]*StaleAccess)
+    eventFor := func(ownerID string) *StaleAccess {
+        event, ok := byOwner[ownerID]
+        if !ok {
+            event = &StaleAccess{
+                OwnerID:      ownerID,
+                Shares:       []*models.Share{},
+                FileRequests: []*models.FileRequest{},
+            }
+            byOwner[ownerID] = event
+            events = append(events, event)
+        }
+        return event
+    }
+
+    for _, share := range shares {
+        event := eventFor(share.OwnerID)
+        event.Shares = append(event.Shares, share)
+    }
+    for _, request := range requests {
+        event := eventFor(request.OwnerID)
+        event.FileRequests = append(event.FileRequests, request)
+    }
+    return events
+}
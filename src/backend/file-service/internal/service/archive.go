@@ -0,0 +1,291 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// Restore lifecycle events, counted by archive_restores_total
+const (
+    restoreEventRequested = "requested"
+    restoreEventCompleted = "completed"
+    restoreEventExpired   = "expired"
+)
+
+// archiveMetrics are the instruments of the archive worker
+type archiveMetrics struct {
+    // transitions counts files moved to an archival storage class, by class
+    transitions metrics.Counter
+    // restores counts restore lifecycle events
+    restores metrics.Counter
+}
+
+// newArchiveMetrics creates the instruments of the archive worker
+func newArchiveMetrics(provider metrics.Provider) archiveMetrics {
+    provider = metrics.OrNop(provider)
+    return archiveMetrics{
+        transitions: provider.Counter(metrics.Opts{
+            Name:   "archive_transitions_total",
+            Help:   "Idle files moved to an archival storage class by storage class",
+            Labels: []string{"storage_class"},
+        }),
+        restores: provider.Counter(metrics.Opts{
+            Name:   "archive_restores_total",
+            Help:   "Restores of archived files by event",
+            Labels: []string{"event"},
+        }),
+    }
+}
+
+// ArchiveOptions configure archiving. Files not accessed for GlacierAfter
+// are moved to GLACIER, or to DEEP_ARCHIVE once idle for DeepArchiveAfter;
+// zero disables a class.
+type ArchiveOptions struct {
+    GlacierAfter     time.Duration
+    DeepArchiveAfter time.Duration
+    // BatchSize bounds the files archived and restores polled per run
+    BatchSize int
+    // RestoreDays is how long restored copies stay readable
+    RestoreDays int
+    // RestoreTier is the S3 retrieval tier: Expedited, Standard or Bulk
+    RestoreTier string
+    // Metrics creates the worker's instruments; nil records nothing
+    Metrics metrics.Provider
+}
+
+// ArchiveService moves the content of idle files to archival storage
+// classes and restores it on request. Archived content cannot be downloaded
+// until a restore completes; restored copies expire, after which the file is
+// archived again.
+type ArchiveService interface {
+    ArchiveIdle(ctx context.Context) (int, error)
+    Restore(ctx context.Context, fileID string) (*models.File, error)
+    PollRestores(ctx context.Context) (int, error)
+}
+
+// archiveService implements ArchiveService
+type archiveService struct {
+    files   repository.FileRepository
+    archive storage.ArchiveStorage
+    opts    ArchiveOptions
+    metrics archiveMetrics
+    now     func() time.Time
+    logger  *logger.Logger
+}
+
+// NewArchiveService creates a new instance of archiveService
+func NewArchiveService(files repository.FileRepository, archive storage.ArchiveStorage,
+    opts ArchiveOptions) (ArchiveService, error) {
+    if files == nil || archive == nil {
+        return nil, errors.New("file repository and archive storage are required")
+    }
+    if opts.GlacierAfter <= 0 && opts.DeepArchiveAfter <= 0 {
+        return nil, errors.New("an idle period is required for GLACIER or DEEP_ARCHIVE")
+    }
+    if opts.BatchSize <= 0 || opts.RestoreDays <= 0 || opts.RestoreTier == "" {
+        return nil, errors.New("archive batch size, restore days and restore tier are required")
+    }
+
+    return &archiveService{
+        files:   files,
+        archive: archive,
+        opts:    opts,
+        metrics: newArchiveMetrics(opts.Metrics),
+        now:     time.Now,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// ArchiveIdle moves the least recently accessed idle files to archival
+// storage and returns how many were moved. Files that fail to move are
+// retried on later runs.
+func (s *archiveService) ArchiveIdle(ctx context.Context) (int, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    now := s.now().UTC()
+    files, err := s.files.ListIdle(ctx, now.Add(-s.idleAfter()), s.opts.BatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    archived := 0
+    for _, file := range files {
+        storageClass := s.storageClass(now.Sub(file.LastAccessedAt))
+        err := s.archive.Transition(ctx, file, storageClass)
+        if ctx.Err() != nil {
+            // Shutting down is not the file's failure
+            break
+        }
+        if err != nil {
+            log.Warn("Failed to archive file",
+                logger.String("fileId", file.ID),
+                logger.String("storageClass", storageClass),
+                logger.Error(err))
+            continue
+        }
+
+        file.MarkArchived(storageClass, now)
+        if err := s.files.UpdateArchive(ctx, file); err != nil && !errors.Is(err, repository.ErrNotFound) {
+            log.Warn("Failed to record archive status",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+            continue
+        }
+        s.metrics.transitions.Inc(storageClass)
+        archived++
+    }
+
+    if archived > 0 {
+        log.Info("Archived idle files",
+            logger.Int("count", archived))
+    }
+    return archived, nil
+}
+
+// idleAfter returns the shortest idle period after which files are archived
+func (s *archiveService) idleAfter() time.Duration {
+    if s.opts.GlacierAfter > 0 {
+        return s.opts.GlacierAfter
+    }
+    return s.opts.DeepArchiveAfter
+}
+
+// storageClass returns the coldest storage class whose idle period a file
+// idle for idle has reached
+func (s *archiveService) storageClass(idle time.Duration) string {
+    if s.opts.DeepArchiveAfter > 0 && idle >= s.opts.DeepArchiveAfter {
+        return models.StorageClassDeepArchive
+    }
+    return models.StorageClassGlacier
+}
+
+// Restore requests a readable copy of an archived file and returns the file.
+// Files that are readable, or already being restored, are returned as they are.
+func (s *archiveService) Restore(ctx context.Context, fileID string) (*models.File, error) {
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    file, err := s.files.GetByID(ctx, fileID)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, ErrFileNotFound
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if !file.IsUploaded() {
+        return nil, ErrFileNotFound
+    }
+    if !file.NeedsRestore(s.now()) || file.ArchiveStatus == models.ArchiveStatusRestoring {
+        return file, nil
+    }
+
+    if err := s.archive.Restore(ctx, file, s.opts.RestoreDays, s.opts.RestoreTier); err != nil {
+        s.logger.Error("Failed to request restore",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    file.MarkRestoring()
+    if err := s.files.UpdateArchive(ctx, file); err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, ErrFileNotFound
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    s.metrics.restores.Inc(restoreEventRequested)
+
+    s.logger.Info("Requested restore",
+        logger.String("fileId", file.ID),
+        logger.String("storageClass", file.StorageClass),
+        logger.String("tier", s.opts.RestoreTier))
+    return file, nil
+}
+
+// PollRestores checks the restores in progress and the restored copies that
+// expired, recording completed restores as restored and expired copies as
+// archived again. It returns how many restores completed.
+func (s *archiveService) PollRestores(ctx context.Context) (int, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    now := s.now().UTC()
+    files, err := s.files.ListPendingRestores(ctx, now, s.opts.BatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    restored := 0
+    for _, file := range files {
+        status, err := s.archive.RestoreStatus(ctx, file)
+        if ctx.Err() != nil {
+            break
+        }
+        if err != nil {
+            log.Warn("Failed to read restore status",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+            continue
+        }
+
+        restoring := file.ArchiveStatus == models.ArchiveStatusRestoring
+        switch {
+        case status.Ongoing:
+            continue
+        case status.ExpiresAt != nil && now.Before(*status.ExpiresAt):
+            // A restored copy may also have been extended past its recorded expiry
+            file.MarkRestored(status.ExpiresAt)
+        default:
+            file.ExpireRestore()
+        }
+
+        if err := s.files.UpdateArchive(ctx, file); err != nil && !errors.Is(err, repository.ErrNotFound) {
+            log.Warn("Failed to record restore status",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+            continue
+        }
+        switch {
+        case file.ArchiveStatus == models.ArchiveStatusArchived:
+            s.metrics.restores.Inc(restoreEventExpired)
+        case restoring:
+            s.metrics.restores.Inc(restoreEventCompleted)
+            restored++
+        }
+    }
+
+    if restored > 0 {
+        log.Info("Restored archived files",
+            logger.Int("count", restored))
+    }
+    return restored, nil
+}
+
+// recordArchived marks a file archived when S3 reports its content archived
+// but the file does not, as for uploads deduplicated into content that was
+// archived with another file, so that it can be restored
+func (s *fileService) recordArchived(ctx context.Context, file *models.File) {
+    if file.ArchiveStatus == models.ArchiveStatusArchived || file.ArchiveStatus == models.ArchiveStatusRestoring {
+        return
+    }
+    file.ExpireRestore()
+    if err := s.repo.UpdateArchive(ctx, file); err != nil {
+        s.logger.Warn("Failed to record archive status",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+    }
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/tracing"
+    "src/backend/file-service/pkg/validator"
+)
+
+// ErrAsyncUploadsNotEnabled is returned for asynchronous uploads when they
+// are not configured
+var ErrAsyncUploadsNotEnabled = errors.New("asynchronous uploads are not enabled")
+
+// asyncUploadJob is the name of the background job processing staged content
+const asyncUploadJob = "async-upload"
+
+// staleUploadBatchSize bounds the number of stale uploads failed per sweep
+const staleUploadBatchSize = 100
+
+// WithAsyncUploads enables asynchronous uploads, whose content is staged in
+// staging and processed in the background. Uploads still pending after
+// timeout, such as those whose processing was cut short by a restart, are
+// failed by FailStaleUploads.
+func WithAsyncUploads(staging storage.StagingStorage, timeout time.Duration) Option {
+    return func(s *fileService) {
+        s.staging = staging
+        s.stagingTimeout = timeout
+    }
+}
+
+// UploadAsync stores the content of an upload in the staging area, records
+// a pending file and returns it, leaving the content to be validated,
+// scanned and stored in the background like a synchronous upload's. The
+// file becomes uploaded, or failed if the content is rejected; clients poll
+// UploadStatus until it is either. Checks that need no content, including
+// the quota, are made before anything is staged.
+func (s *fileService) UploadAsync(ctx context.Context, fileName string, contentType string,
+    size int64, reader io.Reader, opts UploadOptions) (*models.File, error) {
+    log := s.logger.With(
+        logger.String("fileName", fileName),
+        logger.String("contentType", contentType),
+        logger.Int64("size", size),
+    )
+
+    if s.staging == nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, ErrAsyncUploadsNotEnabled)
+    }
+    if err := validator.ValidateFileName(fileName); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    if reader == nil {
+        return nil, fmt.Errorf("%w: content reader is required", ErrInvalidInput)
+    }
+    if opts.Draft && s.drafts == nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, ErrDraftsNotEnabled)
+    }
+    // The key is not kept for the background processing
+    if hasCustomerKey(ctx) {
+        return nil, fmt.Errorf("%w: asynchronous uploads %v", ErrInvalidInput, ErrCustomerKeyNotSupported)
+    }
+    // The pending file is the one clients poll, so it cannot stand in for
+    // the file it would overwrite
+    strategy := opts.NameConflict
+    if strategy == "" {
+        strategy = s.nameConflict
+    }
+    if strategy == NameConflictOverwrite {
+        return nil, fmt.Errorf("%w: asynchronous uploads cannot overwrite files", ErrInvalidInput)
+    }
+
+    if err := s.uploadPolicy.Check(opts.Roles, contentType, size); err != nil {
+        log.Warn("Upload policy check failed",
+            logger.Strings("roles", opts.Roles),
+            logger.Error(err))
+        return nil, err
+    }
+    if err := s.tenants.CheckContentType(ctx, contentType); err != nil {
+        log.Warn("Tenant content type check failed", logger.Error(err))
+        return nil, err
+    }
+    var usage QuotaUsage
+    if s.quota != nil {
+        var err error
+        usage, err = s.quota.Check(ctx, opts.OwnerID, size)
+        if err != nil {
+            log.Warn("Storage quota check failed",
+                logger.String("ownerId", opts.OwnerID),
+                logger.Error(err))
+            return nil, err
+        }
+    }
+
+    // The pending file holds the name while its content is processed
+    fileName, _, err := s.resolveNameConflict(ctx, log, fileName, opts)
+    if err != nil {
+        return nil, err
+    }
+    file, err := models.NewFile(fileName, size, contentType)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    file.OwnerID = opts.OwnerID
+    if err := file.MoveTo(opts.Folder); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    if err := file.SetTags(opts.Tags); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    if err := s.staging.Stage(ctx, file, reader); err != nil {
+        if uploadInterrupted(ctx, err) {
+            log.Info("File upload interrupted by client",
+                logger.String("fileId", file.ID))
+            return nil, ErrUploadInterrupted
+        }
+        if errors.Is(err, storage.ErrTenantRequired) {
+            return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+        }
+        log.Error("Failed to stage upload",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if err := s.repo.Create(ctx, file); err != nil {
+        log.Error("Failed to persist pending file",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+        s.discardStaged(ctx, file.StoragePath)
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if s.quota != nil {
+        s.quota.Record(ctx, opts.OwnerID, usage, file.Size)
+    }
+
+    staged := *file
+    opts.staged = &staged
+    opts.NameConflict = NameConflictKeep
+    jobCtx, job := tracing.StartJob(context.WithoutCancel(ctx), asyncUploadJob)
+    done := telemetry.TrackJob(jobCtx, job.Name)
+    go func() {
+        err := s.processStaged(jobCtx, opts)
+        if err != nil {
+            s.logger.Warn("Asynchronous upload failed",
+                append(job.Fields(), logger.String("fileId", staged.ID), logger.Error(err))...)
+        }
+        done(err)
+    }()
+
+    log.Info("Upload staged for processing",
+        logger.String("fileId", file.ID))
+    return file, nil
+}
+
+// processStaged uploads the staged content of opts.staged as its content,
+// marking the file failed if it is rejected, and removes the staged copy
+func (s *fileService) processStaged(ctx context.Context, opts UploadOptions) error {
+    staged := opts.staged
+    defer s.discardStaged(ctx, staged.StoragePath)
+
+    content, err := s.staging.GetObject(ctx, staged.StoragePath)
+    if err == nil {
+        _, err = s.Upload(ctx, staged.FileName, staged.ContentType, staged.Size, content, opts)
+        content.Close()
+    }
+    if err != nil {
+        s.failStaged(ctx, staged.ID)
+    }
+    return err
+}
+
+// failStaged marks the file of an asynchronous upload failed, unless it is
+// no longer pending
+func (s *fileService) failStaged(ctx context.Context, fileID string) {
+    file, err := s.getFile(ctx, fileID)
+    if err == nil && file.Status == models.FileStatusPending {
+        if err = file.UpdateStatus(models.FileStatusFailed); err == nil {
+            err = s.updateFile(ctx, file)
+        }
+    }
+    if err != nil && !errors.Is(err, ErrFileNotFound) {
+        s.logger.Warn("Failed to mark asynchronous upload failed",
+            logger.String("fileId", fileID),
+            logger.Error(err))
+    }
+}
+
+// discardStaged removes staged content; leftovers are expired by the bucket
+// lifecycle rule
+func (s *fileService) discardStaged(ctx context.Context, key string) {
+    if err := s.staging.DeleteObject(ctx, key); err != nil {
+        s.logger.Warn("Failed to remove staged upload",
+            logger.String("storagePath", key),
+            logger.Error(err))
+    }
+}
+
+// UploadStatus returns the file an upload created in its current status,
+// for the uploader to poll after an asynchronous upload. Pending and failed
+// files of other owners are not revealed.
+func (s *fileService) UploadStatus(ctx context.Context, fileID, ownerID string) (*models.File, error) {
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return nil, err
+    }
+    if !file.IsUploaded() && file.OwnerID != ownerID {
+        return nil, ErrFileNotFound
+    }
+    return file, nil
+}
+
+// FailStaleUploads marks asynchronous uploads still pending after the
+// configured timeout failed, removes their staged content and returns how
+// many were failed. An upload whose processing outlives the timeout fails
+// to complete, and the content it stored is left to the reconciler.
+func (s *fileService) FailStaleUploads(ctx context.Context) (int, error) {
+    if s.staging == nil {
+        return 0, nil
+    }
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    files, err := s.repo.ListStaleStaged(ctx, time.Now().UTC().Add(-s.stagingTimeout), staleUploadBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    failed := 0
+    for _, file := range files {
+        if err := file.UpdateStatus(models.FileStatusFailed); err != nil {
+            continue
+        }
+        if err := s.updateFile(ctx, file); err != nil {
+            log.Warn("Failed to mark stale upload failed",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+            continue
+        }
+        s.discardStaged(ctx, file.StoragePath)
+        failed++
+    }
+
+    if failed > 0 {
+        log.Info("Failed stale asynchronous uploads", logger.Int("count", failed))
+    }
+    return failed, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+)
+
+// Attachment errors
+var (
+    ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+// AttachmentService links files to records owned by other services
+type AttachmentService interface {
+    Attach(ctx context.Context, entityType, entityID, fileID string) (*models.Attachment, error)
+    Detach(ctx context.Context, entityType, entityID, fileID string) error
+    ListFiles(ctx context.Context, entityType, entityID string, offset, limit int) ([]*models.File, int64, error)
+}
+
+// attachmentService implements AttachmentService
+type attachmentService struct {
+    attachments repository.AttachmentRepository
+    files       repository.FileRepository
+    logger      *logger.Logger
+}
+
+// NewAttachmentService creates a new instance of attachmentService
+func NewAttachmentService(attachments repository.AttachmentRepository, files repository.FileRepository) (AttachmentService, error) {
+    if attachments == nil || files == nil {
+        return nil, errors.New("attachment and file repositories are required")
+    }
+
+    return &attachmentService{
+        attachments: attachments,
+        files:       files,
+        logger:      logger.GetLogger(),
+    }, nil
+}
+
+// Attach links an uploaded file to an entity; attaching twice is a no-op
+func (s *attachmentService) Attach(ctx context.Context, entityType, entityID, fileID string) (*models.Attachment, error) {
+    attachment, err := models.NewAttachment(entityType, entityID, fileID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    file, err := s.files.GetByID(ctx, fileID)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, ErrFileNotFound
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    // Drafts may only be attached once committed
+    if !file.IsUploaded() {
+        return nil, ErrFileNotFound
+    }
+
+    if err := s.attachments.Attach(ctx, attachment); err != nil {
+        s.logger.Error("Failed to attach file",
+            logger.String("fileId", fileID),
+            logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    return attachment, nil
+}
+
+// Detach removes the link between a file and an entity; the file itself is kept
+func (s *attachmentService) Detach(ctx context.Context, entityType, entityID, fileID string) error {
+    if err := models.ValidateEntity(entityType, entityID); err != nil || fileID == "" {
+        return ErrInvalidInput
+    }
+
+    if err := s.attachments.Detach(ctx, entityType, entityID, fileID); err != nil {
+        if errors.Is(err, repository.ErrAttachmentNotFound) {
+            return ErrAttachmentNotFound
+        }
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return nil
+}
+
+// ListFiles returns a page of the files attached to an entity with the total count
+func (s *attachmentService) ListFiles(ctx context.Context, entityType, entityID string, offset, limit int) ([]*models.File, int64, error) {
+    if err := models.ValidateEntity(entityType, entityID); err != nil {
+        return nil, 0, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    if offset < 0 || limit <= 0 {
+        return nil, 0, ErrInvalidInput
+    }
+
+    files, total, err := s.attachments.ListFiles(ctx, entityType, entityID, offset, limit)
+    if err != nil {
+        return nil, 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return files, total, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+    "context"
+    "crypto/ed25519"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/auditlog"
+    "src/backend/file-service/pkg/logger"
+)
+
+const (
+    // auditSettleDelay holds back events recorded this recently from export,
+    // so inserts still committing are not skipped by the export cursor
+    auditSettleDelay = time.Minute
+    // maxAuditBatchesPerRun bounds the batches written per export run
+    maxAuditBatchesPerRun = 100
+)
+
+// AuditExportOptions configure the audit log exporter
+type AuditExportOptions struct {
+    // Prefix is the storage prefix batches are written under
+    Prefix string
+    // BatchSize is the largest number of events sealed into one batch
+    BatchSize int
+    // LockMode and Retention set the Object Lock retention of each batch
+    LockMode  string
+    Retention time.Duration
+}
+
+// AuditExporter writes recorded audit events to storage as signed,
+// hash-chained batches
+type AuditExporter interface {
+    Export(ctx context.Context) (int, error)
+}
+
+// auditExporter implements AuditExporter
+type auditExporter struct {
+    events  repository.AuditRepository
+    objects storage.LockedObjectStore
+    key     ed25519.PrivateKey
+    opts    AuditExportOptions
+    now     func() time.Time
+    logger  *logger.Logger
+}
+
+// NewAuditExporter creates a new instance of auditExporter signing batches
+// with key
+func NewAuditExporter(events repository.AuditRepository, objects storage.LockedObjectStore,
+    key ed25519.PrivateKey, opts AuditExportOptions) (AuditExporter, error) {
+    if events == nil || objects == nil {
+        return nil, errors.New("audit repository and object store are required")
+    }
+    if len(key) != ed25519.PrivateKeySize {
+        return nil, errors.New("audit signing key is required")
+    }
+    if opts.BatchSize <= 0 || opts.Retention <= 0 {
+        return nil, errors.New("invalid audit batch size or retention")
+    }
+
+    return &auditExporter{
+        events:  events,
+        objects: objects,
+        key:     key,
+        opts:    opts,
+        now:     time.Now,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// Export seals the events recorded since the last export into batches,
+// writes each under Object Lock and advances the checkpoint, returning the
+// number of events exported. A batch written but not checkpointed is sealed
+// again identically on the next run, so a retry never forks the chain.
+func (e *auditExporter) Export(ctx context.Context) (int, error) {
+    checkpoint, err := e.events.GetCheckpoint(ctx)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    exported := 0
+    for i := 0; i < maxAuditBatchesPerRun; i++ {
+        recorded, err := e.events.ListAfter(ctx, checkpoint.LastEventID, e.now().Add(-auditSettleDelay), e.opts.BatchSize)
+        if err != nil {
+            return exported, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        if len(recorded) == 0 {
+            break
+        }
+
+        next, err := e.exportBatch(ctx, checkpoint, recorded)
+        if err != nil {
+            return exported, err
+        }
+        checkpoint = next
+        exported += len(recorded)
+
+        if len(recorded) < e.opts.BatchSize {
+            break
+        }
+    }
+    return exported, nil
+}
+
+// exportBatch seals one batch after checkpoint, stores it and returns the
+// advanced checkpoint
+func (e *auditExporter) exportBatch(ctx context.Context, checkpoint *models.AuditCheckpoint,
+    recorded []*models.AuditEvent) (*models.AuditCheckpoint, error) {
+    events := make([]auditlog.Event, len(recorded))
+    for i, event := range recorded {
+        events[i] = auditlog.Event{
+            Type:   event.Type,
+            FileID: event.FileID,
+            Detail: event.Detail,
+            At:     event.OccurredAt,
+        }
+    }
+
+    head := auditlog.Head{Number: checkpoint.BatchNumber, LastSeq: checkpoint.LastSeq, Hash: checkpoint.BatchHash}
+    batch, err := auditlog.Seal(head, events, e.key)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    data, err := json.Marshal(batch)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    key := auditlog.ObjectKey(e.opts.Prefix, batch.Number)
+    retainUntil := e.now().Add(e.opts.Retention)
+    if err := e.objects.PutLockedObject(ctx, key, "application/json", data, e.opts.LockMode, retainUntil); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    next := &models.AuditCheckpoint{
+        LastEventID: recorded[len(recorded)-1].ID,
+        BatchNumber: batch.Number,
+        LastSeq:     batch.LastSeq,
+        BatchHash:   batch.Hash,
+        UpdatedAt:   e.now(),
+    }
+    if err := e.events.SaveCheckpoint(ctx, next); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    e.logger.Info("Exported audit batch",
+        logger.Int64("batch", batch.Number),
+        logger.Int64("firstSeq", batch.FirstSeq),
+        logger.Int64("lastSeq", batch.LastSeq),
+        logger.String("key", key))
+
+    return next, nil
+}
@@ -0,0 +1,507 @@
+package service
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrInvalidBackup is returned when a backup's files do not match its manifest
+var ErrInvalidBackup = errors.New("backup is invalid")
+
+// backupManifestFile describes a backup. It is written last, so a directory
+// holding it holds a complete backup.
+const backupManifestFile = "manifest.json"
+
+// backupObjectsPart names the object manifest among a backup's parts
+const backupObjectsPart = "objects"
+
+// BackupOptions configure taking a backup
+type BackupOptions struct {
+    // Dir is the directory the backup is written to, which must not hold a
+    // complete backup already. Parts left by a failed backup are replaced.
+    Dir string
+    // Verify checks each object listed as a restore would, reading the
+    // content of objects stored without a checksum
+    Verify bool
+    // BatchSize bounds the files read per query listing the objects
+    BatchSize int
+    // Checked, if set, is called with each object verified and the error
+    // it failed with, if any
+    Checked func(object *models.BackupObject, err error)
+}
+
+// BackupResult reports a backup taken
+type BackupResult struct {
+    Manifest *models.BackupManifest
+    // Verified counts the objects found intact, and Unchecked those
+    // encrypted with a customer key, which cannot be read
+    Verified  int
+    Unchecked int
+    // Failed maps the keys of objects that failed verification to the reason
+    Failed map[string]error
+}
+
+// RestoreOptions configure restoring a backup
+type RestoreOptions struct {
+    // Dir is the directory holding the backup
+    Dir string
+    // SourceBucket is the bucket objects missing or damaged in storage are
+    // copied from, such as a bucket of the environment backed up; without
+    // one they fail
+    SourceBucket string
+    // Checked, if set, is called with each object checked, whether it was
+    // copied and the error it failed with, if any
+    Checked func(object *models.BackupObject, copied bool, err error)
+}
+
+// RestoreResult reports what a restore found and did
+type RestoreResult struct {
+    Manifest *models.BackupManifest
+    // Verified counts the objects found intact in storage, Copied those
+    // copied from the source bucket and verified, and Unchecked those
+    // encrypted with a customer key, which cannot be read or copied
+    Verified  int
+    Copied    int
+    Unchecked int
+    // Failed maps the keys of objects that are missing or damaged to the
+    // reason. Metadata is only restored once no object fails.
+    Failed map[string]error
+    // Restored counts the rows inserted into each table, and Existing the
+    // rows already present, as when a restore is rerun
+    Restored map[string]int
+    Existing map[string]int
+}
+
+// BackupService takes backups of the file metadata and the objects it
+// references for disaster recovery, and restores them into a new
+// environment
+type BackupService interface {
+    // Backup dumps the metadata tables and lists the objects they reference
+    // from one consistent snapshot
+    Backup(ctx context.Context, opts BackupOptions) (*BackupResult, error)
+    // Restore verifies a backup against its manifest, checks every object
+    // it lists is in storage and intact, copying it from the source bucket
+    // if not, then inserts the metadata rows
+    Restore(ctx context.Context, opts RestoreOptions) (*RestoreResult, error)
+}
+
+// backupService implements BackupService
+type backupService struct {
+    backups repository.BackupRepository
+    files   repository.FileRepository
+    objects storage.BackupStorage
+    logger  *logger.Logger
+}
+
+// NewBackupService creates a new instance of backupService
+func NewBackupService(backups repository.BackupRepository, files repository.FileRepository,
+    objects storage.BackupStorage) (BackupService, error) {
+    if backups == nil || files == nil || objects == nil {
+        return nil, errors.New("backup repository, file repository and backup storage are required")
+    }
+
+    return &backupService{
+        backups: backups,
+        files:   files,
+        objects: objects,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// Backup writes a part per table and the object manifest to the backup
+// directory, all read in one snapshot, then the manifest describing them.
+// Objects are verified, when asked, while the snapshot is held.
+func (s *backupService) Backup(ctx context.Context, opts BackupOptions) (*BackupResult, error) {
+    if opts.Dir == "" {
+        return nil, errors.New("backup directory is required")
+    }
+    if opts.BatchSize <= 0 {
+        return nil, errors.New("backup batch size must be positive")
+    }
+    if _, err := os.Stat(filepath.Join(opts.Dir, backupManifestFile)); err == nil {
+        return nil, fmt.Errorf("%s already holds a backup", opts.Dir)
+    }
+    if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    manifest := &models.BackupManifest{
+        Version:   models.BackupFormatVersion,
+        CreatedAt: time.Now().UTC(),
+    }
+    result := &BackupResult{Manifest: manifest, Failed: make(map[string]error)}
+    err := s.backups.Snapshot(ctx, func(ctx context.Context) error {
+        for _, table := range repository.BackupTables {
+            part, err := writeBackupPart(opts.Dir, table, func(write func(line []byte) error) error {
+                return s.backups.DumpTable(ctx, table, func(row json.RawMessage) error {
+                    return write(row)
+                })
+            })
+            if err != nil {
+                return err
+            }
+            manifest.Tables = append(manifest.Tables, *part)
+        }
+
+        part, err := writeBackupPart(opts.Dir, backupObjectsPart, func(write func(line []byte) error) error {
+            list := func(object *models.BackupObject) error {
+                if opts.Verify {
+                    s.verifyForBackup(ctx, object, result, opts.Checked)
+                }
+                line, err := json.Marshal(object)
+                if err != nil {
+                    return err
+                }
+                return write(line)
+            }
+            if err := s.listObjects(ctx, opts.BatchSize, list); err != nil {
+                return err
+            }
+            return s.listDerivedObjects(ctx, list)
+        })
+        if err != nil {
+            return err
+        }
+        manifest.Objects = *part
+        return nil
+    })
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    encoded, err := json.MarshalIndent(manifest, "", "  ")
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if err := os.WriteFile(filepath.Join(opts.Dir, backupManifestFile), append(encoded, '\n'), 0o600); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    s.logger.Info("Backup written",
+        logger.String("dir", opts.Dir),
+        logger.Int64("objects", manifest.Objects.Rows),
+        logger.Int("verified", result.Verified),
+        logger.Int("failed", len(result.Failed)))
+    return result, nil
+}
+
+// listObjects calls fn with the object of each file's content, once per
+// object for content shared by several files. Deleted files are dumped but
+// their content, archived or gone, is not listed.
+func (s *backupService) listObjects(ctx context.Context, batchSize int, fn func(object *models.BackupObject) error) error {
+    afterPath, afterID := "", ""
+    for {
+        page, err := s.files.ListStoragePaths(ctx, afterPath, afterID, batchSize)
+        if err != nil {
+            return err
+        }
+        if len(page) == 0 {
+            return nil
+        }
+
+        for _, file := range page {
+            if file.StoragePath == afterPath {
+                continue
+            }
+            afterPath = file.StoragePath
+            if err := fn(models.NewBackupObject(file)); err != nil {
+                return err
+            }
+        }
+        afterID = page[len(page)-1].ID
+    }
+}
+
+// listDerivedObjects calls fn with the object of each ready derived object,
+// so the derived_objects rows restored never reference missing content.
+// Derived content is stored without a checksum, so only its size is checked.
+func (s *backupService) listDerivedObjects(ctx context.Context, fn func(object *models.BackupObject) error) error {
+    return s.backups.DumpTable(ctx, "derived_objects", func(row json.RawMessage) error {
+        var derived struct {
+            StorageKey string `json:"storage_key"`
+            Size       int64  `json:"size"`
+            Status     string `json:"status"`
+        }
+        if err := json.Unmarshal(row, &derived); err != nil {
+            return fmt.Errorf("failed to read derived object: %w", err)
+        }
+        if derived.Status != models.DerivedStatusReady || derived.StorageKey == "" {
+            return nil
+        }
+        return fn(&models.BackupObject{
+            Key:   derived.StorageKey,
+            Size:  derived.Size,
+            Check: models.BackupCheckSize,
+        })
+    })
+}
+
+// verifyForBackup verifies an object listed in a backup and tallies the result
+func (s *backupService) verifyForBackup(ctx context.Context, object *models.BackupObject, result *BackupResult,
+    checked func(object *models.BackupObject, err error)) {
+    if object.Check == models.BackupCheckNone {
+        result.Unchecked++
+        return
+    }
+    err := s.checkObject(ctx, object)
+    if err != nil {
+        result.Failed[object.Key] = err
+    } else {
+        result.Verified++
+    }
+    if checked != nil {
+        checked(object, err)
+    }
+}
+
+// Restore verifies every part of the backup before changing anything, then
+// the objects, and inserts the metadata rows only once every object is in
+// storage and intact, so files never reference missing content. Both steps
+// skip what is already restored, so a restore can be rerun.
+func (s *backupService) Restore(ctx context.Context, opts RestoreOptions) (*RestoreResult, error) {
+    if opts.Dir == "" {
+        return nil, errors.New("backup directory is required")
+    }
+    manifest, err := readBackupManifest(opts.Dir)
+    if err != nil {
+        return nil, err
+    }
+    for _, part := range append([]models.BackupPart{manifest.Objects}, manifest.Tables...) {
+        if err := readBackupPart(opts.Dir, part, func(line []byte) error { return nil }); err != nil {
+            return nil, err
+        }
+    }
+
+    result := &RestoreResult{
+        Manifest: manifest,
+        Failed:   make(map[string]error),
+        Restored: make(map[string]int),
+        Existing: make(map[string]int),
+    }
+    err = readBackupPart(opts.Dir, manifest.Objects, func(line []byte) error {
+        if err := ctx.Err(); err != nil {
+            return err
+        }
+        object := &models.BackupObject{}
+        if err := json.Unmarshal(line, object); err != nil {
+            return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+        }
+        if object.Check == models.BackupCheckNone {
+            result.Unchecked++
+            return nil
+        }
+
+        copied, err := s.restoreObject(ctx, object, opts.SourceBucket)
+        switch {
+        case err != nil:
+            result.Failed[object.Key] = err
+        case copied:
+            result.Copied++
+        default:
+            result.Verified++
+        }
+        if opts.Checked != nil {
+            opts.Checked(object, copied, err)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+    if len(result.Failed) > 0 {
+        return result, nil
+    }
+
+    for _, part := range manifest.Tables {
+        err := readBackupPart(opts.Dir, part, func(line []byte) error {
+            inserted, err := s.backups.RestoreRow(ctx, part.Name, line)
+            if err != nil {
+                return err
+            }
+            if inserted {
+                result.Restored[part.Name]++
+            } else {
+                result.Existing[part.Name]++
+            }
+            return nil
+        })
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+    }
+
+    s.logger.Info("Backup restored",
+        logger.String("dir", opts.Dir),
+        logger.Time("backupCreatedAt", manifest.CreatedAt),
+        logger.Int("verified", result.Verified),
+        logger.Int("copied", result.Copied),
+        logger.Int("unchecked", result.Unchecked))
+    return result, nil
+}
+
+// restoreObject checks the object is in storage and intact, copying it from
+// source if it is not, and reports whether it was copied
+func (s *backupService) restoreObject(ctx context.Context, object *models.BackupObject, source string) (bool, error) {
+    err := s.checkObject(ctx, object)
+    if err == nil || source == "" {
+        return false, err
+    }
+    if copyErr := s.objects.CopyObjectFrom(ctx, source, object.Key); copyErr != nil {
+        return false, fmt.Errorf("%v, and copying it from %s failed: %w", err, source, copyErr)
+    }
+    return true, s.checkObject(ctx, object)
+}
+
+// checkObject compares the object in storage with its size and checksum.
+// The checksum S3 recorded is compared when it is comparable; otherwise
+// content with a SHA-256 is read back and hashed. Content uploaded in parts
+// whose object has no comparable checksum is only checked for its size.
+func (s *backupService) checkObject(ctx context.Context, object *models.BackupObject) error {
+    stored, err := s.objects.StatObject(ctx, object.Key)
+    if err != nil {
+        return err
+    }
+    if stored.Size != object.Size {
+        return fmt.Errorf("%w: object is %d bytes, not %d", ErrInvalidChecksum, stored.Size, object.Size)
+    }
+    if object.Check != models.BackupCheckChecksum {
+        return nil
+    }
+
+    composite := strings.Contains(object.Checksum, "-")
+    actual := stored.Checksum
+    if actual == "" || strings.Contains(actual, "-") != composite {
+        if composite {
+            return nil
+        }
+        if actual, err = s.readChecksum(ctx, object.Key); err != nil {
+            return err
+        }
+    }
+    if actual != object.Checksum {
+        return fmt.Errorf("%w: object's checksum is %s, not %s", ErrInvalidChecksum, actual, object.Checksum)
+    }
+    return nil
+}
+
+// readChecksum returns the SHA-256 of the object's content
+func (s *backupService) readChecksum(ctx context.Context, key string) (string, error) {
+    body, err := s.objects.GetObject(ctx, key)
+    if err != nil {
+        return "", err
+    }
+    defer body.Close()
+    hash := sha256.New()
+    if _, err := io.Copy(hash, body); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// writeBackupPart writes the lines fill writes to the part's file, replacing
+// any left by a failed backup, and returns the part with its row count and
+// checksum
+func writeBackupPart(dir, name string, fill func(write func(line []byte) error) error) (*models.BackupPart, error) {
+    part := &models.BackupPart{Name: name, File: name + ".jsonl"}
+    file, err := os.OpenFile(filepath.Join(dir, part.File), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+    if err != nil {
+        return nil, err
+    }
+
+    hash := sha256.New()
+    writer := bufio.NewWriter(io.MultiWriter(file, hash))
+    err = fill(func(line []byte) error {
+        part.Rows++
+        if _, err := writer.Write(line); err != nil {
+            return err
+        }
+        return writer.WriteByte('\n')
+    })
+    if err == nil {
+        err = writer.Flush()
+    }
+    if err == nil {
+        err = file.Sync()
+    }
+    if closeErr := file.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to write %s: %w", part.File, err)
+    }
+
+    part.Checksum = hex.EncodeToString(hash.Sum(nil))
+    return part, nil
+}
+
+// readBackupManifest reads the manifest of the backup in dir
+func readBackupManifest(dir string) (*models.BackupManifest, error) {
+    encoded, err := os.ReadFile(filepath.Join(dir, backupManifestFile))
+    if errors.Is(err, os.ErrNotExist) {
+        return nil, fmt.Errorf("%w: %s holds no complete backup", ErrInvalidBackup, dir)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    manifest := &models.BackupManifest{}
+    if err := json.Unmarshal(encoded, manifest); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+    }
+    if manifest.Version != models.BackupFormatVersion {
+        return nil, fmt.Errorf("%w: unsupported backup version %d", ErrInvalidBackup, manifest.Version)
+    }
+    return manifest, nil
+}
+
+// readBackupPart calls fn with each line of the part's file, failing with
+// ErrInvalidBackup once the file is read if it does not hold the rows and
+// checksum the manifest records
+func readBackupPart(dir string, part models.BackupPart, fn func(line []byte) error) error {
+    file, err := os.Open(filepath.Join(dir, filepath.Base(part.File)))
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+    }
+    defer file.Close()
+
+    hash := sha256.New()
+    reader := bufio.NewReader(io.TeeReader(file, hash))
+    var rows int64
+    for {
+        line, err := reader.ReadBytes('\n')
+        if err == io.EOF {
+            if len(line) > 0 {
+                return fmt.Errorf("%w: %s ends in a partial line", ErrInvalidBackup, part.File)
+            }
+            break
+        }
+        if err != nil {
+            return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        rows++
+        if err := fn(bytes.TrimSuffix(line, []byte{'\n'})); err != nil {
+            return err
+        }
+    }
+
+    if rows != part.Rows || hex.EncodeToString(hash.Sum(nil)) != part.Checksum {
+        return fmt.Errorf("%w: %s does not match its checksum", ErrInvalidBackup, part.File)
+    }
+    return nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// blobCollectBatchSize bounds the number of blobs collected per run
+const blobCollectBatchSize = 100
+
+// WithBlobs deduplicates uploads by content. Files with the same content in
+// the same tenant scope share one reference counted blob, whose object is
+// deleted through objects once it has been unreferenced for the grace
+// period. The grace period also keeps the content of deleted files
+// recoverable.
+func WithBlobs(blobs repository.BlobRepository, objects storage.ObjectStore, grace time.Duration) Option {
+    return func(s *fileService) {
+        s.blobs = blobs
+        s.blobObjects = objects
+        s.blobGrace = grace
+    }
+}
+
+// WithSharedUploads skips storing uploads whose declared checksum matches a
+// blob that is already stored; the content is still read and verified.
+// It only takes effect together with WithBlobs.
+func WithSharedUploads(content storage.SharedContentStorage) Option {
+    return func(s *fileService) {
+        s.sharedContent = content
+    }
+}
+
+// shareStoredContent points the file at the blob holding the content the
+// upload declared by checksum, if one is stored in the upload's tenant
+// scope, and reports whether it did. Blobs without references are left
+// alone, as they may be collected before the upload completes.
+func (s *fileService) shareStoredContent(ctx context.Context, log *logger.Logger, file *models.File, opts UploadOptions) bool {
+    if s.blobs == nil || s.sharedContent == nil || opts.Checksum == "" || file.IsDraft() || hasCustomerKey(ctx) {
+        return false
+    }
+
+    // The upload fails on its own if its tenant is missing
+    tenantID, err := s.sharedContent.ShareScope(ctx)
+    if err != nil {
+        return false
+    }
+    blob, err := s.blobs.Lookup(ctx, tenantID, opts.Checksum, file.Size)
+    if err != nil {
+        if !errors.Is(err, repository.ErrBlobNotFound) {
+            log.Warn("Failed to look up content blob",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+        }
+        return false
+    }
+    if blob.RefCount == 0 {
+        return false
+    }
+
+    shared, err := s.sharedContent.ShareObject(ctx, file, blob.StorageKey)
+    if err != nil {
+        log.Warn("Failed to share stored content",
+            logger.String("fileId", file.ID),
+            logger.String("storagePath", blob.StorageKey),
+            logger.Error(err))
+        return false
+    }
+    if shared {
+        log.Info("Sharing stored content with upload",
+            logger.String("fileId", file.ID),
+            logger.String("storagePath", blob.StorageKey))
+    }
+    return shared
+}
+
+// acquireBlob adds a reference to the blob holding the file's content. When
+// the content is already stored in the tenant scope of the file's key, the
+// file is pointed at the existing blob and its own copy is deleted, so
+// tenants never reference each other's objects. Retained content keeps its
+// own object, which carries the retention.
+func (s *fileService) acquireBlob(ctx context.Context, file *models.File) error {
+    if s.blobs == nil || file.IsRetained(time.Now()) {
+        return nil
+    }
+
+    blob, err := s.blobs.Acquire(ctx, &models.Blob{
+        StorageKey: file.StoragePath,
+        TenantID:   storagekey.Default.Tenant(file.StoragePath),
+        Checksum:   file.Checksum,
+        Size:       file.Size,
+    })
+    if err != nil {
+        return err
+    }
+    if blob.StorageKey == file.StoragePath {
+        return nil
+    }
+
+    duplicate := file.StoragePath
+    if err := file.SetStoragePath(blob.StorageKey); err != nil {
+        return err
+    }
+    if err := s.blobObjects.DeleteObject(ctx, duplicate); err != nil {
+        s.logger.Warn("Failed to delete duplicate upload",
+            logger.String("fileId", file.ID),
+            logger.String("storagePath", duplicate),
+            logger.Error(err))
+    }
+
+    s.logger.Info("Deduplicated upload",
+        logger.String("fileId", file.ID),
+        logger.String("storagePath", blob.StorageKey),
+        logger.Int("refCount", blob.RefCount))
+    return nil
+}
+
+// releaseBlob drops the file's reference to its blob. It reports false for
+// content stored before deduplication, which the caller deletes directly.
+func (s *fileService) releaseBlob(ctx context.Context, file *models.File) (bool, error) {
+    if s.blobs == nil {
+        return false, nil
+    }
+
+    err := s.blobs.Release(ctx, file.StoragePath, time.Now().UTC())
+    if errors.Is(err, repository.ErrBlobNotFound) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// CollectBlobs deletes the objects of blobs that have been unreferenced for
+// longer than the grace period and returns how many were removed
+func (s *fileService) CollectBlobs(ctx context.Context) (int, error) {
+    if s.blobs == nil {
+        return 0, nil
+    }
+
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    cutoff := time.Now().UTC().Add(-s.blobGrace)
+    blobs, err := s.blobs.ListUnreferenced(ctx, cutoff, blobCollectBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    collected := 0
+    for _, blob := range blobs {
+        // Remove the record first, so an upload of the same content racing
+        // the collector either revives the blob or stores a fresh copy
+        removed, err := s.blobs.Remove(ctx, blob.StorageKey, cutoff)
+        if err != nil {
+            log.Warn("Failed to remove unreferenced blob",
+                logger.String("storagePath", blob.StorageKey),
+                logger.Error(err))
+            continue
+        }
+        if !removed {
+            continue
+        }
+
+        if err := s.blobObjects.DeleteObject(ctx, blob.StorageKey); err != nil {
+            log.Warn("Failed to delete unreferenced blob object",
+                logger.String("storagePath", blob.StorageKey),
+                logger.Error(err))
+            continue
+        }
+        collected++
+    }
+
+    if collected > 0 {
+        log.Info("Collected unreferenced blobs", logger.Int("count", collected))
+    }
+    return collected, nil
+}
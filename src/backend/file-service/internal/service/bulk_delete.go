@@ -0,0 +1,179 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+)
+
+// MaxBulkDelete bounds the number of files one bulk delete removes
+const MaxBulkDelete = 10000
+
+// BulkDeleteResult reports the outcome of a bulk delete per file
+type BulkDeleteResult struct {
+    // Deleted lists the files deleted, including those already deleted
+    Deleted []string
+    // Failed maps the files that were not deleted to the reason
+    Failed map[string]error
+}
+
+// WithBatchDelete removes the content of files hard deleted in bulk with
+// one storage request per storage.MaxDeleteBatch files. Without it bulk
+// deletes remove each file on its own.
+func WithBatchDelete(objects storage.BatchDeleteStorage) Option {
+    return func(s *fileService) {
+        s.batchDelete = objects
+    }
+}
+
+// BulkDelete deletes the given files and reports the outcome per file;
+// callers authorize each file beforehand. Soft deletes archive each file's
+// content and so go file by file, while hard deletes remove content in
+// batches. A failing file does not stop the others.
+func (s *fileService) BulkDelete(ctx context.Context, fileIDs []string, softDelete bool) (*BulkDeleteResult, error) {
+    if len(fileIDs) == 0 || len(fileIDs) > MaxBulkDelete {
+        return nil, fmt.Errorf("%w: between 1 and %d file IDs are required", ErrInvalidInput, MaxBulkDelete)
+    }
+
+    result := &BulkDeleteResult{Failed: make(map[string]error)}
+    seen := make(map[string]bool, len(fileIDs))
+    var files []*models.File
+    for _, fileID := range fileIDs {
+        if seen[fileID] {
+            continue
+        }
+        seen[fileID] = true
+
+        if softDelete || s.batchDelete == nil {
+            if err := s.Delete(ctx, fileID, softDelete); err != nil {
+                result.Failed[fileID] = err
+                continue
+            }
+            result.Deleted = append(result.Deleted, fileID)
+            continue
+        }
+
+        file, err := s.hardDeletable(ctx, fileID)
+        switch {
+        case err != nil:
+            result.Failed[fileID] = err
+        case file == nil:
+            result.Deleted = append(result.Deleted, fileID)
+        default:
+            files = append(files, file)
+        }
+    }
+
+    for start := 0; start < len(files); start += storage.MaxDeleteBatch {
+        s.deleteBatch(ctx, files[start:min(start+storage.MaxDeleteBatch, len(files))], result)
+    }
+
+    s.logger.Info("Bulk delete completed",
+        logger.Int("deleted", len(result.Deleted)),
+        logger.Int("failed", len(result.Failed)),
+        logger.Bool("softDelete", softDelete))
+    return result, nil
+}
+
+// hardDeletable loads a file to hard delete, returning nil for files that
+// are already deleted
+func (s *fileService) hardDeletable(ctx context.Context, fileID string) (*models.File, error) {
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return nil, err
+    }
+    if file.IsDeleted() {
+        return nil, nil
+    }
+    // Retained content may be archived, but not removed
+    if file.IsRetained(time.Now()) {
+        return nil, ErrFileRetained
+    }
+    return file, nil
+}
+
+// deleteBatch hard deletes up to storage.MaxDeleteBatch files in one unit of
+// work: their blob references are released, the content no blob holds is
+// deleted in one request, and the files whose content is gone are marked
+// deleted. Files whose content could not be deleted are left untouched.
+func (s *fileService) deleteBatch(ctx context.Context, files []*models.File, result *BulkDeleteResult) {
+    var deleted []*models.File
+    failed := make(map[string]error)
+    err := s.withTx(ctx, func(ctx context.Context) error {
+        // Shared content is left to the blob collector once unreferenced
+        owners := make(map[string][]*models.File)
+        var keys []string
+        for _, file := range files {
+            released, err := s.releaseBlob(ctx, file)
+            if err != nil {
+                return err
+            }
+            if released {
+                deleted = append(deleted, file)
+                continue
+            }
+            if _, ok := owners[file.StoragePath]; !ok {
+                keys = append(keys, file.StoragePath)
+            }
+            owners[file.StoragePath] = append(owners[file.StoragePath], file)
+        }
+
+        if len(keys) > 0 {
+            failedKeys := s.batchDelete.DeleteObjects(ctx, keys)
+            for _, key := range keys {
+                for _, file := range owners[key] {
+                    if err, ok := failedKeys[key]; ok {
+                        failed[file.ID] = fmt.Errorf("%w: %v", ErrOperationFailed, err)
+                        continue
+                    }
+                    deleted = append(deleted, file)
+                }
+            }
+        }
+
+        for _, file := range deleted {
+            err := s.repo.Delete(ctx, file.ID)
+            if err != nil && !errors.Is(err, repository.ErrNotFound) {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        s.logger.Error("Failed to delete batch of files",
+            logger.Int("count", len(files)),
+            logger.Error(err))
+        for _, file := range files {
+            result.Failed[file.ID] = fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        return
+    }
+
+    for fileID, err := range failed {
+        s.logger.Warn("Failed to delete file content",
+            logger.String("fileId", fileID),
+            logger.Error(err))
+        result.Failed[fileID] = err
+    }
+    for _, file := range deleted {
+        result.Deleted = append(result.Deleted, file.ID)
+        // Derived objects are regenerated on demand, so failing to discard
+        // them only leaves orphaned copies behind
+        if s.derived != nil {
+            if err := s.derived.Invalidate(ctx, file.ID); err != nil {
+                s.logger.Warn("Failed to invalidate derived objects",
+                    logger.String("fileId", file.ID),
+                    logger.Error(err))
+            }
+        }
+    }
+}
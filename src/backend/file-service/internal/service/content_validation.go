@@ -0,0 +1,84 @@
+package service
+
+import (
+    "context"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/validator"
+)
+
+// contentMetrics are the instruments of streaming content validation
+type contentMetrics struct {
+    // verdicts counts content check outcomes by check and verdict
+    verdicts metrics.Counter
+}
+
+// newContentMetrics creates the instruments of streaming content validation
+func newContentMetrics(provider metrics.Provider) contentMetrics {
+    provider = metrics.OrNop(provider)
+    return contentMetrics{
+        verdicts: provider.Counter(metrics.Opts{
+            Name:   "content_validation_verdicts_total",
+            Help:   "Uploaded content checked by check and verdict",
+            Labels: []string{"check", "verdict"},
+        }),
+    }
+}
+
+// WithContentValidation inspects uploaded content as it streams to storage:
+// its magic number against the declared content type, malware signatures,
+// and archives for path traversal and decompression bombs. Content a check
+// rejects or cannot decide is rejected unless the policy fails open.
+func WithContentValidation(policy validator.ContentPolicy, provider metrics.Provider) Option {
+    return func(s *fileService) {
+        s.contentPolicy = &policy
+        s.contentMetrics = newContentMetrics(provider)
+    }
+}
+
+// contentScanner returns a scanner for the uploaded content, or nil when
+// content validation is disabled
+func (s *fileService) contentScanner(contentType string) *validator.ContentScanner {
+    if s.contentPolicy == nil || len(s.contentPolicy.Checks) == 0 {
+        return nil
+    }
+    return validator.NewContentScanner(*s.contentPolicy, contentType)
+}
+
+// checkContent records the verdicts of the content checks once the upload
+// ended, after a failed upload only those that decided. Findings the policy
+// accepts are logged. Rejections wrap both ErrInvalidInput and the
+// ValidationError so handlers can report the structured code; content that
+// was already stored is deleted.
+func (s *fileService) checkContent(ctx context.Context, log *logger.Logger, scanner *validator.ContentScanner,
+    file *models.File, stored bool) error {
+    verdicts, err := scanner.Finish()
+    for _, verdict := range verdicts {
+        s.contentMetrics.verdicts.Inc(verdict.Check, verdict.Result)
+        if verdict.Finding != nil && err == nil {
+            log.Warn("File content flagged",
+                logger.String("fileId", file.ID),
+                logger.String("check", verdict.Check),
+                logger.String("code", verdict.Finding.Code),
+                logger.String("reason", verdict.Finding.Message))
+        }
+    }
+    if err == nil {
+        return nil
+    }
+
+    log.Warn("File content rejected",
+        logger.String("fileId", file.ID),
+        logger.Error(err))
+    if stored {
+        if deleteErr := s.storage.Delete(ctx, file, false); deleteErr != nil {
+            log.Warn("Failed to delete rejected content",
+                logger.String("fileId", file.ID),
+                logger.Error(deleteErr))
+        }
+    }
+    return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+    "context"
+    "errors"
+
+    "src/backend/file-service/internal/storage"
+)
+
+// Customer-provided key errors
+var (
+    // ErrCustomerKeyRequired is returned when the content of a file encrypted
+    // with a customer-provided key is read without the key
+    ErrCustomerKeyRequired = errors.New("file is encrypted with a customer-provided key")
+    // ErrCustomerKeyMismatch is returned when the key presented is not the
+    // one the file was encrypted with
+    ErrCustomerKeyMismatch = errors.New("customer-provided key does not match the file's key")
+    // ErrCustomerKeyNotSupported is returned for operations that need the
+    // service to read content it holds no key for
+    ErrCustomerKeyNotSupported = errors.New("operation is not supported with customer-provided keys")
+)
+
+// customerKeyError maps storage errors about customer-provided keys to the
+// service errors, returning nil for any other error
+func customerKeyError(err error) error {
+    switch {
+    case errors.Is(err, storage.ErrCustomerKeyRequired):
+        return ErrCustomerKeyRequired
+    case errors.Is(err, storage.ErrCustomerKeyMismatch):
+        return ErrCustomerKeyMismatch
+    }
+    return nil
+}
+
+// hasCustomerKey checks if the uploads of ctx are encrypted with a
+// customer-provided key
+func hasCustomerKey(ctx context.Context) bool {
+    return storage.CustomerKeyFromContext(ctx) != nil
+}
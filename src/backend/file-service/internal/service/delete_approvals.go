@@ -0,0 +1,277 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// Delete approval errors
+var (
+    ErrPendingActionNotFound = errors.New("pending action not found")
+    ErrPendingActionClosed   = errors.New("pending action is no longer awaiting approval")
+    ErrApprovalNotPermitted  = errors.New("approval not permitted")
+)
+
+// DeleteApprovalOptions configures delete approvals. Hard deletes in
+// RegulatedTenants are held until approved; unapproved requests expire
+// after TTL.
+type DeleteApprovalOptions struct {
+    RegulatedTenants []string
+    TTL              time.Duration
+}
+
+// DeleteApprovals holds hard deletes in regulated tenants until two admins
+// other than the requester approve them. Every request, approval, execution
+// and expiry is recorded in the audit log.
+type DeleteApprovals interface {
+    Required(ctx context.Context) bool
+    Request(ctx context.Context, fileID, requestedBy string) (*models.PendingAction, error)
+    Approve(ctx context.Context, actionID, approverID string) (*models.PendingAction, error)
+    Get(ctx context.Context, actionID string) (*models.PendingAction, error)
+    List(ctx context.Context) ([]*models.PendingAction, error)
+    Expire(ctx context.Context) (int, error)
+}
+
+// deleteApprovals implements DeleteApprovals
+type deleteApprovals struct {
+    actions   repository.PendingActionRepository
+    audit     repository.AuditRepository
+    files     FileService
+    tx        repository.TxManager
+    tenantOf  TenantResolver
+    regulated map[string]bool
+    ttl       time.Duration
+    now       func() time.Time
+    logger    *logger.Logger
+}
+
+// NewDeleteApprovals creates a new instance of deleteApprovals. tx may be
+// nil, in which case an action's state and its audit event are written
+// separately rather than as one unit of work.
+func NewDeleteApprovals(actions repository.PendingActionRepository, audit repository.AuditRepository,
+    files FileService, tx repository.TxManager, tenantOf TenantResolver, opts DeleteApprovalOptions) (DeleteApprovals, error) {
+    if actions == nil || audit == nil || files == nil || tenantOf == nil {
+        return nil, errors.New("pending action repository, audit repository, file service and tenant resolver are required")
+    }
+    if opts.TTL <= 0 {
+        return nil, errors.New("delete approval TTL must be positive")
+    }
+
+    regulated := make(map[string]bool, len(opts.RegulatedTenants))
+    for _, tenantID := range opts.RegulatedTenants {
+        regulated[tenantID] = true
+    }
+
+    return &deleteApprovals{
+        actions:   actions,
+        audit:     audit,
+        files:     files,
+        tx:        tx,
+        tenantOf:  tenantOf,
+        regulated: regulated,
+        ttl:       opts.TTL,
+        now:       time.Now,
+        logger:    logger.GetLogger(),
+    }, nil
+}
+
+// Required checks if hard deletes by the caller's tenant need approval
+func (s *deleteApprovals) Required(ctx context.Context) bool {
+    tenantID := s.tenantOf(ctx)
+    return tenantID != "" && s.regulated[tenantID]
+}
+
+// Request queues a hard delete of a file for approval. A file with a
+// request already awaiting approval returns that request.
+func (s *deleteApprovals) Request(ctx context.Context, fileID, requestedBy string) (*models.PendingAction, error) {
+    if fileID == "" || requestedBy == "" {
+        return nil, ErrInvalidInput
+    }
+    file, err := s.files.Stat(ctx, fileID)
+    if err != nil {
+        return nil, err
+    }
+    if file.IsRetained(s.now()) {
+        return nil, ErrFileRetained
+    }
+
+    existing, err := s.actions.FindPending(ctx, models.PendingHardDelete, fileID, s.now())
+    if err == nil {
+        return existing, nil
+    }
+    if !errors.Is(err, repository.ErrPendingActionNotFound) {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    action, err := models.NewPendingAction(models.PendingHardDelete, fileID, s.tenantOf(ctx), requestedBy, s.ttl)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    err = s.withTx(ctx, func(ctx context.Context) error {
+        if err := s.actions.Create(ctx, action); err != nil {
+            return err
+        }
+        return s.audit.Append(ctx, models.NewPendingActionAuditEvent(
+            models.AuditActionRequested, action, requestedBy, action.CreatedAt))
+    })
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    s.logger.Info("Hard delete awaiting approval",
+        logger.String("actionId", action.ID),
+        logger.String("fileId", fileID),
+        logger.String("requestedBy", requestedBy),
+        logger.Time("expiresAt", action.ExpiresAt))
+    return action, nil
+}
+
+// Approve records an admin's approval of a pending action, executing the
+// action once it has RequiredApprovals. A failed execution closes the
+// action as failed; the delete must then be requested again.
+func (s *deleteApprovals) Approve(ctx context.Context, actionID, approverID string) (*models.PendingAction, error) {
+    if actionID == "" || approverID == "" {
+        return nil, ErrInvalidInput
+    }
+    log := s.logger.With(
+        logger.String("actionId", actionID),
+        logger.String("approverId", approverID),
+    )
+
+    var action *models.PendingAction
+    err := s.withTx(ctx, func(ctx context.Context) error {
+        now := s.now().UTC()
+        var err error
+        action, err = s.actions.Approve(ctx, actionID, approverID, now)
+        if err != nil {
+            return err
+        }
+        return s.audit.Append(ctx, models.NewPendingActionAuditEvent(
+            models.AuditActionApproved, action, approverID, now))
+    })
+    if err != nil {
+        return nil, s.mapError(err)
+    }
+    log.Info("Pending action approved", logger.Int("approvals", len(action.ApprovedBy)))
+
+    if !action.IsApproved() {
+        return action, nil
+    }
+
+    // Of concurrent final approvals, only the first to resolve the action
+    // records its outcome
+    status := models.PendingActionExecuted
+    // The delete acts for the tenant it was requested in, whose content
+    // tenant isolation confines it to
+    execCtx := ctx
+    if action.TenantID != "" {
+        execCtx = requestctx.WithTenant(ctx, action.TenantID)
+    }
+    execErr := s.files.Delete(execCtx, action.FileID, false)
+    if execErr != nil {
+        log.Error("Approved hard delete failed", logger.Error(execErr))
+        status = models.PendingActionFailed
+    }
+
+    err = s.withTx(ctx, func(ctx context.Context) error {
+        now := s.now().UTC()
+        if err := s.actions.Resolve(ctx, action.ID, status, now); err != nil {
+            return err
+        }
+        action.Status = status
+        action.ResolvedAt = &now
+        return s.audit.Append(ctx, models.NewPendingActionAuditEvent(
+            models.AuditActionResolved, action, approverID, now))
+    })
+    if err != nil && !errors.Is(err, models.ErrPendingActionClosed) {
+        log.Error("Failed to resolve pending action", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if execErr != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, execErr)
+    }
+
+    log.Info("Approved hard delete executed", logger.String("fileId", action.FileID))
+    return action, nil
+}
+
+// Get returns a pending action, including resolved ones
+func (s *deleteApprovals) Get(ctx context.Context, actionID string) (*models.PendingAction, error) {
+    if actionID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    action, err := s.actions.GetByID(ctx, actionID)
+    if err != nil {
+        return nil, s.mapError(err)
+    }
+    return action, nil
+}
+
+// List returns the actions awaiting approval, oldest first
+func (s *deleteApprovals) List(ctx context.Context) ([]*models.PendingAction, error) {
+    actions, err := s.actions.ListPending(ctx, s.now())
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return actions, nil
+}
+
+// Expire closes the actions not approved in time, returning how many
+func (s *deleteApprovals) Expire(ctx context.Context) (int, error) {
+    var expired []*models.PendingAction
+    err := s.withTx(ctx, func(ctx context.Context) error {
+        now := s.now().UTC()
+        var err error
+        expired, err = s.actions.Expire(ctx, now)
+        if err != nil {
+            return err
+        }
+        for _, action := range expired {
+            if err := s.audit.Append(ctx, models.NewPendingActionAuditEvent(
+                models.AuditActionResolved, action, "", now)); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if len(expired) > 0 {
+        s.logger.Info("Expired unapproved actions", logger.Int("count", len(expired)))
+    }
+    return len(expired), nil
+}
+
+// mapError maps repository errors to service errors
+func (s *deleteApprovals) mapError(err error) error {
+    switch {
+    case errors.Is(err, repository.ErrPendingActionNotFound):
+        return ErrPendingActionNotFound
+    case errors.Is(err, models.ErrPendingActionClosed):
+        return ErrPendingActionClosed
+    case errors.Is(err, models.ErrApprovalNotPermitted):
+        return ErrApprovalNotPermitted
+    default:
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+}
+
+// withTx runs fn as one unit of work when transactions are configured, and
+// directly otherwise
+func (s *deleteApprovals) withTx(ctx context.Context, fn func(ctx context.Context) error) error {
+    if s.tx == nil {
+        return fn(ctx)
+    }
+    return s.tx.WithTx(ctx, fn)
+}
@@ -0,0 +1,512 @@
+package service
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "path"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid" // v1.3.0
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/archivelist"
+    "src/backend/file-service/pkg/convert"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+    "src/backend/file-service/pkg/thumbnail"
+)
+
+// Derived object errors
+var (
+    ErrDerivedNotSupported = errors.New("derived object not supported for file")
+    ErrDerivedFailed       = errors.New("derived object generation failed")
+)
+
+// derivedPrefix is the storage prefix for derived objects
+const derivedPrefix = storagekey.Derived
+
+// defaultThumbnailSize is the thumbnail size used when none is requested
+const defaultThumbnailSize = 256
+
+// DerivedGenerator produces one kind of derived object from a parent's content
+type DerivedGenerator interface {
+    // Kind names the derived objects the generator produces
+    Kind() string
+    // Supports reports whether the generator can derive from the file
+    Supports(file *models.File) bool
+    // Params validates requested parameters and returns their canonical form,
+    // with defaults applied, so equivalent requests share a cached object
+    Params(requested map[string]string) (map[string]string, error)
+    // Generate derives content from the parent's and returns it with its type
+    Generate(file *models.File, params map[string]string, content []byte) ([]byte, string, error)
+}
+
+// RangedDerivedGenerator is a DerivedGenerator that reads only the parts of
+// the parent's content it needs, such as an archive's directory, rather than
+// being handed it whole, so it derives from parents of any size
+type RangedDerivedGenerator interface {
+    DerivedGenerator
+    // GenerateRanged derives content from the parent's, read from storage.
+    // Failures to read the content are wrapped in errDerivedSource.
+    GenerateRanged(ctx context.Context, file *models.File, params map[string]string) ([]byte, string, error)
+}
+
+// errDerivedSource wraps failures of ranged generators to read the parent's
+// content, which are not cached like content that can't be derived from
+var errDerivedSource = errors.New("failed to read parent content")
+
+// DerivedObjectService generates derived objects on first request, caches
+// them in object storage and discards them when their parent changes
+type DerivedObjectService interface {
+    Get(ctx context.Context, fileID, kind string, params map[string]string) (*models.DerivedObject, io.ReadCloser, error)
+    Invalidate(ctx context.Context, fileID string) error
+}
+
+// derivedObjectService implements DerivedObjectService
+type derivedObjectService struct {
+    repo          repository.DerivedObjectRepository
+    files         repository.FileRepository
+    storage       storage.Storage
+    objects       storage.ObjectStore
+    generators    map[string]DerivedGenerator
+    maxSourceSize int64
+    logger        *logger.Logger
+
+    // inflight holds a channel per object being generated, closed when done,
+    // so concurrent requests for the same object generate it once
+    mu       sync.Mutex
+    inflight map[string]chan struct{}
+}
+
+// NewDerivedObjectService creates a new instance of derivedObjectService.
+// Parents larger than maxSourceSize bytes are only derived from by ranged
+// generators.
+func NewDerivedObjectService(repo repository.DerivedObjectRepository, files repository.FileRepository,
+    fileStorage storage.Storage, objects storage.ObjectStore, maxSourceSize int64,
+    generators ...DerivedGenerator) (DerivedObjectService, error) {
+    if repo == nil || files == nil || fileStorage == nil || objects == nil {
+        return nil, errors.New("derived object repository, file repository and storage are required")
+    }
+    if maxSourceSize <= 0 {
+        return nil, errors.New("derived object max source size must be positive")
+    }
+
+    byKind := make(map[string]DerivedGenerator, len(generators))
+    for _, generator := range generators {
+        if _, exists := byKind[generator.Kind()]; exists {
+            return nil, fmt.Errorf("duplicate derived object generator %q", generator.Kind())
+        }
+        byKind[generator.Kind()] = generator
+    }
+
+    return &derivedObjectService{
+        repo:          repo,
+        files:         files,
+        storage:       fileStorage,
+        objects:       objects,
+        generators:    byKind,
+        maxSourceSize: maxSourceSize,
+        logger:        logger.GetLogger(),
+        inflight:      make(map[string]chan struct{}),
+    }, nil
+}
+
+// WithDerivedObjects discards a file's derived objects when it is deleted
+func WithDerivedObjects(derived DerivedObjectService) Option {
+    return func(s *fileService) {
+        s.derived = derived
+    }
+}
+
+// Get opens the derived object of kind for a file, generating it first if it
+// is missing or was generated from older content. Generation failures are
+// cached until the parent changes and reported as ErrDerivedFailed.
+func (s *derivedObjectService) Get(ctx context.Context, fileID, kind string, params map[string]string) (*models.DerivedObject, io.ReadCloser, error) {
+    generator, ok := s.generators[kind]
+    if !ok || fileID == "" {
+        return nil, nil, ErrDerivedNotSupported
+    }
+    params, err := generator.Params(params)
+    if err != nil {
+        return nil, nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    paramsHash := models.DerivedParamsHash(kind, params)
+
+    for {
+        parent, err := s.parent(ctx, fileID)
+        if err != nil {
+            return nil, nil, err
+        }
+        if !generator.Supports(parent) || parent.IsClientEncrypted() || parent.UsesCustomerKey() {
+            return nil, nil, ErrDerivedNotSupported
+        }
+        if _, ranged := generator.(RangedDerivedGenerator); !ranged && parent.Size > s.maxSourceSize {
+            return nil, nil, ErrDerivedNotSupported
+        }
+
+        cached, err := s.repo.Get(ctx, fileID, kind, paramsHash)
+        if err != nil && !errors.Is(err, repository.ErrDerivedObjectNotFound) {
+            return nil, nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        if cached != nil && !cached.IsStaleFor(parent) {
+            switch cached.Status {
+            case models.DerivedStatusReady:
+                return s.open(ctx, cached)
+            case models.DerivedStatusFailed:
+                return nil, nil, fmt.Errorf("%w: %s", ErrDerivedFailed, cached.Error)
+            }
+        }
+
+        // Wait for another request generating the same object, then look again
+        key := fileID + "/" + paramsHash
+        release, wait := s.claim(key)
+        if wait != nil {
+            select {
+            case <-wait:
+                continue
+            case <-ctx.Done():
+                return nil, nil, ctx.Err()
+            }
+        }
+
+        object, err := s.generate(ctx, generator, parent, params, paramsHash, cached)
+        release()
+        if err != nil {
+            return nil, nil, err
+        }
+        return s.open(ctx, object)
+    }
+}
+
+// Invalidate deletes every derived object of a file
+func (s *derivedObjectService) Invalidate(ctx context.Context, fileID string) error {
+    objects, err := s.repo.ListByFile(ctx, fileID)
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    var errs []error
+    for _, object := range objects {
+        if object.StorageKey != "" {
+            if err := s.objects.DeleteObject(ctx, object.StorageKey); err != nil {
+                errs = append(errs, err)
+                continue
+            }
+        }
+        if err := s.repo.Delete(ctx, object.ID); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    if len(errs) > 0 {
+        return fmt.Errorf("%w: %v", ErrOperationFailed, errors.Join(errs...))
+    }
+
+    if len(objects) > 0 {
+        s.logger.Info("Invalidated derived objects",
+            logger.String("fileId", fileID),
+            logger.Int("count", len(objects)))
+    }
+    return nil
+}
+
+// parent loads an uploaded file to derive from
+func (s *derivedObjectService) parent(ctx context.Context, fileID string) (*models.File, error) {
+    file, err := s.files.GetByID(ctx, fileID)
+    if errors.Is(err, repository.ErrNotFound) {
+        return nil, ErrFileNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if !file.IsUploaded() {
+        return nil, ErrFileNotFound
+    }
+    return file, nil
+}
+
+// claim marks key as being generated by the caller, returning the function
+// that ends the claim. When another request holds the claim it instead
+// returns a channel closed once that request is done.
+func (s *derivedObjectService) claim(key string) (func(), <-chan struct{}) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if wait, ok := s.inflight[key]; ok {
+        return nil, wait
+    }
+    done := make(chan struct{})
+    s.inflight[key] = done
+    return func() {
+        s.mu.Lock()
+        delete(s.inflight, key)
+        s.mu.Unlock()
+        close(done)
+    }, nil
+}
+
+// generate derives an object from the parent's current content and stores
+// it, replacing previous, stale content
+func (s *derivedObjectService) generate(ctx context.Context, generator DerivedGenerator, parent *models.File,
+    params map[string]string, paramsHash string, previous *models.DerivedObject) (*models.DerivedObject, error) {
+    log := s.logger.With(
+        logger.String("fileId", parent.ID),
+        logger.String("kind", generator.Kind()),
+    )
+    start := time.Now()
+
+    now := time.Now().UTC()
+    object := &models.DerivedObject{
+        ID:             uuid.New().String(),
+        FileID:         parent.ID,
+        Kind:           generator.Kind(),
+        Params:         params,
+        ParamsHash:     paramsHash,
+        StorageKey:     path.Join(derivedPrefix, storage.StorageKey(parent.ID), generator.Kind(), paramsHash),
+        Status:         models.DerivedStatusPending,
+        SourceChecksum: parent.Checksum,
+        CreatedAt:      now,
+        UpdatedAt:      now,
+    }
+    if previous != nil {
+        object.ID = previous.ID
+        object.CreatedAt = previous.CreatedAt
+    }
+
+    data, contentType, err := s.derive(ctx, generator, parent, params)
+    if errors.Is(err, storage.ErrObjectArchived) {
+        return nil, ErrFileArchived
+    }
+    if isTenantIsolated(err) {
+        return nil, ErrFileNotFound
+    }
+    if errors.Is(err, errDerivedSource) {
+        log.Error("Failed to read parent content", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if err != nil {
+        // The parent's content can't be derived from; remember that so every
+        // request doesn't retry until the content changes
+        log.Warn("Derived object generation failed", logger.Error(err))
+        object.Status = models.DerivedStatusFailed
+        object.Error = err.Error()
+        object.StorageKey = ""
+        if saveErr := s.repo.Save(ctx, object); saveErr != nil {
+            log.Warn("Failed to record derived object failure", logger.Error(saveErr))
+        }
+        return nil, fmt.Errorf("%w: %v", ErrDerivedFailed, err)
+    }
+
+    if err := s.objects.PutObject(ctx, object.StorageKey, contentType, data); err != nil {
+        log.Error("Failed to store derived object", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    object.ContentType = contentType
+    object.Size = int64(len(data))
+    object.Status = models.DerivedStatusReady
+    object.UpdatedAt = time.Now().UTC()
+    if err := s.repo.Save(ctx, object); err != nil {
+        log.Error("Failed to save derived object", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    log.Info("Generated derived object",
+        logger.Int64("size", object.Size),
+        logger.Duration("duration", time.Since(start)))
+    return object, nil
+}
+
+// derive runs the generator on the parent's content, reading it whole
+// unless the generator reads ranges itself. Failures to read the content are
+// wrapped in errDerivedSource.
+func (s *derivedObjectService) derive(ctx context.Context, generator DerivedGenerator, parent *models.File,
+    params map[string]string) ([]byte, string, error) {
+    if ranged, ok := generator.(RangedDerivedGenerator); ok {
+        return ranged.GenerateRanged(ctx, parent, params)
+    }
+
+    content, err := s.readParent(ctx, parent)
+    if err != nil {
+        return nil, "", fmt.Errorf("%w: %w", errDerivedSource, err)
+    }
+    return generator.Generate(parent, params, content)
+}
+
+// readParent reads the parent's content, bounded by the max source size
+func (s *derivedObjectService) readParent(ctx context.Context, parent *models.File) ([]byte, error) {
+    reader, err := s.storage.Download(ctx, parent)
+    if err != nil {
+        return nil, err
+    }
+    defer reader.Close()
+
+    content, err := io.ReadAll(io.LimitReader(reader, s.maxSourceSize+1))
+    if err != nil {
+        return nil, err
+    }
+    if int64(len(content)) > s.maxSourceSize {
+        return nil, fmt.Errorf("parent content exceeds %d bytes", s.maxSourceSize)
+    }
+    return content, nil
+}
+
+// open opens the stored content of a ready derived object
+func (s *derivedObjectService) open(ctx context.Context, object *models.DerivedObject) (*models.DerivedObject, io.ReadCloser, error) {
+    reader, err := s.objects.GetObject(ctx, object.StorageKey)
+    if err != nil {
+        s.logger.Error("Derived object download failed",
+            logger.String("derivedId", object.ID),
+            logger.Error(err))
+        return nil, nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return object, reader, nil
+}
+
+// thumbnailGenerator renders PNG thumbnails of images
+type thumbnailGenerator struct{}
+
+// NewThumbnailGenerator creates a generator of image thumbnails, sized by the
+// "size" parameter
+func NewThumbnailGenerator() DerivedGenerator {
+    return thumbnailGenerator{}
+}
+
+func (thumbnailGenerator) Kind() string {
+    return models.DerivedKindThumbnail
+}
+
+func (thumbnailGenerator) Supports(file *models.File) bool {
+    return thumbnail.Supports(file.ContentType)
+}
+
+func (thumbnailGenerator) Params(requested map[string]string) (map[string]string, error) {
+    size := defaultThumbnailSize
+    if value, ok := requested["size"]; ok {
+        parsed, err := strconv.Atoi(value)
+        if err != nil || parsed <= 0 || parsed > thumbnail.MaxDimension {
+            return nil, fmt.Errorf("thumbnail size must be between 1 and %d", thumbnail.MaxDimension)
+        }
+        size = parsed
+    }
+    return map[string]string{"size": strconv.Itoa(size)}, nil
+}
+
+func (thumbnailGenerator) Generate(file *models.File, params map[string]string, content []byte) ([]byte, string, error) {
+    size, err := strconv.Atoi(params["size"])
+    if err != nil {
+        return nil, "", err
+    }
+    data, err := thumbnail.Render(file.ContentType, content, size)
+    return data, thumbnail.ContentType, err
+}
+
+// sanitizedGenerator produces script-free copies of previewable markup
+type sanitizedGenerator struct {
+    sanitizer PreviewSanitizer
+}
+
+// NewSanitizedGenerator creates a generator of sanitized copies
+func NewSanitizedGenerator(sanitizer PreviewSanitizer) DerivedGenerator {
+    return sanitizedGenerator{sanitizer: sanitizer}
+}
+
+func (g sanitizedGenerator) Kind() string {
+    return models.DerivedKindSanitized
+}
+
+func (g sanitizedGenerator) Supports(file *models.File) bool {
+    return g.sanitizer.Supports(file.ContentType)
+}
+
+func (g sanitizedGenerator) Params(requested map[string]string) (map[string]string, error) {
+    return nil, nil
+}
+
+func (g sanitizedGenerator) Generate(file *models.File, params map[string]string, content []byte) ([]byte, string, error) {
+    data, err := g.sanitizer.Sanitize(file.ContentType, bytes.NewReader(content))
+    return data, file.ContentType, err
+}
+
+// conversionGenerator converts files to the canonical format named by the
+// "format" parameter, such as PDF from office documents
+type conversionGenerator struct {
+    pipeline *convert.Pipeline
+}
+
+// NewConversionGenerator creates a generator of copies converted by pipeline
+func NewConversionGenerator(pipeline *convert.Pipeline) DerivedGenerator {
+    return conversionGenerator{pipeline: pipeline}
+}
+
+func (g conversionGenerator) Kind() string {
+    return models.DerivedKindConverted
+}
+
+func (g conversionGenerator) Supports(file *models.File) bool {
+    return g.pipeline.Supports(file.ContentType)
+}
+
+func (g conversionGenerator) Params(requested map[string]string) (map[string]string, error) {
+    format := strings.ToLower(requested["format"])
+    if _, ok := convert.ContentType(format); !ok {
+        return nil, fmt.Errorf("unsupported conversion format %q", requested["format"])
+    }
+    return map[string]string{"format": format}, nil
+}
+
+func (g conversionGenerator) Generate(file *models.File, params map[string]string, content []byte) ([]byte, string, error) {
+    contentType, _ := convert.ContentType(params["format"])
+    data, err := g.pipeline.Convert(file.ContentType, contentType, content)
+    return data, contentType, err
+}
+
+// archiveContentsGenerator lists the entries of zip and tar archives as JSON,
+// reading a zip's central directory or a tar's entry headers in ranges
+type archiveContentsGenerator struct {
+    ranges storage.RangeStorage
+}
+
+// NewArchiveContentsGenerator creates a generator of archive listings read
+// from ranges
+func NewArchiveContentsGenerator(ranges storage.RangeStorage) DerivedGenerator {
+    return archiveContentsGenerator{ranges: ranges}
+}
+
+func (g archiveContentsGenerator) Kind() string {
+    return models.DerivedKindContents
+}
+
+func (g archiveContentsGenerator) Supports(file *models.File) bool {
+    _, ok := archivelist.Format(file.ContentType, file.FileName)
+    return ok
+}
+
+func (g archiveContentsGenerator) Params(requested map[string]string) (map[string]string, error) {
+    return nil, nil
+}
+
+func (g archiveContentsGenerator) Generate(file *models.File, params map[string]string, content []byte) ([]byte, string, error) {
+    format, _ := archivelist.Format(file.ContentType, file.FileName)
+    data, err := archivelist.Encode(format, bytes.NewReader(content), int64(len(content)), archivelist.MaxEntries)
+    return data, archivelist.ContentType, err
+}
+
+func (g archiveContentsGenerator) GenerateRanged(ctx context.Context, file *models.File, params map[string]string) ([]byte, string, error) {
+    reader := storage.NewObjectReaderAt(ctx, g.ranges, file)
+    defer reader.Close()
+
+    format, _ := archivelist.Format(file.ContentType, file.FileName)
+    data, err := archivelist.Encode(format, reader, file.Size, archivelist.MaxEntries)
+    if readErr := reader.Err(); readErr != nil {
+        return nil, "", fmt.Errorf("%w: %w", errDerivedSource, readErr)
+    }
+    return data, archivelist.ContentType, err
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+)
+
+// DownloadLinkRequest describes a request for a presigned download: who
+// asked for it, from where, and the headers the content is served with
+type DownloadLinkRequest struct {
+    RequestedBy string
+    IP          string
+    ContentType string
+    Disposition string
+}
+
+// DirectDownloadService hands out short-lived presigned URLs downloading
+// file content straight from storage rather than through the service
+type DirectDownloadService interface {
+    Presign(ctx context.Context, file *models.File, req DownloadLinkRequest) (*storage.PresignedDownload, error)
+}
+
+// directDownloadService implements DirectDownloadService
+type directDownloadService struct {
+    storage storage.DirectDownloadStorage
+    audit   repository.AuditRepository
+    ttl     time.Duration
+    now     func() time.Time
+    logger  *logger.Logger
+}
+
+// NewDirectDownloadService creates a new instance of directDownloadService
+// issuing URLs valid for ttl
+func NewDirectDownloadService(storage storage.DirectDownloadStorage, audit repository.AuditRepository,
+    ttl time.Duration) (DirectDownloadService, error) {
+    if storage == nil || audit == nil {
+        return nil, errors.New("direct download storage and audit repository are required")
+    }
+    if ttl <= 0 {
+        return nil, errors.New("presigned download URL TTL must be positive")
+    }
+
+    return &directDownloadService{
+        storage: storage,
+        audit:   audit,
+        ttl:     ttl,
+        now:     time.Now,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// Presign presigns a download of an uploaded file and records who requested
+// it in the audit log. No URL is returned unless the request was recorded,
+// since the download itself is never seen by the service.
+func (s *directDownloadService) Presign(ctx context.Context, file *models.File, req DownloadLinkRequest) (*storage.PresignedDownload, error) {
+    if file == nil || !file.IsUploaded() {
+        return nil, ErrFileNotFound
+    }
+    // S3 would need the client's key, which the service does not hold
+    if file.UsesCustomerKey() {
+        return nil, ErrCustomerKeyNotSupported
+    }
+    log := s.logger.With(
+        logger.String("fileId", file.ID),
+        logger.String("requestedBy", req.RequestedBy),
+    )
+
+    download, err := s.storage.PresignDownload(ctx, file, s.ttl, req.ContentType, req.Disposition)
+    if isTenantIsolated(err) {
+        log.Warn("Presigned download refused by tenant isolation", logger.Error(err))
+        return nil, ErrFileNotFound
+    }
+    if err != nil {
+        log.Error("Failed to presign download", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    event := models.NewDownloadLinkAuditEvent(file.ID, req.RequestedBy, req.IP, download.ExpiresAt, s.now().UTC())
+    if err := s.audit.Append(ctx, event); err != nil {
+        log.Error("Failed to record presigned download", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    log.Info("Presigned download issued", logger.Time("expiresAt", download.ExpiresAt))
+    return download, nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+    "context"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/validator"
+)
+
+// Direct upload errors
+var (
+    ErrNotAwaitingUpload = errors.New("file is not awaiting a direct upload")
+    ErrUploadNotReceived = errors.New("file content has not been uploaded")
+    ErrUploadMismatch    = errors.New("uploaded content does not match the presigned upload")
+)
+
+// maxDirectUploadSize is the largest object S3 accepts in a single PUT;
+// larger files use the chunked upload protocol
+const maxDirectUploadSize = int64(5 * 1024 * 1024 * 1024) // 5GB
+
+// DirectUploadConfig defines how clients upload straight to storage
+type DirectUploadConfig struct {
+    // URLTTL is how long a presigned upload URL stays valid
+    URLTTL time.Duration
+    // Masquerade controls handling of disguised files, checked by name since
+    // the content never passes through the service
+    Masquerade validator.MasqueradePolicy
+    // Policy is the per-role upload policy; DefaultUploadPolicy is used when nil
+    Policy *UploadPolicy
+    // Quota enforces storage quotas when set
+    Quota *QuotaTracker
+    // Tenants applies per-tenant overrides of the allowed content types
+    Tenants *TenantSettings
+    // SingleUseConfirmation rejects confirmations of an upload that was
+    // already confirmed with ErrTokenReplayed, rather than returning the file
+    SingleUseConfirmation bool
+}
+
+// DirectUploadService lets clients upload content straight to storage with
+// presigned URLs: Presign records a pending file and signs its upload, and
+// Complete marks the file uploaded once its content is in the bucket
+type DirectUploadService interface {
+    Presign(ctx context.Context, fileName, contentType string, size int64, checksum string,
+        ownerID string, roles []string) (*models.File, *storage.PresignedUpload, error)
+    Complete(ctx context.Context, fileID, ownerID string) (*models.File, error)
+}
+
+// directUploadService implements DirectUploadService
+type directUploadService struct {
+    storage storage.DirectUploadStorage
+    files   repository.FileRepository
+    config  DirectUploadConfig
+    logger  *logger.Logger
+}
+
+// NewDirectUploadService creates a new instance of directUploadService
+func NewDirectUploadService(storage storage.DirectUploadStorage, files repository.FileRepository,
+    config DirectUploadConfig) (DirectUploadService, error) {
+    if storage == nil || files == nil {
+        return nil, errors.New("direct upload storage and file repository are required")
+    }
+    if config.URLTTL <= 0 {
+        return nil, errors.New("direct upload URL TTL must be positive")
+    }
+    if config.Policy == nil {
+        config.Policy = DefaultUploadPolicy()
+    }
+
+    return &directUploadService{
+        storage: storage,
+        files:   files,
+        config:  config,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// Presign checks the caller's upload policy and quota, records a pending
+// file and presigns the upload of its content. checksum is the hex SHA-256
+// of the content; S3 verifies it, the size and the content type when the
+// content is uploaded, and Complete verifies them again.
+func (s *directUploadService) Presign(ctx context.Context, fileName, contentType string, size int64, checksum string,
+    ownerID string, roles []string) (*models.File, *storage.PresignedUpload, error) {
+    log := s.logger.With(
+        logger.String("fileName", fileName),
+        logger.Int64("size", size),
+    )
+
+    checksum = strings.ToLower(checksum)
+    if digest, err := hex.DecodeString(checksum); err != nil || len(digest) != 32 {
+        return nil, nil, fmt.Errorf("%w: checksum must be a hex SHA-256 digest", ErrInvalidInput)
+    }
+    if size > maxDirectUploadSize {
+        return nil, nil, fmt.Errorf("%w: direct uploads are limited to %d bytes, use a chunked upload",
+            ErrUploadTooLarge, maxDirectUploadSize)
+    }
+
+    if err := s.config.Policy.Check(roles, contentType, size); err != nil {
+        log.Warn("Upload policy check failed", logger.Strings("roles", roles), logger.Error(err))
+        return nil, nil, err
+    }
+    if err := s.config.Tenants.CheckContentType(ctx, contentType); err != nil {
+        log.Warn("Tenant content type check failed", logger.Error(err))
+        return nil, nil, err
+    }
+
+    if s.config.Quota != nil {
+        if _, err := s.config.Quota.Check(ctx, ownerID, size); err != nil {
+            log.Warn("Storage quota check failed", logger.String("ownerId", ownerID), logger.Error(err))
+            return nil, nil, err
+        }
+    }
+
+    if err := checkMasquerading(log, fileName, nil, s.config.Masquerade); err != nil {
+        return nil, nil, err
+    }
+
+    file, err := models.NewFile(fileName, size, contentType)
+    if err != nil {
+        return nil, nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    file.OwnerID = ownerID
+    if err := file.UpdateChecksum(checksum); err != nil {
+        return nil, nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    if err := file.SetStoragePath(storage.StorageKey(file.ID)); err != nil {
+        return nil, nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    upload, err := s.storage.PresignUpload(ctx, file, s.config.URLTTL)
+    if err != nil {
+        log.Error("Failed to presign upload", logger.Error(err))
+        return nil, nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if err := s.files.Create(ctx, file); err != nil {
+        log.Error("Failed to persist pending file", logger.Error(err))
+        return nil, nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    log.Info("Direct upload presigned",
+        logger.String("fileId", file.ID),
+        logger.Time("expiresAt", upload.ExpiresAt))
+
+    return file, upload, nil
+}
+
+// Complete marks a pending file uploaded once its content is in the bucket.
+// Completing a file that is already uploaded returns it unchanged, so the
+// callback can be retried, unless confirmations are single-use. The file's
+// status records the confirmation, so no nonce is needed.
+func (s *directUploadService) Complete(ctx context.Context, fileID, ownerID string) (*models.File, error) {
+    log := s.logger.With(logger.String("fileId", fileID))
+
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    file, err := s.files.GetByID(ctx, fileID)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, ErrFileNotFound
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    // Other callers' uploads are not revealed
+    if file.OwnerID != ownerID {
+        return nil, ErrFileNotFound
+    }
+    if file.IsUploaded() {
+        if s.config.SingleUseConfirmation {
+            log.Warn("Replayed upload confirmation rejected")
+            return nil, ErrTokenReplayed
+        }
+        return file, nil
+    }
+    if file.Status != models.FileStatusPending {
+        return nil, ErrNotAwaitingUpload
+    }
+
+    object, err := s.storage.StatUpload(ctx, file)
+    if err != nil {
+        if errors.Is(err, storage.ErrObjectNotFound) {
+            return nil, ErrUploadNotReceived
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    // S3 checked the content against the signed policy; this guards
+    // against content that reached the key some other way
+    if object.Size != file.Size || object.Checksum != file.Checksum {
+        log.Warn("Uploaded content does not match the presigned upload",
+            logger.Int64("size", object.Size),
+            logger.String("checksum", object.Checksum))
+        return nil, models.ErrChecksumMismatch
+    }
+    if object.ContentType != file.ContentType {
+        log.Warn("Uploaded content type does not match the presigned upload",
+            logger.String("contentType", object.ContentType))
+        return nil, ErrUploadMismatch
+    }
+
+    // Re-check the quota, since other uploads may have completed since presigning
+    var usage QuotaUsage
+    if s.config.Quota != nil {
+        usage, err = s.config.Quota.Check(ctx, file.OwnerID, file.Size)
+        if err != nil {
+            log.Warn("Storage quota check failed", logger.Error(err))
+            return nil, err
+        }
+    }
+
+    if err := file.UpdateStatus(models.FileStatusUploaded); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if err := s.files.Update(ctx, file); err != nil {
+        switch {
+        case errors.Is(err, repository.ErrVersionConflict):
+            return nil, ErrVersionConflict
+        case errors.Is(err, repository.ErrNotFound):
+            return nil, ErrFileNotFound
+        }
+        log.Error("Failed to mark direct upload complete", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if s.config.Quota != nil {
+        s.config.Quota.Record(ctx, file.OwnerID, usage, file.Size)
+    }
+
+    log.Info("Direct upload completed", logger.String("checksum", file.Checksum))
+    return file, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// ErrDraftsNotEnabled is returned for draft uploads when drafts are not configured
+var ErrDraftsNotEnabled = errors.New("draft uploads are not enabled")
+
+// draftPurgeBatchSize bounds the number of expired drafts removed per purge
+const draftPurgeBatchSize = 100
+
+// WithDrafts enables draft uploads, which are kept under the scratch prefix
+// and hidden from listings until committed or purged after ttl
+func WithDrafts(drafts storage.DraftStorage, ttl time.Duration) Option {
+    return func(s *fileService) {
+        s.drafts = drafts
+        s.draftTTL = ttl
+    }
+}
+
+// Commit promotes a draft upload to a regular file
+func (s *fileService) Commit(ctx context.Context, fileID string) (*models.File, error) {
+    log := s.logger.With(logger.String("fileId", fileID))
+
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+    if s.drafts == nil {
+        return nil, ErrDraftsNotEnabled
+    }
+
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return nil, err
+    }
+    if !file.IsDraft() {
+        return nil, models.ErrNotDraft
+    }
+    if file.IsDraftExpired() {
+        return nil, models.ErrDraftExpired
+    }
+
+    if err := s.drafts.PromoteDraft(ctx, file); err != nil {
+        log.Error("Failed to promote draft", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if err := file.CommitDraft(); err != nil {
+        return nil, err
+    }
+    if err := s.acquireBlob(ctx, file); err != nil {
+        log.Error("Failed to reference content blob", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if err := s.updateFile(ctx, file); err != nil {
+        log.Error("Failed to persist committed draft", logger.Error(err))
+        if _, releaseErr := s.releaseBlob(ctx, file); releaseErr != nil {
+            log.Warn("Failed to release content blob", logger.Error(releaseErr))
+        }
+        return nil, err
+    }
+
+    log.Info("Draft committed")
+    return file, nil
+}
+
+// PurgeExpiredDrafts deletes drafts that were not committed before expiring
+// and returns how many were removed
+func (s *fileService) PurgeExpiredDrafts(ctx context.Context) (int, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    files, err := s.repo.ListExpiredDrafts(ctx, time.Now().UTC(), draftPurgeBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    purged := 0
+    for _, file := range files {
+        if err := s.storage.Delete(ctx, file, false); err != nil {
+            log.Warn("Failed to delete expired draft",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+            continue
+        }
+        if err := s.repo.Delete(ctx, file.ID); err != nil {
+            log.Warn("Failed to mark expired draft deleted",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+            continue
+        }
+        purged++
+    }
+
+    if purged > 0 {
+        log.Info("Purged expired drafts", logger.Int("count", purged))
+    }
+    return purged, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+    "context"
+    "encoding/base64"
+    "errors"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+)
+
+// ErrEncryptionNotAllowed is returned when client-encrypted uploads are disabled
+var ErrEncryptionNotAllowed = errors.New("client-side encryption is not enabled")
+
+// KeyEscrow stores a recoverable copy of a client-wrapped data key
+type KeyEscrow interface {
+    Escrow(ctx context.Context, fileID string, wrappedKey []byte) (keyID string, escrowed []byte, err error)
+}
+
+// WithClientEncryption accepts uploads of client-encrypted content. When escrow
+// is non-nil, wrapped keys are escrowed on request, or always if escrowRequired.
+func WithClientEncryption(escrow KeyEscrow, escrowRequired bool) Option {
+    return func(s *fileService) {
+        s.clientEncryption = true
+        s.keyEscrow = escrow
+        s.escrowRequired = escrowRequired && escrow != nil
+    }
+}
+
+// applyClientEncryption validates the client encryption metadata, escrows the
+// wrapped key when requested and attaches the metadata to the file
+func (s *fileService) applyClientEncryption(ctx context.Context, file *models.File, opts UploadOptions) error {
+    if !s.clientEncryption {
+        return fmt.Errorf("%w: %v", ErrInvalidInput, ErrEncryptionNotAllowed)
+    }
+
+    metadata := *opts.Encryption
+    if err := metadata.Validate(); err != nil {
+        return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    if opts.EscrowKey || s.escrowRequired {
+        if s.keyEscrow == nil {
+            return fmt.Errorf("%w: key escrow is not configured", ErrInvalidInput)
+        }
+
+        wrappedKey, _ := base64.StdEncoding.DecodeString(metadata.WrappedKey)
+        keyID, escrowed, err := s.keyEscrow.Escrow(ctx, file.ID, wrappedKey)
+        if err != nil {
+            return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        metadata.EscrowKeyID = keyID
+        metadata.EscrowedKey = base64.StdEncoding.EncodeToString(escrowed)
+    }
+
+    file.Encryption = &metadata
+    return nil
+}
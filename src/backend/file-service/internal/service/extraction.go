@@ -0,0 +1,330 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "path"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/archivelist"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/tracing"
+    "src/backend/file-service/pkg/validator"
+)
+
+// Extraction errors
+var (
+    // ErrExtractionNotSupported is returned for files that are not zip or
+    // tar archives, or whose content the service cannot read
+    ErrExtractionNotSupported = errors.New("file is not an archive that can be extracted")
+    // ErrExtractionJobNotFound is returned when no extraction job matches
+    ErrExtractionJobNotFound = errors.New("extraction job not found")
+    // ErrExtractionLimit fails jobs whose archive exceeds the extraction limits
+    ErrExtractionLimit = errors.New("archive exceeds extraction limits")
+)
+
+// Entry errors, recorded for entries that are not extracted
+var (
+    errExtractLink   = errors.New("links are not extracted")
+    errExtractPath   = errors.New("entry path leaves the target folder")
+    errExtractRatio  = errors.New("entry is compressed beyond the permitted ratio")
+    errExtractFailed = errors.New("failed to read archive")
+)
+
+// extractJob is the job name of archive extractions
+const extractJob = "archive-extract"
+
+// extractionProgressInterval bounds how often a running job's progress is
+// written
+const extractionProgressInterval = time.Second
+
+// ExtractionOptions bound what an archive may expand to, so an archive bomb
+// cannot exhaust storage. Zip archives record their entries up front and
+// are refused before any is extracted; tar archives are stopped once they
+// exceed a limit, keeping the entries extracted so far.
+type ExtractionOptions struct {
+    // MaxEntries bounds the entries of an archive
+    MaxEntries int
+    // MaxSize bounds the uncompressed size of an archive's entries
+    MaxSize int64
+    // MaxRatio bounds the uncompressed size of an archive over its size,
+    // and of each zip entry over its compressed size
+    MaxRatio float64
+}
+
+// ExtractionService expands uploaded zip and tar archives into a file per
+// entry in a background job
+type ExtractionService interface {
+    Start(ctx context.Context, fileID string, opts UploadOptions) (*models.ExtractionJob, error)
+    Get(ctx context.Context, fileID, jobID string) (*models.ExtractionJob, error)
+}
+
+// extractionService implements ExtractionService
+type extractionService struct {
+    jobs   repository.ExtractionJobRepository
+    files  FileService
+    ranges storage.RangeStorage
+    opts   ExtractionOptions
+    logger *logger.Logger
+}
+
+// NewExtractionService creates a new instance of extractionService. Archives
+// are read through ranges and their entries stored through files, so each
+// is held to the caller's upload policy and quota like any upload.
+func NewExtractionService(jobs repository.ExtractionJobRepository, files FileService,
+    ranges storage.RangeStorage, opts ExtractionOptions) (ExtractionService, error) {
+    if jobs == nil || files == nil || ranges == nil {
+        return nil, errors.New("extraction job repository, file service and range storage are required")
+    }
+    if opts.MaxEntries <= 0 || opts.MaxSize <= 0 || opts.MaxRatio <= 0 {
+        return nil, errors.New("extraction limits must be positive")
+    }
+
+    return &extractionService{
+        jobs:   jobs,
+        files:  files,
+        ranges: ranges,
+        opts:   opts,
+        logger: logger.GetLogger(),
+    }, nil
+}
+
+// Start creates a job extracting the archive into opts.Folder, by default
+// the archive's own folder, and runs it in the background. Entries keep
+// their paths below the folder, and are renamed when a file of the same
+// name exists. The job stops when the owner's quota is exhausted.
+func (s *extractionService) Start(ctx context.Context, fileID string, opts UploadOptions) (*models.ExtractionJob, error) {
+    file, err := s.files.Stat(ctx, fileID)
+    if err != nil {
+        return nil, err
+    }
+
+    format, ok := archivelist.Format(file.ContentType, file.FileName)
+    if !ok || file.IsClientEncrypted() || file.UsesCustomerKey() {
+        return nil, ErrExtractionNotSupported
+    }
+    if file.NeedsRestore(time.Now()) {
+        return nil, ErrFileArchived
+    }
+
+    if opts.Folder == "" {
+        opts.Folder = file.Folder
+    }
+    if err := validator.ValidateFolder(opts.Folder); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    job := models.NewExtractionJob(file.ID, opts.Folder, opts.OwnerID)
+    if err := s.jobs.Create(ctx, job); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    running := *job
+    jobCtx, trace := tracing.StartJob(context.WithoutCancel(ctx), extractJob)
+    done := telemetry.TrackJob(jobCtx, trace.Name)
+    go func() {
+        err := s.run(jobCtx, &running, file, format, opts)
+        if err != nil {
+            s.logger.Warn("Archive extraction failed",
+                append(trace.Fields(), logger.String("jobId", running.ID),
+                    logger.String("fileId", file.ID), logger.Error(err))...)
+        }
+        running.Finish(jobError(err))
+        s.save(jobCtx, &running)
+        done(err)
+    }()
+
+    return job, nil
+}
+
+// Get returns an extraction job of the file with its progress
+func (s *extractionService) Get(ctx context.Context, fileID, jobID string) (*models.ExtractionJob, error) {
+    if fileID == "" || jobID == "" {
+        return nil, ErrExtractionJobNotFound
+    }
+
+    job, err := s.jobs.Get(ctx, jobID)
+    if errors.Is(err, repository.ErrExtractionJobNotFound) {
+        return nil, ErrExtractionJobNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if job.FileID != fileID {
+        return nil, ErrExtractionJobNotFound
+    }
+    return job, nil
+}
+
+// run extracts the archive's entries, recording progress on job
+func (s *extractionService) run(ctx context.Context, job *models.ExtractionJob, file *models.File,
+    format string, opts UploadOptions) error {
+    job.Status = models.ExtractionRunning
+    s.save(ctx, job)
+
+    content := storage.NewObjectReaderAt(ctx, s.ranges, file)
+    defer content.Close()
+
+    archive, err := archivelist.NewReader(format, content, file.Size)
+    if err != nil {
+        return readError(content, err)
+    }
+    defer archive.Close()
+
+    if count, size := archive.Count(); count >= 0 {
+        job.TotalEntries = count
+        if err := s.checkLimits(count, size, file.Size); err != nil {
+            return err
+        }
+    }
+
+    var entries int
+    var size int64
+    saved := time.Now()
+    for {
+        entry, reader, err := archive.Next()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return readError(content, err)
+        }
+
+        entries++
+        size += entry.Size
+        if err := s.checkLimits(entries, size, file.Size); err != nil {
+            return err
+        }
+        if err := s.extract(ctx, job, entry, reader, opts); err != nil {
+            return err
+        }
+        if readErr := content.Err(); readErr != nil {
+            return readError(content, readErr)
+        }
+
+        if time.Since(saved) >= extractionProgressInterval {
+            s.save(ctx, job)
+            saved = time.Now()
+        }
+    }
+
+    job.TotalEntries = job.ProcessedEntries
+    return nil
+}
+
+// extract stores one entry as a file, recording entries that can't be on
+// job. It only fails when the job must stop.
+func (s *extractionService) extract(ctx context.Context, job *models.ExtractionJob,
+    entry archivelist.Entry, reader io.Reader, opts UploadOptions) error {
+    if entry.Dir {
+        job.Skipped()
+        return nil
+    }
+    if entry.Link != "" {
+        job.EntryFailed(entry.Name, errExtractLink)
+        return nil
+    }
+    if entry.Ratio > s.opts.MaxRatio {
+        job.EntryFailed(entry.Name, errExtractRatio)
+        return nil
+    }
+    name, folder, err := extractionPath(entry.Name, opts.Folder)
+    if err != nil {
+        job.EntryFailed(entry.Name, err)
+        return nil
+    }
+
+    entryOpts := opts
+    entryOpts.Folder = folder
+    entryOpts.NameConflict = NameConflictRename
+    entryOpts.Checksum = ""
+    entryOpts.Draft = false
+
+    file, err := s.files.Upload(ctx, name, extractionContentType(name), entry.Size, reader, entryOpts)
+    if errors.Is(err, ErrQuotaExceeded) {
+        job.EntryFailed(entry.Name, err)
+        return err
+    }
+    if err != nil {
+        job.EntryFailed(entry.Name, err)
+        return nil
+    }
+    job.Extracted(file.ID, file.Size)
+    return nil
+}
+
+// checkLimits fails a job whose archive holds more than the permitted
+// entries or expands beyond the permitted size or ratio
+func (s *extractionService) checkLimits(entries int, size, archiveSize int64) error {
+    switch {
+    case entries > s.opts.MaxEntries:
+        return fmt.Errorf("%w: more than %d entries", ErrExtractionLimit, s.opts.MaxEntries)
+    case size > s.opts.MaxSize:
+        return fmt.Errorf("%w: expands to more than %d bytes", ErrExtractionLimit, s.opts.MaxSize)
+    case archiveSize > 0 && float64(size)/float64(archiveSize) > s.opts.MaxRatio:
+        return fmt.Errorf("%w: expands more than %g times", ErrExtractionLimit, s.opts.MaxRatio)
+    }
+    return nil
+}
+
+// save records the job's progress. Failures are logged; the job carries on
+// and its progress is written again with the next update.
+func (s *extractionService) save(ctx context.Context, job *models.ExtractionJob) {
+    job.UpdatedAt = time.Now().UTC()
+    if err := s.jobs.Update(ctx, job); err != nil {
+        s.logger.Warn("Failed to record extraction progress",
+            logger.String("jobId", job.ID), logger.Error(err))
+    }
+}
+
+// readError tells failures to read the archive's content, whose details
+// are logged but not recorded on the job, apart from archives that could
+// not be parsed
+func readError(content *storage.ObjectReaderAt, err error) error {
+    if readErr := content.Err(); readErr != nil {
+        return fmt.Errorf("%w: %w", errExtractFailed, readErr)
+    }
+    return fmt.Errorf("%w: %w", ErrExtractionNotSupported, err)
+}
+
+// jobError returns the error a failed job records
+func jobError(err error) error {
+    if errors.Is(err, errExtractFailed) {
+        return errExtractFailed
+    }
+    return err
+}
+
+// extractionPath splits an entry's path into its file name and its folder
+// below folder, refusing paths that would leave it
+func extractionPath(name, folder string) (string, string, error) {
+    if strings.Contains(name, "\\") || path.IsAbs(name) {
+        return "", "", errExtractPath
+    }
+    for _, segment := range strings.Split(name, "/") {
+        if segment == ".." {
+            return "", "", errExtractPath
+        }
+    }
+
+    dir, base := path.Split(path.Clean(name))
+    return base, path.Join(folder, strings.TrimSuffix(dir, "/")), nil
+}
+
+// extractionContentType returns the content type of an entry by its name's
+// extension
+func extractionContentType(name string) string {
+    contentType := mime.TypeByExtension(path.Ext(name))
+    if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+        return mediaType
+    }
+    return "application/octet-stream"
+}
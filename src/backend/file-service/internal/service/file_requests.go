@@ -0,0 +1,237 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/tracing"
+    "src/backend/file-service/pkg/validator"
+)
+
+// ErrFileRequestNotFound is returned when a file request does not exist
+var ErrFileRequestNotFound = errors.New("file request not found")
+
+// fileRequestNotifyJob names the background job telling an owner about a received file
+const fileRequestNotifyJob = "file-request-notify"
+
+// FileRequestOptions are the settings of a new file request. A zero TTL uses
+// the configured default.
+type FileRequestOptions struct {
+    Title        string        `json:"title"`
+    Folder       string        `json:"folder"`
+    MaxFileSize  int64         `json:"maxFileSize"`
+    AllowedTypes []string      `json:"allowedTypes"`
+    MaxFiles     int           `json:"maxFiles"`
+    TTL          time.Duration `json:"-"`
+}
+
+// FileRequestService manages upload inboxes that let external parties upload
+// files into a user's folder without an account
+type FileRequestService interface {
+    Create(ctx context.Context, ownerID string, roles []string, opts FileRequestOptions) (*models.FileRequest, error)
+    List(ctx context.Context, ownerID string) ([]*models.FileRequest, error)
+    Revoke(ctx context.Context, id, ownerID string) error
+    Get(ctx context.Context, id string) (*models.FileRequest, error)
+    Upload(ctx context.Context, id, fileName, contentType string, size int64, reader io.Reader, uploader string) (*models.File, error)
+}
+
+// fileRequestService implements FileRequestService
+type fileRequestService struct {
+    requests      repository.FileRequestRepository
+    files         FileService
+    notifications NotificationService
+    defaultTTL    time.Duration
+    maxTTL        time.Duration
+    logger        *logger.Logger
+}
+
+// NewFileRequestService creates a new instance of fileRequestService.
+// notifications may be nil, in which case owners are not told about uploads.
+func NewFileRequestService(requests repository.FileRequestRepository, files FileService,
+    notifications NotificationService, defaultTTL, maxTTL time.Duration) (FileRequestService, error) {
+    if requests == nil || files == nil {
+        return nil, errors.New("file request repository and file service are required")
+    }
+    if defaultTTL <= 0 || maxTTL < defaultTTL {
+        return nil, errors.New("invalid file request TTLs")
+    }
+
+    return &fileRequestService{
+        requests:      requests,
+        files:         files,
+        notifications: notifications,
+        defaultTTL:    defaultTTL,
+        maxTTL:        maxTTL,
+        logger:        logger.GetLogger(),
+    }, nil
+}
+
+// Create opens a new file request owned by ownerID. Uploads are held to the
+// owner's upload policy as well as to the request's own limits, and are
+// stored for the tenant ctx acts for.
+func (s *fileRequestService) Create(ctx context.Context, ownerID string, roles []string, opts FileRequestOptions) (*models.FileRequest, error) {
+    ttl := opts.TTL
+    if ttl == 0 {
+        ttl = s.defaultTTL
+    }
+    if ttl < 0 || ttl > s.maxTTL {
+        return nil, fmt.Errorf("%w: file request TTL must be positive and at most %s", ErrInvalidInput, s.maxTTL)
+    }
+
+    request, err := models.NewFileRequest(ownerID, opts.Title, opts.Folder, opts.MaxFileSize,
+        opts.AllowedTypes, opts.MaxFiles, ttl)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    request.OwnerRoles = roles
+    request.OwnerTenant = requestctx.Tenant(ctx)
+
+    if err := s.requests.Create(ctx, request); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return request, nil
+}
+
+// List returns the owner's file requests
+func (s *fileRequestService) List(ctx context.Context, ownerID string) ([]*models.FileRequest, error) {
+    if ownerID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    requests, err := s.requests.ListByOwner(ctx, ownerID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return requests, nil
+}
+
+// Revoke closes one of the owner's file requests to further uploads
+func (s *fileRequestService) Revoke(ctx context.Context, id, ownerID string) error {
+    if id == "" || ownerID == "" {
+        return ErrInvalidInput
+    }
+
+    err := s.requests.Revoke(ctx, id, ownerID, time.Now().UTC())
+    if errors.Is(err, repository.ErrFileRequestNotFound) {
+        return ErrFileRequestNotFound
+    }
+    if err != nil {
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return nil
+}
+
+// Get returns a file request that still accepts uploads
+func (s *fileRequestService) Get(ctx context.Context, id string) (*models.FileRequest, error) {
+    if id == "" {
+        return nil, ErrFileRequestNotFound
+    }
+
+    request, err := s.requests.GetByID(ctx, id)
+    if errors.Is(err, repository.ErrFileRequestNotFound) {
+        return nil, ErrFileRequestNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if !request.IsOpen(time.Now()) {
+        return nil, models.ErrFileRequestClosed
+    }
+    return request, nil
+}
+
+// Upload stores a file sent through a file request in the owner's folder
+// and tenant, charged to the owner, and tells the owner it arrived. uploader
+// is the optional name the external party gave.
+func (s *fileRequestService) Upload(ctx context.Context, id, fileName, contentType string, size int64,
+    reader io.Reader, uploader string) (*models.File, error) {
+    request, err := s.Get(ctx, id)
+    if err != nil {
+        return nil, err
+    }
+    log := s.logger.With(
+        logger.String("requestId", request.ID),
+        logger.String("ownerId", request.OwnerID),
+    )
+
+    // The request's own limits come before the owner's upload policy
+    if err := validator.ValidateFileSizeLimit(size, request.MaxFileSize); err != nil {
+        if size <= 0 {
+            return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+        }
+        return nil, fmt.Errorf("%w: %w", ErrUploadTooLarge, err)
+    }
+    if len(request.AllowedTypes) > 0 && !(UploadRule{ContentTypes: request.AllowedTypes}).allowsType(contentType) {
+        return nil, fmt.Errorf("%w: %w", ErrUploadNotPermitted, &validator.ValidationError{
+            Code:    "TYPE_NOT_PERMITTED",
+            Message: fmt.Sprintf("File type %s is not accepted by this request", contentType),
+        })
+    }
+
+    if err := s.requests.Reserve(ctx, request.ID, time.Now().UTC()); err != nil {
+        if errors.Is(err, models.ErrFileRequestClosed) {
+            return nil, err
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    // Uploaders act for the owner's tenant, so the content is stored in
+    // its scope rather than unscoped
+    if request.OwnerTenant != "" {
+        ctx = requestctx.WithTenant(ctx, request.OwnerTenant)
+    }
+
+    // Uploaders may not replace, or learn about, files already in the folder
+    file, err := s.files.Upload(ctx, fileName, contentType, size, reader, UploadOptions{
+        Roles:        request.OwnerRoles,
+        OwnerID:      request.OwnerID,
+        Folder:       request.Folder,
+        NameConflict: NameConflictRename,
+    })
+    if err != nil {
+        if unreserveErr := s.requests.Unreserve(ctx, request.ID); unreserveErr != nil {
+            log.Warn("Failed to release file request slot", logger.Error(unreserveErr))
+        }
+        return nil, err
+    }
+
+    log.Info("File received through file request", logger.String("fileId", file.ID))
+    s.notifyOwner(ctx, request, file, uploader)
+    return file, nil
+}
+
+// notifyOwner tells the request owner about a received file in the
+// background, as a job linked to the trace of ctx
+func (s *fileRequestService) notifyOwner(ctx context.Context, request *models.FileRequest, file *models.File, uploader string) {
+    if s.notifications == nil {
+        return
+    }
+
+    jobCtx, job := tracing.StartJob(context.WithoutCancel(ctx), fileRequestNotifyJob)
+    done := telemetry.TrackJob(jobCtx, job.Name)
+    event := RequestUpload{
+        RequestID: request.ID,
+        Title:     request.Title,
+        OwnerID:   request.OwnerID,
+        FileID:    file.ID,
+        FileName:  file.FileName,
+        Size:      file.Size,
+        Uploader:  uploader,
+    }
+    go func() {
+        err := s.notifications.NotifyRequestUpload(jobCtx, event)
+        if err != nil {
+            s.logger.Warn("Failed to notify file request owner",
+                append(job.Fields(), logger.String("requestId", request.ID), logger.Error(err))...)
+        }
+        done(err)
+    }()
+}
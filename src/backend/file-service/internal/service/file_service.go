@@ -13,7 +13,9 @@ import (
     "time"
 
     "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
     "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/bandwidth"
     "src/backend/file-service/pkg/logger"
     "src/backend/file-service/pkg/validator"
 )
@@ -24,6 +26,15 @@ var (
     ErrFileNotFound     = errors.New("file not found")
     ErrOperationFailed  = errors.New("operation failed")
     ErrInvalidChecksum  = errors.New("checksum validation failed")
+    // ErrUploadInterrupted is returned when the client went away before its
+    // upload was received in full
+    ErrUploadInterrupted = errors.New("upload interrupted by client")
+    // ErrFileRetained is returned for hard deletes of files whose content is
+    // under an Object Lock retention or legal hold
+    ErrFileRetained = errors.New("file is under retention")
+    // ErrFileArchived is returned for downloads of archived files that have
+    // no restored copy
+    ErrFileArchived = errors.New("file is archived")
 )
 
 // WorkerPoolConfig defines configuration for the worker pool
@@ -33,29 +44,143 @@ type WorkerPoolConfig struct {
     BufferSize int
 }
 
+// UploadOptions carries optional per-request upload settings
+type UploadOptions struct {
+    // Encryption describes client-side encryption applied to the content
+    Encryption *models.EncryptionMetadata
+    // EscrowKey requests that the wrapped key be escrowed for recovery
+    EscrowKey bool
+    // Roles are the caller's roles, used to evaluate the upload policy
+    Roles []string
+    // Draft stores the file as a draft that must be committed before it expires
+    Draft bool
+    // OwnerID identifies the user charged for the file's storage
+    OwnerID string
+    // Folder is the logical folder the file is placed in
+    Folder string
+    // Tags label the file and are stored as its object's tags
+    Tags models.Tags
+    // Checksum is the hex SHA-256 the client declared for the content. The
+    // upload is rejected if the content does not match it, and content
+    // that is already stored is not written again.
+    Checksum string
+    // NameConflict is the strategy applied when the folder already holds a
+    // file of the same name; empty uses the service's default
+    NameConflict string
+
+    // staged is the pending file of an asynchronous upload whose staged
+    // content is being processed; the upload completes it rather than
+    // creating a file, and its quota was checked when it was staged
+    staged *models.File
+}
+
+// Option configures optional fileService behavior
+type Option func(*fileService)
+
+// WithBandwidthScheduler throttles uploads to storage to each owner's share
+// of the scheduler's aggregate bandwidth
+func WithBandwidthScheduler(scheduler *bandwidth.Scheduler) Option {
+    return func(s *fileService) {
+        s.bandwidth = scheduler
+    }
+}
+
+// WithTransactions makes multi-step metadata changes, such as referencing a
+// content blob and inserting the file record, commit or roll back together
+func WithTransactions(tx repository.TxManager) Option {
+    return func(s *fileService) {
+        s.tx = tx
+    }
+}
+
 // FileService defines the interface for file operations
 type FileService interface {
-    Upload(ctx context.Context, fileName string, contentType string, size int64, reader io.Reader) (*models.File, error)
+    Upload(ctx context.Context, fileName string, contentType string, size int64, reader io.Reader, opts UploadOptions) (*models.File, error)
     Download(ctx context.Context, fileID string) (*models.File, io.ReadCloser, error)
+    Stat(ctx context.Context, fileID string) (*models.File, error)
+    Lookup(ctx context.Context, fileID string) (*models.File, error)
+    DownloadPreview(ctx context.Context, fileID string) (*models.File, io.ReadCloser, error)
     Delete(ctx context.Context, fileID string, softDelete bool) error
+    BulkDelete(ctx context.Context, fileIDs []string, softDelete bool) (*BulkDeleteResult, error)
+    Commit(ctx context.Context, fileID string) (*models.File, error)
+    Rename(ctx context.Context, fileID, fileName string, version int64) (*models.File, error)
+    Move(ctx context.Context, fileID, folder string, version int64) (*models.File, error)
+    SetTags(ctx context.Context, fileID string, tags models.Tags, version int64) (*models.File, error)
+    PurgeExpiredDrafts(ctx context.Context) (int, error)
+    UploadAsync(ctx context.Context, fileName string, contentType string, size int64, reader io.Reader, opts UploadOptions) (*models.File, error)
+    UploadStatus(ctx context.Context, fileID, ownerID string) (*models.File, error)
+    FailStaleUploads(ctx context.Context) (int, error)
+    CollectBlobs(ctx context.Context) (int, error)
 }
 
 // fileService implements the FileService interface
 type fileService struct {
     storage    storage.Storage
+    repo       repository.FileRepository
     workerPool *sync.Pool
     logger     *logger.Logger
     bufferSize int
+
+    clientEncryption bool
+    keyEscrow        KeyEscrow
+    escrowRequired   bool
+
+    previewSanitizer PreviewSanitizer
+    previewObjects   storage.ObjectStore
+    previewMaxSize   int64
+
+    masqueradePolicy validator.MasqueradePolicy
+    uploadPolicy     *UploadPolicy
+
+    contentPolicy  *validator.ContentPolicy
+    contentMetrics contentMetrics
+
+    drafts   storage.DraftStorage
+    draftTTL time.Duration
+
+    staging        storage.StagingStorage
+    stagingTimeout time.Duration
+
+    quota *QuotaTracker
+
+    tenants *TenantSettings
+
+    bandwidth *bandwidth.Scheduler
+
+    blobs         repository.BlobRepository
+    blobObjects   storage.ObjectStore
+    blobGrace     time.Duration
+    sharedContent storage.SharedContentStorage
+
+    derived DerivedObjectService
+
+    objectTags storage.TagStorage
+
+    batchDelete storage.BatchDeleteStorage
+
+    integrity IntegrityGuard
+
+    tiering TieringService
+
+    nameConflict string
+    locks        LockService
+
+    tx repository.TxManager
 }
 
-// NewFileService creates a new instance of fileService
-func NewFileService(storage storage.Storage, config WorkerPoolConfig) (FileService, error) {
+// NewFileService creates a new instance of fileService. repo is required:
+// the service keeps each file's metadata in it, recording uploads, loading
+// files before downloads and marking deleted files.
+func NewFileService(storage storage.Storage, repo repository.FileRepository, config WorkerPoolConfig, opts ...Option) (FileService, error) {
     log := logger.GetLogger()
 
     // Validate dependencies and configuration
     if storage == nil {
         return nil, errors.New("storage implementation is required")
     }
+    if repo == nil {
+        return nil, errors.New("file repository is required")
+    }
 
     if config.MaxWorkers <= 0 {
         config.MaxWorkers = 10 // Default workers
@@ -72,87 +197,312 @@ func NewFileService(storage storage.Storage, config WorkerPoolConfig) (FileServi
     }
 
     service := &fileService{
-        storage:    storage,
-        workerPool: workerPool,
-        logger:     log,
-        bufferSize: config.BufferSize,
+        storage:      storage,
+        repo:         repo,
+        workerPool:   workerPool,
+        logger:       log,
+        bufferSize:   config.BufferSize,
+        uploadPolicy: DefaultUploadPolicy(),
+    }
+    for _, opt := range opts {
+        opt(service)
     }
 
     log.Info("File service initialized",
-        logger.zap.Int("maxWorkers", config.MaxWorkers),
-        logger.zap.Int("bufferSize", config.BufferSize))
+        logger.Int("maxWorkers", config.MaxWorkers),
+        logger.Int("bufferSize", config.BufferSize))
 
     return service, nil
 }
 
-// Upload handles secure file upload with validation and encryption
+// Upload handles secure file upload with validation and encryption, and
+// records the new file's metadata in the repository once its content is
+// stored
 func (s *fileService) Upload(ctx context.Context, fileName string, contentType string, 
-    size int64, reader io.Reader) (*models.File, error) {
+    size int64, reader io.Reader, opts UploadOptions) (*models.File, error) {
     
     log := s.logger.With(
-        logger.zap.String("fileName", fileName),
-        logger.zap.String("contentType", contentType),
-        logger.zap.Int64("size", size),
+        logger.String("fileName", fileName),
+        logger.String("contentType", contentType),
+        logger.Int64("size", size),
     )
 
     // Validate input parameters
     if err := validator.ValidateFileName(fileName); err != nil {
-        log.Error("File name validation failed", logger.zap.Error(err))
+        log.Error("File name validation failed", logger.Error(err))
         return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
     }
 
-    if err := validator.ValidateFileType(contentType, nil); err != nil {
-        log.Error("Content type validation failed", logger.zap.Error(err))
-        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    if reader == nil {
+        return nil, fmt.Errorf("%w: content reader is required", ErrInvalidInput)
+    }
+    if opts.Checksum != "" {
+        if digest, err := hex.DecodeString(opts.Checksum); err != nil || len(digest) != sha256.Size {
+            return nil, fmt.Errorf("%w: checksum must be a hex SHA-256", ErrInvalidInput)
+        }
+    }
+    if opts.Draft && s.drafts == nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, ErrDraftsNotEnabled)
+    }
+    // Committing a draft copies its content, which needs the key
+    if opts.Draft && hasCustomerKey(ctx) {
+        return nil, fmt.Errorf("%w: drafts %v", ErrInvalidInput, ErrCustomerKeyNotSupported)
     }
 
-    if err := validator.ValidateFileSize(size); err != nil {
-        log.Error("File size validation failed", logger.zap.Error(err))
-        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    // Check the content type and size against the caller's upload rules
+    if err := s.uploadPolicy.Check(opts.Roles, contentType, size); err != nil {
+        log.Error("Upload policy check failed",
+            logger.Strings("roles", opts.Roles),
+            logger.Error(err))
+        return nil, err
+    }
+    if err := s.tenants.CheckContentType(ctx, contentType); err != nil {
+        log.Error("Tenant content type check failed", logger.Error(err))
+        return nil, err
+    }
+
+    // Reject uploads that would exceed the owner's storage quota
+    var usage QuotaUsage
+    if s.quota != nil && opts.staged == nil {
+        var err error
+        usage, err = s.quota.Check(ctx, opts.OwnerID, size)
+        if err != nil {
+            log.Warn("Storage quota check failed",
+                logger.String("ownerId", opts.OwnerID),
+                logger.Error(err))
+            return nil, err
+        }
+    }
+
+    // Reject files disguised as another type; encrypted content has no magic number
+    var header []byte
+    if opts.Encryption == nil {
+        var err error
+        header, reader, err = peekHeader(reader)
+        if err != nil {
+            log.Error("Failed to read file header", logger.Error(err))
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+    }
+    if err := checkMasquerading(log, fileName, header, s.masqueradePolicy); err != nil {
+        return nil, err
+    }
+
+    // Settle what happens to a file of the same name before storing anything
+    fileName, target, err := s.resolveNameConflict(ctx, log, fileName, opts)
+    if err != nil {
+        return nil, err
     }
 
     // Create file record
     file, err := models.NewFile(fileName, size, contentType)
     if err != nil {
-        log.Error("Failed to create file record", logger.zap.Error(err))
+        log.Error("Failed to create file record", logger.Error(err))
         return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
     }
+    file.OwnerID = opts.OwnerID
+    if err := file.MoveTo(opts.Folder); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    if err := file.SetTags(opts.Tags); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    // An overwrite keeps the file's tags unless the upload sets its own
+    if target != nil && len(opts.Tags) == 0 {
+        file.Tags = target.Tags
+    }
+    // An asynchronous upload completes the file the client is polling
+    if opts.staged != nil {
+        file.ID, file.CreatedAt, file.Version = opts.staged.ID, opts.staged.CreatedAt, opts.staged.Version
+    }
+
+    if opts.Draft {
+        if err := file.MarkDraft(s.tenants.DraftTTL(ctx, s.draftTTL)); err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+    }
+
+    // Attach client-side encryption metadata, escrowing the key if requested
+    if opts.Encryption != nil {
+        if err := s.applyClientEncryption(ctx, file, opts); err != nil {
+            log.Error("Client encryption metadata rejected", logger.Error(err))
+            return nil, err
+        }
+    }
 
     // Calculate checksum while uploading
     hash := sha256.New()
     teeReader := io.TeeReader(reader, hash)
 
+    // Buffer previewable markup so a sanitized copy can be derived after upload
+    var original *previewBuffer
+    if s.wantsPreview(contentType, size, opts) && !hasCustomerKey(ctx) {
+        original = &previewBuffer{max: s.previewMaxSize}
+        teeReader = io.TeeReader(teeReader, original)
+    }
+
+    // Inspect the content as it streams to storage; encrypted content is opaque
+    var scanner *validator.ContentScanner
+    if opts.Encryption == nil {
+        scanner = s.contentScanner(contentType)
+    }
+    if scanner != nil {
+        teeReader = io.TeeReader(teeReader, scanner)
+    }
+
     // Get buffer from pool
     buffer := s.workerPool.Get().([]byte)
     defer s.workerPool.Put(buffer)
 
-    // Upload file with progress tracking
-    if err := s.storage.Upload(ctx, file, teeReader); err != nil {
+    // Share upload bandwidth fairly with other users' transfers
+    if s.bandwidth != nil {
+        throttled := s.bandwidth.Reader(ctx, opts.OwnerID, teeReader)
+        defer throttled.Close()
+        teeReader = throttled
+    }
+
+    // Content that is already stored is read, which proves the client
+    // holds it, but not written again
+    shared := s.shareStoredContent(ctx, log, file, opts)
+    if shared {
+        _, err = io.Copy(io.Discard, teeReader)
+    } else {
+        err = s.storage.Upload(ctx, file, teeReader)
+    }
+    if err != nil {
+        if scanner != nil && scanner.Err() != nil {
+            return nil, s.checkContent(ctx, log, scanner, file, false)
+        }
+        if uploadInterrupted(ctx, err) {
+            log.Info("File upload interrupted by client",
+                logger.String("fileId", file.ID))
+            return nil, ErrUploadInterrupted
+        }
+        if errors.Is(err, storage.ErrTenantRequired) {
+            return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+        }
         log.Error("File upload failed", 
-            logger.zap.String("fileId", file.ID),
-            logger.zap.Error(err))
+            logger.String("fileId", file.ID),
+            logger.Error(err))
         return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
     }
+    if scanner != nil {
+        if err := s.checkContent(ctx, log, scanner, file, !shared); err != nil {
+            return nil, err
+        }
+    }
 
     // Update file checksum
     checksum := hex.EncodeToString(hash.Sum(nil))
+    if opts.Checksum != "" && checksum != opts.Checksum {
+        log.Warn("Upload does not match its declared checksum",
+            logger.String("fileId", file.ID),
+            logger.String("declaredChecksum", opts.Checksum),
+            logger.String("checksum", checksum))
+        if !shared {
+            if err := s.storage.Delete(ctx, file, false); err != nil {
+                log.Warn("Failed to delete mismatched upload",
+                    logger.String("fileId", file.ID),
+                    logger.Error(err))
+            }
+        }
+        return nil, ErrInvalidChecksum
+    }
     if err := file.UpdateChecksum(checksum); err != nil {
         log.Error("Failed to update checksum",
-            logger.zap.String("fileId", file.ID),
-            logger.zap.Error(err))
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if !file.IsUploaded() && !file.IsDraft() {
+        if err := file.UpdateStatus(models.FileStatusUploaded); err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+    }
+
+    if original != nil && !original.overflow {
+        s.storePreview(ctx, file, original.buf.Bytes())
+    }
+
+    err = s.withTx(ctx, func(ctx context.Context) error {
+        // Share the stored content with files that have the same content;
+        // content encrypted with a customer-provided key is never shared
+        if !file.IsDraft() && !file.UsesCustomerKey() {
+            if err := s.acquireBlob(ctx, file); err != nil {
+                log.Error("Failed to reference content blob",
+                    logger.String("fileId", file.ID),
+                    logger.Error(err))
+                return err
+            }
+        }
+
+        // Persist file metadata; an overwrite points the existing file at
+        // the new content instead, and an asynchronous upload updates the
+        // pending file
+        persist := s.repo.Create
+        switch {
+        case target != nil:
+            persist = func(ctx context.Context, file *models.File) error {
+                return s.overwrite(ctx, log, target, file)
+            }
+        case opts.staged != nil:
+            persist = s.updateFile
+        }
+        if err := persist(ctx, file); err != nil {
+            log.Error("Failed to persist file record",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+            // Outside a transaction the blob reference is released by hand
+            if s.tx == nil {
+                if _, releaseErr := s.releaseBlob(ctx, file); releaseErr != nil {
+                    log.Warn("Failed to release content blob",
+                        logger.String("fileId", file.ID),
+                        logger.Error(releaseErr))
+                }
+            }
+            return err
+        }
+        return nil
+    })
+    if err != nil {
+        if errors.Is(err, ErrVersionConflict) {
+            return nil, err
+        }
         return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
     }
 
+    if target != nil {
+        // Derived objects were made from the content that was replaced
+        if s.derived != nil {
+            if err := s.derived.Invalidate(ctx, target.ID); err != nil {
+                log.Warn("Failed to invalidate derived objects",
+                    logger.String("fileId", target.ID),
+                    logger.Error(err))
+            }
+        }
+        log.Info("File overwritten",
+            logger.String("fileId", target.ID),
+            logger.Int64("version", target.Version))
+        file = target
+    }
+
+    if s.quota != nil && opts.staged == nil {
+        s.quota.Record(ctx, opts.OwnerID, usage, file.Size)
+    }
+
     log.Info("File upload completed successfully",
-        logger.zap.String("fileId", file.ID),
-        logger.zap.String("checksum", checksum))
+        logger.String("fileId", file.ID),
+        logger.String("checksum", checksum))
 
     return file, nil
 }
 
-// Download handles secure file download with validation
+// Download handles secure file download with validation. The file is
+// loaded from the repository, so files without metadata are not found even
+// when their content is stored.
 func (s *fileService) Download(ctx context.Context, fileID string) (*models.File, io.ReadCloser, error) {
-    log := s.logger.With(logger.zap.String("fileId", fileID))
+    log := s.logger.With(logger.String("fileId", fileID))
 
     // Validate file ID
     if fileID == "" {
@@ -160,28 +510,78 @@ func (s *fileService) Download(ctx context.Context, fileID string) (*models.File
     }
 
     // Get file metadata
-    file := &models.File{ID: fileID}
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return nil, nil, err
+    }
     if !file.IsUploaded() {
         log.Error("File not in uploaded state")
         return nil, nil, ErrFileNotFound
     }
+    if file.NeedsRestore(time.Now()) {
+        log.Warn("Download of archived file refused",
+            logger.String("archiveStatus", file.ArchiveStatus))
+        return nil, nil, ErrFileArchived
+    }
 
     // Download file with validation
+    lastAccessed := file.LastAccessedAt
     reader, err := s.storage.Download(ctx, file)
     if err != nil {
-        log.Error("File download failed", logger.zap.Error(err))
+        if keyErr := customerKeyError(err); keyErr != nil {
+            return nil, nil, keyErr
+        }
+        if errors.Is(err, storage.ErrObjectArchived) {
+            s.recordArchived(ctx, file)
+            return nil, nil, ErrFileArchived
+        }
+        if isTenantIsolated(err) {
+            return nil, nil, ErrFileNotFound
+        }
+        log.Error("File download failed", logger.Error(err))
         return nil, nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
     }
 
+    s.recordAccess(ctx, file, lastAccessed)
+    if s.integrity != nil {
+        reader = s.integrity.Verify(ctx, file, reader)
+    }
     log.Info("File download started")
     return file, reader, nil
 }
 
-// Delete handles secure file deletion with optional soft delete
+// Stat returns the metadata of an uploaded file without opening its content
+func (s *fileService) Stat(ctx context.Context, fileID string) (*models.File, error) {
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return nil, err
+    }
+    if !file.IsUploaded() {
+        return nil, ErrFileNotFound
+    }
+    return file, nil
+}
+
+// Lookup returns a file's metadata whatever its status, including drafts,
+// pending and archived files that Stat reports missing, so access to them
+// is decided on their actual owner
+func (s *fileService) Lookup(ctx context.Context, fileID string) (*models.File, error) {
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+    return s.getFile(ctx, fileID)
+}
+
+// Delete handles secure file deletion with optional soft delete, marking
+// the file's repository record deleted in the same transaction
 func (s *fileService) Delete(ctx context.Context, fileID string, softDelete bool) error {
     log := s.logger.With(
-        logger.zap.String("fileId", fileID),
-        logger.zap.Bool("softDelete", softDelete),
+        logger.String("fileId", fileID),
+        logger.Bool("softDelete", softDelete),
     )
 
     // Validate file ID
@@ -190,18 +590,90 @@ func (s *fileService) Delete(ctx context.Context, fileID string, softDelete bool
     }
 
     // Get file metadata
-    file := &models.File{ID: fileID}
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return err
+    }
     if file.IsDeleted() {
         log.Warn("File already deleted")
         return nil
     }
+    // Retained content may be archived, but not removed
+    if !softDelete && file.IsRetained(time.Now()) {
+        log.Warn("Hard delete of retained file refused",
+            logger.Timep("retainUntil", file.RetainUntil),
+            logger.Bool("legalHold", file.LegalHold))
+        return ErrFileRetained
+    }
 
-    // Delete file with specified option
-    if err := s.storage.Delete(ctx, file, softDelete); err != nil {
-        log.Error("File deletion failed", logger.zap.Error(err))
+    err = s.withTx(ctx, func(ctx context.Context) error {
+        // Shared content is left to the blob collector once unreferenced
+        released, err := s.releaseBlob(ctx, file)
+        if err != nil {
+            log.Error("Failed to release content blob", logger.Error(err))
+            return err
+        }
+
+        // Delete file with specified option
+        if !released {
+            if err := s.storage.Delete(ctx, file, softDelete); err != nil {
+                log.Error("File deletion failed", logger.Error(err))
+                return err
+            }
+        }
+
+        if err := s.repo.Delete(ctx, file.ID); err != nil {
+            log.Error("Failed to mark file record deleted", logger.Error(err))
+            return err
+        }
+        return nil
+    })
+    if err != nil {
+        if keyErr := customerKeyError(err); keyErr != nil {
+            return keyErr
+        }
+        if isTenantIsolated(err) {
+            return ErrFileNotFound
+        }
         return fmt.Errorf("%w: %v", ErrOperationFailed, err)
     }
 
+    // Derived objects are regenerated on demand, so failing to discard them
+    // only leaves orphaned copies behind
+    if s.derived != nil {
+        if err := s.derived.Invalidate(ctx, file.ID); err != nil {
+            log.Warn("Failed to invalidate derived objects", logger.Error(err))
+        }
+    }
+
     log.Info("File deleted successfully")
     return nil
-}
\ No newline at end of file
+}
+
+// withTx runs fn as one unit of work when transactions are configured, and
+// directly otherwise
+func (s *fileService) withTx(ctx context.Context, fn func(ctx context.Context) error) error {
+    if s.tx == nil {
+        return fn(ctx)
+    }
+    return s.tx.WithTx(ctx, fn)
+}
+
+// getFile loads file metadata, mapping missing records to ErrFileNotFound
+func (s *fileService) getFile(ctx context.Context, fileID string) (*models.File, error) {
+    file, err := s.repo.GetByID(ctx, fileID)
+    if err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, ErrFileNotFound
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return file, nil
+}
+
+// isTenantIsolated reports whether storage refused content to the caller's
+// tenant. Such content is reported as not found, so other tenants' files
+// cannot even be told apart from missing ones.
+func isTenantIsolated(err error) bool {
+    return errors.Is(err, storage.ErrTenantMismatch) || errors.Is(err, storage.ErrTenantRequired)
+}
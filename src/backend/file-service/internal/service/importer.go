@@ -0,0 +1,244 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "mime"
+    "net/http"
+    "path"
+    "strings"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// genericContentTypes are content types that say nothing about the content,
+// which S3 and most clients default to
+var genericContentTypes = map[string]bool{
+    "":                         true,
+    "application/octet-stream": true,
+    "binary/octet-stream":      true,
+}
+
+// ImportOptions configure adopting existing objects as files
+type ImportOptions struct {
+    // Prefix selects the objects imported. The directories of a key below
+    // the prefix's own directory become folders.
+    Prefix string
+    // Folder is the folder the imported folders are nested under
+    Folder string
+    // OwnerID is the user charged for the imported files
+    OwnerID string
+    // Move deletes each original once its file is created; otherwise the
+    // originals are left in place
+    Move bool
+    // DryRun only reports the files that would be created
+    DryRun bool
+    // BatchSize bounds the objects listed per request, at most 1000
+    BatchSize int
+    // Imported, if set, is called with each file created, or that would
+    // be in a dry run, and the key of the object it was created from
+    Imported func(key string, file *models.File)
+}
+
+// ImportResult reports what an import found and did
+type ImportResult struct {
+    Scanned  int
+    Imported int
+    // Skipped counts objects that are not imported: empty objects and
+    // directory markers, objects the service already stores, and objects
+    // whose name is taken in their folder, as by an earlier import
+    Skipped int
+    // Failed maps the keys of objects that could not be imported to the reason
+    Failed map[string]error
+}
+
+// Importer creates files for objects written to the bucket outside the
+// service, so it can adopt existing data
+type Importer interface {
+    Import(ctx context.Context) (*ImportResult, error)
+}
+
+// importer implements Importer
+type importer struct {
+    files   repository.FileRepository
+    objects storage.ImportStorage
+    opts    ImportOptions
+    logger  *logger.Logger
+}
+
+// NewImporter creates a new instance of importer
+func NewImporter(files repository.FileRepository, objects storage.ImportStorage, opts ImportOptions) (Importer, error) {
+    if files == nil || objects == nil {
+        return nil, errors.New("file repository and import storage are required")
+    }
+    if opts.BatchSize <= 0 || opts.BatchSize > 1000 {
+        return nil, errors.New("import batch size must be between 1 and 1000")
+    }
+    if err := (&models.File{}).MoveTo(opts.Folder); err != nil {
+        return nil, fmt.Errorf("invalid import folder: %w", err)
+    }
+
+    return &importer{
+        files:   files,
+        objects: objects,
+        opts:    opts,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// Import lists the objects under the prefix and creates an uploaded file
+// for each, copying its content to a key of the service's layout. Objects
+// the service already stores are skipped, as are objects whose name is
+// taken in their folder, so an interrupted import can be rerun.
+func (i *importer) Import(ctx context.Context) (*ImportResult, error) {
+    log := i.logger.With(
+        logger.String("prefix", i.opts.Prefix),
+        logger.Bool("dryRun", i.opts.DryRun))
+
+    result := &ImportResult{Failed: make(map[string]error)}
+    startAfter := ""
+    for {
+        page, err := i.objects.ListPrefix(ctx, i.opts.Prefix, startAfter, i.opts.BatchSize)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        if len(page) == 0 {
+            break
+        }
+        startAfter = page[len(page)-1].Key
+
+        for _, object := range page {
+            if ctx.Err() != nil {
+                return nil, ctx.Err()
+            }
+            result.Scanned++
+            file, err := i.importObject(ctx, object)
+            switch {
+            case err != nil:
+                log.Warn("Failed to import object",
+                    logger.String("key", object.Key),
+                    logger.Error(err))
+                result.Failed[object.Key] = err
+            case file == nil:
+                result.Skipped++
+            default:
+                result.Imported++
+                if i.opts.Imported != nil {
+                    i.opts.Imported(object.Key, file)
+                }
+            }
+        }
+    }
+
+    log.Info("Import completed",
+        logger.Int("scanned", result.Scanned),
+        logger.Int("imported", result.Imported),
+        logger.Int("skipped", result.Skipped),
+        logger.Int("failed", len(result.Failed)))
+    return result, nil
+}
+
+// importObject creates the file for one object, returning nil if the
+// object is skipped
+func (i *importer) importObject(ctx context.Context, object storage.StoredObject) (*models.File, error) {
+    if object.Size == 0 || strings.HasSuffix(object.Key, "/") || serviceKey(object.Key) {
+        return nil, nil
+    }
+    referenced, err := i.files.StoragePathReferenced(ctx, object.Key)
+    if err != nil {
+        return nil, err
+    }
+    if referenced {
+        return nil, nil
+    }
+
+    folder, fileName := i.placement(object.Key)
+    _, err = i.files.FindByName(ctx, folder, fileName)
+    if err == nil {
+        return nil, nil
+    }
+    if !errors.Is(err, repository.ErrNotFound) {
+        return nil, err
+    }
+
+    info, err := i.objects.InspectObject(ctx, object.Key)
+    if err != nil {
+        return nil, err
+    }
+    file, err := models.NewFile(fileName, info.Size, importContentType(fileName, info))
+    if err != nil {
+        return nil, err
+    }
+    file.OwnerID = i.opts.OwnerID
+    if err := file.MoveTo(folder); err != nil {
+        return nil, err
+    }
+    if i.opts.DryRun {
+        return file, nil
+    }
+
+    if err := i.objects.AdoptObject(ctx, file, object.Key); err != nil {
+        return nil, err
+    }
+    if err := file.UpdateStatus(models.FileStatusUploaded); err != nil {
+        return nil, err
+    }
+    if err := i.files.Create(ctx, file); err != nil {
+        if deleteErr := i.objects.DeleteObject(ctx, file.StoragePath); deleteErr != nil {
+            i.logger.Warn("Failed to delete copy of unimported object",
+                logger.String("storagePath", file.StoragePath),
+                logger.Error(deleteErr))
+        }
+        return nil, err
+    }
+
+    if i.opts.Move {
+        if err := i.objects.DeleteObject(ctx, object.Key); err != nil {
+            return nil, fmt.Errorf("imported as %s, but failed to delete the original: %w", file.ID, err)
+        }
+    }
+    return file, nil
+}
+
+// placement returns the folder and name of the file for the object under
+// key: its directories below the prefix's, nested under the import folder
+func (i *importer) placement(key string) (string, string) {
+    base := i.opts.Prefix[:strings.LastIndex(i.opts.Prefix, "/")+1]
+    dir, fileName := path.Split(strings.TrimPrefix(key, base))
+    return path.Join(i.opts.Folder, dir), fileName
+}
+
+// serviceKey checks if key is one the service writes: content in its key
+// layout, or an object under one of its own prefixes
+func serviceKey(key string) bool {
+    if strings.HasPrefix(key, storagekey.TenantPrefix+"/") {
+        return true
+    }
+    for _, prefix := range storagekey.Default.Prefixes {
+        if strings.HasPrefix(key, prefix+"/") {
+            return true
+        }
+    }
+    return storagekey.Default.Validate(key) == nil && !storagekey.Default.IsLegacy(key)
+}
+
+// importContentType returns the content type of an imported object: the
+// type it was stored with, unless that is generic, then the type of its
+// extension, then the type detected from its content
+func importContentType(fileName string, info *storage.ObjectInfo) string {
+    if !genericContentTypes[info.ContentType] {
+        return info.ContentType
+    }
+    if byExtension := mime.TypeByExtension(path.Ext(fileName)); byExtension != "" {
+        return byExtension
+    }
+    if len(info.Header) > 0 {
+        return http.DetectContentType(info.Header)
+    }
+    return "application/octet-stream"
+}
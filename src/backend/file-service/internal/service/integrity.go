@@ -0,0 +1,330 @@
+package service
+
+import (
+    "bufio"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "hash"
+    "io"
+    "strings"
+    "sync"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/errtrack"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// integrityJob is the background job investigating a checksum mismatch
+const integrityJob = "integrity-incident"
+
+// IntegrityDetectedByDownload is a mismatch found reading a download
+const IntegrityDetectedByDownload = "download"
+
+// IntegrityGuard checks downloaded content against the checksum taken on
+// upload and investigates the files whose content does not match it
+type IntegrityGuard interface {
+    // Verify wraps the content of a download of the file. Whole content is
+    // checked as it is read, and content not matching the checksum fails
+    // with ErrInvalidChecksum in place of its last bytes, so a client never
+    // receives it complete, and starts an investigation in the background.
+    Verify(ctx context.Context, file *models.File, body io.ReadCloser) io.ReadCloser
+    // Investigate handles content of the file found with checksum actual,
+    // bytesRead long: the file is frozen as corrupted, the content read
+    // again and compared with its replica, repaired from an intact replica,
+    // and the incident recorded. It returns nil when the file is already
+    // being investigated, or no longer holds the content found damaged.
+    Investigate(ctx context.Context, file *models.File, detectedBy, actual string,
+        bytesRead int64) (*models.IntegrityIncident, error)
+}
+
+// integrityGuard implements IntegrityGuard
+type integrityGuard struct {
+    files   repository.FileRepository
+    content storage.IntegrityStorage
+    replica storage.ReplicaStorage
+    audit   repository.AuditRepository
+    // incidents counts investigated mismatches by outcome
+    incidents metrics.Counter
+    // active holds the IDs of the files under investigation
+    active sync.Map
+    logger *logger.Logger
+}
+
+// NewIntegrityGuard creates a new instance of integrityGuard. Without a
+// replica damaged content cannot be repaired, and without an audit
+// repository incidents are only logged and reported.
+func NewIntegrityGuard(files repository.FileRepository, content storage.IntegrityStorage,
+    replica storage.ReplicaStorage, audit repository.AuditRepository, provider metrics.Provider) (IntegrityGuard, error) {
+    if files == nil || content == nil {
+        return nil, errors.New("file repository and integrity storage are required")
+    }
+
+    return &integrityGuard{
+        files:   files,
+        content: content,
+        replica: replica,
+        audit:   audit,
+        incidents: metrics.OrNop(provider).Counter(metrics.Opts{
+            Name:   "integrity_incidents_total",
+            Help:   "Content found not to match its checksum by the outcome of its investigation",
+            Labels: []string{"outcome"},
+        }),
+        logger: logger.GetLogger(),
+    }, nil
+}
+
+// WithIntegrityGuard checks downloads against their checksums with guard
+func WithIntegrityGuard(guard IntegrityGuard) Option {
+    return func(s *fileService) {
+        s.integrity = guard
+    }
+}
+
+// Verify wraps the content of a download of the file. Content transformed
+// on the way out, read in a range, or with a checksum over the checksums of
+// its parts cannot be compared and is returned as is.
+func (g *integrityGuard) Verify(ctx context.Context, file *models.File, body io.ReadCloser) io.ReadCloser {
+    if file.Checksum == "" || strings.Contains(file.Checksum, "-") || storage.ObjectLambdaFrom(ctx) != "" {
+        return body
+    }
+    if rng := storage.ContentRangeFrom(ctx); rng != nil && rng.Served {
+        return body
+    }
+
+    snapshot := *file
+    return &verifyingReader{
+        body:     body,
+        buffered: bufio.NewReader(body),
+        hash:     sha256.New(),
+        expected: file.Checksum,
+        mismatch: func(actual string, bytesRead int64) {
+            g.investigate(ctx, &snapshot, actual, bytesRead)
+        },
+    }
+}
+
+// investigate starts the investigation of a mismatch in the background, as
+// the download that found it ends
+func (g *integrityGuard) investigate(ctx context.Context, file *models.File, actual string, bytesRead int64) {
+    jobCtx, trace := tracing.StartJob(context.WithoutCancel(ctx), integrityJob)
+    done := telemetry.TrackJob(jobCtx, trace.Name)
+    go func() {
+        _, err := g.Investigate(jobCtx, file, IntegrityDetectedByDownload, actual, bytesRead)
+        if err != nil {
+            g.logger.Error("Failed to investigate checksum mismatch",
+                append(trace.Fields(), logger.String("fileId", file.ID), logger.Error(err))...)
+        }
+        done(err)
+    }()
+}
+
+// Investigate handles content of the file found not to match its checksum
+func (g *integrityGuard) Investigate(ctx context.Context, file *models.File, detectedBy, actual string,
+    bytesRead int64) (*models.IntegrityIncident, error) {
+    if _, running := g.active.LoadOrStore(file.ID, struct{}{}); running {
+        return nil, nil
+    }
+    defer g.active.Delete(file.ID)
+
+    current, err := g.files.GetByID(ctx, file.ID)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if !current.IsUploaded() || current.Checksum != file.Checksum || current.StoragePath != file.StoragePath {
+        return nil, nil
+    }
+
+    incident := &models.IntegrityIncident{
+        FileID:            current.ID,
+        StoragePath:       current.StoragePath,
+        Size:              current.Size,
+        DetectedBy:        detectedBy,
+        Expected:          current.Checksum,
+        Actual:            actual,
+        BytesRead:         bytesRead,
+        ReplicationStatus: current.ReplicationStatus,
+        Outcome:           models.IntegrityUnrepaired,
+        DetectedAt:        time.Now().UTC(),
+    }
+    // Storage reads the content of the file as it was stored, uploaded
+    stored := *current
+
+    // Stop serving the content while it is investigated
+    if err := g.setStatus(ctx, current, models.FileStatusCorrupted); err != nil {
+        incident.Fail("freeze", err)
+    }
+
+    // A second read tells damaged content from a read that went wrong
+    incident.Reread, err = g.checksum(g.content.Download(ctx, &stored))
+    if err != nil {
+        incident.Fail("reread", err)
+    }
+    switch {
+    case incident.Reread == incident.Expected:
+        incident.Outcome = models.IntegrityTransient
+    case g.repair(ctx, &stored, current, incident):
+        incident.Outcome = models.IntegrityRepaired
+    }
+    if incident.Outcome != models.IntegrityUnrepaired {
+        if err := g.setStatus(ctx, current, models.FileStatusUploaded); err != nil {
+            incident.Fail("release", err)
+        }
+    }
+
+    incident.ResolvedAt = time.Now().UTC()
+    g.record(ctx, incident)
+    return incident, nil
+}
+
+// repair replaces the stored content with its replica if the replica is
+// intact, reporting whether the stored content then matches its checksum
+func (g *integrityGuard) repair(ctx context.Context, stored, current *models.File,
+    incident *models.IntegrityIncident) bool {
+    switch {
+    case g.replica == nil:
+        incident.Fail("replica", errors.New("no secondary bucket is configured"))
+        return false
+    case stored.ReplicationStatus != models.ReplicationReplicated:
+        incident.Fail("replica", fmt.Errorf("content is not replicated (%s)", stored.ReplicationStatus))
+        return false
+    }
+
+    var err error
+    incident.Replica, err = g.checksum(g.replica.ReadReplica(ctx, stored))
+    if err != nil {
+        incident.Fail("replica", err)
+        return false
+    }
+    if incident.Replica != incident.Expected {
+        incident.Fail("replica", errors.New("replica does not match the checksum either"))
+        return false
+    }
+
+    if err := g.replica.RestoreReplica(ctx, current); err != nil {
+        incident.Fail("repair", err)
+        return false
+    }
+    repaired, err := g.checksum(g.content.Download(ctx, stored))
+    if err != nil {
+        incident.Fail("repair", err)
+        return false
+    }
+    if repaired != incident.Expected {
+        incident.Fail("repair", errors.New("restored content does not match the checksum"))
+        return false
+    }
+    return true
+}
+
+// checksum returns the SHA-256 of the content of a download
+func (g *integrityGuard) checksum(body io.ReadCloser, err error) (string, error) {
+    if err != nil {
+        return "", err
+    }
+    defer body.Close()
+    hash := sha256.New()
+    if _, err := io.Copy(hash, body); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// setStatus moves the file to status and saves it
+func (g *integrityGuard) setStatus(ctx context.Context, file *models.File, status string) error {
+    if err := file.UpdateStatus(status); err != nil {
+        return err
+    }
+    return g.files.Update(ctx, file)
+}
+
+// record logs, reports and audits an incident
+func (g *integrityGuard) record(ctx context.Context, incident *models.IntegrityIncident) {
+    g.incidents.Inc(incident.Outcome)
+
+    fields := []logger.Field{
+        logger.String("fileId", incident.FileID),
+        logger.String("storagePath", incident.StoragePath),
+        logger.String("detectedBy", incident.DetectedBy),
+        logger.String("expectedChecksum", incident.Expected),
+        logger.String("actualChecksum", incident.Actual),
+        logger.Int64("bytesRead", incident.BytesRead),
+        logger.String("rereadChecksum", incident.Reread),
+        logger.String("replicaChecksum", incident.Replica),
+        logger.String("outcome", incident.Outcome),
+        logger.Strings("errors", incident.Errors),
+    }
+    if incident.Outcome == models.IntegrityTransient {
+        g.logger.Warn("Checksum mismatch not repeated on a second read", fields...)
+    } else {
+        g.logger.Error("Corrupted file content detected", fields...)
+        errtrack.CaptureError(ctx, fmt.Errorf("%w: file %s", ErrInvalidChecksum, incident.FileID),
+            map[string]string{"fileId": incident.FileID, "outcome": incident.Outcome})
+    }
+
+    if g.audit == nil {
+        return
+    }
+    if err := g.audit.Append(ctx, models.NewIntegrityAuditEvent(incident)); err != nil {
+        g.logger.Error("Failed to record integrity incident",
+            logger.String("fileId", incident.FileID),
+            logger.Error(err))
+    }
+}
+
+// verifyingReader hashes content as it is read and compares it with the
+// expected checksum at its end. The bytes of each read are only returned
+// once more content is known to follow or the content was verified, so
+// content that does not match ends with an error rather than complete.
+type verifyingReader struct {
+    body     io.ReadCloser
+    buffered *bufio.Reader
+    hash     hash.Hash
+    expected string
+    read     int64
+    mismatch func(actual string, bytesRead int64)
+    err      error
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+    if r.err != nil {
+        return 0, r.err
+    }
+
+    n, err := r.buffered.Read(p)
+    r.hash.Write(p[:n])
+    r.read += int64(n)
+    if err == nil {
+        if _, err := r.buffered.Peek(1); err != io.EOF {
+            return n, nil
+        }
+        err = io.EOF
+    }
+    if err != io.EOF {
+        r.err = err
+        return n, err
+    }
+
+    r.err = io.EOF
+    if actual := hex.EncodeToString(r.hash.Sum(nil)); actual != r.expected {
+        r.err = fmt.Errorf("%w: content read does not match the file's checksum", ErrInvalidChecksum)
+        r.mismatch(actual, r.read)
+        return 0, r.err
+    }
+    if n > 0 {
+        return n, nil
+    }
+    return 0, io.EOF
+}
+
+func (r *verifyingReader) Close() error {
+    return r.body.Close()
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+)
+
+// Lock errors
+var (
+    ErrFileLocked   = errors.New("file is locked by another user")
+    ErrLockNotFound = errors.New("file is not locked")
+)
+
+// LockService manages checkout locks on files. While a user holds a lock,
+// other users cannot modify the file.
+type LockService interface {
+    Lock(ctx context.Context, fileID, userID string, ttl time.Duration) (*models.FileLock, error)
+    Unlock(ctx context.Context, fileID, userID string, force bool) error
+    Get(ctx context.Context, fileID string) (*models.FileLock, error)
+    CheckWrite(ctx context.Context, fileID, userID string) (*models.FileLock, error)
+}
+
+// lockService implements LockService
+type lockService struct {
+    locks      repository.LockRepository
+    files      repository.FileRepository
+    defaultTTL time.Duration
+    maxTTL     time.Duration
+    now        func() time.Time
+    logger     *logger.Logger
+}
+
+// NewLockService creates a new instance of lockService. Locks requested
+// without a TTL last defaultTTL; longer than maxTTL is rejected.
+func NewLockService(locks repository.LockRepository, files repository.FileRepository, defaultTTL, maxTTL time.Duration) (LockService, error) {
+    if locks == nil || files == nil {
+        return nil, errors.New("lock and file repositories are required")
+    }
+    if defaultTTL <= 0 || maxTTL < defaultTTL {
+        return nil, errors.New("invalid lock TTLs")
+    }
+
+    return &lockService{
+        locks:      locks,
+        files:      files,
+        defaultTTL: defaultTTL,
+        maxTTL:     maxTTL,
+        now:        time.Now,
+        logger:     logger.GetLogger(),
+    }, nil
+}
+
+// Lock takes or extends the user's lock on a file. When another user holds
+// it, the current lock is returned with ErrFileLocked.
+func (s *lockService) Lock(ctx context.Context, fileID, userID string, ttl time.Duration) (*models.FileLock, error) {
+    if fileID == "" || userID == "" {
+        return nil, ErrInvalidInput
+    }
+    if ttl == 0 {
+        ttl = s.defaultTTL
+    }
+    if ttl < 0 || ttl > s.maxTTL {
+        return nil, fmt.Errorf("%w: lock TTL must be positive and at most %s", ErrInvalidInput, s.maxTTL)
+    }
+
+    if _, err := s.files.GetByID(ctx, fileID); err != nil {
+        if errors.Is(err, repository.ErrNotFound) {
+            return nil, ErrFileNotFound
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    now := s.now().UTC()
+    lock := &models.FileLock{
+        FileID:    fileID,
+        OwnerID:   userID,
+        ExpiresAt: now.Add(ttl),
+        CreatedAt: now,
+    }
+
+    err := s.locks.Acquire(ctx, lock)
+    if errors.Is(err, repository.ErrLockHeld) {
+        held, getErr := s.locks.Get(ctx, fileID)
+        if getErr != nil {
+            return nil, ErrFileLocked
+        }
+        return held, ErrFileLocked
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return lock, nil
+}
+
+// Unlock releases the user's lock on a file. Forcing breaks a lock held by
+// anyone and is reserved for administrators by the caller.
+func (s *lockService) Unlock(ctx context.Context, fileID, userID string, force bool) error {
+    if fileID == "" {
+        return ErrInvalidInput
+    }
+
+    if force {
+        if err := s.locks.Break(ctx, fileID); err != nil {
+            return s.mapLockError(err)
+        }
+        s.logger.Warn("File lock broken",
+            logger.String("fileId", fileID),
+            logger.String("brokenBy", userID))
+        return nil
+    }
+
+    lock, err := s.Get(ctx, fileID)
+    if err != nil {
+        return err
+    }
+    if lock.OwnerID != userID {
+        return ErrFileLocked
+    }
+    return s.mapLockError(s.locks.Release(ctx, fileID, userID))
+}
+
+// Get returns the unexpired lock on a file
+func (s *lockService) Get(ctx context.Context, fileID string) (*models.FileLock, error) {
+    lock, err := s.locks.Get(ctx, fileID)
+    if err != nil {
+        return nil, s.mapLockError(err)
+    }
+    if lock.IsExpired(s.now()) {
+        return nil, ErrLockNotFound
+    }
+    return lock, nil
+}
+
+// CheckWrite returns ErrFileLocked, with the lock, if another user holds an
+// unexpired lock on the file
+func (s *lockService) CheckWrite(ctx context.Context, fileID, userID string) (*models.FileLock, error) {
+    lock, err := s.Get(ctx, fileID)
+    if errors.Is(err, ErrLockNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if lock.OwnerID != userID {
+        return lock, ErrFileLocked
+    }
+    return lock, nil
+}
+
+// mapLockError maps repository lock errors to service errors
+func (s *lockService) mapLockError(err error) error {
+    switch {
+    case err == nil:
+        return nil
+    case errors.Is(err, repository.ErrLockNotFound):
+        return ErrLockNotFound
+    default:
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+}
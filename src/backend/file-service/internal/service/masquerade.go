@@ -0,0 +1,49 @@
+package service
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/validator"
+)
+
+// WithMasqueradePolicy overrides how double extensions, bidirectional control
+// characters and disguised executables are handled. By default they are rejected.
+func WithMasqueradePolicy(policy validator.MasqueradePolicy) Option {
+    return func(s *fileService) {
+        s.masqueradePolicy = policy
+    }
+}
+
+// checkMasquerading applies the masquerade policy, logging findings that are
+// only flagged. Rejections wrap both ErrInvalidInput and the ValidationError
+// so handlers can report the structured code.
+func checkMasquerading(log *logger.Logger, fileName string, header []byte, policy validator.MasqueradePolicy) error {
+    findings, err := validator.CheckMasquerading(fileName, header, policy)
+    if err != nil {
+        log.Warn("Masqueraded file rejected", logger.Error(err))
+        return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+    }
+
+    for _, finding := range findings {
+        log.Warn("Masqueraded file flagged",
+            logger.String("code", finding.Code),
+            logger.String("reason", finding.Message))
+    }
+    return nil
+}
+
+// peekHeader reads the leading bytes of the content for magic number checks
+// and returns a reader that still yields the full content
+func peekHeader(reader io.Reader) ([]byte, io.Reader, error) {
+    header := make([]byte, validator.HeaderSize)
+    n, err := io.ReadFull(reader, header)
+    if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+        return nil, nil, err
+    }
+    header = header[:n]
+    return header, io.MultiReader(bytes.NewReader(header), reader), nil
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "path"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/validator"
+)
+
+// What an upload does when its folder already holds a file of the same name
+const (
+    // NameConflictKeep stores the upload as another, unrelated file
+    NameConflictKeep = "keep"
+    // NameConflictRename stores the upload under the first free numbered
+    // name, such as "report (1).pdf"
+    NameConflictRename = "rename"
+    // NameConflictOverwrite replaces the existing file's content, keeping
+    // its ID and bumping its metadata version
+    NameConflictOverwrite = "overwrite"
+    // NameConflictReject refuses the upload with a NameConflictError
+    NameConflictReject = "reject"
+)
+
+// maxRenameAttempts bounds the numbered names tried for a renamed upload
+const maxRenameAttempts = 100
+
+// ErrNameConflict is matched by NameConflictError
+var ErrNameConflict = errors.New("file name already exists in folder")
+
+// NameConflictError is returned for uploads refused because their folder
+// already holds a file of the same name
+type NameConflictError struct {
+    // Existing is the file holding the name
+    Existing *models.File
+}
+
+func (e *NameConflictError) Error() string {
+    return fmt.Sprintf("%v: %s", ErrNameConflict, e.Existing.ID)
+}
+
+func (e *NameConflictError) Unwrap() error {
+    return ErrNameConflict
+}
+
+// WithNameConflicts sets what uploads not choosing a strategy do when their
+// folder already holds a file of the same name. Overwrites of files locked
+// by another user are refused with ErrFileLocked; locks may be nil.
+func WithNameConflicts(strategy string, locks LockService) Option {
+    return func(s *fileService) {
+        s.nameConflict = strategy
+        s.locks = locks
+    }
+}
+
+// ValidNameConflict reports whether strategy is a known name conflict
+// strategy; the empty strategy uses the service's default
+func ValidNameConflict(strategy string) bool {
+    switch strategy {
+    case "", NameConflictKeep, NameConflictRename, NameConflictOverwrite, NameConflictReject:
+        return true
+    }
+    return false
+}
+
+// resolveNameConflict applies the upload's name conflict strategy. It
+// returns the name to store the upload under and, when overwriting, the
+// file whose content the upload replaces.
+func (s *fileService) resolveNameConflict(ctx context.Context, log *logger.Logger, fileName string, opts UploadOptions) (string, *models.File, error) {
+    strategy := opts.NameConflict
+    if strategy == "" {
+        strategy = s.nameConflict
+    }
+    if !ValidNameConflict(strategy) {
+        return "", nil, fmt.Errorf("%w: unknown name conflict strategy %q", ErrInvalidInput, strategy)
+    }
+    if strategy == "" || strategy == NameConflictKeep {
+        return fileName, nil, nil
+    }
+    if strategy == NameConflictOverwrite && opts.Draft {
+        return "", nil, fmt.Errorf("%w: drafts cannot overwrite files", ErrInvalidInput)
+    }
+
+    existing, err := s.findByName(ctx, opts.Folder, fileName)
+    if err != nil || existing == nil {
+        return fileName, nil, err
+    }
+
+    switch strategy {
+    case NameConflictReject:
+        log.Info("Upload rejected by name conflict",
+            logger.String("existingFileId", existing.ID))
+        return "", nil, &NameConflictError{Existing: existing}
+    case NameConflictRename:
+        name, err := s.freeName(ctx, fileName, existing)
+        return name, nil, err
+    }
+
+    if err := s.checkOverwrite(ctx, existing, opts); err != nil {
+        log.Warn("Overwrite refused",
+            logger.String("existingFileId", existing.ID),
+            logger.Error(err))
+        return "", nil, err
+    }
+    return fileName, existing, nil
+}
+
+// findByName returns the file named fileName in folder, or nil if there is none
+func (s *fileService) findByName(ctx context.Context, folder, fileName string) (*models.File, error) {
+    file, err := s.repo.FindByName(ctx, folder, fileName)
+    if errors.Is(err, repository.ErrNotFound) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return file, nil
+}
+
+// freeName returns the first numbered variant of fileName no file in the
+// folder of existing holds. Concurrent uploads may pick the same name.
+func (s *fileService) freeName(ctx context.Context, fileName string, existing *models.File) (string, error) {
+    ext := path.Ext(fileName)
+    base := strings.TrimSuffix(fileName, ext)
+    if base == "" {
+        // Dot files, such as ".env", have no extension
+        base, ext = fileName, ""
+    }
+    for n := 1; n <= maxRenameAttempts; n++ {
+        candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+        if err := validator.ValidateFileName(candidate); err != nil {
+            return "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+        }
+        taken, err := s.findByName(ctx, existing.Folder, candidate)
+        if err != nil {
+            return "", err
+        }
+        if taken == nil {
+            return candidate, nil
+        }
+    }
+    return "", &NameConflictError{Existing: existing}
+}
+
+// checkOverwrite refuses overwrites of content that must be kept or that
+// another user has locked
+func (s *fileService) checkOverwrite(ctx context.Context, existing *models.File, opts UploadOptions) error {
+    if existing.IsRetained(time.Now()) {
+        return ErrFileRetained
+    }
+    if existing.ArchiveStatus != "" {
+        return ErrFileArchived
+    }
+    if s.locks != nil {
+        if _, err := s.locks.CheckWrite(ctx, existing.ID, opts.OwnerID); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// overwrite points existing at the content uploaded for file, then releases
+// the content existing held. It runs in the upload's unit of work, after
+// file's content has been referenced.
+func (s *fileService) overwrite(ctx context.Context, log *logger.Logger, existing, file *models.File) error {
+    previous := *existing
+    existing.Size = file.Size
+    existing.ContentType = file.ContentType
+    existing.Status = file.Status
+    existing.StoragePath = file.StoragePath
+    existing.Checksum = file.Checksum
+    existing.Encryption = file.Encryption
+    existing.ServerSideEncryption = file.ServerSideEncryption
+    existing.PreviewStoragePath = file.PreviewStoragePath
+    existing.Tags = file.Tags
+    existing.RetentionMode = file.RetentionMode
+    existing.RetainUntil = file.RetainUntil
+    existing.LegalHold = file.LegalHold
+    if err := s.updateFile(ctx, existing); err != nil {
+        return err
+    }
+
+    // The new content has not been copied to the secondary bucket
+    if existing.ReplicationStatus != "" {
+        existing.ReplicationStatus = ""
+        existing.ReplicationAttempts = 0
+        existing.ReplicatedAt = nil
+        if err := s.repo.UpdateReplication(ctx, existing); err != nil {
+            return err
+        }
+    }
+
+    // Shared content is left to the blob collector once unreferenced
+    released, err := s.releaseBlob(ctx, &previous)
+    if err != nil {
+        return err
+    }
+    if !released && previous.StoragePath != "" && previous.StoragePath != existing.StoragePath {
+        if err := s.storage.Delete(ctx, &previous, false); err != nil {
+            log.Warn("Failed to delete overwritten content",
+                logger.String("fileId", existing.ID),
+                logger.String("storagePath", previous.StoragePath),
+                logger.Error(err))
+        }
+    }
+    if previous.PreviewStoragePath != "" && s.previewObjects != nil {
+        if err := s.previewObjects.DeleteObject(ctx, previous.PreviewStoragePath); err != nil {
+            log.Warn("Failed to delete overwritten preview",
+                logger.String("fileId", existing.ID),
+                logger.Error(err))
+        }
+    }
+    return nil
+}
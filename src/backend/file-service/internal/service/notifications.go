@@ -0,0 +1,458 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/notify"
+    "src/backend/file-service/pkg/tracing"
+)
+
+const (
+    // FileSharedEvent is the webhook event type sent when a file is shared with a user
+    FileSharedEvent = "file.shared"
+    // RequestUploadEvent is the webhook event type sent when a file arrives
+    // through one of the user's file requests
+    RequestUploadEvent = "file_request.upload_received"
+    // StaleAccessEvent is the webhook event type sent when an owner's shares
+    // or file requests have gone unused
+    StaleAccessEvent = "access.stale"
+    // FileDownloadedEvent is the webhook event type sent when one of the
+    // user's watched files is downloaded
+    FileDownloadedEvent = "file.downloaded"
+    // digestBatchSize bounds the number of digests sent per run
+    digestBatchSize = 100
+    // maxDigestFiles bounds the uploads listed in a single digest
+    maxDigestFiles = 50
+)
+
+// ShareEvent describes a file being shared with a user
+type ShareEvent struct {
+    FileID     string `json:"fileId"`
+    FileName   string `json:"fileName"`
+    SharedBy   string `json:"sharedBy"`
+    SharedWith string `json:"sharedWith"`
+}
+
+// RequestUpload describes a file uploaded through a file request
+type RequestUpload struct {
+    RequestID string `json:"requestId"`
+    Title     string `json:"title"`
+    OwnerID   string `json:"ownerId"`
+    FileID    string `json:"fileId"`
+    FileName  string `json:"fileName"`
+    Size      int64  `json:"size"`
+    Uploader  string `json:"uploader,omitempty"`
+}
+
+// DownloadEvent describes a download of a user's file. DownloadedBy is empty
+// for callers without a user, such as other services.
+type DownloadEvent struct {
+    FileID       string    `json:"fileId"`
+    FileName     string    `json:"fileName"`
+    Folder       string    `json:"folder"`
+    OwnerID      string    `json:"ownerId"`
+    DownloadedBy string    `json:"downloadedBy,omitempty"`
+    IP           string    `json:"ip"`
+    DownloadedAt time.Time `json:"downloadedAt"`
+}
+
+// StaleAccess lists an owner's shares and file requests that went unused for
+// the review period. Revoked is set when they were revoked automatically.
+type StaleAccess struct {
+    OwnerID      string                `json:"ownerId"`
+    StaleAfter   time.Duration         `json:"-"`
+    Shares       []*models.Share       `json:"shares"`
+    FileRequests []*models.FileRequest `json:"fileRequests"`
+    Revoked      bool                  `json:"revoked"`
+}
+
+// NotificationService manages notification preferences and delivers share
+// and download alerts immediately and folder upload digests on a schedule
+type NotificationService interface {
+    GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+    UpdatePreferences(ctx context.Context, prefs *models.NotificationPreferences) (*models.NotificationPreferences, error)
+    NotifyShare(ctx context.Context, event ShareEvent) error
+    NotifyRequestUpload(ctx context.Context, event RequestUpload) error
+    NotifyStaleAccess(ctx context.Context, event StaleAccess) error
+    NotifyDownload(ctx context.Context, event DownloadEvent) error
+    SendDigests(ctx context.Context) (int, error)
+}
+
+// notificationService implements NotificationService
+type notificationService struct {
+    prefs          repository.NotificationRepository
+    files          repository.FileRepository
+    channels       map[string]notify.Notifier
+    digestInterval time.Duration
+    timeout        time.Duration
+    now            func() time.Time
+    logger         *logger.Logger
+}
+
+// NewNotificationService creates a new instance of notificationService. email
+// may be nil when no email provider is configured, which disables the email
+// channel and digests.
+func NewNotificationService(prefs repository.NotificationRepository, files repository.FileRepository,
+    email, webhook notify.Notifier, digestInterval, timeout time.Duration) (NotificationService, error) {
+    if prefs == nil || files == nil {
+        return nil, errors.New("notification and file repositories are required")
+    }
+    if webhook == nil {
+        return nil, errors.New("webhook notifier is required")
+    }
+    if digestInterval <= 0 || timeout <= 0 {
+        return nil, errors.New("invalid digest interval or delivery timeout")
+    }
+
+    channels := map[string]notify.Notifier{models.ChannelWebhook: webhook}
+    if email != nil {
+        channels[models.ChannelEmail] = email
+    }
+
+    return &notificationService{
+        prefs:          prefs,
+        files:          files,
+        channels:       channels,
+        digestInterval: digestInterval,
+        timeout:        timeout,
+        now:            time.Now,
+        logger:         logger.GetLogger(),
+    }, nil
+}
+
+// GetPreferences returns the user's preferences, or the defaults if none were saved
+func (s *notificationService) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+    if userID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    prefs, err := s.prefs.Get(ctx, userID)
+    if errors.Is(err, repository.ErrPreferencesNotFound) {
+        return models.DefaultNotificationPreferences(userID), nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return prefs, nil
+}
+
+// UpdatePreferences validates and replaces the user's preferences
+func (s *notificationService) UpdatePreferences(ctx context.Context, prefs *models.NotificationPreferences) (*models.NotificationPreferences, error) {
+    if prefs == nil {
+        return nil, ErrInvalidInput
+    }
+    if prefs.ShareChannels == nil {
+        prefs.ShareChannels = []string{}
+    }
+    if prefs.RequestChannels == nil {
+        prefs.RequestChannels = []string{}
+    }
+    if prefs.DownloadChannels == nil {
+        prefs.DownloadChannels = []string{}
+    }
+    if prefs.DownloadFiles == nil {
+        prefs.DownloadFiles = []string{}
+    }
+    if prefs.DownloadFolders == nil {
+        prefs.DownloadFolders = []string{}
+    }
+    if prefs.DigestFolders == nil {
+        prefs.DigestFolders = []string{}
+    }
+    if err := prefs.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    _, emailEnabled := s.channels[models.ChannelEmail]
+    if !emailEnabled && (prefs.DigestEnabled || containsString(prefs.ShareChannels, models.ChannelEmail) ||
+        containsString(prefs.RequestChannels, models.ChannelEmail) ||
+        containsString(prefs.DownloadChannels, models.ChannelEmail)) {
+        return nil, fmt.Errorf("%w: email notifications are not available", ErrInvalidInput)
+    }
+
+    prefs.UpdatedAt = s.now().UTC()
+    if err := s.prefs.Save(ctx, prefs); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return prefs, nil
+}
+
+// NotifyShare tells the recipient of a share on each channel they chose.
+// Delivery is attempted on every channel; the first failure is returned.
+func (s *notificationService) NotifyShare(ctx context.Context, event ShareEvent) error {
+    if event.FileID == "" || event.SharedWith == "" {
+        return ErrInvalidInput
+    }
+
+    prefs, err := s.GetPreferences(ctx, event.SharedWith)
+    if err != nil {
+        return err
+    }
+
+    msg := notify.Message{
+        Subject: fmt.Sprintf("%s shared %q with you", event.SharedBy, event.FileName),
+        Body: fmt.Sprintf("%s shared the file %q (%s) with you.\n",
+            event.SharedBy, event.FileName, event.FileID),
+        Event: FileSharedEvent,
+        Data:  event,
+    }
+
+    return s.deliverAll(ctx, prefs, prefs.ShareChannels, msg, event.FileID)
+}
+
+// NotifyRequestUpload tells the owner of a file request that a file arrived
+// through it, on each channel they chose
+func (s *notificationService) NotifyRequestUpload(ctx context.Context, event RequestUpload) error {
+    if event.RequestID == "" || event.OwnerID == "" {
+        return ErrInvalidInput
+    }
+
+    prefs, err := s.GetPreferences(ctx, event.OwnerID)
+    if err != nil {
+        return err
+    }
+
+    uploader := event.Uploader
+    if uploader == "" {
+        uploader = "Someone"
+    }
+    msg := notify.Message{
+        Subject: fmt.Sprintf("New file for %q", event.Title),
+        Body: fmt.Sprintf("%s uploaded %q (%d bytes) through your file request %q.\n",
+            uploader, event.FileName, event.Size, event.Title),
+        Event: RequestUploadEvent,
+        Data:  event,
+    }
+
+    return s.deliverAll(ctx, prefs, prefs.RequestChannels, msg, event.FileID)
+}
+
+// NotifyStaleAccess asks an owner to review shares and file requests nobody
+// used for a while, on every channel they chose for shares or file requests
+func (s *notificationService) NotifyStaleAccess(ctx context.Context, event StaleAccess) error {
+    if event.OwnerID == "" || len(event.Shares)+len(event.FileRequests) == 0 {
+        return ErrInvalidInput
+    }
+
+    prefs, err := s.GetPreferences(ctx, event.OwnerID)
+    if err != nil {
+        return err
+    }
+
+    channels := append([]string(nil), prefs.ShareChannels...)
+    for _, channel := range prefs.RequestChannels {
+        if !containsString(channels, channel) {
+            channels = append(channels, channel)
+        }
+    }
+
+    return s.deliverAll(ctx, prefs, channels, staleAccessMessage(event), "")
+}
+
+// NotifyDownload tells the owner of a file that it was downloaded, with the
+// downloader's identity and address, if they watch the file or its folder.
+// Owners are not told about their own downloads.
+func (s *notificationService) NotifyDownload(ctx context.Context, event DownloadEvent) error {
+    if event.FileID == "" {
+        return ErrInvalidInput
+    }
+    if event.OwnerID == "" || event.DownloadedBy == event.OwnerID {
+        return nil
+    }
+
+    prefs, err := s.GetPreferences(ctx, event.OwnerID)
+    if err != nil {
+        return err
+    }
+    if !prefs.WatchesDownload(event.FileID, event.Folder) {
+        return nil
+    }
+
+    downloader := event.DownloadedBy
+    if downloader == "" {
+        downloader = "An anonymous caller"
+    }
+    msg := notify.Message{
+        Subject: fmt.Sprintf("%q was downloaded", event.FileName),
+        Body: fmt.Sprintf("%s downloaded your file %q (%s) from %s at %s.\n",
+            downloader, event.FileName, event.FileID, event.IP, event.DownloadedAt.Format(time.RFC1123)),
+        Event: FileDownloadedEvent,
+        Data:  event,
+    }
+
+    return s.deliverAll(ctx, prefs, prefs.DownloadChannels, msg, event.FileID)
+}
+
+// deliverAll sends msg on every channel, returning the first failure
+func (s *notificationService) deliverAll(ctx context.Context, prefs *models.NotificationPreferences, channels []string,
+    msg notify.Message, fileID string) error {
+    var firstErr error
+    for _, channel := range channels {
+        if err := s.deliver(ctx, prefs, channel, msg); err != nil {
+            s.logger.Warn("Failed to deliver notification",
+                logger.String("userId", prefs.UserID),
+                logger.String("fileId", fileID),
+                logger.String("event", msg.Event),
+                logger.String("channel", channel),
+                logger.Error(err))
+            if firstErr == nil {
+                firstErr = fmt.Errorf("%w: %v", ErrOperationFailed, err)
+            }
+        }
+    }
+    return firstErr
+}
+
+// SendDigests emails every due user a summary of the files other users
+// uploaded into their followed folders since their last digest, and returns
+// how many digests were sent. Users with nothing new are marked as done
+// without being emailed.
+func (s *notificationService) SendDigests(ctx context.Context) (int, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+    if _, ok := s.channels[models.ChannelEmail]; !ok {
+        return 0, nil
+    }
+
+    now := s.now().UTC()
+    due, err := s.prefs.ListDigestDue(ctx, now.Add(-s.digestInterval), digestBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    sent := 0
+    for _, prefs := range due {
+        since := now.Add(-s.digestInterval)
+        if prefs.LastDigestAt != nil {
+            since = *prefs.LastDigestAt
+        }
+
+        files, err := s.files.ListUploadedInFolders(ctx, prefs.DigestFolders, since, now, maxDigestFiles+1)
+        if err != nil {
+            log.Warn("Failed to list digest uploads",
+                logger.String("userId", prefs.UserID),
+                logger.Error(err))
+            continue
+        }
+
+        uploads := files[:0]
+        for _, file := range files {
+            if file.OwnerID != prefs.UserID {
+                uploads = append(uploads, file)
+            }
+        }
+        if len(uploads) > 0 {
+            if err := s.deliver(ctx, prefs, models.ChannelEmail, digestMessage(uploads, since)); err != nil {
+                log.Warn("Failed to send digest",
+                    logger.String("userId", prefs.UserID),
+                    logger.Error(err))
+                continue
+            }
+            sent++
+        }
+
+        if err := s.prefs.MarkDigestSent(ctx, prefs.UserID, now); err != nil {
+            log.Warn("Failed to record digest",
+                logger.String("userId", prefs.UserID),
+                logger.Error(err))
+        }
+    }
+
+    if sent > 0 {
+        log.Info("Sent upload digests", logger.Int("count", sent))
+    }
+    return sent, nil
+}
+
+// deliver sends msg to the user's address for channel
+func (s *notificationService) deliver(ctx context.Context, prefs *models.NotificationPreferences, channel string, msg notify.Message) error {
+    notifier, ok := s.channels[channel]
+    if !ok || !prefs.HasAddress(channel) {
+        return fmt.Errorf("channel %q is not available", channel)
+    }
+
+    msg.To = prefs.Email
+    if channel == models.ChannelWebhook {
+        msg.To = prefs.WebhookURL
+    }
+
+    ctx, cancel := context.WithTimeout(ctx, s.timeout)
+    defer cancel()
+    return notifier.Notify(ctx, msg)
+}
+
+// digestMessage renders the digest email for uploads since the given time
+func digestMessage(uploads []*models.File, since time.Time) notify.Message {
+    shown := uploads
+    if len(shown) > maxDigestFiles {
+        shown = shown[:maxDigestFiles]
+    }
+
+    var body strings.Builder
+    fmt.Fprintf(&body, "New uploads in your followed folders since %s:\n\n", since.Format(time.RFC1123))
+    for _, file := range shown {
+        fmt.Fprintf(&body, "  %s/%s (%d bytes)\n", file.Folder, file.FileName, file.Size)
+    }
+    if len(uploads) > maxDigestFiles {
+        body.WriteString("\n...and more.\n")
+    }
+
+    subject := fmt.Sprintf("%d new uploads in your folders", len(uploads))
+    if len(uploads) > maxDigestFiles {
+        subject = fmt.Sprintf("More than %d new uploads in your folders", maxDigestFiles)
+    }
+    return notify.Message{
+        Subject: subject,
+        Body:    body.String(),
+    }
+}
+
+// staleAccessMessage renders the access review notice for an owner
+func staleAccessMessage(event StaleAccess) notify.Message {
+    days := int(event.StaleAfter.Hours() / 24)
+
+    var body strings.Builder
+    if event.Revoked {
+        fmt.Fprintf(&body, "The following access went unused for %d days and was revoked:\n\n", days)
+    } else {
+        fmt.Fprintf(&body, "The following access went unused for %d days. Revoke anything no longer needed:\n\n", days)
+    }
+    for _, share := range event.Shares {
+        fmt.Fprintf(&body, "  File %s shared with %s, last used %s\n",
+            share.FileID, share.SharedWith, share.LastActivity().Format(time.RFC1123))
+    }
+    for _, request := range event.FileRequests {
+        fmt.Fprintf(&body, "  File request %q (%s), last used %s\n",
+            request.Title, request.ID, request.LastActivity().Format(time.RFC1123))
+    }
+
+    subject := fmt.Sprintf("%d unused shares and file requests to review", len(event.Shares)+len(event.FileRequests))
+    if event.Revoked {
+        subject = fmt.Sprintf("%d unused shares and file requests were revoked", len(event.Shares)+len(event.FileRequests))
+    }
+    return notify.Message{
+        Subject: subject,
+        Body:    body.String(),
+        Event:   StaleAccessEvent,
+        Data:    event,
+    }
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+    return false
+}
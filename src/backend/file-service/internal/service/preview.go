@@ -0,0 +1,141 @@
+package service
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "path"
+    "strconv"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/convert"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+    "src/backend/file-service/pkg/thumbnail"
+)
+
+// ErrPreviewNotAvailable is returned when a file has no sanitized preview
+var ErrPreviewNotAvailable = errors.New("preview not available")
+
+// previewPrefix is the storage prefix for sanitized preview copies
+const previewPrefix = storagekey.Previews
+
+// PreviewSanitizer produces a script-free copy of previewable markup
+type PreviewSanitizer interface {
+    Supports(contentType string) bool
+    Sanitize(contentType string, r io.Reader) ([]byte, error)
+}
+
+// WithPreviewSanitizer sanitizes supported uploads up to maxSize bytes and
+// stores the result in objects, to be served for inline previews
+func WithPreviewSanitizer(sanitizer PreviewSanitizer, objects storage.ObjectStore, maxSize int64) Option {
+    return func(s *fileService) {
+        s.previewSanitizer = sanitizer
+        s.previewObjects = objects
+        s.previewMaxSize = maxSize
+    }
+}
+
+// wantsPreview reports whether an upload should get a sanitized preview
+func (s *fileService) wantsPreview(contentType string, size int64, opts UploadOptions) bool {
+    return s.previewSanitizer != nil &&
+        opts.Encryption == nil &&
+        size <= s.previewMaxSize &&
+        s.previewSanitizer.Supports(contentType)
+}
+
+// storePreview sanitizes the buffered original and stores the derived copy.
+// Failures leave the file without a preview so it stays download-only.
+func (s *fileService) storePreview(ctx context.Context, file *models.File, original []byte) {
+    log := s.logger.With(logger.String("fileId", file.ID))
+
+    sanitized, err := s.previewSanitizer.Sanitize(file.ContentType, bytes.NewReader(original))
+    if err != nil {
+        log.Warn("Failed to sanitize preview", logger.Error(err))
+        return
+    }
+
+    key := path.Join(previewPrefix, storage.StorageKey(file.ID))
+    if err := s.previewObjects.PutObject(ctx, key, file.ContentType, sanitized); err != nil {
+        log.Warn("Failed to store sanitized preview", logger.Error(err))
+        return
+    }
+
+    file.PreviewStoragePath = key
+    log.Info("Stored sanitized preview", logger.Int("size", len(sanitized)))
+}
+
+// PreviewSize bounds the width and height of the thumbnails served as
+// previews of images
+const PreviewSize = thumbnail.MaxDimension
+
+// PreviewDerivative returns the derived object previewing a file without a
+// sanitized copy: a thumbnail of an image, or the first page of a PDF
+// converted to PNG. ok is false for other files.
+func PreviewDerivative(file *models.File) (kind string, params map[string]string, ok bool) {
+    switch {
+    case thumbnail.Supports(file.ContentType):
+        return models.DerivedKindThumbnail, map[string]string{"size": strconv.Itoa(PreviewSize)}, true
+    case isPDF(file.ContentType):
+        return models.DerivedKindConverted, map[string]string{"format": "png"}, true
+    }
+    return "", nil, false
+}
+
+// isPDF reports whether the content type is PDF
+func isPDF(contentType string) bool {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    return err == nil && mediaType == convert.Formats["pdf"]
+}
+
+// DownloadPreview opens the sanitized preview of a file
+func (s *fileService) DownloadPreview(ctx context.Context, fileID string) (*models.File, io.ReadCloser, error) {
+    if fileID == "" {
+        return nil, nil, ErrInvalidInput
+    }
+
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return nil, nil, err
+    }
+    if !file.IsUploaded() {
+        return nil, nil, ErrFileNotFound
+    }
+    if !file.HasSanitizedPreview() || s.previewObjects == nil {
+        return nil, nil, ErrPreviewNotAvailable
+    }
+
+    reader, err := s.previewObjects.GetObject(ctx, file.PreviewStoragePath)
+    if err != nil {
+        s.logger.Error("Preview download failed",
+            logger.String("fileId", fileID),
+            logger.Error(err))
+        return nil, nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    return file, reader, nil
+}
+
+// previewBuffer captures uploaded content up to max bytes, discarding it all
+// if the stream turns out to be larger than declared
+type previewBuffer struct {
+    buf      bytes.Buffer
+    max      int64
+    overflow bool
+}
+
+func (b *previewBuffer) Write(p []byte) (int, error) {
+    if b.overflow {
+        return len(p), nil
+    }
+    if int64(b.buf.Len()+len(p)) > b.max {
+        b.overflow = true
+        b.buf.Reset()
+        return len(p), nil
+    }
+    return b.buf.Write(p)
+}
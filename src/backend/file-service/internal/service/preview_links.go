@@ -0,0 +1,116 @@
+package service
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ErrInvalidPreviewToken is returned for preview tokens that are malformed,
+// forged, issued for another file or expired
+var ErrInvalidPreviewToken = errors.New("invalid preview token")
+
+// minPreviewSecretLength is the shortest accepted preview token signing secret
+const minPreviewSecretLength = 32
+
+// previewNonceScope separates preview token nonces from other single-use tokens
+const previewNonceScope = "preview"
+
+// PreviewLinks issues and verifies short-lived capability tokens granting
+// access to a single file's preview. Browsers can embed a tokenized URL in
+// <img> or <iframe> elements, which cannot send an Authorization header.
+type PreviewLinks struct {
+    secret []byte
+    ttl    time.Duration
+    now    func() time.Time
+    replay *ReplayGuard
+}
+
+// NewPreviewLinks creates a token issuer signing with secret
+func NewPreviewLinks(secret []byte, ttl time.Duration) (*PreviewLinks, error) {
+    if len(secret) < minPreviewSecretLength {
+        return nil, errors.New("preview token secret must be at least 32 bytes")
+    }
+    if ttl <= 0 {
+        return nil, errors.New("preview token TTL must be positive")
+    }
+
+    return &PreviewLinks{
+        secret: secret,
+        ttl:    ttl,
+        now:    time.Now,
+    }, nil
+}
+
+// UseReplayGuard makes tokens single-use: Redeem accepts each token once
+func (l *PreviewLinks) UseReplayGuard(replay *ReplayGuard) {
+    l.replay = replay
+}
+
+// Issue returns a token for fileID and when it expires. Tokens have the form
+// <expiry unix seconds>.<base64url nonce>.<base64url HMAC-SHA256>.
+func (l *PreviewLinks) Issue(fileID string) (string, time.Time, error) {
+    expiresAt := l.now().Add(l.ttl).Truncate(time.Second)
+    expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+
+    random := make([]byte, 16)
+    if _, err := rand.Read(random); err != nil {
+        return "", time.Time{}, fmt.Errorf("failed to generate preview token nonce: %w", err)
+    }
+    nonce := base64.RawURLEncoding.EncodeToString(random)
+
+    return expiry + "." + nonce + "." + l.sign(fileID, expiry, nonce), expiresAt, nil
+}
+
+// Verify checks that token was issued for fileID and has not expired
+func (l *PreviewLinks) Verify(fileID, token string) error {
+    _, _, err := l.parse(fileID, token)
+    return err
+}
+
+// Redeem verifies token and, when tokens are single-use, records its use,
+// failing with ErrTokenReplayed if it was used before
+func (l *PreviewLinks) Redeem(ctx context.Context, fileID, token string) error {
+    nonce, expiresAt, err := l.parse(fileID, token)
+    if err != nil {
+        return err
+    }
+    return l.replay.Consume(ctx, previewNonceScope, nonce, expiresAt)
+}
+
+// parse verifies token for fileID, returning its nonce and expiry
+func (l *PreviewLinks) parse(fileID, token string) (string, time.Time, error) {
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 {
+        return "", time.Time{}, ErrInvalidPreviewToken
+    }
+    expiry, nonce, signature := parts[0], parts[1], parts[2]
+
+    if !hmac.Equal([]byte(signature), []byte(l.sign(fileID, expiry, nonce))) {
+        return "", time.Time{}, ErrInvalidPreviewToken
+    }
+
+    unix, err := strconv.ParseInt(expiry, 10, 64)
+    if err != nil {
+        return "", time.Time{}, ErrInvalidPreviewToken
+    }
+    expiresAt := time.Unix(unix, 0)
+    if !l.now().Before(expiresAt) {
+        return "", time.Time{}, ErrInvalidPreviewToken
+    }
+    return nonce, expiresAt, nil
+}
+
+// sign returns the token signature binding fileID to expiry and nonce
+func (l *PreviewLinks) sign(fileID, expiry, nonce string) string {
+    mac := hmac.New(sha256.New, l.secret)
+    mac.Write([]byte("preview\n" + fileID + "\n" + expiry + "\n" + nonce))
+    return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// ErrQuotaExceeded is returned when an upload would exceed the owner's storage quota
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+const (
+    // QuotaThresholdEvent is the event type emitted when usage crosses a threshold
+    QuotaThresholdEvent = "quota.threshold_crossed"
+    // quotaNotifyTimeout bounds the delivery of a single quota event
+    quotaNotifyTimeout = 30 * time.Second
+    // quotaNotifyJob names the background job delivering a quota event
+    quotaNotifyJob = "quota-notify"
+)
+
+// EventSender delivers events to external subscribers, such as a webhook endpoint
+type EventSender interface {
+    Send(ctx context.Context, eventType string, data interface{}) error
+}
+
+// QuotaEvent describes an owner's usage crossing a warning threshold
+type QuotaEvent struct {
+    OwnerID   string `json:"ownerId"`
+    Threshold int    `json:"threshold"`
+    Used      int64  `json:"used"`
+    Limit     int64  `json:"limit"`
+}
+
+// QuotaUsage is an owner's current storage usage
+type QuotaUsage struct {
+    Used  int64 `json:"used"`
+    Limit int64 `json:"limit"`
+}
+
+// Remaining returns the bytes left before uploads start failing
+func (u QuotaUsage) Remaining() int64 {
+    if u.Used >= u.Limit {
+        return 0
+    }
+    return u.Limit - u.Used
+}
+
+// QuotaTracker enforces per-owner storage quotas and emits an event each time
+// an upload pushes usage across one of the warning thresholds. Files without
+// an owner are not charged.
+type QuotaTracker struct {
+    repo       repository.FileRepository
+    limit      int64
+    thresholds []int
+    sender     EventSender
+    tenants    *TenantSettings
+    logger     *logger.Logger
+}
+
+// NewQuotaTracker creates a quota tracker. thresholds are percentages of limit;
+// sender may be nil, in which case threshold events are only logged.
+func NewQuotaTracker(repo repository.FileRepository, limit int64, thresholds []int, sender EventSender) (*QuotaTracker, error) {
+    if repo == nil {
+        return nil, errors.New("file repository is required")
+    }
+    if limit <= 0 {
+        return nil, errors.New("quota limit must be positive")
+    }
+
+    sorted := append([]int(nil), thresholds...)
+    sort.Ints(sorted)
+    for _, threshold := range sorted {
+        if threshold < 1 || threshold > 100 {
+            return nil, fmt.Errorf("invalid quota threshold %d%%", threshold)
+        }
+    }
+
+    return &QuotaTracker{
+        repo:       repo,
+        limit:      limit,
+        thresholds: sorted,
+        sender:     sender,
+        logger:     logger.GetLogger(),
+    }, nil
+}
+
+// WithQuota enforces storage quotas on uploads
+func WithQuota(tracker *QuotaTracker) Option {
+    return func(s *fileService) {
+        s.quota = tracker
+    }
+}
+
+// UseTenantSettings lets tenant settings override the limit for the users
+// of each tenant
+func (t *QuotaTracker) UseTenantSettings(tenants *TenantSettings) {
+    t.tenants = tenants
+}
+
+// Usage returns the owner's current usage, against the limit of the
+// caller's tenant
+func (t *QuotaTracker) Usage(ctx context.Context, ownerID string) (QuotaUsage, error) {
+    used, err := t.repo.UsageByOwner(ctx, ownerID)
+    if err != nil {
+        return QuotaUsage{}, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return QuotaUsage{Used: used, Limit: t.tenants.QuotaLimit(ctx, t.limit)}, nil
+}
+
+// Check verifies that size more bytes fit in the owner's quota and returns the
+// usage before the upload, to be passed to Record once it succeeds
+func (t *QuotaTracker) Check(ctx context.Context, ownerID string, size int64) (QuotaUsage, error) {
+    if ownerID == "" {
+        return QuotaUsage{}, nil
+    }
+
+    usage, err := t.Usage(ctx, ownerID)
+    if err != nil {
+        return QuotaUsage{}, err
+    }
+    if size > usage.Remaining() {
+        return usage, ErrQuotaExceeded
+    }
+    return usage, nil
+}
+
+// Record emits an event for every threshold crossed by adding size bytes to
+// the usage returned by Check. Events are delivered in the background, as
+// jobs linked to the trace of ctx.
+func (t *QuotaTracker) Record(ctx context.Context, ownerID string, before QuotaUsage, size int64) {
+    if ownerID == "" {
+        return
+    }
+
+    after := before.Used + size
+    for _, threshold := range t.thresholds {
+        mark := before.Limit * int64(threshold) / 100
+        if before.Used < mark && after >= mark {
+            jobCtx, job := tracing.StartJob(context.WithoutCancel(ctx), quotaNotifyJob)
+            done := telemetry.TrackJob(jobCtx, job.Name)
+            event := QuotaEvent{
+                OwnerID:   ownerID,
+                Threshold: threshold,
+                Used:      after,
+                Limit:     before.Limit,
+            }
+            go func() {
+                done(t.notify(jobCtx, job, event))
+            }()
+        }
+    }
+}
+
+// notify logs a threshold event and forwards it to the event sender
+func (t *QuotaTracker) notify(ctx context.Context, job tracing.Job, event QuotaEvent) error {
+    log := t.logger.With(job.Fields()...).With(
+        logger.String("ownerId", event.OwnerID),
+        logger.Int("threshold", event.Threshold),
+        logger.Int64("used", event.Used),
+        logger.Int64("limit", event.Limit),
+    )
+    log.Info("Storage quota threshold crossed")
+
+    if t.sender == nil {
+        return nil
+    }
+
+    ctx, cancel := context.WithTimeout(ctx, quotaNotifyTimeout)
+    defer cancel()
+    if err := t.sender.Send(ctx, QuotaThresholdEvent, event); err != nil {
+        log.Warn("Failed to deliver quota event", logger.Error(err))
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,394 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/storagekey"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// Reconciliation modes: what is done with the orphaned objects and
+// dangling files found
+const (
+    // ReconcileDryRun only reports them
+    ReconcileDryRun = "dry-run"
+    // ReconcileQuarantine moves orphaned objects below the quarantine
+    // prefix and marks dangling files corrupted
+    ReconcileQuarantine = "quarantine"
+    // ReconcileClean deletes orphaned objects and dangling files
+    ReconcileClean = "clean"
+)
+
+// Kinds of findings, counted by reconcile_findings_total
+const (
+    // findingOrphanedObject is an object no file or blob references
+    findingOrphanedObject = "orphaned_object"
+    // findingDanglingFile is an uploaded file whose object is missing
+    findingDanglingFile = "dangling_file"
+)
+
+// Actions taken on findings, counted by reconcile_findings_total
+const (
+    findingReported    = "reported"
+    findingQuarantined = "quarantined"
+    findingCleaned     = "cleaned"
+    findingFailed      = "failed"
+)
+
+// maxReportedFindings bounds the keys and IDs listed in a reconciliation
+// result; findings beyond it are only counted
+const maxReportedFindings = 1000
+
+// reconcileMetrics are the instruments of storage reconciliation
+type reconcileMetrics struct {
+    // findings counts findings by kind and the action taken
+    findings metrics.Counter
+}
+
+// newReconcileMetrics creates the instruments of storage reconciliation
+func newReconcileMetrics(provider metrics.Provider) reconcileMetrics {
+    return reconcileMetrics{
+        findings: metrics.OrNop(provider).Counter(metrics.Opts{
+            Name:   "reconcile_findings_total",
+            Help:   "Orphaned objects and dangling files found by reconciliation, by kind and action taken",
+            Labels: []string{"kind", "action"},
+        }),
+    }
+}
+
+// ReconcileOptions configure storage reconciliation
+type ReconcileOptions struct {
+    // Mode is ReconcileDryRun, ReconcileQuarantine or ReconcileClean
+    Mode string
+    // MinAge skips objects and files changed more recently, which may
+    // belong to uploads in flight
+    MinAge time.Duration
+    // Exclude lists key prefixes that do not hold file content, besides
+    // the top-level prefixes of the storage key layout
+    Exclude []string
+    // BatchSize bounds the objects and files read per request, at most 1000
+    BatchSize int
+    // MaxActions bounds the findings acted upon per run; the rest are
+    // only reported until a later run
+    MaxActions int
+    // Metrics creates the reconciler's instruments; nil records nothing
+    Metrics metrics.Provider
+}
+
+// ReconcileResult reports what a reconciliation run found and did
+type ReconcileResult struct {
+    Mode           string
+    ObjectsScanned int
+    FilesScanned   int
+    // OrphanedObjects lists the keys of objects no file references and
+    // DanglingFiles the IDs of uploaded files whose object is missing, up
+    // to maxReportedFindings each
+    OrphanedObjects []string
+    DanglingFiles   []string
+    Orphaned        int
+    Dangling        int
+    // Resolved counts the findings quarantined or cleaned
+    Resolved int
+    // Failed maps the keys and IDs of findings that could not be resolved
+    // to the reason
+    Failed map[string]error
+}
+
+// Reconciler compares the bucket with the files table
+type Reconciler interface {
+    Reconcile(ctx context.Context) (*ReconcileResult, error)
+}
+
+// reconciler implements Reconciler
+type reconciler struct {
+    files   repository.FileRepository
+    objects storage.InventoryStorage
+    opts    ReconcileOptions
+    exclude []string
+    metrics reconcileMetrics
+    now     func() time.Time
+    logger  *logger.Logger
+}
+
+// NewReconciler creates a new instance of reconciler
+func NewReconciler(files repository.FileRepository, objects storage.InventoryStorage,
+    opts ReconcileOptions) (Reconciler, error) {
+    if files == nil || objects == nil {
+        return nil, errors.New("file repository and inventory storage are required")
+    }
+    switch opts.Mode {
+    case ReconcileDryRun, ReconcileQuarantine, ReconcileClean:
+    default:
+        return nil, fmt.Errorf("unknown reconcile mode %q", opts.Mode)
+    }
+    if opts.MinAge < 0 || opts.BatchSize <= 0 || opts.BatchSize > 1000 || opts.MaxActions < 0 {
+        return nil, errors.New("reconcile batch size must be between 1 and 1000, and min age and max actions not negative")
+    }
+
+    exclude := append([]string(nil), opts.Exclude...)
+    for _, prefix := range storagekey.Default.Prefixes {
+        exclude = append(exclude, prefix+"/")
+    }
+    return &reconciler{
+        files:   files,
+        objects: objects,
+        opts:    opts,
+        exclude: exclude,
+        metrics: newReconcileMetrics(opts.Metrics),
+        now:     time.Now,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// Reconcile lists the bucket and the storage paths of the files table in
+// the same order and merges them. An object no file references is
+// orphaned, and an uploaded file whose object was not listed is dangling;
+// both are confirmed before being reported, since uploads and deletes
+// continue during the run. Findings are then resolved as the mode says.
+func (r *reconciler) Reconcile(ctx context.Context) (*ReconcileResult, error) {
+    log := r.logger.With(logger.String("mode", r.opts.Mode))
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    result := &ReconcileResult{Mode: r.opts.Mode, Failed: make(map[string]error)}
+    cutoff := r.now().UTC().Add(-r.opts.MinAge)
+    objects := &objectCursor{storage: r.objects, limit: r.opts.BatchSize}
+    files := &storagePathCursor{files: r.files, limit: r.opts.BatchSize}
+
+    // matched is the last key both listings had, which several files
+    // sharing deduplicated content reference
+    matched := ""
+    for {
+        object, err := objects.peek(ctx, r.excluded)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        file, err := files.peek(ctx, r.excluded)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+
+        switch {
+        case object == nil && file == nil:
+            r.logResult(log, result)
+            return result, nil
+        case file == nil || (object != nil && object.Key < file.StoragePath):
+            if object.Key != matched && object.LastModified.Before(cutoff) {
+                r.checkObject(ctx, log, *object, result)
+            }
+            objects.next()
+            result.ObjectsScanned++
+        case object == nil || file.StoragePath < object.Key:
+            if file.StoragePath != matched && file.IsUploaded() && file.UpdatedAt.Before(cutoff) {
+                r.checkFile(ctx, log, file, result)
+            }
+            files.next()
+            result.FilesScanned++
+        default:
+            matched = file.StoragePath
+            files.next()
+            result.FilesScanned++
+        }
+    }
+}
+
+// excluded checks if key is under a prefix that does not hold file content
+func (r *reconciler) excluded(key string) bool {
+    for _, prefix := range r.exclude {
+        if strings.HasPrefix(key, prefix) {
+            return true
+        }
+    }
+    return false
+}
+
+// checkObject confirms that no file or blob references an object before
+// resolving it as orphaned
+func (r *reconciler) checkObject(ctx context.Context, log *logger.Logger, object storage.StoredObject,
+    result *ReconcileResult) {
+    referenced, err := r.files.StoragePathReferenced(ctx, object.Key)
+    if err != nil {
+        log.Warn("Failed to check object references",
+            logger.String("key", object.Key),
+            logger.Error(err))
+        return
+    }
+    if referenced {
+        return
+    }
+
+    result.Orphaned++
+    if len(result.OrphanedObjects) < maxReportedFindings {
+        result.OrphanedObjects = append(result.OrphanedObjects, object.Key)
+    }
+    action := r.resolve(result, object.Key, func() error {
+        if r.opts.Mode == ReconcileQuarantine {
+            _, err := r.objects.QuarantineObject(ctx, object.Key)
+            return err
+        }
+        return r.objects.DeleteObject(ctx, object.Key)
+    })
+    r.metrics.findings.Inc(findingOrphanedObject, action)
+    log.Info("Found orphaned object",
+        logger.String("key", object.Key),
+        logger.Int64("size", object.Size),
+        logger.String("action", action))
+}
+
+// checkFile confirms that an uploaded file's object is missing before
+// resolving it as dangling
+func (r *reconciler) checkFile(ctx context.Context, log *logger.Logger, file *models.File, result *ReconcileResult) {
+    if _, err := r.objects.StatUpload(ctx, file); !errors.Is(err, storage.ErrObjectNotFound) {
+        if err != nil {
+            log.Warn("Failed to check file content",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+        }
+        return
+    }
+
+    result.Dangling++
+    if len(result.DanglingFiles) < maxReportedFindings {
+        result.DanglingFiles = append(result.DanglingFiles, file.ID)
+    }
+    action := r.resolve(result, file.ID, func() error {
+        if r.opts.Mode == ReconcileQuarantine {
+            if err := file.UpdateStatus(models.FileStatusCorrupted); err != nil {
+                return err
+            }
+            return r.files.Update(ctx, file)
+        }
+        return r.files.Delete(ctx, file.ID)
+    })
+    r.metrics.findings.Inc(findingDanglingFile, action)
+    log.Info("Found dangling file",
+        logger.String("fileId", file.ID),
+        logger.String("storagePath", file.StoragePath),
+        logger.String("action", action))
+}
+
+// resolve quarantines or cleans a finding with fix, unless this is a dry
+// run or the run already acted upon MaxActions findings, and returns the
+// action taken
+func (r *reconciler) resolve(result *ReconcileResult, finding string, fix func() error) string {
+    if r.opts.Mode == ReconcileDryRun || result.Resolved+len(result.Failed) >= r.opts.MaxActions {
+        return findingReported
+    }
+    if err := fix(); err != nil {
+        result.Failed[finding] = err
+        return findingFailed
+    }
+    result.Resolved++
+    if r.opts.Mode == ReconcileQuarantine {
+        return findingQuarantined
+    }
+    return findingCleaned
+}
+
+// logResult logs the summary of a run
+func (r *reconciler) logResult(log *logger.Logger, result *ReconcileResult) {
+    fields := []logger.Field{
+        logger.Int("objectsScanned", result.ObjectsScanned),
+        logger.Int("filesScanned", result.FilesScanned),
+        logger.Int("orphanedObjects", result.Orphaned),
+        logger.Int("danglingFiles", result.Dangling),
+        logger.Int("resolved", result.Resolved),
+        logger.Int("failed", len(result.Failed)),
+    }
+    if result.Orphaned > 0 || result.Dangling > 0 {
+        log.Warn("Storage and metadata are out of sync", fields...)
+        return
+    }
+    log.Info("Storage and metadata are in sync", fields...)
+}
+
+// objectCursor pages through the bucket listing
+type objectCursor struct {
+    storage storage.InventoryStorage
+    limit   int
+    page    []storage.StoredObject
+    last    string
+    done    bool
+}
+
+// peek returns the next object not excluded, or nil at the end
+func (c *objectCursor) peek(ctx context.Context, excluded func(string) bool) (*storage.StoredObject, error) {
+    for {
+        for len(c.page) > 0 && excluded(c.page[0].Key) {
+            c.page = c.page[1:]
+        }
+        if len(c.page) > 0 {
+            return &c.page[0], nil
+        }
+        if c.done {
+            return nil, nil
+        }
+
+        page, err := c.storage.ListObjects(ctx, c.last, c.limit)
+        if err != nil {
+            return nil, err
+        }
+        if len(page) == 0 {
+            c.done = true
+            continue
+        }
+        c.page, c.last = page, page[len(page)-1].Key
+    }
+}
+
+// next moves past the object peek returned
+func (c *objectCursor) next() {
+    c.page = c.page[1:]
+}
+
+// storagePathCursor pages through the files table in storage path order
+type storagePathCursor struct {
+    files    repository.FileRepository
+    limit    int
+    page     []*models.File
+    lastPath string
+    lastID   string
+    done     bool
+}
+
+// peek returns the next file not stored under an excluded prefix, or nil
+// at the end
+func (c *storagePathCursor) peek(ctx context.Context, excluded func(string) bool) (*models.File, error) {
+    for {
+        for len(c.page) > 0 && excluded(c.page[0].StoragePath) {
+            c.page = c.page[1:]
+        }
+        if len(c.page) > 0 {
+            return c.page[0], nil
+        }
+        if c.done {
+            return nil, nil
+        }
+
+        page, err := c.files.ListStoragePaths(ctx, c.lastPath, c.lastID, c.limit)
+        if err != nil {
+            return nil, err
+        }
+        if len(page) == 0 {
+            c.done = true
+            continue
+        }
+        c.page = page
+        c.lastPath, c.lastID = page[len(page)-1].StoragePath, page[len(page)-1].ID
+    }
+}
+
+// next moves past the file peek returned
+func (c *storagePathCursor) next() {
+    c.page = c.page[1:]
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrVersionConflict is returned when a file was modified after the caller
+// read the metadata version it passed
+var ErrVersionConflict = errors.New("file was modified concurrently")
+
+// Rename changes a file's logical name, provided the file is still at the
+// given metadata version. Storage keys are immutable, so no object is copied.
+func (s *fileService) Rename(ctx context.Context, fileID, fileName string, version int64) (*models.File, error) {
+    return s.relocate(ctx, fileID, version, func(file *models.File) error {
+        return file.Rename(fileName)
+    })
+}
+
+// Move changes a file's logical folder, provided the file is still at the
+// given metadata version. Storage keys are immutable, so no object is copied.
+func (s *fileService) Move(ctx context.Context, fileID, folder string, version int64) (*models.File, error) {
+    return s.relocate(ctx, fileID, version, func(file *models.File) error {
+        return file.MoveTo(folder)
+    })
+}
+
+// relocate applies a change of name or folder to a file at the expected version
+func (s *fileService) relocate(ctx context.Context, fileID string, version int64, change func(*models.File) error) (*models.File, error) {
+    file, err := s.changeFile(ctx, fileID, version, change)
+    if err != nil {
+        return nil, err
+    }
+
+    s.logger.Info("File relocated",
+        logger.String("fileId", fileID),
+        logger.String("fileName", file.FileName),
+        logger.String("folder", file.Folder),
+        logger.Int64("version", file.Version))
+    return file, nil
+}
+
+// changeFile applies a metadata change to a file at the expected version and
+// persists it
+func (s *fileService) changeFile(ctx context.Context, fileID string, version int64, change func(*models.File) error) (*models.File, error) {
+    if fileID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    file, err := s.getFile(ctx, fileID)
+    if err != nil {
+        return nil, err
+    }
+    if file.Version != version {
+        return nil, ErrVersionConflict
+    }
+
+    if err := change(file); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    if err := s.updateFile(ctx, file); err != nil {
+        return nil, err
+    }
+    return file, nil
+}
+
+// updateFile persists file metadata, mapping lost updates to ErrVersionConflict
+func (s *fileService) updateFile(ctx context.Context, file *models.File) error {
+    err := s.repo.Update(ctx, file)
+    switch {
+    case err == nil:
+        return nil
+    case errors.Is(err, repository.ErrVersionConflict):
+        return ErrVersionConflict
+    case errors.Is(err, repository.ErrNotFound):
+        return ErrFileNotFound
+    default:
+        s.logger.Error("Failed to persist file metadata",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrTokenReplayed is returned when a single-use token is presented again
+var ErrTokenReplayed = errors.New("token was already used")
+
+// ReplayGuard makes tokens single-use by recording their nonces until they
+// expire, so a captured URL stops working once it has been used. Nonces are
+// stored in the database and shared by all replicas. A nil *ReplayGuard
+// lets tokens be reused until they expire.
+type ReplayGuard struct {
+    nonces repository.NonceRepository
+    now    func() time.Time
+    logger *logger.Logger
+}
+
+// NewReplayGuard creates a replay guard recording nonces in nonces
+func NewReplayGuard(nonces repository.NonceRepository) (*ReplayGuard, error) {
+    if nonces == nil {
+        return nil, errors.New("nonce repository is required")
+    }
+
+    return &ReplayGuard{
+        nonces: nonces,
+        now:    time.Now,
+        logger: logger.GetLogger(),
+    }, nil
+}
+
+// Consume records the use of a token's nonce, failing with ErrTokenReplayed
+// if the token was used before. scope separates the nonces of different
+// kinds of token; expiresAt is when the token expires.
+func (g *ReplayGuard) Consume(ctx context.Context, scope, nonce string, expiresAt time.Time) error {
+    if g == nil {
+        return nil
+    }
+    if nonce == "" {
+        return ErrTokenReplayed
+    }
+
+    if err := g.nonces.Use(ctx, scope, nonce, expiresAt); err != nil {
+        if errors.Is(err, repository.ErrNonceUsed) {
+            g.logger.Warn("Replayed single-use token rejected", logger.String("scope", scope))
+            return ErrTokenReplayed
+        }
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return nil
+}
+
+// Purge deletes the nonces of expired tokens, returning how many
+func (g *ReplayGuard) Purge(ctx context.Context) (int64, error) {
+    if g == nil {
+        return 0, nil
+    }
+
+    purged, err := g.nonces.DeleteExpired(ctx, g.now())
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return purged, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// Results of a replication attempt
+const (
+    replicationResultReplicated = "replicated"
+    replicationResultRetry      = "retry"
+    replicationResultFailed     = "failed"
+)
+
+// replicationMetrics are the instruments of the replication worker
+type replicationMetrics struct {
+    // objects counts replication attempts by result
+    objects metrics.Counter
+    // lag is the age of the oldest file awaiting replication
+    lag metrics.Gauge
+    // delay is how long files took to reach the secondary bucket
+    delay metrics.Histogram
+}
+
+// newReplicationMetrics creates the instruments of the replication worker
+func newReplicationMetrics(provider metrics.Provider) replicationMetrics {
+    provider = metrics.OrNop(provider)
+    return replicationMetrics{
+        objects: provider.Counter(metrics.Opts{
+            Name:   "replication_objects_total",
+            Help:   "Attempts to copy uploaded files to the secondary bucket by result",
+            Labels: []string{"result"},
+        }),
+        lag: provider.Gauge(metrics.Opts{
+            Name: "replication_lag_seconds",
+            Help: "Age of the oldest uploaded file not yet copied to the secondary bucket",
+        }),
+        delay: provider.Histogram(metrics.HistogramOpts{
+            Opts: metrics.Opts{
+                Name: "replication_delay_seconds",
+                Help: "Time from upload until a file was copied to the secondary bucket",
+            },
+            Buckets: metrics.ExponentialBuckets(1, 4, 10),
+        }),
+    }
+}
+
+// ReplicationOptions configure the replication worker
+type ReplicationOptions struct {
+    // BatchSize bounds the files copied per run
+    BatchSize int
+    // MaxAttempts is how many failed copies mark a file failed, after which
+    // it is no longer retried
+    MaxAttempts int
+    // Metrics creates the worker's instruments; nil records nothing
+    Metrics metrics.Provider
+}
+
+// ReplicationService copies newly uploaded files to a secondary bucket,
+// typically in another region, recording each file's replication status
+type ReplicationService interface {
+    ReplicatePending(ctx context.Context) (int, error)
+}
+
+// replicationService implements ReplicationService
+type replicationService struct {
+    files   repository.FileRepository
+    replica storage.Replicator
+    opts    ReplicationOptions
+    metrics replicationMetrics
+    now     func() time.Time
+    logger  *logger.Logger
+}
+
+// NewReplicationService creates a new instance of replicationService
+func NewReplicationService(files repository.FileRepository, replica storage.Replicator,
+    opts ReplicationOptions) (ReplicationService, error) {
+    if files == nil || replica == nil {
+        return nil, errors.New("file repository and replica are required")
+    }
+    if opts.BatchSize <= 0 || opts.MaxAttempts <= 0 {
+        return nil, errors.New("replication batch size and max attempts must be positive")
+    }
+
+    return &replicationService{
+        files:   files,
+        replica: replica,
+        opts:    opts,
+        metrics: newReplicationMetrics(opts.Metrics),
+        now:     time.Now,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// ReplicatePending copies the oldest files awaiting replication and returns
+// how many were copied. Failed copies are retried on later runs until the
+// file has failed MaxAttempts times.
+func (s *replicationService) ReplicatePending(ctx context.Context) (int, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    files, err := s.files.ListAwaitingReplication(ctx, s.opts.BatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    replicated := 0
+    for _, file := range files {
+        err := s.replica.Replicate(ctx, file.StoragePath)
+        if ctx.Err() != nil {
+            // Shutting down is not the file's failure
+            break
+        }
+
+        now := s.now().UTC()
+        if err == nil {
+            file.ReplicationStatus = models.ReplicationReplicated
+            file.ReplicatedAt = &now
+            s.metrics.objects.Inc(replicationResultReplicated)
+            s.metrics.delay.Observe(ctx, now.Sub(file.CreatedAt).Seconds())
+            replicated++
+        } else {
+            file.ReplicationAttempts++
+            file.ReplicationStatus = models.ReplicationPending
+            result := replicationResultRetry
+            if file.ReplicationAttempts >= s.opts.MaxAttempts {
+                file.ReplicationStatus = models.ReplicationFailed
+                result = replicationResultFailed
+            }
+            s.metrics.objects.Inc(result)
+            log.Warn("Failed to replicate file",
+                logger.String("fileId", file.ID),
+                logger.Int("attempts", file.ReplicationAttempts),
+                logger.String("replicationStatus", file.ReplicationStatus),
+                logger.Error(err))
+        }
+
+        if err := s.files.UpdateReplication(ctx, file); err != nil && !errors.Is(err, repository.ErrNotFound) {
+            log.Warn("Failed to record replication status",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+        }
+    }
+
+    if ctx.Err() == nil {
+        s.updateLag(ctx, log)
+    }
+    if replicated > 0 {
+        log.Info("Replicated files",
+            logger.Int("count", replicated))
+    }
+    return replicated, nil
+}
+
+// updateLag sets the replication lag from the oldest file still awaiting
+// replication, or to zero when there is none
+func (s *replicationService) updateLag(ctx context.Context, log *logger.Logger) {
+    oldest, err := s.files.ListAwaitingReplication(ctx, 1)
+    if err != nil {
+        log.Warn("Failed to measure replication lag", logger.Error(err))
+        return
+    }
+    if len(oldest) == 0 {
+        s.metrics.lag.Set(0)
+        return
+    }
+    s.metrics.lag.Set(s.now().Sub(oldest[0].CreatedAt).Seconds())
+}
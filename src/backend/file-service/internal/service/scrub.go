@@ -0,0 +1,228 @@
+package service
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/errtrack"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// Ways the scrubber verifies a file's content
+const (
+    // ScrubDownload reads the content back and recomputes its SHA-256
+    ScrubDownload = "download"
+    // ScrubStoredChecksum compares the checksum S3 recorded for the object,
+    // without reading the content, and reads it back only when S3 has none
+    ScrubStoredChecksum = "s3-checksum"
+)
+
+// Results of verifying a file, counted by integrity_scrub_checks_total
+const (
+    scrubIntact    = "intact"
+    scrubCorrupted = "corrupted"
+    // scrubSkipped is a file that could not be verified the configured way,
+    // such as a large file whose object has no stored checksum
+    scrubSkipped = "skipped"
+    scrubFailed  = "failed"
+)
+
+// scrubMetrics are the instruments of the integrity scrubber
+type scrubMetrics struct {
+    // checks counts verified files by the method used and the result
+    checks metrics.Counter
+    // bytesRead counts the content read back to recompute checksums
+    bytesRead metrics.Counter
+}
+
+// newScrubMetrics creates the instruments of the integrity scrubber
+func newScrubMetrics(provider metrics.Provider) scrubMetrics {
+    provider = metrics.OrNop(provider)
+    return scrubMetrics{
+        checks: provider.Counter(metrics.Opts{
+            Name:   "integrity_scrub_checks_total",
+            Help:   "Files verified by the integrity scrubber by method and result",
+            Labels: []string{"method", "result"},
+        }),
+        bytesRead: provider.Counter(metrics.Opts{
+            Name: "integrity_scrub_bytes_read_total",
+            Help: "Bytes of content read back by the integrity scrubber",
+        }),
+    }
+}
+
+// ScrubOptions configure the integrity scrubber
+type ScrubOptions struct {
+    // Method is ScrubDownload or ScrubStoredChecksum
+    Method string
+    // SampleSize is the number of files verified per run
+    SampleSize int
+    // MaxDownloadSize bounds the content read back; larger files are only
+    // compared with the checksum S3 recorded
+    MaxDownloadSize int64
+    // Metrics creates the scrubber's instruments; nil records nothing
+    Metrics metrics.Provider
+}
+
+// ScrubResult reports what a scrubber run verified
+type ScrubResult struct {
+    Checked   int
+    Corrupted []string
+    Skipped   int
+    Failed    int
+}
+
+// Scrubber verifies a sample of uploaded files against the checksums taken
+// when they were uploaded
+type Scrubber interface {
+    Scrub(ctx context.Context) (*ScrubResult, error)
+}
+
+// scrubber implements Scrubber
+type scrubber struct {
+    files   repository.FileRepository
+    content storage.IntegrityStorage
+    opts    ScrubOptions
+    metrics scrubMetrics
+    logger  *logger.Logger
+}
+
+// NewScrubber creates a new instance of scrubber
+func NewScrubber(files repository.FileRepository, content storage.IntegrityStorage, opts ScrubOptions) (Scrubber, error) {
+    if files == nil || content == nil {
+        return nil, errors.New("file repository and integrity storage are required")
+    }
+    switch opts.Method {
+    case ScrubDownload, ScrubStoredChecksum:
+    default:
+        return nil, fmt.Errorf("unknown scrub method %q", opts.Method)
+    }
+    if opts.SampleSize <= 0 || opts.MaxDownloadSize < 0 {
+        return nil, errors.New("scrub sample size must be positive and max download size not negative")
+    }
+
+    return &scrubber{
+        files:   files,
+        content: content,
+        opts:    opts,
+        metrics: newScrubMetrics(opts.Metrics),
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// Scrub verifies a random sample of uploaded files. Corrupted files are
+// marked corrupted, which stops them being served, and reported; files
+// that could not be verified are retried when sampled again.
+func (s *scrubber) Scrub(ctx context.Context) (*ScrubResult, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    files, err := s.files.SampleUploaded(ctx, s.opts.SampleSize)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    result := &ScrubResult{}
+    for _, file := range files {
+        method, actual, err := s.verify(ctx, file)
+        if ctx.Err() != nil {
+            // Shutting down is not the file's failure
+            break
+        }
+        switch {
+        case err != nil:
+            log.Warn("Failed to verify file content",
+                logger.String("fileId", file.ID),
+                logger.String("method", method),
+                logger.Error(err))
+            s.metrics.checks.Inc(method, scrubFailed)
+            result.Failed++
+        case actual == "":
+            s.metrics.checks.Inc(method, scrubSkipped)
+            result.Skipped++
+        case actual == file.Checksum:
+            s.metrics.checks.Inc(method, scrubIntact)
+            result.Checked++
+        default:
+            s.metrics.checks.Inc(method, scrubCorrupted)
+            result.Checked++
+            result.Corrupted = append(result.Corrupted, file.ID)
+            s.flagCorrupted(ctx, log, file, method, actual)
+        }
+    }
+
+    log.Info("Integrity scrub completed",
+        logger.Int("checked", result.Checked),
+        logger.Int("corrupted", len(result.Corrupted)),
+        logger.Int("skipped", result.Skipped),
+        logger.Int("failed", result.Failed))
+    return result, nil
+}
+
+// verify returns the method used and the checksum of the file's content it
+// found, or "" if the file could not be verified. Checksums of content
+// uploaded in parts cover the parts' checksums, so such checksums are only
+// comparable with one another.
+func (s *scrubber) verify(ctx context.Context, file *models.File) (string, string, error) {
+    composite := strings.Contains(file.Checksum, "-")
+    downloadable := !composite && (s.opts.MaxDownloadSize == 0 || file.Size <= s.opts.MaxDownloadSize)
+    if s.opts.Method == ScrubStoredChecksum || !downloadable {
+        stored, err := s.content.StoredChecksum(ctx, file)
+        if strings.Contains(stored, "-") != composite {
+            stored = ""
+        }
+        if err != nil || stored != "" || !downloadable {
+            return ScrubStoredChecksum, stored, err
+        }
+    }
+
+    body, err := s.content.Download(ctx, file)
+    if err != nil {
+        return ScrubDownload, "", err
+    }
+    defer body.Close()
+    hash := sha256.New()
+    n, err := io.Copy(hash, body)
+    s.metrics.bytesRead.Add(float64(n))
+    if err != nil {
+        return ScrubDownload, "", err
+    }
+    return ScrubDownload, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// flagCorrupted marks a file whose content no longer matches its checksum
+// corrupted and reports it
+func (s *scrubber) flagCorrupted(ctx context.Context, log *logger.Logger, file *models.File, method, actual string) {
+    log.Error("Corrupted file content detected",
+        logger.String("fileId", file.ID),
+        logger.String("storagePath", file.StoragePath),
+        logger.String("method", method),
+        logger.String("expectedChecksum", file.Checksum),
+        logger.String("actualChecksum", actual))
+    errtrack.CaptureError(ctx, fmt.Errorf("%w: file %s", ErrInvalidChecksum, file.ID),
+        map[string]string{"fileId": file.ID, "method": method})
+
+    if err := file.UpdateStatus(models.FileStatusCorrupted); err != nil {
+        log.Warn("Failed to mark file corrupted",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+        return
+    }
+    if err := s.files.Update(ctx, file); err != nil {
+        log.Warn("Failed to mark file corrupted",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+    }
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+)
+
+const (
+    // billingMonth is the month length used to project costs
+    billingMonth = 30 * 24 * time.Hour
+    // bytesPerGB converts stored bytes to the GB unit S3 prices storage in
+    bytesPerGB = 1 << 30
+    // costScrapeTimeout bounds the usage query made when metrics are scraped
+    costScrapeTimeout = 5 * time.Second
+)
+
+// s3Tier1Operations are the S3 requests billed at the PUT, COPY, POST and
+// LIST price. Deletes and aborts are free; all other requests are billed at
+// the GET price.
+var s3Tier1Operations = map[string]bool{
+    "PutObject":               true,
+    "CopyObject":              true,
+    "CreateMultipartUpload":   true,
+    "UploadPart":              true,
+    "UploadPartCopy":          true,
+    "CompleteMultipartUpload": true,
+    "ListObjects":             true,
+    "ListObjectsV2":           true,
+    "ListParts":               true,
+    "ListMultipartUploads":    true,
+    "PutObjectTagging":        true,
+    "RestoreObject":           true,
+}
+
+// s3FreeOperations are S3 requests that are not billed
+var s3FreeOperations = map[string]bool{
+    "DeleteObject":         true,
+    "DeleteObjects":        true,
+    "DeleteObjectTagging":  true,
+    "AbortMultipartUpload": true,
+}
+
+// StoragePrices are the unit prices used to estimate storage costs, in USD
+type StoragePrices struct {
+    StorageGBMonth           float64 `json:"storageGbMonth"`
+    Tier1RequestsPerThousand float64 `json:"tier1RequestsPerThousand"`
+    Tier2RequestsPerThousand float64 `json:"tier2RequestsPerThousand"`
+}
+
+// RequestCounts reports storage API requests by operation since a point in time
+type RequestCounts interface {
+    Counts() (map[string]int64, time.Time)
+}
+
+// OperationCost is the observed and projected requests of one S3 operation
+type OperationCost struct {
+    Operation       string  `json:"operation"`
+    Requests        int64   `json:"requests"`
+    MonthlyRequests int64   `json:"monthlyRequests"`
+    MonthlyCost     float64 `json:"monthlyCost"`
+}
+
+// StorageCostReport is a projection of the monthly storage bill
+type StorageCostReport struct {
+    Since            time.Time       `json:"since"`
+    StoredBytes      int64           `json:"storedBytes"`
+    StorageCost      float64         `json:"storageCost"`
+    RequestCost      float64         `json:"requestCost"`
+    TotalMonthlyCost float64         `json:"totalMonthlyCost"`
+    Currency         string          `json:"currency"`
+    Operations       []OperationCost `json:"operations"`
+    Prices           StoragePrices   `json:"prices"`
+}
+
+// CostEstimator projects the monthly storage bill from the stored volume and
+// the request rate observed since the counter started
+type CostEstimator struct {
+    repo     repository.FileRepository
+    requests RequestCounts
+    prices   StoragePrices
+    logger   *logger.Logger
+
+    monthlyCost *prometheus.Desc
+}
+
+// NewCostEstimator creates a new instance of CostEstimator
+func NewCostEstimator(repo repository.FileRepository, requests RequestCounts, prices StoragePrices) (*CostEstimator, error) {
+    if repo == nil || requests == nil {
+        return nil, errors.New("file repository and request counts are required")
+    }
+    if prices.StorageGBMonth < 0 || prices.Tier1RequestsPerThousand < 0 || prices.Tier2RequestsPerThousand < 0 {
+        return nil, errors.New("storage prices cannot be negative")
+    }
+
+    return &CostEstimator{
+        repo:     repo,
+        requests: requests,
+        prices:   prices,
+        logger:   logger.GetLogger(),
+        monthlyCost: prometheus.NewDesc(
+            "storage_estimated_monthly_cost_dollars",
+            "Projected monthly storage bill in USD by component",
+            []string{"component"}, nil,
+        ),
+    }, nil
+}
+
+// Estimate projects the monthly cost of stored data and requests
+func (e *CostEstimator) Estimate(ctx context.Context) (*StorageCostReport, error) {
+    stored, err := e.repo.TotalUsage(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    counts, since := e.requests.Counts()
+    report := &StorageCostReport{
+        Since:       since,
+        StoredBytes: stored,
+        StorageCost: float64(stored) / bytesPerGB * e.prices.StorageGBMonth,
+        Currency:    "USD",
+        Operations:  make([]OperationCost, 0, len(counts)),
+        Prices:      e.prices,
+    }
+
+    // Extrapolate the observed request rate to a full month
+    scale := 0.0
+    if elapsed := time.Since(since); elapsed > 0 {
+        scale = float64(billingMonth) / float64(elapsed)
+    }
+
+    for operation, count := range counts {
+        monthly := int64(float64(count) * scale)
+        cost := float64(monthly) / 1000 * e.requestPrice(operation)
+        report.Operations = append(report.Operations, OperationCost{
+            Operation:       operation,
+            Requests:        count,
+            MonthlyRequests: monthly,
+            MonthlyCost:     cost,
+        })
+        report.RequestCost += cost
+    }
+    sort.Slice(report.Operations, func(i, j int) bool {
+        return report.Operations[i].Operation < report.Operations[j].Operation
+    })
+
+    report.TotalMonthlyCost = report.StorageCost + report.RequestCost
+    return report, nil
+}
+
+// requestPrice returns the price per thousand requests of an S3 operation
+func (e *CostEstimator) requestPrice(operation string) float64 {
+    switch {
+    case s3FreeOperations[operation]:
+        return 0
+    case s3Tier1Operations[operation]:
+        return e.prices.Tier1RequestsPerThousand
+    default:
+        return e.prices.Tier2RequestsPerThousand
+    }
+}
+
+// Describe implements prometheus.Collector
+func (e *CostEstimator) Describe(ch chan<- *prometheus.Desc) {
+    ch <- e.monthlyCost
+}
+
+// Collect implements prometheus.Collector, estimating costs on each scrape
+func (e *CostEstimator) Collect(ch chan<- prometheus.Metric) {
+    ctx, cancel := context.WithTimeout(context.Background(), costScrapeTimeout)
+    defer cancel()
+
+    report, err := e.Estimate(ctx)
+    if err != nil {
+        e.logger.Warn("Failed to estimate storage costs", logger.Error(err))
+        return
+    }
+
+    ch <- prometheus.MustNewConstMetric(e.monthlyCost, prometheus.GaugeValue, report.StorageCost, "storage")
+    ch <- prometheus.MustNewConstMetric(e.monthlyCost, prometheus.GaugeValue, report.RequestCost, "requests")
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// storageStatsMetrics are the instruments of the storage statistics job
+type storageStatsMetrics struct {
+    // bytes is the size of stored files by storage state and class
+    bytes metrics.Gauge
+    // objects is the number of stored files by storage state and class
+    objects metrics.Gauge
+}
+
+// newStorageStatsMetrics creates the instruments of the storage statistics job
+func newStorageStatsMetrics(provider metrics.Provider) storageStatsMetrics {
+    provider = metrics.OrNop(provider)
+    return storageStatsMetrics{
+        bytes: provider.Gauge(metrics.Opts{
+            Name:   "storage_stored_bytes",
+            Help:   "Size of stored files by storage state (active, archived, quarantined, pending_purge) and storage class",
+            Labels: []string{"state", "storage_class"},
+        }),
+        objects: provider.Gauge(metrics.Opts{
+            Name:   "storage_stored_objects",
+            Help:   "Number of stored files by storage state (active, archived, quarantined, pending_purge) and storage class",
+            Labels: []string{"state", "storage_class"},
+        }),
+    }
+}
+
+// StorageStats exports how much stored data is active, archived,
+// quarantined or pending purge, by storage class, so capacity dashboards
+// show what is reclaimable. Content shared by deduplicated files is counted
+// for each of them.
+type StorageStats interface {
+    // Refresh aggregates the files table and updates the gauges
+    Refresh(ctx context.Context) ([]models.StorageUsage, error)
+}
+
+// storageStats implements StorageStats
+type storageStats struct {
+    files   repository.FileRepository
+    metrics storageStatsMetrics
+    now     func() time.Time
+    logger  *logger.Logger
+
+    mu sync.Mutex
+    // reported are the state and storage class pairs last exported, zeroed
+    // when they no longer hold any files
+    reported map[[2]string]bool
+}
+
+// NewStorageStats creates a new instance of storageStats recording to the
+// instruments of provider; nil records nothing
+func NewStorageStats(files repository.FileRepository, provider metrics.Provider) (StorageStats, error) {
+    if files == nil {
+        return nil, errors.New("file repository is required")
+    }
+
+    return &storageStats{
+        files:    files,
+        metrics:  newStorageStatsMetrics(provider),
+        now:      time.Now,
+        logger:   logger.GetLogger(),
+        reported: make(map[[2]string]bool),
+    }, nil
+}
+
+// Refresh aggregates the stored files by storage state and class, sets the
+// gauges to the totals and returns them
+func (s *storageStats) Refresh(ctx context.Context) ([]models.StorageUsage, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    usage, err := s.files.UsageByState(ctx, s.now().UTC())
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    reported := make(map[[2]string]bool, len(usage))
+    var total int64
+    for _, u := range usage {
+        reported[[2]string{u.State, u.StorageClass}] = true
+        s.metrics.bytes.Set(float64(u.Bytes), u.State, u.StorageClass)
+        s.metrics.objects.Set(float64(u.Objects), u.State, u.StorageClass)
+        total += u.Bytes
+    }
+    for labels := range s.reported {
+        if !reported[labels] {
+            s.metrics.bytes.Set(0, labels[0], labels[1])
+            s.metrics.objects.Set(0, labels[0], labels[1])
+        }
+    }
+    s.reported = reported
+
+    log.Debug("Storage statistics refreshed",
+        logger.Int("series", len(usage)),
+        logger.Int64("bytes", total))
+    return usage, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+    "context"
+    "fmt"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// WithObjectTags mirrors file tags to the files' objects, so bucket lifecycle
+// rules and cost allocation reports can select on them
+func WithObjectTags(tags storage.TagStorage) Option {
+    return func(s *fileService) {
+        s.objectTags = tags
+    }
+}
+
+// SetTags replaces a file's tags, provided the file is still at the given
+// metadata version, and mirrors them to its object
+func (s *fileService) SetTags(ctx context.Context, fileID string, tags models.Tags, version int64) (*models.File, error) {
+    file, err := s.changeFile(ctx, fileID, version, func(file *models.File) error {
+        return file.SetTags(tags)
+    })
+    if err != nil {
+        return nil, err
+    }
+    if err := s.syncTags(ctx, file); err != nil {
+        return nil, err
+    }
+
+    s.logger.Info("File tags updated",
+        logger.String("fileId", fileID),
+        logger.Int("tags", len(file.Tags)),
+        logger.Int64("version", file.Version))
+    return file, nil
+}
+
+// syncTags replaces the object tags of the file's object with its tags.
+// Content deduplicated into another file's blob keeps that file's tags, as
+// the object is shared.
+func (s *fileService) syncTags(ctx context.Context, file *models.File) error {
+    if s.objectTags == nil || !ownsObject(file) {
+        return nil
+    }
+    if err := s.objectTags.PutTags(ctx, file); err != nil {
+        s.logger.Error("Failed to sync object tags",
+            logger.String("fileId", file.ID),
+            logger.Error(err))
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return nil
+}
+
+// ownsObject reports whether the file's content is stored under its own key,
+// scoped to a tenant or not
+func ownsObject(file *models.File) bool {
+    key := storagekey.Default.Unscoped(file.StoragePath)
+    return key == storage.StorageKey(file.ID) || key == storage.ScratchKey(file.ID)
+}
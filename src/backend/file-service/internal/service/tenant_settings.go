@@ -0,0 +1,223 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/validator"
+)
+
+// ErrTenantSettingsNotFound is returned when a tenant has no settings of its own
+var ErrTenantSettingsNotFound = errors.New("tenant settings not found")
+
+// TenantResolver returns the tenant of the caller making a request, or ""
+type TenantResolver func(ctx context.Context) string
+
+// cachedTenantSettings is a tenant's settings as last read, nil when the
+// tenant has none
+type cachedTenantSettings struct {
+    settings  *models.TenantSettings
+    expiresAt time.Time
+}
+
+// TenantSettings applies per-tenant overrides of quotas, allowed content
+// types and draft retention, stored in the database so that they change
+// without a redeploy. Settings are cached for cacheTTL; changes made through
+// this instance apply at once, changes made through other replicas within
+// cacheTTL. A nil *TenantSettings applies no overrides.
+type TenantSettings struct {
+    repo     repository.TenantSettingsRepository
+    tenantOf TenantResolver
+    cacheTTL time.Duration
+    now      func() time.Time
+    logger   *logger.Logger
+
+    mu    sync.Mutex
+    cache map[string]cachedTenantSettings
+}
+
+// NewTenantSettings creates the tenant settings service. tenantOf resolves
+// the tenant whose overrides apply to a request.
+func NewTenantSettings(repo repository.TenantSettingsRepository, tenantOf TenantResolver, cacheTTL time.Duration) (*TenantSettings, error) {
+    if repo == nil || tenantOf == nil {
+        return nil, errors.New("tenant settings repository and resolver are required")
+    }
+    if cacheTTL <= 0 {
+        return nil, errors.New("tenant settings cache TTL must be positive")
+    }
+
+    return &TenantSettings{
+        repo:     repo,
+        tenantOf: tenantOf,
+        cacheTTL: cacheTTL,
+        now:      time.Now,
+        logger:   logger.GetLogger(),
+        cache:    make(map[string]cachedTenantSettings),
+    }, nil
+}
+
+// WithTenantSettings applies per-tenant overrides to uploads
+func WithTenantSettings(tenants *TenantSettings) Option {
+    return func(s *fileService) {
+        s.tenants = tenants
+    }
+}
+
+// Get returns a tenant's stored settings, bypassing the cache
+func (t *TenantSettings) Get(ctx context.Context, tenantID string) (*models.TenantSettings, error) {
+    if tenantID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    settings, err := t.repo.Get(ctx, tenantID)
+    if errors.Is(err, repository.ErrTenantSettingsNotFound) {
+        return nil, ErrTenantSettingsNotFound
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return settings, nil
+}
+
+// List returns the settings of every tenant that has any
+func (t *TenantSettings) List(ctx context.Context) ([]*models.TenantSettings, error) {
+    tenants, err := t.repo.List(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if tenants == nil {
+        tenants = []*models.TenantSettings{}
+    }
+    return tenants, nil
+}
+
+// Update validates and replaces a tenant's settings on behalf of updatedBy
+func (t *TenantSettings) Update(ctx context.Context, settings *models.TenantSettings, updatedBy string) (*models.TenantSettings, error) {
+    if settings == nil {
+        return nil, ErrInvalidInput
+    }
+    if settings.AllowedTypes == nil {
+        settings.AllowedTypes = []string{}
+    }
+    if err := settings.Validate(); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    settings.UpdatedBy = updatedBy
+    settings.UpdatedAt = t.now().UTC()
+    if err := t.repo.Save(ctx, settings); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    t.invalidate(settings.TenantID)
+
+    t.logger.Info("Tenant settings updated",
+        logger.String("tenantId", settings.TenantID),
+        logger.String("updatedBy", updatedBy))
+    return settings, nil
+}
+
+// Delete removes a tenant's settings, restoring the configured defaults
+func (t *TenantSettings) Delete(ctx context.Context, tenantID, deletedBy string) error {
+    if tenantID == "" {
+        return ErrInvalidInput
+    }
+
+    if err := t.repo.Delete(ctx, tenantID); err != nil {
+        if errors.Is(err, repository.ErrTenantSettingsNotFound) {
+            return ErrTenantSettingsNotFound
+        }
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    t.invalidate(tenantID)
+
+    t.logger.Info("Tenant settings deleted",
+        logger.String("tenantId", tenantID),
+        logger.String("deletedBy", deletedBy))
+    return nil
+}
+
+// QuotaLimit returns the storage quota of the caller's tenant, or limit if
+// it does not override it
+func (t *TenantSettings) QuotaLimit(ctx context.Context, limit int64) int64 {
+    if settings := t.overrides(ctx); settings != nil && settings.QuotaLimit > 0 {
+        return settings.QuotaLimit
+    }
+    return limit
+}
+
+// DraftTTL returns how long the caller's tenant keeps drafts, or ttl if it
+// does not override it
+func (t *TenantSettings) DraftTTL(ctx context.Context, ttl time.Duration) time.Duration {
+    if settings := t.overrides(ctx); settings != nil && settings.DraftTTL() > 0 {
+        return settings.DraftTTL()
+    }
+    return ttl
+}
+
+// CheckContentType reports whether the caller's tenant allows uploads of
+// contentType. It narrows the upload policy and never widens it.
+func (t *TenantSettings) CheckContentType(ctx context.Context, contentType string) error {
+    settings := t.overrides(ctx)
+    if settings == nil || len(settings.AllowedTypes) == 0 {
+        return nil
+    }
+
+    if (UploadRule{ContentTypes: settings.AllowedTypes}).allowsType(contentType) {
+        return nil
+    }
+    return fmt.Errorf("%w: %w", ErrUploadNotPermitted, &validator.ValidationError{
+        Code:    "TYPE_NOT_PERMITTED",
+        Message: fmt.Sprintf("File type %s is not allowed for your organization", strings.ToLower(contentType)),
+    })
+}
+
+// overrides returns the cached settings of the caller's tenant, or nil if
+// there are none. Failing to read them keeps the settings last read, if
+// any, or else applies the configured defaults.
+func (t *TenantSettings) overrides(ctx context.Context) *models.TenantSettings {
+    if t == nil {
+        return nil
+    }
+    tenantID := t.tenantOf(ctx)
+    if tenantID == "" {
+        return nil
+    }
+
+    now := t.now()
+    t.mu.Lock()
+    cached, ok := t.cache[tenantID]
+    t.mu.Unlock()
+    if ok && now.Before(cached.expiresAt) {
+        return cached.settings
+    }
+
+    settings, err := t.repo.Get(ctx, tenantID)
+    if errors.Is(err, repository.ErrTenantSettingsNotFound) {
+        settings, err = nil, nil
+    }
+    if err != nil {
+        t.logger.Warn("Failed to load tenant settings",
+            logger.String("tenantId", tenantID),
+            logger.Error(err))
+        return cached.settings
+    }
+
+    t.mu.Lock()
+    t.cache[tenantID] = cachedTenantSettings{settings: settings, expiresAt: now.Add(t.cacheTTL)}
+    t.mu.Unlock()
+    return settings
+}
+
+// invalidate drops a tenant's cached settings
+func (t *TenantSettings) invalidate(tenantID string) {
+    t.mu.Lock()
+    delete(t.cache, tenantID)
+    t.mu.Unlock()
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/telemetry"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// tierPromoteJob is the job name of promotions of accessed cold files
+const tierPromoteJob = "tier-promote"
+
+// accessRecordInterval bounds how often the last access of a file is
+// written, so frequently read files do not write on every read
+const accessRecordInterval = time.Hour
+
+// Directions of storage class transitions, counted by tiering_transitions_total
+const (
+    tierDemote  = "demote"
+    tierPromote = "promote"
+)
+
+// tieringMetrics are the instruments of the tiering engine
+type tieringMetrics struct {
+    // transitions counts files moved between storage classes, by the class
+    // moved to and direction
+    transitions metrics.Counter
+    // reads counts downloads by the storage class read from
+    reads metrics.Counter
+}
+
+// newTieringMetrics creates the instruments of the tiering engine
+func newTieringMetrics(provider metrics.Provider) tieringMetrics {
+    provider = metrics.OrNop(provider)
+    return tieringMetrics{
+        transitions: provider.Counter(metrics.Opts{
+            Name:   "tiering_transitions_total",
+            Help:   "Files moved between storage classes by storage class and direction",
+            Labels: []string{"storage_class", "direction"},
+        }),
+        reads: provider.Counter(metrics.Opts{
+            Name:   "tiering_reads_total",
+            Help:   "File downloads by the storage class read from",
+            Labels: []string{"storage_class"},
+        }),
+    }
+}
+
+// TieringOptions configure tiering. Files not accessed for ColdAfter are
+// moved to ColdStorageClass, STANDARD_IA or ONEZONE_IA.
+type TieringOptions struct {
+    ColdAfter        time.Duration
+    ColdStorageClass string
+    // PromoteOnAccess moves cold files back to STANDARD when downloaded
+    PromoteOnAccess bool
+    // BatchSize bounds the files moved per run
+    BatchSize int
+    // Metrics creates the engine's instruments; nil records nothing
+    Metrics metrics.Provider
+}
+
+// TieringService moves files between storage classes by how recently they
+// were accessed: cold files to an infrequent access class, and accessed cold
+// files back to STANDARD
+type TieringService interface {
+    DemoteCold(ctx context.Context) (int, error)
+    Accessed(ctx context.Context, file *models.File)
+}
+
+// tieringService implements TieringService
+type tieringService struct {
+    files   repository.FileRepository
+    tiers   storage.TierStorage
+    opts    TieringOptions
+    metrics tieringMetrics
+    now     func() time.Time
+    logger  *logger.Logger
+
+    // promoting holds the IDs of files being promoted, so concurrent reads
+    // of a cold file promote it once
+    promoting sync.Map
+}
+
+// NewTieringService creates a new instance of tieringService
+func NewTieringService(files repository.FileRepository, tiers storage.TierStorage,
+    opts TieringOptions) (TieringService, error) {
+    if files == nil || tiers == nil {
+        return nil, errors.New("file repository and tier storage are required")
+    }
+    if opts.ColdAfter <= 0 || opts.BatchSize <= 0 {
+        return nil, errors.New("tiering cold period and batch size must be positive")
+    }
+    switch opts.ColdStorageClass {
+    case models.StorageClassStandardIA, models.StorageClassOneZoneIA:
+    default:
+        return nil, fmt.Errorf("unknown cold storage class %q", opts.ColdStorageClass)
+    }
+
+    return &tieringService{
+        files:   files,
+        tiers:   tiers,
+        opts:    opts,
+        metrics: newTieringMetrics(opts.Metrics),
+        now:     time.Now,
+        logger:  logger.GetLogger(),
+    }, nil
+}
+
+// DemoteCold moves the least recently accessed cold files to the cold
+// storage class and returns how many were moved. Files that fail to move
+// are retried on later runs.
+func (s *tieringService) DemoteCold(ctx context.Context) (int, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    files, err := s.files.ListCold(ctx, s.now().UTC().Add(-s.opts.ColdAfter), s.opts.BatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    demoted := 0
+    for _, file := range files {
+        err := s.transition(ctx, file, s.opts.ColdStorageClass)
+        if ctx.Err() != nil {
+            // Shutting down is not the file's failure
+            break
+        }
+        if err != nil {
+            log.Warn("Failed to move file to cold storage",
+                logger.String("fileId", file.ID),
+                logger.String("storageClass", s.opts.ColdStorageClass),
+                logger.Error(err))
+            continue
+        }
+        s.metrics.transitions.Inc(s.opts.ColdStorageClass, tierDemote)
+        demoted++
+    }
+
+    if demoted > 0 {
+        log.Info("Moved cold files",
+            logger.Int("count", demoted),
+            logger.String("storageClass", s.opts.ColdStorageClass))
+    }
+    return demoted, nil
+}
+
+// Accessed counts a download of the file by its storage class and, for cold
+// files, promotes the file back to STANDARD in the background
+func (s *tieringService) Accessed(ctx context.Context, file *models.File) {
+    storageClass := file.StorageClass
+    if storageClass == "" {
+        storageClass = models.StorageClassStandard
+    }
+    s.metrics.reads.Inc(storageClass)
+
+    if !s.opts.PromoteOnAccess || !file.IsCold() {
+        return
+    }
+    if _, busy := s.promoting.LoadOrStore(file.ID, struct{}{}); busy {
+        return
+    }
+
+    promoted := *file
+    jobCtx, job := tracing.StartJob(context.WithoutCancel(ctx), tierPromoteJob)
+    done := telemetry.TrackJob(jobCtx, job.Name)
+    go func() {
+        defer s.promoting.Delete(promoted.ID)
+        err := s.transition(jobCtx, &promoted, models.StorageClassStandard)
+        if err != nil {
+            s.logger.Warn("Failed to promote accessed file",
+                append(job.Fields(), logger.String("fileId", promoted.ID), logger.Error(err))...)
+        } else {
+            s.metrics.transitions.Inc(models.StorageClassStandard, tierPromote)
+        }
+        done(err)
+    }()
+}
+
+// transition moves the file's content to storageClass and records it
+func (s *tieringService) transition(ctx context.Context, file *models.File, storageClass string) error {
+    if err := s.tiers.Transition(ctx, file, storageClass); err != nil {
+        return err
+    }
+    file.MarkTiered(storageClass)
+    if err := s.files.UpdateArchive(ctx, file); err != nil && !errors.Is(err, repository.ErrNotFound) {
+        return err
+    }
+    return nil
+}
+
+// WithTiering records downloads with the tiering engine, which promotes
+// cold files back to STANDARD when they are read
+func WithTiering(tiering TieringService) Option {
+    return func(s *fileService) {
+        s.tiering = tiering
+    }
+}
+
+// recordAccess records a download of the file. The last access, previously
+// recorded as lastAccessed, is only written once it is accessRecordInterval
+// old, so frequently read files do not write on every read.
+func (s *fileService) recordAccess(ctx context.Context, file *models.File, lastAccessed time.Time) {
+    now := time.Now().UTC()
+    if now.Sub(lastAccessed) >= accessRecordInterval {
+        if err := s.repo.RecordAccess(ctx, file.ID, now); err != nil {
+            s.logger.Warn("Failed to record file access",
+                logger.String("fileId", file.ID),
+                logger.Error(err))
+        }
+    }
+    if s.tiering != nil {
+        s.tiering.Accessed(ctx, file)
+    }
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "mime"
+    "os"
+    "strings"
+
+    "src/backend/file-service/pkg/validator"
+)
+
+// Upload policy errors
+var (
+    ErrUploadNotPermitted = errors.New("upload not permitted")
+    ErrUploadTooLarge     = errors.New("upload exceeds permitted size")
+)
+
+// AnyRole matches every caller, including unauthenticated ones
+const AnyRole = "*"
+
+// UploadRule permits callers holding any of Roles to upload ContentTypes up
+// to MaxSize bytes. Content types may use a "type/*" wildcard.
+type UploadRule struct {
+    Roles        []string `json:"roles"`
+    ContentTypes []string `json:"contentTypes"`
+    MaxSize      int64    `json:"maxSize"`
+}
+
+// UploadPolicy is the matrix of content types and sizes each role may upload.
+// A caller may upload a file when any rule for one of its roles allows it.
+type UploadPolicy struct {
+    Rules []UploadRule `json:"rules"`
+}
+
+// DefaultUploadPolicy allows every caller the built-in file types and size limit
+func DefaultUploadPolicy() *UploadPolicy {
+    return &UploadPolicy{
+        Rules: []UploadRule{{
+            Roles:        []string{AnyRole},
+            ContentTypes: validator.AllowedFileTypes,
+            MaxSize:      validator.MaxFileSize,
+        }},
+    }
+}
+
+// WithUploadPolicy replaces the default upload policy with a per-role matrix
+func WithUploadPolicy(policy *UploadPolicy) Option {
+    return func(s *fileService) {
+        s.uploadPolicy = policy
+    }
+}
+
+// LoadUploadPolicy reads an upload policy from a JSON file
+func LoadUploadPolicy(path string) (*UploadPolicy, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read upload policy: %w", err)
+    }
+
+    var policy UploadPolicy
+    if err := json.Unmarshal(data, &policy); err != nil {
+        return nil, fmt.Errorf("failed to parse upload policy: %w", err)
+    }
+    if err := policy.Validate(); err != nil {
+        return nil, err
+    }
+
+    return &policy, nil
+}
+
+// Validate checks that every rule names roles and content types and a usable size
+func (p *UploadPolicy) Validate() error {
+    if len(p.Rules) == 0 {
+        return errors.New("upload policy has no rules")
+    }
+
+    for i, rule := range p.Rules {
+        if len(rule.Roles) == 0 || len(rule.ContentTypes) == 0 {
+            return fmt.Errorf("upload rule %d must list roles and content types", i)
+        }
+        if rule.MaxSize <= 0 || rule.MaxSize > validator.MaxObjectSize {
+            return fmt.Errorf("upload rule %d has invalid max size %d", i, rule.MaxSize)
+        }
+        for _, contentType := range rule.ContentTypes {
+            if _, _, err := mime.ParseMediaType(contentType); err != nil {
+                return fmt.Errorf("upload rule %d has invalid content type %q", i, contentType)
+            }
+        }
+    }
+
+    return nil
+}
+
+// RulesFor returns the rules that apply to a caller with the given roles
+func (p *UploadPolicy) RulesFor(roles []string) []UploadRule {
+    rules := make([]UploadRule, 0, len(p.Rules))
+    for _, rule := range p.Rules {
+        if rule.appliesTo(roles) {
+            rules = append(rules, rule)
+        }
+    }
+    return rules
+}
+
+// Limits returns the largest file a caller with the given roles may upload and
+// every content type it may upload, in rule order without duplicates
+func (p *UploadPolicy) Limits(roles []string) (int64, []string) {
+    var maxSize int64
+    types := []string{}
+    seen := make(map[string]bool)
+    for _, rule := range p.RulesFor(roles) {
+        if rule.MaxSize > maxSize {
+            maxSize = rule.MaxSize
+        }
+        for _, contentType := range rule.ContentTypes {
+            contentType = strings.ToLower(strings.TrimSpace(contentType))
+            if !seen[contentType] {
+                seen[contentType] = true
+                types = append(types, contentType)
+            }
+        }
+    }
+    return maxSize, types
+}
+
+// Check reports whether a caller with the given roles may upload a file of
+// contentType and size. Errors wrap ErrUploadNotPermitted or ErrUploadTooLarge
+// and a ValidationError carrying the structured code.
+func (p *UploadPolicy) Check(roles []string, contentType string, size int64) error {
+    if err := validator.ValidateContentType(contentType); err != nil {
+        return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+    }
+
+    var maxSize int64
+    permitted := false
+    for _, rule := range p.RulesFor(roles) {
+        if rule.allowsType(contentType) {
+            permitted = true
+            if rule.MaxSize > maxSize {
+                maxSize = rule.MaxSize
+            }
+        }
+    }
+
+    if !permitted {
+        return fmt.Errorf("%w: %w", ErrUploadNotPermitted, &validator.ValidationError{
+            Code:    "TYPE_NOT_PERMITTED",
+            Message: fmt.Sprintf("File type %s is not allowed for your roles", contentType),
+        })
+    }
+
+    if err := validator.ValidateFileSizeLimit(size, maxSize); err != nil {
+        if size <= 0 {
+            return fmt.Errorf("%w: %w", ErrInvalidInput, err)
+        }
+        return fmt.Errorf("%w: %w", ErrUploadTooLarge, err)
+    }
+
+    return nil
+}
+
+func (r UploadRule) appliesTo(roles []string) bool {
+    for _, ruleRole := range r.Roles {
+        if ruleRole == AnyRole {
+            return true
+        }
+        for _, role := range roles {
+            if strings.EqualFold(ruleRole, role) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+func (r UploadRule) allowsType(contentType string) bool {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return false
+    }
+
+    for _, allowed := range r.ContentTypes {
+        allowed = strings.ToLower(strings.TrimSpace(allowed))
+        if allowed == mediaType || allowed == "*/*" {
+            return true
+        }
+        if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+            return true
+        }
+    }
+    return false
+}
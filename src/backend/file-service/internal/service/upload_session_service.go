@@ -0,0 +1,462 @@
+package service
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/bandwidth"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/throughput"
+    "src/backend/file-service/pkg/tracing"
+    "src/backend/file-service/pkg/validator"
+)
+
+// Upload session errors
+var (
+    ErrSessionNotFound = errors.New("upload session not found")
+)
+
+const (
+    // sessionSweepBatchSize bounds the expired sessions and the abandoned
+    // multipart uploads handled per sweep
+    sessionSweepBatchSize = 100
+    // abandonedUploadGrace is how long past the session TTL a multipart
+    // upload in the bucket is left before it is aborted as abandoned
+    abandonedUploadGrace = time.Hour
+    // multipartAbortTimeout bounds aborting the multipart upload of a
+    // session whose request was cancelled
+    multipartAbortTimeout = 30 * time.Second
+)
+
+// ChunkedUploadConfig defines the parameters of the chunked upload protocol
+type ChunkedUploadConfig struct {
+    ChunkSize  int64
+    MaxChunks  int
+    SessionTTL time.Duration
+    // Masquerade controls handling of disguised files, checked on initiation
+    // and against the first chunk
+    Masquerade validator.MasqueradePolicy
+    // Policy is the per-role upload policy; DefaultUploadPolicy is used when nil
+    Policy *UploadPolicy
+    // Quota enforces storage quotas when set
+    Quota *QuotaTracker
+    // Tenants applies per-tenant overrides of the allowed content types
+    Tenants *TenantSettings
+    // Bandwidth throttles chunk uploads to each owner's fair share when set
+    Bandwidth *bandwidth.Scheduler
+    // Throughput sizes the chunks of new sessions by the throughput each
+    // client achieved in its chunk uploads when set
+    Throughput *throughput.Estimator
+}
+
+// UploadSessionService defines the operations of the chunked upload protocol
+type UploadSessionService interface {
+    Initiate(ctx context.Context, fileName string, contentType string, size int64, ownerID string, roles []string) (*models.UploadSession, error)
+    Get(ctx context.Context, sessionID string) (*models.UploadSession, error)
+    UploadChunk(ctx context.Context, sessionID string, number int, size int64, checksum string, reader io.Reader) (*models.UploadPart, error)
+    Complete(ctx context.Context, sessionID string, checksums []string) (*models.File, error)
+    Abort(ctx context.Context, sessionID string) error
+    SweepAbandoned(ctx context.Context) (int, error)
+}
+
+// uploadSessionService implements UploadSessionService on top of S3 multipart uploads
+type uploadSessionService struct {
+    storage  storage.MultipartStorage
+    sessions repository.UploadSessionRepository
+    files    repository.FileRepository
+    config   ChunkedUploadConfig
+    logger   *logger.Logger
+}
+
+// NewUploadSessionService creates a new instance of uploadSessionService
+func NewUploadSessionService(storage storage.MultipartStorage, sessions repository.UploadSessionRepository,
+    files repository.FileRepository, config ChunkedUploadConfig) (UploadSessionService, error) {
+
+    if storage == nil {
+        return nil, errors.New("multipart storage implementation is required")
+    }
+    if sessions == nil || files == nil {
+        return nil, errors.New("session and file repositories are required")
+    }
+    if config.ChunkSize <= 0 || config.MaxChunks <= 0 || config.SessionTTL <= 0 {
+        return nil, errors.New("invalid chunked upload configuration")
+    }
+    if config.Policy == nil {
+        config.Policy = DefaultUploadPolicy()
+    }
+
+    return &uploadSessionService{
+        storage:  storage,
+        sessions: sessions,
+        files:    files,
+        config:   config,
+        logger:   logger.GetLogger(),
+    }, nil
+}
+
+// Initiate checks the caller's upload policy, starts the multipart upload and persists a new session
+func (s *uploadSessionService) Initiate(ctx context.Context, fileName string, contentType string, size int64,
+    ownerID string, roles []string) (*models.UploadSession, error) {
+
+    log := s.logger.With(
+        logger.String("fileName", fileName),
+        logger.Int64("size", size),
+    )
+
+    if err := s.config.Policy.Check(roles, contentType, size); err != nil {
+        log.Warn("Upload policy check failed", logger.Strings("roles", roles), logger.Error(err))
+        return nil, err
+    }
+    if err := s.config.Tenants.CheckContentType(ctx, contentType); err != nil {
+        log.Warn("Tenant content type check failed", logger.Error(err))
+        return nil, err
+    }
+
+    if s.config.Quota != nil {
+        if _, err := s.config.Quota.Check(ctx, ownerID, size); err != nil {
+            log.Warn("Storage quota check failed", logger.String("ownerId", ownerID), logger.Error(err))
+            return nil, err
+        }
+    }
+
+    // Size chunks to the client's throughput, growing them for very large
+    // files so the part count stays within limits
+    chunkSize := s.config.ChunkSize
+    if s.config.Throughput != nil {
+        chunkSize = s.config.Throughput.ChunkSize(throughputClient(ctx, ownerID), chunkSize)
+    }
+    if minChunk := (size + int64(s.config.MaxChunks) - 1) / int64(s.config.MaxChunks); minChunk > chunkSize {
+        chunkSize = minChunk
+    }
+
+    session, err := models.NewUploadSession(fileName, contentType, size, chunkSize, s.config.SessionTTL)
+    if err != nil {
+        log.Error("Upload session validation failed", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    session.OwnerID = ownerID
+    s.adviseChunkSize(ctx, session)
+
+    if err := checkMasquerading(log, fileName, nil, s.config.Masquerade); err != nil {
+        return nil, err
+    }
+
+    if err := s.storage.InitiateMultipart(ctx, session); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if err := s.sessions.Create(ctx, session); err != nil {
+        log.Error("Failed to persist upload session", logger.Error(err))
+        s.abortQuietly(ctx, session)
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    log.Info("Upload session initiated",
+        logger.String("sessionId", session.ID),
+        logger.Int("chunkCount", session.ChunkCount))
+
+    return session, nil
+}
+
+// Get returns the session and its received parts so clients can resume.
+// Sessions of other owners are reported as not found.
+func (s *uploadSessionService) Get(ctx context.Context, sessionID string) (*models.UploadSession, error) {
+    if sessionID == "" {
+        return nil, ErrInvalidInput
+    }
+
+    session, err := s.sessions.GetByID(ctx, sessionID)
+    if err != nil {
+        if errors.Is(err, repository.ErrSessionNotFound) {
+            return nil, ErrSessionNotFound
+        }
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if session.OwnerID != requestctx.UserID(ctx) {
+        return nil, ErrSessionNotFound
+    }
+
+    s.adviseChunkSize(ctx, session)
+    return session, nil
+}
+
+// UploadChunk stores chunk number n and verifies it against the client checksum
+// when one is supplied
+func (s *uploadSessionService) UploadChunk(ctx context.Context, sessionID string, number int, size int64,
+    checksum string, reader io.Reader) (*models.UploadPart, error) {
+
+    log := s.logger.With(
+        logger.String("sessionId", sessionID),
+        logger.Int("chunk", number),
+    )
+
+    session, err := s.Get(ctx, sessionID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := session.CheckWritable(); err != nil {
+        return nil, err
+    }
+
+    expected, err := session.ExpectedChunkSize(number)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+    if size != expected {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, models.ErrInvalidChunkSize)
+    }
+
+    if number == 1 {
+        header, peeked, err := peekHeader(reader)
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+        }
+        if err := checkMasquerading(log, session.FileName, header, s.config.Masquerade); err != nil {
+            return nil, err
+        }
+        reader = peeked
+    }
+
+    if s.config.Bandwidth != nil {
+        throttled := s.config.Bandwidth.Reader(ctx, session.OwnerID, reader)
+        defer throttled.Close()
+        reader = throttled
+    }
+
+    start := time.Now()
+    part, err := s.storage.UploadPart(ctx, session, number, size, io.LimitReader(reader, expected))
+    if err != nil {
+        // S3 keeps no partial part, so the chunk can simply be sent again
+        if uploadInterrupted(ctx, err) {
+            log.Info("Chunk upload interrupted by client, session remains resumable")
+            return nil, ErrUploadInterrupted
+        }
+        log.Error("Chunk upload failed", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if s.config.Throughput != nil {
+        s.config.Throughput.Observe(throughputClient(ctx, session.OwnerID), size, time.Since(start))
+    }
+
+    // A mismatched chunk is not recorded; re-sending it overwrites the S3 part
+    if checksum != "" && !strings.EqualFold(checksum, part.Checksum) {
+        log.Warn("Chunk checksum mismatch",
+            logger.String("expected", checksum),
+            logger.String("actual", part.Checksum))
+        return nil, models.ErrChecksumMismatch
+    }
+
+    part.UploadedAt = time.Now().UTC()
+    if err := session.RecordPart(*part); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+    }
+
+    if err := s.sessions.SavePart(ctx, session.ID, part); err != nil {
+        log.Error("Failed to persist chunk", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    log.Info("Chunk uploaded", logger.String("checksum", part.Checksum))
+    return part, nil
+}
+
+// Complete verifies all chunk checksums, assembles the object and creates the file record
+func (s *uploadSessionService) Complete(ctx context.Context, sessionID string, checksums []string) (*models.File, error) {
+    log := s.logger.With(logger.String("sessionId", sessionID))
+
+    session, err := s.Get(ctx, sessionID)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := session.CheckWritable(); err != nil {
+        return nil, err
+    }
+
+    if err := session.VerifyChecksums(checksums); err != nil {
+        log.Warn("Upload session verification failed", logger.Error(err))
+        return nil, err
+    }
+
+    // Re-check the quota, since other uploads may have completed since initiation
+    var usage QuotaUsage
+    if s.config.Quota != nil {
+        usage, err = s.config.Quota.Check(ctx, session.OwnerID, session.TotalSize)
+        if err != nil {
+            log.Warn("Storage quota check failed", logger.Error(err))
+            return nil, err
+        }
+    }
+
+    file, err := models.NewFile(session.FileName, session.TotalSize, session.ContentType)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    file.ID = session.FileID
+    file.OwnerID = session.OwnerID
+
+    if err := s.storage.CompleteMultipart(ctx, session, file); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if err := file.SetStoragePath(session.StorageKey); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if err := file.UpdateChecksum(session.CompositeChecksum()); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if err := file.UpdateStatus(models.FileStatusUploaded); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if err := s.files.Create(ctx, file); err != nil {
+        log.Error("Failed to create file record", logger.Error(err))
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if err := s.sessions.UpdateStatus(ctx, session.ID, models.UploadSessionStatusCompleted); err != nil {
+        log.Error("Failed to mark upload session completed", logger.Error(err))
+    }
+
+    if s.config.Quota != nil {
+        s.config.Quota.Record(ctx, session.OwnerID, usage, file.Size)
+    }
+
+    log.Info("Upload session completed",
+        logger.String("fileId", file.ID),
+        logger.String("checksum", file.Checksum))
+
+    return file, nil
+}
+
+// Abort discards the uploaded parts and closes the session
+func (s *uploadSessionService) Abort(ctx context.Context, sessionID string) error {
+    session, err := s.Get(ctx, sessionID)
+    if err != nil {
+        return err
+    }
+
+    if session.Status != models.UploadSessionStatusOpen {
+        return models.ErrSessionClosed
+    }
+
+    if err := s.storage.AbortMultipart(ctx, session); err != nil {
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    if err := s.sessions.UpdateStatus(ctx, session.ID, models.UploadSessionStatusAborted); err != nil {
+        return fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    s.logger.Info("Upload session aborted", logger.String("sessionId", session.ID))
+    return nil
+}
+
+// SweepAbandoned expires open sessions past their expiry, discarding their
+// uploaded parts, and aborts multipart uploads left in the bucket by sessions
+// that were never recorded or cleaned up. It returns how many multipart
+// uploads were discarded.
+func (s *uploadSessionService) SweepAbandoned(ctx context.Context) (int, error) {
+    log := s.logger
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        log = log.With(job.Fields()...)
+    }
+
+    now := time.Now().UTC()
+    sessions, err := s.sessions.ListExpired(ctx, now, sessionSweepBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+
+    swept := 0
+    for _, session := range sessions {
+        if err := s.storage.AbortMultipart(ctx, session); err != nil {
+            log.Warn("Failed to abort multipart upload of expired session",
+                logger.String("sessionId", session.ID),
+                logger.Error(err))
+            continue
+        }
+        if err := s.sessions.UpdateStatus(ctx, session.ID, models.UploadSessionStatusExpired); err != nil {
+            log.Warn("Failed to mark upload session expired",
+                logger.String("sessionId", session.ID),
+                logger.Error(err))
+            continue
+        }
+        swept++
+    }
+
+    // Every session has expired by the time its multipart upload is this old
+    uploads, err := s.storage.ListMultipartUploads(ctx, now.Add(-s.config.SessionTTL-abandonedUploadGrace),
+        sessionSweepBatchSize)
+    if err != nil {
+        return swept, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    for _, upload := range uploads {
+        if err := s.storage.AbortMultipartUpload(ctx, upload); err != nil {
+            log.Warn("Failed to abort abandoned multipart upload",
+                logger.String("key", upload.Key),
+                logger.Error(err))
+            continue
+        }
+        swept++
+    }
+
+    if swept > 0 {
+        log.Info("Swept abandoned uploads",
+            logger.Int("expiredSessions", len(sessions)),
+            logger.Int("count", swept))
+    }
+    return swept, nil
+}
+
+// abortQuietly aborts the multipart upload on a best-effort basis, even if
+// ctx was cancelled because the client went away
+func (s *uploadSessionService) abortQuietly(ctx context.Context, session *models.UploadSession) {
+    ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), multipartAbortTimeout)
+    defer cancel()
+    if err := s.storage.AbortMultipart(ctx, session); err != nil {
+        s.logger.Warn("Failed to abort multipart upload",
+            logger.String("sessionId", session.ID),
+            logger.Error(err))
+    }
+}
+
+// adviseChunkSize sets the client's throughput on the session, with the
+// chunk size recommended for its next session
+func (s *uploadSessionService) adviseChunkSize(ctx context.Context, session *models.UploadSession) {
+    if s.config.Throughput == nil {
+        return
+    }
+    client := throughputClient(ctx, session.OwnerID)
+    estimate, ok := s.config.Throughput.Estimate(client)
+    if !ok {
+        return
+    }
+    session.Throughput = int64(estimate.BytesPerSecond)
+    session.RecommendedChunkSize = s.config.Throughput.ChunkSize(client, s.config.ChunkSize)
+}
+
+// throughputClient identifies the client whose throughput a chunk upload
+// measures: the owner on the address it uploads from, since one user's
+// devices may be on very different networks
+func throughputClient(ctx context.Context, ownerID string) string {
+    if ownerID == "" {
+        return ""
+    }
+    return ownerID + "@" + requestctx.ClientIP(ctx)
+}
+
+// uploadInterrupted reports whether an upload failed because the client went
+// away: its request was cancelled or its body ended early
+func uploadInterrupted(ctx context.Context, err error) bool {
+    return errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "os"
+    "strings"
+    "time"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/watermark"
+)
+
+// ErrWatermarkTooLarge is returned when a file that must be watermarked is too
+// large to stamp in memory. Such downloads fail closed rather than serving the
+// unmarked original.
+var ErrWatermarkTooLarge = errors.New("file too large to watermark")
+
+// defaultWatermarkOpacity is used by rules that do not set an opacity
+const defaultWatermarkOpacity = 0.3
+
+// WatermarkRule stamps Text onto downloads of ContentTypes. Text may contain
+// the placeholders {user}, {email}, {tenant}, {file} and {time}. An empty
+// ContentTypes covers every type that can be watermarked; entries may use a
+// "type/*" wildcard.
+type WatermarkRule struct {
+    ContentTypes []string `json:"contentTypes"`
+    Text         string   `json:"text"`
+    Opacity      float64  `json:"opacity"`
+}
+
+// WatermarkPolicy selects the watermark applied to a tenant's downloads.
+// Tenants without an entry use Default; a tenant mapped to null, like a
+// missing Default, downloads unmarked files.
+type WatermarkPolicy struct {
+    Default *WatermarkRule            `json:"default"`
+    Tenants map[string]*WatermarkRule `json:"tenants"`
+}
+
+// WatermarkViewer identifies the downloader stamped onto a file
+type WatermarkViewer struct {
+    UserID   string
+    Email    string
+    TenantID string
+}
+
+// LoadWatermarkPolicy reads a watermark policy from a JSON file
+func LoadWatermarkPolicy(path string) (*WatermarkPolicy, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read watermark policy: %w", err)
+    }
+
+    var policy WatermarkPolicy
+    if err := json.Unmarshal(data, &policy); err != nil {
+        return nil, fmt.Errorf("failed to parse watermark policy: %w", err)
+    }
+    if err := policy.Validate(); err != nil {
+        return nil, err
+    }
+
+    return &policy, nil
+}
+
+// Validate checks every rule's text, opacity and content types, applying the
+// default opacity where none is set
+func (p *WatermarkPolicy) Validate() error {
+    if err := p.Default.validate("default"); err != nil {
+        return err
+    }
+    for tenant, rule := range p.Tenants {
+        if err := rule.validate(fmt.Sprintf("tenant %q", tenant)); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (r *WatermarkRule) validate(name string) error {
+    if r == nil {
+        return nil
+    }
+    if strings.TrimSpace(r.Text) == "" {
+        return fmt.Errorf("watermark rule for %s has no text", name)
+    }
+    if r.Opacity == 0 {
+        r.Opacity = defaultWatermarkOpacity
+    }
+    if r.Opacity < 0 || r.Opacity > 1 {
+        return fmt.Errorf("watermark rule for %s has invalid opacity %v", name, r.Opacity)
+    }
+    for _, contentType := range r.ContentTypes {
+        if _, _, err := mime.ParseMediaType(contentType); err != nil {
+            return fmt.Errorf("watermark rule for %s has invalid content type %q", name, contentType)
+        }
+    }
+    return nil
+}
+
+// RuleFor returns the rule watermarking a tenant's downloads of contentType,
+// or nil when such downloads are served unmarked
+func (p *WatermarkPolicy) RuleFor(tenantID, contentType string) *WatermarkRule {
+    rule := p.Default
+    if tenantRule, ok := p.Tenants[tenantID]; ok {
+        rule = tenantRule
+    }
+
+    if rule == nil || !watermark.Supports(contentType) {
+        return nil
+    }
+    if len(rule.ContentTypes) > 0 && !(UploadRule{ContentTypes: rule.ContentTypes}).allowsType(contentType) {
+        return nil
+    }
+    return rule
+}
+
+// Watermarker stamps downloads on the fly according to a WatermarkPolicy
+type Watermarker struct {
+    policy  *WatermarkPolicy
+    maxSize int64
+    now     func() time.Time
+}
+
+// NewWatermarker creates a Watermarker that stamps files of up to maxSize bytes
+func NewWatermarker(policy *WatermarkPolicy, maxSize int64) (*Watermarker, error) {
+    if policy == nil {
+        return nil, errors.New("watermark policy is required")
+    }
+    if maxSize <= 0 {
+        return nil, errors.New("watermark max size must be positive")
+    }
+
+    return &Watermarker{
+        policy:  policy,
+        maxSize: maxSize,
+        now:     time.Now,
+    }, nil
+}
+
+// RuleFor returns the rule watermarking the viewer's download of file, or nil.
+// Client-encrypted files are never stamped since the service cannot read them.
+func (w *Watermarker) RuleFor(file *models.File, viewer WatermarkViewer) *WatermarkRule {
+    if file.IsClientEncrypted() {
+        return nil
+    }
+    return w.policy.RuleFor(viewer.TenantID, file.ContentType)
+}
+
+// Stamp reads the content of file from reader and returns it stamped with
+// rule's text for viewer
+func (w *Watermarker) Stamp(rule *WatermarkRule, file *models.File, reader io.Reader, viewer WatermarkViewer) ([]byte, error) {
+    if file.Size > w.maxSize {
+        return nil, ErrWatermarkTooLarge
+    }
+
+    content, err := io.ReadAll(io.LimitReader(reader, w.maxSize+1))
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    if int64(len(content)) > w.maxSize {
+        return nil, ErrWatermarkTooLarge
+    }
+
+    text := strings.NewReplacer(
+        "{user}", viewer.UserID,
+        "{email}", viewer.Email,
+        "{tenant}", viewer.TenantID,
+        "{file}", file.FileName,
+        "{time}", w.now().UTC().Format(time.RFC3339),
+    ).Replace(rule.Text)
+
+    stamped, err := watermark.Stamp(file.ContentType, content, text, rule.Opacity)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrOperationFailed, err)
+    }
+    return stamped, nil
+}
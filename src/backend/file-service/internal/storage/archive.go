@@ -0,0 +1,144 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+    "github.com/aws/smithy-go"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ErrObjectArchived is returned for reads of objects in an archival storage
+// class that have no restored copy
+var ErrObjectArchived = errors.New("object is archived")
+
+// restoreExpiryPattern extracts the expiry of a restored copy from the
+// x-amz-restore header: ongoing-request="false", expiry-date="..."
+var restoreExpiryPattern = regexp.MustCompile(`expiry-date="([^"]+)"`)
+
+// RestoreStatus is the state of a restore of an archived object
+type RestoreStatus struct {
+    // Ongoing is set while S3 is still retrieving the object
+    Ongoing bool
+    // ExpiresAt is when the restored copy is removed; nil when there is none
+    ExpiresAt *time.Time
+}
+
+// ArchiveStorage moves objects to archival storage classes and restores
+// temporary readable copies of them
+type ArchiveStorage interface {
+    // Transition moves the file's object to storageClass
+    Transition(ctx context.Context, file *models.File, storageClass string) error
+    // Restore starts a restore of the file's object, readable for days once
+    // retrieved at tier; a restore already in progress is not an error
+    Restore(ctx context.Context, file *models.File, days int, tier string) error
+    // RestoreStatus reports the state of the file's object restore
+    RestoreStatus(ctx context.Context, file *models.File) (RestoreStatus, error)
+}
+
+// TierStorage moves objects between storage classes
+type TierStorage interface {
+    // Transition moves the file's object to storageClass
+    Transition(ctx context.Context, file *models.File, storageClass string) error
+}
+
+// Transition copies the file's object onto itself in storageClass. The copy
+// is encrypted as configured, which is recorded on the file, and keeps the
+// object's metadata and tags.
+func (s *S3Storage) Transition(ctx context.Context, file *models.File, storageClass string) error {
+    if err := s.tenants.check(ctx, file.StoragePath); err != nil {
+        return err
+    }
+
+    input := &s3.CopyObjectInput{
+        Bucket:       aws.String(s.buckets.forKey(file.StoragePath)),
+        CopySource:   aws.String(s.buckets.copySource(file.StoragePath)),
+        Key:          aws.String(file.StoragePath),
+        StorageClass: types.StorageClass(storageClass),
+    }
+    s.sse.applyCopy(input)
+
+    result, err := s.s3Client.CopyObject(ctx, input)
+    if err != nil {
+        return fmt.Errorf("s3 storage class transition failed: %w", err)
+    }
+    file.ServerSideEncryption = s.sse.applied(result.ServerSideEncryption, result.SSEKMSKeyId)
+
+    s.logger.Info("Transitioned object",
+        logger.String("fileId", file.ID),
+        logger.String("storageClass", storageClass))
+    return nil
+}
+
+// Restore requests a temporary copy of the file's archived object
+func (s *S3Storage) Restore(ctx context.Context, file *models.File, days int, tier string) error {
+    _, err := s.s3Client.RestoreObject(ctx, &s3.RestoreObjectInput{
+        Bucket: aws.String(s.buckets.forKey(file.StoragePath)),
+        Key:    aws.String(file.StoragePath),
+        RestoreRequest: &types.RestoreRequest{
+            Days: int32(days),
+            GlacierJobParameters: &types.GlacierJobParameters{
+                Tier: types.Tier(tier),
+            },
+        },
+    })
+    if err != nil && !hasErrorCode(err, "RestoreAlreadyInProgress") {
+        return fmt.Errorf("s3 restore object failed: %w", err)
+    }
+    return nil
+}
+
+// RestoreStatus reads the restore state of the file's object from its
+// x-amz-restore header, which is absent when no restored copy exists
+func (s *S3Storage) RestoreStatus(ctx context.Context, file *models.File) (RestoreStatus, error) {
+    head, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+        Bucket: aws.String(s.buckets.forKey(file.StoragePath)),
+        Key:    aws.String(file.StoragePath),
+    })
+    if err != nil {
+        return RestoreStatus{}, fmt.Errorf("s3 head object failed: %w", err)
+    }
+    return parseRestoreStatus(aws.ToString(head.Restore))
+}
+
+// parseRestoreStatus parses an x-amz-restore header
+func parseRestoreStatus(header string) (RestoreStatus, error) {
+    if header == "" {
+        return RestoreStatus{}, nil
+    }
+    if strings.Contains(header, `ongoing-request="true"`) {
+        return RestoreStatus{Ongoing: true}, nil
+    }
+
+    match := restoreExpiryPattern.FindStringSubmatch(header)
+    if match == nil {
+        return RestoreStatus{}, nil
+    }
+    expiresAt, err := time.Parse(http.TimeFormat, match[1])
+    if err != nil {
+        return RestoreStatus{}, fmt.Errorf("invalid restore expiry %q: %w", match[1], err)
+    }
+    return RestoreStatus{ExpiresAt: &expiresAt}, nil
+}
+
+// isObjectArchived reports whether S3 refused a read because the object is
+// in an archival storage class without a restored copy
+func isObjectArchived(err error) bool {
+    return hasErrorCode(err, "InvalidObjectState")
+}
+
+// hasErrorCode reports whether err is an S3 error with the given code
+func hasErrorCode(err error, code string) bool {
+    var apiErr smithy.APIError
+    return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
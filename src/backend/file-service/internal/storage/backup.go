@@ -0,0 +1,64 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "path"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/pkg/logger"
+)
+
+// BackupStorage checks the objects listed in a backup, and copies them
+// from the bucket of the environment backed up into the one restored
+type BackupStorage interface {
+    // StatObject reports the size and checksum of the object under key, or
+    // ErrObjectNotFound if there is none
+    StatObject(ctx context.Context, key string) (*UploadedObject, error)
+    GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+    // CopyObjectFrom copies the object under key in bucket to the same key
+    CopyObjectFrom(ctx context.Context, bucket, key string) error
+}
+
+// CopyObjectFrom copies the object under key in another bucket, such as
+// the primary or secondary bucket of the environment a backup was taken
+// of, keeping its content type and metadata, which objects too large for
+// a single copy would otherwise lose. The copy is encrypted as configured
+// and has its SHA-256 recorded; Object Lock retention is not carried over.
+func (s *S3Storage) CopyObjectFrom(ctx context.Context, bucket, key string) error {
+    head, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+        Bucket: aws.String(bucket),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        var notFound *types.NotFound
+        if errors.As(err, &notFound) {
+            return fmt.Errorf("%w in %s", ErrObjectNotFound, bucket)
+        }
+        return fmt.Errorf("s3 head object failed: %w", err)
+    }
+
+    input := &s3.CopyObjectInput{
+        Bucket:            aws.String(s.buckets.forKey(key)),
+        CopySource:        aws.String(path.Join(bucket, key)),
+        Key:               aws.String(key),
+        ContentType:       head.ContentType,
+        MetadataDirective: types.MetadataDirectiveReplace,
+        Metadata:          head.Metadata,
+        ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+    }
+    s.sse.applyCopy(input)
+    if err := CopyObject(ctx, s.s3Client, input, head.ContentLength, s.copy); err != nil {
+        return fmt.Errorf("s3 copy failed: %w", err)
+    }
+
+    s.logger.Info("Copied object from backed up bucket",
+        logger.String("bucket", bucket),
+        logger.String("key", key))
+    return nil
+}
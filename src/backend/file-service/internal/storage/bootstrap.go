@@ -0,0 +1,130 @@
+package storage
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/pkg/logger"
+)
+
+// bucketPolicy is an S3 bucket policy document
+type bucketPolicy struct {
+    Version   string            `json:"Version"`
+    Statement []policyStatement `json:"Statement"`
+}
+
+// policyStatement is a statement of a bucket policy
+type policyStatement struct {
+    Sid       string                       `json:"Sid"`
+    Effect    string                       `json:"Effect"`
+    Principal string                       `json:"Principal"`
+    Action    string                       `json:"Action"`
+    Resource  []string                     `json:"Resource"`
+    Condition map[string]map[string]string `json:"Condition,omitempty"`
+}
+
+// BaselineBucketPolicy returns the bucket policy bootstrapped buckets get:
+// requests over plain HTTP are denied when the endpoint is reached over
+// TLS. It returns "" when there is nothing to deny.
+func BaselineBucketPolicy(bucket string, secureTransport bool) (string, error) {
+    policy := bucketPolicy{Version: "2012-10-17"}
+    resources := []string{"arn:aws:s3:::" + bucket, "arn:aws:s3:::" + bucket + "/*"}
+    if secureTransport {
+        policy.Statement = append(policy.Statement, policyStatement{
+            Sid:       "DenyInsecureTransport",
+            Effect:    "Deny",
+            Principal: "*",
+            Action:    "s3:*",
+            Resource:  resources,
+            Condition: map[string]map[string]string{"Bool": {"aws:SecureTransport": "false"}},
+        })
+    }
+    if len(policy.Statement) == 0 {
+        return "", nil
+    }
+
+    document, err := json.Marshal(policy)
+    if err != nil {
+        return "", err
+    }
+    return string(document), nil
+}
+
+// bootstrapBuckets creates the buckets that do not exist yet, for
+// development environments such as MinIO. Each new bucket has Object Lock
+// enabled when content is protected, versioning, the configured encryption
+// as its default and the baseline bucket policy. Existing buckets are not
+// changed.
+func (s *S3Storage) bootstrapBuckets(ctx context.Context, secureTransport bool) error {
+    for _, bucket := range s.buckets.all() {
+        _, err := s.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+            Bucket: aws.String(bucket),
+        })
+        var notFound *types.NotFound
+        var noSuchBucket *types.NoSuchBucket
+        if err == nil || !errors.As(err, &notFound) && !errors.As(err, &noSuchBucket) {
+            continue
+        }
+        if err := s.createBucket(ctx, bucket, secureTransport); err != nil {
+            return fmt.Errorf("bucket bootstrap failed: %s: %w", bucket, err)
+        }
+        s.logger.Info("Created bucket",
+            logger.String("bucket", bucket),
+            logger.Bool("objectLock", s.lock.enabled()))
+    }
+    return nil
+}
+
+// createBucket creates and configures a bootstrapped bucket
+func (s *S3Storage) createBucket(ctx context.Context, bucket string, secureTransport bool) error {
+    input := &s3.CreateBucketInput{
+        Bucket:                     aws.String(bucket),
+        ObjectLockEnabledForBucket: s.lock.enabled(),
+    }
+    // Buckets are created in us-east-1 unless another region is named
+    if s.region != "" && s.region != "us-east-1" {
+        input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+            LocationConstraint: types.BucketLocationConstraint(s.region),
+        }
+    }
+    if _, err := s.s3Client.CreateBucket(ctx, input); err != nil {
+        var owned *types.BucketAlreadyOwnedByYou
+        if !errors.As(err, &owned) {
+            return fmt.Errorf("s3 create bucket failed: %w", err)
+        }
+    }
+
+    if _, err := s.s3Client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+        Bucket: aws.String(bucket),
+        VersioningConfiguration: &types.VersioningConfiguration{
+            Status: types.BucketVersioningStatusEnabled,
+        },
+    }); err != nil {
+        return fmt.Errorf("s3 put bucket versioning failed: %w", err)
+    }
+
+    if _, err := s.s3Client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+        Bucket:                            aws.String(bucket),
+        ServerSideEncryptionConfiguration: s.sse.bucketDefault(),
+    }); err != nil {
+        return fmt.Errorf("s3 put bucket encryption failed: %w", err)
+    }
+
+    policy, err := BaselineBucketPolicy(bucket, secureTransport)
+    if err != nil || policy == "" {
+        return err
+    }
+    if _, err := s.s3Client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+        Bucket: aws.String(bucket),
+        Policy: aws.String(policy),
+    }); err != nil {
+        return fmt.Errorf("s3 put bucket policy failed: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/pkg/logger"
+)
+
+// MaxDeleteBatch is the most keys S3 deletes in one DeleteObjects request
+const MaxDeleteBatch = 1000
+
+// BatchDeleteStorage deletes many objects with one request per
+// MaxDeleteBatch keys rather than one per object
+type BatchDeleteStorage interface {
+    // DeleteObjects deletes the objects under keys and returns the keys that
+    // could not be deleted with the reason. Keys of missing objects count
+    // as deleted.
+    DeleteObjects(ctx context.Context, keys []string) map[string]error
+}
+
+// DeleteObjects deletes the objects under keys in batches of
+// MaxDeleteBatch per bucket. A failed request fails every key of its batch;
+// the other batches are still attempted.
+func (s *S3Storage) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+    byBucket := make(map[string][]string)
+    for _, key := range keys {
+        bucket := s.buckets.forKey(key)
+        byBucket[bucket] = append(byBucket[bucket], key)
+    }
+
+    failed := make(map[string]error)
+    for bucket, keys := range byBucket {
+        s.deleteBatches(ctx, bucket, keys, failed)
+    }
+
+    s.logger.Info("Deleted objects",
+        logger.Int("count", len(keys)-len(failed)),
+        logger.Int("failed", len(failed)))
+    return failed
+}
+
+// deleteBatches deletes the objects under keys in bucket, recording the
+// keys that could not be deleted in failed
+func (s *S3Storage) deleteBatches(ctx context.Context, bucket string, keys []string, failed map[string]error) {
+    for start := 0; start < len(keys); start += MaxDeleteBatch {
+        batch := keys[start:min(start+MaxDeleteBatch, len(keys))]
+        objects := make([]types.ObjectIdentifier, 0, len(batch))
+        for _, key := range batch {
+            objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+        }
+
+        // Quiet mode only reports the keys that failed
+        result, err := s.s3Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+            Bucket: aws.String(bucket),
+            Delete: &types.Delete{
+                Objects: objects,
+                Quiet:   true,
+            },
+        })
+        if err != nil {
+            s.logger.Error("Failed to delete objects",
+                logger.String("bucket", bucket),
+                logger.Int("count", len(batch)),
+                logger.Error(err))
+            for _, key := range batch {
+                failed[key] = fmt.Errorf("s3 deletion failed: %w", err)
+            }
+            continue
+        }
+        for _, objectErr := range result.Errors {
+            key := aws.ToString(objectErr.Key)
+            failed[key] = fmt.Errorf("s3 deletion failed: %s: %s",
+                aws.ToString(objectErr.Code), aws.ToString(objectErr.Message))
+        }
+    }
+}
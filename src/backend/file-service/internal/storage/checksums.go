@@ -0,0 +1,114 @@
+package storage
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "hash"
+    "strconv"
+    "strings"
+
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrStoredChecksumMismatch is returned when the checksum S3 recorded for
+// uploaded content does not match the one taken while sending it
+var ErrStoredChecksumMismatch = errors.New("checksum recorded by S3 does not match the uploaded content")
+
+// additionalChecksums are the SHA-256 checksums (x-amz-checksum-sha256) the
+// SDK computes as content is sent, which S3 validates and records with the
+// object. On downloads read with a single request S3 returns the recorded
+// checksum and the SDK validates the content against it as it is read;
+// ranged and parallel downloads carry no checksum to validate.
+type additionalChecksums struct {
+    enabled bool
+}
+
+// applyPut requests a checksum of an object upload, unless one was given
+func (c additionalChecksums) applyPut(input *s3.PutObjectInput) {
+    if c.enabled && input.ChecksumSHA256 == nil {
+        input.ChecksumAlgorithm = types.ChecksumAlgorithmSha256
+    }
+}
+
+// applyGet requests the recorded checksum with a download
+func (c additionalChecksums) applyGet(input *s3.GetObjectInput) {
+    if c.enabled {
+        input.ChecksumMode = types.ChecksumModeEnabled
+    }
+}
+
+// partHasher takes the SHA-256 of each part of content written to it, split
+// as the transfer manager splits uploads into parts of size bytes, so the
+// checksum S3 records for an upload in parts can be derived locally
+type partHasher struct {
+    size    int64
+    written int64
+    current hash.Hash
+    digests [][]byte
+}
+
+func newPartHasher(size int64) *partHasher {
+    return &partHasher{size: size, current: sha256.New()}
+}
+
+func (h *partHasher) Write(p []byte) (int, error) {
+    n := len(p)
+    for len(p) > 0 {
+        chunk := p[:min(int64(len(p)), h.size-h.written)]
+        h.current.Write(chunk)
+        h.written += int64(len(chunk))
+        p = p[len(chunk):]
+        if h.written == h.size {
+            h.digests = append(h.digests, h.current.Sum(nil))
+            h.current.Reset()
+            h.written = 0
+        }
+    }
+    return n, nil
+}
+
+// composite returns the checksum of the parts' checksums in parts, as hex
+// suffixed with the part count like decodeChecksum returns it, or "" if
+// the content was not split into that many parts
+func (h *partHasher) composite(parts int) string {
+    digests := h.digests
+    if h.written > 0 {
+        digests = append(digests[:len(digests):len(digests)], h.current.Sum(nil))
+    }
+    if len(digests) != parts {
+        return ""
+    }
+    combined := sha256.New()
+    for _, digest := range digests {
+        combined.Write(digest)
+    }
+    return hex.EncodeToString(combined.Sum(nil)) + "-" + strconv.Itoa(parts)
+}
+
+// verifyStoredChecksum compares the checksum S3 recorded for an upload,
+// base64 as S3 reports it, with checksum, the hex SHA-256 of the content
+// sent, or for an upload in parts with the checksum derived from parts. It
+// reports whether S3 recorded a checksum at all; S3-compatible stores may
+// not.
+func verifyStoredChecksum(recorded, checksum string, parts *partHasher) (bool, error) {
+    stored, err := decodeChecksum(recorded)
+    if err != nil || stored == "" {
+        return false, err
+    }
+
+    expected := checksum
+    if _, count, composite := strings.Cut(stored, "-"); composite {
+        n, err := strconv.Atoi(count)
+        if err != nil {
+            return true, fmt.Errorf("invalid object checksum: %s", stored)
+        }
+        expected = parts.composite(n)
+    }
+    if stored != expected {
+        return true, fmt.Errorf("%w: S3 recorded %s", ErrStoredChecksumMismatch, stored)
+    }
+    return true, nil
+}
@@ -0,0 +1,176 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "sync"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// MaxCopyObjectSize is the largest object a single CopyObject copies
+const MaxCopyObjectSize = 5 << 30
+
+// maxCopyParts is the most parts a multipart upload takes
+const maxCopyParts = 10000
+
+// CopyOptions tune how objects too large for a single CopyObject are copied
+type CopyOptions struct {
+    // PartSize is the size of the ranges copied with each UploadPartCopy;
+    // it grows for objects that would take more than 10,000 parts
+    PartSize int64
+    // Concurrency is the number of parts copied at a time
+    Concurrency int
+}
+
+// CopyAPIClient is the S3 API objects are copied with
+type CopyAPIClient interface {
+    CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+    CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+    UploadPartCopy(ctx context.Context, params *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error)
+    CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+    AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// CopyObject makes the copy input describes of an object size bytes long.
+// Objects up to MaxCopyObjectSize are copied with a single CopyObject;
+// larger ones are copied in ranges of PartSize with UploadPartCopy into a
+// multipart upload, which is aborted if a part fails. A multipart copy does
+// not carry over the source's metadata or tags, so it gets input's content
+// type, metadata and, when replaced, tags whatever its directives.
+func CopyObject(ctx context.Context, client CopyAPIClient, input *s3.CopyObjectInput, size int64,
+    opts CopyOptions) error {
+    if size <= MaxCopyObjectSize {
+        _, err := client.CopyObject(ctx, input)
+        return err
+    }
+
+    created, err := client.CreateMultipartUpload(ctx, multipartCopyInput(input))
+    if err != nil {
+        return fmt.Errorf("failed to start multipart copy: %w", err)
+    }
+    uploadID := created.UploadId
+
+    parts, err := copyParts(ctx, client, input, uploadID, size, opts)
+    if err == nil {
+        _, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+            Bucket:          input.Bucket,
+            Key:             input.Key,
+            UploadId:        uploadID,
+            MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+        })
+    }
+    if err != nil {
+        // Uncompleted uploads keep their parts, and their cost, until aborted
+        _, _ = client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+            Bucket:   input.Bucket,
+            Key:      input.Key,
+            UploadId: uploadID,
+        })
+        return fmt.Errorf("multipart copy failed: %w", err)
+    }
+    return nil
+}
+
+// copyParts copies the source of input into the multipart upload in parts,
+// Concurrency at a time, returning the completed parts in order
+func copyParts(ctx context.Context, client CopyAPIClient, input *s3.CopyObjectInput, uploadID *string,
+    size int64, opts CopyOptions) ([]types.CompletedPart, error) {
+    partSize := opts.PartSize
+    if minimum := (size + maxCopyParts - 1) / maxCopyParts; partSize < minimum {
+        partSize = minimum
+    }
+    count := int((size + partSize - 1) / partSize)
+    concurrency := opts.Concurrency
+    if concurrency <= 0 {
+        concurrency = 1
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+
+    parts := make([]types.CompletedPart, count)
+    slots := make(chan struct{}, concurrency)
+    var (
+        wg       sync.WaitGroup
+        once     sync.Once
+        firstErr error
+    )
+    for i := 0; i < count; i++ {
+        select {
+        case slots <- struct{}{}:
+        case <-ctx.Done():
+        }
+        if ctx.Err() != nil {
+            break
+        }
+
+        wg.Add(1)
+        go func(i int) {
+            defer wg.Done()
+            defer func() { <-slots }()
+
+            start := int64(i) * partSize
+            end := min(start+partSize, size) - 1
+            number := int32(i + 1)
+            result, err := client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+                Bucket:                         input.Bucket,
+                Key:                            input.Key,
+                UploadId:                       uploadID,
+                PartNumber:                     number,
+                CopySource:                     input.CopySource,
+                CopySourceRange:                aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+                CopySourceSSECustomerAlgorithm: input.CopySourceSSECustomerAlgorithm,
+                CopySourceSSECustomerKey:       input.CopySourceSSECustomerKey,
+                CopySourceSSECustomerKeyMD5:    input.CopySourceSSECustomerKeyMD5,
+                SSECustomerAlgorithm:           input.SSECustomerAlgorithm,
+                SSECustomerKey:                 input.SSECustomerKey,
+                SSECustomerKeyMD5:              input.SSECustomerKeyMD5,
+            })
+            if err != nil {
+                once.Do(func() {
+                    firstErr = fmt.Errorf("part %d: %w", number, err)
+                    cancel()
+                })
+                return
+            }
+            parts[i] = types.CompletedPart{ETag: result.CopyPartResult.ETag, PartNumber: number}
+        }(i)
+    }
+    wg.Wait()
+
+    if firstErr != nil {
+        return nil, firstErr
+    }
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+    return parts, nil
+}
+
+// multipartCopyInput creates the multipart upload a copy is assembled in,
+// written as input's copy would be
+func multipartCopyInput(input *s3.CopyObjectInput) *s3.CreateMultipartUploadInput {
+    created := &s3.CreateMultipartUploadInput{
+        Bucket:                    input.Bucket,
+        Key:                       input.Key,
+        ContentType:               input.ContentType,
+        Metadata:                  input.Metadata,
+        StorageClass:              input.StorageClass,
+        ServerSideEncryption:      input.ServerSideEncryption,
+        SSEKMSKeyId:               input.SSEKMSKeyId,
+        BucketKeyEnabled:          input.BucketKeyEnabled,
+        SSECustomerAlgorithm:      input.SSECustomerAlgorithm,
+        SSECustomerKey:            input.SSECustomerKey,
+        SSECustomerKeyMD5:         input.SSECustomerKeyMD5,
+        ObjectLockMode:            input.ObjectLockMode,
+        ObjectLockRetainUntilDate: input.ObjectLockRetainUntilDate,
+        ObjectLockLegalHoldStatus: input.ObjectLockLegalHoldStatus,
+    }
+    if input.TaggingDirective == types.TaggingDirectiveReplace {
+        created.Tagging = input.Tagging
+    }
+    return created
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "io"
+    "sync"
+
+    "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DownloadOptions tune how objects are read through the transfer manager
+type DownloadOptions struct {
+    // PartSize is the size of the ranged requests an object is read with
+    PartSize int64
+    // Concurrency is the number of parts read at a time; 1 reads objects
+    // with a single GetObject
+    Concurrency int
+}
+
+// errStreamClosed is returned to the transfer manager once the reader of a
+// stream went away
+var errStreamClosed = errors.New("download stream closed")
+
+// StreamObject streams the object input names. With a Concurrency above 1
+// the object is read by the transfer manager in parallel ranged requests of
+// PartSize; parts arriving ahead of the reader are buffered, up to
+// Concurrency parts, and the content is streamed in order. It returns once
+// the first part responded, so errors such as a missing object or a wrong
+// customer key are returned here rather than from the stream.
+func StreamObject(ctx context.Context, client manager.DownloadAPIClient, input *s3.GetObjectInput,
+    opts DownloadOptions) (io.ReadCloser, error) {
+    if opts.Concurrency <= 1 {
+        result, err := client.GetObject(ctx, input)
+        if err != nil {
+            return nil, err
+        }
+        return result.Body, nil
+    }
+
+    ctx, cancel := context.WithCancel(ctx)
+    pr, pw := io.Pipe()
+    stream := &partStream{
+        pw:       pw,
+        partSize: opts.PartSize,
+        limit:    int64(opts.Concurrency) * opts.PartSize,
+        parts:    make(map[int64][]byte),
+        started:  make(chan struct{}),
+    }
+    stream.cond = sync.NewCond(&stream.mu)
+
+    downloader := manager.NewDownloader(client, func(d *manager.Downloader) {
+        d.PartSize = opts.PartSize
+        d.Concurrency = opts.Concurrency
+    })
+    done := make(chan error, 1)
+    go func() {
+        _, err := downloader.Download(ctx, stream, input)
+        done <- err
+        stream.finish(err)
+    }()
+
+    // The download ends without writing when the object is empty or the
+    // first part failed
+    select {
+    case <-stream.started:
+    case err := <-done:
+        if err != nil {
+            cancel()
+            return nil, err
+        }
+    }
+    return &streamReader{PipeReader: pr, stream: stream, cancel: cancel}, nil
+}
+
+// partStream is the io.WriterAt the transfer manager writes parts to. The
+// content is written to a pipe in order: bytes at the read position are
+// written through, while parts further ahead are held until the parts before
+// them were written. Parts are written from their start on every attempt, so
+// bytes already written are skipped.
+type partStream struct {
+    mu       sync.Mutex
+    cond     *sync.Cond
+    pw       *io.PipeWriter
+    partSize int64
+    // limit bounds the bytes held before writers further ahead wait
+    limit int64
+    // parts holds the bytes received of each part not yet fully written,
+    // by part number
+    parts    map[int64][]byte
+    buffered int64
+    // next is the offset of the next byte to write to the pipe
+    next int64
+    // writing is set while a writer drains parts into the pipe
+    writing bool
+    closed  bool
+
+    started     chan struct{}
+    startedOnce sync.Once
+}
+
+func (s *partStream) WriteAt(p []byte, off int64) (int, error) {
+    s.startedOnce.Do(func() { close(s.started) })
+
+    s.mu.Lock()
+    part := off / s.partSize
+    for !s.closed && part != s.next/s.partSize && s.buffered >= s.limit {
+        s.cond.Wait()
+    }
+    if s.closed {
+        s.mu.Unlock()
+        return 0, errStreamClosed
+    }
+    // Retried parts rewrite bytes that may have been written already
+    if off+int64(len(p)) <= s.next {
+        s.mu.Unlock()
+        return len(p), nil
+    }
+
+    data := s.parts[part]
+    if data == nil {
+        data = make([]byte, 0, s.partSize)
+    }
+    end := off - part*s.partSize + int64(len(p))
+    if grown := end - int64(len(data)); grown > 0 {
+        data = append(data, make([]byte, grown)...)
+        s.buffered += grown
+    }
+    copy(data[off-part*s.partSize:], p)
+    s.parts[part] = data
+
+    // Another writer is already draining and will pick up these bytes
+    if s.writing {
+        s.mu.Unlock()
+        return len(p), nil
+    }
+    err := s.drain()
+    s.mu.Unlock()
+    if err != nil {
+        return 0, err
+    }
+    return len(p), nil
+}
+
+// drain writes the bytes at the read position to the pipe until it reaches
+// bytes not yet received. It is called with mu held, which it releases
+// while writing.
+func (s *partStream) drain() error {
+    s.writing = true
+    defer func() {
+        s.writing = false
+        s.cond.Broadcast()
+    }()
+
+    for !s.closed {
+        part := s.next / s.partSize
+        data := s.parts[part]
+        pos := s.next - part*s.partSize
+        if pos >= int64(len(data)) {
+            return nil
+        }
+        chunk := append([]byte(nil), data[pos:]...)
+
+        s.mu.Unlock()
+        _, err := s.pw.Write(chunk)
+        s.mu.Lock()
+        if err != nil {
+            s.closed = true
+            return err
+        }
+
+        s.next += int64(len(chunk))
+        if s.next == (part+1)*s.partSize {
+            s.buffered -= int64(len(s.parts[part]))
+            delete(s.parts, part)
+            s.cond.Broadcast()
+        }
+    }
+    return errStreamClosed
+}
+
+// finish ends the stream once the download completed or failed with err
+func (s *partStream) finish(err error) {
+    s.mu.Lock()
+    for s.writing {
+        s.cond.Wait()
+    }
+    s.closed = true
+    s.parts = nil
+    s.cond.Broadcast()
+    s.mu.Unlock()
+    s.pw.CloseWithError(err)
+}
+
+// streamReader is the content of a streamed object; closing it stops the
+// download
+type streamReader struct {
+    *io.PipeReader
+    stream *partStream
+    cancel context.CancelFunc
+}
+
+func (r *streamReader) Close() error {
+    r.cancel()
+    err := r.PipeReader.Close()
+    r.stream.mu.Lock()
+    r.stream.closed = true
+    r.stream.cond.Broadcast()
+    r.stream.mu.Unlock()
+    return err
+}
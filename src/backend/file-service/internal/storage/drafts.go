@@ -0,0 +1,68 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "path"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// ScratchPrefix is the key prefix for uncommitted draft uploads. A bucket
+// lifecycle rule expiring this prefix backs up the draft purge job.
+const ScratchPrefix = storagekey.Scratch
+
+// ScratchKey returns the object key used for a draft of the file with the given ID
+func ScratchKey(fileID string) string {
+    return path.Join(ScratchPrefix, StorageKey(fileID))
+}
+
+// DraftStorage moves committed drafts out of the scratch prefix
+type DraftStorage interface {
+    PromoteDraft(ctx context.Context, file *models.File) error
+}
+
+// PromoteDraft copies a draft to its permanent key, in the same tenant's
+// scope, removes the scratch copy and updates the file's storage path
+func (s *S3Storage) PromoteDraft(ctx context.Context, file *models.File) error {
+    log := s.logger.With(logger.String("fileId", file.ID))
+
+    storagePath := StorageKey(file.ID)
+    if tenantID := storagekey.Default.Tenant(file.StoragePath); tenantID != "" {
+        storagePath = storagekey.Default.ForTenant(tenantID, storagePath)
+    }
+    input := &s3.CopyObjectInput{
+        Bucket:     aws.String(s.buckets.forKey(storagePath)),
+        CopySource: aws.String(s.buckets.copySource(file.StoragePath)),
+        Key:        aws.String(storagePath),
+    }
+    s.sse.applyCopy(input)
+    replaceTags(input, file)
+    s.lock.applyCopy(input, file)
+
+    result, err := s.s3Client.CopyObject(ctx, input)
+    if err != nil {
+        return fmt.Errorf("s3 copy failed: %w", err)
+    }
+
+    // A leftover scratch copy is removed by the bucket lifecycle rule
+    if _, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+        Bucket: aws.String(s.buckets.forKey(file.StoragePath)),
+        Key:    aws.String(file.StoragePath),
+    }); err != nil {
+        log.Warn("Failed to remove scratch copy", logger.Error(err))
+    }
+
+    if err := file.SetStoragePath(storagePath); err != nil {
+        return err
+    }
+    file.ServerSideEncryption = s.sse.applied(result.ServerSideEncryption, result.SSEKMSKeyId)
+
+    log.Info("Promoted draft", logger.String("storagePath", storagePath))
+    return nil
+}
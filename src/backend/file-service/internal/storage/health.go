@@ -0,0 +1,330 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "math"
+    "net/http"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/aws/smithy-go/middleware"
+    smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Backend health statuses
+const (
+    HealthUnknown  = "unknown"
+    HealthHealthy  = "healthy"
+    HealthDegraded = "degraded"
+    HealthDown     = "down"
+)
+
+const (
+    // maxHealthSamples bounds the request outcomes kept per backend
+    maxHealthSamples = 1024
+    // minHealthSamples is the fewest requests in the window from which an
+    // error rate is judged
+    minHealthSamples = 10
+    // degradedErrorRate and downErrorRate are the error rates from which a
+    // backend is reported degraded and down
+    degradedErrorRate = 0.05
+    downErrorRate     = 0.5
+)
+
+// Backend describes a storage backend tracked by a HealthBoard
+type Backend struct {
+    // Name identifies the backend, e.g. its bucket
+    Name   string
+    Kind   string
+    Region string
+    // Probe checks that the backend is reachable; nil disables probing
+    Probe func(ctx context.Context) error
+}
+
+// BackendHealth summarizes a backend's recent requests and probes. Latencies
+// are percentiles over the requests in the window.
+type BackendHealth struct {
+    Name               string     `json:"name"`
+    Kind               string     `json:"kind"`
+    Region             string     `json:"region,omitempty"`
+    Status             string     `json:"status"`
+    Requests           int        `json:"requests"`
+    Errors             int        `json:"errors"`
+    ErrorRate          float64    `json:"errorRate"`
+    LatencyP50Ms       float64    `json:"latencyP50Ms"`
+    LatencyP95Ms       float64    `json:"latencyP95Ms"`
+    LatencyMaxMs       float64    `json:"latencyMaxMs"`
+    LastSuccessAt      *time.Time `json:"lastSuccessAt,omitempty"`
+    LastErrorAt        *time.Time `json:"lastErrorAt,omitempty"`
+    LastError          string     `json:"lastError,omitempty"`
+    LastProbeAt        *time.Time `json:"lastProbeAt,omitempty"`
+    LastProbeSuccessAt *time.Time `json:"lastProbeSuccessAt,omitempty"`
+    LastProbeError     string     `json:"lastProbeError,omitempty"`
+}
+
+// HealthReport is the scoreboard of every tracked backend
+type HealthReport struct {
+    GeneratedAt time.Time       `json:"generatedAt"`
+    Window      string          `json:"window"`
+    Backends    []BackendHealth `json:"backends"`
+}
+
+// healthSample is the outcome of one request to a backend
+type healthSample struct {
+    at      time.Time
+    latency time.Duration
+    failed  bool
+}
+
+// backendState is what a HealthBoard knows about one backend
+type backendState struct {
+    backend   Backend
+    samples   []healthSample
+    next      int
+    lastOK    *time.Time
+    lastErrAt *time.Time
+    lastErr   string
+    probeAt   *time.Time
+    probeOKAt *time.Time
+    probeErr  string
+}
+
+// HealthBoard keeps a scoreboard of storage backends: error rates and
+// latencies of the requests sent to each over a recent window, and the
+// outcome of periodic reachability probes, so operators can tell at a glance
+// which backend is failing
+type HealthBoard struct {
+    mu       sync.Mutex
+    window   time.Duration
+    backends []*backendState
+    byName   map[string]*backendState
+    now      func() time.Time
+}
+
+// NewHealthBoard creates a scoreboard judging backends by their requests
+// over the given window
+func NewHealthBoard(window time.Duration) *HealthBoard {
+    return &HealthBoard{
+        window: window,
+        byName: make(map[string]*backendState),
+        now:    time.Now,
+    }
+}
+
+// Track adds a backend to the scoreboard
+func (b *HealthBoard) Track(backend Backend) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if _, ok := b.byName[backend.Name]; ok {
+        return
+    }
+    state := &backendState{backend: backend}
+    b.backends = append(b.backends, state)
+    b.byName[backend.Name] = state
+}
+
+// Record records the outcome of one request to the named backend. Requests
+// the backend answered with a client error, other than throttling, and
+// requests abandoned by the caller do not count as failures.
+func (b *HealthBoard) Record(name string, latency time.Duration, err error) {
+    if errors.Is(err, context.Canceled) {
+        return
+    }
+
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    state, ok := b.byName[name]
+    if !ok {
+        return
+    }
+
+    now := b.now().UTC()
+    sample := healthSample{at: now, latency: latency, failed: isBackendFailure(err)}
+    if len(state.samples) < maxHealthSamples {
+        state.samples = append(state.samples, sample)
+    } else {
+        state.samples[state.next] = sample
+        state.next = (state.next + 1) % maxHealthSamples
+    }
+
+    if sample.failed {
+        state.lastErrAt = &now
+        state.lastErr = err.Error()
+    } else {
+        state.lastOK = &now
+    }
+}
+
+// Probe probes every backend once, each bounded by timeout
+func (b *HealthBoard) Probe(ctx context.Context, timeout time.Duration) {
+    b.mu.Lock()
+    backends := make([]Backend, 0, len(b.backends))
+    for _, state := range b.backends {
+        backends = append(backends, state.backend)
+    }
+    b.mu.Unlock()
+
+    for _, backend := range backends {
+        if backend.Probe == nil {
+            continue
+        }
+        probeCtx, cancel := context.WithTimeout(ctx, timeout)
+        err := backend.Probe(probeCtx)
+        cancel()
+        if ctx.Err() != nil {
+            return
+        }
+        b.recordProbe(backend.Name, err)
+    }
+}
+
+// Run probes every backend each interval until ctx is done
+func (b *HealthBoard) Run(ctx context.Context, interval, timeout time.Duration) {
+    b.Probe(ctx, timeout)
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            b.Probe(ctx, timeout)
+        }
+    }
+}
+
+// recordProbe records the outcome of a probe of the named backend
+func (b *HealthBoard) recordProbe(name string, err error) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    state, ok := b.byName[name]
+    if !ok {
+        return
+    }
+
+    now := b.now().UTC()
+    state.probeAt = &now
+    state.probeErr = ""
+    if err != nil {
+        state.probeErr = err.Error()
+        return
+    }
+    state.probeOKAt = &now
+}
+
+// Report returns the scoreboard of every backend, in the order tracked
+func (b *HealthBoard) Report() *HealthReport {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    now := b.now().UTC()
+    report := &HealthReport{
+        GeneratedAt: now,
+        Window:      b.window.String(),
+        Backends:    make([]BackendHealth, 0, len(b.backends)),
+    }
+    for _, state := range b.backends {
+        report.Backends = append(report.Backends, state.health(now.Add(-b.window)))
+    }
+    return report
+}
+
+// health summarizes the backend's requests since the given time
+func (s *backendState) health(since time.Time) BackendHealth {
+    health := BackendHealth{
+        Name:               s.backend.Name,
+        Kind:               s.backend.Kind,
+        Region:             s.backend.Region,
+        LastSuccessAt:      s.lastOK,
+        LastErrorAt:        s.lastErrAt,
+        LastError:          s.lastErr,
+        LastProbeAt:        s.probeAt,
+        LastProbeSuccessAt: s.probeOKAt,
+        LastProbeError:     s.probeErr,
+    }
+
+    var latencies []time.Duration
+    for _, sample := range s.samples {
+        if sample.at.Before(since) {
+            continue
+        }
+        health.Requests++
+        if sample.failed {
+            health.Errors++
+        }
+        latencies = append(latencies, sample.latency)
+    }
+    if health.Requests > 0 {
+        health.ErrorRate = float64(health.Errors) / float64(health.Requests)
+        sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+        health.LatencyP50Ms = milliseconds(percentile(latencies, 0.50))
+        health.LatencyP95Ms = milliseconds(percentile(latencies, 0.95))
+        health.LatencyMaxMs = milliseconds(latencies[len(latencies)-1])
+    }
+
+    health.Status = s.status(health)
+    return health
+}
+
+// status judges the backend from its last probe and its recent error rate
+func (s *backendState) status(health BackendHealth) string {
+    judged := health.Requests >= minHealthSamples
+    switch {
+    case s.probeErr != "" || (judged && health.ErrorRate >= downErrorRate):
+        return HealthDown
+    case judged && health.ErrorRate >= degradedErrorRate:
+        return HealthDegraded
+    case s.probeAt == nil && health.Requests == 0:
+        return HealthUnknown
+    default:
+        return HealthHealthy
+    }
+}
+
+// addToStack records every attempt sent to the named backend, after the
+// retry middleware so failed attempts that were retried still count
+func (b *HealthBoard) addToStack(name string) func(*middleware.Stack) error {
+    return func(stack *middleware.Stack) error {
+        return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("HealthBoard",
+            func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+                middleware.FinalizeOutput, middleware.Metadata, error) {
+
+                start := time.Now()
+                out, metadata, err := next.HandleFinalize(ctx, in)
+                b.Record(name, time.Since(start), err)
+                return out, metadata, err
+            }), middleware.After)
+    }
+}
+
+// isBackendFailure reports whether err means the backend failed to serve a
+// request, rather than the request being wrong
+func isBackendFailure(err error) bool {
+    if err == nil {
+        return false
+    }
+    var responseErr *smithyhttp.ResponseError
+    if errors.As(err, &responseErr) {
+        status := responseErr.HTTPStatusCode()
+        return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+    }
+    return true
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+    rank := int(math.Ceil(p * float64(len(sorted))))
+    if rank < 1 {
+        rank = 1
+    }
+    return sorted[rank-1]
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+    return float64(d) / float64(time.Millisecond)
+}
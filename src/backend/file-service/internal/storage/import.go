@@ -0,0 +1,191 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "sort"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// sniffLength is the content read to detect an object's type, as much as
+// http.DetectContentType considers
+const sniffLength = 512
+
+// ObjectInfo describes an object to be imported
+type ObjectInfo struct {
+    Size int64
+    // ContentType is the type the object was stored with, if any
+    ContentType string
+    // Header is the start of the content, for detecting its type
+    Header []byte
+}
+
+// ImportStorage adopts objects written to the bucket outside the service,
+// so files can be created for them
+type ImportStorage interface {
+    // ListPrefix returns up to limit objects under prefix with keys after
+    // startAfter, in lexicographic byte order
+    ListPrefix(ctx context.Context, prefix, startAfter string, limit int) ([]StoredObject, error)
+    // InspectObject returns the size and content type recorded for the
+    // object under key, and the start of its content
+    InspectObject(ctx context.Context, key string) (*ObjectInfo, error)
+    // AdoptObject copies the object under key to the file's content key
+    // and points the file at it, recording the SHA-256 S3 computed for the
+    // copy. The original is left for the caller to delete, if at all.
+    AdoptObject(ctx context.Context, file *models.File, key string) error
+    DeleteObject(ctx context.Context, key string) error
+}
+
+// ListPrefix returns up to limit objects, at most 1000, under prefix with
+// keys after startAfter. With shard buckets, each bucket is listed for the
+// objects that resolve to it and the listings are merged.
+func (s *S3Storage) ListPrefix(ctx context.Context, prefix, startAfter string, limit int) ([]StoredObject, error) {
+    var objects []StoredObject
+    for _, bucket := range s.buckets.all() {
+        listed, err := s.listBucket(ctx, bucket, prefix, startAfter, limit)
+        if err != nil {
+            return nil, err
+        }
+        objects = append(objects, listed...)
+    }
+
+    sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+    if len(objects) > limit {
+        objects = objects[:limit]
+    }
+    return objects, nil
+}
+
+// listBucket returns up to limit objects of bucket under prefix with keys
+// after startAfter, skipping objects that resolve to another bucket
+func (s *S3Storage) listBucket(ctx context.Context, bucket, prefix, startAfter string, limit int) ([]StoredObject, error) {
+    input := &s3.ListObjectsV2Input{
+        Bucket:  aws.String(bucket),
+        MaxKeys: int32(limit),
+    }
+    if prefix != "" {
+        input.Prefix = aws.String(prefix)
+    }
+    if startAfter != "" {
+        input.StartAfter = aws.String(startAfter)
+    }
+
+    var objects []StoredObject
+    for {
+        result, err := s.s3Client.ListObjectsV2(ctx, input)
+        if err != nil {
+            return nil, fmt.Errorf("s3 list objects failed: %w", err)
+        }
+        for _, object := range result.Contents {
+            key := aws.ToString(object.Key)
+            if s.buckets.forKey(key) != bucket {
+                continue
+            }
+            objects = append(objects, StoredObject{
+                Key:          key,
+                Size:         object.Size,
+                LastModified: aws.ToTime(object.LastModified),
+            })
+            if len(objects) == limit {
+                return objects, nil
+            }
+        }
+        if !result.IsTruncated {
+            return objects, nil
+        }
+        input.ContinuationToken = result.NextContinuationToken
+    }
+}
+
+// InspectObject reads the metadata of the object under key and the first
+// bytes of its content, failing with ErrObjectNotFound if there is none
+func (s *S3Storage) InspectObject(ctx context.Context, key string) (*ObjectInfo, error) {
+    head, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+        Bucket: aws.String(s.buckets.forKey(key)),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        var notFound *types.NotFound
+        if errors.As(err, &notFound) {
+            return nil, ErrObjectNotFound
+        }
+        return nil, fmt.Errorf("s3 head object failed: %w", err)
+    }
+    info := &ObjectInfo{Size: head.ContentLength, ContentType: aws.ToString(head.ContentType)}
+    if info.Size == 0 {
+        return info, nil
+    }
+
+    result, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(s.buckets.forKey(key)),
+        Key:    aws.String(key),
+        Range:  aws.String(fmt.Sprintf("bytes=0-%d", sniffLength-1)),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("s3 get object failed: %w", err)
+    }
+    defer result.Body.Close()
+    info.Header, err = io.ReadAll(io.LimitReader(result.Body, sniffLength))
+    if err != nil {
+        return nil, fmt.Errorf("s3 read failed: %w", err)
+    }
+    return info, nil
+}
+
+// AdoptObject copies the object under key to the file's content key, with
+// the service's encryption and Object Lock protection. S3 copies objects of
+// up to 5GB in one request; larger objects fail.
+func (s *S3Storage) AdoptObject(ctx context.Context, file *models.File, key string) error {
+    storagePath := StorageKey(file.ID)
+    input := &s3.CopyObjectInput{
+        Bucket:            aws.String(s.buckets.forKey(storagePath)),
+        CopySource:        aws.String(s.buckets.copySource(key)),
+        Key:               aws.String(storagePath),
+        ContentType:       aws.String(file.ContentType),
+        MetadataDirective: types.MetadataDirectiveReplace,
+        Metadata: map[string]string{
+            "file-id":  file.ID,
+            "filename": file.FileName,
+        },
+        ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+    }
+    s.sse.applyCopy(input)
+    s.lock.applyCopy(input, file)
+
+    result, err := s.s3Client.CopyObject(ctx, input)
+    if err != nil {
+        return fmt.Errorf("s3 copy failed: %w", err)
+    }
+    var checksum string
+    if result.CopyObjectResult != nil {
+        checksum, err = decodeChecksum(aws.ToString(result.CopyObjectResult.ChecksumSHA256))
+        if err != nil {
+            return err
+        }
+    }
+    if checksum == "" {
+        return errors.New("s3 copy returned no checksum")
+    }
+
+    if err := file.SetStoragePath(storagePath); err != nil {
+        return err
+    }
+    if err := file.UpdateChecksum(checksum); err != nil {
+        return err
+    }
+    file.ServerSideEncryption = s.sse.applied(result.ServerSideEncryption, result.SSEKMSKeyId)
+
+    s.logger.Info("Adopted object",
+        logger.String("fileId", file.ID),
+        logger.String("key", key),
+        logger.String("storagePath", storagePath))
+    return nil
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+    "context"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/models"
+)
+
+// IntegrityStorage reads back file content, or the checksum S3 recorded for
+// it, to verify it against the checksum taken on upload
+type IntegrityStorage interface {
+    Download(ctx context.Context, file *models.File) (io.ReadCloser, error)
+    // StoredChecksum returns the hex SHA-256 S3 recorded for the file's
+    // object, suffixed with -N for an object uploaded in N parts, or "" if
+    // the object was stored without one
+    StoredChecksum(ctx context.Context, file *models.File) (string, error)
+}
+
+// StoredChecksum reads the SHA-256 checksum of the file's object with a
+// HEAD request, without reading the content
+func (s *S3Storage) StoredChecksum(ctx context.Context, file *models.File) (string, error) {
+    if err := s.tenants.check(ctx, file.StoragePath); err != nil {
+        return "", err
+    }
+
+    result, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+        Bucket:       aws.String(s.buckets.forKey(file.StoragePath)),
+        Key:          aws.String(file.StoragePath),
+        ChecksumMode: types.ChecksumModeEnabled,
+    })
+    if err != nil {
+        var notFound *types.NotFound
+        if errors.As(err, &notFound) {
+            return "", ErrObjectNotFound
+        }
+        return "", fmt.Errorf("s3 head object failed: %w", err)
+    }
+    return decodeChecksum(aws.ToString(result.ChecksumSHA256))
+}
+
+// decodeChecksum converts a base64 checksum as S3 reports it to hex. The
+// checksum of a multipart object is a checksum of its parts' checksums,
+// which S3 suffixes with the part count.
+func decodeChecksum(encoded string) (string, error) {
+    if encoded == "" {
+        return "", nil
+    }
+    encoded, parts, composite := strings.Cut(encoded, "-")
+    digest, err := base64.StdEncoding.DecodeString(encoded)
+    if err != nil {
+        return "", fmt.Errorf("invalid object checksum: %w", err)
+    }
+    if composite {
+        return hex.EncodeToString(digest) + "-" + parts, nil
+    }
+    return hex.EncodeToString(digest), nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// StoredObject is an object found listing the bucket
+type StoredObject struct {
+    Key          string
+    Size         int64
+    LastModified time.Time
+}
+
+// InventoryStorage lists the bucket for reconciliation against the files
+// table, and quarantines or deletes the objects no file references
+type InventoryStorage interface {
+    // ListObjects returns up to limit objects with keys after startAfter,
+    // in lexicographic byte order
+    ListObjects(ctx context.Context, startAfter string, limit int) ([]StoredObject, error)
+    StatUpload(ctx context.Context, file *models.File) (*UploadedObject, error)
+    // QuarantineObject moves the object under key below the quarantine
+    // prefix and returns the key it moved to
+    QuarantineObject(ctx context.Context, key string) (string, error)
+    DeleteObject(ctx context.Context, key string) error
+}
+
+// ListObjects returns up to limit objects, at most 1000, with keys after
+// startAfter
+func (s *S3Storage) ListObjects(ctx context.Context, startAfter string, limit int) ([]StoredObject, error) {
+    return s.ListPrefix(ctx, "", startAfter, limit)
+}
+
+// QuarantineObject copies the object under key below the quarantine prefix,
+// keeping its metadata and tags, then deletes the original
+func (s *S3Storage) QuarantineObject(ctx context.Context, key string) (string, error) {
+    quarantined := storagekey.Default.Under(storagekey.Quarantine, key)
+    input := &s3.CopyObjectInput{
+        Bucket:     aws.String(s.buckets.forKey(quarantined)),
+        CopySource: aws.String(s.buckets.copySource(key)),
+        Key:        aws.String(quarantined),
+    }
+    s.sse.applyCopy(input)
+    if _, err := s.s3Client.CopyObject(ctx, input); err != nil {
+        return "", fmt.Errorf("s3 copy failed: %w", err)
+    }
+    if err := s.DeleteObject(ctx, key); err != nil {
+        return "", err
+    }
+
+    s.logger.Info("Quarantined object",
+        logger.String("key", key),
+        logger.String("quarantinedKey", quarantined))
+    return quarantined, nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// KeyMigrationStorage moves file content to a new object key, used to move
+// content stored under legacy keys to the current key layout
+type KeyMigrationStorage interface {
+    // MoveContent copies the file's object to key and points the file at
+    // it. The old object is left for the caller to delete once the new
+    // storage path is recorded.
+    MoveContent(ctx context.Context, file *models.File, key string) error
+}
+
+// MoveContent copies the file's object to key, keeping its metadata and
+// tags. The copy is encrypted and protected as configured, which is
+// recorded on the file. Under tenant isolation the content stays within its
+// tenant's scope.
+func (s *S3Storage) MoveContent(ctx context.Context, file *models.File, key string) error {
+    if err := s.tenants.checkMove(ctx, file.StoragePath, key); err != nil {
+        return err
+    }
+
+    input := &s3.CopyObjectInput{
+        Bucket:     aws.String(s.buckets.forKey(key)),
+        CopySource: aws.String(s.buckets.copySource(file.StoragePath)),
+        Key:        aws.String(key),
+    }
+    s.sse.applyCopy(input)
+    s.lock.applyCopy(input, file)
+
+    result, err := s.s3Client.CopyObject(ctx, input)
+    if err != nil {
+        return fmt.Errorf("s3 copy failed: %w", err)
+    }
+
+    from := file.StoragePath
+    if err := file.SetStoragePath(key); err != nil {
+        return err
+    }
+    file.ServerSideEncryption = s.sse.applied(result.ServerSideEncryption, result.SSEKMSKeyId)
+
+    s.logger.Info("Moved file content",
+        logger.String("fileId", file.ID),
+        logger.String("from", from),
+        logger.String("to", key))
+    return nil
+}
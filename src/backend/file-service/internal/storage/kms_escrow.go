@@ -0,0 +1,55 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/kms"
+
+    "src/backend/file-service/internal/config"
+)
+
+// KMSKeyEscrow escrows client-wrapped data keys by encrypting them under a
+// dedicated KMS key. The service only needs kms:Encrypt on the escrow key;
+// kms:Decrypt should be granted solely to the recovery role so the service
+// itself can never unwrap escrowed keys.
+type KMSKeyEscrow struct {
+    kmsClient *kms.Client
+    keyID     string
+}
+
+// NewKMSKeyEscrow creates a KMSKeyEscrow for the configured escrow key
+func NewKMSKeyEscrow(cfg *config.Config) (*KMSKeyEscrow, error) {
+    if cfg.Encryption.EscrowKMSKeyID == "" {
+        return nil, errors.New("escrow KMS key ID is required")
+    }
+
+    awsCfg, err := loadAWSConfig(cfg)
+    if err != nil {
+        return nil, err
+    }
+
+    return &KMSKeyEscrow{
+        kmsClient: kms.NewFromConfig(awsCfg),
+        keyID:     cfg.Encryption.EscrowKMSKeyID,
+    }, nil
+}
+
+// Escrow encrypts the wrapped key under the escrow key, binding the ciphertext
+// to the file ID through the KMS encryption context
+func (e *KMSKeyEscrow) Escrow(ctx context.Context, fileID string, wrappedKey []byte) (string, []byte, error) {
+    result, err := e.kmsClient.Encrypt(ctx, &kms.EncryptInput{
+        KeyId:     aws.String(e.keyID),
+        Plaintext: wrappedKey,
+        EncryptionContext: map[string]string{
+            "file-id": fileID,
+        },
+    })
+    if err != nil {
+        return "", nil, fmt.Errorf("kms escrow failed: %w", err)
+    }
+
+    return aws.ToString(result.KeyId), result.CiphertextBlob, nil
+}
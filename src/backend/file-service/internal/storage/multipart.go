@@ -0,0 +1,250 @@
+package storage
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// MultipartStorage defines the operations required to assemble an object from
+// independently uploaded parts
+type MultipartStorage interface {
+    InitiateMultipart(ctx context.Context, session *models.UploadSession) error
+    UploadPart(ctx context.Context, session *models.UploadSession, number int, size int64, reader io.Reader) (*models.UploadPart, error)
+    CompleteMultipart(ctx context.Context, session *models.UploadSession, file *models.File) error
+    AbortMultipart(ctx context.Context, session *models.UploadSession) error
+    ListMultipartUploads(ctx context.Context, initiatedBefore time.Time, limit int) ([]MultipartUpload, error)
+    AbortMultipartUpload(ctx context.Context, upload MultipartUpload) error
+}
+
+// MultipartUpload identifies an in-progress multipart upload in the bucket
+type MultipartUpload struct {
+    Key       string
+    UploadID  string
+    Initiated time.Time
+}
+
+// InitiateMultipart starts an S3 multipart upload for the session and records
+// the storage key, scoped to the tenant under tenant isolation, and upload
+// ID on it
+func (s *S3Storage) InitiateMultipart(ctx context.Context, session *models.UploadSession) error {
+    storagePath, err := s.tenants.scope(ctx, StorageKey(session.FileID))
+    if err != nil {
+        return err
+    }
+
+    input := &s3.CreateMultipartUploadInput{
+        Bucket:      aws.String(s.buckets.forKey(storagePath)),
+        Key:         aws.String(storagePath),
+        ContentType: aws.String(session.ContentType),
+        Metadata: map[string]string{
+            "file-id":  session.FileID,
+            "filename": session.FileName,
+        },
+    }
+    s.sse.applyMultipart(input)
+    s.lock.applyMultipart(input)
+
+    result, err := s.s3Client.CreateMultipartUpload(ctx, input)
+    if err != nil {
+        s.logger.Error("Failed to initiate multipart upload",
+            logger.String("sessionId", session.ID),
+            logger.Error(err))
+        return fmt.Errorf("s3 multipart initiation failed: %w", err)
+    }
+
+    session.StorageKey = storagePath
+    session.MultipartUploadID = aws.ToString(result.UploadId)
+    return nil
+}
+
+// UploadPart uploads a single chunk as a multipart part, computing its SHA-256
+// checksum while streaming
+func (s *S3Storage) UploadPart(ctx context.Context, session *models.UploadSession, number int, size int64, reader io.Reader) (*models.UploadPart, error) {
+    hash := sha256.New()
+    counter := &countingReader{reader: io.TeeReader(reader, hash)}
+
+    input := &s3.UploadPartInput{
+        Bucket:        aws.String(s.buckets.forKey(session.StorageKey)),
+        Key:           aws.String(session.StorageKey),
+        UploadId:      aws.String(session.MultipartUploadID),
+        PartNumber:    int32(number),
+        ContentLength: size,
+        Body:          counter,
+    }
+    if s.lock.enabled() {
+        input.ChecksumAlgorithm = types.ChecksumAlgorithmSha256
+    }
+
+    result, err := s.s3Client.UploadPart(ctx, input)
+    if err != nil {
+        s.logger.Error("Failed to upload part",
+            logger.String("sessionId", session.ID),
+            logger.Int("partNumber", number),
+            logger.Error(err))
+        return nil, fmt.Errorf("s3 part upload failed: %w", err)
+    }
+
+    return &models.UploadPart{
+        Number:   number,
+        Size:     counter.n,
+        Checksum: hex.EncodeToString(hash.Sum(nil)),
+        ETag:     aws.ToString(result.ETag),
+    }, nil
+}
+
+// CompleteMultipart assembles the uploaded parts into the final object and
+// records its encryption and Object Lock protection on file
+func (s *S3Storage) CompleteMultipart(ctx context.Context, session *models.UploadSession, file *models.File) error {
+    completed := make([]types.CompletedPart, 0, len(session.Parts))
+    for _, part := range session.Parts {
+        completedPart := types.CompletedPart{
+            ETag:       aws.String(part.ETag),
+            PartNumber: int32(part.Number),
+        }
+        if s.lock.enabled() {
+            digest, err := hex.DecodeString(part.Checksum)
+            if err != nil {
+                return fmt.Errorf("invalid checksum of part %d: %w", part.Number, err)
+            }
+            completedPart.ChecksumSHA256 = aws.String(base64.StdEncoding.EncodeToString(digest))
+        }
+        completed = append(completed, completedPart)
+    }
+
+    result, err := s.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+        Bucket:          aws.String(s.buckets.forKey(session.StorageKey)),
+        Key:             aws.String(session.StorageKey),
+        UploadId:        aws.String(session.MultipartUploadID),
+        MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+    })
+    if err != nil {
+        s.logger.Error("Failed to complete multipart upload",
+            logger.String("sessionId", session.ID),
+            logger.Error(err))
+        return fmt.Errorf("s3 multipart completion failed: %w", err)
+    }
+    file.ServerSideEncryption = s.sse.applied(result.ServerSideEncryption, result.SSEKMSKeyId)
+
+    // The retention was set when the upload started, so it is read back
+    if s.lock.enabled() {
+        head, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+            Bucket: aws.String(s.buckets.forKey(session.StorageKey)),
+            Key:    aws.String(session.StorageKey),
+        })
+        if err != nil {
+            return fmt.Errorf("s3 head object failed: %w", err)
+        }
+        recordObjectLock(file, head)
+    }
+    return nil
+}
+
+// AbortMultipart discards all parts uploaded for the session
+func (s *S3Storage) AbortMultipart(ctx context.Context, session *models.UploadSession) error {
+    if session.MultipartUploadID == "" {
+        return nil
+    }
+
+    err := s.AbortMultipartUpload(ctx, MultipartUpload{Key: session.StorageKey, UploadID: session.MultipartUploadID})
+    if err != nil {
+        s.logger.Error("Failed to abort multipart upload",
+            logger.String("sessionId", session.ID),
+            logger.Error(err))
+        return err
+    }
+
+    return nil
+}
+
+// ListMultipartUploads returns up to limit multipart uploads in the buckets
+// that were initiated before the given time
+func (s *S3Storage) ListMultipartUploads(ctx context.Context, initiatedBefore time.Time, limit int) ([]MultipartUpload, error) {
+    var uploads []MultipartUpload
+    for _, bucket := range s.buckets.all() {
+        listed, err := s.listMultipartUploads(ctx, bucket, initiatedBefore, limit-len(uploads))
+        if err != nil {
+            return nil, err
+        }
+        uploads = append(uploads, listed...)
+        if len(uploads) >= limit {
+            break
+        }
+    }
+    return uploads, nil
+}
+
+// listMultipartUploads returns up to limit multipart uploads in bucket that
+// were initiated before the given time, skipping uploads to keys that
+// resolve to another bucket
+func (s *S3Storage) listMultipartUploads(ctx context.Context, bucket string, initiatedBefore time.Time, limit int) ([]MultipartUpload, error) {
+    var uploads []MultipartUpload
+    input := &s3.ListMultipartUploadsInput{Bucket: aws.String(bucket)}
+    for {
+        result, err := s.s3Client.ListMultipartUploads(ctx, input)
+        if err != nil {
+            return nil, fmt.Errorf("s3 multipart listing failed: %w", err)
+        }
+
+        for _, upload := range result.Uploads {
+            initiated := aws.ToTime(upload.Initiated)
+            key := aws.ToString(upload.Key)
+            if !initiated.Before(initiatedBefore) || s.buckets.forKey(key) != bucket {
+                continue
+            }
+            uploads = append(uploads, MultipartUpload{
+                Key:       key,
+                UploadID:  aws.ToString(upload.UploadId),
+                Initiated: initiated,
+            })
+            if len(uploads) >= limit {
+                return uploads, nil
+            }
+        }
+
+        if !result.IsTruncated {
+            return uploads, nil
+        }
+        input.KeyMarker = result.NextKeyMarker
+        input.UploadIdMarker = result.NextUploadIdMarker
+    }
+}
+
+// AbortMultipartUpload discards a multipart upload and its parts. Uploads
+// that no longer exist are already discarded.
+func (s *S3Storage) AbortMultipartUpload(ctx context.Context, upload MultipartUpload) error {
+    _, err := s.s3Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+        Bucket:   aws.String(s.buckets.forKey(upload.Key)),
+        Key:      aws.String(upload.Key),
+        UploadId: aws.String(upload.UploadID),
+    })
+    var noSuchUpload *types.NoSuchUpload
+    if err != nil && !errors.As(err, &noSuchUpload) {
+        return fmt.Errorf("s3 multipart abort failed: %w", err)
+    }
+    return nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+    reader io.Reader
+    n      int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+    n, err := c.reader.Read(p)
+    c.n += int64(n)
+    return n, err
+}
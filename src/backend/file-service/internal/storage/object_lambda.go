@@ -0,0 +1,100 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "strings"
+
+    "github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// ObjectLambdaNone opts a tenant out of the default access point, so its
+// downloads are read from the bucket untransformed
+const ObjectLambdaNone = "none"
+
+// objectLambdaService is the ARN service of S3 Object Lambda access points
+const objectLambdaService = "s3-object-lambda"
+
+// objectLambdaKey is the context key of the access point a read goes through
+type objectLambdaKey struct{}
+
+// ObjectLambdaRoutes chooses the S3 Object Lambda access point each tenant's
+// downloads are read through, so transformations such as redaction or format
+// conversion run in AWS and the service only sends requests to the access
+// point. Tenants without a route of their own use the default, if any.
+type ObjectLambdaRoutes struct {
+    defaultAccessPoint string
+    tenants            map[string]string
+}
+
+// NewObjectLambdaRoutes validates the access point ARNs, returning nil when
+// no tenant reads through an access point
+func NewObjectLambdaRoutes(defaultAccessPoint string, tenants map[string]string) (*ObjectLambdaRoutes, error) {
+    if err := validateAccessPoint(defaultAccessPoint); err != nil {
+        return nil, err
+    }
+    routed := defaultAccessPoint != ""
+    for tenant, accessPoint := range tenants {
+        if accessPoint == ObjectLambdaNone {
+            continue
+        }
+        if err := validateAccessPoint(accessPoint); err != nil {
+            return nil, fmt.Errorf("tenant %q: %w", tenant, err)
+        }
+        routed = routed || accessPoint != ""
+    }
+    if !routed {
+        return nil, nil
+    }
+
+    return &ObjectLambdaRoutes{
+        defaultAccessPoint: defaultAccessPoint,
+        tenants:            tenants,
+    }, nil
+}
+
+// AccessPointFor returns the access point the tenant's downloads are read
+// through, or "" to read the bucket
+func (r *ObjectLambdaRoutes) AccessPointFor(tenantID string) string {
+    if r == nil {
+        return ""
+    }
+    accessPoint, ok := r.tenants[tenantID]
+    if !ok {
+        return r.defaultAccessPoint
+    }
+    if accessPoint == ObjectLambdaNone {
+        return ""
+    }
+    return accessPoint
+}
+
+// WithObjectLambda returns a context whose downloads are read through the
+// access point instead of the bucket; an empty access point reads the bucket.
+// Reads for the service's own use, such as generating thumbnails, never
+// carry one and always see the stored content.
+func WithObjectLambda(ctx context.Context, accessPoint string) context.Context {
+    if accessPoint == "" {
+        return ctx
+    }
+    return context.WithValue(ctx, objectLambdaKey{}, accessPoint)
+}
+
+// ObjectLambdaFrom returns the access point of the context, or ""
+func ObjectLambdaFrom(ctx context.Context) string {
+    accessPoint, _ := ctx.Value(objectLambdaKey{}).(string)
+    return accessPoint
+}
+
+// validateAccessPoint checks that accessPoint, if set, is the ARN of an
+// S3 Object Lambda access point
+func validateAccessPoint(accessPoint string) error {
+    if accessPoint == "" {
+        return nil
+    }
+    parsed, err := arn.Parse(accessPoint)
+    if err != nil || parsed.Service != objectLambdaService || !strings.HasPrefix(parsed.Resource, "accesspoint/") {
+        return fmt.Errorf("invalid object lambda access point %q", accessPoint)
+    }
+    return nil
+}
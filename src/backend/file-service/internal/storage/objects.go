@@ -0,0 +1,119 @@
+package storage
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "io"
+    "sort"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ObjectStore provides raw access to objects by key, used for objects derived
+// from uploaded files rather than the files themselves
+type ObjectStore interface {
+    PutObject(ctx context.Context, key string, contentType string, data []byte) error
+    GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+    DeleteObject(ctx context.Context, key string) error
+}
+
+// LockedObjectStore writes objects that cannot be overwritten or deleted
+// until their retention expires, for records that must stay tamper-evident
+type LockedObjectStore interface {
+    PutLockedObject(ctx context.Context, key string, contentType string, data []byte, mode string, retainUntil time.Time) error
+    GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+    ListObjectKeys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// PutObject stores data under the given key with server-side encryption
+func (s *S3Storage) PutObject(ctx context.Context, key string, contentType string, data []byte) error {
+    input := &s3.PutObjectInput{
+        Bucket:      aws.String(s.buckets.forKey(key)),
+        Key:         aws.String(key),
+        Body:        bytes.NewReader(data),
+        ContentType: aws.String(contentType),
+    }
+    s.sse.applyPut(input)
+
+    _, err := s.s3Client.PutObject(ctx, input)
+    if err != nil {
+        return fmt.Errorf("s3 put object failed: %w", err)
+    }
+    return nil
+}
+
+// GetObject opens the object stored under the given key
+func (s *S3Storage) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+    result, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(s.buckets.forKey(key)),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("s3 get object failed: %w", err)
+    }
+    return result.Body, nil
+}
+
+// DeleteObject removes the object stored under the given key
+func (s *S3Storage) DeleteObject(ctx context.Context, key string) error {
+    _, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+        Bucket: aws.String(s.buckets.forKey(key)),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        return fmt.Errorf("s3 delete object failed: %w", err)
+    }
+    return nil
+}
+
+// PutLockedObject stores data under the given key with an S3 Object Lock
+// retention of mode ("COMPLIANCE" or "GOVERNANCE") until retainUntil. The
+// bucket must have Object Lock enabled, which also keeps every version of a
+// key, so a later put under the same key cannot replace the retained one.
+func (s *S3Storage) PutLockedObject(ctx context.Context, key string, contentType string, data []byte, mode string, retainUntil time.Time) error {
+    input := &s3.PutObjectInput{
+        Bucket:                    aws.String(s.buckets.forKey(key)),
+        Key:                       aws.String(key),
+        Body:                      bytes.NewReader(data),
+        ContentType:               aws.String(contentType),
+        ObjectLockMode:            types.ObjectLockMode(mode),
+        ObjectLockRetainUntilDate: aws.Time(retainUntil),
+        ChecksumAlgorithm:         types.ChecksumAlgorithmSha256,
+    }
+    s.sse.applyPut(input)
+
+    _, err := s.s3Client.PutObject(ctx, input)
+    if err != nil {
+        return fmt.Errorf("s3 put locked object failed: %w", err)
+    }
+    return nil
+}
+
+// ListObjectKeys returns the keys of every object under prefix in
+// lexicographic order, in every bucket the objects may be stored in
+func (s *S3Storage) ListObjectKeys(ctx context.Context, prefix string) ([]string, error) {
+    var keys []string
+    for _, bucket := range s.buckets.all() {
+        paginator := s3.NewListObjectsV2Paginator(s.s3Client, &s3.ListObjectsV2Input{
+            Bucket: aws.String(bucket),
+            Prefix: aws.String(prefix),
+        })
+        for paginator.HasMorePages() {
+            page, err := paginator.NextPage(ctx)
+            if err != nil {
+                return nil, fmt.Errorf("s3 list objects failed: %w", err)
+            }
+            for _, object := range page.Contents {
+                if key := aws.ToString(object.Key); s.buckets.forKey(key) == bucket {
+                    keys = append(keys, key)
+                }
+            }
+        }
+    }
+    sort.Strings(keys)
+    return keys, nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Formats of the dates in a signed POST policy
+const (
+    policyDateFormat    = "20060102"
+    policyTimeFormat    = "20060102T150405Z"
+    policyExpiresFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// postPolicy is an S3 POST policy: the form fields of a browser-based
+// upload, which S3 requires to match exactly, and the size the content
+// must have, accepted until the policy expires
+type postPolicy struct {
+    bucket  string
+    fields  map[string]string
+    minSize int64
+    maxSize int64
+    expires time.Time
+}
+
+// sign adds the SigV4 authentication fields to the policy's fields, signed
+// with creds for region at now, and returns the form fields to post
+func (p postPolicy) sign(creds aws.Credentials, region string, now time.Time) (map[string]string, error) {
+    now = now.UTC()
+    scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format(policyDateFormat), region)
+
+    fields := make(map[string]string, len(p.fields)+6)
+    for name, value := range p.fields {
+        fields[name] = value
+    }
+    fields["x-amz-algorithm"] = "AWS4-HMAC-SHA256"
+    fields["x-amz-credential"] = creds.AccessKeyID + "/" + scope
+    fields["x-amz-date"] = now.Format(policyTimeFormat)
+    if creds.SessionToken != "" {
+        fields["x-amz-security-token"] = creds.SessionToken
+    }
+
+    // Every field posted but the policy and signature must be matched by
+    // a condition
+    conditions := []interface{}{map[string]string{"bucket": p.bucket}}
+    for name, value := range fields {
+        conditions = append(conditions, map[string]string{name: value})
+    }
+    conditions = append(conditions, []interface{}{"content-length-range", p.minSize, p.maxSize})
+
+    document, err := json.Marshal(map[string]interface{}{
+        "expiration": p.expires.UTC().Format(policyExpiresFormat),
+        "conditions": conditions,
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode POST policy: %w", err)
+    }
+    policy := base64.StdEncoding.EncodeToString(document)
+
+    key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), now.Format(policyDateFormat))
+    key = hmacSHA256(key, region)
+    key = hmacSHA256(key, "s3")
+    key = hmacSHA256(key, "aws4_request")
+
+    fields["policy"] = policy
+    fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(key, policy))
+    return fields, nil
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+    mac := hmac.New(sha256.New, key)
+    mac.Write([]byte(data))
+    return mac.Sum(nil)
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+    "context"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/models"
+)
+
+// ErrObjectNotFound is returned when an object expected in the bucket is missing
+var ErrObjectNotFound = errors.New("object not found")
+
+// DirectUploadStorage issues presigned requests uploading file content
+// straight to the bucket, and inspects what was uploaded through them
+type DirectUploadStorage interface {
+    PresignUpload(ctx context.Context, file *models.File, ttl time.Duration) (*PresignedUpload, error)
+    StatUpload(ctx context.Context, file *models.File) (*UploadedObject, error)
+}
+
+// DirectDownloadStorage issues presigned requests downloading file content
+// straight from the bucket
+type DirectDownloadStorage interface {
+    PresignDownload(ctx context.Context, file *models.File, ttl time.Duration, contentType, disposition string) (*PresignedDownload, error)
+}
+
+// PresignedUpload is a presigned request uploading one object: a
+// multipart/form-data POST of Fields, exactly as given since they are
+// signed, followed by the content in a field named "file"
+type PresignedUpload struct {
+    URL       string            `json:"url"`
+    Method    string            `json:"method"`
+    Fields    map[string]string `json:"fields"`
+    ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// PresignedDownload is a presigned GET of one object
+type PresignedDownload struct {
+    URL       string    `json:"url"`
+    ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// UploadedObject describes an object uploaded through a presigned request
+type UploadedObject struct {
+    Size        int64
+    ContentType string
+    // Checksum is the hex SHA-256 of the content, verified by S3 on upload
+    Checksum string
+}
+
+// PresignUpload presigns a POST of the file's content to its storage path,
+// valid for ttl. The policy signed into the request requires the file's
+// exact content type, size and SHA-256 checksum, so S3 rejects content that
+// does not match them, and the encryption and Object Lock protection the
+// service applies to uploads. Under tenant isolation the file's storage
+// path is scoped to the tenant first.
+func (s *S3Storage) PresignUpload(ctx context.Context, file *models.File, ttl time.Duration) (*PresignedUpload, error) {
+    digest, err := hex.DecodeString(file.Checksum)
+    if err != nil {
+        return nil, fmt.Errorf("invalid checksum: %w", err)
+    }
+    storagePath, err := s.tenants.scope(ctx, file.StoragePath)
+    if err != nil {
+        return nil, err
+    }
+    if err := file.SetStoragePath(storagePath); err != nil {
+        return nil, err
+    }
+
+    bucket := s.buckets.forKey(file.StoragePath)
+    input := &s3.PutObjectInput{
+        Bucket: aws.String(bucket),
+        Key:    aws.String(file.StoragePath),
+    }
+    s.sse.applyPut(input)
+    s.lock.applyPut(input, file)
+
+    fields := map[string]string{
+        "key":                      file.StoragePath,
+        "Content-Type":             file.ContentType,
+        "x-amz-meta-file-id":       file.ID,
+        "x-amz-meta-filename":      file.FileName,
+        "x-amz-checksum-algorithm": string(types.ChecksumAlgorithmSha256),
+        "x-amz-checksum-sha256":    base64.StdEncoding.EncodeToString(digest),
+    }
+    if input.ServerSideEncryption != "" {
+        fields["x-amz-server-side-encryption"] = string(input.ServerSideEncryption)
+    }
+    if input.SSEKMSKeyId != nil {
+        fields["x-amz-server-side-encryption-aws-kms-key-id"] = aws.ToString(input.SSEKMSKeyId)
+        fields["x-amz-server-side-encryption-bucket-key-enabled"] = strconv.FormatBool(input.BucketKeyEnabled)
+    }
+    if input.ObjectLockMode != "" {
+        fields["x-amz-object-lock-mode"] = string(input.ObjectLockMode)
+        fields["x-amz-object-lock-retain-until-date"] = aws.ToTime(input.ObjectLockRetainUntilDate).UTC().Format(time.RFC3339)
+    }
+    if input.ObjectLockLegalHoldStatus != "" {
+        fields["x-amz-object-lock-legal-hold"] = string(input.ObjectLockLegalHoldStatus)
+    }
+
+    creds, err := s.credentials.Retrieve(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
+    }
+    endpoint, err := s.bucketURL(ctx, bucket, file.StoragePath)
+    if err != nil {
+        return nil, err
+    }
+    now := time.Now().UTC()
+    policy := postPolicy{
+        bucket:  bucket,
+        fields:  fields,
+        minSize: file.Size,
+        maxSize: file.Size,
+        expires: now.Add(ttl),
+    }
+    signed, err := policy.sign(creds, s.region, now)
+    if err != nil {
+        return nil, err
+    }
+    // The encryption fields are signed, so S3 applies the configured encryption
+    file.ServerSideEncryption = s.sse.metadata()
+
+    return &PresignedUpload{
+        URL:       endpoint,
+        Method:    http.MethodPost,
+        Fields:    signed,
+        ExpiresAt: policy.expires,
+    }, nil
+}
+
+// bucketURL returns the URL objects are posted to in bucket: the URL of
+// the object under key without the key, as the client resolves it for the
+// configured endpoint and addressing style
+func (s *S3Storage) bucketURL(ctx context.Context, bucket, key string) (string, error) {
+    presigner := s3.NewPresignClient(s.s3Client)
+    request, err := presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+        Bucket: aws.String(bucket),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        return "", fmt.Errorf("s3 upload presign failed: %w", err)
+    }
+    u, err := url.Parse(request.URL)
+    if err != nil {
+        return "", fmt.Errorf("invalid presigned URL: %w", err)
+    }
+    u.Path = strings.TrimSuffix(u.Path, key)
+    u.RawPath, u.RawQuery = "", ""
+    return u.String(), nil
+}
+
+// StatUpload reports the size and checksum of the object uploaded to the
+// file's storage path, or ErrObjectNotFound if nothing was uploaded
+func (s *S3Storage) StatUpload(ctx context.Context, file *models.File) (*UploadedObject, error) {
+    return s.StatObject(ctx, file.StoragePath)
+}
+
+// StatObject reports the size and checksum of the object under key, or
+// ErrObjectNotFound if there is none
+func (s *S3Storage) StatObject(ctx context.Context, key string) (*UploadedObject, error) {
+    result, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+        Bucket:       aws.String(s.buckets.forKey(key)),
+        Key:          aws.String(key),
+        ChecksumMode: types.ChecksumModeEnabled,
+    })
+    if err != nil {
+        var notFound *types.NotFound
+        if errors.As(err, &notFound) {
+            return nil, ErrObjectNotFound
+        }
+        return nil, fmt.Errorf("s3 head object failed: %w", err)
+    }
+
+    checksum, err := decodeChecksum(aws.ToString(result.ChecksumSHA256))
+    if err != nil {
+        return nil, err
+    }
+    return &UploadedObject{
+        Size:        result.ContentLength,
+        ContentType: aws.ToString(result.ContentType),
+        Checksum:    checksum,
+    }, nil
+}
+
+// PresignDownload presigns a GET of the file's content, valid for ttl. S3
+// serves the content with the given Content-Type and Content-Disposition,
+// since the service's own download headers are not added. Content of
+// another tenant is refused as it is for downloads.
+func (s *S3Storage) PresignDownload(ctx context.Context, file *models.File, ttl time.Duration,
+    contentType, disposition string) (*PresignedDownload, error) {
+    if !file.IsUploaded() {
+        return nil, errors.New("file is not in uploaded state")
+    }
+    if err := s.tenants.check(ctx, file.StoragePath); err != nil {
+        return nil, err
+    }
+
+    presigner := s3.NewPresignClient(s.s3Client)
+    request, err := presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+        Bucket:                     aws.String(s.buckets.forKey(file.StoragePath)),
+        Key:                        aws.String(file.StoragePath),
+        ResponseContentType:        aws.String(contentType),
+        ResponseContentDisposition: aws.String(disposition),
+    }, s3.WithPresignExpires(ttl))
+    if err != nil {
+        return nil, fmt.Errorf("s3 download presign failed: %w", err)
+    }
+
+    return &PresignedDownload{
+        URL:       request.URL,
+        ExpiresAt: time.Now().UTC().Add(ttl),
+    }, nil
+}
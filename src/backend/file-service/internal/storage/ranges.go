@@ -0,0 +1,194 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "sync"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+
+    "src/backend/file-service/internal/models"
+)
+
+// rangeSkipLimit is the gap a read may leave after the previous one and
+// still be served by reading on through the open download; further reads,
+// and reads behind it, start a new download
+const rangeSkipLimit = 256 << 10
+
+// RangeStorage reads a file's content from an offset rather than from its
+// start, so parts of large files, such as an archive's directory, are read
+// without downloading the rest
+type RangeStorage interface {
+    // DownloadFrom streams the file's content from offset to its end
+    DownloadFrom(ctx context.Context, file *models.File, offset int64) (io.ReadCloser, error)
+}
+
+// DownloadFrom streams the file's content from offset to its end
+func (s *S3Storage) DownloadFrom(ctx context.Context, file *models.File, offset int64) (io.ReadCloser, error) {
+    if !file.IsUploaded() {
+        return nil, errors.New("file is not in uploaded state")
+    }
+    if err := s.tenants.check(ctx, file.StoragePath); err != nil {
+        return nil, err
+    }
+
+    input := &s3.GetObjectInput{
+        Bucket: aws.String(s.buckets.forKey(file.StoragePath)),
+        Key:    aws.String(file.StoragePath),
+        Range:  aws.String(fmt.Sprintf("bytes=%d-", offset)),
+    }
+    customerKey, err := customerKeyFor(ctx, file)
+    if err != nil {
+        return nil, err
+    }
+    if customerKey != nil {
+        customerKey.applyGet(input)
+    }
+
+    result, err := s.s3Client.GetObject(ctx, input)
+    if err != nil {
+        if customerKey != nil && isCustomerKeyMismatch(err) {
+            return nil, ErrCustomerKeyMismatch
+        }
+        if isObjectArchived(err) {
+            return nil, ErrObjectArchived
+        }
+        return nil, fmt.Errorf("s3 ranged download failed: %w", err)
+    }
+    return result.Body, nil
+}
+
+// ContentRange is a byte range of a file's content a download asks storage
+// for, so a client seeking into a video or PDF is sent the range read from
+// storage rather than the content up to it read and discarded. Storage that
+// cannot read the range, such as Object Lambda access points transforming
+// the whole object, returns the whole content and leaves it unserved.
+type ContentRange struct {
+    Offset int64
+    Length int64
+    // Served is set by storage returning the range alone
+    Served bool
+}
+
+// contentRangeKey is the context key of a download's content range
+type contentRangeKey struct{}
+
+// WithContentRange asks downloads made with ctx for rng of the content
+func WithContentRange(ctx context.Context, rng *ContentRange) context.Context {
+    return context.WithValue(ctx, contentRangeKey{}, rng)
+}
+
+// ContentRangeFrom returns the content range downloads made with ctx ask
+// for, or nil
+func ContentRangeFrom(ctx context.Context) *ContentRange {
+    rng, _ := ctx.Value(contentRangeKey{}).(*ContentRange)
+    return rng
+}
+
+// header returns the HTTP Range header of the range
+func (r *ContentRange) header() string {
+    return fmt.Sprintf("bytes=%d-%d", r.Offset, r.Offset+r.Length-1)
+}
+
+// ObjectReaderAt reads a file's content at arbitrary offsets through ranged
+// downloads. A read continuing at or shortly after where the previous one
+// ended reads on through the same download, so sequential reads and small
+// skips cost one request.
+type ObjectReaderAt struct {
+    ctx    context.Context
+    ranges RangeStorage
+    file   *models.File
+
+    mu   sync.Mutex
+    body io.ReadCloser
+    pos  int64
+    err  error
+}
+
+// NewObjectReaderAt creates a reader of the file's content from ranges; it
+// must be closed to end its open download
+func NewObjectReaderAt(ctx context.Context, ranges RangeStorage, file *models.File) *ObjectReaderAt {
+    return &ObjectReaderAt{ctx: ctx, ranges: ranges, file: file}
+}
+
+// ReadAt reads len(p) bytes of content at off
+func (r *ObjectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if off < 0 {
+        return 0, errors.New("negative offset")
+    }
+    if off >= r.file.Size {
+        return 0, io.EOF
+    }
+
+    if r.body != nil && (off < r.pos || off-r.pos > rangeSkipLimit) {
+        r.body.Close()
+        r.body = nil
+    }
+    if r.body == nil {
+        body, err := r.ranges.DownloadFrom(r.ctx, r.file, off)
+        if err != nil {
+            return 0, r.fail(err)
+        }
+        r.body, r.pos = body, off
+    }
+    if off > r.pos {
+        skipped, err := io.CopyN(io.Discard, r.body, off-r.pos)
+        r.pos += skipped
+        if err != nil {
+            return 0, r.fail(err)
+        }
+    }
+
+    n, err := io.ReadFull(r.body, p)
+    r.pos += int64(n)
+    switch {
+    case err == nil:
+        return n, nil
+    case (err == io.EOF || err == io.ErrUnexpectedEOF) && r.pos >= r.file.Size:
+        return n, io.EOF
+    default:
+        return n, r.fail(err)
+    }
+}
+
+// Err returns the error a download failed with, if any, telling failures
+// to read the content apart from content that could not be parsed
+func (r *ObjectReaderAt) Err() error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return r.err
+}
+
+// Close ends the open download
+func (r *ObjectReaderAt) Close() error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.body == nil {
+        return nil
+    }
+    err := r.body.Close()
+    r.body = nil
+    return err
+}
+
+// fail records a download failure and drops the download, so the next read
+// starts a new one
+func (r *ObjectReaderAt) fail(err error) error {
+    if err == io.EOF || err == io.ErrUnexpectedEOF {
+        err = fmt.Errorf("content ended before its recorded size: %w", err)
+    }
+    if r.err == nil {
+        r.err = err
+    }
+    if r.body != nil {
+        r.body.Close()
+        r.body = nil
+    }
+    return err
+}
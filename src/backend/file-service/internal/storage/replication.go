@@ -0,0 +1,141 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net/url"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+)
+
+// Replicator copies stored objects to a secondary bucket
+type Replicator interface {
+    // Replicate copies the object stored under key, replacing any earlier copy
+    Replicate(ctx context.Context, key string) error
+}
+
+// ReplicaStorage reads the secondary copies of files' content, and restores
+// them over primary objects found damaged
+type ReplicaStorage interface {
+    // ReadReplica streams the secondary copy of the file's content
+    ReadReplica(ctx context.Context, file *models.File) (io.ReadCloser, error)
+    // RestoreReplica copies the secondary copy of the file's content over
+    // its primary object
+    RestoreReplica(ctx context.Context, file *models.File) error
+}
+
+// S3Replica copies objects from the primary or shard buckets to a single
+// secondary bucket, typically in another region. Copies are made by S3
+// itself, so content never passes through the service; S3 limits a single
+// copy to 5GB.
+type S3Replica struct {
+    s3Client *s3.Client
+    source   bucketShards
+    bucket   string
+    primary  *S3Storage
+}
+
+// NewS3Replica creates a replica of primary's bucket in the configured
+// secondary bucket and region. Its requests are counted and scored with
+// primary's, so the replica shows on the same storage health scoreboard.
+func NewS3Replica(cfg *config.Config, primary *S3Storage) (*S3Replica, error) {
+    awsCfg, err := loadAWSConfig(cfg)
+    if err != nil {
+        return nil, err
+    }
+    awsCfg.Region = cfg.Replication.Region
+
+    s3Client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+        if cfg.S3.Endpoint != "" {
+            o.BaseEndpoint = aws.String(cfg.S3.Endpoint)
+        }
+        o.UsePathStyle = cfg.S3.ForcePathStyle
+        o.APIOptions = append(o.APIOptions, primary.requests.addToStack, primary.health.addToStack(cfg.Replication.Bucket))
+    })
+
+    replica := &S3Replica{
+        s3Client: s3Client,
+        source:   primary.buckets,
+        bucket:   cfg.Replication.Bucket,
+        primary:  primary,
+    }
+    primary.health.Track(Backend{
+        Name:   cfg.Replication.Bucket,
+        Kind:   "s3-replica",
+        Region: cfg.Replication.Region,
+        Probe:  replica.verifyBucket,
+    })
+
+    if err := replica.verifyBucket(context.Background()); err != nil {
+        return nil, fmt.Errorf("replica %w", err)
+    }
+
+    return replica, nil
+}
+
+// Replicate copies the object stored under key to the secondary bucket.
+// Copies use S3-managed keys, since KMS keys do not leave their region.
+func (r *S3Replica) Replicate(ctx context.Context, key string) error {
+    _, err := r.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+        Bucket:               aws.String(r.bucket),
+        Key:                  aws.String(key),
+        CopySource:           aws.String(url.PathEscape(r.source.forKey(key) + "/" + key)),
+        ServerSideEncryption: types.ServerSideEncryptionAes256,
+    })
+    if err != nil {
+        return fmt.Errorf("s3 replicate object failed: %w", err)
+    }
+    return nil
+}
+
+// ReadReplica streams the secondary copy of the file's content
+func (r *S3Replica) ReadReplica(ctx context.Context, file *models.File) (io.ReadCloser, error) {
+    result, err := r.s3Client.GetObject(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(r.bucket),
+        Key:    aws.String(file.StoragePath),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("s3 replica download failed: %w", err)
+    }
+    return result.Body, nil
+}
+
+// RestoreReplica copies the secondary copy of the file's content back over
+// its primary object, encrypted and protected as the primary storage writes
+// objects and carrying the file's metadata and tags. The copy is made by
+// the primary's client, as copies are requested of the destination region.
+func (r *S3Replica) RestoreReplica(ctx context.Context, file *models.File) error {
+    input := &s3.CopyObjectInput{
+        Bucket:            aws.String(r.source.forKey(file.StoragePath)),
+        Key:               aws.String(file.StoragePath),
+        CopySource:        aws.String(url.PathEscape(r.bucket + "/" + file.StoragePath)),
+        ContentType:       aws.String(file.ContentType),
+        Metadata:          objectMetadata(file),
+        MetadataDirective: types.MetadataDirectiveReplace,
+    }
+    replaceTags(input, file)
+    r.primary.sse.applyCopy(input)
+    r.primary.lock.applyCopy(input, file)
+
+    if err := CopyObject(ctx, r.primary.s3Client, input, file.Size, r.primary.copy); err != nil {
+        return fmt.Errorf("s3 replica restore failed: %w", err)
+    }
+    return nil
+}
+
+// verifyBucket checks that the secondary bucket exists and is accessible
+func (r *S3Replica) verifyBucket(ctx context.Context) error {
+    _, err := r.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+        Bucket: aws.String(r.bucket),
+    })
+    if err != nil {
+        return fmt.Errorf("bucket verification failed: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+    "context"
+    "sync"
+    "time"
+
+    awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+    "github.com/aws/smithy-go/middleware"
+
+    "src/backend/file-service/pkg/metrics"
+)
+
+// RequestCounter counts S3 API requests by operation and records their
+// latency. Retried attempts are counted individually, since S3 bills each of them.
+type RequestCounter struct {
+    mu       sync.Mutex
+    counts   map[string]int64
+    since    time.Time
+    requests metrics.Counter
+    latency  metrics.Histogram
+}
+
+// NewRequestCounter creates an empty request counter whose instruments are
+// created from provider, which may be nil
+func NewRequestCounter(provider metrics.Provider) *RequestCounter {
+    provider = metrics.OrNop(provider)
+    return &RequestCounter{
+        counts: make(map[string]int64),
+        since:  time.Now().UTC(),
+        requests: provider.Counter(metrics.Opts{
+            Name:   "s3_requests_total",
+            Help:   "S3 API requests by operation, including retries",
+            Labels: []string{"operation"},
+        }),
+        latency: provider.Histogram(metrics.HistogramOpts{
+            Opts: metrics.Opts{
+                Name:   "s3_request_duration_seconds",
+                Help:   "Latency of S3 API request attempts by operation",
+                Labels: []string{"operation"},
+            },
+            Native: true,
+        }),
+    }
+}
+
+// Counts returns a copy of the request counts and when counting started
+func (c *RequestCounter) Counts() (map[string]int64, time.Time) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    counts := make(map[string]int64, len(c.counts))
+    for operation, count := range c.counts {
+        counts[operation] = count
+    }
+    return counts, c.since
+}
+
+// record counts one request for operation
+func (c *RequestCounter) record(operation string) {
+    c.mu.Lock()
+    c.counts[operation]++
+    c.mu.Unlock()
+    c.requests.Inc(operation)
+}
+
+// addToStack installs the counter after the retry middleware so that every
+// attempt sent to S3 is recorded. Latency observations carry the request's
+// trace ID as an exemplar.
+func (c *RequestCounter) addToStack(stack *middleware.Stack) error {
+    return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("RequestCounter",
+        func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (
+            middleware.FinalizeOutput, middleware.Metadata, error) {
+
+            operation := awsmiddleware.GetOperationName(ctx)
+            c.record(operation)
+
+            start := time.Now()
+            out, metadata, err := next.HandleFinalize(ctx, in)
+            c.latency.Observe(ctx, time.Since(start).Seconds(), operation)
+            return out, metadata, err
+        }), middleware.After)
+}
@@ -8,13 +8,13 @@ import (
     "errors"
     "fmt"
     "io"
-    "path"
     "sync"
     "time"
 
     "github.com/aws/aws-sdk-go-v2/aws"
     "github.com/aws/aws-sdk-go-v2/aws/retry"
     "github.com/aws/aws-sdk-go-v2/config"
+    "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
     "github.com/aws/aws-sdk-go-v2/service/kms"
     "github.com/aws/aws-sdk-go-v2/service/s3"
     "github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -22,6 +22,8 @@ import (
     "src/backend/file-service/internal/config"
     "src/backend/file-service/internal/models"
     "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/storagekey"
 )
 
 // Storage defines the interface for file storage operations
@@ -34,46 +36,55 @@ type Storage interface {
 // S3Storage implements the Storage interface using AWS S3
 type S3Storage struct {
     s3Client        *s3.Client
+    uploader        *manager.Uploader
+    download        DownloadOptions
+    copy            CopyOptions
+    archivePrefix   string
     kmsClient       *kms.Client
-    bucket          string
+    sse             serverSideEncryption
+    checksums       additionalChecksums
+    lock            objectLock
+    buckets         bucketShards
+    tenants         tenantIsolation
+    region          string
+    credentials     aws.CredentialsProvider
     retryer         *retry.Retryer
     workerPool      *sync.Pool
     encryptionKeyID string
     logger          *logger.Logger
+    requests        *RequestCounter
+    health          *HealthBoard
 }
 
-// NewS3Storage creates a new S3Storage instance with the provided configuration
-func NewS3Storage(cfg *config.Config) (*S3Storage, error) {
+// NewS3Storage creates a new S3Storage instance with the provided
+// configuration. Its instruments are created from provider, which may be nil.
+func NewS3Storage(cfg *config.Config, provider metrics.Provider) (*S3Storage, error) {
     log := logger.GetLogger()
 
     // Configure AWS SDK
-    awsCfg, err := config.LoadDefaultConfig(context.Background(),
-        config.WithRegion(cfg.S3.Region),
-        config.WithCredentialsProvider(aws.NewStaticCredentialsProvider(
-            cfg.S3.AccessKey,
-            cfg.S3.SecretKey,
-            cfg.S3.SessionToken,
-        )),
-        config.WithRetryer(func() aws.Retryer {
-            return retry.NewStandard(func(o *retry.StandardOptions) {
-                o.MaxAttempts = cfg.S3.RetryMax
-            })
-        }),
-    )
+    awsCfg, err := loadAWSConfig(cfg)
     if err != nil {
-        return nil, fmt.Errorf("failed to load AWS config: %w", err)
+        return nil, err
     }
 
-    // Initialize S3 client with custom endpoint if specified
+    // Initialize S3 client with custom endpoint if specified, counting
+    // requests and scoring the bucket's health
+    requests := NewRequestCounter(provider)
+    health := NewHealthBoard(cfg.S3.HealthWindow)
     s3Client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
         if cfg.S3.Endpoint != "" {
             o.BaseEndpoint = aws.String(cfg.S3.Endpoint)
         }
         o.UsePathStyle = cfg.S3.ForcePathStyle
+        o.APIOptions = append(o.APIOptions, requests.addToStack, health.addToStack(cfg.S3.Bucket))
     })
 
-    // Initialize KMS client for encryption
+    // Initialize KMS client and check the SSE-KMS key, if objects use one
     kmsClient := kms.NewFromConfig(awsCfg)
+    sse, err := newServerSideEncryption(context.Background(), cfg, kmsClient)
+    if err != nil {
+        return nil, err
+    }
 
     // Initialize worker pool for concurrent operations
     workerPool := &sync.Pool{
@@ -83,30 +94,85 @@ func NewS3Storage(cfg *config.Config) (*S3Storage, error) {
     }
 
     storage := &S3Storage{
-        s3Client:   s3Client,
-        kmsClient:  kmsClient,
-        bucket:     cfg.S3.Bucket,
-        workerPool: workerPool,
-        logger:     log,
+        s3Client:    s3Client,
+        uploader:    newUploader(s3Client, cfg, provider),
+        kmsClient:   kmsClient,
+        sse:         sse,
+        checksums:   additionalChecksums{enabled: cfg.S3.AdditionalChecksums},
+        lock:        newObjectLock(cfg),
+        buckets:     newBucketShards(cfg.S3.Bucket, cfg.S3.ShardBuckets, cfg.S3.TenantBuckets),
+        tenants:     newTenantIsolation(cfg),
+        region:      cfg.S3.Region,
+        credentials: awsCfg.Credentials,
+        workerPool:  workerPool,
+        logger:      log,
+        requests:    requests,
+        health:      health,
+        download: DownloadOptions{
+            PartSize:    cfg.S3.DownloadPartSize,
+            Concurrency: cfg.S3.DownloadConcurrency,
+        },
+        copy: CopyOptions{
+            PartSize:    cfg.S3.CopyPartSize,
+            Concurrency: cfg.S3.UploadConcurrency,
+        },
+        archivePrefix: cfg.S3.SoftDeletePrefix,
+    }
+    health.Track(Backend{
+        Name:   cfg.S3.Bucket,
+        Kind:   "s3",
+        Region: cfg.S3.Region,
+        Probe:  storage.verifyBucket,
+    })
+
+    // Create missing buckets in development environments
+    if cfg.S3.Bootstrap {
+        if err := storage.bootstrapBuckets(context.Background(), cfg.S3.UseSSL); err != nil {
+            return nil, err
+        }
     }
 
-    // Verify bucket exists and is accessible
+    // Verify buckets exist and are accessible
     if err := storage.verifyBucket(context.Background()); err != nil {
         return nil, fmt.Errorf("bucket verification failed: %w", err)
     }
+    for _, bucket := range storage.buckets.all() {
+        if err := storage.lock.verify(context.Background(), s3Client, bucket); err != nil {
+            return nil, fmt.Errorf("bucket %s: %w", bucket, err)
+        }
+    }
 
     return storage, nil
 }
 
+// Requests returns the counter of S3 API requests made by this storage
+func (s *S3Storage) Requests() *RequestCounter {
+    return s.requests
+}
+
+// Health returns the health scoreboard of the storage backends
+func (s *S3Storage) Health() *HealthBoard {
+    return s.health
+}
+
 // Upload securely uploads a file to S3 with encryption and validation
 func (s *S3Storage) Upload(ctx context.Context, file *models.File, reader io.Reader) error {
     log := s.logger.With(
-        logger.zap.String("fileId", file.ID),
-        logger.zap.String("fileName", file.FileName),
+        logger.String("fileId", file.ID),
+        logger.String("fileName", file.FileName),
     )
 
-    // Generate secure storage path
-    storagePath := path.Join(file.ID[:2], file.ID[2:4], file.ID)
+    // Generate secure storage path; drafts live under the scratch prefix,
+    // and under tenant isolation both are scoped to the tenant
+    storagePath := StorageKey(file.ID)
+    if file.IsDraft() {
+        storagePath = ScratchKey(file.ID)
+    }
+    storagePath, err := s.tenants.scope(ctx, storagePath)
+    if err != nil {
+        log.Warn("Upload refused without a tenant", logger.Error(err))
+        return err
+    }
     
     // Calculate checksum while uploading
     hash := sha256.New()
@@ -114,47 +180,99 @@ func (s *S3Storage) Upload(ctx context.Context, file *models.File, reader io.Rea
 
     // Configure server-side encryption
     uploadInput := &s3.PutObjectInput{
-        Bucket: aws.String(s.bucket),
-        Key:    aws.String(storagePath),
-        Body:   teeReader,
-        Metadata: map[string]string{
-            "file-id":   file.ID,
-            "filename": file.FileName,
-        },
-        ServerSideEncryption: types.ServerSideEncryptionAes256,
+        Bucket:   aws.String(s.buckets.forKey(storagePath)),
+        Key:      aws.String(storagePath),
+        Metadata: objectMetadata(file),
+        Tagging:  taggingHeader(file.Tags),
+    }
+    // A customer-provided key replaces S3-managed encryption
+    customerKey := CustomerKeyFromContext(ctx)
+    if customerKey != nil {
+        customerKey.applyPut(uploadInput)
+    } else {
+        s.sse.applyPut(uploadInput)
     }
+    // Drafts are protected once committed
+    if !file.IsDraft() {
+        s.lock.applyPut(uploadInput, file)
+    }
+    // Content S3 checksums is also hashed by part, as the checksum of an
+    // upload in parts is derived from its parts'
+    s.checksums.applyPut(uploadInput)
+    var parts *partHasher
+    if uploadInput.ChecksumAlgorithm != "" {
+        parts = newPartHasher(s.uploader.PartSize)
+        teeReader = io.TeeReader(reader, io.MultiWriter(hash, parts))
+    }
+    uploadInput.Body = teeReader
 
-    // Upload file with retry logic
-    _, err := s.s3Client.PutObject(ctx, uploadInput)
+    // Upload file, in concurrent parts when it is large; parts are retried
+    // individually and the multipart upload is aborted if one still fails
+    result, err := s.uploader.Upload(ctx, uploadInput)
     if err != nil {
+        var multipartFailure manager.MultiUploadFailure
+        if errors.As(err, &multipartFailure) {
+            log = log.With(logger.String("uploadId", multipartFailure.UploadID()))
+        }
         log.Error("Failed to upload file to S3",
-            logger.zap.Error(err))
+            logger.Error(err))
         return fmt.Errorf("s3 upload failed: %w", err)
     }
 
-    // Update file metadata
+    // Check the checksum S3 recorded against the content sent, before the
+    // file is marked uploaded
     checksum := hex.EncodeToString(hash.Sum(nil))
+    if parts != nil {
+        recorded, err := verifyStoredChecksum(aws.ToString(result.ChecksumSHA256), checksum, parts)
+        if err != nil {
+            log.Error("Uploaded content failed checksum verification",
+                logger.String("storagePath", storagePath),
+                logger.Error(err))
+            if _, deleteErr := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+                Bucket: uploadInput.Bucket,
+                Key:    uploadInput.Key,
+            }); deleteErr != nil {
+                log.Warn("Failed to delete unverified upload", logger.Error(deleteErr))
+            }
+            return err
+        }
+        if !recorded {
+            log.Warn("S3 recorded no checksum for the upload")
+        }
+    }
+
+    // Update file metadata
     if err := file.UpdateChecksum(checksum); err != nil {
         log.Error("Failed to update file checksum",
-            logger.zap.Error(err))
+            logger.Error(err))
         return err
     }
 
     if err := file.SetStoragePath(storagePath); err != nil {
         log.Error("Failed to update storage path",
-            logger.zap.Error(err))
+            logger.Error(err))
         return err
     }
+    if customerKey != nil {
+        // The service cannot read the content back without the client, so
+        // it is never copied to the secondary bucket
+        file.ServerSideEncryption = &models.ServerSideEncryption{Algorithm: models.SSEAlgorithmCustomer}
+        file.ReplicationStatus = models.ReplicationSkipped
+    } else {
+        file.ServerSideEncryption = s.sse.applied(result.ServerSideEncryption, result.SSEKMSKeyId)
+    }
 
-    if err := file.UpdateStatus(models.FileStatusUploaded); err != nil {
-        log.Error("Failed to update file status",
-            logger.zap.Error(err))
-        return err
+    if !file.IsDraft() {
+        if err := file.UpdateStatus(models.FileStatusUploaded); err != nil {
+            log.Error("Failed to update file status",
+                logger.Error(err))
+            return err
+        }
     }
 
     log.Info("File uploaded successfully",
-        logger.zap.String("storagePath", storagePath),
-        logger.zap.String("checksum", checksum))
+        logger.String("storagePath", storagePath),
+        logger.String("checksum", checksum))
 
     return nil
 }
@@ -162,80 +280,153 @@ func (s *S3Storage) Upload(ctx context.Context, file *models.File, reader io.Rea
 // Download securely downloads a file from S3 with validation
 func (s *S3Storage) Download(ctx context.Context, file *models.File) (io.ReadCloser, error) {
     log := s.logger.With(
-        logger.zap.String("fileId", file.ID),
-        logger.zap.String("storagePath", file.StoragePath),
+        logger.String("fileId", file.ID),
+        logger.String("storagePath", file.StoragePath),
     )
 
     if !file.IsUploaded() {
         return nil, errors.New("file is not in uploaded state")
     }
+    if err := s.tenants.check(ctx, file.StoragePath); err != nil {
+        log.Warn("Download refused by tenant isolation", logger.Error(err))
+        return nil, err
+    }
 
-    // Configure download request
+    // Configure download request, through the caller's Object Lambda
+    // access point when it has one
+    bucket := s.buckets.forKey(file.StoragePath)
+    viaAccessPoint := false
+    if accessPoint := ObjectLambdaFrom(ctx); accessPoint != "" {
+        bucket, viaAccessPoint = accessPoint, true
+        log = log.With(logger.String("accessPoint", accessPoint))
+    }
     input := &s3.GetObjectInput{
-        Bucket: aws.String(s.bucket),
+        Bucket: aws.String(bucket),
         Key:    aws.String(file.StoragePath),
     }
+    customerKey, err := customerKeyFor(ctx, file)
+    if err != nil {
+        return nil, err
+    }
+    if customerKey != nil {
+        customerKey.applyGet(input)
+    }
+    // Transformed content does not match the checksum of the object
+    if !viaAccessPoint {
+        s.checksums.applyGet(input)
+    }
 
-    // Download file with retry logic
-    result, err := s.s3Client.GetObject(ctx, input)
+    // A requested range is read with one ranged request. Large objects are
+    // read in parallel parts, while transformations of an access point run
+    // on the whole object.
+    contentRange := ContentRangeFrom(ctx)
+    if contentRange != nil && (viaAccessPoint || contentRange.Length <= 0) {
+        contentRange = nil
+    }
+    if contentRange != nil {
+        input.Range = aws.String(contentRange.header())
+        log = log.With(logger.String("range", *input.Range))
+    }
+    var body io.ReadCloser
+    if viaAccessPoint || contentRange != nil {
+        var result *s3.GetObjectOutput
+        result, err = s.s3Client.GetObject(ctx, input)
+        if result != nil {
+            body = result.Body
+        }
+    } else {
+        body, err = StreamObject(ctx, s.s3Client, input, s.download)
+    }
     if err != nil {
+        if customerKey != nil && isCustomerKeyMismatch(err) {
+            log.Warn("Download refused for a wrong customer-provided key")
+            return nil, ErrCustomerKeyMismatch
+        }
+        if isObjectArchived(err) {
+            log.Warn("Download refused for archived content")
+            return nil, ErrObjectArchived
+        }
         log.Error("Failed to download file from S3",
-            logger.zap.Error(err))
+            logger.Error(err))
         return nil, fmt.Errorf("s3 download failed: %w", err)
     }
 
     // Update last accessed timestamp
     file.UpdateLastAccessed()
 
+    if contentRange != nil {
+        contentRange.Served = true
+    }
     log.Info("File download started")
-    return result.Body, nil
+    return body, nil
 }
 
 // Delete removes a file from S3 with optional soft delete
 func (s *S3Storage) Delete(ctx context.Context, file *models.File, softDelete bool) error {
     log := s.logger.With(
-        logger.zap.String("fileId", file.ID),
-        logger.zap.String("storagePath", file.StoragePath),
-        logger.zap.Bool("softDelete", softDelete),
+        logger.String("fileId", file.ID),
+        logger.String("storagePath", file.StoragePath),
+        logger.Bool("softDelete", softDelete),
     )
 
     if file.IsDeleted() {
         return errors.New("file is already deleted")
     }
+    if err := s.tenants.check(ctx, file.StoragePath); err != nil {
+        log.Warn("Deletion refused by tenant isolation", logger.Error(err))
+        return err
+    }
 
     if softDelete {
-        // Move to archive prefix
-        archivePath := path.Join("archive", file.StoragePath)
-        copySource := path.Join(s.bucket, file.StoragePath)
-
-        // Copy to archive location
-        _, err := s.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
-            Bucket:     aws.String(s.bucket),
-            CopySource: aws.String(copySource),
-            Key:        aws.String(archivePath),
-        })
+        // Move to archive prefix, within the tenant's scope
+        archivePath := storagekey.Default.Under(s.archivePrefix, file.StoragePath)
+        copySource := s.buckets.copySource(file.StoragePath)
+
+        // Copy to archive location; SSE-C content can only be copied with its
+        // key. Objects over 5GB are copied in parts, which carry no metadata
+        // of the source, so the copy is given the file's.
+        customerKey, err := customerKeyFor(ctx, file)
+        if err != nil {
+            return err
+        }
+        copyInput := &s3.CopyObjectInput{
+            Bucket:            aws.String(s.buckets.forKey(archivePath)),
+            CopySource:        aws.String(copySource),
+            Key:               aws.String(archivePath),
+            ContentType:       aws.String(file.ContentType),
+            Metadata:          objectMetadata(file),
+            MetadataDirective: types.MetadataDirectiveReplace,
+        }
+        replaceTags(copyInput, file)
+        if customerKey != nil {
+            customerKey.applyCopy(copyInput)
+        }
+        err = CopyObject(ctx, s.s3Client, copyInput, file.Size, s.copy)
         if err != nil {
+            if customerKey != nil && isCustomerKeyMismatch(err) {
+                return ErrCustomerKeyMismatch
+            }
             log.Error("Failed to archive file",
-                logger.zap.Error(err))
+                logger.Error(err))
             return fmt.Errorf("file archival failed: %w", err)
         }
     }
 
     // Delete original file
     _, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-        Bucket: aws.String(s.bucket),
+        Bucket: aws.String(s.buckets.forKey(file.StoragePath)),
         Key:    aws.String(file.StoragePath),
     })
     if err != nil {
         log.Error("Failed to delete file from S3",
-            logger.zap.Error(err))
+            logger.Error(err))
         return fmt.Errorf("s3 deletion failed: %w", err)
     }
 
     // Update file status
     if err := file.UpdateStatus(models.FileStatusDeleted); err != nil {
         log.Error("Failed to update file status",
-            logger.zap.Error(err))
+            logger.Error(err))
         return err
     }
 
@@ -243,13 +434,50 @@ func (s *S3Storage) Delete(ctx context.Context, file *models.File, softDelete bo
     return nil
 }
 
-// verifyBucket checks if the configured bucket exists and is accessible
-func (s *S3Storage) verifyBucket(ctx context.Context) error {
-    _, err := s.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
-        Bucket: aws.String(s.bucket),
-    })
+// objectMetadata returns the user metadata stored with the file's object
+func objectMetadata(file *models.File) map[string]string {
+    return map[string]string{
+        "file-id":  file.ID,
+        "filename": file.FileName,
+    }
+}
+
+// loadAWSConfig builds the AWS SDK configuration shared by all AWS clients
+func loadAWSConfig(cfg *config.Config) (aws.Config, error) {
+    awsCfg, err := config.LoadDefaultConfig(context.Background(),
+        config.WithRegion(cfg.S3.Region),
+        config.WithCredentialsProvider(aws.NewStaticCredentialsProvider(
+            cfg.S3.AccessKey,
+            cfg.S3.SecretKey,
+            cfg.S3.SessionToken,
+        )),
+        config.WithRetryer(func() aws.Retryer {
+            return retry.NewStandard(func(o *retry.StandardOptions) {
+                o.MaxAttempts = cfg.S3.RetryMax
+            })
+        }),
+    )
     if err != nil {
-        return fmt.Errorf("bucket verification failed: %w", err)
+        return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+    }
+    return awsCfg, nil
+}
+
+// StorageKey returns the sharded object key used to store the file with the
+// given ID, as laid out by the storage key policy
+func StorageKey(fileID string) string {
+    return storagekey.Default.Key(fileID)
+}
+
+// verifyBucket checks if the configured buckets exist and are accessible
+func (s *S3Storage) verifyBucket(ctx context.Context) error {
+    for _, bucket := range s.buckets.all() {
+        _, err := s.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+            Bucket: aws.String(bucket),
+        })
+        if err != nil {
+            return fmt.Errorf("bucket verification failed: %s: %w", bucket, err)
+        }
     }
     return nil
 }
\ No newline at end of file
@@ -0,0 +1,96 @@
+package storage
+
+import (
+    "hash/fnv"
+    "path"
+    "sort"
+
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// bucketShards resolves the bucket an object is stored in. With shard
+// buckets configured, the objects of each file are spread over them by a
+// hash of the file ID in their key, which gets around per-bucket request
+// rate limits. Content, its scratch, soft-deleted and quarantined copies
+// and derived objects of one file all resolve to the same bucket. Keys
+// without a file ID, and legacy keys, which predate sharding, are in the
+// primary bucket. Keys scoped to a tenant with a dedicated bucket are in
+// that bucket, whatever their file ID.
+type bucketShards struct {
+    primary string
+    shards  []string
+    tenants map[string]string
+}
+
+// newBucketShards creates the bucket resolution for the primary bucket, the
+// shard buckets and the dedicated buckets of tenants, if any
+func newBucketShards(primary string, shards []string, tenants map[string]string) bucketShards {
+    return bucketShards{primary: primary, shards: shards, tenants: tenants}
+}
+
+// forKey returns the bucket of the object under key
+func (b bucketShards) forKey(key string) string {
+    if len(b.tenants) > 0 {
+        if bucket, ok := b.tenants[storagekey.Default.Tenant(key)]; ok {
+            return bucket
+        }
+    }
+    if len(b.shards) == 0 {
+        return b.primary
+    }
+    id, ok := storagekey.Default.ID(key)
+    if !ok {
+        return b.primary
+    }
+    return b.forID(id)
+}
+
+// forID returns the shard bucket of the file with the given ID. Jump
+// consistent hashing moves only the files of the new bucket when a bucket
+// is appended to the list.
+func (b bucketShards) forID(id string) string {
+    hash := fnv.New64a()
+    hash.Write([]byte(id))
+    return b.shards[jumpHash(hash.Sum64(), len(b.shards))]
+}
+
+// all returns every bucket objects may be stored in, the primary first and
+// tenant buckets last, by name
+func (b bucketShards) all() []string {
+    buckets := []string{b.primary}
+    seen := map[string]bool{b.primary: true}
+    for _, shard := range b.shards {
+        if !seen[shard] {
+            buckets = append(buckets, shard)
+            seen[shard] = true
+        }
+    }
+
+    var tenantBuckets []string
+    for _, bucket := range b.tenants {
+        if !seen[bucket] {
+            tenantBuckets = append(tenantBuckets, bucket)
+            seen[bucket] = true
+        }
+    }
+    sort.Strings(tenantBuckets)
+    return append(buckets, tenantBuckets...)
+}
+
+// copySource returns the CopySource of the object under key
+func (b bucketShards) copySource(key string) string {
+    return path.Join(b.forKey(key), key)
+}
+
+// jumpHash maps key to one of n buckets such that growing n to n+1 remaps
+// only a 1/(n+1) share of keys (Lamping and Veach, "A Fast, Minimal Memory,
+// Consistent Hash Algorithm")
+func jumpHash(key uint64, n int) int {
+    var b, j int64 = -1, 0
+    for j < int64(n) {
+        b = j
+        key = key*2862933555777941757 + 1
+        j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+    }
+    return int(b)
+}
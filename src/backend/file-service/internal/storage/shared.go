@@ -0,0 +1,62 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/models"
+)
+
+// SharedContentStorage points files at content that is already stored, so
+// an upload of the same content is not written again
+type SharedContentStorage interface {
+    // ShareScope returns the tenant content uploaded for ctx is scoped to,
+    // "" for unscoped keys; only content of that scope may be shared
+    ShareScope(ctx context.Context) (string, error)
+    // ShareObject points file at the object stored under key. It reports
+    // false when the upload needs an object of its own.
+    ShareObject(ctx context.Context, file *models.File, key string) (bool, error)
+}
+
+// ShareScope returns the tenant content uploaded for ctx is scoped to under
+// tenant isolation, "" otherwise
+func (s *S3Storage) ShareScope(ctx context.Context) (string, error) {
+    return s.tenants.scopeTenant(ctx)
+}
+
+// ShareObject points file at the object stored under key, recording the
+// object's encryption on file. Uploads protected by Object Lock or
+// encrypted with a customer-provided key need objects of their own, as do
+// uploads of tenants that may not read key, and a missing object fails with
+// ErrObjectNotFound.
+func (s *S3Storage) ShareObject(ctx context.Context, file *models.File, key string) (bool, error) {
+    if s.lock.enabled() || CustomerKeyFromContext(ctx) != nil || file.IsDraft() {
+        return false, nil
+    }
+    if err := s.tenants.check(ctx, key); err != nil {
+        return false, nil
+    }
+
+    result, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+        Bucket: aws.String(s.buckets.forKey(key)),
+        Key:    aws.String(key),
+    })
+    if err != nil {
+        var notFound *types.NotFound
+        if errors.As(err, &notFound) {
+            return false, ErrObjectNotFound
+        }
+        return false, fmt.Errorf("s3 head object failed: %w", err)
+    }
+
+    if err := file.SetStoragePath(key); err != nil {
+        return false, err
+    }
+    file.ServerSideEncryption = s.sse.applied(result.ServerSideEncryption, result.SSEKMSKeyId)
+    return true, nil
+}
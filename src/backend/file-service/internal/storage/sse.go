@@ -0,0 +1,122 @@
+package storage
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/kms"
+    kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+)
+
+// serverSideEncryption is the encryption S3 applies to every object the
+// service stores: AES256 with S3-managed keys, or aws:kms with a customer
+// managed key. Under aws:kms the service role needs kms:GenerateDataKey and
+// kms:Decrypt on the key, through the key policy or a grant; S3 Bucket Keys
+// cut the KMS requests, and so the cost, to one per bucket key lifetime.
+type serverSideEncryption struct {
+    algorithm types.ServerSideEncryption
+    kmsKeyID  string
+    bucketKey bool
+}
+
+// newServerSideEncryption resolves the configured encryption. Under aws:kms
+// the key is described once, so a missing, disabled or unusable key, or a
+// role without access to it, fails startup rather than every upload, and
+// an alias is recorded as the ARN of the key it names.
+func newServerSideEncryption(ctx context.Context, cfg *config.Config, kmsClient *kms.Client) (serverSideEncryption, error) {
+    if cfg.S3.SSEAlgorithm != models.SSEAlgorithmKMS {
+        return serverSideEncryption{algorithm: types.ServerSideEncryptionAes256}, nil
+    }
+
+    result, err := kmsClient.DescribeKey(ctx, &kms.DescribeKeyInput{
+        KeyId: aws.String(cfg.S3.SSEKMSKeyID),
+    })
+    if err != nil {
+        return serverSideEncryption{}, fmt.Errorf("failed to describe SSE-KMS key: %w", err)
+    }
+    key := result.KeyMetadata
+    if key == nil {
+        return serverSideEncryption{}, errors.New("SSE-KMS key has no metadata")
+    }
+    if key.KeyState != kmstypes.KeyStateEnabled {
+        return serverSideEncryption{}, fmt.Errorf("SSE-KMS key %s is %s", aws.ToString(key.Arn), key.KeyState)
+    }
+    if key.KeyUsage != kmstypes.KeyUsageTypeEncryptDecrypt || key.KeySpec != kmstypes.KeySpecSymmetricDefault {
+        return serverSideEncryption{}, fmt.Errorf("SSE-KMS key %s is not a symmetric encryption key", aws.ToString(key.Arn))
+    }
+
+    return serverSideEncryption{
+        algorithm: types.ServerSideEncryptionAwsKms,
+        kmsKeyID:  aws.ToString(key.Arn),
+        bucketKey: cfg.S3.SSEBucketKey,
+    }, nil
+}
+
+// applyPut sets the encryption of an object upload
+func (e serverSideEncryption) applyPut(input *s3.PutObjectInput) {
+    input.ServerSideEncryption = e.algorithm
+    if e.kmsKeyID != "" {
+        input.SSEKMSKeyId = aws.String(e.kmsKeyID)
+        input.BucketKeyEnabled = e.bucketKey
+    }
+}
+
+// applyMultipart sets the encryption of an object assembled from parts
+func (e serverSideEncryption) applyMultipart(input *s3.CreateMultipartUploadInput) {
+    input.ServerSideEncryption = e.algorithm
+    if e.kmsKeyID != "" {
+        input.SSEKMSKeyId = aws.String(e.kmsKeyID)
+        input.BucketKeyEnabled = e.bucketKey
+    }
+}
+
+// applyCopy sets the encryption of an object copy
+func (e serverSideEncryption) applyCopy(input *s3.CopyObjectInput) {
+    input.ServerSideEncryption = e.algorithm
+    if e.kmsKeyID != "" {
+        input.SSEKMSKeyId = aws.String(e.kmsKeyID)
+        input.BucketKeyEnabled = e.bucketKey
+    }
+}
+
+// bucketDefault returns the configured encryption as a bucket's default
+// encryption, applied to objects stored without encryption headers
+func (e serverSideEncryption) bucketDefault() *types.ServerSideEncryptionConfiguration {
+    rule := types.ServerSideEncryptionRule{
+        ApplyServerSideEncryptionByDefault: &types.ServerSideEncryptionByDefault{
+            SSEAlgorithm: e.algorithm,
+        },
+    }
+    if e.kmsKeyID != "" {
+        rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID = aws.String(e.kmsKeyID)
+        rule.BucketKeyEnabled = e.bucketKey
+    }
+    return &types.ServerSideEncryptionConfiguration{Rules: []types.ServerSideEncryptionRule{rule}}
+}
+
+// metadata returns the configured encryption as recorded on files
+func (e serverSideEncryption) metadata() *models.ServerSideEncryption {
+    return &models.ServerSideEncryption{
+        Algorithm: string(e.algorithm),
+        KMSKeyID:  e.kmsKeyID,
+    }
+}
+
+// applied returns the encryption S3 reports it applied to an object,
+// falling back to the configured encryption when the response omits it
+func (e serverSideEncryption) applied(algorithm types.ServerSideEncryption, kmsKeyID *string) *models.ServerSideEncryption {
+    if algorithm == "" {
+        return e.metadata()
+    }
+    return &models.ServerSideEncryption{
+        Algorithm: string(algorithm),
+        KMSKeyID:  aws.ToString(kmsKeyID),
+    }
+}
@@ -0,0 +1,128 @@
+package storage
+
+import (
+    "context"
+    "crypto/md5"
+    "encoding/base64"
+    "errors"
+    "net/http"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    smithyhttp "github.com/aws/smithy-go/transport/http"
+
+    "src/backend/file-service/internal/models"
+)
+
+// Customer-provided key errors
+var (
+    ErrInvalidCustomerKey  = errors.New("customer-provided key must be a base64 AES-256 key with a matching MD5")
+    ErrCustomerKeyRequired = errors.New("file is encrypted with a customer-provided key")
+    ErrCustomerKeyMismatch = errors.New("customer-provided key does not match the file's key")
+)
+
+// customerKeyAlgorithm is the only algorithm S3 supports for SSE-C
+const customerKeyAlgorithm = "AES256"
+
+// customerKeyKey is the context key of the customer-provided key of a request
+type customerKeyKey struct{}
+
+// CustomerKey is an AES-256 key supplied by the client for SSE-C. S3 uses it
+// to encrypt or decrypt the object and then discards it; the service passes
+// it to S3 with the request it came with and never stores or logs it.
+type CustomerKey struct {
+    encoded string
+    md5     string
+}
+
+// ParseCustomerKey parses a key as sent in the SSE-C headers: the algorithm,
+// which must be AES256, the base64 key and, optionally, the base64 MD5 of the
+// key, checked to catch keys damaged in transit
+func ParseCustomerKey(algorithm, key, keyMD5 string) (*CustomerKey, error) {
+    if algorithm != customerKeyAlgorithm {
+        return nil, ErrInvalidCustomerKey
+    }
+    raw, err := base64.StdEncoding.DecodeString(key)
+    if err != nil || len(raw) != 32 {
+        return nil, ErrInvalidCustomerKey
+    }
+
+    digest := md5.Sum(raw)
+    encodedMD5 := base64.StdEncoding.EncodeToString(digest[:])
+    if keyMD5 != "" && keyMD5 != encodedMD5 {
+        return nil, ErrInvalidCustomerKey
+    }
+
+    return &CustomerKey{encoded: key, md5: encodedMD5}, nil
+}
+
+// String keeps the key out of logs and error messages
+func (k *CustomerKey) String() string {
+    return "[REDACTED]"
+}
+
+// GoString keeps the key out of %#v formatting
+func (k *CustomerKey) GoString() string {
+    return k.String()
+}
+
+// WithCustomerKey returns a context whose uploads and downloads are encrypted
+// with key; a nil key leaves the context unchanged
+func WithCustomerKey(ctx context.Context, key *CustomerKey) context.Context {
+    if key == nil {
+        return ctx
+    }
+    return context.WithValue(ctx, customerKeyKey{}, key)
+}
+
+// CustomerKeyFromContext returns the customer-provided key of the context, or nil
+func CustomerKeyFromContext(ctx context.Context) *CustomerKey {
+    key, _ := ctx.Value(customerKeyKey{}).(*CustomerKey)
+    return key
+}
+
+// applyPut sets the key on an object upload
+func (k *CustomerKey) applyPut(input *s3.PutObjectInput) {
+    input.SSECustomerAlgorithm = aws.String(customerKeyAlgorithm)
+    input.SSECustomerKey = aws.String(k.encoded)
+    input.SSECustomerKeyMD5 = aws.String(k.md5)
+}
+
+// applyGet sets the key on an object download
+func (k *CustomerKey) applyGet(input *s3.GetObjectInput) {
+    input.SSECustomerAlgorithm = aws.String(customerKeyAlgorithm)
+    input.SSECustomerKey = aws.String(k.encoded)
+    input.SSECustomerKeyMD5 = aws.String(k.md5)
+}
+
+// applyCopy sets the key on a copy of an object within the bucket, which is
+// decrypted and re-encrypted with the same key
+func (k *CustomerKey) applyCopy(input *s3.CopyObjectInput) {
+    input.CopySourceSSECustomerAlgorithm = aws.String(customerKeyAlgorithm)
+    input.CopySourceSSECustomerKey = aws.String(k.encoded)
+    input.CopySourceSSECustomerKeyMD5 = aws.String(k.md5)
+    input.SSECustomerAlgorithm = aws.String(customerKeyAlgorithm)
+    input.SSECustomerKey = aws.String(k.encoded)
+    input.SSECustomerKeyMD5 = aws.String(k.md5)
+}
+
+// customerKeyFor returns the key needed to read file, failing with
+// ErrCustomerKeyRequired when the context has none; files encrypted with S3
+// keys need no key
+func customerKeyFor(ctx context.Context, file *models.File) (*CustomerKey, error) {
+    if !file.UsesCustomerKey() {
+        return nil, nil
+    }
+    key := CustomerKeyFromContext(ctx)
+    if key == nil {
+        return nil, ErrCustomerKeyRequired
+    }
+    return key, nil
+}
+
+// isCustomerKeyMismatch reports whether S3 refused a read of an SSE-C object
+// because it was given the wrong key
+func isCustomerKeyMismatch(err error) bool {
+    var responseErr *smithyhttp.ResponseError
+    return errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusForbidden
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "io"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// StagingPrefix is the key prefix for the content of asynchronous uploads
+// awaiting processing. A bucket lifecycle rule expiring this prefix backs
+// up the removal of staged content.
+const StagingPrefix = storagekey.Staging
+
+// StagingKey returns the object key the content of an asynchronous upload
+// of the file with the given ID is staged under
+func StagingKey(fileID string) string {
+    return storagekey.Default.Nested(StagingPrefix, fileID)
+}
+
+// StagingStorage holds the content of asynchronous uploads until it is
+// processed and stored as the file's content
+type StagingStorage interface {
+    // Stage stores the content read from reader under the file's staging
+    // key and sets its storage path to it
+    Stage(ctx context.Context, file *models.File, reader io.Reader) error
+    GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+    DeleteObject(ctx context.Context, key string) error
+}
+
+// Stage stores the content of an asynchronous upload under the staging
+// prefix, in the tenant's scope under tenant isolation, encrypted as
+// configured. Staged content is neither tagged nor protected by Object Lock,
+// since it is only kept until processed.
+func (s *S3Storage) Stage(ctx context.Context, file *models.File, reader io.Reader) error {
+    log := s.logger.With(logger.String("fileId", file.ID))
+
+    storagePath, err := s.tenants.scope(ctx, StagingKey(file.ID))
+    if err != nil {
+        log.Warn("Staging refused without a tenant", logger.Error(err))
+        return err
+    }
+
+    input := &s3.PutObjectInput{
+        Bucket: aws.String(s.buckets.forKey(storagePath)),
+        Key:    aws.String(storagePath),
+        Body:   reader,
+    }
+    s.sse.applyPut(input)
+    s.checksums.applyPut(input)
+    if _, err := s.uploader.Upload(ctx, input); err != nil {
+        log.Error("Failed to stage upload", logger.Error(err))
+        return fmt.Errorf("s3 upload failed: %w", err)
+    }
+
+    if err := file.SetStoragePath(storagePath); err != nil {
+        return err
+    }
+    log.Info("Upload staged", logger.String("storagePath", storagePath))
+    return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+    "context"
+    "fmt"
+    "net/url"
+    "sort"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/pkg/logger"
+)
+
+// TagStorage mirrors file tags to the objects holding their content
+type TagStorage interface {
+    PutTags(ctx context.Context, file *models.File) error
+}
+
+// PutTags replaces the object tags of the file's object with its tags
+func (s *S3Storage) PutTags(ctx context.Context, file *models.File) error {
+    if len(file.Tags) == 0 {
+        if _, err := s.s3Client.DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{
+            Bucket: aws.String(s.buckets.forKey(file.StoragePath)),
+            Key:    aws.String(file.StoragePath),
+        }); err != nil {
+            return fmt.Errorf("s3 delete object tagging failed: %w", err)
+        }
+        return nil
+    }
+
+    tagSet := make([]types.Tag, 0, len(file.Tags))
+    for _, key := range sortedTagKeys(file.Tags) {
+        tagSet = append(tagSet, types.Tag{
+            Key:   aws.String(key),
+            Value: aws.String(file.Tags[key]),
+        })
+    }
+    if _, err := s.s3Client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+        Bucket:  aws.String(s.buckets.forKey(file.StoragePath)),
+        Key:     aws.String(file.StoragePath),
+        Tagging: &types.Tagging{TagSet: tagSet},
+    }); err != nil {
+        return fmt.Errorf("s3 put object tagging failed: %w", err)
+    }
+
+    s.logger.Debug("Synced object tags",
+        logger.String("fileId", file.ID),
+        logger.Int("tags", len(tagSet)))
+    return nil
+}
+
+// taggingHeader encodes tags as the URL query PutObject and CopyObject take,
+// or returns nil when there are none
+func taggingHeader(tags models.Tags) *string {
+    if len(tags) == 0 {
+        return nil
+    }
+    values := url.Values{}
+    for key, value := range tags {
+        values.Set(key, value)
+    }
+    return aws.String(values.Encode())
+}
+
+// replaceTags makes an object copy carry the file's tags rather than those
+// of the source object, which may be stale if an earlier sync failed
+func replaceTags(input *s3.CopyObjectInput, file *models.File) {
+    input.TaggingDirective = types.TaggingDirectiveReplace
+    input.Tagging = taggingHeader(file.Tags)
+}
+
+// sortedTagKeys returns the keys of tags in order
+func sortedTagKeys(tags models.Tags) []string {
+    keys := make([]string, 0, len(tags))
+    for key := range tags {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
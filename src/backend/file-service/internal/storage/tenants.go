@@ -0,0 +1,104 @@
+package storage
+
+import (
+    "context"
+    "errors"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// Tenant isolation errors
+var (
+    // ErrTenantMismatch is returned for objects of another tenant
+    ErrTenantMismatch = errors.New("object belongs to another tenant")
+    // ErrTenantRequired is returned under tenant isolation for requests
+    // whose principal has no tenant
+    ErrTenantRequired = errors.New("tenant is required to access storage")
+)
+
+// tenantIsolation keeps each tenant's objects apart: content written for a
+// tenant is stored under tenants/{tenantID}/, in the tenant's dedicated
+// bucket if it has one, and keys scoped to another tenant are refused, so
+// a request cannot reach another tenant's content even with its key. The
+// tenant is the one the request acts for, from the principal's claims.
+// Work without a principal, such as background jobs, is the service's own
+// and reaches every key.
+type tenantIsolation struct {
+    enabled bool
+    // scopedOnly also refuses unscoped keys, written before isolation was
+    // enabled, to tenants
+    scopedOnly bool
+}
+
+// newTenantIsolation creates the tenant isolation configured by cfg
+func newTenantIsolation(cfg *config.Config) tenantIsolation {
+    return tenantIsolation{
+        enabled:    cfg.S3.TenantIsolation,
+        scopedOnly: cfg.S3.TenantKeysRequired,
+    }
+}
+
+// tenant returns the tenant ctx acts for, "" for the service's own work
+func (t tenantIsolation) tenant(ctx context.Context) (string, error) {
+    tenantID := requestctx.Tenant(ctx)
+    if tenantID == "" {
+        if _, ok := requestctx.PrincipalFrom(ctx); ok {
+            return "", ErrTenantRequired
+        }
+    }
+    return tenantID, nil
+}
+
+// scopeTenant returns the tenant content written for ctx is scoped to, ""
+// when it is written under unscoped keys
+func (t tenantIsolation) scopeTenant(ctx context.Context) (string, error) {
+    if !t.enabled {
+        return "", nil
+    }
+    return t.tenant(ctx)
+}
+
+// scope returns the key content for the tenant ctx acts for is written
+// under in place of key
+func (t tenantIsolation) scope(ctx context.Context, key string) (string, error) {
+    tenantID, err := t.scopeTenant(ctx)
+    if err != nil || tenantID == "" {
+        return key, err
+    }
+    return storagekey.Default.ForTenant(tenantID, key), nil
+}
+
+// checkMove refuses moving the object under from to key when the tenant ctx
+// acts for cannot access it, or when key is scoped to another tenant than
+// from, which would hand the content to that tenant
+func (t tenantIsolation) checkMove(ctx context.Context, from, key string) error {
+    if !t.enabled {
+        return nil
+    }
+    if err := t.check(ctx, from); err != nil {
+        return err
+    }
+    if storagekey.Default.Tenant(key) != storagekey.Default.Tenant(from) {
+        return ErrTenantMismatch
+    }
+    return nil
+}
+
+// check refuses the tenant ctx acts for access to the object under key
+func (t tenantIsolation) check(ctx context.Context, key string) error {
+    if !t.enabled {
+        return nil
+    }
+    tenantID, err := t.tenant(ctx)
+    if err != nil || tenantID == "" {
+        return err
+    }
+
+    owner := storagekey.Default.Tenant(key)
+    if owner != tenantID && (owner != "" || t.scopedOnly) {
+        return ErrTenantMismatch
+    }
+    return nil
+}
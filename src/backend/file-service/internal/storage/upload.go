@@ -0,0 +1,53 @@
+package storage
+
+import (
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/aws/retry"
+    "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/pkg/metrics"
+)
+
+// newUploader creates an uploader that sends content smaller than the
+// configured part size with a single PutObject and larger content as a
+// multipart upload with parts sent concurrently. Each part is buffered, so a
+// failed part is retried on its own; when its retries are exhausted the
+// multipart upload is aborted rather than leaving parts behind.
+func newUploader(client *s3.Client, cfg *config.Config, provider metrics.Provider) *manager.Uploader {
+    // Retried attempts are counted so flaky part uploads show before uploads
+    // start failing
+    retries := metrics.OrNop(provider).Counter(metrics.Opts{
+        Name: "s3_upload_part_retries_total",
+        Help: "Retried attempts of S3 upload requests, including multipart parts",
+    })
+
+    return manager.NewUploader(client, func(u *manager.Uploader) {
+        u.PartSize = cfg.S3.UploadPartSize
+        u.Concurrency = cfg.S3.UploadConcurrency
+        u.LeavePartsOnError = false
+        u.ClientOptions = append(u.ClientOptions, func(o *s3.Options) {
+            o.Retryer = partRetryer{
+                RetryerV2: retry.NewStandard(func(so *retry.StandardOptions) {
+                    so.MaxAttempts = cfg.S3.UploadPartRetryMax
+                }),
+                retries: retries,
+            }
+        })
+    })
+}
+
+// partRetryer counts the retries of upload requests
+type partRetryer struct {
+    aws.RetryerV2
+    retries metrics.Counter
+}
+
+// RetryDelay is called once per retried attempt
+func (r partRetryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+    r.retries.Inc()
+    return r.RetryerV2.RetryDelay(attempt, err)
+}
@@ -0,0 +1,162 @@
+// Package apiversion negotiates the version of the HTTP API a request is
+// served with and marks the unversioned legacy routes as deprecated. Clients
+// select a version by path (/api/v1/...) or by a vendor media type in the
+// Accept header; legacy routes keep working but answer with Deprecation,
+// Sunset and successor Link headers and are counted so their removal can be
+// planned from real usage.
+package apiversion
+
+import (
+    "context"
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+)
+
+// Current is the newest, and only, API version
+const Current = "v1"
+
+// Prefix is the path prefix of the current API version
+const Prefix = "/api/" + Current
+
+// MediaType is the Accept media type selecting the current API version
+const MediaType = "application/vnd.fileservice." + Current + "+json"
+
+// Header names
+const (
+    // VersionHeader reports the API version a response was served with
+    VersionHeader     = "API-Version"
+    deprecationHeader = "Deprecation"
+    sunsetHeader      = "Sunset"
+)
+
+// vendorMediaPrefix and vendorMediaSuffix enclose the version in a vendor
+// media type such as application/vnd.fileservice.v1+json
+const (
+    vendorMediaPrefix = "application/vnd.fileservice."
+    vendorMediaSuffix = "+json"
+)
+
+// supported lists the versions the API can serve
+var supported = map[string]bool{Current: true}
+
+// legacyRequests counts requests to deprecated routes
+var legacyRequests = prometheus.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: "legacy_route_requests_total",
+        Help: "Number of requests to deprecated unversioned API routes",
+    },
+    []string{"route"},
+)
+
+// Collectors returns the API versioning instruments for registration
+func Collectors() []prometheus.Collector {
+    return []prometheus.Collector{legacyRequests}
+}
+
+type versionKey struct{}
+
+// FromContext returns the API version negotiated for a request, or "" if the
+// request was not served through Negotiate
+func FromContext(ctx context.Context) string {
+    version, _ := ctx.Value(versionKey{}).(string)
+    return version
+}
+
+// Negotiate selects the API version of each request from its Accept header,
+// answering 406 when every versioned media type asks for an unsupported
+// version, and reports the version served in the API-Version header.
+// Requests without a versioned media type get the current version.
+func Negotiate(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        version, ok := acceptedVersion(r.Header.Values("Accept"))
+        if !ok {
+            w.Header().Set("Content-Type", "application/json")
+            w.WriteHeader(http.StatusNotAcceptable)
+            w.Write([]byte(`{"error":"Unsupported API version"}`))
+            return
+        }
+
+        w.Header().Set(VersionHeader, version)
+        next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)))
+    })
+}
+
+// acceptedVersion returns the first supported version named by a vendor
+// media type in the Accept header values. It is false only if vendor media
+// types were given and none of them is supported.
+func acceptedVersion(accept []string) (string, bool) {
+    requested := false
+    for _, value := range accept {
+        for _, part := range strings.Split(value, ",") {
+            mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+            if err != nil || !strings.HasPrefix(mediaType, vendorMediaPrefix) {
+                continue
+            }
+            requested = true
+            version := strings.TrimSuffix(strings.TrimPrefix(mediaType, vendorMediaPrefix), vendorMediaSuffix)
+            if supported[version] {
+                return version, true
+            }
+        }
+    }
+    return Current, !requested
+}
+
+// Policy describes the retirement of the legacy routes
+type Policy struct {
+    // DeprecatedAt is when the legacy routes were deprecated; zero announces
+    // the deprecation without a date
+    DeprecatedAt time.Time
+    // Sunset is when the legacy routes stop being served; zero omits the
+    // Sunset header
+    Sunset time.Time
+}
+
+// Legacy marks requests to the given unversioned routes as deprecated. Their
+// responses carry the Deprecation header (RFC 9745), the Sunset header (RFC
+// 8594) when a sunset is planned and a Link to the versioned successor, and
+// each request is counted by route. Other paths pass through untouched.
+func Legacy(policy Policy, routes ...string) func(http.Handler) http.Handler {
+    legacy := make(map[string]bool, len(routes))
+    for _, route := range routes {
+        legacy[route] = true
+    }
+
+    deprecation := "true"
+    if !policy.DeprecatedAt.IsZero() {
+        deprecation = "@" + strconv.FormatInt(policy.DeprecatedAt.Unix(), 10)
+    }
+
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            route := r.URL.Path
+            if !legacy[route] {
+                next.ServeHTTP(w, r)
+                return
+            }
+
+            legacyRequests.WithLabelValues(route).Inc()
+            w.Header().Set(deprecationHeader, deprecation)
+            if !policy.Sunset.IsZero() {
+                w.Header().Set(sunsetHeader, policy.Sunset.UTC().Format(http.TimeFormat))
+            }
+            w.Header().Add("Link", "<"+Prefix+route+`>; rel="successor-version"`)
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+// Mount serves api under Prefix with the prefix stripped, so routes are
+// registered once and reachable both versioned and, until they are retired,
+// unversioned through legacy
+func Mount(api http.Handler, legacy func(http.Handler) http.Handler) http.Handler {
+    mux := http.NewServeMux()
+    mux.Handle(Prefix+"/", http.StripPrefix(Prefix, api))
+    mux.Handle("/", legacy(api))
+    return Negotiate(mux)
+}
@@ -0,0 +1,228 @@
+// Package archivelist lists the entries of zip and tar archives without
+// extracting them, and reads them one at a time for extraction.
+package archivelist
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bufio"
+    "compress/gzip"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "strings"
+    "time"
+)
+
+// ContentType is the type of every encoded listing
+const ContentType = "application/json"
+
+// Archive formats
+const (
+    FormatZip     = "zip"
+    FormatTar     = "tar"
+    FormatTarGzip = "tar+gzip"
+)
+
+// MaxEntries bounds the entries of a listing; larger archives are listed
+// truncated
+const MaxEntries = 10000
+
+// ErrUnsupportedFormat is returned for files that are not zip or tar archives
+var ErrUnsupportedFormat = errors.New("file is not a zip or tar archive")
+
+// Entry is one file or directory in an archive
+type Entry struct {
+    Name string `json:"name"`
+    // Size is the entry's uncompressed size
+    Size int64 `json:"size"`
+    // CompressedSize is the size the entry takes in the archive, for
+    // formats that compress entries individually
+    CompressedSize int64 `json:"compressedSize,omitempty"`
+    // Ratio is Size over CompressedSize
+    Ratio float64 `json:"ratio,omitempty"`
+    Dir   bool    `json:"dir,omitempty"`
+    // Link is the target of symbolic and hard links. A zip symbolic link
+    // holds its target as content, so only entries read through a Reader
+    // have it.
+    Link     string     `json:"link,omitempty"`
+    Modified *time.Time `json:"modified,omitempty"`
+}
+
+// Listing is the entries of an archive with their totals. Totals cover
+// every entry read: all of a zip's, and those listed of a truncated tar.
+type Listing struct {
+    Format  string  `json:"format"`
+    Entries []Entry `json:"entries"`
+    // Count is the number of entries in the archive; truncated tar
+    // archives are read no further, so it counts those listed
+    Count     int  `json:"count"`
+    Truncated bool `json:"truncated"`
+    // Size is the uncompressed size of the entries
+    Size int64 `json:"size"`
+    // ArchiveSize is the size of the archive itself
+    ArchiveSize int64 `json:"archiveSize"`
+    // Ratio is Size over ArchiveSize
+    Ratio float64 `json:"ratio,omitempty"`
+}
+
+// Format returns the archive format of a file by its content type and,
+// for gzip compressed files, its name
+func Format(contentType, name string) (string, bool) {
+    name = strings.ToLower(name)
+    switch mediaType(contentType) {
+    case "application/zip", "application/x-zip-compressed":
+        return FormatZip, true
+    case "application/x-tar":
+        return FormatTar, true
+    case "application/x-gtar", "application/x-compressed-tar":
+        return FormatTarGzip, true
+    case "application/gzip", "application/x-gzip":
+        if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+            return FormatTarGzip, true
+        }
+    }
+    return "", false
+}
+
+// List reads the entries of the archive of format in r, size bytes long,
+// listing at most maxEntries. Zip archives are listed from their central
+// directory at the end of the archive, tar archives from the entry headers
+// with their content skipped; compressed tar archives are decompressed as
+// they are read, but nothing is written out.
+func List(format string, r io.ReaderAt, size int64, maxEntries int) (*Listing, error) {
+    listing := &Listing{Format: format, Entries: []Entry{}, ArchiveSize: size}
+
+    var err error
+    switch format {
+    case FormatZip:
+        err = listZip(listing, r, size, maxEntries)
+    case FormatTar:
+        err = listTar(listing, io.NewSectionReader(r, 0, size), maxEntries)
+    case FormatTarGzip:
+        var gz *gzip.Reader
+        gz, err = gzip.NewReader(bufio.NewReaderSize(io.NewSectionReader(r, 0, size), 1<<20))
+        if err != nil {
+            return nil, fmt.Errorf("failed to read gzip header: %w", err)
+        }
+        defer gz.Close()
+        err = listTar(listing, gz, maxEntries)
+    default:
+        return nil, ErrUnsupportedFormat
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    listing.Ratio = ratio(listing.Size, listing.ArchiveSize)
+    return listing, nil
+}
+
+// Encode lists the archive as List does and encodes the listing as JSON
+func Encode(format string, r io.ReaderAt, size int64, maxEntries int) ([]byte, error) {
+    listing, err := List(format, r, size, maxEntries)
+    if err != nil {
+        return nil, err
+    }
+    return json.Marshal(listing)
+}
+
+// listZip lists a zip archive from its central directory
+func listZip(listing *Listing, r io.ReaderAt, size int64, maxEntries int) error {
+    archive, err := zip.NewReader(r, size)
+    if err != nil {
+        return fmt.Errorf("failed to read zip directory: %w", err)
+    }
+
+    for _, file := range archive.File {
+        listing.Count++
+        listing.Size += int64(file.UncompressedSize64)
+        if len(listing.Entries) >= maxEntries {
+            listing.Truncated = true
+            continue
+        }
+        listing.Entries = append(listing.Entries, zipEntry(file))
+    }
+    return nil
+}
+
+// listTar lists a tar archive from its entry headers, stopping at the
+// first entry past maxEntries
+func listTar(listing *Listing, r io.Reader, maxEntries int) error {
+    archive := tar.NewReader(r)
+    for {
+        header, err := archive.Next()
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return fmt.Errorf("failed to read tar header: %w", err)
+        }
+        entry, ok := tarEntry(header)
+        if !ok {
+            continue
+        }
+
+        if len(listing.Entries) >= maxEntries {
+            listing.Truncated = true
+            return nil
+        }
+        listing.Count++
+        listing.Size += header.Size
+        listing.Entries = append(listing.Entries, entry)
+    }
+}
+
+// zipEntry describes a file of a zip archive
+func zipEntry(file *zip.File) Entry {
+    entry := Entry{
+        Name:           file.Name,
+        Size:           int64(file.UncompressedSize64),
+        CompressedSize: int64(file.CompressedSize64),
+        Ratio:          ratio(int64(file.UncompressedSize64), int64(file.CompressedSize64)),
+        Dir:            file.FileInfo().IsDir(),
+    }
+    if modified := file.Modified; !modified.IsZero() {
+        modified = modified.UTC()
+        entry.Modified = &modified
+    }
+    return entry
+}
+
+// tarEntry describes a tar entry, and whether it is a file, directory or
+// link at all. Global headers, devices and FIFOs are not.
+func tarEntry(header *tar.Header) (Entry, bool) {
+    entry := Entry{Name: header.Name, Size: header.Size}
+    switch header.Typeflag {
+    case tar.TypeReg, tar.TypeRegA, tar.TypeGNUSparse:
+    case tar.TypeDir:
+        entry.Dir = true
+    case tar.TypeSymlink, tar.TypeLink:
+        entry.Link = header.Linkname
+    default:
+        return Entry{}, false
+    }
+    if !header.ModTime.IsZero() {
+        modified := header.ModTime.UTC()
+        entry.Modified = &modified
+    }
+    return entry, true
+}
+
+// ratio returns size over compressed, or 0 when nothing was stored
+func ratio(size, compressed int64) float64 {
+    if compressed <= 0 {
+        return 0
+    }
+    return float64(size) / float64(compressed)
+}
+
+// mediaType strips parameters from a content type
+func mediaType(contentType string) string {
+    if parsed, _, err := mime.ParseMediaType(contentType); err == nil {
+        return parsed
+    }
+    return strings.ToLower(strings.TrimSpace(contentType))
+}
@@ -0,0 +1,129 @@
+package archivelist
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bufio"
+    "bytes"
+    "compress/gzip"
+    "fmt"
+    "io"
+    "io/fs"
+)
+
+// maxLinkSize bounds the content read as the target of a zip symbolic link
+const maxLinkSize = 4096
+
+// Reader reads the entries of an archive one at a time with their content,
+// in the order they are stored
+type Reader struct {
+    zip   *zip.Reader
+    next  int
+    tar   *tar.Reader
+    gzip  *gzip.Reader
+    entry io.ReadCloser
+}
+
+// NewReader creates a reader of the archive of format in r, size bytes long.
+// Zip archives are opened from their central directory; tar archives are
+// read from their start as entries are.
+func NewReader(format string, r io.ReaderAt, size int64) (*Reader, error) {
+    switch format {
+    case FormatZip:
+        archive, err := zip.NewReader(r, size)
+        if err != nil {
+            return nil, fmt.Errorf("failed to read zip directory: %w", err)
+        }
+        return &Reader{zip: archive}, nil
+    case FormatTar:
+        return &Reader{tar: tar.NewReader(bufio.NewReaderSize(io.NewSectionReader(r, 0, size), 1<<20))}, nil
+    case FormatTarGzip:
+        gz, err := gzip.NewReader(bufio.NewReaderSize(io.NewSectionReader(r, 0, size), 1<<20))
+        if err != nil {
+            return nil, fmt.Errorf("failed to read gzip header: %w", err)
+        }
+        return &Reader{tar: tar.NewReader(gz), gzip: gz}, nil
+    default:
+        return nil, ErrUnsupportedFormat
+    }
+}
+
+// Count returns the number of entries in the archive and their uncompressed
+// size, as zip archives record them in their directory; for tar archives
+// they are unknown until read, and Count returns -1
+func (r *Reader) Count() (int, int64) {
+    if r.zip == nil {
+        return -1, 0
+    }
+    var size int64
+    for _, file := range r.zip.File {
+        size += int64(file.UncompressedSize64)
+    }
+    return len(r.zip.File), size
+}
+
+// Next returns the next entry with its content, which is valid until the
+// following call; it returns io.EOF after the last entry. Tar devices and
+// FIFOs are skipped.
+func (r *Reader) Next() (Entry, io.Reader, error) {
+    if r.entry != nil {
+        r.entry.Close()
+        r.entry = nil
+    }
+    if r.zip != nil {
+        return r.nextZip()
+    }
+
+    for {
+        header, err := r.tar.Next()
+        if err == io.EOF {
+            return Entry{}, nil, io.EOF
+        }
+        if err != nil {
+            return Entry{}, nil, fmt.Errorf("failed to read tar header: %w", err)
+        }
+        if entry, ok := tarEntry(header); ok {
+            return entry, r.tar, nil
+        }
+    }
+}
+
+// nextZip opens the next file of a zip archive, reading symbolic links'
+// targets from their content
+func (r *Reader) nextZip() (Entry, io.Reader, error) {
+    if r.next >= len(r.zip.File) {
+        return Entry{}, nil, io.EOF
+    }
+    file := r.zip.File[r.next]
+    r.next++
+
+    entry := zipEntry(file)
+    content, err := file.Open()
+    if err != nil {
+        return Entry{}, nil, fmt.Errorf("failed to open zip entry %s: %w", file.Name, err)
+    }
+    if file.Mode()&fs.ModeSymlink == 0 {
+        r.entry = content
+        return entry, content, nil
+    }
+
+    defer content.Close()
+    target, err := io.ReadAll(io.LimitReader(content, maxLinkSize))
+    if err != nil {
+        return Entry{}, nil, fmt.Errorf("failed to read zip entry %s: %w", file.Name, err)
+    }
+    entry.Link = string(target)
+    return entry, bytes.NewReader(nil), nil
+}
+
+// Close releases the reader; it does not close the underlying archive
+func (r *Reader) Close() error {
+    if r.entry != nil {
+        r.entry.Close()
+        r.entry = nil
+    }
+    if r.gzip != nil {
+        return r.gzip.Close()
+    }
+    return nil
+}
@@ -0,0 +1,214 @@
+// Package auditlog seals audit events into hash-chained, signed batches and
+// verifies them. Every batch carries the hash of the batch before it and
+// numbers its events contiguously with the previous batch, so a removed,
+// reordered or edited batch breaks the chain, and the Ed25519 signature over
+// each batch's hash shows it was written by the service. Batches are stored
+// as JSON objects whose keys sort in chain order.
+package auditlog
+
+import (
+    "crypto/ed25519"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// FormatVersion is the batch format written by Seal
+const FormatVersion = 1
+
+// Errors reported by Verifier.Check
+var (
+    // ErrTampered is returned for a batch whose content, hash or signature
+    // does not match
+    ErrTampered = errors.New("audit batch tampered")
+    // ErrGap is returned when batches or events are missing from the chain
+    ErrGap = errors.New("audit chain gap")
+)
+
+// Event is one audited occurrence. Seq numbers events contiguously across
+// the whole chain.
+type Event struct {
+    Seq    int64             `json:"seq"`
+    Type   string            `json:"type"`
+    FileID string            `json:"fileId,omitempty"`
+    Detail map[string]string `json:"detail,omitempty"`
+    At     time.Time         `json:"at"`
+}
+
+// Batch is a sealed run of events. Hash is the SHA-256 of the batch encoded
+// without Hash and Signature; Signature signs Hash.
+type Batch struct {
+    Version   int     `json:"version"`
+    Number    int64   `json:"number"`
+    FirstSeq  int64   `json:"firstSeq"`
+    LastSeq   int64   `json:"lastSeq"`
+    PrevHash  string  `json:"prevHash"`
+    Events    []Event `json:"events"`
+    Hash      string  `json:"hash,omitempty"`
+    Signature string  `json:"signature,omitempty"`
+}
+
+// Head identifies the last batch of a chain, from which the next is sealed.
+// The zero Head starts a new chain.
+type Head struct {
+    Number  int64
+    LastSeq int64
+    Hash    string
+}
+
+// Next returns the head after b
+func (b *Batch) Next() Head {
+    return Head{Number: b.Number, LastSeq: b.LastSeq, Hash: b.Hash}
+}
+
+// ObjectKey returns the storage key of batch number under prefix
+func ObjectKey(prefix string, number int64) string {
+    return fmt.Sprintf("%s%020d.json", prefix, number)
+}
+
+// Seal numbers events after head, chains them to it and signs the batch.
+// Sealing is deterministic, so sealing the same events after the same head
+// again yields an identical batch.
+func Seal(head Head, events []Event, key ed25519.PrivateKey) (*Batch, error) {
+    if len(events) == 0 {
+        return nil, errors.New("audit batch has no events")
+    }
+
+    sealed := make([]Event, len(events))
+    for i, event := range events {
+        event.Seq = head.LastSeq + int64(i) + 1
+        event.At = event.At.UTC()
+        sealed[i] = event
+    }
+
+    batch := &Batch{
+        Version:  FormatVersion,
+        Number:   head.Number + 1,
+        FirstSeq: head.LastSeq + 1,
+        LastSeq:  head.LastSeq + int64(len(events)),
+        PrevHash: head.Hash,
+        Events:   sealed,
+    }
+    digest, err := batch.digest()
+    if err != nil {
+        return nil, err
+    }
+    batch.Hash = hex.EncodeToString(digest)
+    batch.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, digest))
+    return batch, nil
+}
+
+// digest hashes the batch encoded without its hash and signature
+func (b *Batch) digest() ([]byte, error) {
+    unsigned := *b
+    unsigned.Hash = ""
+    unsigned.Signature = ""
+    data, err := json.Marshal(unsigned)
+    if err != nil {
+        return nil, err
+    }
+    sum := sha256.Sum256(data)
+    return sum[:], nil
+}
+
+// Verifier checks a chain one batch at a time, in order
+type Verifier struct {
+    key  ed25519.PublicKey
+    head *Head
+}
+
+// NewVerifier creates a Verifier for batches signed with the private half of key
+func NewVerifier(key ed25519.PublicKey) *Verifier {
+    return &Verifier{key: key}
+}
+
+// Start makes the next batch checked continue from head instead of
+// starting a new chain, to verify a chain from the middle
+func (v *Verifier) Start(head Head) {
+    v.head = &head
+}
+
+// Check verifies batch and its link to the batch checked before it. After a
+// failure the chain continues from batch, so later batches are checked
+// against it and each break is reported once.
+func (v *Verifier) Check(batch *Batch) error {
+    err := v.check(batch)
+    head := batch.Next()
+    v.head = &head
+    return err
+}
+
+func (v *Verifier) check(batch *Batch) error {
+    if batch.Version != FormatVersion {
+        return fmt.Errorf("%w: batch %d has unsupported version %d", ErrTampered, batch.Number, batch.Version)
+    }
+
+    digest, err := batch.digest()
+    if err != nil {
+        return err
+    }
+    if !strings.EqualFold(hex.EncodeToString(digest), batch.Hash) {
+        return fmt.Errorf("%w: batch %d content does not match its hash", ErrTampered, batch.Number)
+    }
+    signature, err := base64.StdEncoding.DecodeString(batch.Signature)
+    if err != nil || !ed25519.Verify(v.key, digest, signature) {
+        return fmt.Errorf("%w: batch %d signature is invalid", ErrTampered, batch.Number)
+    }
+
+    if len(batch.Events) == 0 || batch.LastSeq-batch.FirstSeq+1 != int64(len(batch.Events)) {
+        return fmt.Errorf("%w: batch %d event range does not match its events", ErrTampered, batch.Number)
+    }
+    for i, event := range batch.Events {
+        if event.Seq != batch.FirstSeq+int64(i) {
+            return fmt.Errorf("%w: batch %d event %d is out of sequence", ErrTampered, batch.Number, event.Seq)
+        }
+    }
+
+    prev := Head{}
+    if v.head != nil {
+        prev = *v.head
+    }
+    if batch.Number != prev.Number+1 {
+        return fmt.Errorf("%w: expected batch %d, found batch %d", ErrGap, prev.Number+1, batch.Number)
+    }
+    if batch.FirstSeq != prev.LastSeq+1 {
+        return fmt.Errorf("%w: batch %d starts at event %d, expected %d", ErrGap, batch.Number, batch.FirstSeq, prev.LastSeq+1)
+    }
+    if batch.PrevHash != prev.Hash {
+        return fmt.Errorf("%w: batch %d does not chain to batch %d", ErrTampered, batch.Number, prev.Number)
+    }
+    return nil
+}
+
+// ParsePrivateKey decodes a base64 Ed25519 seed or private key
+func ParsePrivateKey(encoded string) (ed25519.PrivateKey, error) {
+    data, err := base64.StdEncoding.DecodeString(encoded)
+    if err != nil {
+        return nil, fmt.Errorf("invalid audit signing key: %w", err)
+    }
+    switch len(data) {
+    case ed25519.SeedSize:
+        return ed25519.NewKeyFromSeed(data), nil
+    case ed25519.PrivateKeySize:
+        return ed25519.PrivateKey(data), nil
+    default:
+        return nil, fmt.Errorf("invalid audit signing key: %d bytes", len(data))
+    }
+}
+
+// ParsePublicKey decodes a base64 Ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+    data, err := base64.StdEncoding.DecodeString(encoded)
+    if err != nil {
+        return nil, fmt.Errorf("invalid audit verify key: %w", err)
+    }
+    if len(data) != ed25519.PublicKeySize {
+        return nil, fmt.Errorf("invalid audit verify key: %d bytes", len(data))
+    }
+    return ed25519.PublicKey(data), nil
+}
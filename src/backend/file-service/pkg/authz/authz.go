@@ -0,0 +1,213 @@
+// Package authz delegates file access decisions to an embedded Open Policy
+// Agent engine. Policies are Rego modules, either the bundled policy or the
+// modules of a configured directory, and every decision is logged and
+// counted so security teams can audit what the policy allowed and why.
+package authz
+
+import (
+    "context"
+    "embed"
+    "encoding/json"
+    "fmt"
+    "io/fs"
+    "os"
+    "path"
+    "strings"
+
+    "github.com/open-policy-agent/opa/rego"          // v0.57.0
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+    "go.uber.org/zap"                                // v1.24.0
+)
+
+// Authorization modes
+const (
+    // ModeRoles keeps the built-in role checks of each route
+    ModeRoles = "roles"
+    // ModeOPA asks the policy engine for every file access decision
+    ModeOPA = "opa"
+)
+
+// Actions decided by the policy
+const (
+    ActionDownload = "download"
+    ActionDelete   = "delete"
+    ActionShare    = "share"
+)
+
+// DecisionQuery is the Rego rule evaluated for every decision. It must
+// produce an object with a boolean allow and a string reason.
+const DecisionQuery = "data.fileservice.authz.decision"
+
+// Decision results, labelling authz_decisions_total
+const (
+    resultAllow = "allow"
+    resultDeny  = "deny"
+    resultError = "error"
+)
+
+// bundledPolicies holds the policy used when no policy directory is configured
+//
+//go:embed policies/*.rego
+var bundledPolicies embed.FS
+
+// decisions counts policy decisions by action and result
+var decisions = prometheus.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: "authz_decisions_total",
+        Help: "Number of authorization policy decisions by action and result",
+    },
+    []string{"action", "result"},
+)
+
+// Collectors returns the authorization instruments for registration
+func Collectors() []prometheus.Collector {
+    return []prometheus.Collector{decisions}
+}
+
+// Subject is the authenticated caller
+type Subject struct {
+    ID       string   `json:"id"`
+    Roles    []string `json:"roles"`
+    TenantID string   `json:"tenantId,omitempty"`
+    SPIFFEID string   `json:"spiffeId,omitempty"`
+}
+
+// Resource is the file an action applies to, in any status. Files that
+// cannot be looked up are never decided on.
+type Resource struct {
+    ID          string `json:"id"`
+    OwnerID     string `json:"ownerId"`
+    Folder      string `json:"folder,omitempty"`
+    ContentType string `json:"contentType,omitempty"`
+    Size        int64  `json:"size"`
+}
+
+// Input is the document a decision is made on
+type Input struct {
+    Action     string            `json:"action"`
+    Subject    Subject           `json:"subject"`
+    Resource   Resource          `json:"resource"`
+    Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Decision is the policy's verdict with the reason it gave
+type Decision struct {
+    Allow  bool   `json:"allow"`
+    Reason string `json:"reason"`
+}
+
+// Authorizer decides whether a subject may perform an action on a resource
+type Authorizer interface {
+    Decide(ctx context.Context, input Input) (Decision, error)
+}
+
+// OPA evaluates decisions with an embedded policy engine
+type OPA struct {
+    query  rego.PreparedEvalQuery
+    source string
+    log    *zap.Logger
+}
+
+// NewOPA compiles the Rego modules in policyDir, or the bundled policy if
+// policyDir is empty, and prepares DecisionQuery for evaluation
+func NewOPA(ctx context.Context, policyDir string) (*OPA, error) {
+    var policies fs.FS = bundledPolicies
+    source := "bundled"
+    if policyDir != "" {
+        policies = os.DirFS(policyDir)
+        source = policyDir
+    }
+
+    modules, err := loadModules(policies)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load policies from %s: %w", source, err)
+    }
+    if len(modules) == 0 {
+        return nil, fmt.Errorf("no Rego policies found in %s", source)
+    }
+
+    opts := []func(*rego.Rego){rego.Query(DecisionQuery)}
+    for name, module := range modules {
+        opts = append(opts, rego.Module(name, module))
+    }
+    query, err := rego.New(opts...).PrepareForEval(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to compile policies from %s: %w", source, err)
+    }
+
+    return &OPA{
+        query:  query,
+        source: source,
+        log:    zap.L().Named("authz"),
+    }, nil
+}
+
+// loadModules reads every .rego file under policies, keyed by path
+func loadModules(policies fs.FS) (map[string]string, error) {
+    modules := make(map[string]string)
+    err := fs.WalkDir(policies, ".", func(name string, entry fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if entry.IsDir() || path.Ext(name) != ".rego" || strings.HasSuffix(name, "_test.rego") {
+            return nil
+        }
+        data, err := fs.ReadFile(policies, name)
+        if err != nil {
+            return err
+        }
+        modules[name] = string(data)
+        return nil
+    })
+    return modules, err
+}
+
+// Decide evaluates the policy for input. An undefined decision denies.
+func (o *OPA) Decide(ctx context.Context, input Input) (Decision, error) {
+    decision, err := o.evaluate(ctx, input)
+    if err != nil {
+        decisions.WithLabelValues(input.Action, resultError).Inc()
+        o.log.Error("Authorization policy evaluation failed",
+            zap.String("action", input.Action),
+            zap.String("subject", input.Subject.ID),
+            zap.String("resource", input.Resource.ID),
+            zap.Error(err))
+        return Decision{}, err
+    }
+
+    result := resultDeny
+    if decision.Allow {
+        result = resultAllow
+    }
+    decisions.WithLabelValues(input.Action, result).Inc()
+    o.log.Info("Authorization decision",
+        zap.String("action", input.Action),
+        zap.String("subject", input.Subject.ID),
+        zap.Strings("roles", input.Subject.Roles),
+        zap.String("resource", input.Resource.ID),
+        zap.Bool("allow", decision.Allow),
+        zap.String("reason", decision.Reason),
+        zap.String("policy", o.source))
+
+    return decision, nil
+}
+
+func (o *OPA) evaluate(ctx context.Context, input Input) (Decision, error) {
+    results, err := o.query.Eval(ctx, rego.EvalInput(input))
+    if err != nil {
+        return Decision{}, fmt.Errorf("policy evaluation failed: %w", err)
+    }
+    if len(results) == 0 || len(results[0].Expressions) == 0 {
+        return Decision{Reason: "policy made no decision"}, nil
+    }
+
+    data, err := json.Marshal(results[0].Expressions[0].Value)
+    if err != nil {
+        return Decision{}, err
+    }
+    var decision Decision
+    if err := json.Unmarshal(data, &decision); err != nil {
+        return Decision{}, fmt.Errorf("policy decision is not an {allow, reason} object: %w", err)
+    }
+    return decision, nil
+}
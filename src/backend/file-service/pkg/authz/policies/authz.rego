@@ -0,0 +1,35 @@
+# Bundled file service authorization policy, used when APP_AUTHZ_MODE=opa and
+# no policy directory is configured.
+#
+# Input:
+#   action      "download", "delete" or "share"
+#   subject     {id, roles, tenantId, spiffeId}
+#   resource    {id, ownerId, folder, contentType, size}
+#   attributes  action-specific values, e.g. sharedWith for "share" and
+#               shared="true" for "download" by an active share's recipient
+#
+# The decision is an object {allow, reason}; the reason is logged with every
+# decision.
+package fileservice.authz
+
+import future.keywords.if
+import future.keywords.in
+
+default decision := {"allow": false, "reason": "no rule allows this action"}
+
+# Administrators may do anything
+decision := {"allow": true, "reason": "administrator"} if {
+	"admin" in input.subject.roles
+} else := {"allow": true, "reason": "file owner"} if {
+	input.action in {"download", "delete", "share"}
+	input.resource.ownerId != ""
+	input.resource.ownerId == input.subject.id
+} else := {"allow": true, "reason": "shared with subject"} if {
+	# Revoked shares, e.g. by the access review, no longer grant downloads
+	input.action == "download"
+	input.attributes.shared == "true"
+} else := {"allow": true, "reason": "file without owner"} if {
+	# Files uploaded before owners were recorded stay accessible
+	input.action in {"download", "delete"}
+	input.resource.ownerId == ""
+}
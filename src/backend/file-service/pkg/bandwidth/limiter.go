@@ -0,0 +1,81 @@
+package bandwidth
+
+import (
+    "context"
+    "sync"
+    "time"
+)
+
+// limiter is a token bucket whose rate can be changed while readers wait on it.
+// Waiters reserve tokens up front and may drive the bucket into debt, which
+// later waiters pay off at the current rate.
+type limiter struct {
+    mu     sync.Mutex
+    rate   float64 // bytes per second
+    burst  float64
+    tokens float64
+    last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+    return &limiter{
+        rate:   rate,
+        burst:  float64(burst),
+        tokens: float64(burst),
+        last:   time.Now(),
+    }
+}
+
+// setRate changes the refill rate, crediting tokens earned at the old rate first
+func (l *limiter) setRate(rate float64) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.advance(time.Now())
+    l.rate = rate
+}
+
+// wait blocks until n bytes may be transferred or ctx is done
+func (l *limiter) wait(ctx context.Context, n int) error {
+    l.mu.Lock()
+    now := time.Now()
+    l.advance(now)
+    l.tokens -= float64(n)
+    var delay time.Duration
+    if l.tokens < 0 {
+        if l.rate <= 0 {
+            // No share allocated yet; retry once the scheduler rebalances
+            delay = time.Second
+        } else {
+            delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+        }
+    }
+    l.mu.Unlock()
+
+    if delay == 0 {
+        return nil
+    }
+
+    timer := time.NewTimer(delay)
+    defer timer.Stop()
+    select {
+    case <-timer.C:
+        return nil
+    case <-ctx.Done():
+        // Return the unused reservation
+        l.mu.Lock()
+        l.tokens += float64(n)
+        l.mu.Unlock()
+        return ctx.Err()
+    }
+}
+
+// advance refills the bucket up to burst; callers hold mu
+func (l *limiter) advance(now time.Time) {
+    if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+        l.tokens += elapsed * l.rate
+        if l.tokens > l.burst {
+            l.tokens = l.burst
+        }
+    }
+    l.last = now
+}
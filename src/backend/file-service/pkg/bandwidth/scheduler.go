@@ -0,0 +1,298 @@
+// Package bandwidth shares an aggregate transfer budget fairly between users
+package bandwidth
+
+import (
+    "context"
+    "errors"
+    "io"
+    "math"
+    "sort"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+
+    "src/backend/file-service/pkg/tracing"
+)
+
+const (
+    // saturation is the fraction of its allocation a user must use to be
+    // considered limited by the scheduler rather than by its own client
+    saturation = 0.9
+    // headroom lets an unsaturated user grow by this factor per rebalance
+    headroom = 1.5
+)
+
+// Scheduler divides an aggregate bandwidth budget between users with active
+// transfers using max-min fairness: every user is entitled to an equal share,
+// and share that a user leaves unused, for example because its client is
+// slower, is redistributed to the others on the next rebalance. A user's
+// concurrent transfers draw on the same share.
+type Scheduler struct {
+    mu    sync.Mutex
+    total float64
+    burst int
+    users map[string]*user
+    last  time.Time
+
+    activeUsers prometheus.Gauge
+    fairShare   prometheus.Gauge
+    waitTime    prometheus.Histogram
+    transferred prometheus.Counter
+    fairness    prometheus.GaugeFunc
+}
+
+// user is the bandwidth state of one user with active transfers
+type user struct {
+    limiter   *limiter
+    streams   int
+    bytes     int64   // transferred since the last rebalance, updated atomically
+    demand    float64 // estimated rate the user could use
+    rate      float64 // rate measured over the last rebalance interval
+    allocated float64
+    joined    time.Time
+}
+
+// NewScheduler creates a scheduler sharing bytesPerSecond between users.
+// burst bounds how many bytes a single read may transfer without waiting.
+func NewScheduler(bytesPerSecond int64, burst int) (*Scheduler, error) {
+    if bytesPerSecond <= 0 {
+        return nil, errors.New("aggregate bandwidth must be positive")
+    }
+    if burst <= 0 {
+        return nil, errors.New("burst size must be positive")
+    }
+
+    s := &Scheduler{
+        total: float64(bytesPerSecond),
+        burst: burst,
+        users: make(map[string]*user),
+        last:  time.Now(),
+        activeUsers: prometheus.NewGauge(prometheus.GaugeOpts{
+            Name: "upload_bandwidth_active_users",
+            Help: "Number of users with throttled uploads in progress",
+        }),
+        fairShare: prometheus.NewGauge(prometheus.GaugeOpts{
+            Name: "upload_bandwidth_fair_share_bytes",
+            Help: "Equal per-user share of the aggregate upload bandwidth in bytes per second",
+        }),
+        waitTime: prometheus.NewHistogram(prometheus.HistogramOpts{
+            Name:                            "upload_bandwidth_throttle_wait_seconds",
+            Help:                            "Time reads spent waiting for bandwidth",
+            Buckets:                         prometheus.DefBuckets,
+            NativeHistogramBucketFactor:     1.1,
+            NativeHistogramMaxBucketNumber:  160,
+            NativeHistogramMinResetDuration: time.Hour,
+        }),
+        transferred: prometheus.NewCounter(prometheus.CounterOpts{
+            Name: "upload_bandwidth_transferred_bytes_total",
+            Help: "Bytes transferred through the bandwidth scheduler",
+        }),
+    }
+    s.fairness = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+        Name: "upload_bandwidth_fairness_index",
+        Help: "Jain's fairness index of the throughput of users limited by the scheduler (1 is perfectly fair)",
+    }, s.FairnessIndex)
+
+    return s, nil
+}
+
+// Register registers the scheduler's metrics
+func (s *Scheduler) Register(reg prometheus.Registerer) error {
+    for _, c := range []prometheus.Collector{s.activeUsers, s.fairShare, s.waitTime, s.transferred, s.fairness} {
+        if err := reg.Register(c); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Reader returns a reader that paces reads from r against userID's share.
+// The returned reader must be closed to release the share.
+func (s *Scheduler) Reader(ctx context.Context, userID string, r io.Reader) io.ReadCloser {
+    return &reader{
+        scheduler: s,
+        ctx:       ctx,
+        userID:    userID,
+        user:      s.acquire(userID),
+        reader:    r,
+    }
+}
+
+// Allocation returns the bandwidth currently allocated to userID in bytes
+// per second, or 0 when the user has no active transfers
+func (s *Scheduler) Allocation(userID string) int64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if u, ok := s.users[userID]; ok {
+        return int64(u.allocated)
+    }
+    return 0
+}
+
+// FairnessIndex returns Jain's fairness index over the measured throughput of
+// the users the scheduler is limiting. Users held back by their own clients
+// are excluded, since their lower throughput is not unfairness.
+func (s *Scheduler) FairnessIndex() float64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    var sum, squares float64
+    n := 0
+    for _, u := range s.users {
+        if !math.IsInf(u.demand, 1) || u.rate == 0 {
+            continue
+        }
+        sum += u.rate
+        squares += u.rate * u.rate
+        n++
+    }
+    if n == 0 || squares == 0 {
+        return 1
+    }
+    return sum * sum / (float64(n) * squares)
+}
+
+// Run periodically measures each user's throughput and redistributes unused
+// share until ctx is done
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            s.mu.Lock()
+            s.sample()
+            s.allocate()
+            s.mu.Unlock()
+        }
+    }
+}
+
+// acquire registers a transfer for userID and rebalances the shares
+func (s *Scheduler) acquire(userID string) *user {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    u, ok := s.users[userID]
+    if !ok {
+        u = &user{
+            limiter: newLimiter(0, s.burst),
+            demand:  math.Inf(1),
+            joined:  time.Now(),
+        }
+        s.users[userID] = u
+    }
+    u.streams++
+    s.allocate()
+    return u
+}
+
+// release unregisters a transfer for userID and rebalances the shares
+func (s *Scheduler) release(userID string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    u, ok := s.users[userID]
+    if !ok {
+        return
+    }
+    u.streams--
+    if u.streams <= 0 {
+        delete(s.users, userID)
+    }
+    s.allocate()
+}
+
+// sample measures throughput since the last sample and updates each user's
+// demand; callers hold mu
+func (s *Scheduler) sample() {
+    now := time.Now()
+    since := s.last
+    elapsed := now.Sub(since).Seconds()
+    s.last = now
+    if elapsed <= 0 {
+        return
+    }
+
+    for _, u := range s.users {
+        u.rate = float64(atomic.SwapInt64(&u.bytes, 0)) / elapsed
+        // Users that joined mid-interval have not had a full interval to ramp up
+        if u.joined.After(since) {
+            u.demand = math.Inf(1)
+            continue
+        }
+        if u.allocated > 0 && u.rate < u.allocated*saturation {
+            u.demand = math.Max(u.rate*headroom, float64(s.burst))
+        } else {
+            u.demand = math.Inf(1)
+        }
+    }
+}
+
+// allocate water-fills the aggregate budget: users are served in order of
+// increasing demand, each receiving the smaller of its demand and an equal
+// split of what remains; callers hold mu
+func (s *Scheduler) allocate() {
+    users := make([]*user, 0, len(s.users))
+    for _, u := range s.users {
+        users = append(users, u)
+    }
+    sort.Slice(users, func(i, j int) bool { return users[i].demand < users[j].demand })
+
+    remaining := s.total
+    for i, u := range users {
+        share := math.Min(u.demand, remaining/float64(len(users)-i))
+        u.allocated = share
+        u.limiter.setRate(share)
+        remaining -= share
+    }
+
+    s.activeUsers.Set(float64(len(users)))
+    if len(users) > 0 {
+        s.fairShare.Set(s.total / float64(len(users)))
+    } else {
+        s.fairShare.Set(0)
+    }
+}
+
+// reader paces reads against a user's share
+type reader struct {
+    scheduler *Scheduler
+    ctx       context.Context
+    userID    string
+    user      *user
+    reader    io.Reader
+    closeOnce sync.Once
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+    if len(p) > r.scheduler.burst {
+        p = p[:r.scheduler.burst]
+    }
+
+    n, err := r.reader.Read(p)
+    if n > 0 {
+        atomic.AddInt64(&r.user.bytes, int64(n))
+        r.scheduler.transferred.Add(float64(n))
+
+        start := time.Now()
+        if waitErr := r.user.limiter.wait(r.ctx, n); waitErr != nil {
+            return n, waitErr
+        }
+        tracing.Observe(r.ctx, r.scheduler.waitTime, time.Since(start).Seconds())
+    }
+    return n, err
+}
+
+// Close releases the user's share; it does not close the underlying reader
+func (r *reader) Close() error {
+    r.closeOnce.Do(func() {
+        r.scheduler.release(r.userID)
+    })
+    return nil
+}
@@ -0,0 +1,48 @@
+// Package buildinfo carries the version details embedded at build time with
+//
+//	go build -ldflags "-X src/backend/file-service/pkg/buildinfo.Version=1.2.3 \
+//	    -X src/backend/file-service/pkg/buildinfo.GitSHA=$(git rev-parse HEAD) \
+//	    -X src/backend/file-service/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+    "runtime"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+)
+
+// Set via -ldflags at build time
+var (
+    Version   = "dev"
+    GitSHA    = "unknown"
+    BuildTime = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+    Version   string `json:"version"`
+    GitSHA    string `json:"gitSha"`
+    BuildTime string `json:"buildTime"`
+    GoVersion string `json:"goVersion"`
+}
+
+// Get returns the running build's details
+func Get() Info {
+    return Info{
+        Version:   Version,
+        GitSHA:    GitSHA,
+        BuildTime: BuildTime,
+        GoVersion: runtime.Version(),
+    }
+}
+
+// NewCollector returns a build_info gauge, always 1, labelled with the build details
+func NewCollector() prometheus.Collector {
+    info := Get()
+    gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+        Name: "build_info",
+        Help: "Build details of the running service, always 1",
+    }, []string{"version", "git_sha", "build_time", "go_version"})
+    gauge.WithLabelValues(info.Version, info.GitSHA, info.BuildTime, info.GoVersion).Set(1)
+    return gauge
+}
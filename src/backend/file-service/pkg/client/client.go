@@ -0,0 +1,209 @@
+// Package client is a Go client for the file service API. Downloads and
+// uploads resume where they left off after network failures: downloads with
+// byte ranges validated against the file's checksum, uploads through the
+// chunked upload session protocol. Resume state is kept next to the local
+// file, so a later call with the same arguments continues an interrupted
+// transfer even from another process.
+package client
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// Defaults applied by New
+const (
+    defaultRetries = 5
+    defaultBackoff = time.Second
+    maxBackoff     = 30 * time.Second
+)
+
+// apiPrefix is the path prefix of the API version the client speaks
+const apiPrefix = "/api/v1"
+
+// ErrChecksumMismatch is returned when transferred content does not match
+// the checksum the service reports for it
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// APIError is an error response from the service
+type APIError struct {
+    StatusCode int
+    Message    string
+}
+
+func (e *APIError) Error() string {
+    return fmt.Sprintf("file service returned %d: %s", e.StatusCode, e.Message)
+}
+
+// File is the metadata of a stored file
+type File struct {
+    ID          string    `json:"id"`
+    FileName    string    `json:"fileName"`
+    Folder      string    `json:"folder"`
+    Size        int64     `json:"size"`
+    ContentType string    `json:"contentType"`
+    Status      string    `json:"status"`
+    Checksum    string    `json:"checksum"`
+    Version     int64     `json:"version"`
+    CreatedAt   time.Time `json:"createdAt"`
+    UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ProgressFunc is called as a transfer advances with the bytes transferred so
+// far, including bytes transferred before resuming, and the total size
+type ProgressFunc func(done, total int64)
+
+// Client calls the file service API
+type Client struct {
+    baseURL string
+    http    *http.Client
+    token   string
+    retries int
+    backoff time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+    return func(c *Client) {
+        c.http = httpClient
+    }
+}
+
+// WithToken authenticates requests with a bearer token
+func WithToken(token string) Option {
+    return func(c *Client) {
+        c.token = token
+    }
+}
+
+// WithRetries sets how many times a failed request is retried and the
+// initial delay between attempts, which doubles after each failure
+func WithRetries(retries int, backoff time.Duration) Option {
+    return func(c *Client) {
+        c.retries = retries
+        c.backoff = backoff
+    }
+}
+
+// New creates a Client for the service at baseURL
+func New(baseURL string, opts ...Option) *Client {
+    c := &Client{
+        baseURL: strings.TrimRight(baseURL, "/"),
+        http:    http.DefaultClient,
+        retries: defaultRetries,
+        backoff: defaultBackoff,
+    }
+    for _, opt := range opts {
+        opt(c)
+    }
+    return c
+}
+
+// newRequest creates an authenticated request for path of the versioned API
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+    req, err := http.NewRequestWithContext(ctx, method, c.baseURL+apiPrefix+path, body)
+    if err != nil {
+        return nil, err
+    }
+    if c.token != "" {
+        req.Header.Set("Authorization", "Bearer "+c.token)
+    }
+    return req, nil
+}
+
+// doJSON sends req and decodes a successful JSON response into out
+func (c *Client) doJSON(req *http.Request, out interface{}) error {
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return readAPIError(resp)
+    }
+    if out == nil {
+        return nil
+    }
+    return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// readAPIError builds an APIError from an error response
+func readAPIError(resp *http.Response) error {
+    var body struct {
+        Error string `json:"error"`
+    }
+    data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+    if json.Unmarshal(data, &body) != nil || body.Error == "" {
+        body.Error = strings.TrimSpace(string(data))
+    }
+    return &APIError{StatusCode: resp.StatusCode, Message: body.Error}
+}
+
+// retry calls fn until it succeeds, fails with an error that is not worth
+// retrying, or the retries are used up
+func (c *Client) retry(ctx context.Context, fn func() error) error {
+    backoff := c.backoff
+    for attempt := 0; ; attempt++ {
+        err := fn()
+        if err == nil || attempt >= c.retries || !retryable(ctx, err) {
+            return err
+        }
+
+        select {
+        case <-ctx.Done():
+            return ctx.Err()
+        case <-time.After(backoff):
+        }
+        if backoff *= 2; backoff > maxBackoff {
+            backoff = maxBackoff
+        }
+    }
+}
+
+// retryable reports whether err is transient: a network failure, a truncated
+// response, a server error or throttling response, or a discarded partial
+// download
+func retryable(ctx context.Context, err error) bool {
+    if ctx.Err() != nil {
+        return false
+    }
+    if errors.Is(err, errRestart) {
+        return true
+    }
+
+    var apiErr *APIError
+    if errors.As(err, &apiErr) {
+        return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+    }
+
+    var netErr net.Error
+    return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// progressWriter reports progress as bytes are written through it
+type progressWriter struct {
+    w        io.Writer
+    done     int64
+    total    int64
+    progress ProgressFunc
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+    n, err := p.w.Write(b)
+    p.done += int64(n)
+    if p.progress != nil {
+        p.progress(p.done, p.total)
+    }
+    return n, err
+}
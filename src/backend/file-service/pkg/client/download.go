@@ -0,0 +1,208 @@
+package client
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "strings"
+)
+
+const (
+    // partSuffix names the file holding a download in progress
+    partSuffix = ".part"
+    // stateSuffix names the resume state stored next to a partial transfer
+    stateSuffix = ".json"
+
+    checksumHeader     = "X-Checksum-Sha256"
+    checksumTypeHeader = "X-Checksum-Type"
+    // fullObjectChecksum marks a checksum over the whole content; composite
+    // checksums of chunked uploads cannot be recomputed from the content alone
+    fullObjectChecksum = "FULL_OBJECT"
+)
+
+// errRestart reports that a partial download was discarded and must start over
+var errRestart = errors.New("partial download discarded, restarting")
+
+// downloadState is the resume state of a download. ETag identifies the
+// content the partial file holds, so a resumed request only continues it
+// while the file is unchanged.
+type downloadState struct {
+    FileID       string `json:"fileId"`
+    ETag         string `json:"etag"`
+    Size         int64  `json:"size"`
+    Checksum     string `json:"checksum"`
+    ChecksumType string `json:"checksumType"`
+}
+
+// Download fetches a file's content to path. An interrupted download leaves
+// path+".part" and its resume state behind, and calling Download again picks
+// up from the last byte received. The complete content is verified against
+// the file's checksum before it is moved to path.
+func (c *Client) Download(ctx context.Context, fileID, path string, progress ProgressFunc) error {
+    partPath := path + partSuffix
+    statePath := partPath + stateSuffix
+
+    err := c.retry(ctx, func() error {
+        return c.downloadOnce(ctx, fileID, partPath, statePath, progress)
+    })
+    if err != nil {
+        return err
+    }
+
+    var state downloadState
+    if err := loadState(statePath, &state); err != nil {
+        return err
+    }
+    if err := verifyDownload(partPath, state); err != nil {
+        // Corrupt content cannot be resumed, so the next attempt starts over
+        os.Remove(partPath)
+        os.Remove(statePath)
+        return err
+    }
+
+    if err := os.Rename(partPath, path); err != nil {
+        return err
+    }
+    os.Remove(statePath)
+    return nil
+}
+
+// downloadOnce requests the content still missing from the partial file and
+// appends it
+func (c *Client) downloadOnce(ctx context.Context, fileID, partPath, statePath string, progress ProgressFunc) error {
+    var state downloadState
+    if err := loadState(statePath, &state); err != nil && !errors.Is(err, os.ErrNotExist) {
+        return err
+    }
+
+    // Without a validator there is no way to tell whether the partial
+    // content is still current, so such downloads always restart
+    var offset int64
+    if state.FileID == fileID && state.ETag != "" {
+        if info, err := os.Stat(partPath); err == nil {
+            offset = info.Size()
+        }
+    }
+    if offset > 0 && offset == state.Size {
+        return nil
+    }
+
+    req, err := c.newRequest(ctx, http.MethodGet, "/download?id="+url.QueryEscape(fileID), nil)
+    if err != nil {
+        return err
+    }
+    if offset > 0 {
+        req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+        req.Header.Set("If-Range", state.ETag)
+    }
+
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    flags := os.O_CREATE | os.O_WRONLY
+    switch resp.StatusCode {
+    case http.StatusPartialContent:
+        start, _, err := parseContentRange(resp.Header.Get("Content-Range"))
+        if err != nil || start != offset {
+            return fmt.Errorf("unexpected content range %q", resp.Header.Get("Content-Range"))
+        }
+        flags |= os.O_APPEND
+    case http.StatusOK:
+        // The content changed since the partial download, or the service
+        // ignored the range: start from the beginning
+        offset = 0
+        flags |= os.O_TRUNC
+        state = downloadState{
+            FileID:       fileID,
+            ETag:         resp.Header.Get("ETag"),
+            Size:         resp.ContentLength,
+            Checksum:     resp.Header.Get(checksumHeader),
+            ChecksumType: resp.Header.Get(checksumTypeHeader),
+        }
+        if err := saveState(statePath, state); err != nil {
+            return err
+        }
+    case http.StatusRequestedRangeNotSatisfiable:
+        // The partial file no longer fits the content
+        os.Remove(partPath)
+        os.Remove(statePath)
+        return errRestart
+    default:
+        return readAPIError(resp)
+    }
+
+    f, err := os.OpenFile(partPath, flags, 0o644)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    w := &progressWriter{w: f, done: offset, total: state.Size, progress: progress}
+    if _, err := io.Copy(w, resp.Body); err != nil {
+        return err
+    }
+    return f.Sync()
+}
+
+// verifyDownload checks the downloaded content's size and, for whole-object
+// checksums, its SHA-256
+func verifyDownload(path string, state downloadState) error {
+    f, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    hash := sha256.New()
+    size, err := io.Copy(hash, f)
+    if err != nil {
+        return err
+    }
+    if state.Size >= 0 && size != state.Size {
+        return fmt.Errorf("%w: downloaded %d of %d bytes", ErrChecksumMismatch, size, state.Size)
+    }
+    if state.Checksum == "" || state.ChecksumType != fullObjectChecksum {
+        return nil
+    }
+    if !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), state.Checksum) {
+        return ErrChecksumMismatch
+    }
+    return nil
+}
+
+// parseContentRange parses a "bytes start-end/size" Content-Range header
+func parseContentRange(header string) (start, size int64, err error) {
+    var end int64
+    if _, err := fmt.Sscanf(header, "bytes %d-%d/%d", &start, &end, &size); err != nil {
+        return 0, 0, err
+    }
+    return start, size, nil
+}
+
+// loadState reads resume state saved by saveState
+func loadState(path string, v interface{}) error {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return err
+    }
+    return json.Unmarshal(data, v)
+}
+
+// saveState writes resume state next to the transfer it describes
+func saveState(path string, v interface{}) error {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, data, 0o600)
+}
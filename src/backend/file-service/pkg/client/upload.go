@@ -0,0 +1,234 @@
+package client
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+)
+
+const (
+    // uploadStateSuffix names the resume state stored next to an upload's
+    // source file
+    uploadStateSuffix = ".upload" + stateSuffix
+
+    chunkChecksumHeader = "X-Chunk-Checksum"
+    sessionStatusOpen   = "open"
+)
+
+// uploadState is the resume state of an upload. The source's size and
+// modification time guard against resuming a session with changed content.
+type uploadState struct {
+    SessionID string    `json:"sessionId"`
+    Size      int64     `json:"size"`
+    ModTime   time.Time `json:"modTime"`
+}
+
+// uploadSession is an upload session as returned by the service
+type uploadSession struct {
+    ID         string       `json:"id"`
+    FileID     string       `json:"fileId"`
+    TotalSize  int64        `json:"totalSize"`
+    ChunkSize  int64        `json:"chunkSize"`
+    ChunkCount int          `json:"chunkCount"`
+    Status     string       `json:"status"`
+    Parts      []uploadPart `json:"parts"`
+}
+
+type uploadPart struct {
+    Number   int    `json:"number"`
+    Size     int64  `json:"size"`
+    Checksum string `json:"checksum"`
+}
+
+// chunkLength returns the size of chunk n; only the last chunk is short
+func (s *uploadSession) chunkLength(n int) int64 {
+    if n < s.ChunkCount {
+        return s.ChunkSize
+    }
+    return s.TotalSize - int64(s.ChunkCount-1)*s.ChunkSize
+}
+
+// Upload sends the file at path through a chunked upload session. If an
+// earlier call was interrupted, the session it started is resumed and only
+// the chunks the service has not received intact are sent again.
+func (c *Client) Upload(ctx context.Context, path, contentType string, progress ProgressFunc) (*File, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    info, err := f.Stat()
+    if err != nil {
+        return nil, err
+    }
+
+    statePath := path + uploadStateSuffix
+    session, err := c.resumeSession(ctx, statePath, info)
+    if err != nil {
+        return nil, err
+    }
+    if session == nil {
+        if session, err = c.initiateSession(ctx, filepath.Base(path), contentType, info.Size()); err != nil {
+            return nil, err
+        }
+        state := uploadState{SessionID: session.ID, Size: info.Size(), ModTime: info.ModTime()}
+        if err := saveState(statePath, state); err != nil {
+            return nil, err
+        }
+    }
+
+    file, err := c.sendChunks(ctx, f, session, progress)
+    if err != nil {
+        // A session the service no longer accepts cannot be resumed
+        var apiErr *APIError
+        if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound ||
+            apiErr.StatusCode == http.StatusGone || apiErr.StatusCode == http.StatusConflict) {
+            os.Remove(statePath)
+        }
+        return nil, err
+    }
+
+    os.Remove(statePath)
+    return file, nil
+}
+
+// resumeSession returns the open session recorded in the resume state, or nil
+// if there is none to resume
+func (c *Client) resumeSession(ctx context.Context, statePath string, info os.FileInfo) (*uploadSession, error) {
+    var state uploadState
+    if err := loadState(statePath, &state); err != nil {
+        if errors.Is(err, os.ErrNotExist) {
+            return nil, nil
+        }
+        return nil, err
+    }
+    if state.Size != info.Size() || !state.ModTime.Equal(info.ModTime()) {
+        return nil, nil
+    }
+
+    var session uploadSession
+    err := c.retry(ctx, func() error {
+        req, err := c.newRequest(ctx, http.MethodGet, "/uploads/"+state.SessionID, nil)
+        if err != nil {
+            return err
+        }
+        return c.doJSON(req, &session)
+    })
+
+    var apiErr *APIError
+    if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusGone) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    if session.Status != sessionStatusOpen {
+        return nil, nil
+    }
+    return &session, nil
+}
+
+// initiateSession starts a new upload session
+func (c *Client) initiateSession(ctx context.Context, fileName, contentType string, size int64) (*uploadSession, error) {
+    body, err := json.Marshal(map[string]interface{}{
+        "fileName":    fileName,
+        "contentType": contentType,
+        "size":        size,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    var session uploadSession
+    err = c.retry(ctx, func() error {
+        req, err := c.newRequest(ctx, http.MethodPost, "/uploads", bytes.NewReader(body))
+        if err != nil {
+            return err
+        }
+        req.Header.Set("Content-Type", "application/json")
+        return c.doJSON(req, &session)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &session, nil
+}
+
+// sendChunks uploads every chunk the session lacks, or holds with another
+// checksum than the local content, then completes the session
+func (c *Client) sendChunks(ctx context.Context, f *os.File, session *uploadSession, progress ProgressFunc) (*File, error) {
+    received := make(map[int]string, len(session.Parts))
+    for _, part := range session.Parts {
+        received[part.Number] = part.Checksum
+    }
+
+    checksums := make([]string, session.ChunkCount)
+    buf := make([]byte, session.ChunkSize)
+    var done int64
+    for n := 1; n <= session.ChunkCount; n++ {
+        chunk := buf[:session.chunkLength(n)]
+        read, err := f.ReadAt(chunk, int64(n-1)*session.ChunkSize)
+        if err != nil && !(errors.Is(err, io.EOF) && read == len(chunk)) {
+            return nil, err
+        }
+
+        digest := sha256.Sum256(chunk)
+        checksum := hex.EncodeToString(digest[:])
+        checksums[n-1] = checksum
+
+        if !strings.EqualFold(received[n], checksum) {
+            err := c.retry(ctx, func() error {
+                return c.uploadChunk(ctx, session.ID, n, chunk, checksum)
+            })
+            if err != nil {
+                return nil, fmt.Errorf("failed to upload chunk %d: %w", n, err)
+            }
+        }
+
+        done += int64(len(chunk))
+        if progress != nil {
+            progress(done, session.TotalSize)
+        }
+    }
+
+    body, err := json.Marshal(map[string][]string{"checksums": checksums})
+    if err != nil {
+        return nil, err
+    }
+
+    var file File
+    err = c.retry(ctx, func() error {
+        req, err := c.newRequest(ctx, http.MethodPost, "/uploads/"+session.ID+"/complete", bytes.NewReader(body))
+        if err != nil {
+            return err
+        }
+        req.Header.Set("Content-Type", "application/json")
+        return c.doJSON(req, &file)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &file, nil
+}
+
+// uploadChunk sends one chunk with its checksum, which the service verifies
+func (c *Client) uploadChunk(ctx context.Context, sessionID string, n int, chunk []byte, checksum string) error {
+    req, err := c.newRequest(ctx, http.MethodPut, fmt.Sprintf("/uploads/%s/chunks/%d", sessionID, n), bytes.NewReader(chunk))
+    if err != nil {
+        return err
+    }
+    req.Header.Set(chunkChecksumHeader, checksum)
+    req.Header.Set("Content-Type", "application/octet-stream")
+    return c.doJSON(req, nil)
+}
@@ -0,0 +1,93 @@
+// Package convert converts uploaded documents and images to canonical
+// formats through external converters, such as a Gotenberg sidecar running
+// LibreOffice or the rsvg-convert tool.
+package convert
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "mime"
+    "strings"
+    "time"
+)
+
+// ErrUnsupported is returned for conversions no converter performs
+var ErrUnsupported = errors.New("conversion not supported")
+
+// Formats maps the format names downloads may request to their content types
+var Formats = map[string]string{
+    "pdf": "application/pdf",
+    "png": "image/png",
+}
+
+// Converter converts content between content types
+type Converter interface {
+    // Converts reports whether the converter produces content of type to
+    // from content of type from
+    Converts(from, to string) bool
+    // Convert converts content of type from to type to
+    Convert(ctx context.Context, from, to string, content []byte) ([]byte, error)
+}
+
+// Pipeline converts content with the first of its converters that supports
+// the conversion, bounding each conversion by a timeout
+type Pipeline struct {
+    converters []Converter
+    timeout    time.Duration
+}
+
+// NewPipeline creates a pipeline of converters
+func NewPipeline(timeout time.Duration, converters ...Converter) (*Pipeline, error) {
+    if timeout <= 0 {
+        return nil, errors.New("conversion timeout must be positive")
+    }
+    return &Pipeline{converters: converters, timeout: timeout}, nil
+}
+
+// ContentType returns the content type of a format name
+func ContentType(format string) (string, bool) {
+    contentType, ok := Formats[strings.ToLower(format)]
+    return contentType, ok
+}
+
+// Supports reports whether content of type from converts to any format
+func (p *Pipeline) Supports(from string) bool {
+    for _, to := range Formats {
+        if p.converter(from, to) != nil {
+            return true
+        }
+    }
+    return false
+}
+
+// Convert converts content of type from to type to
+func (p *Pipeline) Convert(from, to string, content []byte) ([]byte, error) {
+    converter := p.converter(from, to)
+    if converter == nil {
+        return nil, fmt.Errorf("%w: %s to %s", ErrUnsupported, mediaType(from), to)
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+    defer cancel()
+    return converter.Convert(ctx, mediaType(from), to, content)
+}
+
+func (p *Pipeline) converter(from, to string) Converter {
+    from = mediaType(from)
+    for _, converter := range p.converters {
+        if converter.Converts(from, to) {
+            return converter
+        }
+    }
+    return nil
+}
+
+// mediaType strips parameters from a content type
+func mediaType(contentType string) string {
+    parsed, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return strings.ToLower(strings.TrimSpace(contentType))
+    }
+    return parsed
+}
@@ -0,0 +1,102 @@
+package convert
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "mime/multipart"
+    "net/http"
+    "strings"
+)
+
+// maxConvertedSize bounds the converted documents read back from Gotenberg
+const maxConvertedSize = 100 * 1024 * 1024 // 100MB
+
+// officeExtensions maps the office document types LibreOffice converts to
+// the file extensions Gotenberg detects them by
+var officeExtensions = map[string]string{
+    "application/msword": ".doc",
+    "application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+    "application/vnd.ms-excel": ".xls",
+    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+    "application/vnd.ms-powerpoint":                                             ".ppt",
+    "application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+    "application/vnd.oasis.opendocument.text":                                   ".odt",
+    "application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+    "application/vnd.oasis.opendocument.presentation":                           ".odp",
+    "application/rtf": ".rtf",
+    "text/plain":      ".txt",
+    "text/csv":        ".csv",
+}
+
+// Gotenberg converts office documents to PDF with the LibreOffice route of a
+// Gotenberg sidecar
+type Gotenberg struct {
+    url        string
+    httpClient *http.Client
+}
+
+// NewGotenberg creates a converter for the Gotenberg instance at url
+func NewGotenberg(url string) (*Gotenberg, error) {
+    if url == "" {
+        return nil, errors.New("gotenberg URL is required")
+    }
+    return &Gotenberg{
+        url:        strings.TrimSuffix(url, "/"),
+        httpClient: &http.Client{},
+    }, nil
+}
+
+// Converts reports whether from is an office document and to is PDF
+func (g *Gotenberg) Converts(from, to string) bool {
+    _, ok := officeExtensions[from]
+    return ok && to == Formats["pdf"]
+}
+
+// Convert posts the document to Gotenberg and returns the PDF it renders
+func (g *Gotenberg) Convert(ctx context.Context, from, to string, content []byte) ([]byte, error) {
+    if !g.Converts(from, to) {
+        return nil, ErrUnsupported
+    }
+
+    var body bytes.Buffer
+    form := multipart.NewWriter(&body)
+    part, err := form.CreateFormFile("files", "document"+officeExtensions[from])
+    if err != nil {
+        return nil, err
+    }
+    if _, err := part.Write(content); err != nil {
+        return nil, err
+    }
+    if err := form.Close(); err != nil {
+        return nil, err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url+"/forms/libreoffice/convert", &body)
+    if err != nil {
+        return nil, fmt.Errorf("failed to build conversion request: %w", err)
+    }
+    req.Header.Set("Content-Type", form.FormDataContentType())
+
+    resp, err := g.httpClient.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("conversion request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+        return nil, fmt.Errorf("gotenberg returned status %d", resp.StatusCode)
+    }
+
+    converted, err := io.ReadAll(io.LimitReader(resp.Body, maxConvertedSize+1))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read converted document: %w", err)
+    }
+    if len(converted) > maxConvertedSize {
+        return nil, fmt.Errorf("converted document exceeds %d bytes", maxConvertedSize)
+    }
+    return converted, nil
+}
@@ -0,0 +1,52 @@
+package convert
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "os/exec"
+    "strconv"
+    "strings"
+)
+
+// pdfPageSize bounds the width and height of rendered PDF pages in pixels
+const pdfPageSize = 1024
+
+// Pdftocairo renders the first page of PDF documents to PNG with the
+// pdftocairo tool from poppler
+type Pdftocairo struct {
+    path string
+}
+
+// NewPdftocairo creates a converter running the pdftocairo binary at path
+func NewPdftocairo(path string) (*Pdftocairo, error) {
+    if path == "" {
+        return nil, errors.New("pdftocairo path is required")
+    }
+    return &Pdftocairo{path: path}, nil
+}
+
+// Converts reports whether from is PDF and to is PNG
+func (c *Pdftocairo) Converts(from, to string) bool {
+    return from == Formats["pdf"] && to == Formats["png"]
+}
+
+// Convert renders the first page of the PDF, read from stdin, to PNG on
+// stdout, scaled to fit within pdfPageSize pixels
+func (c *Pdftocairo) Convert(ctx context.Context, from, to string, content []byte) ([]byte, error) {
+    if !c.Converts(from, to) {
+        return nil, ErrUnsupported
+    }
+
+    var stdout, stderr bytes.Buffer
+    cmd := exec.CommandContext(ctx, c.path, "-png", "-singlefile", "-f", "1", "-l", "1",
+        "-scale-to", strconv.Itoa(pdfPageSize), "-", "-")
+    cmd.Stdin = bytes.NewReader(content)
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        return nil, fmt.Errorf("pdftocairo failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+    }
+    return stdout.Bytes(), nil
+}
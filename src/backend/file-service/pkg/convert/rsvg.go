@@ -0,0 +1,45 @@
+package convert
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "os/exec"
+    "strings"
+)
+
+// Rsvg renders SVG images to PNG with the rsvg-convert tool
+type Rsvg struct {
+    path string
+}
+
+// NewRsvg creates a converter running the rsvg-convert binary at path
+func NewRsvg(path string) (*Rsvg, error) {
+    if path == "" {
+        return nil, errors.New("rsvg-convert path is required")
+    }
+    return &Rsvg{path: path}, nil
+}
+
+// Converts reports whether from is SVG and to is PNG
+func (c *Rsvg) Converts(from, to string) bool {
+    return from == "image/svg+xml" && to == Formats["png"]
+}
+
+// Convert renders the SVG, read from stdin, to PNG on stdout
+func (c *Rsvg) Convert(ctx context.Context, from, to string, content []byte) ([]byte, error) {
+    if !c.Converts(from, to) {
+        return nil, ErrUnsupported
+    }
+
+    var stdout, stderr bytes.Buffer
+    cmd := exec.CommandContext(ctx, c.path, "--format", "png")
+    cmd.Stdin = bytes.NewReader(content)
+    cmd.Stdout = &stdout
+    cmd.Stderr = &stderr
+    if err := cmd.Run(); err != nil {
+        return nil, fmt.Errorf("rsvg-convert failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+    }
+    return stdout.Bytes(), nil
+}
@@ -0,0 +1,80 @@
+package discovery
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/hashicorp/consul/api" // v1.20.0
+    "go.uber.org/zap"                 // v1.24.0
+)
+
+// consulRegistrar registers the instance with the local Consul agent, which
+// runs the health check itself
+type consulRegistrar struct {
+    config Config
+    client *api.Client
+    logger *zap.Logger
+}
+
+func newConsulRegistrar(config Config) (*consulRegistrar, error) {
+    clientConfig := api.DefaultConfig()
+    clientConfig.Address = config.Endpoints[0]
+    clientConfig.Token = config.Token
+
+    client, err := api.NewClient(clientConfig)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create consul client: %w", err)
+    }
+
+    return &consulRegistrar{
+        config: config,
+        client: client,
+        logger: zap.L().Named("discovery"),
+    }, nil
+}
+
+// Register adds the service with an HTTP health check. Consul only returns
+// the instance from health queries while the check passes.
+func (r *consulRegistrar) Register(ctx context.Context) error {
+    registration := &api.AgentServiceRegistration{
+        ID:      r.config.ServiceID,
+        Name:    r.config.ServiceName,
+        Address: r.config.Address,
+        Port:    r.config.Port,
+        Tags:    r.config.Tags,
+        Meta:    r.config.Meta,
+        Check: &api.AgentServiceCheck{
+            CheckID:  r.config.ServiceID + ":health",
+            Name:     "HTTP health",
+            HTTP:     r.config.HealthURL,
+            Method:   "GET",
+            Interval: r.config.CheckInterval.String(),
+            Timeout:  r.config.CheckTimeout.String(),
+        },
+    }
+    if r.config.DeregisterAfter > 0 {
+        registration.Check.DeregisterCriticalServiceAfter = r.config.DeregisterAfter.String()
+    }
+
+    opts := api.ServiceRegisterOpts{ReplaceExistingChecks: true}.WithContext(ctx)
+    if err := r.client.Agent().ServiceRegisterOpts(registration, opts); err != nil {
+        return fmt.Errorf("consul registration failed: %w", err)
+    }
+
+    r.logger.Info("Registered with consul",
+        zap.String("serviceId", r.config.ServiceID),
+        zap.String("address", fmt.Sprintf("%s:%d", r.config.Address, r.config.Port)))
+    return nil
+}
+
+// Deregister removes the service and its check
+func (r *consulRegistrar) Deregister(ctx context.Context) error {
+    opts := (&api.QueryOptions{}).WithContext(ctx)
+    if err := r.client.Agent().ServiceDeregisterOpts(r.config.ServiceID, opts); err != nil {
+        return fmt.Errorf("consul deregistration failed: %w", err)
+    }
+
+    r.logger.Info("Deregistered from consul",
+        zap.String("serviceId", r.config.ServiceID))
+    return nil
+}
@@ -0,0 +1,91 @@
+// Package discovery registers the running instance with a service registry,
+// Consul or etcd, so deployments outside Kubernetes get service discovery
+// without a sidecar. Instances are only discoverable while healthy.
+package discovery
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+)
+
+// Supported registry backends
+const (
+    BackendConsul = "consul"
+    BackendEtcd   = "etcd"
+)
+
+// Config configures self-registration
+type Config struct {
+    Backend string
+    // Endpoints are the registry addresses: the Consul agent URL, or the etcd
+    // cluster members
+    Endpoints []string
+    // Token is the Consul ACL token; Username and Password authenticate to etcd
+    Token    string
+    Username string
+    Password string
+    // ServiceName and ServiceID identify the instance; ID must be unique
+    ServiceName string
+    ServiceID   string
+    // Address and Port are where clients reach the instance
+    Address string
+    Port    int
+    Tags    []string
+    Meta    map[string]string
+    // HealthURL is probed to decide whether the instance is healthy
+    HealthURL     string
+    CheckInterval time.Duration
+    CheckTimeout  time.Duration
+    // DeregisterAfter removes an instance that stays critical this long, so
+    // crashed instances do not linger in Consul
+    DeregisterAfter time.Duration
+    // KeyPrefix is the etcd key prefix under which instances are registered
+    KeyPrefix string
+}
+
+// Registrar registers the instance on startup and deregisters it on shutdown
+type Registrar interface {
+    Register(ctx context.Context) error
+    Deregister(ctx context.Context) error
+}
+
+// New validates the configuration and creates the registrar for its backend
+func New(config Config) (Registrar, error) {
+    if config.ServiceName == "" || config.ServiceID == "" {
+        return nil, errors.New("service name and ID are required")
+    }
+    if config.Address == "" || config.Port <= 0 {
+        return nil, errors.New("service address and port are required")
+    }
+    if len(config.Endpoints) == 0 {
+        return nil, errors.New("registry endpoints are required")
+    }
+    if config.HealthURL == "" || config.CheckInterval <= 0 || config.CheckTimeout <= 0 {
+        return nil, errors.New("health URL, check interval and check timeout are required")
+    }
+
+    switch config.Backend {
+    case BackendConsul:
+        return newConsulRegistrar(config)
+    case BackendEtcd:
+        return newEtcdRegistrar(config)
+    }
+    return nil, fmt.Errorf("unsupported discovery backend: %s", config.Backend)
+}
+
+// probe reports whether the health endpoint answers with a 2xx status
+func probe(ctx context.Context, client *http.Client, url string) bool {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return false
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return false
+    }
+    resp.Body.Close()
+    return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
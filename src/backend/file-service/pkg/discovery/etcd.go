@@ -0,0 +1,232 @@
+package discovery
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "path"
+    "sync"
+    "time"
+
+    clientv3 "go.etcd.io/etcd/client/v3" // v3.5.9
+    "go.uber.org/zap"                    // v1.24.0
+)
+
+// leaseChecks is how many health checks fit in the lease TTL, so a few
+// missed refreshes don't drop a healthy instance
+const leaseChecks = 3
+
+// instance is the value stored under an instance's etcd key
+type instance struct {
+    ID      string            `json:"id"`
+    Name    string            `json:"name"`
+    Address string            `json:"address"`
+    Port    int               `json:"port"`
+    Tags    []string          `json:"tags,omitempty"`
+    Meta    map[string]string `json:"meta,omitempty"`
+}
+
+// etcdRegistrar keeps the instance's key alive under a lease while its health
+// check passes. etcd has no health checks of its own, so the registrar probes
+// the health URL and removes the key as soon as a probe fails.
+type etcdRegistrar struct {
+    config Config
+    client *clientv3.Client
+    http   *http.Client
+    key    string
+    value  string
+    ttl    int64
+    logger *zap.Logger
+
+    mu      sync.Mutex
+    leaseID clientv3.LeaseID
+    stop    context.CancelFunc
+    done    chan struct{}
+}
+
+func newEtcdRegistrar(config Config) (*etcdRegistrar, error) {
+    client, err := clientv3.New(clientv3.Config{
+        Endpoints:   config.Endpoints,
+        DialTimeout: config.CheckTimeout,
+        Username:    config.Username,
+        Password:    config.Password,
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to create etcd client: %w", err)
+    }
+
+    value, err := json.Marshal(instance{
+        ID:      config.ServiceID,
+        Name:    config.ServiceName,
+        Address: config.Address,
+        Port:    config.Port,
+        Tags:    config.Tags,
+        Meta:    config.Meta,
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode instance: %w", err)
+    }
+
+    ttl := int64((config.CheckInterval * leaseChecks).Seconds())
+    if ttl < 5 {
+        ttl = 5
+    }
+
+    return &etcdRegistrar{
+        config: config,
+        client: client,
+        http:   &http.Client{Timeout: config.CheckTimeout},
+        key:    path.Join("/", config.KeyPrefix, config.ServiceName, config.ServiceID),
+        value:  string(value),
+        ttl:    ttl,
+        logger: zap.L().Named("discovery"),
+    }, nil
+}
+
+// Register puts the instance key and keeps it alive in the background until
+// Deregister is called
+func (r *etcdRegistrar) Register(ctx context.Context) error {
+    if err := r.put(ctx); err != nil {
+        return err
+    }
+
+    loopCtx, stop := context.WithCancel(context.Background())
+    r.mu.Lock()
+    r.stop = stop
+    r.done = make(chan struct{})
+    r.mu.Unlock()
+    go r.run(loopCtx)
+
+    r.logger.Info("Registered with etcd",
+        zap.String("key", r.key),
+        zap.Int64("leaseTtl", r.ttl))
+    return nil
+}
+
+// Deregister stops refreshing the lease and revokes it, removing the key
+func (r *etcdRegistrar) Deregister(ctx context.Context) error {
+    r.mu.Lock()
+    stop, done := r.stop, r.done
+    r.mu.Unlock()
+    if stop != nil {
+        stop()
+        <-done
+    }
+
+    err := r.revoke(ctx)
+    if closeErr := r.client.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return fmt.Errorf("etcd deregistration failed: %w", err)
+    }
+
+    r.logger.Info("Deregistered from etcd",
+        zap.String("key", r.key))
+    return nil
+}
+
+// run probes the health URL every check interval, refreshing the lease while
+// healthy, removing the key when unhealthy and restoring it on recovery
+func (r *etcdRegistrar) run(ctx context.Context) {
+    defer close(r.done)
+
+    ticker := time.NewTicker(r.config.CheckInterval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+
+        checkCtx, cancel := context.WithTimeout(ctx, r.config.CheckTimeout)
+        healthy := probe(checkCtx, r.http, r.config.HealthURL)
+        err := r.refresh(checkCtx, healthy)
+        cancel()
+        if err != nil && ctx.Err() == nil {
+            r.logger.Warn("Failed to refresh etcd registration",
+                zap.Bool("healthy", healthy),
+                zap.Error(err))
+        }
+    }
+}
+
+// refresh applies the outcome of one health probe
+func (r *etcdRegistrar) refresh(ctx context.Context, healthy bool) error {
+    r.mu.Lock()
+    registered := r.leaseID != clientv3.NoLease
+    r.mu.Unlock()
+
+    switch {
+    case healthy && registered:
+        if err := r.keepAlive(ctx); err == nil || !errors.Is(err, errLeaseExpired) {
+            return err
+        }
+        // The lease lapsed, e.g. across an etcd outage; register afresh
+        return r.put(ctx)
+    case healthy:
+        r.logger.Info("Instance healthy again, restoring etcd registration")
+        return r.put(ctx)
+    case registered:
+        r.logger.Warn("Instance unhealthy, removing etcd registration")
+        return r.revoke(ctx)
+    }
+    return nil
+}
+
+// errLeaseExpired reports that the registration lease no longer exists
+var errLeaseExpired = errors.New("lease expired")
+
+func (r *etcdRegistrar) keepAlive(ctx context.Context) error {
+    r.mu.Lock()
+    leaseID := r.leaseID
+    r.mu.Unlock()
+
+    resp, err := r.client.KeepAliveOnce(ctx, leaseID)
+    if err != nil || resp.TTL <= 0 {
+        r.mu.Lock()
+        r.leaseID = clientv3.NoLease
+        r.mu.Unlock()
+        if err == nil {
+            return errLeaseExpired
+        }
+        return fmt.Errorf("%w: %v", errLeaseExpired, err)
+    }
+    return nil
+}
+
+// put grants a new lease and writes the instance key under it
+func (r *etcdRegistrar) put(ctx context.Context) error {
+    lease, err := r.client.Grant(ctx, r.ttl)
+    if err != nil {
+        return fmt.Errorf("failed to grant etcd lease: %w", err)
+    }
+    if _, err := r.client.Put(ctx, r.key, r.value, clientv3.WithLease(lease.ID)); err != nil {
+        return fmt.Errorf("failed to write etcd registration: %w", err)
+    }
+
+    r.mu.Lock()
+    r.leaseID = lease.ID
+    r.mu.Unlock()
+    return nil
+}
+
+// revoke revokes the current lease, deleting the instance key
+func (r *etcdRegistrar) revoke(ctx context.Context) error {
+    r.mu.Lock()
+    leaseID := r.leaseID
+    r.leaseID = clientv3.NoLease
+    r.mu.Unlock()
+
+    if leaseID == clientv3.NoLease {
+        return nil
+    }
+    if _, err := r.client.Revoke(ctx, leaseID); err != nil {
+        return fmt.Errorf("failed to revoke etcd lease: %w", err)
+    }
+    return nil
+}
@@ -0,0 +1,108 @@
+// Package errtrack reports unexpected errors and panics to an error tracking
+// service. Reporting is disabled until a reporter is installed with SetReporter.
+package errtrack
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "sync"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+)
+
+// flushTimeout bounds how long a crashing goroutine waits for its report to be sent
+const flushTimeout = 2 * time.Second
+
+// Reporter sends errors and recovered panics to an error tracker
+type Reporter interface {
+    // CaptureError reports err with the given tags
+    CaptureError(ctx context.Context, err error, tags map[string]string)
+    // CapturePanic reports a value recovered from a panic
+    CapturePanic(ctx context.Context, recovered interface{}, tags map[string]string)
+    // Flush waits up to timeout for buffered reports to be delivered
+    Flush(timeout time.Duration) bool
+}
+
+var (
+    reporter   Reporter = nopReporter{}
+    reporterMu sync.RWMutex
+)
+
+// SetReporter installs the process-wide reporter
+func SetReporter(r Reporter) {
+    reporterMu.Lock()
+    defer reporterMu.Unlock()
+    if r == nil {
+        r = nopReporter{}
+    }
+    reporter = r
+}
+
+// Default returns the process-wide reporter
+func Default() Reporter {
+    reporterMu.RLock()
+    defer reporterMu.RUnlock()
+    return reporter
+}
+
+// CaptureError reports err to the process-wide reporter
+func CaptureError(ctx context.Context, err error, tags map[string]string) {
+    if err == nil {
+        return
+    }
+    Default().CaptureError(ctx, err, tags)
+}
+
+// Middleware recovers handler panics, reports them and responds with 500
+func Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        defer func() {
+            recovered := recover()
+            if recovered == nil {
+                return
+            }
+            if recovered == http.ErrAbortHandler {
+                panic(recovered)
+            }
+
+            zap.L().Error("Recovered handler panic",
+                zap.String("path", r.URL.Path),
+                zap.Any("panic", recovered),
+                zap.Stack("stack"))
+            Default().CapturePanic(r.Context(), recovered, map[string]string{
+                "method": r.Method,
+                "path":   r.URL.Path,
+            })
+            http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+        }()
+
+        next.ServeHTTP(w, r)
+    })
+}
+
+// Go runs a background job, reporting a panic before letting it crash the process
+func Go(ctx context.Context, job string, fn func()) {
+    go func() {
+        defer func() {
+            if recovered := recover(); recovered != nil {
+                Default().CapturePanic(ctx, recovered, map[string]string{"job": job})
+                Default().Flush(flushTimeout)
+                panic(fmt.Sprintf("background job %s: %v", job, recovered))
+            }
+        }()
+        fn()
+    }()
+}
+
+// nopReporter discards reports while error tracking is disabled
+type nopReporter struct{}
+
+func (nopReporter) CaptureError(ctx context.Context, err error, tags map[string]string) {}
+
+func (nopReporter) CapturePanic(ctx context.Context, recovered interface{}, tags map[string]string) {}
+
+func (nopReporter) Flush(timeout time.Duration) bool {
+    return true
+}
@@ -0,0 +1,134 @@
+package errtrack
+
+import (
+    "context"
+    "fmt"
+    "regexp"
+    "time"
+
+    "github.com/getsentry/sentry-go" // v0.25.0
+
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// SentryConfig configures the Sentry reporter
+type SentryConfig struct {
+    DSN         string
+    Environment string
+    Release     string
+    // SampleRate is the fraction of errors sent, between 0 and 1
+    SampleRate float64
+    // ScrubPII strips user details, credentials and e-mail addresses from events
+    ScrubPII bool
+}
+
+// PII patterns removed from event messages when scrubbing is enabled
+var (
+    emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+    bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`)
+)
+
+// sensitiveHeaders are dropped from request data attached to events
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Encryption-Customer-Key"}
+
+// sentryReporter reports to Sentry
+type sentryReporter struct {
+    hub *sentry.Hub
+}
+
+// NewSentryReporter creates a reporter sending events to Sentry
+func NewSentryReporter(config SentryConfig) (Reporter, error) {
+    if config.SampleRate < 0 || config.SampleRate > 1 {
+        return nil, fmt.Errorf("invalid sample rate %v", config.SampleRate)
+    }
+
+    options := sentry.ClientOptions{
+        Dsn:            config.DSN,
+        Environment:    config.Environment,
+        Release:        config.Release,
+        SampleRate:     config.SampleRate,
+        SendDefaultPII: !config.ScrubPII,
+    }
+    if config.ScrubPII {
+        options.BeforeSend = scrubEvent
+    }
+
+    client, err := sentry.NewClient(options)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create sentry client: %w", err)
+    }
+
+    return &sentryReporter{hub: sentry.NewHub(client, sentry.NewScope())}, nil
+}
+
+func (s *sentryReporter) CaptureError(ctx context.Context, err error, tags map[string]string) {
+    s.hub.WithScope(func(scope *sentry.Scope) {
+        s.tagScope(ctx, scope, tags)
+        s.hub.CaptureException(err)
+    })
+}
+
+func (s *sentryReporter) CapturePanic(ctx context.Context, recovered interface{}, tags map[string]string) {
+    s.hub.WithScope(func(scope *sentry.Scope) {
+        s.tagScope(ctx, scope, tags)
+        scope.SetLevel(sentry.LevelFatal)
+        s.hub.Recover(recovered)
+    })
+}
+
+func (s *sentryReporter) Flush(timeout time.Duration) bool {
+    return s.hub.Flush(timeout)
+}
+
+// tagScope applies caller tags, the trace and request IDs, the tenant and any
+// background job to scope
+func (s *sentryReporter) tagScope(ctx context.Context, scope *sentry.Scope, tags map[string]string) {
+    scope.SetTags(tags)
+    if traceID := tracing.TraceID(ctx); traceID != "" {
+        scope.SetTag(tracing.ExemplarLabel, traceID)
+    }
+    if requestID := requestctx.RequestID(ctx); requestID != "" {
+        scope.SetTag("request_id", requestID)
+    }
+    if tenantID := requestctx.Tenant(ctx); tenantID != "" {
+        scope.SetTag("tenant_id", tenantID)
+    }
+    if job, ok := tracing.JobFromContext(ctx); ok {
+        scope.SetTag("job_id", job.ID)
+        if job.LinkedTraceID != "" {
+            scope.SetTag("linked_trace_id", job.LinkedTraceID)
+        }
+    }
+}
+
+// scrubEvent removes personal data and credentials before an event leaves the process
+func scrubEvent(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
+    event.User = sentry.User{ID: event.User.ID}
+    event.ServerName = ""
+    event.Message = ScrubText(event.Message)
+
+    for i := range event.Exception {
+        event.Exception[i].Value = ScrubText(event.Exception[i].Value)
+    }
+    for _, breadcrumb := range event.Breadcrumbs {
+        breadcrumb.Message = ScrubText(breadcrumb.Message)
+    }
+
+    if event.Request != nil {
+        event.Request.Cookies = ""
+        event.Request.Data = ""
+        event.Request.QueryString = ""
+        for _, header := range sensitiveHeaders {
+            delete(event.Request.Headers, header)
+        }
+    }
+    return event
+}
+
+// ScrubText masks e-mail addresses and bearer tokens, for text leaving the
+// process
+func ScrubText(text string) string {
+    text = emailPattern.ReplaceAllString(text, "[email]")
+    return bearerPattern.ReplaceAllString(text, "Bearer [token]")
+}
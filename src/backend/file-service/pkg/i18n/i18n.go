@@ -0,0 +1,128 @@
+// Package i18n translates user-facing messages into the language a client
+// prefers. Catalogs are embedded JSON files keyed by the English message, so
+// English needs no catalog and any message without a translation falls back
+// to it. Only the human-readable text is translated: error codes and the
+// English message stay the same in every language for clients that match on
+// them.
+package i18n
+
+import (
+    "embed"
+    "encoding/json"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Default is the language of the messages in the code, used when the client
+// prefers none of the catalog languages
+const Default = "en"
+
+// locales holds one catalog per language, named <language>.json
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// catalogs maps a lowercase language tag to its translations
+var catalogs = mustLoadCatalogs()
+
+// mustLoadCatalogs parses the embedded catalogs. They are part of the binary,
+// so a malformed catalog is a build defect and fails at startup.
+func mustLoadCatalogs() map[string]map[string]string {
+    entries, err := locales.ReadDir("locales")
+    if err != nil {
+        panic("i18n: failed to read embedded catalogs: " + err.Error())
+    }
+
+    catalogs := make(map[string]map[string]string, len(entries))
+    for _, entry := range entries {
+        name := entry.Name()
+        data, err := locales.ReadFile(path.Join("locales", name))
+        if err != nil {
+            panic("i18n: failed to read catalog " + name + ": " + err.Error())
+        }
+        var messages map[string]string
+        if err := json.Unmarshal(data, &messages); err != nil {
+            panic("i18n: invalid catalog " + name + ": " + err.Error())
+        }
+        catalogs[strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))] = messages
+    }
+    return catalogs
+}
+
+// Languages returns the supported languages, including Default, sorted
+func Languages() []string {
+    languages := []string{Default}
+    for language := range catalogs {
+        if language != Default {
+            languages = append(languages, language)
+        }
+    }
+    sort.Strings(languages)
+    return languages
+}
+
+// Translate returns message in language, or message itself if the language
+// has no catalog or the catalog has no translation for it
+func Translate(language, message string) string {
+    if translated, ok := catalogs[language][message]; ok && translated != "" {
+        return translated
+    }
+    return message
+}
+
+// languageRange is one entry of an Accept-Language header
+type languageRange struct {
+    tag     string
+    quality float64
+}
+
+// Negotiate picks the supported language that best matches an
+// Accept-Language header (RFC 9110). Ranges are tried by descending quality,
+// each first as given and then by its primary subtag, so de-CH is served the
+// de catalog. Default is returned when nothing matches.
+func Negotiate(acceptLanguage string) string {
+    var ranges []languageRange
+    for _, part := range strings.Split(acceptLanguage, ",") {
+        fields := strings.Split(part, ";")
+        tag := strings.ToLower(strings.TrimSpace(fields[0]))
+        if tag == "" {
+            continue
+        }
+
+        quality := 1.0
+        for _, param := range fields[1:] {
+            name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+            if !ok || strings.ToLower(strings.TrimSpace(name)) != "q" {
+                continue
+            }
+            q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+            if err != nil {
+                q = 0
+            }
+            quality = q
+        }
+        if quality <= 0 {
+            continue
+        }
+        ranges = append(ranges, languageRange{tag: tag, quality: quality})
+    }
+    sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+    for _, r := range ranges {
+        if r.tag == "*" {
+            return Default
+        }
+        primary, _, _ := strings.Cut(r.tag, "-")
+        for _, candidate := range []string{r.tag, primary} {
+            if candidate == Default {
+                return Default
+            }
+            if _, ok := catalogs[candidate]; ok {
+                return candidate
+            }
+        }
+    }
+    return Default
+}
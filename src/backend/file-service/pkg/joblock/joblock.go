@@ -0,0 +1,270 @@
+// Package joblock coordinates scheduled background jobs across replicas so a
+// run executes on one instance at a time. The Postgres backend uses
+// session-level advisory locks: when an instance dies its database session
+// ends, Postgres releases the lock and another replica takes over on its next
+// tick.
+package joblock
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "fmt"
+    "hash/fnv"
+    "sync"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+    "go.uber.org/zap"                                // v1.24.0
+)
+
+// Supported lock backends
+const (
+    BackendPostgres = "postgres"
+    BackendLocal    = "local"
+)
+
+// Lock attempt results, labelling job_lock_attempts_total
+const (
+    resultAcquired  = "acquired"
+    resultContended = "contended"
+    resultError     = "error"
+)
+
+// keyNamespace separates job lock keys from other advisory lock users
+const keyNamespace = "file-service/job/"
+
+// unlockTimeout bounds releasing a lock after a run
+const unlockTimeout = 5 * time.Second
+
+// Job lock instruments, labelled by job name
+var (
+    lockAttempts = prometheus.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "job_lock_attempts_total",
+            Help: "Number of attempts to take a background job's lock by result",
+        },
+        []string{"job", "result"},
+    )
+
+    lockHeld = prometheus.NewGaugeVec(
+        prometheus.GaugeOpts{
+            Name: "job_lock_held",
+            Help: "Whether this instance holds a background job's lock",
+        },
+        []string{"job"},
+    )
+
+    lockLost = prometheus.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "job_lock_lost_total",
+            Help: "Number of runs cancelled because their lock session was lost",
+        },
+        []string{"job"},
+    )
+)
+
+// Collectors returns the job lock instruments for registration
+func Collectors() []prometheus.Collector {
+    return []prometheus.Collector{lockAttempts, lockHeld, lockLost}
+}
+
+// lease is a held lock. lost is closed if the lock may have been released
+// while held; release gives it up.
+type lease struct {
+    lost    <-chan struct{}
+    release func()
+}
+
+// Locker runs jobs under a lock shared by all replicas
+type Locker struct {
+    // acquire takes the lock for key without waiting, returning a nil lease
+    // when another holder has it
+    acquire func(ctx context.Context, key int64) (*lease, error)
+    logger  *zap.Logger
+}
+
+// New creates a Locker for the given backend. db is required by the Postgres
+// backend; keepAlive is how often a held lock's session is checked.
+func New(backend string, db *sql.DB, keepAlive time.Duration) (*Locker, error) {
+    switch backend {
+    case BackendPostgres:
+        if db == nil {
+            return nil, errors.New("postgres job locks require a database")
+        }
+        if keepAlive <= 0 {
+            return nil, errors.New("lock keep-alive interval must be positive")
+        }
+        return NewPostgres(db, keepAlive), nil
+    case BackendLocal:
+        return NewLocal(), nil
+    }
+    return nil, fmt.Errorf("unsupported job lock backend: %s", backend)
+}
+
+// NewPostgres creates a Locker backed by Postgres advisory locks
+func NewPostgres(db *sql.DB, keepAlive time.Duration) *Locker {
+    locks := &postgresLocks{db: db, keepAlive: keepAlive}
+    return &Locker{
+        acquire: locks.acquire,
+        logger:  zap.L().Named("joblock"),
+    }
+}
+
+// NewLocal creates a Locker that only prevents overlapping runs within this
+// process, for single-instance deployments
+func NewLocal() *Locker {
+    locks := &localLocks{held: make(map[int64]bool)}
+    return &Locker{
+        acquire: locks.acquire,
+        logger:  zap.L().Named("joblock"),
+    }
+}
+
+// Key derives the advisory lock key for a job name
+func Key(name string) int64 {
+    h := fnv.New64a()
+    h.Write([]byte(keyNamespace + name))
+    return int64(h.Sum64())
+}
+
+// Run calls fn if the job's lock is free, holding the lock until fn returns.
+// When another replica holds the lock the run is skipped. fn's context is
+// cancelled if the lock is lost while it runs. Only failures to take the lock
+// are returned; fn reports its own errors.
+func (l *Locker) Run(ctx context.Context, name string, fn func(ctx context.Context)) error {
+    held, err := l.acquire(ctx, Key(name))
+    if err != nil {
+        lockAttempts.WithLabelValues(name, resultError).Inc()
+        return fmt.Errorf("failed to acquire lock for job %s: %w", name, err)
+    }
+    if held == nil {
+        lockAttempts.WithLabelValues(name, resultContended).Inc()
+        l.logger.Debug("Job lock held elsewhere, skipping run",
+            zap.String("job", name))
+        return nil
+    }
+    lockAttempts.WithLabelValues(name, resultAcquired).Inc()
+
+    lockHeld.WithLabelValues(name).Set(1)
+    defer lockHeld.WithLabelValues(name).Set(0)
+    defer held.release()
+
+    runCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    go func() {
+        select {
+        case <-held.lost:
+            lockLost.WithLabelValues(name).Inc()
+            l.logger.Warn("Job lock lost, cancelling run",
+                zap.String("job", name))
+            cancel()
+        case <-runCtx.Done():
+        }
+    }()
+
+    fn(runCtx)
+    return nil
+}
+
+// postgresLocks takes session-level advisory locks on a dedicated connection
+type postgresLocks struct {
+    db        *sql.DB
+    keepAlive time.Duration
+}
+
+func (p *postgresLocks) acquire(ctx context.Context, key int64) (*lease, error) {
+    // The lock belongs to the session, so the same connection must be used
+    // to take and release it
+    conn, err := p.db.Conn(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    var locked bool
+    if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+        conn.Close()
+        return nil, err
+    }
+    if !locked {
+        conn.Close()
+        return nil, nil
+    }
+
+    lost := make(chan struct{})
+    stop := make(chan struct{})
+    done := make(chan struct{})
+    go p.watch(conn, lost, stop, done)
+
+    return &lease{
+        lost: lost,
+        release: func() {
+            close(stop)
+            <-done
+            p.release(conn, key)
+        },
+    }, nil
+}
+
+// watch pings the lock's session until stopped, closing lost if it fails.
+// A broken session means Postgres may already have released the lock.
+func (p *postgresLocks) watch(conn *sql.Conn, lost, stop, done chan struct{}) {
+    defer close(done)
+
+    ticker := time.NewTicker(p.keepAlive)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-stop:
+            return
+        case <-ticker.C:
+            ctx, cancel := context.WithTimeout(context.Background(), p.keepAlive)
+            err := conn.PingContext(ctx)
+            cancel()
+            if err != nil {
+                close(lost)
+                return
+            }
+        }
+    }
+}
+
+// release unlocks and returns the connection to the pool. If unlocking fails
+// the connection is discarded instead, ending the session and with it the
+// lock, so a pooled connection never keeps holding it.
+func (p *postgresLocks) release(conn *sql.Conn, key int64) {
+    ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+    defer cancel()
+
+    var unlocked bool
+    err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", key).Scan(&unlocked)
+    if err != nil || !unlocked {
+        conn.Raw(func(any) error { return driver.ErrBadConn })
+    }
+    conn.Close()
+}
+
+// localLocks tracks locks held within this process
+type localLocks struct {
+    mu   sync.Mutex
+    held map[int64]bool
+}
+
+func (l *localLocks) acquire(ctx context.Context, key int64) (*lease, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if l.held[key] {
+        return nil, nil
+    }
+    l.held[key] = true
+
+    return &lease{
+        release: func() {
+            l.mu.Lock()
+            delete(l.held, key)
+            l.mu.Unlock()
+        },
+    }, nil
+}
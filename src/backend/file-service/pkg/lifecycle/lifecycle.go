@@ -0,0 +1,136 @@
+// Package lifecycle implements the Kubernetes probe and termination
+// conventions: /healthz for liveness, /readyz for readiness and a preStop hook
+// that takes the instance out of rotation before the process is signalled, so
+// rolling deploys drain traffic instead of cutting off transfers in flight.
+package lifecycle
+
+import (
+    "context"
+    "net"
+    "net/http"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "go.uber.org/zap" // v1.24.0
+)
+
+// checkTimeout bounds each readiness check so a slow dependency fails the
+// probe instead of hanging it
+const checkTimeout = 2 * time.Second
+
+// Check reports whether a dependency needed to serve traffic is available
+type Check func(ctx context.Context) error
+
+// Drainer tracks whether the instance is draining. Once draining starts the
+// readiness probe fails, endpoints controllers and load balancers stop sending
+// new requests, and the drain delay gives them time to notice before the
+// servers stop accepting connections.
+type Drainer struct {
+    delay    time.Duration
+    checks   []Check
+    draining atomic.Bool
+    once     sync.Once
+    drained  chan struct{}
+    logger   *zap.Logger
+}
+
+// NewDrainer creates a Drainer that waits delay after draining starts. checks
+// are run by the readiness probe while the instance is not draining.
+func NewDrainer(delay time.Duration, checks ...Check) *Drainer {
+    return &Drainer{
+        delay:   delay,
+        checks:  checks,
+        drained: make(chan struct{}),
+        logger:  zap.L().Named("lifecycle"),
+    }
+}
+
+// Drain marks the instance as draining and returns a channel that is closed
+// once the drain delay has passed. It is safe to call repeatedly: the preStop
+// hook and the termination signal share the same drain.
+func (d *Drainer) Drain() <-chan struct{} {
+    d.once.Do(func() {
+        d.draining.Store(true)
+        d.logger.Info("Draining, readiness probe now failing",
+            zap.Duration("delay", d.delay))
+        time.AfterFunc(d.delay, func() { close(d.drained) })
+    })
+    return d.drained
+}
+
+// Draining reports whether draining has started
+func (d *Drainer) Draining() bool {
+    return d.draining.Load()
+}
+
+// LivenessHandler serves /healthz. It only reports that the process is
+// responsive, so a draining or degraded instance is not restarted.
+func (d *Drainer) LivenessHandler() http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte("ok"))
+    })
+}
+
+// ReadinessHandler serves /readyz, failing while draining or while any
+// readiness check fails
+func (d *Drainer) ReadinessHandler() http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if d.Draining() {
+            http.Error(w, "draining", http.StatusServiceUnavailable)
+            return
+        }
+
+        ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+        defer cancel()
+        for _, check := range d.checks {
+            if err := check(ctx); err != nil {
+                d.logger.Warn("Readiness check failed",
+                    zap.Error(err))
+                http.Error(w, "not ready", http.StatusServiceUnavailable)
+                return
+            }
+        }
+
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte("ok"))
+    })
+}
+
+// PreStopHandler serves the preStop hook. It starts draining and holds the
+// hook until the drain delay has passed; Kubernetes only sends SIGTERM once
+// the hook returns. Draining cannot be undone, so the hook only accepts POST
+// requests from the pod itself, as sent by an exec hook calling localhost.
+func (d *Drainer) PreStopHandler() http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            w.Header().Set("Allow", http.MethodPost)
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+        if !fromLoopback(r) {
+            d.logger.Warn("Refused preStop hook from remote address",
+                zap.String("remoteAddr", r.RemoteAddr))
+            http.Error(w, "forbidden", http.StatusForbidden)
+            return
+        }
+
+        select {
+        case <-d.Drain():
+            w.WriteHeader(http.StatusOK)
+        case <-r.Context().Done():
+        }
+    })
+}
+
+// fromLoopback reports whether the request's connection comes from a
+// loopback address. Forwarding headers are ignored, as any client can set them.
+func fromLoopback(r *http.Request) bool {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        host = r.RemoteAddr
+    }
+    ip := net.ParseIP(host)
+    return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"context"
+
+	"src/backend/file-service/pkg/requestctx"
+	"src/backend/file-service/pkg/tracing"
+)
+
+// WithContext returns a child logger adding the request ID, trace ID, user
+// and tenant carried by ctx to every entry, so entries of one request or
+// job can be correlated. Values absent from ctx are omitted.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	var fields []Field
+	if requestID := requestctx.RequestID(ctx); requestID != "" {
+		fields = append(fields, String("requestId", requestID))
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		fields = append(fields, String("traceId", traceID))
+	}
+	if userID := requestctx.UserID(ctx); userID != "" {
+		fields = append(fields, String("userId", userID))
+	}
+	if tenantID := requestctx.Tenant(ctx); tenantID != "" {
+		fields = append(fields, String("tenantId", tenantID))
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+// FromContext returns the global logger with the values carried by ctx
+func FromContext(ctx context.Context) *Logger {
+	return GetLogger().WithContext(ctx)
+}
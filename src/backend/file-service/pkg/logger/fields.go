@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap" // v1.24.0
+)
+
+// Field is a key-value pair attached to a log entry
+type Field = zap.Field
+
+// String constructs a field with a string value
+func String(key, value string) Field {
+	return zap.String(key, value)
+}
+
+// Strings constructs a field with a list of strings
+func Strings(key string, values []string) Field {
+	return zap.Strings(key, values)
+}
+
+// Int constructs a field with an int value
+func Int(key string, value int) Field {
+	return zap.Int(key, value)
+}
+
+// Int64 constructs a field with an int64 value
+func Int64(key string, value int64) Field {
+	return zap.Int64(key, value)
+}
+
+// Float64 constructs a field with a float64 value
+func Float64(key string, value float64) Field {
+	return zap.Float64(key, value)
+}
+
+// Bool constructs a field with a bool value
+func Bool(key string, value bool) Field {
+	return zap.Bool(key, value)
+}
+
+// Duration constructs a field with a duration
+func Duration(key string, value time.Duration) Field {
+	return zap.Duration(key, value)
+}
+
+// Time constructs a field with a timestamp
+func Time(key string, value time.Time) Field {
+	return zap.Time(key, value)
+}
+
+// Timep constructs a field with a timestamp, or null when value is nil
+func Timep(key string, value *time.Time) Field {
+	return zap.Timep(key, value)
+}
+
+// Binary constructs a field with opaque bytes, encoded as base64
+func Binary(key string, value []byte) Field {
+	return zap.Binary(key, value)
+}
+
+// Error constructs an "error" field from err, or a no-op field when err is nil
+func Error(err error) Field {
+	return zap.Error(err)
+}
+
+// Any constructs a field with an arbitrary value, choosing the best encoding
+func Any(key string, value interface{}) Field {
+	return zap.Any(key, value)
+}
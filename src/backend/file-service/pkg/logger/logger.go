@@ -5,20 +5,83 @@ package logger
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"sync"
+	"time"
 
-	"go.uber.org/zap" // v1.24.0
-	"go.uber.org/zap/zapcore" // v1.24.0
+	"go.uber.org/zap"                  // v1.24.0
+	"go.uber.org/zap/zapcore"          // v1.24.0
 	"gopkg.in/natefinch/lumberjack.v2" // v2.0.0
 )
 
 var (
 	// defaultLogger holds the global logger instance
-	defaultLogger *zap.Logger
+	defaultLogger *Logger
 	// loggerMutex ensures thread-safe logger operations
 	loggerMutex sync.RWMutex
 )
 
+// Logger is the structured logger used across the service. It wraps a zap
+// logger, and its fields are zap fields, so the helpers of this package and
+// zap's own can be mixed.
+type Logger struct {
+	// zap skips the Logger's own frame when reporting the caller
+	zap *zap.Logger
+}
+
+// New wraps a zap logger; nil logs nothing
+func New(z *zap.Logger) *Logger {
+	if z == nil {
+		z = zap.NewNop()
+	}
+	return &Logger{zap: z.WithOptions(zap.AddCallerSkip(1))}
+}
+
+// Debug logs a message at debug level
+func (l *Logger) Debug(msg string, fields ...Field) {
+	l.zap.Debug(msg, fields...)
+}
+
+// Info logs a message at info level
+func (l *Logger) Info(msg string, fields ...Field) {
+	l.zap.Info(msg, fields...)
+}
+
+// Warn logs a message at warn level
+func (l *Logger) Warn(msg string, fields ...Field) {
+	l.zap.Warn(msg, fields...)
+}
+
+// Error logs a message at error level, with a stack trace
+func (l *Logger) Error(msg string, fields ...Field) {
+	l.zap.Error(msg, fields...)
+}
+
+// Fatal logs a message at fatal level and exits the process
+func (l *Logger) Fatal(msg string, fields ...Field) {
+	l.zap.Fatal(msg, fields...)
+}
+
+// With returns a child logger adding fields to every entry
+func (l *Logger) With(fields ...Field) *Logger {
+	return &Logger{zap: l.zap.With(fields...)}
+}
+
+// Named returns a child logger with name appended to the logger's name
+func (l *Logger) Named(name string) *Logger {
+	return &Logger{zap: l.zap.Named(name)}
+}
+
+// Zap returns the underlying zap logger, for libraries that take one
+func (l *Logger) Zap() *zap.Logger {
+	return l.zap.WithOptions(zap.AddCallerSkip(-1))
+}
+
+// Sync flushes buffered entries
+func (l *Logger) Sync() error {
+	return l.zap.Sync()
+}
+
 // LogConfig defines the configuration parameters for the logger
 type LogConfig struct {
 	// Level defines the minimum enabled logging level (debug, info, warn, error)
@@ -33,6 +96,8 @@ type LogConfig struct {
 	EnableConsole bool
 	// Encoding specifies the log format (json or console)
 	Encoding string
+	// Recorder, when set, also keeps the most recent warnings and errors
+	Recorder *Recorder
 }
 
 // RotationConfig defines settings for log file rotation
@@ -81,7 +146,7 @@ func (c *LogConfig) Validate() error {
 
 	if c.FilePath != "" {
 		// Verify file path is writable
-		dir := c.FilePath[:len(c.FilePath)-len("/"+c.FilePath)]
+		dir := filepath.Dir(c.FilePath)
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return errors.New("unable to create log directory: " + err.Error())
 		}
@@ -90,11 +155,26 @@ func (c *LogConfig) Validate() error {
 	return c.Rotation.Validate()
 }
 
-// InitLogger initializes the global logger instance with the provided configuration
-func InitLogger(config *LogConfig) (*zap.Logger, error) {
+// InitLogger initializes the global logger instance with the provided
+// configuration. It also replaces zap's global logger, so code logging
+// through zap.L() shares its configuration.
+func InitLogger(config *LogConfig) (*Logger, error) {
 	loggerMutex.Lock()
 	defer loggerMutex.Unlock()
 
+	z, err := build(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update global logger instance
+	defaultLogger = New(z)
+	zap.ReplaceGlobals(z)
+	return defaultLogger, nil
+}
+
+// build creates a zap logger from config
+func build(config *LogConfig) (*zap.Logger, error) {
 	if err := config.Validate(); err != nil {
 		return nil, err
 	}
@@ -148,6 +228,11 @@ func InitLogger(config *LogConfig) (*zap.Logger, error) {
 		))
 	}
 
+	// Keep recent warnings and errors in memory if asked to
+	if config.Recorder != nil {
+		cores = append(cores, config.Recorder.Core())
+	}
+
 	// Create the logger
 	core := zapcore.NewTee(cores...)
 	logger := zap.New(core, 
@@ -162,13 +247,11 @@ func InitLogger(config *LogConfig) (*zap.Logger, error) {
 		}))
 	}
 
-	// Update global logger instance
-	defaultLogger = logger
 	return logger, nil
 }
 
 // GetLogger returns the global logger instance, initializing with defaults if needed
-func GetLogger() *zap.Logger {
+func GetLogger() *Logger {
 	loggerMutex.RLock()
 	if defaultLogger != nil {
 		defer loggerMutex.RUnlock()
@@ -199,11 +282,11 @@ func GetLogger() *zap.Logger {
 		},
 	}
 
-	logger, err := InitLogger(config)
+	logger, err := build(config)
 	if err != nil {
 		// Fallback to basic production logger
 		logger, _ = zap.NewProduction()
 	}
-	defaultLogger = logger
+	defaultLogger = New(logger)
 	return defaultLogger
 }
\ No newline at end of file
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore" // v1.24.0
+)
+
+// RecordedEntry is a log entry kept by a Recorder
+type RecordedEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Logger  string                 `json:"logger,omitempty"`
+	Message string                 `json:"message"`
+	Caller  string                 `json:"caller,omitempty"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+	Stack   string                 `json:"stack,omitempty"`
+}
+
+// Recorder keeps the most recent warnings and errors in memory, so they
+// can be collected from a running instance, such as into a support bundle,
+// without access to where its logs are shipped
+type Recorder struct {
+	mu      sync.Mutex
+	entries []RecordedEntry
+	next    int
+	full    bool
+}
+
+// NewRecorder creates a Recorder keeping the last capacity entries
+func NewRecorder(capacity int) *Recorder {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Recorder{entries: make([]RecordedEntry, capacity)}
+}
+
+// Entries returns the recorded entries, oldest first
+func (r *Recorder) Entries() []RecordedEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]RecordedEntry(nil), r.entries[:r.next]...)
+	}
+	entries := make([]RecordedEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+func (r *Recorder) record(entry RecordedEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = entry
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Core returns a zap core recording into r, to tee with the cores writing
+// the log
+func (r *Recorder) Core() zapcore.Core {
+	return &recorderCore{LevelEnabler: zapcore.WarnLevel, recorder: r}
+}
+
+// recorderCore is the zap core of a Recorder; fields are the context fields
+// added with With
+type recorderCore struct {
+	zapcore.LevelEnabler
+	recorder *Recorder
+	fields   []zapcore.Field
+}
+
+func (c *recorderCore) With(fields []zapcore.Field) zapcore.Core {
+	return &recorderCore{
+		LevelEnabler: c.LevelEnabler,
+		recorder:     c.recorder,
+		fields:       append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *recorderCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *recorderCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(encoder)
+	}
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	recorded := RecordedEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+		Stack:   entry.Stack,
+	}
+	if entry.Caller.Defined {
+		recorded.Caller = entry.Caller.TrimmedPath()
+	}
+	if len(encoder.Fields) > 0 {
+		recorded.Fields = encoder.Fields
+	}
+	c.recorder.record(recorded)
+	return nil
+}
+
+func (c *recorderCore) Sync() error {
+	return nil
+}
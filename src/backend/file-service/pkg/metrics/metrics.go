@@ -0,0 +1,94 @@
+// Package metrics is the instrumentation interface used across the service.
+// Components create the counters, gauges and histograms they record to from
+// a Provider, so they do not depend on how metrics are exported.
+package metrics
+
+import (
+    "context"
+)
+
+// Opts describe an instrument. Labels name the label values passed when
+// recording, in order.
+type Opts struct {
+    Name   string
+    Help   string
+    Labels []string
+    // Tenant makes a counter take the tenant ID it records for as its last
+    // label value. Providers made tenant-aware with WithTenants label the
+    // counter by tenant; others drop the value. Gauges and histograms
+    // ignore it.
+    Tenant bool
+}
+
+// HistogramOpts describe a histogram
+type HistogramOpts struct {
+    Opts
+    // Buckets are the upper bounds of the buckets; nil uses the provider's defaults
+    Buckets []float64
+    // Native also records the histogram with exponential buckets, for
+    // backends that support them
+    Native bool
+}
+
+// ExponentialBuckets returns count bucket bounds, the first being start and
+// each following one factor times the previous
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+    buckets := make([]float64, count)
+    for i := range buckets {
+        buckets[i] = start
+        start *= factor
+    }
+    return buckets
+}
+
+// Counter is a cumulative count
+type Counter interface {
+    Inc(labelValues ...string)
+    Add(delta float64, labelValues ...string)
+}
+
+// Gauge is a value that goes up and down
+type Gauge interface {
+    Set(value float64, labelValues ...string)
+    Add(delta float64, labelValues ...string)
+}
+
+// Histogram samples observations into buckets. The trace ID in ctx, if any,
+// is attached to the observation as an exemplar.
+type Histogram interface {
+    Observe(ctx context.Context, value float64, labelValues ...string)
+}
+
+// Provider creates instruments. Creating an instrument that already exists
+// returns the existing one, so components may be constructed more than once.
+type Provider interface {
+    Counter(opts Opts) Counter
+    Gauge(opts Opts) Gauge
+    Histogram(opts HistogramOpts) Histogram
+}
+
+// Nop is a Provider whose instruments discard everything recorded to them
+var Nop Provider = nopProvider{}
+
+// OrNop returns provider, or Nop when it is nil
+func OrNop(provider Provider) Provider {
+    if provider == nil {
+        return Nop
+    }
+    return provider
+}
+
+// nopProvider implements Provider without recording anything
+type nopProvider struct{}
+
+func (nopProvider) Counter(Opts) Counter              { return nopInstrument{} }
+func (nopProvider) Gauge(Opts) Gauge                  { return nopInstrument{} }
+func (nopProvider) Histogram(HistogramOpts) Histogram { return nopInstrument{} }
+
+// nopInstrument implements every instrument without recording anything
+type nopInstrument struct{}
+
+func (nopInstrument) Inc(...string)                               {}
+func (nopInstrument) Add(float64, ...string)                      {}
+func (nopInstrument) Set(float64, ...string)                      {}
+func (nopInstrument) Observe(context.Context, float64, ...string) {}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+
+    "src/backend/file-service/pkg/tracing"
+)
+
+// prometheusProvider implements Provider with Prometheus instruments
+type prometheusProvider struct {
+    reg prometheus.Registerer
+}
+
+// NewPrometheus creates a Provider registering its instruments with reg
+func NewPrometheus(reg prometheus.Registerer) Provider {
+    return &prometheusProvider{reg: reg}
+}
+
+// Counter creates a counter vector. Counters taking a tenant ID are not
+// labelled by it; see WithTenants.
+func (p *prometheusProvider) Counter(opts Opts) Counter {
+    vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: opts.Name, Help: opts.Help}, opts.Labels)
+    counter := promCounter{p.register(vec).(*prometheus.CounterVec)}
+    if opts.Tenant {
+        return tenantCounter{counter: counter}
+    }
+    return counter
+}
+
+// Gauge creates a gauge vector
+func (p *prometheusProvider) Gauge(opts Opts) Gauge {
+    vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: opts.Name, Help: opts.Help}, opts.Labels)
+    return promGauge{p.register(vec).(*prometheus.GaugeVec)}
+}
+
+// Histogram creates a histogram vector
+func (p *prometheusProvider) Histogram(opts HistogramOpts) Histogram {
+    histogramOpts := prometheus.HistogramOpts{
+        Name:    opts.Name,
+        Help:    opts.Help,
+        Buckets: opts.Buckets,
+    }
+    if opts.Native {
+        histogramOpts.NativeHistogramBucketFactor = 1.1
+        histogramOpts.NativeHistogramMaxBucketNumber = 160
+        histogramOpts.NativeHistogramMinResetDuration = time.Hour
+    }
+    vec := prometheus.NewHistogramVec(histogramOpts, opts.Labels)
+    return promHistogram{p.register(vec).(*prometheus.HistogramVec)}
+}
+
+// register registers c, returning the collector registered before it when
+// the instrument already exists. Other failures are programming errors in
+// the instrument's definition, so they panic like prometheus.MustRegister.
+func (p *prometheusProvider) register(c prometheus.Collector) prometheus.Collector {
+    err := p.reg.Register(c)
+    if err == nil {
+        return c
+    }
+
+    var registered prometheus.AlreadyRegisteredError
+    if errors.As(err, &registered) {
+        return registered.ExistingCollector
+    }
+    panic(fmt.Sprintf("metrics: %v", err))
+}
+
+// promCounter implements Counter
+type promCounter struct {
+    vec *prometheus.CounterVec
+}
+
+func (c promCounter) Inc(labelValues ...string) {
+    c.vec.WithLabelValues(labelValues...).Inc()
+}
+
+func (c promCounter) Add(delta float64, labelValues ...string) {
+    c.vec.WithLabelValues(labelValues...).Add(delta)
+}
+
+// promGauge implements Gauge
+type promGauge struct {
+    vec *prometheus.GaugeVec
+}
+
+func (g promGauge) Set(value float64, labelValues ...string) {
+    g.vec.WithLabelValues(labelValues...).Set(value)
+}
+
+func (g promGauge) Add(delta float64, labelValues ...string) {
+    g.vec.WithLabelValues(labelValues...).Add(delta)
+}
+
+// promHistogram implements Histogram
+type promHistogram struct {
+    vec *prometheus.HistogramVec
+}
+
+func (h promHistogram) Observe(ctx context.Context, value float64, labelValues ...string) {
+    tracing.Observe(ctx, h.vec.WithLabelValues(labelValues...), value)
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+    "sort"
+    "sync"
+    "time"
+)
+
+// Tenant label values that are not tenant IDs
+const (
+    // OtherTenant labels the tenants outside the limit
+    OtherTenant = "other"
+    // NoTenant labels work done for no tenant
+    NoTenant = "none"
+)
+
+// TenantLabel is the label counters created with Opts.Tenant are given by
+// WithTenants
+const TenantLabel = "tenant"
+
+// trackedPerLabel bounds the tenants a TenantLimiter counts per window to a
+// multiple of its limit; tenants beyond it are not candidates for labels
+// in the next window
+const trackedPerLabel = 10
+
+// TenantLimiter bounds the tenants that get a label of their own. The
+// busiest limit tenants of the previous window keep their labels for the
+// current one; while fewer than limit have labels, tenants are given them
+// as they are first seen. Everyone else is counted as OtherTenant, so a
+// counter has at most limit+2 series per combination of its other labels.
+type TenantLimiter struct {
+    limit  int
+    window time.Duration
+
+    mu          sync.Mutex
+    windowStart time.Time
+    labelled    map[string]bool
+    counts      map[string]int
+}
+
+// NewTenantLimiter creates a TenantLimiter labelling up to limit tenants,
+// chosen again every window
+func NewTenantLimiter(limit int, window time.Duration) *TenantLimiter {
+    return &TenantLimiter{
+        limit:       limit,
+        window:      window,
+        windowStart: time.Now(),
+        labelled:    make(map[string]bool),
+        counts:      make(map[string]int),
+    }
+}
+
+// Label counts a recording for tenantID and returns its label value
+func (l *TenantLimiter) Label(tenantID string) string {
+    if tenantID == "" {
+        return NoTenant
+    }
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if now := time.Now(); now.Sub(l.windowStart) >= l.window {
+        l.rotate(now)
+    }
+
+    if _, ok := l.counts[tenantID]; ok || len(l.counts) < l.limit*trackedPerLabel {
+        l.counts[tenantID]++
+    }
+    if !l.labelled[tenantID] && len(l.labelled) < l.limit {
+        l.labelled[tenantID] = true
+    }
+    if !l.labelled[tenantID] {
+        return OtherTenant
+    }
+    return tenantID
+}
+
+// rotate starts a window at now, labelling the busiest tenants of the
+// window that ended
+func (l *TenantLimiter) rotate(now time.Time) {
+    tenants := make([]string, 0, len(l.counts))
+    for tenantID := range l.counts {
+        tenants = append(tenants, tenantID)
+    }
+    sort.Slice(tenants, func(i, j int) bool {
+        if l.counts[tenants[i]] != l.counts[tenants[j]] {
+            return l.counts[tenants[i]] > l.counts[tenants[j]]
+        }
+        return tenants[i] < tenants[j]
+    })
+    if len(tenants) > l.limit {
+        tenants = tenants[:l.limit]
+    }
+
+    l.labelled = make(map[string]bool, l.limit)
+    for _, tenantID := range tenants {
+        l.labelled[tenantID] = true
+    }
+    l.counts = make(map[string]int)
+    l.windowStart = now
+}
+
+// WithTenants returns a Provider creating instruments from provider whose
+// counters created with Opts.Tenant are labelled by tenant, limited by
+// limiter. A nil limiter returns provider unchanged.
+func WithTenants(provider Provider, limiter *TenantLimiter) Provider {
+    if limiter == nil {
+        return provider
+    }
+    return tenantProvider{Provider: OrNop(provider), limiter: limiter}
+}
+
+// tenantProvider implements Provider, adding the tenant label to counters
+// taking a tenant ID
+type tenantProvider struct {
+    Provider
+    limiter *TenantLimiter
+}
+
+// Counter creates a counter, labelled by tenant if opts.Tenant is set
+func (p tenantProvider) Counter(opts Opts) Counter {
+    if !opts.Tenant {
+        return p.Provider.Counter(opts)
+    }
+    opts.Labels = append(append([]string(nil), opts.Labels...), TenantLabel)
+    opts.Tenant = false
+    return tenantCounter{counter: p.Provider.Counter(opts), limiter: p.limiter}
+}
+
+// tenantCounter implements Counter for counters taking a tenant ID as
+// their last label value. With a limiter the value is replaced by the
+// tenant's label; without one it is dropped.
+type tenantCounter struct {
+    counter Counter
+    limiter *TenantLimiter
+}
+
+func (c tenantCounter) Inc(labelValues ...string) {
+    c.counter.Inc(c.labelValues(labelValues)...)
+}
+
+func (c tenantCounter) Add(delta float64, labelValues ...string) {
+    c.counter.Add(delta, c.labelValues(labelValues)...)
+}
+
+// labelValues returns labelValues with the tenant ID replaced or dropped
+func (c tenantCounter) labelValues(labelValues []string) []string {
+    if len(labelValues) == 0 {
+        return labelValues
+    }
+    last := len(labelValues) - 1
+    if c.limiter == nil {
+        return labelValues[:last]
+    }
+    values := append([]string(nil), labelValues[:last]...)
+    return append(values, c.limiter.Label(labelValues[last]))
+}
@@ -0,0 +1,65 @@
+// Package notify delivers user notifications by email or webhook
+package notify
+
+import (
+    "context"
+    "errors"
+    "strings"
+    "time"
+
+    "src/backend/file-service/pkg/webhook"
+)
+
+// ErrInvalidMessage is returned when a message has no recipient or an unsafe header value
+var ErrInvalidMessage = errors.New("invalid notification message")
+
+// Message is a single notification. Email notifiers send Subject and Body to
+// the To address; webhook notifiers post Data as an Event to the To URL.
+type Message struct {
+    To      string
+    Subject string
+    Body    string
+    Event   string
+    Data    interface{}
+}
+
+// Notifier delivers messages over one channel
+type Notifier interface {
+    Notify(ctx context.Context, msg Message) error
+}
+
+// validateEmail rejects messages that could inject extra mail headers
+func validateEmail(msg Message) error {
+    if msg.To == "" || strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+        return ErrInvalidMessage
+    }
+    return nil
+}
+
+// WebhookNotifier posts messages to the per-user webhook URL in Message.To,
+// signed with a shared secret
+type WebhookNotifier struct {
+    secret  string
+    timeout time.Duration
+}
+
+// NewWebhook creates a webhook notifier
+func NewWebhook(secret string, timeout time.Duration) (*WebhookNotifier, error) {
+    if timeout <= 0 {
+        return nil, errors.New("webhook timeout must be positive")
+    }
+    return &WebhookNotifier{secret: secret, timeout: timeout}, nil
+}
+
+// Notify posts the message's event to its URL
+func (n *WebhookNotifier) Notify(ctx context.Context, msg Message) error {
+    if msg.To == "" || msg.Event == "" {
+        return ErrInvalidMessage
+    }
+
+    client, err := webhook.New(msg.To, n.secret, n.timeout)
+    if err != nil {
+        return err
+    }
+    return client.Send(ctx, msg.Event, msg.Data)
+}
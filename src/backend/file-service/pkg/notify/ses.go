@@ -0,0 +1,71 @@
+package notify
+
+import (
+    "context"
+    "errors"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/config"
+    "github.com/aws/aws-sdk-go-v2/service/sesv2"
+    "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+// SESClient is the subset of the SES v2 API used to send email
+type SESClient interface {
+    SendEmail(ctx context.Context, params *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error)
+}
+
+// SESNotifier sends plain text email through Amazon SES
+type SESNotifier struct {
+    client SESClient
+    from   string
+}
+
+// NewSES creates an SES notifier using the default AWS credential chain
+func NewSES(ctx context.Context, region, from string) (*SESNotifier, error) {
+    if region == "" {
+        return nil, errors.New("SES region is required")
+    }
+
+    awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+    if err != nil {
+        return nil, fmt.Errorf("failed to load AWS config: %w", err)
+    }
+    return NewSESWithClient(sesv2.NewFromConfig(awsCfg), from)
+}
+
+// NewSESWithClient creates an SES notifier around an existing client
+func NewSESWithClient(client SESClient, from string) (*SESNotifier, error) {
+    if client == nil {
+        return nil, errors.New("SES client is required")
+    }
+    if from == "" {
+        return nil, errors.New("sender address is required")
+    }
+    return &SESNotifier{client: client, from: from}, nil
+}
+
+// Notify sends the message
+func (n *SESNotifier) Notify(ctx context.Context, msg Message) error {
+    if err := validateEmail(msg); err != nil {
+        return err
+    }
+
+    _, err := n.client.SendEmail(ctx, &sesv2.SendEmailInput{
+        FromEmailAddress: aws.String(n.from),
+        Destination:      &types.Destination{ToAddresses: []string{msg.To}},
+        Content: &types.EmailContent{
+            Simple: &types.Message{
+                Subject: &types.Content{Data: aws.String(msg.Subject), Charset: aws.String("UTF-8")},
+                Body: &types.Body{
+                    Text: &types.Content{Data: aws.String(msg.Body), Charset: aws.String("UTF-8")},
+                },
+            },
+        },
+    })
+    if err != nil {
+        return fmt.Errorf("SES delivery failed: %w", err)
+    }
+    return nil
+}
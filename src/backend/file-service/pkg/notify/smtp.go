@@ -0,0 +1,110 @@
+package notify
+
+import (
+    "bytes"
+    "context"
+    "crypto/tls"
+    "errors"
+    "fmt"
+    "mime"
+    "net"
+    "net/smtp"
+    "strconv"
+    "time"
+)
+
+// SMTPNotifier sends plain text email through an SMTP relay, upgrading the
+// connection with STARTTLS when the server offers it
+type SMTPNotifier struct {
+    addr     string
+    host     string
+    username string
+    password string
+    from     string
+}
+
+// NewSMTP creates an SMTP notifier. Authentication is skipped when username is empty.
+func NewSMTP(host string, port int, username, password, from string) (*SMTPNotifier, error) {
+    if host == "" || port <= 0 {
+        return nil, errors.New("SMTP host and port are required")
+    }
+    if from == "" {
+        return nil, errors.New("sender address is required")
+    }
+
+    return &SMTPNotifier{
+        addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+        host:     host,
+        username: username,
+        password: password,
+        from:     from,
+    }, nil
+}
+
+// Notify sends the message, giving up when ctx is done
+func (n *SMTPNotifier) Notify(ctx context.Context, msg Message) error {
+    if err := validateEmail(msg); err != nil {
+        return err
+    }
+
+    var dialer net.Dialer
+    conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+    if err != nil {
+        return fmt.Errorf("failed to connect to SMTP server: %w", err)
+    }
+    if deadline, ok := ctx.Deadline(); ok {
+        conn.SetDeadline(deadline)
+    }
+
+    client, err := smtp.NewClient(conn, n.host)
+    if err != nil {
+        conn.Close()
+        return fmt.Errorf("failed to start SMTP session: %w", err)
+    }
+    defer client.Close()
+
+    if ok, _ := client.Extension("STARTTLS"); ok {
+        if err := client.StartTLS(&tls.Config{ServerName: n.host, MinVersion: tls.VersionTLS12}); err != nil {
+            return fmt.Errorf("failed to start TLS: %w", err)
+        }
+    }
+    if n.username != "" {
+        if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+            return fmt.Errorf("SMTP authentication failed: %w", err)
+        }
+    }
+
+    if err := client.Mail(n.from); err != nil {
+        return fmt.Errorf("SMTP sender rejected: %w", err)
+    }
+    if err := client.Rcpt(msg.To); err != nil {
+        return fmt.Errorf("SMTP recipient rejected: %w", err)
+    }
+
+    w, err := client.Data()
+    if err != nil {
+        return fmt.Errorf("failed to start SMTP data: %w", err)
+    }
+    if _, err := w.Write(n.compose(msg)); err != nil {
+        w.Close()
+        return fmt.Errorf("failed to write message: %w", err)
+    }
+    if err := w.Close(); err != nil {
+        return fmt.Errorf("SMTP server rejected message: %w", err)
+    }
+    return client.Quit()
+}
+
+// compose renders the message headers and body
+func (n *SMTPNotifier) compose(msg Message) []byte {
+    var buf bytes.Buffer
+    fmt.Fprintf(&buf, "From: %s\r\n", n.from)
+    fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+    fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+    fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+    buf.WriteString("MIME-Version: 1.0\r\n")
+    buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+    buf.WriteString("\r\n")
+    buf.WriteString(msg.Body)
+    return buf.Bytes()
+}
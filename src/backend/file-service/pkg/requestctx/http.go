@@ -0,0 +1,53 @@
+package requestctx
+
+import (
+    "net"
+    "net/http"
+
+    "github.com/google/uuid" // v1.3.0
+)
+
+// RequestIDHeader carries the request ID from clients and back to them
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// Middleware stores the request ID and the client IP in the request context.
+// The request ID is taken from the X-Request-Id header, or generated when it
+// is missing or malformed, and echoed in the response.
+func Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requestID := r.Header.Get(RequestIDHeader)
+        if !validRequestID(requestID) {
+            requestID = uuid.NewString()
+        }
+
+        w.Header().Set(RequestIDHeader, requestID)
+        ctx := WithClientIP(WithRequestID(r.Context(), requestID), RemoteIP(r))
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// RemoteIP returns the IP address of the peer that sent r
+func RemoteIP(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
+
+// validRequestID checks that a client's request ID is short printable ASCII,
+// so it is safe to log and echo
+func validRequestID(requestID string) bool {
+    if requestID == "" || len(requestID) > maxRequestIDLength {
+        return false
+    }
+    for i := 0; i < len(requestID); i++ {
+        if requestID[i] <= ' ' || requestID[i] > '~' {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,119 @@
+// Package requestctx carries who a unit of work runs for and where it came
+// from: the authenticated principal, its tenant, the request ID and the
+// client IP. Every transport sets them through this package and background
+// jobs inherit them from the context they are started with, so request
+// handling and jobs see the same identity.
+package requestctx
+
+import (
+    "context"
+)
+
+// Principal is the authenticated caller of a request, whether a user with a
+// token or a workload with an X.509 SVID
+type Principal struct {
+    UserID      string
+    Email       string
+    Roles       []string
+    Permissions []string
+    TenantID    string
+    DeviceID    string
+    // SPIFFEID is set for workloads authenticated by their SVID
+    SPIFFEID string
+}
+
+// HasRole reports whether the principal has any of the given roles
+func (p *Principal) HasRole(roles ...string) bool {
+    if p == nil {
+        return false
+    }
+    for _, role := range roles {
+        for _, held := range p.Roles {
+            if role == held {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// Context keys of the values set by this package
+type (
+    principalKey struct{}
+    tenantKey    struct{}
+    requestIDKey struct{}
+    clientIPKey  struct{}
+)
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+    return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the authenticated principal carried by ctx
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+    principal, ok := ctx.Value(principalKey{}).(*Principal)
+    return principal, ok && principal != nil
+}
+
+// UserID returns the ID of the authenticated principal, or ""
+func UserID(ctx context.Context) string {
+    if principal, ok := PrincipalFrom(ctx); ok {
+        return principal.UserID
+    }
+    return ""
+}
+
+// Roles returns the roles of the authenticated principal, or nil
+func Roles(ctx context.Context) []string {
+    if principal, ok := PrincipalFrom(ctx); ok {
+        return principal.Roles
+    }
+    return nil
+}
+
+// HasRole reports whether the authenticated principal has any of the given roles
+func HasRole(ctx context.Context, roles ...string) bool {
+    principal, _ := PrincipalFrom(ctx)
+    return principal.HasRole(roles...)
+}
+
+// WithTenant returns a copy of ctx acting for tenantID, such as a background
+// job working through one tenant's files, whatever the principal's tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+    return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// Tenant returns the tenant ctx acts for: the one set by WithTenant, else
+// the authenticated principal's, else ""
+func Tenant(ctx context.Context) string {
+    if tenantID, ok := ctx.Value(tenantKey{}).(string); ok {
+        return tenantID
+    }
+    if principal, ok := PrincipalFrom(ctx); ok {
+        return principal.TenantID
+    }
+    return ""
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of its request
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+    return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the ID of the request carried by ctx, or ""
+func RequestID(ctx context.Context) string {
+    requestID, _ := ctx.Value(requestIDKey{}).(string)
+    return requestID
+}
+
+// WithClientIP returns a copy of ctx carrying the IP address of the client
+func WithClientIP(ctx context.Context, ip string) context.Context {
+    return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the IP address of the client carried by ctx, or ""
+func ClientIP(ctx context.Context) string {
+    ip, _ := ctx.Value(clientIPKey{}).(string)
+    return ip
+}
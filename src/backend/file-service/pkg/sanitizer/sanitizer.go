@@ -0,0 +1,85 @@
+// Package sanitizer produces script-free copies of HTML and SVG uploads so they
+// can be rendered inline in browsers without exposing users to stored XSS.
+package sanitizer
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "strings"
+
+    "github.com/microcosm-cc/bluemonday" // v1.0.26
+)
+
+// Supported content types
+const (
+    ContentTypeHTML  = "text/html"
+    ContentTypeXHTML = "application/xhtml+xml"
+    ContentTypeSVG   = "image/svg+xml"
+)
+
+// ErrUnsupportedType is returned for content types the sanitizer cannot handle
+var ErrUnsupportedType = errors.New("content type cannot be sanitized")
+
+// Sanitizer strips scripts, event handlers and external references from markup
+type Sanitizer struct {
+    htmlPolicy *bluemonday.Policy
+}
+
+// New creates a Sanitizer with a strict policy that only allows formatting
+// markup, in-document links and inline data images
+func New() *Sanitizer {
+    policy := bluemonday.NewPolicy()
+    policy.AllowStandardAttributes()
+    policy.AllowElements(
+        "html", "head", "body", "title", "article", "section", "header", "footer", "main", "nav", "aside",
+        "h1", "h2", "h3", "h4", "h5", "h6", "p", "div", "span", "br", "hr", "blockquote", "pre", "code",
+        "b", "i", "u", "s", "em", "strong", "small", "sub", "sup", "mark", "abbr", "cite", "q",
+        "ul", "ol", "li", "dl", "dt", "dd", "figure", "figcaption",
+    )
+    policy.AllowTables()
+    policy.AllowLists()
+    policy.AllowAttrs("href").OnElements("a")
+    policy.AllowAttrs("src", "alt", "width", "height").OnElements("img")
+    policy.AllowURLSchemes("mailto")
+    policy.AllowRelativeURLs(false)
+    policy.AllowDataURIImages()
+    policy.RequireParseableURLs(true)
+
+    return &Sanitizer{htmlPolicy: policy}
+}
+
+// Supports reports whether the content type can be sanitized
+func (s *Sanitizer) Supports(contentType string) bool {
+    switch mediaType(contentType) {
+    case ContentTypeHTML, ContentTypeXHTML, ContentTypeSVG:
+        return true
+    }
+    return false
+}
+
+// Sanitize returns a sanitized copy of the markup read from r
+func (s *Sanitizer) Sanitize(contentType string, r io.Reader) ([]byte, error) {
+    switch mediaType(contentType) {
+    case ContentTypeHTML, ContentTypeXHTML:
+        return s.htmlPolicy.SanitizeReader(r).Bytes(), nil
+    case ContentTypeSVG:
+        var out bytes.Buffer
+        if err := sanitizeSVG(r, &out); err != nil {
+            return nil, fmt.Errorf("svg sanitization failed: %w", err)
+        }
+        return out.Bytes(), nil
+    }
+    return nil, ErrUnsupportedType
+}
+
+// mediaType normalizes a content type to its lower-case media type
+func mediaType(contentType string) string {
+    parsed, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return strings.ToLower(strings.TrimSpace(contentType))
+    }
+    return parsed
+}
@@ -0,0 +1,159 @@
+package sanitizer
+
+import (
+    "encoding/xml"
+    "errors"
+    "io"
+    "strings"
+)
+
+// svgElements lists the SVG elements kept in sanitized output; anything else
+// is removed together with its children
+var svgElements = map[string]bool{
+    "svg": true, "g": true, "defs": true, "title": true, "desc": true, "symbol": true, "use": true,
+    "path": true, "rect": true, "circle": true, "ellipse": true, "line": true, "polyline": true, "polygon": true,
+    "text": true, "tspan": true, "textPath": true, "image": true, "marker": true, "pattern": true,
+    "linearGradient": true, "radialGradient": true, "stop": true, "clipPath": true, "mask": true,
+    "filter": true, "feGaussianBlur": true, "feOffset": true, "feBlend": true, "feColorMatrix": true,
+    "feComposite": true, "feFlood": true, "feMerge": true, "feMergeNode": true, "feMorphology": true,
+}
+
+// maxSVGDepth bounds element nesting to defend against pathological documents
+const maxSVGDepth = 256
+
+var (
+    errSVGTooDeep    = errors.New("svg nesting too deep")
+    errSVGUnbalanced = errors.New("svg elements are not balanced")
+)
+
+// sanitizeSVG copies the SVG document from r to w, dropping scripts, foreign
+// content, animation elements, event handlers and external references
+func sanitizeSVG(r io.Reader, w io.Writer) error {
+    decoder := xml.NewDecoder(r)
+    decoder.Strict = true
+
+    var out strings.Builder
+    var open []xml.Name
+    skipDepth := 0
+
+    for {
+        token, err := decoder.RawToken()
+        if err == io.EOF {
+            if len(open) > 0 {
+                return errSVGUnbalanced
+            }
+            break
+        }
+        if err != nil {
+            return err
+        }
+
+        switch t := token.(type) {
+        case xml.StartElement:
+            open = append(open, t.Name)
+            depth := len(open)
+            if depth > maxSVGDepth {
+                return errSVGTooDeep
+            }
+            if skipDepth > 0 || !svgElements[t.Name.Local] {
+                if skipDepth == 0 {
+                    skipDepth = depth
+                }
+                continue
+            }
+            writeStartElement(&out, t)
+
+        case xml.EndElement:
+            // RawToken does not match end tags, so nesting is checked here
+            if len(open) == 0 || open[len(open)-1] != t.Name {
+                return errSVGUnbalanced
+            }
+            depth := len(open)
+            open = open[:depth-1]
+            if skipDepth > 0 {
+                if depth == skipDepth {
+                    skipDepth = 0
+                }
+                continue
+            }
+            out.WriteString("</" + qualifiedName(t.Name) + ">")
+
+        case xml.CharData:
+            if skipDepth == 0 {
+                xml.EscapeText(&out, t)
+            }
+
+        case xml.ProcInst:
+            // Only the XML declaration is kept; xml-stylesheet and others can load external content
+            if t.Target == "xml" && skipDepth == 0 {
+                out.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>")
+            }
+
+        // Comments and directives (including DOCTYPE entity declarations) are dropped
+        }
+    }
+
+    _, err := io.WriteString(w, out.String())
+    return err
+}
+
+// writeStartElement writes the element with only its safe attributes
+func writeStartElement(out *strings.Builder, element xml.StartElement) {
+    out.WriteString("<" + qualifiedName(element.Name))
+    for _, attr := range element.Attr {
+        if !safeSVGAttr(element.Name.Local, attr) {
+            continue
+        }
+        out.WriteString(" " + qualifiedName(attr.Name) + "=\"")
+        xml.EscapeText(out, []byte(attr.Value))
+        out.WriteString("\"")
+    }
+    out.WriteString(">")
+}
+
+// safeSVGAttr reports whether an attribute can be kept on the given element
+func safeSVGAttr(element string, attr xml.Attr) bool {
+    name := strings.ToLower(attr.Name.Local)
+    value := strings.ToLower(strings.TrimSpace(attr.Value))
+
+    // Event handlers
+    if strings.HasPrefix(name, "on") {
+        return false
+    }
+
+    // References may only point inside the document, except data images on <image>
+    if name == "href" || name == "src" {
+        if strings.HasPrefix(value, "#") {
+            return true
+        }
+        return element == "image" && strings.HasPrefix(value, "data:image/") && !strings.HasPrefix(value, "data:image/svg")
+    }
+
+    if strings.Contains(value, "javascript:") || strings.Contains(value, "expression(") || strings.Contains(value, "@import") {
+        return false
+    }
+
+    return onlyLocalURLs(value)
+}
+
+// onlyLocalURLs reports whether every url(...) in the value is a fragment reference
+func onlyLocalURLs(value string) bool {
+    for {
+        i := strings.Index(value, "url(")
+        if i < 0 {
+            return true
+        }
+        value = strings.TrimLeft(value[i+len("url("):], " \t\n'\"")
+        if !strings.HasPrefix(value, "#") {
+            return false
+        }
+    }
+}
+
+// qualifiedName renders a raw XML name with its prefix
+func qualifiedName(name xml.Name) string {
+    if name.Space != "" {
+        return name.Space + ":" + name.Local
+    }
+    return name.Local
+}
@@ -0,0 +1,283 @@
+// Package storagekey defines the layout of object keys in the bucket. File
+// content is stored under a key sharded by the file ID, ab/cd/abcd..., which
+// spreads objects over S3 partitions. Scratch copies, derived objects,
+// previews, the content of soft-deleted files, quarantined objects and
+// staged uploads nest the same layout under their own top-level prefix, and tenant-scoped keys
+// are nested under tenants/{tenantID}/.
+//
+// Keys written before sharding was introduced are the bare file ID. They are
+// still accepted, and Canonical returns the sharded key to move them to.
+package storagekey
+
+import (
+    "errors"
+    "path"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+)
+
+// Top-level prefixes of keys that are not file content
+const (
+    Scratch     = "scratch"
+    Derived     = "derived"
+    Previews    = "previews"
+    SoftDeleted = "archive"
+    Quarantine  = "quarantine"
+    Staging     = "staging"
+)
+
+// TenantPrefix is the top-level prefix of tenant-scoped keys
+const TenantPrefix = "tenants"
+
+// MaxLength is the longest object key S3 accepts, in bytes
+const MaxLength = 1024
+
+// Errors returned by Policy.Validate
+var (
+    ErrEmpty          = errors.New("storage key is empty")
+    ErrTooLong        = errors.New("storage key is too long")
+    ErrInvalidSegment = errors.New("storage key has an empty, relative or unprintable segment")
+    ErrLayout         = errors.New("storage key has an unknown prefix or is not sharded by its ID")
+    ErrLegacy         = errors.New("storage key uses the legacy unsharded layout")
+    ErrTenantMismatch = errors.New("storage key is not scoped to the tenant")
+    ErrInvalidPrefix  = errors.New("storage key prefix must be one segment, unlike a shard or the tenant prefix")
+)
+
+// Policy is a key layout. Content keys are sharded into ShardLevels
+// directories, each named after the next ShardWidth characters of the ID.
+type Policy struct {
+    // Prefixes are the top-level prefixes keys may be nested under. Keys
+    // under a prefix may have further segments after the sharded ID, as
+    // derived objects do.
+    Prefixes    []string
+    ShardLevels int
+    ShardWidth  int
+    // MaxLength bounds keys in bytes
+    MaxLength int
+    // AllowLegacy accepts keys in the legacy unsharded layout
+    AllowLegacy bool
+    // TenantKeys requires the keys checked by ValidateTenant to be scoped
+    // to the tenant; otherwise only keys scoped to another tenant are refused
+    TenantKeys bool
+}
+
+// Default is the layout of the keys the service writes
+var Default = Policy{
+    Prefixes:    []string{Scratch, Derived, Previews, SoftDeleted, Quarantine, Staging},
+    ShardLevels: 2,
+    ShardWidth:  2,
+    MaxLength:   MaxLength,
+    AllowLegacy: true,
+}
+
+// WithPrefix returns the policy with prefix added to its prefixes, so keys
+// nested under a configured prefix follow the layout. Prefixes as long as a
+// shard would be taken for one.
+func (p Policy) WithPrefix(prefix string) (Policy, error) {
+    if strings.Contains(prefix, "/") || !validSegment(prefix) || prefix == TenantPrefix ||
+        len(prefix) == p.ShardWidth {
+        return p, ErrInvalidPrefix
+    }
+    if p.hasPrefix(prefix) {
+        return p, nil
+    }
+    p.Prefixes = append(append([]string(nil), p.Prefixes...), prefix)
+    return p, nil
+}
+
+// Key returns the content key of the given ID. IDs too short to shard keep
+// the legacy layout.
+func (p Policy) Key(id string) string {
+    if len(id) < p.ShardLevels*p.ShardWidth {
+        return id
+    }
+    segments := make([]string, 0, p.ShardLevels+1)
+    for level := 0; level < p.ShardLevels; level++ {
+        segments = append(segments, id[level*p.ShardWidth:(level+1)*p.ShardWidth])
+    }
+    return path.Join(append(segments, id)...)
+}
+
+// Nested returns the key of the given ID under prefix
+func (p Policy) Nested(prefix, id string) string {
+    return path.Join(prefix, p.Key(id))
+}
+
+// ForTenant scopes key to a tenant
+func (p Policy) ForTenant(tenantID, key string) string {
+    return path.Join(TenantPrefix, tenantID, key)
+}
+
+// Tenant returns the tenant key is scoped to, or "" for unscoped keys
+func (p Policy) Tenant(key string) string {
+    parsed, err := p.parse(key)
+    if err != nil {
+        return ""
+    }
+    return parsed.tenantID
+}
+
+// Unscoped returns key without its tenant scope, if it has one
+func (p Policy) Unscoped(key string) string {
+    tenantID := p.Tenant(key)
+    if tenantID == "" {
+        return key
+    }
+    return strings.TrimPrefix(key, p.ForTenant(tenantID, "")+"/")
+}
+
+// Under nests key below prefix, keeping its tenant scope: a tenant's key
+// nests within the tenant's prefix rather than being scoped under prefix
+func (p Policy) Under(prefix, key string) string {
+    tenantID := p.Tenant(key)
+    if tenantID == "" {
+        return path.Join(prefix, key)
+    }
+    return p.ForTenant(tenantID, path.Join(prefix, p.Unscoped(key)))
+}
+
+// Validate checks that key follows the layout
+func (p Policy) Validate(key string) error {
+    parsed, err := p.parse(key)
+    if err != nil {
+        return err
+    }
+    if parsed.isLegacy() {
+        if !p.AllowLegacy {
+            return ErrLegacy
+        }
+        return nil
+    }
+    return p.checkShards(parsed)
+}
+
+// ValidateTenant checks that key follows the layout and may be used by the
+// tenant: keys scoped to another tenant are refused, and when TenantKeys is
+// set so are keys not scoped to tenantID
+func (p Policy) ValidateTenant(key, tenantID string) error {
+    if err := p.Validate(key); err != nil {
+        return err
+    }
+    parsed, _ := p.parse(key)
+    if parsed.tenantID != "" && parsed.tenantID != tenantID {
+        return ErrTenantMismatch
+    }
+    if p.TenantKeys && tenantID != "" && parsed.tenantID == "" {
+        return ErrTenantMismatch
+    }
+    return nil
+}
+
+// IsLegacy checks if key is a valid key in the legacy unsharded layout
+func (p Policy) IsLegacy(key string) bool {
+    parsed, err := p.parse(key)
+    return err == nil && parsed.isLegacy()
+}
+
+// Canonical returns the sharded key a legacy key moves to, keeping its
+// tenant scope and prefix, and whether key is a legacy key at all
+func (p Policy) Canonical(key string) (string, bool) {
+    if !p.IsLegacy(key) {
+        return key, false
+    }
+    dir, id := path.Split(key)
+    canonical := path.Join(dir, p.Key(id))
+    return canonical, canonical != key
+}
+
+// ID returns the ID of the file a key in the sharded layout belongs to,
+// including keys nested under a prefix or scoped to a tenant, and whether
+// key is such a key. Legacy keys and keys of other layouts have none.
+func (p Policy) ID(key string) (string, bool) {
+    parsed, err := p.parse(key)
+    if err != nil || parsed.isLegacy() || p.checkShards(parsed) != nil {
+        return "", false
+    }
+    return parsed.segments[p.ShardLevels], true
+}
+
+// parsedKey is a key split into its tenant scope, prefix and the segments
+// below them
+type parsedKey struct {
+    tenantID string
+    prefixed bool
+    segments []string
+}
+
+// isLegacy checks if the segments below the prefix are a bare ID
+func (k parsedKey) isLegacy() bool {
+    return len(k.segments) == 1
+}
+
+// parse checks the length and segments of key and splits it
+func (p Policy) parse(key string) (parsedKey, error) {
+    if key == "" {
+        return parsedKey{}, ErrEmpty
+    }
+    if p.MaxLength > 0 && len(key) > p.MaxLength {
+        return parsedKey{}, ErrTooLong
+    }
+
+    parsed := parsedKey{segments: strings.Split(key, "/")}
+    for _, segment := range parsed.segments {
+        if !validSegment(segment) {
+            return parsedKey{}, ErrInvalidSegment
+        }
+    }
+
+    if parsed.segments[0] == TenantPrefix {
+        if len(parsed.segments) < 3 {
+            return parsedKey{}, ErrLayout
+        }
+        parsed.tenantID, parsed.segments = parsed.segments[1], parsed.segments[2:]
+    }
+    if len(parsed.segments) > 1 && p.hasPrefix(parsed.segments[0]) {
+        parsed.prefixed, parsed.segments = true, parsed.segments[1:]
+    }
+    return parsed, nil
+}
+
+// checkShards checks that the segments below the prefix start with the
+// shards of the ID that follows them. Only prefixed keys have further
+// segments after the ID.
+func (p Policy) checkShards(parsed parsedKey) error {
+    segments := parsed.segments
+    if len(segments) < p.ShardLevels+1 || (!parsed.prefixed && len(segments) > p.ShardLevels+1) {
+        return ErrLayout
+    }
+    id := segments[p.ShardLevels]
+    if len(id) < p.ShardLevels*p.ShardWidth {
+        return ErrLayout
+    }
+    for level := 0; level < p.ShardLevels; level++ {
+        if segments[level] != id[level*p.ShardWidth:(level+1)*p.ShardWidth] {
+            return ErrLayout
+        }
+    }
+    return nil
+}
+
+// hasPrefix checks if segment is one of the policy's prefixes
+func (p Policy) hasPrefix(segment string) bool {
+    for _, prefix := range p.Prefixes {
+        if segment == prefix {
+            return true
+        }
+    }
+    return false
+}
+
+// validSegment checks that a key segment is non-empty, not relative and
+// printable UTF-8 without backslashes
+func validSegment(segment string) bool {
+    if segment == "" || segment == "." || segment == ".." || !utf8.ValidString(segment) {
+        return false
+    }
+    for _, r := range segment {
+        if r == '\\' || !unicode.IsPrint(r) {
+            return false
+        }
+    }
+    return true
+}
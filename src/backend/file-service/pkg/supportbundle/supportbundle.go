@@ -0,0 +1,210 @@
+// Package supportbundle collects the diagnostics of a running instance into
+// a zip archive to attach to support tickets: the sections the service adds,
+// such as its configuration and the health of its dependencies, alongside
+// its recent warnings and errors, a metric snapshot and a goroutine dump.
+// Sections are collected independently; one that fails is listed with its
+// error in the bundle's manifest rather than failing the bundle.
+package supportbundle
+
+import (
+    "archive/zip"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "runtime/pprof"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+    "github.com/prometheus/common/expfmt"            // v0.42.0
+
+    "src/backend/file-service/pkg/buildinfo"
+    "src/backend/file-service/pkg/errtrack"
+    "src/backend/file-service/pkg/logger"
+)
+
+// ManifestName is the name of the manifest in a bundle
+const ManifestName = "manifest.json"
+
+// sectionTimeout bounds the collection of each section, so one slow
+// dependency does not hold up the bundle
+const sectionTimeout = 10 * time.Second
+
+// Section is a file of a bundle, whose content Collect writes
+type Section struct {
+    Name    string
+    Collect func(ctx context.Context, w io.Writer) error
+}
+
+// SectionResult records how collecting a section went
+type SectionResult struct {
+    Name  string `json:"name"`
+    Bytes int64  `json:"bytes"`
+    Error string `json:"error,omitempty"`
+}
+
+// Manifest describes a bundle and the build that generated it
+type Manifest struct {
+    GeneratedAt time.Time       `json:"generatedAt"`
+    Build       buildinfo.Info  `json:"build"`
+    Sections    []SectionResult `json:"sections"`
+}
+
+// Bundle generates support bundles from a fixed list of sections
+type Bundle struct {
+    sections []Section
+}
+
+// New creates a Bundle of sections, in the order they are collected
+func New(sections ...Section) *Bundle {
+    return &Bundle{sections: sections}
+}
+
+// Write collects every section into a zip archive written to w, followed
+// by the manifest, and returns the manifest. It only fails if the archive
+// cannot be written.
+func (b *Bundle) Write(ctx context.Context, w io.Writer) (*Manifest, error) {
+    archive := zip.NewWriter(w)
+    manifest := &Manifest{
+        GeneratedAt: time.Now().UTC(),
+        Build:       buildinfo.Get(),
+    }
+
+    for _, section := range b.sections {
+        entry, err := archive.CreateHeader(&zip.FileHeader{
+            Name:     section.Name,
+            Method:   zip.Deflate,
+            Modified: manifest.GeneratedAt,
+        })
+        if err != nil {
+            return nil, err
+        }
+
+        counter := &countingWriter{w: entry}
+        sectionCtx, cancel := context.WithTimeout(ctx, sectionTimeout)
+        err = section.Collect(sectionCtx, counter)
+        cancel()
+        if counter.err != nil {
+            return nil, counter.err
+        }
+
+        result := SectionResult{Name: section.Name, Bytes: counter.n}
+        if err != nil {
+            result.Error = errtrack.ScrubText(err.Error())
+        }
+        manifest.Sections = append(manifest.Sections, result)
+    }
+
+    entry, err := archive.CreateHeader(&zip.FileHeader{
+        Name:     ManifestName,
+        Method:   zip.Deflate,
+        Modified: manifest.GeneratedAt,
+    })
+    if err != nil {
+        return nil, err
+    }
+    if err := writeJSON(entry, manifest); err != nil {
+        return nil, err
+    }
+    return manifest, archive.Close()
+}
+
+// JSON returns a section holding the value collect returns as indented JSON
+func JSON(name string, collect func(ctx context.Context) (interface{}, error)) Section {
+    return Section{
+        Name: name,
+        Collect: func(ctx context.Context, w io.Writer) error {
+            value, err := collect(ctx)
+            if err != nil {
+                return err
+            }
+            return writeJSON(w, value)
+        },
+    }
+}
+
+// Logs returns a section holding the entries of recorder as JSON lines,
+// oldest first. E-mail addresses and bearer tokens are masked in messages
+// and string fields.
+func Logs(recorder *logger.Recorder) Section {
+    return Section{
+        Name: "logs.jsonl",
+        Collect: func(ctx context.Context, w io.Writer) error {
+            encoder := json.NewEncoder(w)
+            for _, entry := range recorder.Entries() {
+                entry.Message = errtrack.ScrubText(entry.Message)
+                entry.Stack = errtrack.ScrubText(entry.Stack)
+                // The fields are shared with the recorder
+                fields := make(map[string]interface{}, len(entry.Fields))
+                for key, value := range entry.Fields {
+                    if text, ok := value.(string); ok {
+                        value = errtrack.ScrubText(text)
+                    }
+                    fields[key] = value
+                }
+                entry.Fields = fields
+                if err := encoder.Encode(entry); err != nil {
+                    return err
+                }
+            }
+            return nil
+        },
+    }
+}
+
+// Metrics returns a section holding the metrics gathered from gatherer in
+// the Prometheus text format
+func Metrics(gatherer prometheus.Gatherer) Section {
+    return Section{
+        Name: "metrics.txt",
+        Collect: func(ctx context.Context, w io.Writer) error {
+            families, gatherErr := gatherer.Gather()
+            encoder := expfmt.NewEncoder(w, expfmt.FmtText)
+            for _, family := range families {
+                if err := encoder.Encode(family); err != nil {
+                    return err
+                }
+            }
+            // Gather returns what it could gather along with its errors
+            if gatherErr != nil {
+                return fmt.Errorf("gathering metrics: %w", gatherErr)
+            }
+            return nil
+        },
+    }
+}
+
+// Goroutines returns a section holding the stack of every goroutine
+func Goroutines() Section {
+    return Section{
+        Name: "goroutines.txt",
+        Collect: func(ctx context.Context, w io.Writer) error {
+            return pprof.Lookup("goroutine").WriteTo(w, 2)
+        },
+    }
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(v)
+}
+
+// countingWriter counts the bytes written through it and keeps the first
+// write error, which fails the whole archive
+type countingWriter struct {
+    w   io.Writer
+    n   int64
+    err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+    if c.err != nil {
+        return 0, c.err
+    }
+    n, err := c.w.Write(p)
+    c.n += int64(n)
+    c.err = err
+    return n, err
+}
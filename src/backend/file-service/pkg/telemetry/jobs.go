@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+    "context"
+    "sync/atomic"
+    "time"
+
+    "src/backend/file-service/pkg/metrics"
+)
+
+// Job outcomes recorded by TrackJob
+const (
+    JobOutcomeSuccess = "success"
+    JobOutcomeFailure = "failure"
+)
+
+// jobMetrics are the background job instruments, labelled by job name
+type jobMetrics struct {
+    queueDepth metrics.Gauge
+    duration   metrics.Histogram
+    runs       metrics.Counter
+}
+
+// jobInstruments are the instruments TrackJob records to, or nil until
+// InstrumentJobs is called
+var jobInstruments atomic.Pointer[jobMetrics]
+
+// InstrumentJobs makes TrackJob record to instruments created from provider;
+// until it is called, jobs are tracked without recording anything
+func InstrumentJobs(provider metrics.Provider) {
+    jobInstruments.Store(newJobMetrics(provider))
+}
+
+// newJobMetrics creates the background job instruments
+func newJobMetrics(provider metrics.Provider) *jobMetrics {
+    provider = metrics.OrNop(provider)
+    return &jobMetrics{
+        queueDepth: provider.Gauge(metrics.Opts{
+            Name:   "job_queue_depth",
+            Help:   "Number of background jobs enqueued or running",
+            Labels: []string{"job"},
+        }),
+        duration: provider.Histogram(metrics.HistogramOpts{
+            Opts: metrics.Opts{
+                Name:   "job_duration_seconds",
+                Help:   "Time from enqueuing a background job to its completion",
+                Labels: []string{"job"},
+            },
+            Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+        }),
+        runs: provider.Counter(metrics.Opts{
+            Name:   "job_runs_total",
+            Help:   "Number of completed background jobs by outcome",
+            Labels: []string{"job", "outcome"},
+        }),
+    }
+}
+
+// TrackJob counts a job as queued from now until the returned function is
+// called with its result. The job's latency is observed with the trace ID
+// in ctx as exemplar.
+func TrackJob(ctx context.Context, name string) func(err error) {
+    instruments := jobInstruments.Load()
+    if instruments == nil {
+        instruments = newJobMetrics(nil)
+    }
+
+    start := time.Now()
+    instruments.queueDepth.Add(1, name)
+
+    return func(err error) {
+        instruments.queueDepth.Add(-1, name)
+        instruments.duration.Observe(ctx, time.Since(start).Seconds(), name)
+
+        outcome := JobOutcomeSuccess
+        if err != nil {
+            outcome = JobOutcomeFailure
+        }
+        instruments.runs.Inc(name, outcome)
+    }
+}
@@ -0,0 +1,372 @@
+package telemetry
+
+import (
+    "bytes"
+    "context"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+    dto "github.com/prometheus/client_model/go"      // v0.3.0
+    "go.uber.org/zap"                                // v1.24.0
+
+    "src/backend/file-service/pkg/tracing"
+)
+
+// OTLP aggregation temporality of cumulative Prometheus instruments
+const aggregationTemporalityCumulative = 2
+
+// OTLPConfig configures the OTLP/HTTP metrics push exporter
+type OTLPConfig struct {
+    // Endpoint is the full metrics URL, e.g. https://collector:4318/v1/metrics
+    Endpoint    string
+    Headers     map[string]string
+    Interval    time.Duration
+    Timeout     time.Duration
+    ServiceName string
+}
+
+// otlpProvider periodically pushes the gatherer's metrics to an OTLP/HTTP
+// endpoint using the JSON encoding. Counters become monotonic sums, gauges and
+// untyped metrics become gauges, and classic histograms and summaries map to
+// their OTLP counterparts; histogram exemplars carry their trace IDs across.
+type otlpProvider struct {
+    gatherer   prometheus.Gatherer
+    config     OTLPConfig
+    httpClient *http.Client
+    start      time.Time
+    logger     *zap.Logger
+}
+
+// NewOTLPProvider creates a provider pushing the gatherer's metrics over OTLP/HTTP
+func NewOTLPProvider(gatherer prometheus.Gatherer, config OTLPConfig) (Provider, error) {
+    if gatherer == nil {
+        return nil, errors.New("metrics gatherer is required")
+    }
+    if config.Endpoint == "" {
+        return nil, errors.New("OTLP endpoint is required")
+    }
+    if config.Interval <= 0 || config.Timeout <= 0 {
+        return nil, errors.New("OTLP interval and timeout must be positive")
+    }
+
+    return &otlpProvider{
+        gatherer:   gatherer,
+        config:     config,
+        httpClient: &http.Client{Timeout: config.Timeout},
+        start:      time.Now(),
+        logger:     zap.L().Named("otlp-exporter"),
+    }, nil
+}
+
+func (p *otlpProvider) Handler() http.Handler {
+    return nil
+}
+
+func (p *otlpProvider) Start(ctx context.Context) {
+    ticker := time.NewTicker(p.config.Interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := p.export(ctx); err != nil {
+                p.logger.Warn("Failed to export metrics", zap.Error(err))
+            }
+        }
+    }
+}
+
+// Shutdown pushes a final export so the last interval is not lost
+func (p *otlpProvider) Shutdown(ctx context.Context) error {
+    return p.export(ctx)
+}
+
+// export gathers and pushes the current metric values
+func (p *otlpProvider) export(ctx context.Context) error {
+    families, err := p.gatherer.Gather()
+    if err != nil {
+        return fmt.Errorf("failed to gather metrics: %w", err)
+    }
+
+    body, err := json.Marshal(p.encode(families, time.Now()))
+    if err != nil {
+        return fmt.Errorf("failed to encode metrics: %w", err)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint, bytes.NewReader(body))
+    if err != nil {
+        return fmt.Errorf("failed to build export request: %w", err)
+    }
+    req.Header.Set("Content-Type", "application/json")
+    for name, value := range p.config.Headers {
+        req.Header.Set(name, value)
+    }
+
+    resp, err := p.httpClient.Do(req)
+    if err != nil {
+        return fmt.Errorf("metrics export failed: %w", err)
+    }
+    defer resp.Body.Close()
+    io.Copy(io.Discard, resp.Body)
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("OTLP endpoint returned status %d", resp.StatusCode)
+    }
+    return nil
+}
+
+// OTLP JSON encoding of ExportMetricsServiceRequest
+
+type otlpRequest struct {
+    ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+    Resource     otlpResource       `json:"resource"`
+    ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+    Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+    Scope   otlpScope    `json:"scope"`
+    Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+    Name string `json:"name"`
+}
+
+type otlpAttribute struct {
+    Key   string       `json:"key"`
+    Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+    StringValue string `json:"stringValue"`
+}
+
+type otlpMetric struct {
+    Name        string         `json:"name"`
+    Description string         `json:"description,omitempty"`
+    Sum         *otlpSum       `json:"sum,omitempty"`
+    Gauge       *otlpGauge     `json:"gauge,omitempty"`
+    Histogram   *otlpHistogram `json:"histogram,omitempty"`
+    Summary     *otlpSummary   `json:"summary,omitempty"`
+}
+
+type otlpSum struct {
+    AggregationTemporality int               `json:"aggregationTemporality"`
+    IsMonotonic            bool              `json:"isMonotonic"`
+    DataPoints             []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpGauge struct {
+    DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpNumberPoint struct {
+    Attributes        []otlpAttribute `json:"attributes,omitempty"`
+    StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+    TimeUnixNano      string          `json:"timeUnixNano"`
+    AsDouble          float64         `json:"asDouble"`
+}
+
+type otlpHistogram struct {
+    AggregationTemporality int                  `json:"aggregationTemporality"`
+    DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+}
+
+type otlpHistogramPoint struct {
+    Attributes        []otlpAttribute `json:"attributes,omitempty"`
+    StartTimeUnixNano string          `json:"startTimeUnixNano"`
+    TimeUnixNano      string          `json:"timeUnixNano"`
+    Count             string          `json:"count"`
+    Sum               float64         `json:"sum"`
+    BucketCounts      []string        `json:"bucketCounts"`
+    ExplicitBounds    []float64       `json:"explicitBounds"`
+    Exemplars         []otlpExemplar  `json:"exemplars,omitempty"`
+}
+
+type otlpExemplar struct {
+    TimeUnixNano string  `json:"timeUnixNano,omitempty"`
+    AsDouble     float64 `json:"asDouble"`
+    TraceID      string  `json:"traceId,omitempty"`
+}
+
+type otlpSummary struct {
+    DataPoints []otlpSummaryPoint `json:"dataPoints"`
+}
+
+type otlpSummaryPoint struct {
+    Attributes        []otlpAttribute `json:"attributes,omitempty"`
+    StartTimeUnixNano string          `json:"startTimeUnixNano"`
+    TimeUnixNano      string          `json:"timeUnixNano"`
+    Count             string          `json:"count"`
+    Sum               float64         `json:"sum"`
+    QuantileValues    []otlpQuantile  `json:"quantileValues"`
+}
+
+type otlpQuantile struct {
+    Quantile float64 `json:"quantile"`
+    Value    float64 `json:"value"`
+}
+
+// encode converts gathered metric families into an OTLP export request
+func (p *otlpProvider) encode(families []*dto.MetricFamily, now time.Time) otlpRequest {
+    start := unixNano(p.start)
+    timestamp := unixNano(now)
+
+    metrics := make([]otlpMetric, 0, len(families))
+    for _, family := range families {
+        metric := otlpMetric{
+            Name:        family.GetName(),
+            Description: family.GetHelp(),
+        }
+
+        switch family.GetType() {
+        case dto.MetricType_COUNTER:
+            sum := &otlpSum{AggregationTemporality: aggregationTemporalityCumulative, IsMonotonic: true}
+            for _, m := range family.GetMetric() {
+                sum.DataPoints = append(sum.DataPoints, otlpNumberPoint{
+                    Attributes:        attributes(m),
+                    StartTimeUnixNano: start,
+                    TimeUnixNano:      timestamp,
+                    AsDouble:          m.GetCounter().GetValue(),
+                })
+            }
+            metric.Sum = sum
+        case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+            gauge := &otlpGauge{}
+            for _, m := range family.GetMetric() {
+                value := m.GetGauge().GetValue()
+                if family.GetType() == dto.MetricType_UNTYPED {
+                    value = m.GetUntyped().GetValue()
+                }
+                gauge.DataPoints = append(gauge.DataPoints, otlpNumberPoint{
+                    Attributes:   attributes(m),
+                    TimeUnixNano: timestamp,
+                    AsDouble:     value,
+                })
+            }
+            metric.Gauge = gauge
+        case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+            histogram := &otlpHistogram{AggregationTemporality: aggregationTemporalityCumulative}
+            for _, m := range family.GetMetric() {
+                histogram.DataPoints = append(histogram.DataPoints, histogramPoint(m, start, timestamp))
+            }
+            metric.Histogram = histogram
+        case dto.MetricType_SUMMARY:
+            summary := &otlpSummary{}
+            for _, m := range family.GetMetric() {
+                point := otlpSummaryPoint{
+                    Attributes:        attributes(m),
+                    StartTimeUnixNano: start,
+                    TimeUnixNano:      timestamp,
+                    Count:             strconv.FormatUint(m.GetSummary().GetSampleCount(), 10),
+                    Sum:               m.GetSummary().GetSampleSum(),
+                }
+                for _, q := range m.GetSummary().GetQuantile() {
+                    point.QuantileValues = append(point.QuantileValues, otlpQuantile{
+                        Quantile: q.GetQuantile(),
+                        Value:    q.GetValue(),
+                    })
+                }
+                summary.DataPoints = append(summary.DataPoints, point)
+            }
+            metric.Summary = summary
+        default:
+            continue
+        }
+
+        metrics = append(metrics, metric)
+    }
+
+    return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+        Resource: otlpResource{Attributes: []otlpAttribute{
+            {Key: "service.name", Value: otlpAnyValue{StringValue: p.config.ServiceName}},
+        }},
+        ScopeMetrics: []otlpScopeMetrics{{
+            Scope:   otlpScope{Name: p.config.ServiceName},
+            Metrics: metrics,
+        }},
+    }}}
+}
+
+// histogramPoint converts cumulative Prometheus buckets into the per-bucket
+// counts OTLP expects, with a final overflow bucket
+func histogramPoint(m *dto.Metric, start, timestamp string) otlpHistogramPoint {
+    h := m.GetHistogram()
+    point := otlpHistogramPoint{
+        Attributes:        attributes(m),
+        StartTimeUnixNano: start,
+        TimeUnixNano:      timestamp,
+        Count:             strconv.FormatUint(h.GetSampleCount(), 10),
+        Sum:               h.GetSampleSum(),
+        BucketCounts:      []string{},
+        ExplicitBounds:    []float64{},
+    }
+
+    var previous uint64
+    for _, bucket := range h.GetBucket() {
+        if math.IsInf(bucket.GetUpperBound(), 1) {
+            continue
+        }
+        point.ExplicitBounds = append(point.ExplicitBounds, bucket.GetUpperBound())
+        point.BucketCounts = append(point.BucketCounts, strconv.FormatUint(bucket.GetCumulativeCount()-previous, 10))
+        previous = bucket.GetCumulativeCount()
+
+        if exemplar := bucket.GetExemplar(); exemplar != nil {
+            point.Exemplars = append(point.Exemplars, encodeExemplar(exemplar))
+        }
+    }
+    point.BucketCounts = append(point.BucketCounts, strconv.FormatUint(h.GetSampleCount()-previous, 10))
+
+    return point
+}
+
+// encodeExemplar converts an exemplar, carrying a valid trace_id label across as the trace ID
+func encodeExemplar(exemplar *dto.Exemplar) otlpExemplar {
+    encoded := otlpExemplar{AsDouble: exemplar.GetValue()}
+    if ts := exemplar.GetTimestamp(); ts != nil {
+        encoded.TimeUnixNano = unixNano(ts.AsTime())
+    }
+    for _, label := range exemplar.GetLabel() {
+        if label.GetName() != tracing.ExemplarLabel {
+            continue
+        }
+        if id, err := hex.DecodeString(label.GetValue()); err == nil && len(id) == 16 {
+            encoded.TraceID = label.GetValue()
+        }
+    }
+    return encoded
+}
+
+// attributes converts metric labels into OTLP attributes
+func attributes(m *dto.Metric) []otlpAttribute {
+    attrs := make([]otlpAttribute, 0, len(m.GetLabel()))
+    for _, label := range m.GetLabel() {
+        attrs = append(attrs, otlpAttribute{
+            Key:   label.GetName(),
+            Value: otlpAnyValue{StringValue: label.GetValue()},
+        })
+    }
+    return attrs
+}
+
+// unixNano formats t as OTLP JSON encodes 64-bit integers
+func unixNano(t time.Time) string {
+    return strconv.FormatInt(t.UnixNano(), 10)
+}
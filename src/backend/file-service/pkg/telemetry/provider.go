@@ -0,0 +1,51 @@
+// Package telemetry exports the service's metric instruments to a metrics
+// backend, either by Prometheus scrape or by OTLP push
+package telemetry
+
+import (
+    "context"
+    "net/http"
+
+    "github.com/prometheus/client_golang/prometheus"          // v1.15.0
+    "github.com/prometheus/client_golang/prometheus/promhttp" // v1.15.0
+)
+
+// Supported metrics exporters
+const (
+    ExporterPrometheus = "prometheus"
+    ExporterOTLP       = "otlp"
+)
+
+// Provider exports the instruments registered with a Prometheus registry.
+// Instruments are always registered with the registry; the provider only
+// decides how their values leave the process.
+type Provider interface {
+    // Handler returns the scrape endpoint handler, or nil for push exporters
+    Handler() http.Handler
+    // Start runs the exporter until ctx is done
+    Start(ctx context.Context)
+    // Shutdown flushes pending data
+    Shutdown(ctx context.Context) error
+}
+
+// prometheusProvider serves instruments on a scrape endpoint
+type prometheusProvider struct {
+    gatherer prometheus.Gatherer
+}
+
+// NewPrometheusProvider creates a provider serving the gatherer's metrics for scraping
+func NewPrometheusProvider(gatherer prometheus.Gatherer) Provider {
+    return &prometheusProvider{gatherer: gatherer}
+}
+
+func (p *prometheusProvider) Handler() http.Handler {
+    return promhttp.HandlerFor(p.gatherer, promhttp.HandlerOpts{
+        EnableOpenMetrics: true,
+    })
+}
+
+func (p *prometheusProvider) Start(ctx context.Context) {}
+
+func (p *prometheusProvider) Shutdown(ctx context.Context) error {
+    return nil
+}
@@ -0,0 +1,115 @@
+package telemetry
+
+import (
+    "errors"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+)
+
+// SLO defines an availability objective for an operation: the share of
+// requests that must succeed within the latency target
+type SLO struct {
+    Operation     string
+    LatencyTarget time.Duration
+    Objective     float64
+}
+
+// SLOMetrics counts requests against their SLOs. Burn rate over a window is
+//
+//    (1 - rate(slo_requests_good_total[w]) / rate(slo_requests_total[w])) / (1 - slo_objective)
+//
+// so recording rules need no knowledge of the latency histograms.
+type SLOMetrics struct {
+    slos map[string]SLO
+
+    total         *prometheus.CounterVec
+    good          *prometheus.CounterVec
+    objective     *prometheus.GaugeVec
+    latencyTarget *prometheus.GaugeVec
+}
+
+// NewSLOMetrics creates SLO instruments for the given objectives
+func NewSLOMetrics(slos ...SLO) (*SLOMetrics, error) {
+    m := &SLOMetrics{
+        slos: make(map[string]SLO, len(slos)),
+        total: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "slo_requests_total",
+            Help: "Number of requests counted against an SLO",
+        }, []string{"operation"}),
+        good: prometheus.NewCounterVec(prometheus.CounterOpts{
+            Name: "slo_requests_good_total",
+            Help: "Number of requests that succeeded within the SLO latency target",
+        }, []string{"operation"}),
+        objective: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+            Name: "slo_objective",
+            Help: "Target share of good requests",
+        }, []string{"operation"}),
+        latencyTarget: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+            Name: "slo_latency_target_seconds",
+            Help: "Latency within which a successful request is good",
+        }, []string{"operation"}),
+    }
+
+    for _, slo := range slos {
+        if slo.Operation == "" || slo.LatencyTarget <= 0 || slo.Objective <= 0 || slo.Objective >= 1 {
+            return nil, errors.New("invalid SLO for operation " + strconv.Quote(slo.Operation))
+        }
+        m.slos[slo.Operation] = slo
+        m.objective.WithLabelValues(slo.Operation).Set(slo.Objective)
+        m.latencyTarget.WithLabelValues(slo.Operation).Set(slo.LatencyTarget.Seconds())
+
+        // Expose zero counts so burn rates are defined before the first request
+        m.total.WithLabelValues(slo.Operation)
+        m.good.WithLabelValues(slo.Operation)
+    }
+    return m, nil
+}
+
+// Register registers the SLO instruments with reg
+func (m *SLOMetrics) Register(reg prometheus.Registerer) error {
+    for _, collector := range []prometheus.Collector{m.total, m.good, m.objective, m.latencyTarget} {
+        if err := reg.Register(collector); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Middleware counts requests to next against the operation's SLO. Requests
+// are good unless they fail with a 5xx status or exceed the latency target.
+func (m *SLOMetrics) Middleware(operation string, next http.Handler) http.Handler {
+    slo, ok := m.slos[operation]
+    if !ok {
+        return next
+    }
+
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+        start := time.Now()
+        next.ServeHTTP(recorder, r)
+
+        m.total.WithLabelValues(operation).Inc()
+        if recorder.status < http.StatusInternalServerError && time.Since(start) <= slo.LatencyTarget {
+            m.good.WithLabelValues(operation).Inc()
+        }
+    })
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+    r.status = status
+    r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+    return r.ResponseWriter
+}
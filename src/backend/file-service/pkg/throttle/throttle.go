@@ -0,0 +1,82 @@
+// Package throttle limits how many requests each caller makes per window and
+// reports the caller's remaining allowance, so responses can tell clients how
+// to pace themselves. Counts are kept per instance.
+package throttle
+
+import (
+    "sync"
+    "time"
+)
+
+// Result is the outcome of counting one request against a caller's limit
+type Result struct {
+    Allowed   bool
+    Limit     int
+    Remaining int
+    // Reset is when the caller's window ends and the allowance is restored
+    Reset time.Time
+}
+
+// window counts the requests of one caller in the current window
+type window struct {
+    start time.Time
+    count int
+}
+
+// Limiter allows each key limit requests per fixed window
+type Limiter struct {
+    limit  int
+    period time.Duration
+
+    mu        sync.Mutex
+    windows   map[string]*window
+    lastSweep time.Time
+}
+
+// New creates a Limiter allowing limit requests per period for each key
+func New(limit int, period time.Duration) *Limiter {
+    return &Limiter{
+        limit:     limit,
+        period:    period,
+        windows:   make(map[string]*window),
+        lastSweep: time.Now(),
+    }
+}
+
+// Allow counts a request by key and reports whether it is within the limit.
+// Rejected requests are not counted.
+func (l *Limiter) Allow(key string) Result {
+    now := time.Now()
+
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    l.sweep(now)
+    w, ok := l.windows[key]
+    if !ok || now.Sub(w.start) >= l.period {
+        w = &window{start: now}
+        l.windows[key] = w
+    }
+
+    result := Result{Limit: l.limit, Reset: w.start.Add(l.period)}
+    if w.count < l.limit {
+        w.count++
+        result.Allowed = true
+    }
+    result.Remaining = l.limit - w.count
+    return result
+}
+
+// sweep drops expired windows once per period, bounding memory to the
+// callers seen recently
+func (l *Limiter) sweep(now time.Time) {
+    if now.Sub(l.lastSweep) < l.period {
+        return
+    }
+    l.lastSweep = now
+    for key, w := range l.windows {
+        if now.Sub(w.start) >= l.period {
+            delete(l.windows, key)
+        }
+    }
+}
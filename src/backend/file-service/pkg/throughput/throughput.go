@@ -0,0 +1,126 @@
+// Package throughput estimates the upload throughput of each client from the
+// transfers it makes and recommends the chunk size that keeps a chunk's
+// transfer near a target duration, so fast clients make fewer round trips
+// and slow clients do not time out. Estimates are kept per instance.
+package throughput
+
+import (
+    "sync"
+    "time"
+)
+
+const (
+    // smoothing is the weight of a new sample in a client's estimate
+    smoothing = 0.3
+    // minSampleSize is the smallest transfer observed; shorter transfers are
+    // dominated by latency rather than throughput
+    minSampleSize = 1 << 20 // 1MB
+    // chunkAlignment is the multiple recommended chunk sizes are rounded to
+    chunkAlignment = 1 << 20 // 1MB
+)
+
+// Options configure an Estimator
+type Options struct {
+    // TargetDuration is how long a recommended chunk takes to transfer
+    TargetDuration time.Duration
+    // MinChunkSize and MaxChunkSize bound recommended chunk sizes
+    MinChunkSize int64
+    MaxChunkSize int64
+    // TTL is how long an estimate is kept without new transfers
+    TTL time.Duration
+}
+
+// Estimate is a client's observed throughput
+type Estimate struct {
+    // BytesPerSecond is the moving average of the client's transfers
+    BytesPerSecond float64
+    Samples        int
+    Updated        time.Time
+}
+
+// Estimator tracks the throughput of each client, identified by key
+type Estimator struct {
+    opts Options
+
+    mu        sync.Mutex
+    clients   map[string]*Estimate
+    lastSweep time.Time
+}
+
+// New creates an Estimator recommending chunk sizes by opts
+func New(opts Options) *Estimator {
+    return &Estimator{
+        opts:      opts,
+        clients:   make(map[string]*Estimate),
+        lastSweep: time.Now(),
+    }
+}
+
+// Observe records that the client identified by key transferred size bytes
+// in elapsed
+func (e *Estimator) Observe(key string, size int64, elapsed time.Duration) {
+    if key == "" || size < minSampleSize || elapsed <= 0 {
+        return
+    }
+    sample := float64(size) / elapsed.Seconds()
+    now := time.Now()
+
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    e.sweep(now)
+    estimate, ok := e.clients[key]
+    if !ok || now.Sub(estimate.Updated) >= e.opts.TTL {
+        estimate = &Estimate{BytesPerSecond: sample}
+        e.clients[key] = estimate
+    } else {
+        estimate.BytesPerSecond = smoothing*sample + (1-smoothing)*estimate.BytesPerSecond
+    }
+    estimate.Samples++
+    estimate.Updated = now
+}
+
+// Estimate returns the throughput of the client identified by key, if it
+// made transfers within the TTL
+func (e *Estimator) Estimate(key string) (Estimate, bool) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+
+    estimate, ok := e.clients[key]
+    if !ok || time.Since(estimate.Updated) >= e.opts.TTL {
+        return Estimate{}, false
+    }
+    return *estimate, true
+}
+
+// ChunkSize returns the chunk size recommended for the client identified by
+// key, or fallback for clients without a current estimate
+func (e *Estimator) ChunkSize(key string, fallback int64) int64 {
+    estimate, ok := e.Estimate(key)
+    if !ok {
+        return fallback
+    }
+
+    size := int64(estimate.BytesPerSecond*e.opts.TargetDuration.Seconds()) / chunkAlignment * chunkAlignment
+    if size < e.opts.MinChunkSize {
+        return e.opts.MinChunkSize
+    }
+    if size > e.opts.MaxChunkSize {
+        return e.opts.MaxChunkSize
+    }
+    return size
+}
+
+// sweep drops expired estimates once per TTL, bounding memory to the clients
+// seen recently
+func (e *Estimator) sweep(now time.Time) {
+    if now.Sub(e.lastSweep) < e.opts.TTL {
+        return
+    }
+    e.lastSweep = now
+    for key, estimate := range e.clients {
+        if now.Sub(estimate.Updated) >= e.opts.TTL {
+            delete(e.clients, key)
+        }
+    }
+}
@@ -0,0 +1,105 @@
+// Package thumbnail renders small PNG previews of uploaded images.
+package thumbnail
+
+import (
+    "bytes"
+    "errors"
+    "fmt"
+    "image"
+    _ "image/gif"  // register the GIF decoder
+    _ "image/jpeg" // register the JPEG decoder
+    "image/png"
+    "mime"
+    "strconv"
+    "strings"
+
+    "golang.org/x/image/draw" // v0.14.0
+)
+
+// ContentType is the type of every thumbnail
+const ContentType = "image/png"
+
+// MaxDimension bounds the requested thumbnail size
+const MaxDimension = 1024
+
+// Sizes are the fixed thumbnail sizes offered to clients in srcset
+// descriptors, so each image has a few cached renditions rather than one per
+// requested size
+var Sizes = []int{128, 256, 512}
+
+// ErrUnsupportedType is returned for content types that cannot be thumbnailed
+var ErrUnsupportedType = errors.New("content type cannot be thumbnailed")
+
+// Supports reports whether a thumbnail can be rendered for the content type
+func Supports(contentType string) bool {
+    switch mediaType(contentType) {
+    case "image/png", "image/jpeg", "image/gif":
+        return true
+    }
+    return false
+}
+
+// Render scales the image in content to fit within size×size pixels,
+// preserving its aspect ratio, and encodes the result as PNG. Images smaller
+// than size are not enlarged.
+func Render(contentType string, content []byte, size int) ([]byte, error) {
+    if !Supports(contentType) {
+        return nil, ErrUnsupportedType
+    }
+    if size <= 0 || size > MaxDimension {
+        return nil, fmt.Errorf("thumbnail size must be between 1 and %d", MaxDimension)
+    }
+
+    src, _, err := image.Decode(bytes.NewReader(content))
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode image: %w", err)
+    }
+
+    bounds := src.Bounds()
+    width, height := bounds.Dx(), bounds.Dy()
+    if width > size || height > size {
+        if width >= height {
+            height = max(1, height*size/width)
+            width = size
+        } else {
+            width = max(1, width*size/height)
+            height = size
+        }
+    }
+
+    dst := image.NewRGBA(image.Rect(0, 0, width, height))
+    draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+    var out bytes.Buffer
+    if err := png.Encode(&out, dst); err != nil {
+        return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+    }
+    return out.Bytes(), nil
+}
+
+// Srcset describes the thumbnails of an image as an HTML srcset attribute
+// value, listing the URL returned by url for each of Sizes. Thumbnails fit
+// within their size but are narrower for tall images, so candidates carry
+// pixel density descriptors relative to Sizes[0], the size an <img> showing
+// them is laid out at, rather than width descriptors.
+func Srcset(url func(size int) (string, error)) (string, error) {
+    candidates := make([]string, 0, len(Sizes))
+    for _, size := range Sizes {
+        candidate, err := url(size)
+        if err != nil {
+            return "", err
+        }
+        density := strconv.FormatFloat(float64(size)/float64(Sizes[0]), 'f', -1, 64)
+        candidates = append(candidates, candidate+" "+density+"x")
+    }
+    return strings.Join(candidates, ", "), nil
+}
+
+// mediaType normalizes a content type to its lower-case media type
+func mediaType(contentType string) string {
+    parsed, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return strings.ToLower(strings.TrimSpace(contentType))
+    }
+    return parsed
+}
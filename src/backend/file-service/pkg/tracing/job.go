@@ -0,0 +1,70 @@
+package tracing
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+
+    "github.com/google/uuid" // v1.3.0
+    "go.uber.org/zap"        // v1.24.0
+)
+
+// Job identifies a unit of background work. Each run gets its own trace,
+// linked to the trace of the request that enqueued it, if any.
+type Job struct {
+    ID      string
+    Name    string
+    TraceID string
+    // LinkedTraceID is the trace ID of the originating request
+    LinkedTraceID string
+}
+
+// jobContextKey is the context key of the running job
+type jobContextKey struct{}
+
+// StartJob returns a context for running a background job enqueued from
+// parent, carrying the job and its new trace ID. Jobs that outlive the
+// enqueuing request should detach parent with context.WithoutCancel first.
+func StartJob(parent context.Context, name string) (context.Context, Job) {
+    job := Job{
+        ID:            uuid.NewString(),
+        Name:          name,
+        TraceID:       newTraceID(),
+        LinkedTraceID: TraceID(parent),
+    }
+
+    ctx := ContextWithTraceID(parent, job.TraceID)
+    return context.WithValue(ctx, jobContextKey{}, job), job
+}
+
+// JobFromContext returns the job running under ctx
+func JobFromContext(ctx context.Context) (Job, bool) {
+    job, ok := ctx.Value(jobContextKey{}).(Job)
+    return job, ok
+}
+
+// Fields returns the log fields correlating a job's log lines
+func (j Job) Fields() []zap.Field {
+    fields := []zap.Field{
+        zap.String("jobId", j.ID),
+        zap.String("job", j.Name),
+        zap.String("traceId", j.TraceID),
+    }
+    if j.LinkedTraceID != "" {
+        fields = append(fields, zap.String("linkedTraceId", j.LinkedTraceID))
+    }
+    return fields
+}
+
+// Traceparent formats a W3C traceparent header continuing the trace in ctx,
+// or returns "" when ctx carries no trace
+func Traceparent(ctx context.Context) string {
+    traceID := TraceID(ctx)
+    if traceID == "" {
+        return ""
+    }
+
+    var spanID [8]byte
+    rand.Read(spanID[:])
+    return "00-" + traceID + "-" + hex.EncodeToString(spanID[:]) + "-01"
+}
@@ -0,0 +1,88 @@
+// Package tracing propagates request trace IDs and attaches them to metric
+// observations as exemplars
+package tracing
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "net/http"
+    "strings"
+
+    "github.com/prometheus/client_golang/prometheus" // v1.15.0
+)
+
+const (
+    // TraceparentHeader is the W3C Trace Context header
+    TraceparentHeader = "traceparent"
+    // TraceIDHeader is set on responses so clients can quote the trace ID
+    TraceIDHeader = "X-Trace-Id"
+    // ExemplarLabel is the exemplar label Grafana links to traces
+    ExemplarLabel = "trace_id"
+)
+
+// contextKey is the type of context keys set by this package
+type contextKey struct{}
+
+// ContextWithTraceID returns a copy of ctx carrying traceID
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+    return context.WithValue(ctx, contextKey{}, traceID)
+}
+
+// TraceID returns the trace ID carried by ctx, or ""
+func TraceID(ctx context.Context) string {
+    traceID, _ := ctx.Value(contextKey{}).(string)
+    return traceID
+}
+
+// Middleware takes the trace ID from the request's traceparent header, or
+// starts a new trace, stores it in the request context and echoes it in the
+// X-Trace-Id response header
+func Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        traceID, ok := ParseTraceparent(r.Header.Get(TraceparentHeader))
+        if !ok {
+            traceID = newTraceID()
+        }
+
+        w.Header().Set(TraceIDHeader, traceID)
+        next.ServeHTTP(w, r.WithContext(ContextWithTraceID(r.Context(), traceID)))
+    })
+}
+
+// ParseTraceparent extracts the trace ID from a W3C traceparent header value
+// of the form version-traceid-parentid-flags
+func ParseTraceparent(header string) (string, bool) {
+    parts := strings.Split(strings.TrimSpace(header), "-")
+    if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+        return "", false
+    }
+
+    traceID := strings.ToLower(parts[1])
+    if len(traceID) != 32 || traceID == strings.Repeat("0", 32) {
+        return "", false
+    }
+    if _, err := hex.DecodeString(traceID); err != nil {
+        return "", false
+    }
+    return traceID, true
+}
+
+// Observe records value on observer, attaching the trace ID in ctx as an
+// exemplar when there is one
+func Observe(ctx context.Context, observer prometheus.Observer, value float64) {
+    if traceID := TraceID(ctx); traceID != "" {
+        if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+            exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{ExemplarLabel: traceID})
+            return
+        }
+    }
+    observer.Observe(value)
+}
+
+// newTraceID returns a random 16-byte trace ID in hex
+func newTraceID() string {
+    var id [16]byte
+    rand.Read(id[:])
+    return hex.EncodeToString(id[:])
+}
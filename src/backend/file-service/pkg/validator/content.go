@@ -0,0 +1,526 @@
+package validator
+
+import (
+    "bytes"
+    "encoding/binary"
+    "fmt"
+    "mime"
+    "strings"
+)
+
+// Streaming content checks, as configured and reported in verdicts
+const (
+    CheckMagic      = "magic"
+    CheckSignatures = "signatures"
+    CheckArchive    = "archive"
+)
+
+// Verdicts of a content check
+const (
+    VerdictPass         = "pass"
+    VerdictReject       = "reject"
+    VerdictInconclusive = "inconclusive"
+)
+
+// Content validation codes, reported as ValidationError codes
+const (
+    CodeMalwareDetected      = "MALWARE_DETECTED"
+    CodeSuspiciousContent    = "SUSPICIOUS_CONTENT"
+    CodeContentTypeMismatch  = "CONTENT_TYPE_MISMATCH"
+    CodeArchivePathTraversal = "ARCHIVE_PATH_TRAVERSAL"
+    CodeArchiveBomb          = "ARCHIVE_BOMB"
+    CodeContentInconclusive  = "CONTENT_INCONCLUSIVE"
+)
+
+// Archive limits; archives beyond them are treated as decompression bombs
+const (
+    MaxArchiveEntries            = 10000
+    MaxArchiveRatio              = 100
+    MaxArchiveExpandedSize int64 = 10 * 1024 * 1024 * 1024
+
+    // minBombEntrySize exempts small entries from the ratio limit, as
+    // tiny or empty files compress extremely well
+    minBombEntrySize = 1024 * 1024
+)
+
+// contentMagic lists the magic numbers content of a declared type starts
+// with. Types not listed are not checked.
+var contentMagic = map[string][][]byte{
+    "application/pdf":  {[]byte("%PDF-")},
+    "image/png":        {[]byte("\x89PNG\r\n\x1a\n")},
+    "image/jpeg":       {{0xFF, 0xD8, 0xFF}},
+    "image/gif":        {[]byte("GIF87a"), []byte("GIF89a")},
+    "image/bmp":        {[]byte("BM")},
+    "image/tiff":       {[]byte("II*\x00"), []byte("MM\x00*")},
+    "application/zip":  {[]byte("PK\x03\x04"), []byte("PK\x05\x06")},
+    "application/gzip": {{0x1F, 0x8B}},
+}
+
+// ContentPolicy selects the streaming content checks and how their
+// verdicts are handled
+type ContentPolicy struct {
+    // Checks lists the checks to run; unknown and empty names are ignored
+    Checks []string
+    // FailOpen accepts content a check rejects or cannot decide, leaving
+    // the verdicts for the caller to record. By default such content is
+    // rejected.
+    FailOpen bool
+}
+
+// Validate reports checks the policy names that do not exist
+func (p ContentPolicy) Validate() error {
+    for _, name := range p.Checks {
+        name = strings.TrimSpace(name)
+        if name != "" && newContentCheck(name, "") == nil {
+            return fmt.Errorf("unknown content check: %s", name)
+        }
+    }
+    return nil
+}
+
+// Verdict is the outcome of one content check
+type Verdict struct {
+    Check  string
+    Result string
+    // Finding explains a reject or inconclusive result
+    Finding *ValidationError
+}
+
+// contentCheck inspects content as it streams past
+type contentCheck interface {
+    // inspect examines the next chunk of content, returning a finding as
+    // soon as the content is certain to fail
+    inspect(chunk []byte) *ValidationError
+    // finish returns the finding for the whole content
+    finish() *ValidationError
+}
+
+// newContentCheck creates the named check for content of contentType
+func newContentCheck(name, contentType string) contentCheck {
+    switch name {
+    case CheckMagic:
+        return newMagicCheck(contentType)
+    case CheckSignatures:
+        return &signatureCheck{text: isText(contentType)}
+    case CheckArchive:
+        return &archiveCheck{}
+    default:
+        return nil
+    }
+}
+
+// ContentScanner runs the policy's checks over the content written to it,
+// so it can be teed from a stream as it is stored. Unless the policy fails
+// open, a write fails as soon as a check rejects the content, aborting the
+// stream. A ContentScanner is not safe for concurrent use.
+type ContentScanner struct {
+    failOpen bool
+    checks   []contentCheck
+    verdicts []Verdict
+    err      error
+}
+
+// NewContentScanner creates a scanner for content declared as contentType
+func NewContentScanner(policy ContentPolicy, contentType string) *ContentScanner {
+    scanner := &ContentScanner{failOpen: policy.FailOpen}
+    for _, name := range policy.Checks {
+        name = strings.TrimSpace(name)
+        if check := newContentCheck(name, contentType); check != nil {
+            scanner.checks = append(scanner.checks, check)
+            scanner.verdicts = append(scanner.verdicts, Verdict{Check: name})
+        }
+    }
+    return scanner
+}
+
+// Write inspects the next chunk of content
+func (s *ContentScanner) Write(p []byte) (int, error) {
+    if s.err != nil {
+        return 0, s.err
+    }
+    for i, check := range s.checks {
+        if s.verdicts[i].Result != "" {
+            continue
+        }
+        if finding := check.inspect(p); finding != nil {
+            s.decide(i, finding)
+        }
+    }
+    if s.err != nil {
+        return 0, s.err
+    }
+    return len(p), nil
+}
+
+// Err returns the finding that rejected the content, if any
+func (s *ContentScanner) Err() error {
+    return s.err
+}
+
+// Finish completes the checks once all content was written and returns
+// their verdicts, with the finding that rejects the content unless the
+// policy fails open. After a rejected write only the checks that decided
+// are returned, as the rest did not see the whole content.
+func (s *ContentScanner) Finish() ([]Verdict, error) {
+    if s.err == nil {
+        for i, check := range s.checks {
+            if s.verdicts[i].Result == "" {
+                s.decide(i, check.finish())
+            }
+        }
+    }
+
+    verdicts := make([]Verdict, 0, len(s.verdicts))
+    for _, verdict := range s.verdicts {
+        if verdict.Result != "" {
+            verdicts = append(verdicts, verdict)
+        }
+    }
+    return verdicts, s.err
+}
+
+// decide records the verdict of check i from its finding
+func (s *ContentScanner) decide(i int, finding *ValidationError) {
+    verdict := &s.verdicts[i]
+    switch {
+    case finding == nil:
+        verdict.Result = VerdictPass
+        return
+    case finding.Code == CodeContentInconclusive:
+        verdict.Result = VerdictInconclusive
+    default:
+        verdict.Result = VerdictReject
+    }
+    verdict.Finding = finding
+    if !s.failOpen && s.err == nil {
+        s.err = finding
+    }
+}
+
+// magicCheck compares the leading bytes with the declared content type
+type magicCheck struct {
+    magic  [][]byte
+    header []byte
+    need   int
+}
+
+func newMagicCheck(contentType string) *magicCheck {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        mediaType = strings.ToLower(strings.TrimSpace(contentType))
+    }
+    check := &magicCheck{magic: contentMagic[mediaType]}
+    for _, magic := range check.magic {
+        if len(magic) > check.need {
+            check.need = len(magic)
+        }
+    }
+    return check
+}
+
+func (c *magicCheck) inspect(chunk []byte) *ValidationError {
+    if len(c.header) >= c.need {
+        return nil
+    }
+    c.header = append(c.header, chunk[:min(c.need-len(c.header), len(chunk))]...)
+    if len(c.header) < c.need {
+        return nil
+    }
+    return c.finish()
+}
+
+func (c *magicCheck) finish() *ValidationError {
+    if len(c.magic) == 0 {
+        return nil
+    }
+    for _, magic := range c.magic {
+        if bytes.HasPrefix(c.header, magic) {
+            return nil
+        }
+    }
+    return &ValidationError{
+        Code:    CodeContentTypeMismatch,
+        Message: "File content does not match its declared content type",
+    }
+}
+
+// signatureCheck looks for malware signatures anywhere in the content,
+// including across chunk boundaries, and for text that is mostly nulls
+type signatureCheck struct {
+    // text enables the null check; binary formats are often mostly nulls
+    text  bool
+    head  []byte
+    tail  []byte
+    size  int64
+    nulls int64
+}
+
+// signatureOverlap is the longest signature, less one byte: the bytes kept
+// from the previous chunk to find signatures spanning two chunks
+var signatureOverlap = func() int {
+    longest := 0
+    for _, signature := range malwareSignatures {
+        longest = max(longest, len(signature.magic))
+    }
+    return longest - 1
+}()
+
+func (c *signatureCheck) inspect(chunk []byte) *ValidationError {
+    if len(c.head) <= signatureOverlap {
+        c.head = append(c.head, chunk[:min(signatureOverlap+1-len(c.head), len(chunk))]...)
+    }
+
+    boundary := append(c.tail, chunk[:min(signatureOverlap, len(chunk))]...)
+    for _, signature := range malwareSignatures {
+        found := false
+        if signature.anchored {
+            found = bytes.HasPrefix(c.head, signature.magic)
+        } else {
+            found = bytes.Contains(chunk, signature.magic) || bytes.Contains(boundary, signature.magic)
+        }
+        if found {
+            return &ValidationError{
+                Code:    CodeMalwareDetected,
+                Message: "Potential security threat detected in file content",
+            }
+        }
+    }
+
+    c.size += int64(len(chunk))
+    c.nulls += int64(bytes.Count(chunk, []byte{0}))
+    if len(chunk) >= signatureOverlap {
+        c.tail = append(c.tail[:0], chunk[len(chunk)-signatureOverlap:]...)
+    } else {
+        c.tail = append(c.tail, chunk...)
+        c.tail = c.tail[max(0, len(c.tail)-signatureOverlap):]
+    }
+    return nil
+}
+
+func (c *signatureCheck) finish() *ValidationError {
+    if c.text && c.nulls > c.size/2 {
+        return &ValidationError{
+            Code:    CodeSuspiciousContent,
+            Message: "File content appears to be corrupted or suspicious",
+        }
+    }
+    return nil
+}
+
+// isText reports whether contentType declares text content; content of an
+// unknown type is treated as text
+func isText(contentType string) bool {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil || mediaType == "" {
+        return true
+    }
+    return strings.HasPrefix(mediaType, "text/")
+}
+
+// ZIP record signatures
+const (
+    zipLocalHeader   = 0x04034b50
+    zipCentralHeader = 0x02014b50
+    zipEndOfCentral  = 0x06054b50
+
+    zipLocalHeaderSize   = 30
+    zipCentralHeaderSize = 46
+
+    // zipDataDescriptor flags entries whose sizes follow their data
+    zipDataDescriptor = 0x8
+    // zip64Size marks sizes stored in the ZIP64 extra field
+    zip64Size = 0xFFFFFFFF
+)
+
+// archiveCheck parses ZIP archives as they stream past for entries that
+// extract outside the target directory and for decompression bombs. Entry
+// names are checked in both the local and central headers, and sizes in the
+// central directory, which extractors trust. Other content passes.
+type archiveCheck struct {
+    zip  bool
+    done bool
+    // buf holds the record being read
+    buf []byte
+    // skip counts entry data bytes still to pass over
+    skip int64
+    // resync searches for the next header after an entry whose size is
+    // only known after its data
+    resync bool
+
+    entries  int
+    expanded int64
+}
+
+func (c *archiveCheck) inspect(chunk []byte) *ValidationError {
+    for len(chunk) > 0 && !c.done {
+        if c.skip > 0 {
+            n := int(min(c.skip, int64(len(chunk))))
+            c.skip -= int64(n)
+            chunk = chunk[n:]
+            continue
+        }
+        if c.resync {
+            chunk = c.findHeader(chunk)
+            continue
+        }
+
+        need := c.recordSize()
+        n := min(need-len(c.buf), len(chunk))
+        c.buf = append(c.buf, chunk[:n]...)
+        chunk = chunk[n:]
+        if len(c.buf) < c.recordSize() {
+            continue
+        }
+        finding := c.parse()
+        c.buf = c.buf[:0]
+        if finding != nil {
+            c.done = true
+            return finding
+        }
+    }
+    return nil
+}
+
+func (c *archiveCheck) finish() *ValidationError {
+    if !c.zip || c.done {
+        return nil
+    }
+    return &ValidationError{
+        Code:    CodeContentInconclusive,
+        Message: "Archive ends before its central directory",
+    }
+}
+
+// recordSize returns the size of the record being read, as far as the
+// bytes read so far tell
+func (c *archiveCheck) recordSize() int {
+    if len(c.buf) < 4 {
+        return 4
+    }
+    switch binary.LittleEndian.Uint32(c.buf) {
+    case zipLocalHeader:
+        if len(c.buf) < zipLocalHeaderSize {
+            return zipLocalHeaderSize
+        }
+        return zipLocalHeaderSize + int(binary.LittleEndian.Uint16(c.buf[26:])) +
+            int(binary.LittleEndian.Uint16(c.buf[28:]))
+    case zipCentralHeader:
+        if len(c.buf) < zipCentralHeaderSize {
+            return zipCentralHeaderSize
+        }
+        return zipCentralHeaderSize + int(binary.LittleEndian.Uint16(c.buf[28:])) +
+            int(binary.LittleEndian.Uint16(c.buf[30:])) + int(binary.LittleEndian.Uint16(c.buf[32:]))
+    default:
+        return 4
+    }
+}
+
+// parse checks the complete record in buf
+func (c *archiveCheck) parse() *ValidationError {
+    signature := binary.LittleEndian.Uint32(c.buf)
+    if !c.zip {
+        if signature != zipLocalHeader {
+            // Not an archive, or an empty one
+            c.done = true
+            return nil
+        }
+        c.zip = true
+    }
+
+    switch signature {
+    case zipLocalHeader:
+        flags := binary.LittleEndian.Uint16(c.buf[6:])
+        compressed := binary.LittleEndian.Uint32(c.buf[18:])
+        if finding := checkEntryName(c.buf[zipLocalHeaderSize : zipLocalHeaderSize+int(binary.LittleEndian.Uint16(c.buf[26:]))]); finding != nil {
+            return finding
+        }
+        if flags&zipDataDescriptor != 0 || compressed == zip64Size {
+            c.resync = true
+            return nil
+        }
+        c.skip = int64(compressed)
+        return nil
+
+    case zipCentralHeader:
+        if finding := checkEntryName(c.buf[zipCentralHeaderSize : zipCentralHeaderSize+int(binary.LittleEndian.Uint16(c.buf[28:]))]); finding != nil {
+            return finding
+        }
+        compressed := int64(binary.LittleEndian.Uint32(c.buf[20:]))
+        uncompressed := int64(binary.LittleEndian.Uint32(c.buf[24:]))
+        if compressed == zip64Size || uncompressed == zip64Size {
+            return &ValidationError{
+                Code:    CodeContentInconclusive,
+                Message: "Archive entry sizes are stored in ZIP64 records",
+            }
+        }
+        return c.checkEntry(compressed, uncompressed)
+
+    case zipEndOfCentral:
+        c.done = true
+        return nil
+
+    default:
+        return &ValidationError{
+            Code:    CodeContentInconclusive,
+            Message: "Archive contains an unrecognized record",
+        }
+    }
+}
+
+// checkEntry applies the archive limits to an entry of the central directory
+func (c *archiveCheck) checkEntry(compressed, uncompressed int64) *ValidationError {
+    c.entries++
+    c.expanded += uncompressed
+    switch {
+    case c.entries > MaxArchiveEntries:
+        return &ValidationError{
+            Code:    CodeArchiveBomb,
+            Message: fmt.Sprintf("Archive has more than %d entries", MaxArchiveEntries),
+        }
+    case c.expanded > MaxArchiveExpandedSize:
+        return &ValidationError{
+            Code:    CodeArchiveBomb,
+            Message: fmt.Sprintf("Archive expands to more than %d bytes", MaxArchiveExpandedSize),
+        }
+    case uncompressed > minBombEntrySize && uncompressed > compressed*MaxArchiveRatio:
+        return &ValidationError{
+            Code:    CodeArchiveBomb,
+            Message: fmt.Sprintf("Archive entry compresses more than %d times", MaxArchiveRatio),
+        }
+    }
+    return nil
+}
+
+// findHeader passes over entry data up to the next local or central
+// header, returning the rest of the chunk from it. The last bytes are kept
+// in buf in case a signature spans two chunks.
+func (c *archiveCheck) findHeader(chunk []byte) []byte {
+    window := append(c.buf, chunk...)
+    for i := 0; i+4 <= len(window); i++ {
+        switch binary.LittleEndian.Uint32(window[i:]) {
+        case zipLocalHeader, zipCentralHeader:
+            c.resync = false
+            rest := append([]byte(nil), window[i:]...)
+            c.buf = c.buf[:0]
+            return rest
+        }
+    }
+    c.buf = append(c.buf[:0], window[max(0, len(window)-3):]...)
+    return nil
+}
+
+// checkEntryName rejects entry names that extract outside the target directory
+func checkEntryName(name []byte) *ValidationError {
+    entry := strings.ReplaceAll(string(name), "\\", "/")
+    unsafe := strings.HasPrefix(entry, "/") || (len(entry) > 1 && entry[1] == ':')
+    for _, segment := range strings.Split(entry, "/") {
+        unsafe = unsafe || segment == ".."
+    }
+    if unsafe {
+        return &ValidationError{
+            Code:    CodeArchivePathTraversal,
+            Message: "Archive entry extracts outside the target directory",
+        }
+    }
+    return nil
+}
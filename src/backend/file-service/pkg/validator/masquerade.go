@@ -0,0 +1,147 @@
+package validator
+
+import (
+    "bytes"
+    "fmt"
+    "path/filepath"
+    "strings"
+)
+
+// Masquerading detection codes, reported as ValidationError codes
+const (
+    CodeDoubleExtension     = "DOUBLE_EXTENSION"
+    CodeBidiControl         = "BIDI_CONTROL"
+    CodeExecutableExtension = "EXECUTABLE_EXTENSION"
+    CodeExecutableContent   = "EXECUTABLE_CONTENT"
+)
+
+// HeaderSize is the number of leading content bytes inspected for magic numbers
+const HeaderSize = 512
+
+// executableExtensions lists extensions that run code when opened
+var executableExtensions = map[string]bool{
+    ".exe": true, ".com": true, ".scr": true, ".pif": true, ".cpl": true, ".dll": true, ".sys": true,
+    ".bat": true, ".cmd": true, ".msi": true, ".msp": true, ".msc": true, ".lnk": true, ".reg": true,
+    ".js": true, ".jse": true, ".vbs": true, ".vbe": true, ".wsf": true, ".wsh": true, ".hta": true,
+    ".ps1": true, ".psm1": true, ".jar": true, ".sh": true, ".app": true, ".apk": true, ".elf": true,
+}
+
+// executableSignatures lists magic numbers of executable formats
+var executableSignatures = []struct {
+    format string
+    magic  []byte
+}{
+    {"PE", []byte("MZ")},
+    {"ELF", []byte("\x7fELF")},
+    {"Mach-O", []byte{0xFE, 0xED, 0xFA, 0xCE}},
+    {"Mach-O", []byte{0xFE, 0xED, 0xFA, 0xCF}},
+    {"Mach-O", []byte{0xCE, 0xFA, 0xED, 0xFE}},
+    {"Mach-O", []byte{0xCF, 0xFA, 0xED, 0xFE}},
+    {"Mach-O universal or Java class", []byte{0xCA, 0xFE, 0xBA, 0xBE}},
+    {"script", []byte("#!")},
+}
+
+// MasqueradePolicy controls how masquerading findings are handled
+type MasqueradePolicy struct {
+    // FlagOnly reports findings without rejecting the file
+    FlagOnly bool
+    // AllowedCodes lists finding codes that are ignored entirely
+    AllowedCodes []string
+}
+
+func (p MasqueradePolicy) allows(code string) bool {
+    for _, allowed := range p.AllowedCodes {
+        if strings.EqualFold(strings.TrimSpace(allowed), code) {
+            return true
+        }
+    }
+    return false
+}
+
+// DetectMasquerading inspects the file name and leading content bytes for
+// files disguised as a different type. A nil header only checks the name.
+func DetectMasquerading(fileName string, header []byte) []*ValidationError {
+    var findings []*ValidationError
+
+    if strings.IndexFunc(fileName, isBidiControl) >= 0 {
+        findings = append(findings, &ValidationError{
+            Code:    CodeBidiControl,
+            Message: "File name contains bidirectional text control characters",
+        })
+    }
+
+    extensions := fileExtensions(fileName)
+    if len(extensions) > 0 && executableExtensions[extensions[len(extensions)-1]] {
+        findings = append(findings, &ValidationError{
+            Code:    CodeExecutableExtension,
+            Message: fmt.Sprintf("Executable file extension %s is not allowed", extensions[len(extensions)-1]),
+        })
+    }
+    if len(extensions) > 1 {
+        for _, ext := range extensions {
+            if executableExtensions[ext] {
+                findings = append(findings, &ValidationError{
+                    Code:    CodeDoubleExtension,
+                    Message: "File name combines multiple extensions with an executable one",
+                })
+                break
+            }
+        }
+    }
+
+    for _, signature := range executableSignatures {
+        if bytes.HasPrefix(header, signature.magic) {
+            findings = append(findings, &ValidationError{
+                Code:    CodeExecutableContent,
+                Message: fmt.Sprintf("File content is a %s executable", signature.format),
+            })
+            break
+        }
+    }
+
+    return findings
+}
+
+// CheckMasquerading applies the policy to the findings for a file. Without
+// FlagOnly the first finding is returned as the error; otherwise findings are
+// returned for the caller to record and the file is accepted.
+func CheckMasquerading(fileName string, header []byte, policy MasqueradePolicy) ([]*ValidationError, error) {
+    var findings []*ValidationError
+    for _, finding := range DetectMasquerading(fileName, header) {
+        if !policy.allows(finding.Code) {
+            findings = append(findings, finding)
+        }
+    }
+
+    if len(findings) == 0 || policy.FlagOnly {
+        return findings, nil
+    }
+    return findings, findings[0]
+}
+
+// fileExtensions returns the lower-cased extensions of a file name in order,
+// ignoring trailing dots and spaces that Windows strips when opening files
+func fileExtensions(fileName string) []string {
+    base := strings.TrimRight(filepath.Base(fileName), ". ")
+    parts := strings.Split(strings.TrimLeft(base, "."), ".")
+
+    var extensions []string
+    for _, part := range parts[1:] {
+        if ext := strings.ToLower(strings.TrimSpace(part)); ext != "" {
+            extensions = append(extensions, "."+ext)
+        }
+    }
+    return extensions
+}
+
+// isBidiControl reports whether r reorders or marks the direction of text,
+// as used to render "invoice\u202Efdp.exe" as "invoiceexe.pdf"
+func isBidiControl(r rune) bool {
+    switch {
+    case r >= '\u202A' && r <= '\u202E', r >= '\u2066' && r <= '\u2069':
+        return true
+    case r == '\u200E', r == '\u200F', r == '\u061C':
+        return true
+    }
+    return false
+}
@@ -0,0 +1,42 @@
+package validator
+
+import (
+    "errors"
+
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// ValidateStoragePath checks that an object key follows the storage key
+// policy. Keys in the legacy unsharded layout are accepted until migrated.
+func ValidateStoragePath(storagePath string) error {
+    if err := storagekey.Default.Validate(storagePath); err != nil {
+        return storagePathError(err)
+    }
+    return nil
+}
+
+// ValidateTenantStoragePath checks that an object key follows the storage
+// key policy and is not scoped to a tenant other than tenantID
+func ValidateTenantStoragePath(storagePath, tenantID string) error {
+    if err := storagekey.Default.ValidateTenant(storagePath, tenantID); err != nil {
+        return storagePathError(err)
+    }
+    return nil
+}
+
+// storagePathError reports a storage key policy violation
+func storagePathError(err error) error {
+    code := "INVALID_STORAGE_PATH"
+    switch {
+    case errors.Is(err, storagekey.ErrTooLong):
+        code = "STORAGE_PATH_TOO_LONG"
+    case errors.Is(err, storagekey.ErrInvalidSegment):
+        code = "PATH_TRAVERSAL"
+    case errors.Is(err, storagekey.ErrTenantMismatch):
+        code = "TENANT_MISMATCH"
+    }
+    return &ValidationError{
+        Code:    code,
+        Message: err.Error(),
+    }
+}
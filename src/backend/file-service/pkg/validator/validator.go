@@ -3,10 +3,8 @@
 package validator
 
 import (
-    "bytes"
     "errors"
     "fmt"
-    "io"
     "mime"
     "path/filepath"
     "strings"
@@ -21,6 +19,13 @@ const (
     
     // MaxFileNameLength defines maximum allowed filename length
     MaxFileNameLength = 255
+
+    // MaxFolderLength defines maximum allowed logical folder path length
+    MaxFolderLength = 1024
+    
+    // MaxObjectSize defines the largest object storage accepts (5TB), the
+    // ceiling for sizes granted by upload policies
+    MaxObjectSize int64 = 5 * 1024 * 1024 * 1024 * 1024
 )
 
 // AllowedFileTypes defines the list of allowed MIME types
@@ -31,11 +36,15 @@ var AllowedFileTypes = []string{
     "text/plain",
 }
 
-// Common malware signatures (simplified example - in production use comprehensive signature database)
-var malwareSignatures = [][]byte{
-    []byte{0x4D, 0x5A}, // EXE signature
-    []byte("<?php"),    // PHP script signature
-    []byte("<script>"), // JavaScript signature
+// Common malware signatures (simplified example - in production use comprehensive signature database).
+// Anchored signatures only match at the start of the content.
+var malwareSignatures = []struct {
+    magic    []byte
+    anchored bool
+}{
+    {[]byte{0x4D, 0x5A}, true},  // EXE signature
+    {[]byte("<?php"), false},    // PHP script signature
+    {[]byte("<script>"), false}, // JavaScript signature
 }
 
 // ValidationError represents a custom error type for validation failures
@@ -50,6 +59,11 @@ func (e *ValidationError) Error() string {
 
 // ValidateFileSize checks if the file size is within acceptable limits
 func ValidateFileSize(size int64) error {
+    return ValidateFileSizeLimit(size, MaxFileSize)
+}
+
+// ValidateFileSizeLimit checks if the file size is positive and within maxSize
+func ValidateFileSizeLimit(size int64, maxSize int64) error {
     log := logger.GetLogger()
     
     if size <= 0 {
@@ -59,46 +73,36 @@ func ValidateFileSize(size int64) error {
         }
     }
     
-    if size > MaxFileSize {
+    if size > maxSize {
         log.Error("File size validation failed",
-            logger.zap.Int64("size", size),
-            logger.zap.Int64("maxAllowed", MaxFileSize))
+            logger.Int64("size", size),
+            logger.Int64("maxAllowed", maxSize))
         return &ValidationError{
             Code:    "SIZE_EXCEEDED",
-            Message: fmt.Sprintf("File size %d exceeds maximum allowed size of %d bytes", size, MaxFileSize),
+            Message: fmt.Sprintf("File size %d exceeds maximum allowed size of %d bytes", size, maxSize),
         }
     }
     
-    log.Debug("File size validation passed", logger.zap.Int64("size", size))
+    log.Debug("File size validation passed", logger.Int64("size", size))
     return nil
 }
 
 // ValidateFileType performs enhanced MIME type validation with spoofing detection
 func ValidateFileType(contentType string, header []byte) error {
+    return ValidateFileTypeIn(contentType, header, AllowedFileTypes)
+}
+
+// ValidateFileTypeIn performs MIME type validation against the given allowed types
+func ValidateFileTypeIn(contentType string, header []byte, allowedTypes []string) error {
     log := logger.GetLogger()
     
-    if contentType == "" {
-        return &ValidationError{
-            Code:    "MISSING_CONTENT_TYPE",
-            Message: "Content type is required",
-        }
-    }
-    
-    // Detect MIME type from file header
-    detectedType := mime.TypeByExtension(filepath.Ext(contentType))
-    if detectedType != "" && detectedType != contentType {
-        log.Warn("Potential MIME type spoofing detected",
-            logger.zap.String("claimed", contentType),
-            logger.zap.String("detected", detectedType))
-        return &ValidationError{
-            Code:    "MIME_SPOOFING",
-            Message: "Content type mismatch - potential MIME spoofing attempt",
-        }
+    if err := ValidateContentType(contentType); err != nil {
+        return err
     }
     
     // Validate against allowed types
     allowed := false
-    for _, allowedType := range AllowedFileTypes {
+    for _, allowedType := range allowedTypes {
         if strings.EqualFold(contentType, allowedType) {
             allowed = true
             break
@@ -107,7 +111,7 @@ func ValidateFileType(contentType string, header []byte) error {
     
     if !allowed {
         log.Error("Invalid file type",
-            logger.zap.String("contentType", contentType))
+            logger.String("contentType", contentType))
         return &ValidationError{
             Code:    "INVALID_TYPE",
             Message: fmt.Sprintf("File type %s is not allowed", contentType),
@@ -115,7 +119,34 @@ func ValidateFileType(contentType string, header []byte) error {
     }
     
     log.Debug("File type validation passed",
-        logger.zap.String("contentType", contentType))
+        logger.String("contentType", contentType))
+    return nil
+}
+
+// ValidateContentType checks that a content type is present and not spoofed,
+// without restricting it to the allowed types
+func ValidateContentType(contentType string) error {
+    log := logger.GetLogger()
+    
+    if contentType == "" {
+        return &ValidationError{
+            Code:    "MISSING_CONTENT_TYPE",
+            Message: "Content type is required",
+        }
+    }
+    
+    // Detect MIME type from file header
+    detectedType := mime.TypeByExtension(filepath.Ext(contentType))
+    if detectedType != "" && detectedType != contentType {
+        log.Warn("Potential MIME type spoofing detected",
+            logger.String("claimed", contentType),
+            logger.String("detected", detectedType))
+        return &ValidationError{
+            Code:    "MIME_SPOOFING",
+            Message: "Content type mismatch - potential MIME spoofing attempt",
+        }
+    }
+    
     return nil
 }
 
@@ -141,7 +172,7 @@ func ValidateFileName(fileName string) error {
     cleanPath := filepath.Clean(fileName)
     if strings.Contains(cleanPath, "..") {
         log.Error("Path traversal attempt detected",
-            logger.zap.String("fileName", fileName))
+            logger.String("fileName", fileName))
         return &ValidationError{
             Code:    "PATH_TRAVERSAL",
             Message: "Invalid file name - path traversal attempt detected",
@@ -158,7 +189,35 @@ func ValidateFileName(fileName string) error {
     }
     
     log.Debug("File name validation passed",
-        logger.zap.String("fileName", fileName))
+        logger.String("fileName", fileName))
+    return nil
+}
+
+// ValidateFolder checks a logical folder path such as "reports/2024". The
+// empty path is the root folder; each segment must be a valid file name.
+func ValidateFolder(folder string) error {
+    if folder == "" {
+        return nil
+    }
+
+    if len(folder) > MaxFolderLength {
+        return &ValidationError{
+            Code:    "FOLDER_TOO_LONG",
+            Message: fmt.Sprintf("Folder exceeds maximum length of %d characters", MaxFolderLength),
+        }
+    }
+
+    for _, segment := range strings.Split(folder, "/") {
+        if segment == "" || segment == "." {
+            return &ValidationError{
+                Code:    "INVALID_FOLDER",
+                Message: "Folder contains an empty segment",
+            }
+        }
+        if err := ValidateFileName(segment); err != nil {
+            return err
+        }
+    }
     return nil
 }
 
@@ -173,45 +232,19 @@ func ValidateFileContent(content []byte) error {
         }
     }
     
-    // Check for malware signatures
-    reader := bytes.NewReader(content)
-    buffer := make([]byte, 1024)
-    
-    for {
-        n, err := reader.Read(buffer)
-        if err == io.EOF {
-            break
-        }
-        if err != nil {
-            return &ValidationError{
-                Code:    "READ_ERROR",
-                Message: "Error reading file content: " + err.Error(),
-            }
-        }
-        
-        chunk := buffer[:n]
-        for _, signature := range malwareSignatures {
-            if bytes.Contains(chunk, signature) {
-                log.Error("Malware signature detected",
-                    logger.zap.Binary("signature", signature))
-                return &ValidationError{
-                    Code:    "MALWARE_DETECTED",
-                    Message: "Potential security threat detected in file content",
-                }
-            }
-        }
+    // Check for malware signatures and verify content integrity
+    check := &signatureCheck{text: true}
+    finding := check.inspect(content)
+    if finding == nil {
+        finding = check.finish()
     }
-    
-    // Verify content integrity
-    if bytes.Count(content, []byte{0}) > len(content)/2 {
-        log.Warn("Suspicious content detected - high concentration of null bytes")
-        return &ValidationError{
-            Code:    "SUSPICIOUS_CONTENT",
-            Message: "File content appears to be corrupted or suspicious",
-        }
+    if finding != nil {
+        log.Warn("File content validation failed",
+            logger.String("code", finding.Code))
+        return finding
     }
     
     log.Debug("File content validation passed",
-        logger.zap.Int("contentLength", len(content)))
+        logger.Int("contentLength", len(content)))
     return nil
 }
\ No newline at end of file
@@ -0,0 +1,97 @@
+// Package webhook delivers signed JSON event notifications to an HTTP endpoint
+package webhook
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "src/backend/file-service/pkg/tracing"
+)
+
+const (
+    // SignatureHeader carries the hex HMAC-SHA256 of the body, keyed with the shared secret
+    SignatureHeader = "X-Webhook-Signature"
+    // EventHeader carries the event type so receivers can route without parsing the body
+    EventHeader = "X-Webhook-Event"
+)
+
+// Event is the JSON body posted to the endpoint
+type Event struct {
+    Type   string      `json:"type"`
+    SentAt time.Time   `json:"sentAt"`
+    Data   interface{} `json:"data"`
+}
+
+// Client posts events to a single webhook endpoint
+type Client struct {
+    url        string
+    secret     []byte
+    httpClient *http.Client
+}
+
+// New creates a webhook client. Events are signed when secret is non-empty.
+func New(url, secret string, timeout time.Duration) (*Client, error) {
+    if url == "" {
+        return nil, errors.New("webhook URL is required")
+    }
+    if timeout <= 0 {
+        return nil, errors.New("webhook timeout must be positive")
+    }
+
+    return &Client{
+        url:        url,
+        secret:     []byte(secret),
+        httpClient: &http.Client{Timeout: timeout},
+    }, nil
+}
+
+// Send posts an event of the given type; any non-2xx response is an error
+func (c *Client) Send(ctx context.Context, eventType string, data interface{}) error {
+    body, err := json.Marshal(Event{
+        Type:   eventType,
+        SentAt: time.Now().UTC(),
+        Data:   data,
+    })
+    if err != nil {
+        return fmt.Errorf("failed to encode webhook event: %w", err)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+    if err != nil {
+        return fmt.Errorf("failed to build webhook request: %w", err)
+    }
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set(EventHeader, eventType)
+    if traceparent := tracing.Traceparent(ctx); traceparent != "" {
+        req.Header.Set(tracing.TraceparentHeader, traceparent)
+    }
+    if len(c.secret) > 0 {
+        req.Header.Set(SignatureHeader, Sign(c.secret, body))
+    }
+
+    resp, err := c.httpClient.Do(req)
+    if err != nil {
+        return fmt.Errorf("webhook delivery failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        return fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+    }
+    return nil
+}
+
+// Sign returns the signature header value for body
+func Sign(secret, body []byte) string {
+    mac := hmac.New(sha256.New, secret)
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
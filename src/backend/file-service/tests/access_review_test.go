@@ -0,0 +1,208 @@
+package tests
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+)
+
+// mockShareRepository is an in-memory ShareRepository. Shared files have no
+// owner here, so shares are reviewed by the user who shared them.
+type mockShareRepository struct {
+    mu       sync.Mutex
+    shares   map[string]*models.Share
+    notified map[string]time.Time
+    requests *mockFileRequestRepository
+}
+
+func newMockShareRepository(requests *mockFileRequestRepository) *mockShareRepository {
+    return &mockShareRepository{
+        shares:   make(map[string]*models.Share),
+        notified: make(map[string]time.Time),
+        requests: requests,
+    }
+}
+
+func (m *mockShareRepository) Record(ctx context.Context, share *models.Share) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    stored := *share
+    stored.OwnerID = share.SharedBy
+    m.shares[share.ID] = &stored
+    return nil
+}
+
+func (m *mockShareRepository) Touch(ctx context.Context, fileID, userID string, at time.Time) (bool, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    touched := false
+    for _, share := range m.shares {
+        if share.FileID == fileID && share.SharedWith == userID && share.RevokedAt == nil {
+            share.LastUsedAt = &at
+            touched = true
+        }
+    }
+    return touched, nil
+}
+
+func (m *mockShareRepository) Revoke(ctx context.Context, id string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    share, ok := m.shares[id]
+    if !ok || share.RevokedAt != nil {
+        return repository.ErrShareNotFound
+    }
+    share.RevokedAt = &at
+    return nil
+}
+
+// stale reports whether an item last active at lastActivity is due for review
+func (m *mockShareRepository) stale(id string, lastActivity, before time.Time, includeNotified bool) bool {
+    notifiedAt, notified := m.notified[id]
+    return lastActivity.Before(before) && (includeNotified || !notified || notifiedAt.Before(lastActivity))
+}
+
+func (m *mockShareRepository) ListStale(ctx context.Context, before time.Time, includeNotified bool, limit int) ([]*models.Share, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var shares []*models.Share
+    for _, share := range m.shares {
+        if share.RevokedAt == nil && m.stale(share.ID, share.LastActivity(), before, includeNotified) && len(shares) < limit {
+            found := *share
+            shares = append(shares, &found)
+        }
+    }
+    return shares, nil
+}
+
+func (m *mockShareRepository) MarkNotified(ctx context.Context, id string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.notified[id] = at
+    return nil
+}
+
+func (m *mockShareRepository) ListStaleFileRequests(ctx context.Context, before, now time.Time, includeNotified bool,
+    limit int) ([]*models.FileRequest, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.requests.mu.Lock()
+    defer m.requests.mu.Unlock()
+    var requests []*models.FileRequest
+    for _, request := range m.requests.requests {
+        if request.IsOpen(now) && m.stale(request.ID, request.LastActivity(), before, includeNotified) && len(requests) < limit {
+            found := *request
+            requests = append(requests, &found)
+        }
+    }
+    return requests, nil
+}
+
+func (m *mockShareRepository) MarkFileRequestNotified(ctx context.Context, id string, at time.Time) error {
+    return m.MarkNotified(ctx, id, at)
+}
+
+// TestAccessReview tests finding, reporting and revoking unused shares and
+// file requests
+func TestAccessReview(t *testing.T) {
+    ctx := context.Background()
+    hooks := &recordingNotifier{}
+    notifications, err := service.NewNotificationService(newMockNotificationRepository(), newMockRepository(), nil, hooks,
+        24*time.Hour, time.Second)
+    require.NoError(t, err)
+    _, err = notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+        UserID:        "bob",
+        WebhookURL:    "https://example.com/hook",
+        ShareChannels: []string{models.ChannelWebhook},
+    })
+    require.NoError(t, err)
+
+    requests := newMockFileRequestRepository()
+    shares := newMockShareRepository(requests)
+    staleAfter := 30 * 24 * time.Hour
+    review, err := service.NewAccessReview(shares, requests, notifications, service.AccessReviewOptions{StaleAfter: staleAfter})
+    require.NoError(t, err)
+
+    // A share and a file request created before the stale period, and a
+    // share created just now
+    longAgo := time.Now().UTC().Add(-40 * 24 * time.Hour)
+    require.NoError(t, review.RecordShare(ctx, service.ShareEvent{FileID: "file-1", SharedBy: "bob", SharedWith: "carol"}))
+    require.NoError(t, review.RecordShare(ctx, service.ShareEvent{FileID: "file-2", SharedBy: "bob", SharedWith: "dave"}))
+    for _, share := range shares.shares {
+        if share.FileID == "file-1" {
+            share.CreatedAt = longAgo
+        }
+    }
+    request, err := models.NewFileRequest("bob", "Tax documents", "finance", 1024, nil, 5, 90*24*time.Hour)
+    require.NoError(t, err)
+    request.CreatedAt = longAgo
+    require.NoError(t, requests.Create(ctx, request))
+
+    t.Run("Report", func(t *testing.T) {
+        report, err := review.Report(ctx, 0)
+        require.NoError(t, err)
+        require.Len(t, report.Shares, 1)
+        assert.Equal(t, "file-1", report.Shares[0].FileID)
+        require.Len(t, report.FileRequests, 1)
+        assert.Equal(t, request.ID, report.FileRequests[0].ID)
+
+        report, err = review.Report(ctx, 50*24*time.Hour)
+        require.NoError(t, err)
+        assert.Empty(t, report.Shares)
+        assert.Empty(t, report.FileRequests)
+    })
+
+    t.Run("Notify Owners Once", func(t *testing.T) {
+        handled, err := review.Review(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 2, handled)
+        require.Len(t, hooks.messages(), 1)
+        assert.Equal(t, service.StaleAccessEvent, hooks.messages()[0].Event)
+
+        handled, err = review.Review(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 0, handled)
+        assert.Len(t, hooks.messages(), 1)
+    })
+
+    t.Run("Use Keeps Share", func(t *testing.T) {
+        shared, err := review.TouchShare(ctx, "file-1", "carol")
+        require.NoError(t, err)
+        assert.True(t, shared)
+
+        shared, err = review.TouchShare(ctx, "file-1", "eve")
+        require.NoError(t, err)
+        assert.False(t, shared)
+
+        report, err := review.Report(ctx, 0)
+        require.NoError(t, err)
+        assert.Empty(t, report.Shares)
+        assert.Len(t, report.FileRequests, 1)
+    })
+
+    t.Run("Auto Revoke", func(t *testing.T) {
+        revoking, err := service.NewAccessReview(shares, requests, notifications, service.AccessReviewOptions{
+            StaleAfter: staleAfter,
+            AutoRevoke: true,
+        })
+        require.NoError(t, err)
+
+        handled, err := revoking.Review(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 1, handled)
+        require.Len(t, hooks.messages(), 2)
+        assert.True(t, hooks.messages()[1].Data.(service.StaleAccess).Revoked)
+
+        revoked, err := requests.GetByID(ctx, request.ID)
+        require.NoError(t, err)
+        assert.NotNil(t, revoked.RevokedAt)
+    })
+}
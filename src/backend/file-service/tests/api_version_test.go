@@ -0,0 +1,80 @@
+package tests
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+
+    "src/backend/file-service/pkg/apiversion"
+)
+
+// TestAPIVersioning tests version negotiation by path and Accept header and
+// the deprecation headers of legacy routes
+func TestAPIVersioning(t *testing.T) {
+    var served string
+    api := http.NewServeMux()
+    for _, route := range []string{"/upload", "/download", "/rename"} {
+        api.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+            served = r.URL.Path
+        })
+    }
+
+    deprecatedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+    sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+    handler := apiversion.Mount(api, apiversion.Legacy(apiversion.Policy{
+        DeprecatedAt: deprecatedAt,
+        Sunset:       sunset,
+    }, "/upload", "/download"))
+
+    serve := func(path, accept string) *httptest.ResponseRecorder {
+        served = ""
+        req := httptest.NewRequest(http.MethodGet, path, nil)
+        if accept != "" {
+            req.Header.Set("Accept", accept)
+        }
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        return rec
+    }
+
+    t.Run("Versioned Path", func(t *testing.T) {
+        rec := serve("/api/v1/download?id=file-1", "")
+        assert.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, "/download", served)
+        assert.Equal(t, "v1", rec.Header().Get(apiversion.VersionHeader))
+        assert.Empty(t, rec.Header().Get("Deprecation"))
+    })
+
+    t.Run("Legacy Route", func(t *testing.T) {
+        rec := serve("/download?id=file-1", "")
+        assert.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, "/download", served)
+        assert.Equal(t, "@1767225600", rec.Header().Get("Deprecation"))
+        assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", rec.Header().Get("Sunset"))
+        assert.Equal(t, `</api/v1/download>; rel="successor-version"`, rec.Header().Get("Link"))
+    })
+
+    t.Run("Unversioned Route Not Deprecated", func(t *testing.T) {
+        rec := serve("/rename", "")
+        assert.Equal(t, http.StatusOK, rec.Code)
+        assert.Empty(t, rec.Header().Get("Deprecation"))
+    })
+
+    t.Run("Accept Header", func(t *testing.T) {
+        rec := serve("/api/v1/upload", "application/vnd.fileservice.v2+json, application/vnd.fileservice.v1+json;q=0.5")
+        assert.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, "v1", rec.Header().Get(apiversion.VersionHeader))
+
+        rec = serve("/api/v1/upload", "application/json")
+        assert.Equal(t, http.StatusOK, rec.Code)
+    })
+
+    t.Run("Unsupported Version", func(t *testing.T) {
+        rec := serve("/api/v1/upload", "application/vnd.fileservice.v2+json")
+        assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+        assert.Empty(t, served)
+    })
+}
@@ -0,0 +1,218 @@
+package tests
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bytes"
+    "compress/gzip"
+    "context"
+    "crypto/rand"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/archivelist"
+)
+
+// rangeContentStorage serves fixed file content from offsets, counting the
+// bytes read
+type rangeContentStorage struct {
+    contentStorage
+
+    mu   sync.Mutex
+    read int64
+}
+
+func (s *rangeContentStorage) DownloadFrom(ctx context.Context, file *models.File, offset int64) (io.ReadCloser, error) {
+    return io.NopCloser(&countingReader{r: bytes.NewReader(s.content[file.ID][offset:]), storage: s}), nil
+}
+
+func (s *rangeContentStorage) bytesRead() int64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.read
+}
+
+// countingReader adds the bytes read through it to its storage's count
+type countingReader struct {
+    r       io.Reader
+    storage *rangeContentStorage
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+    n, err := c.r.Read(p)
+    c.storage.mu.Lock()
+    c.storage.read += int64(n)
+    c.storage.mu.Unlock()
+    return n, err
+}
+
+// testZip builds a zip archive of a compressible text file and a stored
+// file of random bytes
+func testZip(t *testing.T, padding int) []byte {
+    var buf bytes.Buffer
+    archive := zip.NewWriter(&buf)
+
+    w, err := archive.Create("docs/readme.txt")
+    require.NoError(t, err)
+    _, err = w.Write([]byte(strings.Repeat("archive ", 4096)))
+    require.NoError(t, err)
+
+    random := make([]byte, padding)
+    _, err = rand.Read(random)
+    require.NoError(t, err)
+    w, err = archive.CreateHeader(&zip.FileHeader{Name: "data.bin", Method: zip.Store})
+    require.NoError(t, err)
+    _, err = w.Write(random)
+    require.NoError(t, err)
+
+    require.NoError(t, archive.Close())
+    return buf.Bytes()
+}
+
+// testTarGzip builds a gzip compressed tar archive of files with the given
+// names and sizes
+func testTarGzip(t *testing.T, files map[string]int) []byte {
+    var buf bytes.Buffer
+    gz := gzip.NewWriter(&buf)
+    archive := tar.NewWriter(gz)
+    for name, size := range files {
+        require.NoError(t, archive.WriteHeader(&tar.Header{
+            Name:     name,
+            Mode:     0o644,
+            Size:     int64(size),
+            ModTime:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+            Typeflag: tar.TypeReg,
+        }))
+        _, err := archive.Write(bytes.Repeat([]byte("x"), size))
+        require.NoError(t, err)
+    }
+    require.NoError(t, archive.Close())
+    require.NoError(t, gz.Close())
+    return buf.Bytes()
+}
+
+// TestArchiveContents tests listing archives as a derived object without
+// reading more of them than their directory
+func TestArchiveContents(t *testing.T) {
+    ctx := context.Background()
+    files := newMockRepository()
+    content := &rangeContentStorage{contentStorage: contentStorage{content: map[string][]byte{
+        "zip-1":  testZip(t, 1<<20),
+        "tgz-1":  testTarGzip(t, map[string]int{"a.txt": 10, "b.txt": 20}),
+        "text-1": []byte("not an archive"),
+    }}}
+    objects := &memoryObjectStore{objects: make(map[string][]byte)}
+
+    for id, meta := range map[string][2]string{
+        "zip-1":  {"bundle.zip", "application/zip"},
+        "tgz-1":  {"bundle.tar.gz", "application/gzip"},
+        "text-1": {"notes.txt", "text/plain"},
+    } {
+        require.NoError(t, files.Create(ctx, &models.File{
+            ID:          id,
+            FileName:    meta[0],
+            ContentType: meta[1],
+            Size:        int64(len(content.content[id])),
+            Status:      models.FileStatusUploaded,
+            Checksum:    "v1",
+            CreatedAt:   time.Now().UTC(),
+        }))
+    }
+
+    // The zip is far beyond the max source size, but only its directory is read
+    derived, err := service.NewDerivedObjectService(newMockDerivedObjectRepository(), files, content, objects, 1024,
+        service.NewArchiveContentsGenerator(content))
+    require.NoError(t, err)
+
+    listing := func(t *testing.T, reader io.ReadCloser) archivelist.Listing {
+        defer reader.Close()
+        var listing archivelist.Listing
+        require.NoError(t, json.NewDecoder(reader).Decode(&listing))
+        return listing
+    }
+
+    t.Run("Zip", func(t *testing.T) {
+        object, reader, err := derived.Get(ctx, "zip-1", models.DerivedKindContents, nil)
+        require.NoError(t, err)
+        assert.Equal(t, archivelist.ContentType, object.ContentType)
+
+        contents := listing(t, reader)
+        assert.Equal(t, archivelist.FormatZip, contents.Format)
+        assert.Equal(t, 2, contents.Count)
+        assert.False(t, contents.Truncated)
+        require.Len(t, contents.Entries, 2)
+        assert.Equal(t, "docs/readme.txt", contents.Entries[0].Name)
+        assert.Equal(t, int64(8*4096), contents.Entries[0].Size)
+        assert.Greater(t, contents.Entries[0].Ratio, 10.0)
+        assert.Equal(t, "data.bin", contents.Entries[1].Name)
+        assert.Equal(t, int64(1<<20), contents.Entries[1].CompressedSize)
+        assert.InDelta(t, 1.0, contents.Entries[1].Ratio, 0.001)
+
+        assert.Less(t, content.bytesRead(), int64(64<<10))
+    })
+
+    t.Run("Tar Gzip", func(t *testing.T) {
+        _, reader, err := derived.Get(ctx, "tgz-1", models.DerivedKindContents, nil)
+        require.NoError(t, err)
+
+        contents := listing(t, reader)
+        assert.Equal(t, archivelist.FormatTarGzip, contents.Format)
+        assert.Equal(t, 2, contents.Count)
+        assert.Equal(t, int64(30), contents.Size)
+        for _, entry := range contents.Entries {
+            assert.Zero(t, entry.CompressedSize)
+            require.NotNil(t, entry.Modified)
+            assert.Equal(t, 2024, entry.Modified.Year())
+        }
+    })
+
+    t.Run("Not An Archive", func(t *testing.T) {
+        _, _, err := derived.Get(ctx, "text-1", models.DerivedKindContents, nil)
+        assert.ErrorIs(t, err, service.ErrDerivedNotSupported)
+    })
+
+    t.Run("Endpoint", func(t *testing.T) {
+        handler := handlers.NewDerivedHandler(derived, nil, handlers.DownloadSecurityPolicy{}, nil, nil)
+        assert.True(t, handlers.IsDerivedPath("/files/zip-1/contents"))
+
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/zip-1/contents", nil))
+        require.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, archivelist.ContentType, rec.Header().Get("Content-Type"))
+        assert.Len(t, listing(t, io.NopCloser(rec.Body)).Entries, 2)
+
+        rec = httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/text-1/contents", nil))
+        assert.Equal(t, http.StatusNotFound, rec.Code)
+    })
+}
+
+// TestArchiveListTruncated tests bounding the entries of a listing
+func TestArchiveListTruncated(t *testing.T) {
+    data := testTarGzip(t, map[string]int{"a": 1, "b": 2, "c": 3})
+    listing, err := archivelist.List(archivelist.FormatTarGzip, bytes.NewReader(data), int64(len(data)), 2)
+    require.NoError(t, err)
+    assert.True(t, listing.Truncated)
+    assert.Len(t, listing.Entries, 2)
+
+    _, err = archivelist.List(archivelist.FormatZip, bytes.NewReader([]byte("not a zip")), 9, 2)
+    assert.Error(t, err)
+
+    format, ok := archivelist.Format("application/x-zip-compressed", "bundle.zip")
+    assert.True(t, ok)
+    assert.Equal(t, archivelist.FormatZip, format)
+    _, ok = archivelist.Format("application/gzip", "dump.sql.gz")
+    assert.False(t, ok)
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// fakeArchive records transitions and restores, reporting restore states
+// from statuses by storage path
+type fakeArchive struct {
+    transitions map[string]string
+    restores    []string
+    statuses    map[string]storage.RestoreStatus
+}
+
+func newFakeArchive() *fakeArchive {
+    return &fakeArchive{
+        transitions: make(map[string]string),
+        statuses:    make(map[string]storage.RestoreStatus),
+    }
+}
+
+func (a *fakeArchive) Transition(ctx context.Context, file *models.File, storageClass string) error {
+    a.transitions[file.StoragePath] = storageClass
+    return nil
+}
+
+func (a *fakeArchive) Restore(ctx context.Context, file *models.File, days int, tier string) error {
+    a.restores = append(a.restores, file.StoragePath)
+    a.statuses[file.StoragePath] = storage.RestoreStatus{Ongoing: true}
+    return nil
+}
+
+func (a *fakeArchive) RestoreStatus(ctx context.Context, file *models.File) (storage.RestoreStatus, error) {
+    return a.statuses[file.StoragePath], nil
+}
+
+// TestArchiveIdle tests moving idle files to the storage class their idle period reached
+func TestArchiveIdle(t *testing.T) {
+    repo := newMockRepository()
+    now := time.Now()
+    for id, idle := range map[string]time.Duration{
+        "file-recent": time.Hour,
+        "file-idle":   100 * 24 * time.Hour,
+        "file-cold":   400 * 24 * time.Hour,
+    } {
+        repo.files[id] = &models.File{
+            ID:             id,
+            Status:         models.FileStatusUploaded,
+            StoragePath:    "files/" + id,
+            LastAccessedAt: now.Add(-idle),
+        }
+    }
+
+    archive := newFakeArchive()
+    archiver, err := service.NewArchiveService(repo, archive, service.ArchiveOptions{
+        GlacierAfter:     90 * 24 * time.Hour,
+        DeepArchiveAfter: 365 * 24 * time.Hour,
+        BatchSize:        10,
+        RestoreDays:      7,
+        RestoreTier:      "Standard",
+    })
+    require.NoError(t, err)
+
+    count, err := archiver.ArchiveIdle(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 2, count)
+    assert.Equal(t, map[string]string{
+        "files/file-idle": models.StorageClassGlacier,
+        "files/file-cold": models.StorageClassDeepArchive,
+    }, archive.transitions)
+
+    assert.False(t, repo.files["file-recent"].IsArchived())
+    idle := repo.files["file-idle"]
+    assert.Equal(t, models.ArchiveStatusArchived, idle.ArchiveStatus)
+    assert.Equal(t, models.StorageClassGlacier, idle.StorageClass)
+    assert.NotNil(t, idle.ArchivedAt)
+
+    // Archived files are not archived again
+    count, err = archiver.ArchiveIdle(context.Background())
+    require.NoError(t, err)
+    assert.Zero(t, count)
+
+    _, err = service.NewArchiveService(repo, archive, service.ArchiveOptions{BatchSize: 10, RestoreDays: 7, RestoreTier: "Standard"})
+    assert.Error(t, err)
+}
+
+// TestArchiveRestore tests that archived files are restored before they can be downloaded
+func TestArchiveRestore(t *testing.T) {
+    ctx := context.Background()
+    repo := newMockRepository()
+    archivedAt := time.Now().Add(-time.Hour)
+    repo.files["file-1"] = &models.File{
+        ID:            "file-1",
+        FileName:      "report.pdf",
+        ContentType:   "application/pdf",
+        Status:        models.FileStatusUploaded,
+        StoragePath:   "files/file-1",
+        StorageClass:  models.StorageClassGlacier,
+        ArchiveStatus: models.ArchiveStatusArchived,
+        ArchivedAt:    &archivedAt,
+    }
+
+    fileService, err := service.NewFileService(newMockStorage(), repo, service.WorkerPoolConfig{})
+    require.NoError(t, err)
+    _, _, err = fileService.Download(ctx, "file-1")
+    assert.ErrorIs(t, err, service.ErrFileArchived)
+
+    archive := newFakeArchive()
+    archiver, err := service.NewArchiveService(repo, archive, service.ArchiveOptions{
+        GlacierAfter: 90 * 24 * time.Hour,
+        BatchSize:    10,
+        RestoreDays:  7,
+        RestoreTier:  "Bulk",
+    })
+    require.NoError(t, err)
+
+    file, err := archiver.Restore(ctx, "file-1")
+    require.NoError(t, err)
+    assert.Equal(t, models.ArchiveStatusRestoring, file.ArchiveStatus)
+    assert.True(t, file.NeedsRestore(time.Now()))
+
+    // Restores already in progress are not requested again
+    _, err = archiver.Restore(ctx, "file-1")
+    require.NoError(t, err)
+    assert.Equal(t, []string{"files/file-1"}, archive.restores)
+
+    count, err := archiver.PollRestores(ctx)
+    require.NoError(t, err)
+    assert.Zero(t, count, "ongoing restore")
+
+    expiresAt := time.Now().Add(7 * 24 * time.Hour).UTC()
+    archive.statuses["files/file-1"] = storage.RestoreStatus{ExpiresAt: &expiresAt}
+    count, err = archiver.PollRestores(ctx)
+    require.NoError(t, err)
+    assert.Equal(t, 1, count)
+    restored := repo.files["file-1"]
+    assert.Equal(t, models.ArchiveStatusRestored, restored.ArchiveStatus)
+    assert.False(t, restored.NeedsRestore(time.Now()))
+    assert.False(t, restored.NeedsRestore(expiresAt.Add(-time.Minute)))
+    assert.True(t, restored.NeedsRestore(expiresAt))
+
+    // Once the restored copy expires, the file is archived again
+    expired := time.Now().Add(-time.Minute)
+    restored.RestoreExpiresAt = &expired
+    archive.statuses["files/file-1"] = storage.RestoreStatus{}
+    _, err = archiver.PollRestores(ctx)
+    require.NoError(t, err)
+    assert.Equal(t, models.ArchiveStatusArchived, repo.files["file-1"].ArchiveStatus)
+    assert.Nil(t, repo.files["file-1"].RestoreExpiresAt)
+
+    _, err = archiver.Restore(ctx, "missing")
+    assert.ErrorIs(t, err, service.ErrFileNotFound)
+}
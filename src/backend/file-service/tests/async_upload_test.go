@@ -0,0 +1,137 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// stagingStore stages uploads in memory under their staging keys
+type stagingStore struct {
+    memoryObjectStore
+}
+
+func (s *stagingStore) Stage(ctx context.Context, file *models.File, reader io.Reader) error {
+    content, err := io.ReadAll(reader)
+    if err != nil {
+        return err
+    }
+    key := storage.StagingKey(file.ID)
+    if err := s.PutObject(ctx, key, file.ContentType, content); err != nil {
+        return err
+    }
+    return file.SetStoragePath(key)
+}
+
+func (s *stagingStore) staged() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return len(s.objects)
+}
+
+// TestAsyncUpload tests uploads that return once their content is staged
+// and are processed in the background
+func TestAsyncUpload(t *testing.T) {
+    ctx := context.Background()
+
+    newService := func(t *testing.T, timeout time.Duration) (service.FileService, *mockStorage, *mockRepository, *stagingStore) {
+        mockStore := newMockStorage()
+        repo := newMockRepository()
+        staging := &stagingStore{memoryObjectStore{objects: map[string][]byte{}}}
+        fileService, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        }, service.WithAsyncUploads(staging, timeout))
+        require.NoError(t, err)
+        return fileService, mockStore, repo, staging
+    }
+    statusOf := func(fileService service.FileService, fileID string) func() string {
+        return func() string {
+            file, err := fileService.UploadStatus(ctx, fileID, "user-1")
+            if err != nil {
+                return err.Error()
+            }
+            return file.Status
+        }
+    }
+
+    t.Run("Processed", func(t *testing.T) {
+        fileService, mockStore, _, staging := newService(t, time.Hour)
+        mockStore.On("Upload", mock.Anything, mock.AnythingOfType("*models.File"), mock.Anything).Return(nil).Once()
+
+        file, err := fileService.UploadAsync(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{OwnerID: "user-1"})
+        require.NoError(t, err)
+        assert.Equal(t, models.FileStatusPending, file.Status)
+        assert.Equal(t, storage.StagingKey(file.ID), file.StoragePath)
+
+        assert.Eventually(t, func() bool { return statusOf(fileService, file.ID)() == models.FileStatusUploaded },
+            time.Second, 10*time.Millisecond)
+        assert.Eventually(t, func() bool { return staging.staged() == 0 }, time.Second, 10*time.Millisecond)
+
+        uploaded, err := fileService.UploadStatus(ctx, file.ID, "user-1")
+        require.NoError(t, err)
+        assert.Equal(t, file.ID, uploaded.ID)
+        assert.NotEmpty(t, uploaded.Checksum)
+        mockStore.AssertExpectations(t)
+    })
+
+    t.Run("Failed", func(t *testing.T) {
+        fileService, mockStore, _, staging := newService(t, time.Hour)
+        mockStore.On("Upload", mock.Anything, mock.AnythingOfType("*models.File"), mock.Anything).
+            Return(errors.New("s3 unavailable")).Once()
+
+        file, err := fileService.UploadAsync(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{OwnerID: "user-1"})
+        require.NoError(t, err)
+
+        assert.Eventually(t, func() bool { return statusOf(fileService, file.ID)() == models.FileStatusFailed },
+            time.Second, 10*time.Millisecond)
+        assert.Eventually(t, func() bool { return staging.staged() == 0 }, time.Second, 10*time.Millisecond)
+
+        // Other callers' uploads are not revealed until uploaded
+        _, err = fileService.UploadStatus(ctx, file.ID, "user-2")
+        assert.ErrorIs(t, err, service.ErrFileNotFound)
+    })
+
+    t.Run("Overwrite Rejected", func(t *testing.T) {
+        fileService, _, _, staging := newService(t, time.Hour)
+
+        _, err := fileService.UploadAsync(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{NameConflict: service.NameConflictOverwrite})
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+        assert.Zero(t, staging.staged())
+    })
+
+    t.Run("Stale Uploads Failed", func(t *testing.T) {
+        fileService, _, repo, staging := newService(t, time.Minute)
+        key := storage.StagingKey("stale-file")
+        require.NoError(t, staging.PutObject(ctx, key, testContentType, []byte("staged")))
+        require.NoError(t, repo.Create(ctx, &models.File{
+            ID: "stale-file", OwnerID: "user-1", Status: models.FileStatusPending,
+            StoragePath: key, UpdatedAt: time.Now().Add(-time.Hour),
+        }))
+        require.NoError(t, repo.Create(ctx, &models.File{
+            ID: "recent-file", OwnerID: "user-1", Status: models.FileStatusPending,
+            StoragePath: storage.StagingKey("recent-file"), UpdatedAt: time.Now(),
+        }))
+
+        failed, err := fileService.FailStaleUploads(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 1, failed)
+        assert.Equal(t, models.FileStatusFailed, statusOf(fileService, "stale-file")())
+        assert.Equal(t, models.FileStatusPending, statusOf(fileService, "recent-file")())
+        assert.Zero(t, staging.staged())
+    })
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+)
+
+// mockAttachmentRepository is an in-memory AttachmentRepository backed by a mockRepository
+type mockAttachmentRepository struct {
+    mu    sync.Mutex
+    files *mockRepository
+    links map[models.Attachment]bool
+}
+
+func newMockAttachmentRepository(files *mockRepository) *mockAttachmentRepository {
+    return &mockAttachmentRepository{files: files, links: make(map[models.Attachment]bool)}
+}
+
+func attachmentKey(entityType, entityID, fileID string) models.Attachment {
+    return models.Attachment{EntityType: entityType, EntityID: entityID, FileID: fileID}
+}
+
+func (m *mockAttachmentRepository) Attach(ctx context.Context, attachment *models.Attachment) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.links[attachmentKey(attachment.EntityType, attachment.EntityID, attachment.FileID)] = true
+    return nil
+}
+
+func (m *mockAttachmentRepository) Detach(ctx context.Context, entityType, entityID, fileID string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    key := attachmentKey(entityType, entityID, fileID)
+    if !m.links[key] {
+        return repository.ErrAttachmentNotFound
+    }
+    delete(m.links, key)
+    return nil
+}
+
+func (m *mockAttachmentRepository) ListFiles(ctx context.Context, entityType, entityID string, offset, limit int) ([]*models.File, int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for key := range m.links {
+        if key.EntityType != entityType || key.EntityID != entityID {
+            continue
+        }
+        if file, err := m.files.GetByID(ctx, key.FileID); err == nil {
+            files = append(files, file)
+        }
+    }
+    return files, int64(len(files)), nil
+}
+
+// TestAttachments tests linking files to external entities
+func TestAttachments(t *testing.T) {
+    ctx := context.Background()
+    files := newMockRepository()
+    attachmentService, err := service.NewAttachmentService(newMockAttachmentRepository(files), files)
+    require.NoError(t, err)
+
+    file, err := models.NewFile(testFileName, testFileSize, testContentType)
+    require.NoError(t, err)
+    require.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+    require.NoError(t, files.Create(ctx, file))
+
+    t.Run("Attach And List", func(t *testing.T) {
+        _, err := attachmentService.Attach(ctx, "order", "123", file.ID)
+        require.NoError(t, err)
+        _, err = attachmentService.Attach(ctx, "order", "123", file.ID)
+        require.NoError(t, err)
+
+        attached, total, err := attachmentService.ListFiles(ctx, "order", "123", 0, 10)
+        require.NoError(t, err)
+        assert.Equal(t, int64(1), total)
+        require.Len(t, attached, 1)
+        assert.Equal(t, file.ID, attached[0].ID)
+
+        other, _, err := attachmentService.ListFiles(ctx, "order", "124", 0, 10)
+        require.NoError(t, err)
+        assert.Empty(t, other)
+    })
+
+    t.Run("Detach", func(t *testing.T) {
+        require.NoError(t, attachmentService.Detach(ctx, "order", "123", file.ID))
+        err := attachmentService.Detach(ctx, "order", "123", file.ID)
+        assert.True(t, errors.Is(err, service.ErrAttachmentNotFound))
+    })
+
+    t.Run("Invalid References", func(t *testing.T) {
+        _, err := attachmentService.Attach(ctx, "Order Lines", "123", file.ID)
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+
+        _, err = attachmentService.Attach(ctx, "order", "123", "missing")
+        assert.True(t, errors.Is(err, service.ErrFileNotFound))
+    })
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+    "crypto/ed25519"
+    "crypto/rand"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/auditlog"
+)
+
+// sealChain seals n single-event batches into a chain
+func sealChain(t *testing.T, key ed25519.PrivateKey, n int) []*auditlog.Batch {
+    var batches []*auditlog.Batch
+    head := auditlog.Head{}
+    for i := 0; i < n; i++ {
+        batch, err := auditlog.Seal(head, []auditlog.Event{{
+            Type:   "file.status",
+            FileID: "file-1",
+            Detail: map[string]string{"from": "pending", "to": "uploaded"},
+            At:     time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+        }}, key)
+        require.NoError(t, err)
+        batches = append(batches, batch)
+        head = batch.Next()
+    }
+    return batches
+}
+
+// verifyChain checks batches in order and returns the errors reported
+func verifyChain(key ed25519.PublicKey, batches []*auditlog.Batch) []error {
+    verifier := auditlog.NewVerifier(key)
+    var errs []error
+    for _, batch := range batches {
+        if err := verifier.Check(batch); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errs
+}
+
+// TestAuditLog tests that sealed audit batches verify and that edits,
+// missing batches and foreign signatures are detected
+func TestAuditLog(t *testing.T) {
+    public, private, err := ed25519.GenerateKey(rand.Reader)
+    require.NoError(t, err)
+
+    t.Run("Intact Chain", func(t *testing.T) {
+        batches := sealChain(t, private, 3)
+        assert.Empty(t, verifyChain(public, batches))
+        assert.Equal(t, int64(3), batches[2].LastSeq)
+        assert.Equal(t, batches[1].Hash, batches[2].PrevHash)
+    })
+
+    t.Run("Deterministic", func(t *testing.T) {
+        first := sealChain(t, private, 2)
+        second := sealChain(t, private, 2)
+        assert.Equal(t, first[1].Hash, second[1].Hash)
+        assert.Equal(t, first[1].Signature, second[1].Signature)
+    })
+
+    t.Run("Edited Event", func(t *testing.T) {
+        batches := sealChain(t, private, 3)
+        batches[1].Events[0].Detail["to"] = "deleted"
+        errs := verifyChain(public, batches)
+        require.Len(t, errs, 1)
+        assert.ErrorIs(t, errs[0], auditlog.ErrTampered)
+    })
+
+    t.Run("Missing Batch", func(t *testing.T) {
+        batches := sealChain(t, private, 3)
+        errs := verifyChain(public, []*auditlog.Batch{batches[0], batches[2]})
+        require.Len(t, errs, 1)
+        assert.ErrorIs(t, errs[0], auditlog.ErrGap)
+    })
+
+    t.Run("Resealed With Another Key", func(t *testing.T) {
+        _, other, err := ed25519.GenerateKey(rand.Reader)
+        require.NoError(t, err)
+        batches := sealChain(t, private, 2)
+        forged, err := auditlog.Seal(batches[0].Next(), batches[1].Events, other)
+        require.NoError(t, err)
+        errs := verifyChain(public, []*auditlog.Batch{batches[0], forged})
+        require.Len(t, errs, 1)
+        assert.ErrorIs(t, errs[0], auditlog.ErrTampered)
+    })
+
+    t.Run("Object Keys Sort In Chain Order", func(t *testing.T) {
+        assert.True(t, auditlog.ObjectKey("audit/", 9) < auditlog.ObjectKey("audit/", 10))
+    })
+}
@@ -0,0 +1,203 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/authz"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// TestAuthzPolicy tests access decisions made by the bundled and custom
+// Rego policies
+func TestAuthzPolicy(t *testing.T) {
+    ctx := context.Background()
+
+    t.Run("Bundled Policy", func(t *testing.T) {
+        engine, err := authz.NewOPA(ctx, "")
+        require.NoError(t, err)
+
+        owned := authz.Resource{ID: "file-1", OwnerID: "user-1"}
+        tests := []struct {
+            name    string
+            action  string
+            subject authz.Subject
+            allow   bool
+        }{
+            {"Owner Downloads", authz.ActionDownload, authz.Subject{ID: "user-1", Roles: []string{"user"}}, true},
+            {"Owner Deletes", authz.ActionDelete, authz.Subject{ID: "user-1", Roles: []string{"user"}}, true},
+            {"Other User Downloads", authz.ActionDownload, authz.Subject{ID: "user-2", Roles: []string{"user"}}, false},
+            {"Other User Shares", authz.ActionShare, authz.Subject{ID: "user-2", Roles: []string{"user"}}, false},
+            {"Admin Deletes", authz.ActionDelete, authz.Subject{ID: "user-3", Roles: []string{"admin"}}, true},
+        }
+        for _, tt := range tests {
+            t.Run(tt.name, func(t *testing.T) {
+                decision, err := engine.Decide(ctx, authz.Input{Action: tt.action, Subject: tt.subject, Resource: owned})
+                require.NoError(t, err)
+                assert.Equal(t, tt.allow, decision.Allow)
+                assert.NotEmpty(t, decision.Reason)
+            })
+        }
+    })
+
+    t.Run("Custom Policy", func(t *testing.T) {
+        dir := t.TempDir()
+        policy := `package fileservice.authz
+
+import future.keywords.if
+
+default decision := {"allow": false, "reason": "read-only"}
+
+decision := {"allow": true, "reason": "downloads allowed"} if input.action == "download"
+`
+        require.NoError(t, os.WriteFile(filepath.Join(dir, "readonly.rego"), []byte(policy), 0o644))
+
+        engine, err := authz.NewOPA(ctx, dir)
+        require.NoError(t, err)
+
+        decision, err := engine.Decide(ctx, authz.Input{Action: authz.ActionDownload, Subject: authz.Subject{ID: "user-1"}})
+        require.NoError(t, err)
+        assert.True(t, decision.Allow)
+
+        decision, err = engine.Decide(ctx, authz.Input{Action: authz.ActionDelete, Subject: authz.Subject{ID: "user-1"}})
+        require.NoError(t, err)
+        assert.False(t, decision.Allow)
+        assert.Equal(t, "read-only", decision.Reason)
+    })
+
+    t.Run("Invalid Policy", func(t *testing.T) {
+        dir := t.TempDir()
+        require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.rego"), []byte("package fileservice.authz\n\ndecision := {"), 0o644))
+        _, err := authz.NewOPA(ctx, dir)
+        assert.Error(t, err)
+    })
+}
+
+// asUser returns req as made by an authenticated user with the user role
+func asUser(req *http.Request, userID string) *http.Request {
+    principal := &requestctx.Principal{UserID: userID, Roles: []string{"user"}}
+    return req.WithContext(requestctx.WithPrincipal(req.Context(), principal))
+}
+
+// TestAuthorizeUnfinishedFiles tests that files which cannot be downloaded
+// yet, such as drafts, are still decided on their owner
+func TestAuthorizeUnfinishedFiles(t *testing.T) {
+    engine, err := authz.NewOPA(context.Background(), "")
+    require.NoError(t, err)
+
+    repo := newMockRepository()
+    for id, status := range map[string]string{
+        "draft-1":   models.FileStatusDraft,
+        "pending-1": models.FileStatusPending,
+    } {
+        repo.files[id] = &models.File{
+            ID:          id,
+            FileName:    "notes.txt",
+            ContentType: "text/plain",
+            Status:      status,
+            StoragePath: "files/" + id,
+            OwnerID:     "user-1",
+        }
+    }
+    fileService, err := service.NewFileService(&contentStorage{}, repo, service.WorkerPoolConfig{})
+    require.NoError(t, err)
+    handler := handlers.NewFileHandler(fileService, metrics.NewPrometheus(prometheus.NewRegistry()),
+        handlers.DownloadSecurityPolicy{}, nil, nil, engine, nil, nil, nil, nil, nil, nil, nil, nil)
+
+    for _, id := range []string{"draft-1", "pending-1"} {
+        t.Run("Other User Deletes "+id, func(t *testing.T) {
+            rec := httptest.NewRecorder()
+            handler.DeleteHandler(rec, asUser(httptest.NewRequest(http.MethodDelete, "/delete?id="+id, nil), "user-2"))
+            assert.Equal(t, http.StatusForbidden, rec.Code)
+            assert.False(t, repo.files[id].IsDeleted())
+        })
+    }
+
+    t.Run("Missing File", func(t *testing.T) {
+        rec := httptest.NewRecorder()
+        handler.DeleteHandler(rec, asUser(httptest.NewRequest(http.MethodDelete, "/delete?id=missing", nil), "user-2"))
+        assert.Equal(t, http.StatusNotFound, rec.Code)
+    })
+}
+
+// TestAuthorizeFileContentRoutes tests that previews, capability URLs and
+// derived objects, which all reveal a file's content, are decided by the
+// policy as downloads
+func TestAuthorizeFileContentRoutes(t *testing.T) {
+    ctx := context.Background()
+    engine, err := authz.NewOPA(ctx, "")
+    require.NoError(t, err)
+
+    repo := newMockRepository()
+    require.NoError(t, repo.Create(ctx, &models.File{
+        ID:          "file-1",
+        FileName:    "notes.txt",
+        ContentType: "text/plain",
+        Size:        5,
+        Status:      models.FileStatusUploaded,
+        StoragePath: "files/file-1",
+        Checksum:    "v1",
+        OwnerID:     "user-1",
+        CreatedAt:   time.Now().UTC(),
+    }))
+    content := &contentStorage{content: map[string][]byte{"file-1": []byte("notes")}}
+    fileService, err := service.NewFileService(content, repo, service.WorkerPoolConfig{})
+    require.NoError(t, err)
+
+    generator := &countingGenerator{}
+    derived, err := service.NewDerivedObjectService(newMockDerivedObjectRepository(), repo, content,
+        &memoryObjectStore{objects: make(map[string][]byte)}, 1024, generator)
+    require.NoError(t, err)
+    links, err := service.NewPreviewLinks([]byte("preview-secret-preview-secret-32"), time.Minute)
+    require.NoError(t, err)
+
+    policy := handlers.DownloadSecurityPolicy{InlinePreviewEnabled: true}
+    previews := handlers.NewPreviewHandler(fileService, nil, links, policy, "'self'", engine, nil)
+    derivedObjects := handlers.NewDerivedHandler(derived, fileService, policy, engine, nil)
+
+    routes := []struct {
+        name    string
+        path    string
+        handler http.HandlerFunc
+    }{
+        {"Preview", "/files/file-1/preview", previews.FilePreviewHandler},
+        {"Preview Link", "/files/file-1/preview?format=url", previews.FilePreviewHandler},
+        {"Derived Object", "/files/file-1/derived/upper", derivedObjects.ServeHTTP},
+        {"Archive Contents", "/files/file-1/contents", derivedObjects.ServeHTTP},
+    }
+    for _, route := range routes {
+        t.Run(route.name, func(t *testing.T) {
+            rec := httptest.NewRecorder()
+            route.handler(rec, asUser(httptest.NewRequest(http.MethodGet, route.path, nil), "user-2"))
+            assert.Equal(t, http.StatusForbidden, rec.Code)
+            assert.NotContains(t, rec.Body.String(), "notes")
+            assert.NotContains(t, rec.Body.String(), "token=")
+        })
+    }
+    assert.Zero(t, generator.runs)
+
+    t.Run("Owner", func(t *testing.T) {
+        rec := httptest.NewRecorder()
+        previews.FilePreviewHandler(rec, asUser(httptest.NewRequest(http.MethodGet, "/files/file-1/preview?format=url", nil), "user-1"))
+        require.Equal(t, http.StatusOK, rec.Code)
+        assert.Contains(t, rec.Body.String(), "token=")
+
+        rec = httptest.NewRecorder()
+        derivedObjects.ServeHTTP(rec, asUser(httptest.NewRequest(http.MethodGet, "/files/file-1/derived/upper", nil), "user-1"))
+        require.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, "NOTES", rec.Body.String())
+    })
+}
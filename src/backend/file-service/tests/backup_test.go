@@ -0,0 +1,216 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "io"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// memoryTables holds metadata rows by table, as a backup dumps them
+type memoryTables struct {
+    rows map[string][]json.RawMessage
+}
+
+func (m *memoryTables) Snapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+    return fn(ctx)
+}
+
+func (m *memoryTables) DumpTable(ctx context.Context, table string, fn func(row json.RawMessage) error) error {
+    for _, row := range m.rows[table] {
+        if err := fn(row); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (m *memoryTables) RestoreRow(ctx context.Context, table string, row json.RawMessage) (bool, error) {
+    for _, existing := range m.rows[table] {
+        if bytes.Equal(existing, row) {
+            return false, nil
+        }
+    }
+    m.rows[table] = append(m.rows[table], bytes.Clone(row))
+    return true, nil
+}
+
+// bucketObjects stores objects by key, with the checksums S3 recorded for
+// them, and copies them from other buckets
+type bucketObjects struct {
+    objects   map[string][]byte
+    checksums map[string]string
+    buckets   map[string]map[string][]byte
+}
+
+func (b *bucketObjects) StatObject(ctx context.Context, key string) (*storage.UploadedObject, error) {
+    content, ok := b.objects[key]
+    if !ok {
+        return nil, storage.ErrObjectNotFound
+    }
+    return &storage.UploadedObject{Size: int64(len(content)), Checksum: b.checksums[key]}, nil
+}
+
+func (b *bucketObjects) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+    content, ok := b.objects[key]
+    if !ok {
+        return nil, storage.ErrObjectNotFound
+    }
+    return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (b *bucketObjects) CopyObjectFrom(ctx context.Context, bucket, key string) error {
+    content, ok := b.buckets[bucket][key]
+    if !ok {
+        return storage.ErrObjectNotFound
+    }
+    b.objects[key] = content
+    b.checksums[key] = sha256Hex(content)
+    return nil
+}
+
+// TestBackup tests backing up the metadata and object manifest and
+// restoring them into a new environment
+func TestBackup(t *testing.T) {
+    ctx := context.Background()
+    report := []byte("quarterly report")
+    photo := []byte("holiday photo")
+    sealed := []byte("customer key encrypted")
+    thumbnail := []byte("thumbnail of the photo")
+
+    files := newMockRepository()
+    for _, file := range []*models.File{
+        {ID: "file-1", StoragePath: "aa/report", Status: models.FileStatusUploaded, Size: int64(len(report)), Checksum: sha256Hex(report)},
+        {ID: "file-2", StoragePath: "aa/report", Status: models.FileStatusUploaded, Size: int64(len(report)), Checksum: sha256Hex(report)},
+        {ID: "file-3", StoragePath: "bb/photo", Status: models.FileStatusUploaded, Size: int64(len(photo)), Checksum: sha256Hex(photo)},
+        {ID: "file-4", StoragePath: "cc/sealed", Status: models.FileStatusUploaded, Size: int64(len(sealed)), Checksum: sha256Hex(sealed),
+            ServerSideEncryption: &models.ServerSideEncryption{Algorithm: models.SSEAlgorithmCustomer}},
+    } {
+        require.NoError(t, files.Create(ctx, file))
+    }
+    tables := &memoryTables{rows: map[string][]json.RawMessage{
+        "files":       {json.RawMessage(`{"id":"file-1"}`), json.RawMessage(`{"id":"file-2"}`)},
+        "blobs":       {json.RawMessage(`{"storage_key":"aa/report","ref_count":2}`)},
+        "file_shares": {json.RawMessage(`{"id":"share-1","file_id":"file-1"}`)},
+        "attachments": {json.RawMessage(`{"entity_type":"task","entity_id":"task-1","file_id":"file-1"}`)},
+        "derived_objects": {
+            json.RawMessage(`{"id":"derived-1","file_id":"file-3","kind":"thumbnail","storage_key":"dd/thumbnail","size":22,"status":"ready"}`),
+            json.RawMessage(`{"id":"derived-2","file_id":"file-1","kind":"thumbnail","storage_key":"dd/pending","size":0,"status":"pending"}`),
+        },
+        "file_locks":               {json.RawMessage(`{"file_id":"file-2","user_id":"user-1"}`)},
+        "file_requests":            {json.RawMessage(`{"id":"request-1","folder_id":"folder-1"}`)},
+        "tenant_settings":          {json.RawMessage(`{"tenant_id":"acme","settings":{}}`)},
+        "notification_preferences": {json.RawMessage(`{"user_id":"user-1","event":"file.shared","enabled":false}`)},
+    }}
+    primary := map[string][]byte{"aa/report": report, "bb/photo": photo, "cc/sealed": sealed, "dd/thumbnail": thumbnail}
+
+    backups, err := service.NewBackupService(tables, files, &bucketObjects{
+        objects:   primary,
+        checksums: map[string]string{"aa/report": sha256Hex(report)},
+    })
+    require.NoError(t, err)
+    dir := filepath.Join(t.TempDir(), "backup")
+    result, err := backups.Backup(ctx, service.BackupOptions{Dir: dir, Verify: true, BatchSize: 1})
+    require.NoError(t, err)
+    assert.Equal(t, 3, result.Verified)
+    assert.Equal(t, 1, result.Unchecked)
+    assert.Empty(t, result.Failed)
+    require.Len(t, result.Manifest.Tables, 9)
+    for i, table := range []string{"files", "blobs", "file_shares", "attachments", "derived_objects",
+        "file_locks", "file_requests", "tenant_settings", "notification_preferences"} {
+        assert.Equal(t, table, result.Manifest.Tables[i].Name)
+        assert.Equal(t, int64(len(tables.rows[table])), result.Manifest.Tables[i].Rows, table)
+    }
+    // Content shared by two files is listed once, and derived objects
+    // only once generated
+    assert.Equal(t, int64(4), result.Manifest.Objects.Rows)
+
+    // A directory holding a backup is not overwritten
+    _, err = backups.Backup(ctx, service.BackupOptions{Dir: dir, BatchSize: 1})
+    assert.Error(t, err)
+
+    restoreTo := func(objects map[string][]byte) (*memoryTables, *bucketObjects, service.BackupService) {
+        target := &memoryTables{rows: map[string][]json.RawMessage{}}
+        bucket := &bucketObjects{
+            objects:   objects,
+            checksums: map[string]string{},
+            buckets:   map[string]map[string][]byte{"old-primary": primary},
+        }
+        restorer, err := service.NewBackupService(target, newMockRepository(), bucket)
+        require.NoError(t, err)
+        return target, bucket, restorer
+    }
+
+    t.Run("Restore", func(t *testing.T) {
+        target, bucket, restorer := restoreTo(map[string][]byte{"bb/photo": photo})
+
+        result, err := restorer.Restore(ctx, service.RestoreOptions{Dir: dir, SourceBucket: "old-primary"})
+        require.NoError(t, err)
+        assert.Equal(t, 1, result.Verified)
+        assert.Equal(t, 2, result.Copied)
+        assert.Equal(t, 1, result.Unchecked)
+        assert.Empty(t, result.Failed)
+        assert.Equal(t, report, bucket.objects["aa/report"])
+        assert.Equal(t, thumbnail, bucket.objects["dd/thumbnail"])
+        assert.NotContains(t, bucket.objects, "dd/pending")
+        assert.Equal(t, tables.rows, target.rows)
+        assert.Equal(t, map[string]int{
+            "files":                    2,
+            "blobs":                    1,
+            "file_shares":              1,
+            "attachments":              1,
+            "derived_objects":          2,
+            "file_locks":               1,
+            "file_requests":            1,
+            "tenant_settings":          1,
+            "notification_preferences": 1,
+        }, result.Restored)
+
+        // Rerunning restores nothing twice
+        result, err = restorer.Restore(ctx, service.RestoreOptions{Dir: dir})
+        require.NoError(t, err)
+        assert.Equal(t, 3, result.Verified)
+        assert.Empty(t, result.Restored)
+        assert.Equal(t, 2, result.Existing["files"])
+    })
+
+    t.Run("Damaged Object", func(t *testing.T) {
+        damaged := append(bytes.Clone(photo[:len(photo)-1]), '!')
+        target, _, restorer := restoreTo(map[string][]byte{"aa/report": report, "bb/photo": damaged, "dd/thumbnail": thumbnail})
+
+        result, err := restorer.Restore(ctx, service.RestoreOptions{Dir: dir})
+        require.NoError(t, err)
+        require.Contains(t, result.Failed, "bb/photo")
+        assert.ErrorIs(t, result.Failed["bb/photo"], service.ErrInvalidChecksum)
+        assert.Empty(t, target.rows)
+    })
+
+    t.Run("Tampered Backup", func(t *testing.T) {
+        tampered := filepath.Join(t.TempDir(), "backup")
+        require.NoError(t, os.Mkdir(tampered, 0o700))
+        entries, err := os.ReadDir(dir)
+        require.NoError(t, err)
+        for _, entry := range entries {
+            content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+            require.NoError(t, err)
+            require.NoError(t, os.WriteFile(filepath.Join(tampered, entry.Name()), content, 0o600))
+        }
+        shares := filepath.Join(tampered, "file_shares.jsonl")
+        require.NoError(t, os.WriteFile(shares, []byte(`{"id":"share-1","file_id":"file-3"}`+"\n"), 0o600))
+        target, _, restorer := restoreTo(map[string][]byte{"aa/report": report, "bb/photo": photo, "dd/thumbnail": thumbnail})
+
+        _, err = restorer.Restore(ctx, service.RestoreOptions{Dir: tampered})
+        assert.ErrorIs(t, err, service.ErrInvalidBackup)
+        assert.Empty(t, target.rows)
+    })
+}
@@ -0,0 +1,61 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/bandwidth"
+)
+
+// TestBandwidthScheduler tests per-user shares of the aggregate upload bandwidth
+func TestBandwidthScheduler(t *testing.T) {
+    ctx := context.Background()
+
+    t.Run("Equal Shares", func(t *testing.T) {
+        scheduler, err := bandwidth.NewScheduler(100000, 10000)
+        require.NoError(t, err)
+
+        first := scheduler.Reader(ctx, "user-1", bytes.NewReader(nil))
+        second := scheduler.Reader(ctx, "user-2", bytes.NewReader(nil))
+        sameUser := scheduler.Reader(ctx, "user-2", bytes.NewReader(nil))
+        assert.Equal(t, int64(50000), scheduler.Allocation("user-1"))
+        assert.Equal(t, int64(50000), scheduler.Allocation("user-2"))
+
+        require.NoError(t, second.Close())
+        require.NoError(t, second.Close())
+        assert.Equal(t, int64(50000), scheduler.Allocation("user-1"))
+
+        require.NoError(t, sameUser.Close())
+        assert.Equal(t, int64(100000), scheduler.Allocation("user-1"))
+        assert.Equal(t, int64(0), scheduler.Allocation("user-2"))
+        require.NoError(t, first.Close())
+    })
+
+    t.Run("Throttled Reads", func(t *testing.T) {
+        scheduler, err := bandwidth.NewScheduler(100000, 10000)
+        require.NoError(t, err)
+
+        // The first burst is free; the remaining 20KB take about 200ms
+        reader := scheduler.Reader(ctx, "user-1", bytes.NewReader(make([]byte, 30000)))
+        defer reader.Close()
+
+        start := time.Now()
+        n, err := io.Copy(io.Discard, reader)
+        require.NoError(t, err)
+        assert.Equal(t, int64(30000), n)
+        assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+    })
+
+    t.Run("Invalid Configuration", func(t *testing.T) {
+        _, err := bandwidth.NewScheduler(0, 10000)
+        assert.Error(t, err)
+        _, err = bandwidth.NewScheduler(100000, 0)
+        assert.Error(t, err)
+    })
+}
@@ -0,0 +1,301 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// mockBlobRepository is an in-memory BlobRepository
+type mockBlobRepository struct {
+    mu    sync.Mutex
+    blobs map[string]*models.Blob
+}
+
+func newMockBlobRepository() *mockBlobRepository {
+    return &mockBlobRepository{blobs: make(map[string]*models.Blob)}
+}
+
+func (m *mockBlobRepository) Acquire(ctx context.Context, blob *models.Blob) (*models.Blob, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, existing := range m.blobs {
+        if existing.TenantID == blob.TenantID && existing.Checksum == blob.Checksum && existing.Size == blob.Size {
+            existing.RefCount++
+            existing.UnreferencedAt = nil
+            acquired := *existing
+            return &acquired, nil
+        }
+    }
+    stored := *blob
+    stored.RefCount = 1
+    m.blobs[blob.StorageKey] = &stored
+    return &stored, nil
+}
+
+func (m *mockBlobRepository) Lookup(ctx context.Context, tenantID, checksum string, size int64) (*models.Blob, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, blob := range m.blobs {
+        if blob.TenantID == tenantID && blob.Checksum == checksum && blob.Size == size {
+            found := *blob
+            return &found, nil
+        }
+    }
+    return nil, repository.ErrBlobNotFound
+}
+
+func (m *mockBlobRepository) Release(ctx context.Context, storageKey string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    blob, ok := m.blobs[storageKey]
+    if !ok || blob.RefCount == 0 {
+        return repository.ErrBlobNotFound
+    }
+    blob.RefCount--
+    if blob.RefCount == 0 {
+        blob.UnreferencedAt = &at
+    }
+    return nil
+}
+
+func (m *mockBlobRepository) ListUnreferenced(ctx context.Context, before time.Time, limit int) ([]*models.Blob, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var blobs []*models.Blob
+    for _, blob := range m.blobs {
+        if blob.RefCount == 0 && blob.UnreferencedAt.Before(before) && len(blobs) < limit {
+            found := *blob
+            blobs = append(blobs, &found)
+        }
+    }
+    return blobs, nil
+}
+
+func (m *mockBlobRepository) Remove(ctx context.Context, storageKey string, before time.Time) (bool, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    blob, ok := m.blobs[storageKey]
+    if !ok || blob.RefCount > 0 || !blob.UnreferencedAt.Before(before) {
+        return false, nil
+    }
+    delete(m.blobs, storageKey)
+    return true, nil
+}
+
+// fakeObjectStore records deleted object keys
+type fakeObjectStore struct {
+    deleted []string
+}
+
+func (f *fakeObjectStore) PutObject(ctx context.Context, key string, contentType string, data []byte) error {
+    return nil
+}
+
+func (f *fakeObjectStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+    return io.NopCloser(bytes.NewReader(nil)), nil
+}
+
+func (f *fakeObjectStore) DeleteObject(ctx context.Context, key string) error {
+    f.deleted = append(f.deleted, key)
+    return nil
+}
+
+// fakeSharedContent shares any stored object of the tenant scope uploads
+// are written to, the request's tenant as under tenant isolation
+type fakeSharedContent struct{}
+
+func (fakeSharedContent) ShareScope(ctx context.Context) (string, error) {
+    return requestctx.Tenant(ctx), nil
+}
+
+func (fakeSharedContent) ShareObject(ctx context.Context, file *models.File, key string) (bool, error) {
+    if storagekey.Default.Tenant(key) != requestctx.Tenant(ctx) {
+        return false, nil
+    }
+    return true, file.SetStoragePath(key)
+}
+
+// TestBlobDeduplication tests that identical uploads share a blob that is
+// only collected once unreferenced for the grace period
+func TestBlobDeduplication(t *testing.T) {
+    ctx := context.Background()
+
+    newService := func(t *testing.T, grace time.Duration) (service.FileService, *mockStorage, *mockBlobRepository, *fakeObjectStore) {
+        mockStore := newMockStorage()
+        mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+            Run(func(args mock.Arguments) {
+                file := args.Get(1).(*models.File)
+                io.Copy(io.Discard, args.Get(2).(io.Reader))
+                file.SetStoragePath(storage.StorageKey(file.ID))
+            }).
+            Return(nil)
+
+        blobs := newMockBlobRepository()
+        objects := &fakeObjectStore{}
+        fileService, err := service.NewFileService(mockStore, newMockRepository(), service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        }, service.WithBlobs(blobs, objects, grace), service.WithSharedUploads(fakeSharedContent{}))
+        require.NoError(t, err)
+        return fileService, mockStore, blobs, objects
+    }
+
+    t.Run("Shared Content", func(t *testing.T) {
+        fileService, _, blobs, objects := newService(t, time.Hour)
+        content := testPDFContent()
+
+        first, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(content), service.UploadOptions{})
+        require.NoError(t, err)
+        second, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(content), service.UploadOptions{})
+        require.NoError(t, err)
+
+        assert.Equal(t, first.StoragePath, second.StoragePath)
+        assert.Equal(t, []string{storage.StorageKey(second.ID)}, objects.deleted)
+        require.Len(t, blobs.blobs, 1)
+        assert.Equal(t, 2, blobs.blobs[first.StoragePath].RefCount)
+
+        // Deleting one file keeps the content for the other
+        require.NoError(t, fileService.Delete(ctx, first.ID, false))
+        assert.Equal(t, 1, blobs.blobs[first.StoragePath].RefCount)
+
+        collected, err := fileService.CollectBlobs(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 0, collected)
+    })
+
+    t.Run("Declared Checksum", func(t *testing.T) {
+        fileService, mockStore, blobs, objects := newService(t, time.Hour)
+        content := testPDFContent()
+        checksum := sha256Hex(content)
+
+        first, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(content), service.UploadOptions{Checksum: checksum})
+        require.NoError(t, err)
+        second, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(content), service.UploadOptions{Checksum: checksum})
+        require.NoError(t, err)
+
+        // Stored content is referenced without being written again
+        mockStore.AssertNumberOfCalls(t, "Upload", 1)
+        assert.Equal(t, first.StoragePath, second.StoragePath)
+        assert.Equal(t, checksum, second.Checksum)
+        assert.Empty(t, objects.deleted)
+        assert.Equal(t, 2, blobs.blobs[first.StoragePath].RefCount)
+
+        // Content must match the checksum it is shared by
+        forged := append([]byte(nil), content...)
+        forged[len(forged)-1] ^= 0xff
+        _, err = fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(forged), service.UploadOptions{Checksum: checksum})
+        assert.ErrorIs(t, err, service.ErrInvalidChecksum)
+        assert.Equal(t, 2, blobs.blobs[first.StoragePath].RefCount)
+
+        _, err = fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(content), service.UploadOptions{Checksum: "not-a-checksum"})
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+    })
+
+    t.Run("Tenant Scopes", func(t *testing.T) {
+        // Uploads are stored under their tenant's prefix
+        mockStore := newMockStorage()
+        mockStore.On("Upload", mock.Anything, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+            Run(func(args mock.Arguments) {
+                tenantID := requestctx.Tenant(args.Get(0).(context.Context))
+                file := args.Get(1).(*models.File)
+                io.Copy(io.Discard, args.Get(2).(io.Reader))
+                file.SetStoragePath(storagekey.Default.ForTenant(tenantID, storage.StorageKey(file.ID)))
+            }).
+            Return(nil)
+        blobs := newMockBlobRepository()
+        objects := &fakeObjectStore{}
+        fileService, err := service.NewFileService(mockStore, newMockRepository(), service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        }, service.WithBlobs(blobs, objects, time.Hour), service.WithSharedUploads(fakeSharedContent{}))
+        require.NoError(t, err)
+
+        content := testPDFContent()
+        upload := func(t *testing.T, tenantID string, opts service.UploadOptions) *models.File {
+            file, err := fileService.Upload(requestctx.WithTenant(ctx, tenantID), testFileName, testContentType,
+                testFileSize, bytes.NewReader(content), opts)
+            require.NoError(t, err)
+            assert.Equal(t, tenantID, storagekey.Default.Tenant(file.StoragePath))
+            return file
+        }
+
+        // Identical content, whether declared by checksum or found on
+        // upload, is never shared with another tenant
+        acme := upload(t, "acme", service.UploadOptions{Checksum: sha256Hex(content)})
+        globex := upload(t, "globex", service.UploadOptions{Checksum: sha256Hex(content)})
+        other := upload(t, "globex", service.UploadOptions{})
+        mockStore.AssertNumberOfCalls(t, "Upload", 3)
+
+        assert.NotEqual(t, acme.StoragePath, globex.StoragePath)
+        assert.Equal(t, globex.StoragePath, other.StoragePath)
+        assert.Equal(t, []string{storagekey.Default.ForTenant("globex", storage.StorageKey(other.ID))}, objects.deleted)
+        require.Len(t, blobs.blobs, 2)
+        assert.Equal(t, 1, blobs.blobs[acme.StoragePath].RefCount)
+        assert.Equal(t, "acme", blobs.blobs[acme.StoragePath].TenantID)
+        assert.Equal(t, 2, blobs.blobs[globex.StoragePath].RefCount)
+    })
+
+    t.Run("Grace Period", func(t *testing.T) {
+        fileService, _, blobs, objects := newService(t, time.Hour)
+
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{})
+        require.NoError(t, err)
+        require.NoError(t, fileService.Delete(ctx, file.ID, false))
+
+        collected, err := fileService.CollectBlobs(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 0, collected)
+        assert.Contains(t, blobs.blobs, file.StoragePath)
+        assert.Empty(t, objects.deleted)
+    })
+
+    t.Run("Collect Unreferenced", func(t *testing.T) {
+        fileService, _, blobs, objects := newService(t, -time.Minute)
+
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{})
+        require.NoError(t, err)
+        require.NoError(t, fileService.Delete(ctx, file.ID, false))
+
+        collected, err := fileService.CollectBlobs(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 1, collected)
+        assert.Empty(t, blobs.blobs)
+        assert.Equal(t, []string{file.StoragePath}, objects.deleted)
+    })
+
+    t.Run("Untracked Content", func(t *testing.T) {
+        fileService, mockStore, blobs, _ := newService(t, time.Hour)
+
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{})
+        require.NoError(t, err)
+        delete(blobs.blobs, file.StoragePath)
+
+        mockStore.On("Delete", ctx, mock.AnythingOfType("*models.File"), false).Return(nil).Once()
+        require.NoError(t, fileService.Delete(ctx, file.ID, false))
+        mockStore.AssertExpectations(t)
+    })
+}
@@ -0,0 +1,41 @@
+package tests
+
+import (
+    "encoding/json"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/storage"
+)
+
+// TestBaselineBucketPolicy tests the policy applied to bootstrapped buckets
+func TestBaselineBucketPolicy(t *testing.T) {
+    t.Run("Secure Transport", func(t *testing.T) {
+        policy, err := storage.BaselineBucketPolicy("files", true)
+        require.NoError(t, err)
+
+        var document struct {
+            Statement []struct {
+                Sid       string
+                Effect    string
+                Resource  []string
+                Condition map[string]map[string]string
+            }
+        }
+        require.NoError(t, json.Unmarshal([]byte(policy), &document))
+        require.Len(t, document.Statement, 1)
+        statement := document.Statement[0]
+        assert.Equal(t, "DenyInsecureTransport", statement.Sid)
+        assert.Equal(t, "Deny", statement.Effect)
+        assert.Equal(t, []string{"arn:aws:s3:::files", "arn:aws:s3:::files/*"}, statement.Resource)
+        assert.Equal(t, "false", statement.Condition["Bool"]["aws:SecureTransport"])
+    })
+
+    t.Run("Plain Transport", func(t *testing.T) {
+        policy, err := storage.BaselineBucketPolicy("files", false)
+        require.NoError(t, err)
+        assert.Empty(t, policy)
+    })
+}
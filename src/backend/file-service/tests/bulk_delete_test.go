@@ -0,0 +1,95 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// fakeBatchDelete records batch delete requests, failing the keys in fail
+type fakeBatchDelete struct {
+    mu       sync.Mutex
+    requests [][]string
+    fail     map[string]bool
+}
+
+func (f *fakeBatchDelete) DeleteObjects(ctx context.Context, keys []string) map[string]error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.requests = append(f.requests, keys)
+    failed := make(map[string]error)
+    for _, key := range keys {
+        if f.fail[key] {
+            failed[key] = errors.New("AccessDenied")
+        }
+    }
+    return failed
+}
+
+// TestBulkDelete tests hard deleting files in batches, reporting each
+// file's outcome
+func TestBulkDelete(t *testing.T) {
+    ctx := context.Background()
+    repo := newMockRepository()
+    retainUntil := time.Now().Add(time.Hour)
+    for _, id := range []string{"file-1", "file-2", "file-locked", "file-retained"} {
+        repo.files[id] = &models.File{ID: id, Status: models.FileStatusUploaded, StoragePath: "files/" + id}
+    }
+    repo.files["file-retained"].RetainUntil = &retainUntil
+
+    batches := &fakeBatchDelete{fail: map[string]bool{"files/file-locked": true}}
+    fileService, err := service.NewFileService(newMockStorage(), repo, service.WorkerPoolConfig{},
+        service.WithBatchDelete(batches))
+    require.NoError(t, err)
+
+    result, err := fileService.BulkDelete(ctx, []string{"file-1", "file-2", "file-1", "file-locked", "file-retained", "file-missing"}, false)
+    require.NoError(t, err)
+    assert.ElementsMatch(t, []string{"file-1", "file-2"}, result.Deleted)
+    require.Len(t, result.Failed, 3)
+    assert.ErrorIs(t, result.Failed["file-locked"], service.ErrOperationFailed)
+    assert.ErrorIs(t, result.Failed["file-retained"], service.ErrFileRetained)
+    assert.ErrorIs(t, result.Failed["file-missing"], service.ErrFileNotFound)
+
+    require.Len(t, batches.requests, 1)
+    assert.ElementsMatch(t, []string{"files/file-1", "files/file-2", "files/file-locked"}, batches.requests[0])
+    assert.True(t, repo.files["file-1"].IsDeleted())
+    assert.False(t, repo.files["file-locked"].IsDeleted())
+    assert.False(t, repo.files["file-retained"].IsDeleted())
+
+    _, err = fileService.BulkDelete(ctx, nil, false)
+    assert.ErrorIs(t, err, service.ErrInvalidInput)
+}
+
+// TestBulkDeleteBatches tests that content is deleted with one request per
+// storage.MaxDeleteBatch files
+func TestBulkDeleteBatches(t *testing.T) {
+    repo := newMockRepository()
+    ids := make([]string, storage.MaxDeleteBatch+500)
+    for i := range ids {
+        ids[i] = fmt.Sprintf("file-%d", i)
+        repo.files[ids[i]] = &models.File{ID: ids[i], Status: models.FileStatusUploaded, StoragePath: "files/" + ids[i]}
+    }
+
+    batches := &fakeBatchDelete{}
+    fileService, err := service.NewFileService(newMockStorage(), repo, service.WorkerPoolConfig{},
+        service.WithBatchDelete(batches))
+    require.NoError(t, err)
+
+    result, err := fileService.BulkDelete(context.Background(), ids, false)
+    require.NoError(t, err)
+    assert.Len(t, result.Deleted, len(ids))
+    assert.Empty(t, result.Failed)
+    require.Len(t, batches.requests, 2)
+    assert.Len(t, batches.requests[0], storage.MaxDeleteBatch)
+    assert.Len(t, batches.requests[1], 500)
+}
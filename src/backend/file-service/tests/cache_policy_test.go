@@ -0,0 +1,88 @@
+package tests
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strconv"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+)
+
+// TestCacheHeaders tests that responses are cached per the policy for their
+// endpoint and content type
+func TestCacheHeaders(t *testing.T) {
+    checksum := "4f1b2c"
+    handler := handlers.CacheHeaders(handlers.DefaultCachePolicy())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        switch r.URL.Path {
+        case "/download":
+            w.Header().Set("Content-Type", "image/png")
+            w.Header().Set("ETag", strconv.Quote(checksum))
+            w.Write([]byte("png"))
+        case "/files/file-1/preview":
+            w.Header().Set("Content-Type", "application/json")
+            w.Write([]byte("{}"))
+        case "/files/file-1/content":
+            w.Header().Set("Cache-Control", "private, no-store")
+            w.Write([]byte("preview"))
+        default:
+            w.Header().Set("Content-Type", "image/png")
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    cacheControl := func(target string) string {
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+        return rec.Header().Get("Cache-Control")
+    }
+
+    assert.Equal(t, "private, max-age=31536000, immutable", cacheControl("/download?id=file-1&checksum="+checksum))
+    assert.Equal(t, "private, no-cache", cacheControl("/download?id=file-1&checksum=other"))
+    assert.Equal(t, "private, no-cache", cacheControl("/download?id=file-1"))
+    assert.Equal(t, "no-store", cacheControl("/files/file-1/preview"))
+    assert.Equal(t, "private, no-store", cacheControl("/files/file-1/content"))
+    assert.Equal(t, "no-store", cacheControl("/missing"))
+}
+
+// TestLoadCachePolicy tests reading per-tenant cache rules from a file
+func TestLoadCachePolicy(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "cache.json")
+    require.NoError(t, os.WriteFile(path, []byte(`{
+        "rules": [
+            {"endpoints": ["/files/*/thumbnail"], "contentTypes": ["image/*"], "cacheControl": "public, max-age=3600", "expiresIn": 3600},
+            {"cacheControl": "no-store"}
+        ]
+    }`), 0o600))
+
+    policy, err := handlers.LoadCachePolicy(path)
+    require.NoError(t, err)
+    assert.Equal(t, "private, max-age=31536000, immutable", policy.ContentAddressed)
+
+    handler := handlers.CacheHeaders(policy)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "image/webp")
+        w.Write([]byte("webp"))
+    }))
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/file-1/thumbnail", nil))
+    assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+    assert.NotEmpty(t, rec.Header().Get("Expires"))
+
+    rec = httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?id=file-1", nil))
+    assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+    assert.Empty(t, rec.Header().Get("Expires"))
+
+    require.NoError(t, os.WriteFile(path, []byte(`{"rules": [{"endpoints": ["files"], "cacheControl": "no-store"}]}`), 0o600))
+    _, err = handlers.LoadCachePolicy(path)
+    assert.Error(t, err)
+
+    require.NoError(t, os.WriteFile(path, []byte(`{"rules": []}`), 0o600))
+    _, err = handlers.LoadCachePolicy(path)
+    assert.Error(t, err)
+}
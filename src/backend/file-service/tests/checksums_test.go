@@ -0,0 +1,267 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+)
+
+// checksumPartSize is the smallest part size the transfer manager uploads in
+const checksumPartSize = 5 << 20
+
+// checksumS3 is an S3 endpoint that records the SHA-256 checksum sent with
+// each upload, deriving the checksum of an upload in parts from its parts'
+// as S3 does
+type checksumS3 struct {
+    mu        sync.Mutex
+    objects   map[string][]byte
+    checksums map[string]string
+    // parts holds the content and checksum digest of each part received, by
+    // upload ID and part number
+    parts   map[string]map[int][2][]byte
+    uploads int
+    deleted []string
+    // modes are the x-amz-checksum-mode headers of downloads
+    modes []string
+    // tamper has the checksums recorded differ from the content received
+    tamper bool
+}
+
+func newChecksumS3() *checksumS3 {
+    return &checksumS3{
+        objects:   make(map[string][]byte),
+        checksums: make(map[string]string),
+        parts:     make(map[string]map[int][2][]byte),
+    }
+}
+
+func (s *checksumS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    // Path-style requests: /{bucket}/{key}
+    _, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+    query := r.URL.Query()
+    body, _ := io.ReadAll(r.Body)
+
+    switch {
+    case key == "":
+        // HeadBucket
+    case r.Method == http.MethodPost && query.Has("uploads"):
+        s.uploads++
+        uploadID := "upload-" + strconv.Itoa(s.uploads)
+        s.parts[uploadID] = make(map[int][2][]byte)
+        fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>files</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`,
+            key, uploadID)
+    case r.Method == http.MethodPut && query.Has("partNumber"):
+        number, _ := strconv.Atoi(query.Get("partNumber"))
+        digest := sentDigest(r, body)
+        s.parts[query.Get("uploadId")][number] = [2][]byte{body, digest}
+        w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, number))
+        w.Header().Set("X-Amz-Checksum-Sha256", base64.StdEncoding.EncodeToString(digest))
+    case r.Method == http.MethodPost && query.Has("uploadId"):
+        parts := s.parts[query.Get("uploadId")]
+        numbers := make([]int, 0, len(parts))
+        for number := range parts {
+            numbers = append(numbers, number)
+        }
+        sort.Ints(numbers)
+        var content []byte
+        combined := sha256.New()
+        for _, number := range numbers {
+            content = append(content, parts[number][0]...)
+            combined.Write(parts[number][1])
+        }
+        if s.tamper {
+            combined.Write([]byte("tampered"))
+        }
+        checksum := base64.StdEncoding.EncodeToString(combined.Sum(nil)) + "-" + strconv.Itoa(len(parts))
+        s.objects[key] = content
+        s.checksums[key] = checksum
+        fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>files</Bucket><Key>%s</Key><ETag>"object"</ETag><ChecksumSHA256>%s</ChecksumSHA256></CompleteMultipartUploadResult>`,
+            key, checksum)
+    case r.Method == http.MethodDelete && query.Has("uploadId"):
+        delete(s.parts, query.Get("uploadId"))
+        w.WriteHeader(http.StatusNoContent)
+    case r.Method == http.MethodPut:
+        digest := sentDigest(r, body)
+        if s.tamper {
+            digest = sha256Digest(append(body, "tampered"...))
+        }
+        s.objects[key] = body
+        s.checksums[key] = base64.StdEncoding.EncodeToString(digest)
+        w.Header().Set("ETag", `"object"`)
+        w.Header().Set("X-Amz-Checksum-Sha256", s.checksums[key])
+    case r.Method == http.MethodGet:
+        content, ok := s.objects[key]
+        if !ok {
+            w.WriteHeader(http.StatusNotFound)
+            fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+            return
+        }
+        mode := r.Header.Get("X-Amz-Checksum-Mode")
+        s.modes = append(s.modes, mode)
+        if mode != "" {
+            w.Header().Set("X-Amz-Checksum-Sha256", s.checksums[key])
+        }
+        w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+        w.Write(content)
+    case r.Method == http.MethodDelete:
+        delete(s.objects, key)
+        s.deleted = append(s.deleted, key)
+        w.WriteHeader(http.StatusNoContent)
+    default:
+        w.WriteHeader(http.StatusNotImplemented)
+    }
+}
+
+// sentDigest returns the SHA-256 checksum the client sent with the content,
+// or takes it from the content
+func sentDigest(r *http.Request, body []byte) []byte {
+    if sent := r.Header.Get("X-Amz-Checksum-Sha256"); sent != "" {
+        digest, err := base64.StdEncoding.DecodeString(sent)
+        if err == nil {
+            return digest
+        }
+    }
+    return sha256Digest(body)
+}
+
+func sha256Digest(content []byte) []byte {
+    sum := sha256.Sum256(content)
+    return sum[:]
+}
+
+// newChecksumStorage returns S3 storage backed by s3, with additional
+// checksums when checksums is set
+func newChecksumStorage(t *testing.T, s3 *checksumS3, checksums bool) *storage.S3Storage {
+    server := httptest.NewServer(s3)
+    t.Cleanup(server.Close)
+
+    store, err := storage.NewS3Storage(&config.Config{S3: config.S3Config{
+        Region:              "us-west-2",
+        Bucket:              "files",
+        AccessKey:           "test",
+        SecretKey:           "test",
+        Endpoint:            server.URL,
+        ForcePathStyle:      true,
+        RetryMax:            1,
+        UploadPartSize:      checksumPartSize,
+        UploadConcurrency:   2,
+        UploadPartRetryMax:  1,
+        DownloadPartSize:    checksumPartSize,
+        DownloadConcurrency: 1,
+        AdditionalChecksums: checksums,
+    }}, nil)
+    require.NoError(t, err)
+    return store
+}
+
+// checksumContent returns size bytes of content with a PDF header
+func checksumContent(size int) []byte {
+    content := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+    copy(content, "%PDF-1.7\n")
+    return content
+}
+
+// TestStoredChecksums tests verifying the checksums S3 records for uploads
+// and validating downloads against them
+func TestStoredChecksums(t *testing.T) {
+    ctx := context.Background()
+
+    upload := func(t *testing.T, store *storage.S3Storage, content []byte) (*models.File, error) {
+        file, err := models.NewFile(testFileName, int64(len(content)), testContentType)
+        require.NoError(t, err)
+        return file, store.Upload(ctx, file, bytes.NewReader(content))
+    }
+
+    for name, size := range map[string]int{
+        "Single Part": 1024,
+        "Multipart":   2*checksumPartSize + 1000,
+    } {
+        t.Run(name, func(t *testing.T) {
+            s3 := newChecksumS3()
+            store := newChecksumStorage(t, s3, true)
+            content := checksumContent(size)
+
+            file, err := upload(t, store, content)
+            require.NoError(t, err)
+            assert.Equal(t, models.FileStatusUploaded, file.Status)
+            assert.Equal(t, fmt.Sprintf("%x", sha256Digest(content)), file.Checksum)
+            assert.Equal(t, content, s3.objects[file.StoragePath])
+            assert.Empty(t, s3.deleted)
+            if size > checksumPartSize {
+                assert.True(t, strings.HasSuffix(s3.checksums[file.StoragePath], "-3"))
+            }
+        })
+
+        t.Run(name+" Mismatch", func(t *testing.T) {
+            s3 := newChecksumS3()
+            s3.tamper = true
+            store := newChecksumStorage(t, s3, true)
+
+            file, err := upload(t, store, checksumContent(size))
+            assert.True(t, errors.Is(err, storage.ErrStoredChecksumMismatch))
+            assert.NotEqual(t, models.FileStatusUploaded, file.Status)
+            require.Len(t, s3.deleted, 1)
+            assert.NotContains(t, s3.objects, s3.deleted[0])
+        })
+    }
+
+    t.Run("Download", func(t *testing.T) {
+        s3 := newChecksumS3()
+        store := newChecksumStorage(t, s3, true)
+        content := checksumContent(1024)
+        file, err := upload(t, store, content)
+        require.NoError(t, err)
+
+        body, err := store.Download(ctx, file)
+        require.NoError(t, err)
+        read, err := io.ReadAll(body)
+        body.Close()
+        require.NoError(t, err)
+        assert.Equal(t, content, read)
+        assert.Equal(t, []string{"ENABLED"}, s3.modes)
+
+        // Content that no longer matches the recorded checksum fails as it
+        // is read
+        s3.objects[file.StoragePath] = bytes.Repeat([]byte("x"), len(content))
+        body, err = store.Download(ctx, file)
+        require.NoError(t, err)
+        _, err = io.ReadAll(body)
+        body.Close()
+        assert.Error(t, err)
+    })
+
+    t.Run("Download Without Checksums", func(t *testing.T) {
+        s3 := newChecksumS3()
+        store := newChecksumStorage(t, s3, false)
+        file, err := upload(t, store, checksumContent(1024))
+        require.NoError(t, err)
+
+        body, err := store.Download(ctx, file)
+        require.NoError(t, err)
+        _, err = io.ReadAll(body)
+        body.Close()
+        require.NoError(t, err)
+        assert.Equal(t, []string{""}, s3.modes)
+    })
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/client"
+)
+
+// TestClientDownloadResume tests that an interrupted download resumes with a
+// range request and is verified against the file's checksum
+func TestClientDownloadResume(t *testing.T) {
+    content := []byte(strings.Repeat("resumable download content ", 1000))
+    digest := sha256.Sum256(content)
+    checksum := hex.EncodeToString(digest[:])
+
+    var mu sync.Mutex
+    var ranges []string
+    newServer := func(checksum string) *httptest.Server {
+        return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            mu.Lock()
+            ranges = append(ranges, r.Header.Get("Range"))
+            attempt := len(ranges)
+            mu.Unlock()
+
+            w.Header().Set("ETag", strconv.Quote(checksum))
+            w.Header().Set("X-Checksum-Sha256", checksum)
+            w.Header().Set("X-Checksum-Type", "FULL_OBJECT")
+
+            var start int64
+            if rng := r.Header.Get("Range"); rng != "" && r.Header.Get("If-Range") == strconv.Quote(checksum) {
+                fmt.Sscanf(rng, "bytes=%d-", &start)
+                w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+                w.Header().Set("Content-Length", strconv.Itoa(len(content)-int(start)))
+                w.WriteHeader(http.StatusPartialContent)
+            } else {
+                w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+                w.WriteHeader(http.StatusOK)
+            }
+
+            // Drop the connection halfway through the first response
+            if attempt == 1 {
+                w.Write(content[:len(content)/2])
+                w.(http.Flusher).Flush()
+                panic(http.ErrAbortHandler)
+            }
+            w.Write(content[start:])
+        }))
+    }
+
+    t.Run("Resumes", func(t *testing.T) {
+        ranges = nil
+        server := newServer(checksum)
+        defer server.Close()
+
+        var lastDone, lastTotal int64
+        c := client.New(server.URL, client.WithRetries(3, time.Millisecond))
+        path := filepath.Join(t.TempDir(), "download.bin")
+        err := c.Download(context.Background(), "file-1", path, func(done, total int64) {
+            lastDone, lastTotal = done, total
+        })
+        require.NoError(t, err)
+
+        downloaded, err := os.ReadFile(path)
+        require.NoError(t, err)
+        assert.Equal(t, content, downloaded)
+        assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(content)/2)}, ranges)
+        assert.Equal(t, int64(len(content)), lastDone)
+        assert.Equal(t, int64(len(content)), lastTotal)
+
+        _, err = os.Stat(path + ".part")
+        assert.True(t, os.IsNotExist(err))
+    })
+
+    t.Run("Checksum Mismatch", func(t *testing.T) {
+        ranges = nil
+        server := newServer(strings.Repeat("0", 64))
+        defer server.Close()
+
+        c := client.New(server.URL, client.WithRetries(3, time.Millisecond))
+        path := filepath.Join(t.TempDir(), "download.bin")
+        err := c.Download(context.Background(), "file-1", path, nil)
+        assert.ErrorIs(t, err, client.ErrChecksumMismatch)
+
+        _, err = os.Stat(path + ".part")
+        assert.True(t, os.IsNotExist(err))
+    })
+}
+
+// TestClientUploadResume tests that chunked uploads retry failed chunks and
+// complete with the checksums of every chunk
+func TestClientUploadResume(t *testing.T) {
+    content := []byte("0123456789")
+    const chunkSize = 4
+
+    var mu sync.Mutex
+    chunks := map[int][]byte{}
+    failures := map[int]int{2: 1}
+    var completed []string
+
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mu.Lock()
+        defer mu.Unlock()
+
+        switch {
+        case r.Method == http.MethodPost && r.URL.Path == "/api/v1/uploads":
+            w.Header().Set("Content-Type", "application/json")
+            w.WriteHeader(http.StatusCreated)
+            json.NewEncoder(w).Encode(map[string]interface{}{
+                "id": "session-1", "totalSize": len(content), "chunkSize": chunkSize,
+                "chunkCount": 3, "status": "open", "parts": []interface{}{},
+            })
+        case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/api/v1/uploads/session-1/chunks/"):
+            n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/v1/uploads/session-1/chunks/"))
+            if failures[n] > 0 {
+                failures[n]--
+                w.WriteHeader(http.StatusInternalServerError)
+                return
+            }
+            data, _ := io.ReadAll(r.Body)
+            digest := sha256.Sum256(data)
+            if r.Header.Get("X-Chunk-Checksum") != hex.EncodeToString(digest[:]) {
+                w.WriteHeader(http.StatusUnprocessableEntity)
+                return
+            }
+            chunks[n] = data
+            w.Write([]byte("{}"))
+        case r.Method == http.MethodPost && r.URL.Path == "/api/v1/uploads/session-1/complete":
+            var req struct {
+                Checksums []string `json:"checksums"`
+            }
+            json.NewDecoder(r.Body).Decode(&req)
+            completed = req.Checksums
+            w.WriteHeader(http.StatusCreated)
+            json.NewEncoder(w).Encode(map[string]interface{}{"id": "file-1", "size": len(content)})
+        default:
+            w.WriteHeader(http.StatusNotFound)
+        }
+    }))
+    defer server.Close()
+
+    path := filepath.Join(t.TempDir(), "upload.bin")
+    require.NoError(t, os.WriteFile(path, content, 0o644))
+
+    c := client.New(server.URL, client.WithRetries(3, time.Millisecond))
+    file, err := c.Upload(context.Background(), path, "application/octet-stream", nil)
+    require.NoError(t, err)
+    assert.Equal(t, "file-1", file.ID)
+
+    assert.Equal(t, content, append(append(chunks[1], chunks[2]...), chunks[3]...))
+    require.Len(t, completed, 3)
+    last := sha256.Sum256([]byte("89"))
+    assert.Equal(t, hex.EncodeToString(last[:]), completed[2])
+
+    _, err = os.Stat(path + ".upload.json")
+    assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,262 @@
+package tests
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/config"
+)
+
+// TestConfigSchema tests that the schema lists nested variables with their metadata
+func TestConfigSchema(t *testing.T) {
+    entries := map[string]config.SchemaEntry{}
+    for _, entry := range config.Schema() {
+        entries[entry.Name] = entry
+    }
+
+    bucket, ok := entries["APP_S3_BUCKET"]
+    require.True(t, ok)
+    assert.True(t, bucket.Required)
+    assert.False(t, bucket.Sensitive)
+
+    assert.True(t, entries["APP_S3_SECRET_KEY"].Sensitive)
+    assert.True(t, entries["APP_DB_DSN"].Sensitive)
+    assert.Equal(t, "24h", entries["APP_UPLOAD_SESSION_TTL"].Default)
+}
+
+// setRequiredConfigEnv sets the variables every configuration needs
+func setRequiredConfigEnv(t *testing.T) {
+    t.Setenv("APP_S3_BUCKET", "files")
+    t.Setenv("APP_S3_ACCESS_KEY", "AKIAEXAMPLE")
+    t.Setenv("APP_S3_SECRET_KEY", "super-secret")
+    t.Setenv("APP_DB_DSN", "postgres://user:pass@db/files")
+    t.Setenv("APP_JWT_SIGNING_KEY", "signing-key")
+}
+
+// TestConfigRedacted tests that printed configuration masks secrets
+func TestConfigRedacted(t *testing.T) {
+    setRequiredConfigEnv(t)
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+
+    values := cfg.Redacted()
+    assert.Equal(t, "files", values["APP_S3_BUCKET"])
+    assert.Equal(t, "****", values["APP_S3_SECRET_KEY"])
+    assert.Equal(t, "****", values["APP_DB_DSN"])
+    assert.Equal(t, "24h0m0s", values["APP_UPLOAD_SESSION_TTL"])
+}
+
+// TestConfigProfiles tests profile defaults and the prod safety checks
+func TestConfigProfiles(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_ENV", "dev")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    assert.False(t, cfg.Server.TLSEnabled)
+    assert.True(t, cfg.Profiling.Enabled)
+    assert.Equal(t, "dev", cfg.Errors.Environment)
+    assert.NoError(t, cfg.Validate())
+
+    // Explicit settings override profile defaults, but prod rejects plaintext serving
+    t.Setenv("APP_ENV", "prod")
+    t.Setenv("APP_SERVER_TLS_ENABLED", "false")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.False(t, cfg.Server.TLSEnabled)
+    assert.False(t, cfg.Profiling.Enabled)
+    assert.ErrorContains(t, cfg.Validate(), "TLS must be enabled")
+
+    t.Setenv("APP_ENV", "qa")
+    _, err = config.ParseConfig()
+    assert.Error(t, err)
+
+    // Without a profile the baseline defaults apply, serving plaintext as
+    // deployments that predate profiles do
+    require.NoError(t, os.Unsetenv("APP_ENV"))
+    require.NoError(t, os.Unsetenv("APP_SERVER_TLS_ENABLED"))
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.Equal(t, config.ProfileDefault, cfg.Env)
+    assert.False(t, cfg.Server.TLSEnabled)
+    assert.True(t, cfg.S3.UseSSL)
+    assert.NoError(t, cfg.Validate())
+}
+
+// TestConfigDiscovery tests service registration settings
+func TestConfigDiscovery(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_ENV", "dev")
+    t.Setenv("APP_DISCOVERY_BACKEND", "consul")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "discovery configuration error")
+
+    t.Setenv("APP_DISCOVERY_ENDPOINTS", "http://127.0.0.1:8500")
+    t.Setenv("APP_DISCOVERY_ACL_TOKEN", "consul-token")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.NoError(t, cfg.Validate())
+    assert.Equal(t, "file-service", cfg.Discovery.ServiceName)
+    assert.Equal(t, "****", cfg.Redacted()["APP_DISCOVERY_ACL_TOKEN"])
+}
+
+// TestConfigUploadParts tests the multipart upload settings
+func TestConfigUploadParts(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_ENV", "dev")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    assert.NoError(t, cfg.Validate())
+    assert.Equal(t, int64(16*1024*1024), cfg.S3.UploadPartSize)
+    assert.Equal(t, 5, cfg.S3.UploadConcurrency)
+
+    t.Setenv("APP_S3_UPLOAD_PART_SIZE", "1048576")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "part size")
+
+    t.Setenv("APP_S3_UPLOAD_PART_SIZE", "8388608")
+    t.Setenv("APP_S3_UPLOAD_CONCURRENCY", "0")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "upload concurrency")
+}
+
+// TestConfigServerSideEncryption tests the S3 encryption settings
+func TestConfigServerSideEncryption(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_ENV", "dev")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    assert.NoError(t, cfg.Validate())
+    assert.Equal(t, "AES256", cfg.S3.SSEAlgorithm)
+
+    t.Setenv("APP_S3_SSE_ALGORITHM", "aws:kms")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "SSE-KMS key ID")
+
+    t.Setenv("APP_S3_SSE_KMS_KEY_ID", "alias/file-service")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.NoError(t, cfg.Validate())
+    assert.True(t, cfg.S3.SSEBucketKey)
+
+    t.Setenv("APP_S3_SSE_ALGORITHM", "aws:kms:dsse")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "encryption algorithm")
+}
+
+// TestConfigBucketBootstrap tests that bucket bootstrap is refused in prod
+func TestConfigBucketBootstrap(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_ENV", "dev")
+    t.Setenv("APP_S3_BOOTSTRAP", "true")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    assert.True(t, cfg.S3.Bootstrap)
+    assert.NoError(t, cfg.Validate())
+
+    t.Setenv("APP_ENV", "prod")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "bucket bootstrap")
+}
+
+// TestConfigArchive tests the archive policy settings
+func TestConfigArchive(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_ENV", "dev")
+    t.Setenv("APP_ARCHIVE_ENABLED", "true")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    assert.NoError(t, cfg.Validate())
+    assert.Equal(t, 90*24*time.Hour, cfg.Archive.GlacierAfter)
+
+    t.Setenv("APP_ARCHIVE_DEEP_ARCHIVE_AFTER", "720h")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "longer than the GLACIER one")
+
+    t.Setenv("APP_ARCHIVE_DEEP_ARCHIVE_AFTER", "8760h")
+    t.Setenv("APP_ARCHIVE_RESTORE_TIER", "Expedited")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "Expedited")
+}
+
+// TestConfigTiering tests the tiering defaults and their checks against archiving
+func TestConfigTiering(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_ENV", "dev")
+    t.Setenv("APP_TIERING_ENABLED", "true")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    assert.NoError(t, cfg.Validate())
+    assert.Equal(t, 30*24*time.Hour, cfg.Tiering.ColdAfter)
+    assert.Equal(t, "STANDARD_IA", cfg.Tiering.ColdStorageClass)
+    assert.True(t, cfg.Tiering.PromoteOnAccess)
+
+    t.Setenv("APP_TIERING_COLD_STORAGE_CLASS", "GLACIER")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "unknown cold storage class")
+
+    t.Setenv("APP_TIERING_COLD_STORAGE_CLASS", "ONEZONE_IA")
+    t.Setenv("APP_TIERING_COLD_AFTER", "2160h")
+    t.Setenv("APP_ARCHIVE_ENABLED", "true")
+    cfg, err = config.ParseConfig()
+    require.NoError(t, err)
+    assert.ErrorContains(t, cfg.Validate(), "shorter than the archive idle period")
+}
+
+// TestConfigDiff tests the configuration diff against defaults and snapshots
+func TestConfigDiff(t *testing.T) {
+    setRequiredConfigEnv(t)
+    t.Setenv("APP_SERVER_PORT", "8081")
+
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    current := cfg.Redacted()
+
+    defaults, err := config.Defaults()
+    require.NoError(t, err)
+    changes, err := config.Diff(defaults, current)
+    require.NoError(t, err)
+
+    byName := map[string]config.Change{}
+    for _, change := range changes {
+        byName[change.Name] = change
+    }
+    assert.Equal(t, float64(8081), byName["APP_SERVER_PORT"].Value)
+    assert.Equal(t, float64(8080), byName["APP_SERVER_PORT"].Baseline)
+    assert.Equal(t, "****", byName["APP_S3_SECRET_KEY"].Value)
+    assert.Nil(t, byName["APP_S3_SECRET_KEY"].Baseline)
+    assert.NotContains(t, byName, "APP_SERVER_HOST")
+
+    path := filepath.Join(t.TempDir(), "config.json")
+    previous, err := config.LoadSnapshot(path)
+    require.NoError(t, err)
+    assert.Nil(t, previous)
+
+    require.NoError(t, config.SaveSnapshot(path, current))
+    previous, err = config.LoadSnapshot(path)
+    require.NoError(t, err)
+    changes, err = config.Diff(previous, current)
+    require.NoError(t, err)
+    assert.Empty(t, changes)
+}
@@ -0,0 +1,179 @@
+package tests
+
+import (
+    "archive/zip"
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "strings"
+    "sync"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/validator"
+)
+
+// streamingStorage reads uploaded content to the end, as object storage does
+type streamingStorage struct {
+    mu      sync.Mutex
+    objects map[string][]byte
+}
+
+func (s *streamingStorage) Upload(ctx context.Context, file *models.File, reader io.Reader) error {
+    content, err := io.ReadAll(reader)
+    if err != nil {
+        return err
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.objects[file.ID] = content
+    return nil
+}
+
+func (s *streamingStorage) Download(ctx context.Context, file *models.File) (io.ReadCloser, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return io.NopCloser(bytes.NewReader(s.objects[file.ID])), nil
+}
+
+func (s *streamingStorage) Delete(ctx context.Context, file *models.File, softDelete bool) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.objects, file.ID)
+    return nil
+}
+
+func (s *streamingStorage) count() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return len(s.objects)
+}
+
+// zipArchive creates a ZIP archive of the named entries
+func zipArchive(t *testing.T, entries map[string][]byte) []byte {
+    var buf bytes.Buffer
+    writer := zip.NewWriter(&buf)
+    for name, content := range entries {
+        entry, err := writer.Create(name)
+        require.NoError(t, err)
+        _, err = entry.Write(content)
+        require.NoError(t, err)
+    }
+    require.NoError(t, writer.Close())
+    return buf.Bytes()
+}
+
+// scanContent writes content to a scanner in chunks of size bytes
+func scanContent(policy validator.ContentPolicy, contentType string, content []byte, size int) ([]validator.Verdict, error) {
+    scanner := validator.NewContentScanner(policy, contentType)
+    for len(content) > 0 {
+        n := min(size, len(content))
+        if _, err := scanner.Write(content[:n]); err != nil {
+            break
+        }
+        content = content[n:]
+    }
+    return scanner.Finish()
+}
+
+// TestContentScanner tests the streaming content checks
+func TestContentScanner(t *testing.T) {
+    policy := validator.ContentPolicy{Checks: []string{
+        validator.CheckMagic, validator.CheckSignatures, validator.CheckArchive,
+    }}
+    archive := zipArchive(t, map[string][]byte{"docs/readme.txt": []byte("hello"), "a.txt": []byte("world")})
+
+    testCases := []struct {
+        name        string
+        contentType string
+        content     []byte
+        code        string
+    }{
+        {"pdf", "application/pdf", []byte("%PDF-1.7 report"), ""},
+        {"unchecked type", "text/plain; charset=utf-8", []byte("plain text"), ""},
+        {"archive", "application/zip", archive, ""},
+        {"declared type mismatch", "image/png", []byte("%PDF-1.7 report"), validator.CodeContentTypeMismatch},
+        {"script across chunks", "text/plain", []byte("notes <?php system($_GET['c']); ?>"), validator.CodeMalwareDetected},
+        {"executable", "application/octet-stream", []byte("MZ\x90\x00"), validator.CodeMalwareDetected},
+        {"nulls", "text/plain", make([]byte, 64), validator.CodeSuspiciousContent},
+        {"path traversal", "application/zip", zipArchive(t, map[string][]byte{"../../etc/cron.d/job": []byte("x")}), validator.CodeArchivePathTraversal},
+        {"bomb", "application/zip", zipArchive(t, map[string][]byte{"zeros.bin": make([]byte, 4<<20)}), validator.CodeArchiveBomb},
+        {"truncated archive", "application/zip", archive[:len(archive)/2], validator.CodeContentInconclusive},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            _, err := scanContent(policy, tc.contentType, tc.content, 3)
+            if tc.code == "" {
+                assert.NoError(t, err)
+                return
+            }
+            var validationErr *validator.ValidationError
+            require.ErrorAs(t, err, &validationErr)
+            assert.Equal(t, tc.code, validationErr.Code)
+        })
+    }
+
+    // Failing open reports the verdicts but accepts the content
+    policy.FailOpen = true
+    verdicts, err := scanContent(policy, "image/png", []byte("<script>alert(1)</script>"), 4096)
+    require.NoError(t, err)
+    results := make(map[string]string)
+    for _, verdict := range verdicts {
+        results[verdict.Check] = verdict.Result
+    }
+    assert.Equal(t, map[string]string{
+        validator.CheckMagic:      validator.VerdictReject,
+        validator.CheckSignatures: validator.VerdictReject,
+        validator.CheckArchive:    validator.VerdictPass,
+    }, results)
+
+    assert.Error(t, validator.ContentPolicy{Checks: []string{"antivirus"}}.Validate())
+    assert.Error(t, validator.ValidateFileContent([]byte("<?php echo 1;")))
+    assert.NoError(t, validator.ValidateFileContent([]byte("%PDF-1.7 with MZ inside")))
+}
+
+// TestUploadContentValidation tests that uploads are checked as they stream
+// to storage and rejected content is not kept
+func TestUploadContentValidation(t *testing.T) {
+    ctx := context.Background()
+    registry := prometheus.NewRegistry()
+    store := &streamingStorage{objects: make(map[string][]byte)}
+    policy := validator.ContentPolicy{Checks: []string{validator.CheckMagic, validator.CheckSignatures}}
+    fileService, err := service.NewFileService(store, newMockRepository(), service.WorkerPoolConfig{},
+        service.WithContentValidation(policy, metrics.NewPrometheus(registry)))
+    require.NoError(t, err)
+
+    content := "%PDF-1.7 " + strings.Repeat("x", 64<<10)
+    file, err := fileService.Upload(ctx, "report.pdf", "application/pdf", int64(len(content)),
+        strings.NewReader(content), service.UploadOptions{OwnerID: "user-1"})
+    require.NoError(t, err)
+    assert.Equal(t, len(content), len(store.objects[file.ID]))
+
+    // Rejected mid-stream, before the content is stored
+    content = strings.Repeat("x", 64<<10) + "<?php"
+    _, err = fileService.Upload(ctx, "notes.txt", "text/plain", int64(len(content)),
+        strings.NewReader(content), service.UploadOptions{OwnerID: "user-1"})
+    assert.ErrorIs(t, err, service.ErrInvalidInput)
+    var validationErr *validator.ValidationError
+    require.True(t, errors.As(err, &validationErr))
+    assert.Equal(t, validator.CodeMalwareDetected, validationErr.Code)
+
+    // Rejected once all content is stored, which deletes it
+    content = strings.Repeat("\x00", 1024)
+    _, err = fileService.Upload(ctx, "notes.txt", "text/plain", int64(len(content)),
+        strings.NewReader(content), service.UploadOptions{OwnerID: "user-1"})
+    require.True(t, errors.As(err, &validationErr))
+    assert.Equal(t, validator.CodeSuspiciousContent, validationErr.Code)
+    assert.Equal(t, 1, store.count())
+
+    assert.Equal(t, 2.0, gatheredValue(t, registry, "content_validation_verdicts_total", validator.CheckMagic, validator.VerdictPass))
+    assert.Equal(t, 2.0, gatheredValue(t, registry, "content_validation_verdicts_total", validator.CheckSignatures, validator.VerdictReject))
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/convert"
+)
+
+const docxContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// TestConvert tests converting documents through a Gotenberg sidecar
+func TestConvert(t *testing.T) {
+    var uploadedName string
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        require.Equal(t, "/forms/libreoffice/convert", r.URL.Path)
+        file, header, err := r.FormFile("files")
+        require.NoError(t, err)
+        defer file.Close()
+        uploadedName = header.Filename
+        content, _ := io.ReadAll(file)
+        if string(content) == "broken" {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        w.Header().Set("Content-Type", "application/pdf")
+        w.Write([]byte("%PDF-1.7 " + string(content)))
+    }))
+    defer server.Close()
+
+    gotenberg, err := convert.NewGotenberg(server.URL + "/")
+    require.NoError(t, err)
+    pipeline, err := convert.NewPipeline(time.Second, gotenberg)
+    require.NoError(t, err)
+
+    assert.True(t, pipeline.Supports(docxContentType))
+    assert.False(t, pipeline.Supports("image/svg+xml"))
+
+    contentType, ok := convert.ContentType("PDF")
+    require.True(t, ok)
+    pdf, err := pipeline.Convert(docxContentType+"; charset=binary", contentType, []byte("report"))
+    require.NoError(t, err)
+    assert.Equal(t, "%PDF-1.7 report", string(pdf))
+    assert.Equal(t, "document.docx", uploadedName)
+
+    _, err = pipeline.Convert(docxContentType, contentType, []byte("broken"))
+    assert.Error(t, err)
+
+    _, err = pipeline.Convert(docxContentType, "image/png", []byte("report"))
+    assert.True(t, errors.Is(err, convert.ErrUnsupported))
+
+    _, ok = convert.ContentType("exe")
+    assert.False(t, ok)
+
+    rsvg, err := convert.NewRsvg("rsvg-convert")
+    require.NoError(t, err)
+    assert.True(t, rsvg.Converts("image/svg+xml", "image/png"))
+    _, err = rsvg.Convert(context.Background(), docxContentType, "image/png", nil)
+    assert.True(t, errors.Is(err, convert.ErrUnsupported))
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/middleware"
+)
+
+// TestCSRFProtection tests that cookie-authenticated state changes need a
+// token issued for the session and a trusted origin, while bearer clients
+// are unaffected
+func TestCSRFProtection(t *testing.T) {
+    csrf, err := middleware.NewCSRF(middleware.CSRFOptions{
+        SessionCookie:  "session",
+        Cookie:         "csrf_token",
+        Header:         "X-CSRF-Token",
+        Secret:         []byte(strings.Repeat("s", 32)),
+        TrustedOrigins: []string{"https://app.example.com"},
+    })
+    require.NoError(t, err)
+    handler := csrf.Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNoContent)
+    }))
+
+    serve := func(method, session, token, header, origin string) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(method, "https://files.example.com/upload", nil)
+        if session != "" {
+            req.AddCookie(&http.Cookie{Name: "session", Value: session})
+        }
+        if token != "" {
+            req.AddCookie(&http.Cookie{Name: "csrf_token", Value: token})
+        }
+        if header != "" {
+            req.Header.Set("X-CSRF-Token", header)
+        }
+        if origin != "" {
+            req.Header.Set("Origin", origin)
+        }
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        return rec
+    }
+
+    // A safe request of the session is issued a token
+    rec := serve(http.MethodGet, "session-1", "", "", "")
+    assert.Equal(t, http.StatusNoContent, rec.Code)
+    cookies := rec.Result().Cookies()
+    require.Len(t, cookies, 1)
+    token := cookies[0].Value
+    assert.Equal(t, http.SameSiteStrictMode, cookies[0].SameSite)
+    assert.True(t, cookies[0].Secure)
+
+    tests := []struct {
+        name    string
+        session string
+        token   string
+        header  string
+        origin  string
+        want    int
+    }{
+        {"Bearer Client", "", "", "", "https://evil.example", http.StatusNoContent},
+        {"Valid Token", "session-1", token, token, "", http.StatusNoContent},
+        {"Trusted Origin", "session-1", token, token, "https://app.example.com", http.StatusNoContent},
+        {"Same Origin", "session-1", token, token, "https://files.example.com", http.StatusNoContent},
+        {"Missing Header", "session-1", token, "", "", http.StatusForbidden},
+        {"Header Mismatch", "session-1", token, token + "x", "", http.StatusForbidden},
+        {"Other Session", "session-2", token, token, "", http.StatusForbidden},
+        {"Untrusted Origin", "session-1", token, token, "https://evil.example", http.StatusForbidden},
+        {"Forged Token", "session-1", "nonce.signature", "nonce.signature", "", http.StatusForbidden},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := serve(http.MethodPost, tt.session, tt.token, tt.header, tt.origin)
+            assert.Equal(t, tt.want, rec.Code)
+        })
+    }
+
+    t.Run("Valid Token Not Reissued", func(t *testing.T) {
+        rec := serve(http.MethodGet, "session-1", token, "", "")
+        assert.Empty(t, rec.Result().Cookies())
+    })
+
+    t.Run("Invalid Options", func(t *testing.T) {
+        _, err := middleware.NewCSRF(middleware.CSRFOptions{SessionCookie: "session", Cookie: "csrf_token",
+            Header: "X-CSRF-Token", Secret: []byte("short")})
+        assert.Error(t, err)
+    })
+}
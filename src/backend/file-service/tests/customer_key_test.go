@@ -0,0 +1,93 @@
+package tests
+
+import (
+    "context"
+    "crypto/md5"
+    "encoding/base64"
+    "errors"
+    "fmt"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// TestCustomerKeys tests parsing SSE-C keys and mapping their storage errors
+func TestCustomerKeys(t *testing.T) {
+    raw := make([]byte, 32)
+    for i := range raw {
+        raw[i] = byte(i)
+    }
+    encoded := base64.StdEncoding.EncodeToString(raw)
+    digest := md5.Sum(raw)
+    encodedMD5 := base64.StdEncoding.EncodeToString(digest[:])
+
+    t.Run("Parse", func(t *testing.T) {
+        key, err := storage.ParseCustomerKey("AES256", encoded, encodedMD5)
+        require.NoError(t, err)
+        assert.NotNil(t, key)
+
+        _, err = storage.ParseCustomerKey("AES256", encoded, "")
+        assert.NoError(t, err)
+
+        for name, params := range map[string][3]string{
+            "Wrong Algorithm": {"aws:kms", encoded, encodedMD5},
+            "Short Key":       {"AES256", base64.StdEncoding.EncodeToString(raw[:16]), ""},
+            "Not Base64":      {"AES256", "not-a-key", ""},
+            "Wrong MD5":       {"AES256", encoded, base64.StdEncoding.EncodeToString(make([]byte, 16))},
+        } {
+            _, err := storage.ParseCustomerKey(params[0], params[1], params[2])
+            assert.True(t, errors.Is(err, storage.ErrInvalidCustomerKey), name)
+        }
+    })
+
+    t.Run("Never Formatted", func(t *testing.T) {
+        key, err := storage.ParseCustomerKey("AES256", encoded, "")
+        require.NoError(t, err)
+        for _, format := range []string{"%s", "%v", "%+v", "%#v"} {
+            assert.NotContains(t, fmt.Sprintf(format, key), encoded)
+        }
+    })
+
+    t.Run("Context", func(t *testing.T) {
+        key, err := storage.ParseCustomerKey("AES256", encoded, "")
+        require.NoError(t, err)
+        assert.Nil(t, storage.CustomerKeyFromContext(context.Background()))
+        assert.Same(t, key, storage.CustomerKeyFromContext(storage.WithCustomerKey(context.Background(), key)))
+    })
+
+    t.Run("Download Errors", func(t *testing.T) {
+        ctx := context.Background()
+        mockStore := newMockStorage()
+        files := newMockRepository()
+        fileService, err := service.NewFileService(mockStore, files, service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        })
+        require.NoError(t, err)
+
+        file, err := models.NewFile(testFileName, testFileSize, testContentType)
+        require.NoError(t, err)
+        require.NoError(t, file.SetStoragePath(storage.StorageKey(file.ID)))
+        require.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+        file.ServerSideEncryption = &models.ServerSideEncryption{Algorithm: models.SSEAlgorithmCustomer}
+        require.NoError(t, files.Create(ctx, file))
+        assert.True(t, file.UsesCustomerKey())
+
+        mockStore.On("Download", ctx, mock.AnythingOfType("*models.File")).
+            Return(nil, storage.ErrCustomerKeyRequired).Once()
+        _, _, err = fileService.Download(ctx, file.ID)
+        assert.True(t, errors.Is(err, service.ErrCustomerKeyRequired))
+
+        mockStore.On("Download", ctx, mock.AnythingOfType("*models.File")).
+            Return(nil, fmt.Errorf("wrapped: %w", storage.ErrCustomerKeyMismatch)).Once()
+        _, _, err = fileService.Download(ctx, file.ID)
+        assert.True(t, errors.Is(err, service.ErrCustomerKeyMismatch))
+        mockStore.AssertExpectations(t)
+    })
+}
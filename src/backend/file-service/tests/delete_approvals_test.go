@@ -0,0 +1,221 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// mockPendingActionRepository is an in-memory PendingActionRepository
+type mockPendingActionRepository struct {
+    mu      sync.Mutex
+    actions map[string]*models.PendingAction
+}
+
+func newMockPendingActionRepository() *mockPendingActionRepository {
+    return &mockPendingActionRepository{actions: make(map[string]*models.PendingAction)}
+}
+
+func (m *mockPendingActionRepository) Create(ctx context.Context, action *models.PendingAction) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    stored := *action
+    m.actions[action.ID] = &stored
+    return nil
+}
+
+func (m *mockPendingActionRepository) GetByID(ctx context.Context, id string) (*models.PendingAction, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    action, ok := m.actions[id]
+    if !ok {
+        return nil, repository.ErrPendingActionNotFound
+    }
+    found := *action
+    return &found, nil
+}
+
+func (m *mockPendingActionRepository) FindPending(ctx context.Context, kind, fileID string, now time.Time) (*models.PendingAction, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, action := range m.actions {
+        if action.Kind == kind && action.FileID == fileID && action.IsPending(now) {
+            found := *action
+            return &found, nil
+        }
+    }
+    return nil, repository.ErrPendingActionNotFound
+}
+
+func (m *mockPendingActionRepository) ListPending(ctx context.Context, now time.Time) ([]*models.PendingAction, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var actions []*models.PendingAction
+    for _, action := range m.actions {
+        if action.IsPending(now) {
+            found := *action
+            actions = append(actions, &found)
+        }
+    }
+    return actions, nil
+}
+
+func (m *mockPendingActionRepository) Approve(ctx context.Context, id, approverID string, now time.Time) (*models.PendingAction, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    action, ok := m.actions[id]
+    if !ok {
+        return nil, repository.ErrPendingActionNotFound
+    }
+    if !action.IsPending(now) {
+        return nil, models.ErrPendingActionClosed
+    }
+    if !action.CanApprove(approverID) {
+        return nil, models.ErrApprovalNotPermitted
+    }
+    action.ApprovedBy = append(action.ApprovedBy, approverID)
+    found := *action
+    return &found, nil
+}
+
+func (m *mockPendingActionRepository) Resolve(ctx context.Context, id, status string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    action, ok := m.actions[id]
+    if !ok || action.Status != models.PendingActionPending {
+        return models.ErrPendingActionClosed
+    }
+    action.Status = status
+    action.ResolvedAt = &at
+    return nil
+}
+
+func (m *mockPendingActionRepository) Expire(ctx context.Context, now time.Time) ([]*models.PendingAction, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var expired []*models.PendingAction
+    for _, action := range m.actions {
+        if action.Status == models.PendingActionPending && !now.Before(action.ExpiresAt) {
+            action.Status = models.PendingActionExpired
+            action.ResolvedAt = &now
+            found := *action
+            expired = append(expired, &found)
+        }
+    }
+    return expired, nil
+}
+
+// TestDeleteApprovals tests holding hard deletes in regulated tenants for two admin approvals
+func TestDeleteApprovals(t *testing.T) {
+    ctx := context.WithValue(context.Background(), tenantKey{}, "bank")
+    mockStore := newMockStorage()
+    files := newMockRepository()
+    fileService, err := service.NewFileService(mockStore, files, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    })
+    require.NoError(t, err)
+
+    actions := newMockPendingActionRepository()
+    audit := &fakeAuditRepository{}
+    approvals, err := service.NewDeleteApprovals(actions, audit, fileService, nil, func(ctx context.Context) string {
+        tenantID, _ := ctx.Value(tenantKey{}).(string)
+        return tenantID
+    }, service.DeleteApprovalOptions{RegulatedTenants: []string{"bank"}, TTL: time.Hour})
+    require.NoError(t, err)
+
+    newUploadedFile := func(t *testing.T) *models.File {
+        file, err := models.NewFile(testFileName, testFileSize, testContentType)
+        require.NoError(t, err)
+        require.NoError(t, file.SetStoragePath(storage.StorageKey(file.ID)))
+        require.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+        require.NoError(t, files.Create(ctx, file))
+        return file
+    }
+
+    t.Run("Required For Regulated Tenants", func(t *testing.T) {
+        assert.True(t, approvals.Required(ctx))
+        assert.False(t, approvals.Required(context.WithValue(context.Background(), tenantKey{}, "shop")))
+        assert.False(t, approvals.Required(context.Background()))
+    })
+
+    t.Run("Executes After Two Distinct Approvals", func(t *testing.T) {
+        file := newUploadedFile(t)
+        mockStore.On("Delete", mock.Anything, mock.AnythingOfType("*models.File"), false).Return(nil).Once()
+
+        action, err := approvals.Request(ctx, file.ID, "user-1")
+        require.NoError(t, err)
+        assert.Equal(t, "bank", action.TenantID)
+
+        again, err := approvals.Request(ctx, file.ID, "user-1")
+        require.NoError(t, err)
+        assert.Equal(t, action.ID, again.ID)
+
+        _, err = approvals.Approve(ctx, action.ID, "user-1")
+        assert.True(t, errors.Is(err, service.ErrApprovalNotPermitted))
+
+        action, err = approvals.Approve(ctx, action.ID, "admin-1")
+        require.NoError(t, err)
+        assert.Equal(t, models.PendingActionPending, action.Status)
+        _, err = fileService.Stat(ctx, file.ID)
+        require.NoError(t, err)
+
+        _, err = approvals.Approve(ctx, action.ID, "admin-1")
+        assert.True(t, errors.Is(err, service.ErrApprovalNotPermitted))
+
+        action, err = approvals.Approve(ctx, action.ID, "admin-2")
+        require.NoError(t, err)
+        assert.Equal(t, models.PendingActionExecuted, action.Status)
+        _, err = fileService.Stat(ctx, file.ID)
+        assert.True(t, errors.Is(err, service.ErrFileNotFound))
+        mockStore.AssertExpectations(t)
+
+        _, err = approvals.Approve(ctx, action.ID, "admin-3")
+        assert.True(t, errors.Is(err, service.ErrPendingActionClosed))
+    })
+
+    t.Run("Every Step Is Audited", func(t *testing.T) {
+        var types []string
+        for _, event := range audit.events {
+            types = append(types, event.Type)
+        }
+        assert.Equal(t, []string{
+            models.AuditActionRequested,
+            models.AuditActionApproved,
+            models.AuditActionApproved,
+            models.AuditActionResolved,
+        }, types)
+        assert.Equal(t, "admin-2", audit.events[3].Detail["actor"])
+        assert.Equal(t, models.PendingActionExecuted, audit.events[3].Detail["status"])
+    })
+
+    t.Run("Unapproved Requests Expire", func(t *testing.T) {
+        file := newUploadedFile(t)
+        action, err := approvals.Request(ctx, file.ID, "user-1")
+        require.NoError(t, err)
+
+        actions.mu.Lock()
+        actions.actions[action.ID].ExpiresAt = time.Now().Add(-time.Minute)
+        actions.mu.Unlock()
+
+        expired, err := approvals.Expire(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 1, expired)
+
+        _, err = approvals.Approve(ctx, action.ID, "admin-1")
+        assert.True(t, errors.Is(err, service.ErrPendingActionClosed))
+        _, err = fileService.Stat(ctx, file.ID)
+        assert.NoError(t, err)
+    })
+}
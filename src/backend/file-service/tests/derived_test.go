@@ -0,0 +1,266 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "image/png"
+    "io"
+    "strconv"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/thumbnail"
+)
+
+// mockDerivedObjectRepository is an in-memory DerivedObjectRepository
+type mockDerivedObjectRepository struct {
+    mu      sync.Mutex
+    objects map[string]*models.DerivedObject
+}
+
+func newMockDerivedObjectRepository() *mockDerivedObjectRepository {
+    return &mockDerivedObjectRepository{objects: make(map[string]*models.DerivedObject)}
+}
+
+func (m *mockDerivedObjectRepository) Get(ctx context.Context, fileID, kind, paramsHash string) (*models.DerivedObject, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, object := range m.objects {
+        if object.FileID == fileID && object.Kind == kind && object.ParamsHash == paramsHash {
+            found := *object
+            return &found, nil
+        }
+    }
+    return nil, repository.ErrDerivedObjectNotFound
+}
+
+func (m *mockDerivedObjectRepository) Save(ctx context.Context, object *models.DerivedObject) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    stored := *object
+    m.objects[object.ID] = &stored
+    return nil
+}
+
+func (m *mockDerivedObjectRepository) ListByFile(ctx context.Context, fileID string) ([]*models.DerivedObject, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var objects []*models.DerivedObject
+    for _, object := range m.objects {
+        if object.FileID == fileID {
+            found := *object
+            objects = append(objects, &found)
+        }
+    }
+    return objects, nil
+}
+
+func (m *mockDerivedObjectRepository) Delete(ctx context.Context, id string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    delete(m.objects, id)
+    return nil
+}
+
+// memoryObjectStore is an in-memory ObjectStore
+type memoryObjectStore struct {
+    mu      sync.Mutex
+    objects map[string][]byte
+}
+
+func (s *memoryObjectStore) PutObject(ctx context.Context, key string, contentType string, data []byte) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.objects[key] = data
+    return nil
+}
+
+func (s *memoryObjectStore) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    data, ok := s.objects[key]
+    if !ok {
+        return nil, errors.New("object not found")
+    }
+    return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryObjectStore) DeleteObject(ctx context.Context, key string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    delete(s.objects, key)
+    return nil
+}
+
+// contentStorage serves fixed file content by file ID
+type contentStorage struct {
+    content map[string][]byte
+}
+
+func (s *contentStorage) Upload(ctx context.Context, file *models.File, reader io.Reader) error {
+    return errors.New("not supported")
+}
+
+func (s *contentStorage) Download(ctx context.Context, file *models.File) (io.ReadCloser, error) {
+    return io.NopCloser(bytes.NewReader(s.content[file.ID])), nil
+}
+
+func (s *contentStorage) Delete(ctx context.Context, file *models.File, softDelete bool) error {
+    return nil
+}
+
+// countingGenerator derives upper-case copies of text files, counting runs
+type countingGenerator struct {
+    mu   sync.Mutex
+    runs int
+}
+
+func (g *countingGenerator) Kind() string { return "upper" }
+
+func (g *countingGenerator) Supports(file *models.File) bool { return file.ContentType == "text/plain" }
+
+func (g *countingGenerator) Params(requested map[string]string) (map[string]string, error) {
+    return nil, nil
+}
+
+func (g *countingGenerator) Generate(file *models.File, params map[string]string, content []byte) ([]byte, string, error) {
+    g.mu.Lock()
+    g.runs++
+    g.mu.Unlock()
+    if len(content) == 0 {
+        return nil, "", errors.New("empty content")
+    }
+    return bytes.ToUpper(content), "text/plain", nil
+}
+
+func (g *countingGenerator) count() int {
+    g.mu.Lock()
+    defer g.mu.Unlock()
+    return g.runs
+}
+
+// TestDerivedObjects tests lazy generation, caching and invalidation of
+// objects derived from uploaded files
+func TestDerivedObjects(t *testing.T) {
+    ctx := context.Background()
+    files := newMockRepository()
+    content := &contentStorage{content: map[string][]byte{
+        "text-1":  []byte("hello"),
+        "image-1": testPNG(t, 800, 400),
+        "empty-1": {},
+    }}
+    objects := &memoryObjectStore{objects: make(map[string][]byte)}
+    repo := newMockDerivedObjectRepository()
+    generator := &countingGenerator{}
+
+    for id, contentType := range map[string]string{"text-1": "text/plain", "image-1": "image/png", "empty-1": "text/plain"} {
+        require.NoError(t, files.Create(ctx, &models.File{
+            ID:          id,
+            FileName:    id,
+            ContentType: contentType,
+            Size:        int64(len(content.content[id])),
+            Status:      models.FileStatusUploaded,
+            Checksum:    "v1",
+            CreatedAt:   time.Now().UTC(),
+        }))
+    }
+
+    derived, err := service.NewDerivedObjectService(repo, files, content, objects, 1024*1024,
+        service.NewThumbnailGenerator(), generator)
+    require.NoError(t, err)
+
+    read := func(t *testing.T, reader io.ReadCloser) []byte {
+        defer reader.Close()
+        data, err := io.ReadAll(reader)
+        require.NoError(t, err)
+        return data
+    }
+
+    t.Run("Generates Once", func(t *testing.T) {
+        for i := 0; i < 3; i++ {
+            object, reader, err := derived.Get(ctx, "text-1", "upper", nil)
+            require.NoError(t, err)
+            assert.Equal(t, models.DerivedStatusReady, object.Status)
+            assert.Equal(t, "HELLO", string(read(t, reader)))
+        }
+        assert.Equal(t, 1, generator.count())
+    })
+
+    t.Run("Regenerates When Parent Changes", func(t *testing.T) {
+        file, err := files.GetByID(ctx, "text-1")
+        require.NoError(t, err)
+        file.Checksum = "v2"
+        require.NoError(t, files.Create(ctx, file))
+        content.content["text-1"] = []byte("changed")
+
+        _, reader, err := derived.Get(ctx, "text-1", "upper", nil)
+        require.NoError(t, err)
+        assert.Equal(t, "CHANGED", string(read(t, reader)))
+        assert.Equal(t, 2, generator.count())
+    })
+
+    t.Run("Caches Failures", func(t *testing.T) {
+        runs := generator.count()
+        for i := 0; i < 2; i++ {
+            _, _, err := derived.Get(ctx, "empty-1", "upper", nil)
+            assert.ErrorIs(t, err, service.ErrDerivedFailed)
+        }
+        assert.Equal(t, runs+1, generator.count())
+    })
+
+    t.Run("Thumbnail", func(t *testing.T) {
+        object, reader, err := derived.Get(ctx, "image-1", models.DerivedKindThumbnail, map[string]string{"size": "100"})
+        require.NoError(t, err)
+        assert.Equal(t, "image/png", object.ContentType)
+
+        img, err := png.Decode(bytes.NewReader(read(t, reader)))
+        require.NoError(t, err)
+        assert.Equal(t, 100, img.Bounds().Dx())
+        assert.Equal(t, 50, img.Bounds().Dy())
+
+        _, _, err = derived.Get(ctx, "image-1", models.DerivedKindThumbnail, map[string]string{"size": "0"})
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+
+        _, _, err = derived.Get(ctx, "text-1", models.DerivedKindThumbnail, nil)
+        assert.ErrorIs(t, err, service.ErrDerivedNotSupported)
+    })
+
+    t.Run("Invalidate", func(t *testing.T) {
+        require.NoError(t, derived.Invalidate(ctx, "image-1"))
+
+        remaining, err := repo.ListByFile(ctx, "image-1")
+        require.NoError(t, err)
+        assert.Empty(t, remaining)
+        for key := range objects.objects {
+            assert.NotContains(t, key, "image-1")
+        }
+    })
+}
+
+// TestThumbnailSrcset tests describing a file's thumbnails as a srcset
+func TestThumbnailSrcset(t *testing.T) {
+    srcset, err := thumbnail.Srcset(func(size int) (string, error) {
+        return "/thumbnails/" + strconv.Itoa(size), nil
+    })
+    require.NoError(t, err)
+    assert.Equal(t, "/thumbnails/128 1x, /thumbnails/256 2x, /thumbnails/512 4x", srcset)
+
+    _, err = thumbnail.Srcset(func(size int) (string, error) {
+        return "", errors.New("no links")
+    })
+    assert.Error(t, err)
+
+    file := &models.File{ID: "image 1", ContentType: "image/jpeg", Status: models.FileStatusUploaded}
+    assert.True(t, file.HasThumbnails())
+    file.Status = models.FileStatusDraft
+    assert.False(t, file.HasThumbnails())
+    assert.False(t, (&models.File{ID: "text-1", ContentType: "text/plain", Status: models.FileStatusUploaded}).HasThumbnails())
+}
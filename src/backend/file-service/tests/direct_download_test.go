@@ -0,0 +1,96 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// fakeDirectDownloadStorage presigns fake URLs
+type fakeDirectDownloadStorage struct{}
+
+func (fakeDirectDownloadStorage) PresignDownload(ctx context.Context, file *models.File, ttl time.Duration,
+    contentType, disposition string) (*storage.PresignedDownload, error) {
+    return &storage.PresignedDownload{
+        URL:       "https://bucket.example.com/" + file.StoragePath,
+        ExpiresAt: time.Now().Add(ttl),
+    }, nil
+}
+
+// fakeAuditRepository records appended audit events, failing with err when set
+type fakeAuditRepository struct {
+    events []*models.AuditEvent
+    err    error
+}
+
+func (r *fakeAuditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+    if r.err != nil {
+        return r.err
+    }
+    r.events = append(r.events, event)
+    return nil
+}
+
+func (r *fakeAuditRepository) ListAfter(ctx context.Context, afterID int64, recordedBefore time.Time, limit int) ([]*models.AuditEvent, error) {
+    return r.events, nil
+}
+
+func (r *fakeAuditRepository) GetCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+    return &models.AuditCheckpoint{}, nil
+}
+
+func (r *fakeAuditRepository) SaveCheckpoint(ctx context.Context, checkpoint *models.AuditCheckpoint) error {
+    return nil
+}
+
+// TestDirectDownload tests presigning downloads and auditing who requested them
+func TestDirectDownload(t *testing.T) {
+    ctx := context.Background()
+    audit := &fakeAuditRepository{}
+    downloads, err := service.NewDirectDownloadService(fakeDirectDownloadStorage{}, audit, time.Minute)
+    require.NoError(t, err)
+
+    file, err := models.NewFile(testFileName, testFileSize, testContentType)
+    require.NoError(t, err)
+    require.NoError(t, file.SetStoragePath(storage.StorageKey(file.ID)))
+    require.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+
+    request := service.DownloadLinkRequest{RequestedBy: "alice", IP: "192.0.2.1"}
+
+    t.Run("Presign", func(t *testing.T) {
+        download, err := downloads.Presign(ctx, file, request)
+        require.NoError(t, err)
+        assert.Contains(t, download.URL, file.StoragePath)
+
+        require.Len(t, audit.events, 1)
+        event := audit.events[0]
+        assert.Equal(t, models.AuditDownloadLink, event.Type)
+        assert.Equal(t, file.ID, event.FileID)
+        assert.Equal(t, "alice", event.Detail["requestedBy"])
+        assert.Equal(t, "192.0.2.1", event.Detail["ip"])
+    })
+
+    t.Run("Unaudited Links Are Not Issued", func(t *testing.T) {
+        audit.err = errors.New("database unavailable")
+        defer func() { audit.err = nil }()
+
+        _, err := downloads.Presign(ctx, file, request)
+        assert.True(t, errors.Is(err, service.ErrOperationFailed))
+    })
+
+    t.Run("Pending Files", func(t *testing.T) {
+        pending, err := models.NewFile(testFileName, testFileSize, testContentType)
+        require.NoError(t, err)
+
+        _, err = downloads.Presign(ctx, pending, request)
+        assert.True(t, errors.Is(err, service.ErrFileNotFound))
+    })
+}
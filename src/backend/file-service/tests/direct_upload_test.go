@@ -0,0 +1,136 @@
+package tests
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// fakeDirectUploadStorage presigns fake URLs and reports the objects
+// uploaded to it by key
+type fakeDirectUploadStorage struct {
+    objects map[string]*storage.UploadedObject
+}
+
+func (s *fakeDirectUploadStorage) PresignUpload(ctx context.Context, file *models.File, ttl time.Duration) (*storage.PresignedUpload, error) {
+    return &storage.PresignedUpload{
+        URL:       "https://bucket.example.com/" + file.StoragePath,
+        Method:    "PUT",
+        ExpiresAt: time.Now().Add(ttl),
+    }, nil
+}
+
+func (s *fakeDirectUploadStorage) StatUpload(ctx context.Context, file *models.File) (*storage.UploadedObject, error) {
+    object, ok := s.objects[file.StoragePath]
+    if !ok {
+        return nil, storage.ErrObjectNotFound
+    }
+    return object, nil
+}
+
+// TestDirectUpload tests presigning uploads and completing them once the content is stored
+func TestDirectUpload(t *testing.T) {
+    ctx := context.Background()
+    repo := newMockRepository()
+    objects := &fakeDirectUploadStorage{objects: make(map[string]*storage.UploadedObject)}
+    uploads, err := service.NewDirectUploadService(objects, repo, service.DirectUploadConfig{URLTTL: time.Minute})
+    require.NoError(t, err)
+
+    digest := sha256.Sum256([]byte("content"))
+    checksum := hex.EncodeToString(digest[:])
+
+    file, upload, err := uploads.Presign(ctx, testFileName, testContentType, testFileSize, checksum, "alice", nil)
+    require.NoError(t, err)
+    assert.Equal(t, models.FileStatusPending, file.Status)
+    assert.Equal(t, checksum, file.Checksum)
+    assert.Equal(t, "PUT", upload.Method)
+    assert.Contains(t, upload.URL, file.StoragePath)
+
+    t.Run("Not Uploaded Yet", func(t *testing.T) {
+        _, err := uploads.Complete(ctx, file.ID, "alice")
+        assert.True(t, errors.Is(err, service.ErrUploadNotReceived))
+    })
+
+    t.Run("Other Owner", func(t *testing.T) {
+        _, err := uploads.Complete(ctx, file.ID, "mallory")
+        assert.True(t, errors.Is(err, service.ErrFileNotFound))
+    })
+
+    t.Run("Complete", func(t *testing.T) {
+        objects.objects[file.StoragePath] = &storage.UploadedObject{
+            Size: testFileSize, ContentType: testContentType, Checksum: checksum,
+        }
+
+        completed, err := uploads.Complete(ctx, file.ID, "alice")
+        require.NoError(t, err)
+        assert.True(t, completed.IsUploaded())
+
+        // Retried callbacks return the uploaded file
+        again, err := uploads.Complete(ctx, file.ID, "alice")
+        require.NoError(t, err)
+        assert.Equal(t, completed.Version, again.Version)
+    })
+
+    t.Run("Single Use Confirmation", func(t *testing.T) {
+        singleUse, err := service.NewDirectUploadService(objects, repo, service.DirectUploadConfig{
+            URLTTL:                time.Minute,
+            SingleUseConfirmation: true,
+        })
+        require.NoError(t, err)
+
+        other, _, err := singleUse.Presign(ctx, testFileName, testContentType, testFileSize, checksum, "alice", nil)
+        require.NoError(t, err)
+        objects.objects[other.StoragePath] = &storage.UploadedObject{
+            Size: testFileSize, ContentType: testContentType, Checksum: checksum,
+        }
+
+        _, err = singleUse.Complete(ctx, other.ID, "alice")
+        require.NoError(t, err)
+        _, err = singleUse.Complete(ctx, other.ID, "alice")
+        assert.True(t, errors.Is(err, service.ErrTokenReplayed))
+    })
+
+    t.Run("Mismatched Content", func(t *testing.T) {
+        other, _, err := uploads.Presign(ctx, testFileName, testContentType, testFileSize, checksum, "alice", nil)
+        require.NoError(t, err)
+        objects.objects[other.StoragePath] = &storage.UploadedObject{Size: testFileSize - 1}
+
+        _, err = uploads.Complete(ctx, other.ID, "alice")
+        assert.True(t, errors.Is(err, models.ErrChecksumMismatch))
+    })
+
+    t.Run("Mismatched Content Type", func(t *testing.T) {
+        other, _, err := uploads.Presign(ctx, testFileName, testContentType, testFileSize, checksum, "alice", nil)
+        require.NoError(t, err)
+        objects.objects[other.StoragePath] = &storage.UploadedObject{
+            Size: testFileSize, ContentType: "text/html", Checksum: checksum,
+        }
+
+        _, err = uploads.Complete(ctx, other.ID, "alice")
+        assert.ErrorIs(t, err, service.ErrUploadMismatch)
+    })
+
+    t.Run("Missing Checksum", func(t *testing.T) {
+        other, _, err := uploads.Presign(ctx, testFileName, testContentType, testFileSize, checksum, "alice", nil)
+        require.NoError(t, err)
+        objects.objects[other.StoragePath] = &storage.UploadedObject{Size: testFileSize, ContentType: testContentType}
+
+        _, err = uploads.Complete(ctx, other.ID, "alice")
+        assert.ErrorIs(t, err, models.ErrChecksumMismatch)
+    })
+
+    t.Run("Invalid Checksum", func(t *testing.T) {
+        _, _, err := uploads.Presign(ctx, testFileName, testContentType, testFileSize, "not-a-digest", "alice", nil)
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+    })
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/discovery"
+)
+
+// TestDiscoveryNew tests registrar configuration checks
+func TestDiscoveryNew(t *testing.T) {
+    config := discovery.Config{
+        Backend:       discovery.BackendConsul,
+        Endpoints:     []string{"http://127.0.0.1:8500"},
+        ServiceName:   "file-service",
+        ServiceID:     "files-1-8080",
+        Address:       "files-1",
+        Port:          8080,
+        HealthURL:     "http://files-1:9090/health",
+        CheckInterval: 10 * time.Second,
+        CheckTimeout:  5 * time.Second,
+    }
+
+    registrar, err := discovery.New(config)
+    require.NoError(t, err)
+    assert.NotNil(t, registrar)
+
+    unsupported := config
+    unsupported.Backend = "zookeeper"
+    _, err = discovery.New(unsupported)
+    assert.ErrorContains(t, err, "unsupported discovery backend")
+
+    missingID := config
+    missingID.ServiceID = ""
+    _, err = discovery.New(missingID)
+    assert.Error(t, err)
+
+    missingHealth := config
+    missingHealth.HealthURL = ""
+    _, err = discovery.New(missingHealth)
+    assert.Error(t, err)
+}
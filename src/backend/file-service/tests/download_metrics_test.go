@@ -0,0 +1,161 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/metrics"
+)
+
+// disconnectingWriter accepts limit bytes of the response, as a client that
+// goes away mid-download
+type disconnectingWriter struct {
+    *httptest.ResponseRecorder
+    limit int
+}
+
+func (d *disconnectingWriter) Write(p []byte) (int, error) {
+    if len(p) > d.limit {
+        n, _ := d.ResponseRecorder.Write(p[:d.limit])
+        d.limit = 0
+        return n, errors.New("broken pipe")
+    }
+    d.limit -= len(p)
+    return d.ResponseRecorder.Write(p)
+}
+
+// TestDownloadResponseMetrics tests counting completed and aborted
+// downloads with the bytes they sent
+func TestDownloadResponseMetrics(t *testing.T) {
+    content := bytes.Repeat([]byte("x"), 64<<10)
+    repo := newMockRepository()
+    repo.files["file-1"] = &models.File{
+        ID:          "file-1",
+        FileName:    "notes.txt",
+        ContentType: "text/plain",
+        Size:        int64(len(content)),
+        Status:      models.FileStatusUploaded,
+        StoragePath: "files/file-1",
+    }
+    fileService, err := service.NewFileService(&contentStorage{content: map[string][]byte{"file-1": content}},
+        repo, service.WorkerPoolConfig{})
+    require.NoError(t, err)
+
+    registry := prometheus.NewRegistry()
+    handler := handlers.NewFileHandler(fileService, metrics.NewPrometheus(registry), handlers.DownloadSecurityPolicy{},
+        nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+    rec := httptest.NewRecorder()
+    handler.DownloadHandler(rec, httptest.NewRequest(http.MethodGet, "/download?id=file-1", nil))
+    require.Equal(t, http.StatusOK, rec.Code)
+    assert.Equal(t, content, rec.Body.Bytes())
+
+    aborted := &disconnectingWriter{ResponseRecorder: httptest.NewRecorder(), limit: 1000}
+    handler.DownloadHandler(aborted, httptest.NewRequest(http.MethodGet, "/download?id=file-1", nil))
+    assert.Equal(t, 1000, aborted.Body.Len())
+
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "download_responses_total", "full", "completed"))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "download_responses_total", "full", "aborted"))
+    assert.Equal(t, float64(len(content)+1000), gatheredValue(t, registry, "download_bytes_sent_total", "full"))
+    assert.Equal(t, float64(2*len(content)), gatheredValue(t, registry, "download_bytes_expected_total", "full"))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "file_downloads_total", "full"))
+}
+
+// rangedContentStorage serves fixed file content, returning the requested
+// range alone as S3 does
+type rangedContentStorage struct {
+    contentStorage
+    ranges []storage.ContentRange
+}
+
+func (s *rangedContentStorage) Download(ctx context.Context, file *models.File) (io.ReadCloser, error) {
+    content := s.content[file.ID]
+    rng := storage.ContentRangeFrom(ctx)
+    if rng == nil {
+        return io.NopCloser(bytes.NewReader(content)), nil
+    }
+    rng.Served = true
+    s.ranges = append(s.ranges, *rng)
+    return io.NopCloser(bytes.NewReader(content[rng.Offset : rng.Offset+rng.Length])), nil
+}
+
+// TestDownloadRange tests reading requested ranges from storage rather than
+// discarding the content up to them
+func TestDownloadRange(t *testing.T) {
+    content := make([]byte, 1<<20)
+    for i := range content {
+        content[i] = byte(i % 251)
+    }
+    repo := newMockRepository()
+    repo.files["file-1"] = &models.File{
+        ID:          "file-1",
+        FileName:    "movie.mp4",
+        ContentType: "video/mp4",
+        Size:        int64(len(content)),
+        Status:      models.FileStatusUploaded,
+        StoragePath: "files/file-1",
+        Checksum:    "v1",
+    }
+
+    download := func(t *testing.T, store storage.Storage, header http.Header) *httptest.ResponseRecorder {
+        fileService, err := service.NewFileService(store, repo, service.WorkerPoolConfig{})
+        require.NoError(t, err)
+        handler := handlers.NewFileHandler(fileService, metrics.NewPrometheus(prometheus.NewRegistry()),
+            handlers.DownloadSecurityPolicy{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+        req := httptest.NewRequest(http.MethodGet, "/download?id=file-1", nil)
+        req.Header = header
+        rec := httptest.NewRecorder()
+        handler.DownloadHandler(rec, req)
+        return rec
+    }
+
+    t.Run("Served By Storage", func(t *testing.T) {
+        store := &rangedContentStorage{contentStorage: contentStorage{content: map[string][]byte{"file-1": content}}}
+        rec := download(t, store, http.Header{"Range": {"bytes=500000-500099"}})
+        require.Equal(t, http.StatusPartialContent, rec.Code)
+        assert.Equal(t, fmt.Sprintf("bytes 500000-500099/%d", len(content)), rec.Header().Get("Content-Range"))
+        assert.Equal(t, content[500000:500100], rec.Body.Bytes())
+        assert.Equal(t, []storage.ContentRange{{Offset: 500000, Length: 100, Served: true}}, store.ranges)
+
+        // Suffix ranges resolve against the file's size
+        rec = download(t, store, http.Header{"Range": {"bytes=-10"}})
+        require.Equal(t, http.StatusPartialContent, rec.Code)
+        assert.Equal(t, content[len(content)-10:], rec.Body.Bytes())
+    })
+
+    t.Run("Whole Content", func(t *testing.T) {
+        store := &rangedContentStorage{contentStorage: contentStorage{content: map[string][]byte{"file-1": content}}}
+
+        // A stale If-Range asks for the whole content
+        rec := download(t, store, http.Header{"Range": {"bytes=100-"}, "If-Range": {`"v0"`}})
+        require.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, content, rec.Body.Bytes())
+        assert.Empty(t, store.ranges)
+
+        rec = download(t, store, http.Header{"Range": {fmt.Sprintf("bytes=%d-", len(content))}})
+        assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+        assert.Empty(t, store.ranges)
+    })
+
+    t.Run("Not Served By Storage", func(t *testing.T) {
+        store := &contentStorage{content: map[string][]byte{"file-1": content}}
+        rec := download(t, store, http.Header{"Range": {"bytes=500000-500099"}})
+        require.Equal(t, http.StatusPartialContent, rec.Code)
+        assert.Equal(t, content[500000:500100], rec.Body.Bytes())
+    })
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/metrics"
+)
+
+// TestDownloadSecurityHeaders tests the headers that keep browsers from
+// rendering downloaded content as part of the service's origin
+func TestDownloadSecurityHeaders(t *testing.T) {
+    const previewCSP = "default-src 'none'; img-src 'self'; sandbox"
+    content := map[string][]byte{
+        "page":  []byte("<script>alert(1)</script>"),
+        "notes": []byte("plain notes"),
+    }
+    repo := newMockRepository()
+    repo.files["page"] = &models.File{
+        ID:          "page",
+        FileName:    "page.html",
+        ContentType: "text/html",
+        Size:        int64(len(content["page"])),
+        Status:      models.FileStatusUploaded,
+        StoragePath: "files/page",
+    }
+    repo.files["notes"] = &models.File{
+        ID:          "notes",
+        FileName:    "résumé.txt",
+        ContentType: "text/plain",
+        Size:        int64(len(content["notes"])),
+        Status:      models.FileStatusUploaded,
+        StoragePath: "files/notes",
+    }
+    fileService, err := service.NewFileService(&contentStorage{content: content}, repo, service.WorkerPoolConfig{})
+    require.NoError(t, err)
+
+    download := func(t *testing.T, policy handlers.DownloadSecurityPolicy, target string, header http.Header) *httptest.ResponseRecorder {
+        handler := handlers.NewFileHandler(fileService, metrics.NewPrometheus(prometheus.NewRegistry()), policy,
+            nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+        req := httptest.NewRequest(http.MethodGet, target, nil)
+        for name, values := range header {
+            req.Header[name] = values
+        }
+        rec := httptest.NewRecorder()
+        handler.DownloadHandler(rec, req)
+        return rec
+    }
+
+    policy := handlers.DownloadSecurityPolicy{
+        InlinePreviewEnabled: true,
+        ForceOctetStream:     true,
+        RiskyContentTypes:    []string{"text/html", "image/svg+xml"},
+        PreviewCSP:           previewCSP,
+    }
+
+    tests := []struct {
+        name        string
+        policy      handlers.DownloadSecurityPolicy
+        target      string
+        header      http.Header
+        status      int
+        contentType string
+        disposition string
+        csp         string
+    }{
+        {
+            name:        "Attachment",
+            policy:      policy,
+            target:      "/download?id=notes",
+            status:      http.StatusOK,
+            contentType: "text/plain",
+            disposition: "attachment; filename*=utf-8''r%C3%A9sum%C3%A9.txt",
+            csp:         "default-src 'none'; sandbox",
+        },
+        {
+            name:        "Inline Preview",
+            policy:      policy,
+            target:      "/download?id=notes&inline=true",
+            status:      http.StatusOK,
+            contentType: "text/plain",
+            disposition: "inline; filename*=utf-8''r%C3%A9sum%C3%A9.txt",
+            csp:         previewCSP,
+        },
+        {
+            name:        "Inline Preview Disabled",
+            policy:      handlers.DownloadSecurityPolicy{PreviewCSP: previewCSP},
+            target:      "/download?id=notes&inline=true",
+            status:      http.StatusOK,
+            contentType: "text/plain",
+            disposition: "attachment; filename*=utf-8''r%C3%A9sum%C3%A9.txt",
+            csp:         "default-src 'none'; sandbox",
+        },
+        {
+            name:        "Risky Content",
+            policy:      policy,
+            target:      "/download?id=page&inline=true",
+            status:      http.StatusOK,
+            contentType: "application/octet-stream",
+            disposition: "attachment; filename=page.html",
+            csp:         "default-src 'none'; sandbox",
+        },
+        {
+            name:        "Risky Content Type Kept",
+            policy:      handlers.DownloadSecurityPolicy{RiskyContentTypes: []string{"text/html"}},
+            target:      "/download?id=page",
+            status:      http.StatusOK,
+            contentType: "text/html",
+            disposition: "attachment; filename=page.html",
+            csp:         "default-src 'none'; sandbox",
+        },
+        {
+            name:        "Range",
+            policy:      policy,
+            target:      "/download?id=page",
+            header:      http.Header{"Range": {"bytes=0-7"}},
+            status:      http.StatusPartialContent,
+            contentType: "application/octet-stream",
+            disposition: "attachment; filename=page.html",
+            csp:         "default-src 'none'; sandbox",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := download(t, tt.policy, tt.target, tt.header)
+            require.Equal(t, tt.status, rec.Code)
+            assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+            assert.Equal(t, tt.disposition, rec.Header().Get("Content-Disposition"))
+            assert.Equal(t, tt.csp, rec.Header().Get("Content-Security-Policy"))
+            assert.Equal(t, "nosniff", rec.Header().Get("X-Content-Type-Options"))
+            assert.Equal(t, "same-origin", rec.Header().Get("Cross-Origin-Resource-Policy"))
+        })
+    }
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/errtrack"
+)
+
+// recordingReporter keeps reported errors and panics in memory
+type recordingReporter struct {
+    mu     sync.Mutex
+    errors []error
+    panics []interface{}
+    tags   []map[string]string
+}
+
+func (r *recordingReporter) CaptureError(ctx context.Context, err error, tags map[string]string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.errors = append(r.errors, err)
+    r.tags = append(r.tags, tags)
+}
+
+func (r *recordingReporter) CapturePanic(ctx context.Context, recovered interface{}, tags map[string]string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.panics = append(r.panics, recovered)
+    r.tags = append(r.tags, tags)
+}
+
+func (r *recordingReporter) Flush(timeout time.Duration) bool {
+    return true
+}
+
+// TestErrorTracking tests error capture and handler panic recovery
+func TestErrorTracking(t *testing.T) {
+    reporter := &recordingReporter{}
+    errtrack.SetReporter(reporter)
+    defer errtrack.SetReporter(nil)
+
+    errtrack.CaptureError(context.Background(), errors.New("boom"), map[string]string{"job": "test"})
+    errtrack.CaptureError(context.Background(), nil, nil)
+    require.Len(t, reporter.errors, 1)
+    assert.Equal(t, "test", reporter.tags[0]["job"])
+
+    handler := errtrack.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        panic("handler bug")
+    }))
+    rec := httptest.NewRecorder()
+    handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/1", nil))
+
+    assert.Equal(t, http.StatusInternalServerError, rec.Code)
+    require.Len(t, reporter.panics, 1)
+    assert.Equal(t, "handler bug", reporter.panics[0])
+    assert.Equal(t, "/files/1", reporter.tags[1]["path"])
+}
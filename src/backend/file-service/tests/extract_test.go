@@ -0,0 +1,202 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+)
+
+// mockExtractionJobRepository keeps extraction jobs in memory
+type mockExtractionJobRepository struct {
+    mu   sync.Mutex
+    jobs map[string]*models.ExtractionJob
+}
+
+func newMockExtractionJobRepository() *mockExtractionJobRepository {
+    return &mockExtractionJobRepository{jobs: make(map[string]*models.ExtractionJob)}
+}
+
+func (m *mockExtractionJobRepository) Create(ctx context.Context, job *models.ExtractionJob) error {
+    return m.Update(ctx, job)
+}
+
+func (m *mockExtractionJobRepository) Get(ctx context.Context, id string) (*models.ExtractionJob, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    job, ok := m.jobs[id]
+    if !ok {
+        return nil, repository.ErrExtractionJobNotFound
+    }
+    found := *job
+    found.FileIDs = append([]string(nil), job.FileIDs...)
+    found.Errors = append([]models.ExtractionEntryError(nil), job.Errors...)
+    return &found, nil
+}
+
+func (m *mockExtractionJobRepository) Update(ctx context.Context, job *models.ExtractionJob) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    stored := *job
+    stored.FileIDs = append([]string(nil), job.FileIDs...)
+    stored.Errors = append([]models.ExtractionEntryError(nil), job.Errors...)
+    m.jobs[job.ID] = &stored
+    return nil
+}
+
+// waitForExtraction polls a job until it completes or fails
+func waitForExtraction(t *testing.T, extractions service.ExtractionService, fileID, jobID string) *models.ExtractionJob {
+    var job *models.ExtractionJob
+    require.Eventually(t, func() bool {
+        var err error
+        job, err = extractions.Get(context.Background(), fileID, jobID)
+        require.NoError(t, err)
+        return job.IsDone()
+    }, 5*time.Second, 10*time.Millisecond)
+    return job
+}
+
+// TestArchiveExtraction tests expanding archives into files in the background
+func TestArchiveExtraction(t *testing.T) {
+    ctx := context.Background()
+    files := newMockRepository()
+    objects := &streamingStorage{objects: make(map[string][]byte)}
+    fileService, err := service.NewFileService(objects, files, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    })
+    require.NoError(t, err)
+
+    archives := &rangeContentStorage{contentStorage: contentStorage{content: map[string][]byte{
+        "zip-1": zipArchive(t, map[string][]byte{
+            "docs/readme.txt": []byte("read me"),
+            "notes.txt":       []byte("# notes"),
+            "../escape.txt":   []byte("outside"),
+        }),
+        "zip-2":  zipArchive(t, map[string][]byte{"a": {1}, "b": {2}, "c": {3}}),
+        "tgz-1":  testTarGzip(t, map[string]int{"a.txt": 10, "b.txt": 20}),
+        "text-1": []byte("not an archive"),
+    }}}
+    for id, meta := range map[string][2]string{
+        "zip-1":  {"bundle.zip", "application/zip"},
+        "zip-2":  {"many.zip", "application/zip"},
+        "tgz-1":  {"bundle.tar.gz", "application/gzip"},
+        "text-1": {"notes.txt", "text/plain"},
+    } {
+        require.NoError(t, files.Create(ctx, &models.File{
+            ID:          id,
+            FileName:    meta[0],
+            ContentType: meta[1],
+            Size:        int64(len(archives.content[id])),
+            Status:      models.FileStatusUploaded,
+            CreatedAt:   time.Now().UTC(),
+        }))
+    }
+
+    jobs := newMockExtractionJobRepository()
+    newService := func(t *testing.T, opts service.ExtractionOptions) service.ExtractionService {
+        extractions, err := service.NewExtractionService(jobs, fileService, archives, opts)
+        require.NoError(t, err)
+        return extractions
+    }
+    limits := service.ExtractionOptions{MaxEntries: 10, MaxSize: 1 << 20, MaxRatio: 100}
+
+    t.Run("Zip", func(t *testing.T) {
+        extractions := newService(t, limits)
+        started, err := extractions.Start(ctx, "zip-1", service.UploadOptions{Folder: "imports"})
+        require.NoError(t, err)
+        assert.Equal(t, "imports", started.Folder)
+
+        job := waitForExtraction(t, extractions, "zip-1", started.ID)
+        assert.Equal(t, models.ExtractionCompleted, job.Status)
+        assert.Equal(t, 3, job.TotalEntries)
+        assert.Equal(t, 3, job.ProcessedEntries)
+        assert.Equal(t, 2, job.ExtractedFiles)
+        assert.Equal(t, int64(14), job.ExtractedBytes)
+        require.Len(t, job.Errors, 1)
+        assert.Equal(t, "../escape.txt", job.Errors[0].Name)
+
+        folders := make(map[string]string)
+        for _, id := range job.FileIDs {
+            file, err := files.GetByID(ctx, id)
+            require.NoError(t, err)
+            folders[file.FileName] = file.Folder
+        }
+        assert.Equal(t, map[string]string{"readme.txt": "imports/docs", "notes.txt": "imports"}, folders)
+        assert.Equal(t, 2, objects.count())
+    })
+
+    t.Run("Entry Limit", func(t *testing.T) {
+        extractions := newService(t, service.ExtractionOptions{MaxEntries: 2, MaxSize: 1 << 20, MaxRatio: 100})
+        started, err := extractions.Start(ctx, "zip-2", service.UploadOptions{})
+        require.NoError(t, err)
+
+        job := waitForExtraction(t, extractions, "zip-2", started.ID)
+        assert.Equal(t, models.ExtractionFailed, job.Status)
+        assert.Contains(t, job.Error, service.ErrExtractionLimit.Error())
+        assert.Zero(t, job.ExtractedFiles)
+    })
+
+    t.Run("Tar Size Limit", func(t *testing.T) {
+        extractions := newService(t, service.ExtractionOptions{MaxEntries: 10, MaxSize: 25, MaxRatio: 100})
+        started, err := extractions.Start(ctx, "tgz-1", service.UploadOptions{Folder: "tar"})
+        require.NoError(t, err)
+
+        // Entries are extracted until the archive exceeds the limit
+        job := waitForExtraction(t, extractions, "tgz-1", started.ID)
+        assert.Equal(t, models.ExtractionFailed, job.Status)
+        assert.Equal(t, 1, job.ExtractedFiles)
+    })
+
+    t.Run("Not An Archive", func(t *testing.T) {
+        _, err := newService(t, limits).Start(ctx, "text-1", service.UploadOptions{})
+        assert.ErrorIs(t, err, service.ErrExtractionNotSupported)
+
+        _, err = newService(t, limits).Start(ctx, "zip-1", service.UploadOptions{Folder: "../up"})
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+    })
+
+    t.Run("Endpoint", func(t *testing.T) {
+        extractions := newService(t, limits)
+        handler := handlers.NewExtractionHandler(extractions, fileService, nil)
+        assert.True(t, handlers.IsExtractionPath("/files/zip-1/extract"))
+        assert.True(t, handlers.IsExtractionPath("/files/zip-1/extract/job-1"))
+        assert.False(t, handlers.IsExtractionPath("/files/zip-1/contents"))
+
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/files/zip-1/extract",
+            strings.NewReader(`{"folder":"endpoint"}`)))
+        require.Equal(t, http.StatusAccepted, rec.Code)
+        location := rec.Header().Get("Location")
+        require.True(t, strings.HasPrefix(location, "/files/zip-1/extract/"))
+
+        rec = httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+        assert.Equal(t, http.StatusOK, rec.Code)
+        assert.Contains(t, rec.Body.String(), `"folder":"endpoint"`)
+
+        // Jobs are only found under the archive they extract
+        rec = httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+            strings.Replace(location, "zip-1", "zip-2", 1), nil))
+        assert.Equal(t, http.StatusNotFound, rec.Code)
+
+        rec = httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/files/text-1/extract", nil))
+        assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+        jobID := strings.TrimPrefix(location, "/files/zip-1/extract/")
+        waitForExtraction(t, extractions, "zip-1", jobID)
+    })
+}
@@ -0,0 +1,249 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// mockFileRequestRepository is an in-memory FileRequestRepository
+type mockFileRequestRepository struct {
+    mu       sync.Mutex
+    requests map[string]*models.FileRequest
+}
+
+func newMockFileRequestRepository() *mockFileRequestRepository {
+    return &mockFileRequestRepository{requests: make(map[string]*models.FileRequest)}
+}
+
+func (m *mockFileRequestRepository) Create(ctx context.Context, request *models.FileRequest) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    stored := *request
+    m.requests[request.ID] = &stored
+    return nil
+}
+
+func (m *mockFileRequestRepository) GetByID(ctx context.Context, id string) (*models.FileRequest, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    request, ok := m.requests[id]
+    if !ok {
+        return nil, repository.ErrFileRequestNotFound
+    }
+    found := *request
+    return &found, nil
+}
+
+func (m *mockFileRequestRepository) ListByOwner(ctx context.Context, ownerID string) ([]*models.FileRequest, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var requests []*models.FileRequest
+    for _, request := range m.requests {
+        if request.OwnerID == ownerID {
+            found := *request
+            requests = append(requests, &found)
+        }
+    }
+    return requests, nil
+}
+
+func (m *mockFileRequestRepository) Reserve(ctx context.Context, id string, now time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    request, ok := m.requests[id]
+    if !ok || !request.IsOpen(now) {
+        return models.ErrFileRequestClosed
+    }
+    request.UploadCount++
+    request.LastUploadAt = &now
+    return nil
+}
+
+func (m *mockFileRequestRepository) Unreserve(ctx context.Context, id string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if request, ok := m.requests[id]; ok && request.UploadCount > 0 {
+        request.UploadCount--
+    }
+    return nil
+}
+
+func (m *mockFileRequestRepository) Revoke(ctx context.Context, id, ownerID string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    request, ok := m.requests[id]
+    if !ok || request.OwnerID != ownerID {
+        return repository.ErrFileRequestNotFound
+    }
+    request.RevokedAt = &at
+    return nil
+}
+
+// TestFileRequests tests upload inboxes for external parties
+func TestFileRequests(t *testing.T) {
+    ctx := context.Background()
+    mockStore := newMockStorage()
+    files := newMockRepository()
+    fileService, err := service.NewFileService(mockStore, files, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    })
+    require.NoError(t, err)
+    mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+        Return(nil)
+
+    hooks := &recordingNotifier{}
+    notifications, err := service.NewNotificationService(newMockNotificationRepository(), files, nil, hooks, 24*time.Hour, time.Second)
+    require.NoError(t, err)
+    _, err = notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+        UserID:          "alice",
+        WebhookURL:      "https://example.com/hook",
+        RequestChannels: []string{models.ChannelWebhook},
+    })
+    require.NoError(t, err)
+
+    requests, err := service.NewFileRequestService(newMockFileRequestRepository(), fileService, notifications,
+        24*time.Hour, 7*24*time.Hour)
+    require.NoError(t, err)
+
+    request, err := requests.Create(ctx, "alice", nil, service.FileRequestOptions{
+        Title:        "Tax documents",
+        Folder:       "clients/acme",
+        MaxFileSize:  testFileSize,
+        AllowedTypes: []string{"application/pdf"},
+        MaxFiles:     1,
+    })
+    require.NoError(t, err)
+    assert.WithinDuration(t, time.Now().Add(24*time.Hour), request.ExpiresAt, time.Minute)
+
+    t.Run("InvalidOptions", func(t *testing.T) {
+        _, err := requests.Create(ctx, "alice", nil, service.FileRequestOptions{Title: "No limits"})
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+
+        _, err = requests.Create(ctx, "alice", nil, service.FileRequestOptions{
+            Title:       "Too long",
+            MaxFileSize: testFileSize,
+            MaxFiles:    1,
+            TTL:         30 * 24 * time.Hour,
+        })
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+    })
+
+    t.Run("RejectsOutsideLimits", func(t *testing.T) {
+        _, err := requests.Upload(ctx, request.ID, "photo.png", "image/png", 1024,
+            bytes.NewReader(make([]byte, 1024)), "")
+        assert.ErrorIs(t, err, service.ErrUploadNotPermitted)
+
+        _, err = requests.Upload(ctx, request.ID, testFileName, testContentType, testFileSize+1,
+            bytes.NewReader(testPDFContent()), "")
+        assert.ErrorIs(t, err, service.ErrUploadTooLarge)
+    })
+
+    t.Run("Upload", func(t *testing.T) {
+        file, err := requests.Upload(ctx, request.ID, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), "Bob")
+        require.NoError(t, err)
+        assert.Equal(t, "alice", file.OwnerID)
+        assert.Equal(t, "clients/acme", file.Folder)
+
+        assert.Eventually(t, func() bool { return len(hooks.messages()) == 1 }, time.Second, 10*time.Millisecond)
+        assert.Equal(t, service.RequestUploadEvent, hooks.messages()[0].Event)
+
+        // The request only accepted one file
+        _, err = requests.Upload(ctx, request.ID, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), "")
+        assert.ErrorIs(t, err, models.ErrFileRequestClosed)
+    })
+
+    t.Run("Revoke", func(t *testing.T) {
+        open, err := requests.Create(ctx, "alice", nil, service.FileRequestOptions{
+            Title:       "Photos",
+            MaxFileSize: testFileSize,
+            MaxFiles:    10,
+        })
+        require.NoError(t, err)
+
+        assert.ErrorIs(t, requests.Revoke(ctx, open.ID, "mallory"), service.ErrFileRequestNotFound)
+        require.NoError(t, requests.Revoke(ctx, open.ID, "alice"))
+
+        _, err = requests.Get(ctx, open.ID)
+        assert.ErrorIs(t, err, models.ErrFileRequestClosed)
+    })
+}
+
+// tenantKeyStorage keeps content as S3 storage does under tenant isolation
+// with tenant keys required: uploads for a tenant are stored under its
+// prefix, and tenants may only read keys under their own prefix
+type tenantKeyStorage struct {
+    contentStorage
+}
+
+func (s *tenantKeyStorage) Upload(ctx context.Context, file *models.File, reader io.Reader) error {
+    content, err := io.ReadAll(reader)
+    if err != nil {
+        return err
+    }
+    key := storage.StorageKey(file.ID)
+    if tenantID := requestctx.Tenant(ctx); tenantID != "" {
+        key = storagekey.Default.ForTenant(tenantID, key)
+    }
+    if err := file.SetStoragePath(key); err != nil {
+        return err
+    }
+    s.content[file.ID] = content
+    return nil
+}
+
+func (s *tenantKeyStorage) Download(ctx context.Context, file *models.File) (io.ReadCloser, error) {
+    if tenantID := requestctx.Tenant(ctx); tenantID != "" && storagekey.Default.Tenant(file.StoragePath) != tenantID {
+        return nil, storage.ErrTenantMismatch
+    }
+    return s.contentStorage.Download(ctx, file)
+}
+
+// TestFileRequestTenant tests that files dropped into a request are stored
+// in the owner's tenant scope, where the owner can read them
+func TestFileRequestTenant(t *testing.T) {
+    content := &tenantKeyStorage{contentStorage{content: make(map[string][]byte)}}
+    fileService, err := service.NewFileService(content, newMockRepository(), service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    })
+    require.NoError(t, err)
+    requests, err := service.NewFileRequestService(newMockFileRequestRepository(), fileService, nil,
+        24*time.Hour, 7*24*time.Hour)
+    require.NoError(t, err)
+
+    owner := requestctx.WithPrincipal(context.Background(), &requestctx.Principal{UserID: "alice", TenantID: "acme"})
+    request, err := requests.Create(owner, "alice", nil, service.FileRequestOptions{
+        Title:       "Tax documents",
+        MaxFileSize: testFileSize,
+        MaxFiles:    1,
+    })
+    require.NoError(t, err)
+
+    // The external party has no principal or tenant of its own
+    file, err := requests.Upload(context.Background(), request.ID, testFileName, testContentType, testFileSize,
+        bytes.NewReader(testPDFContent()), "Bob")
+    require.NoError(t, err)
+    assert.Equal(t, "acme", storagekey.Default.Tenant(file.StoragePath))
+
+    _, reader, err := fileService.Download(owner, file.ID)
+    require.NoError(t, err)
+    reader.Close()
+}
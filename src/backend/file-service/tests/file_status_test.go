@@ -0,0 +1,98 @@
+package tests
+
+import (
+    "errors"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+)
+
+// TestFileLifecycle tests the allowed File.Status transitions
+func TestFileLifecycle(t *testing.T) {
+    newFile := func(t *testing.T) *models.File {
+        file, err := models.NewFile(testFileName, testFileSize, testContentType)
+        require.NoError(t, err)
+        return file
+    }
+
+    t.Run("Scanned Upload", func(t *testing.T) {
+        file := newFile(t)
+        require.NoError(t, file.UpdateStatus(models.FileStatusScanning))
+        require.NoError(t, file.UpdateStatus(models.FileStatusPendingReview))
+        require.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+        require.NoError(t, file.UpdateStatus(models.FileStatusCorrupted))
+        require.NoError(t, file.UpdateStatus(models.FileStatusDeleted))
+        assert.True(t, file.IsDeleted())
+    })
+
+    t.Run("Disallowed Transitions", func(t *testing.T) {
+        file := newFile(t)
+        require.NoError(t, file.UpdateStatus(models.FileStatusScanning))
+        require.NoError(t, file.UpdateStatus(models.FileStatusInfected))
+
+        err := file.UpdateStatus(models.FileStatusUploaded)
+        assert.True(t, errors.Is(err, models.ErrInvalidTransition))
+        assert.Equal(t, models.FileStatusInfected, file.Status)
+
+        require.NoError(t, file.UpdateStatus(models.FileStatusDeleted))
+        err = file.UpdateStatus(models.FileStatusPending)
+        assert.True(t, errors.Is(err, models.ErrInvalidTransition))
+    })
+
+    t.Run("Unknown Status", func(t *testing.T) {
+        file := newFile(t)
+        err := file.UpdateStatus("archived")
+        assert.True(t, errors.Is(err, models.ErrInvalidStatus))
+        assert.Equal(t, models.FileStatusPending, file.Status)
+    })
+
+    t.Run("Same Status", func(t *testing.T) {
+        file := newFile(t)
+        require.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+        assert.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+    })
+
+    t.Run("Transition Hooks", func(t *testing.T) {
+        machine := models.NewStatusMachine(map[string][]string{
+            models.FileStatusPending:  {models.FileStatusUploaded},
+            models.FileStatusUploaded: {},
+        })
+        var changes []models.StatusChange
+        machine.OnTransition(func(change models.StatusChange) {
+            changes = append(changes, change)
+        })
+
+        file := newFile(t)
+        require.NoError(t, machine.Transition(file, models.FileStatusUploaded))
+        require.NoError(t, machine.Transition(file, models.FileStatusUploaded))
+        assert.Error(t, machine.Transition(file, models.FileStatusPending))
+
+        require.Len(t, changes, 1)
+        assert.Equal(t, file.ID, changes[0].FileID)
+        assert.Equal(t, models.FileStatusPending, changes[0].From)
+        assert.Equal(t, models.FileStatusUploaded, changes[0].To)
+    })
+
+    t.Run("Unsaved Changes", func(t *testing.T) {
+        file := newFile(t)
+        require.NoError(t, file.UpdateStatus(models.FileStatusScanning))
+        require.NoError(t, file.UpdateStatus(models.FileStatusScanning))
+        require.NoError(t, file.UpdateStatus(models.FileStatusUploaded))
+
+        // The changes are kept for the repository to audit as it saves them
+        changes := file.StatusChanges()
+        require.Len(t, changes, 2)
+        assert.Equal(t, models.FileStatusPending, changes[0].From)
+        assert.Equal(t, models.FileStatusScanning, changes[0].To)
+        assert.Equal(t, models.FileStatusUploaded, changes[1].To)
+        event := models.NewStatusAuditEvent(changes[1])
+        assert.Equal(t, file.ID, event.FileID)
+        assert.Equal(t, changes[1].At, event.OccurredAt)
+
+        file.ResetStatusChanges()
+        assert.Empty(t, file.StatusChanges())
+    })
+}
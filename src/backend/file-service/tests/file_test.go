@@ -6,9 +6,13 @@ import (
     "context"
     "crypto/aes"
     "crypto/rand"
+    "encoding/base64"
     "encoding/hex"
     "errors"
     "io"
+    "sort"
+    "strings"
+    "sync"
     "testing"
     "time"
 
@@ -17,6 +21,7 @@ import (
     "github.com/stretchr/testify/require"
 
     "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
     "src/backend/file-service/internal/service"
     "src/backend/file-service/internal/storage"
     "src/backend/file-service/pkg/logger"
@@ -30,6 +35,15 @@ const (
     maxConcurrentOps = 10
 )
 
+// testPDFContent returns random content with a PDF header, so that it passes
+// magic number checks
+func testPDFContent() []byte {
+    content := make([]byte, testFileSize)
+    rand.Read(content)
+    copy(content, "%PDF-1.7\n")
+    return content
+}
+
 // mockStorage implements the Storage interface for testing
 type mockStorage struct {
     mock.Mock
@@ -75,12 +89,398 @@ func (m *mockStorage) Delete(ctx context.Context, file *models.File, softDelete
     return args.Error(0)
 }
 
+// mockRepository implements the FileRepository interface in memory for testing
+type mockRepository struct {
+    mu    sync.Mutex
+    files map[string]*models.File
+}
+
+func newMockRepository() *mockRepository {
+    return &mockRepository{files: make(map[string]*models.File)}
+}
+
+func (m *mockRepository) Create(ctx context.Context, file *models.File) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    file.Version = 1
+    file.ResetStatusChanges()
+    stored := *file
+    m.files[file.ID] = &stored
+    return nil
+}
+
+func (m *mockRepository) GetByID(ctx context.Context, id string) (*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    file, ok := m.files[id]
+    if !ok || file.IsDeleted() {
+        return nil, repository.ErrNotFound
+    }
+    found := *file
+    return &found, nil
+}
+
+func (m *mockRepository) FindByName(ctx context.Context, folder, fileName string) (*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var latest *models.File
+    for _, file := range m.files {
+        if file.Folder != folder || file.FileName != fileName ||
+            file.IsDeleted() || file.IsDraft() || file.Status == models.FileStatusFailed {
+            continue
+        }
+        if latest == nil || file.CreatedAt.After(latest.CreatedAt) {
+            latest = file
+        }
+    }
+    if latest == nil {
+        return nil, repository.ErrNotFound
+    }
+    found := *latest
+    return &found, nil
+}
+
+func (m *mockRepository) Update(ctx context.Context, file *models.File) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    current, ok := m.files[file.ID]
+    if !ok || current.IsDeleted() {
+        return repository.ErrNotFound
+    }
+    if current.Version != file.Version {
+        return repository.ErrVersionConflict
+    }
+    file.Version++
+    file.ResetStatusChanges()
+    stored := *file
+    m.files[file.ID] = &stored
+    return nil
+}
+
+func (m *mockRepository) Delete(ctx context.Context, id string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    file, ok := m.files[id]
+    if !ok {
+        return repository.ErrNotFound
+    }
+    file.Status = models.FileStatusDeleted
+    return nil
+}
+
+func (m *mockRepository) List(ctx context.Context, offset, limit int, filters map[string]interface{}) ([]*models.File, int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if !file.IsDeleted() && !file.IsDraft() {
+            files = append(files, file)
+        }
+    }
+    return files, int64(len(files)), nil
+}
+
+func (m *mockRepository) ListExpiredDrafts(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if file.IsDraft() && file.DraftExpiresAt.Before(before) && len(files) < limit {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    return files, nil
+}
+
+func (m *mockRepository) ListStaleStaged(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        staged := strings.Contains(file.StoragePath, storage.StagingPrefix+"/")
+        if file.Status == models.FileStatusPending && staged && file.UpdatedAt.Before(before) && len(files) < limit {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    return files, nil
+}
+
+func (m *mockRepository) ListUploadedInFolders(ctx context.Context, folders []string, since, until time.Time, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if file.IsDeleted() || file.IsDraft() || file.CreatedAt.Before(since) || !file.CreatedAt.Before(until) {
+            continue
+        }
+        for _, folder := range folders {
+            if file.Folder == folder {
+                found := *file
+                files = append(files, &found)
+                break
+            }
+        }
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.Before(files[j].CreatedAt) })
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+func (m *mockRepository) UsageByOwner(ctx context.Context, ownerID string) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var used int64
+    for _, file := range m.files {
+        if file.OwnerID == ownerID && !file.IsDeleted() {
+            used += file.Size
+        }
+    }
+    return used, nil
+}
+
+func (m *mockRepository) TotalUsage(ctx context.Context) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var used int64
+    for _, file := range m.files {
+        if !file.IsDeleted() {
+            used += file.Size
+        }
+    }
+    return used, nil
+}
+
+func (m *mockRepository) UsageByState(ctx context.Context, now time.Time) ([]models.StorageUsage, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    totals := make(map[[2]string]*models.StorageUsage)
+    for _, file := range m.files {
+        state := file.StorageState()
+        if state == "" {
+            continue
+        }
+        key := [2]string{state, file.EffectiveStorageClass()}
+        if totals[key] == nil {
+            totals[key] = &models.StorageUsage{State: key[0], StorageClass: key[1]}
+        }
+        totals[key].Bytes += file.Size
+        totals[key].Objects++
+    }
+    var usage []models.StorageUsage
+    for _, total := range totals {
+        usage = append(usage, *total)
+    }
+    return usage, nil
+}
+
+func (m *mockRepository) ListAwaitingReplication(ctx context.Context, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if file.AwaitsReplication() {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.Before(files[j].CreatedAt) })
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+func (m *mockRepository) UpdateReplication(ctx context.Context, file *models.File) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    current, ok := m.files[file.ID]
+    if !ok || current.IsDeleted() {
+        return repository.ErrNotFound
+    }
+    current.ReplicationStatus = file.ReplicationStatus
+    current.ReplicationAttempts = file.ReplicationAttempts
+    current.ReplicatedAt = file.ReplicatedAt
+    return nil
+}
+
+func (m *mockRepository) ListIdle(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if file.IsUploaded() && !file.IsArchived() && file.LastAccessedAt.Before(before) {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].LastAccessedAt.Before(files[j].LastAccessedAt) })
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+func (m *mockRepository) ListPendingRestores(ctx context.Context, now time.Time, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        restoring := file.ArchiveStatus == models.ArchiveStatusRestoring
+        expired := file.ArchiveStatus == models.ArchiveStatusRestored && file.NeedsRestore(now)
+        if file.IsUploaded() && (restoring || expired) {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+func (m *mockRepository) UpdateArchive(ctx context.Context, file *models.File) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    current, ok := m.files[file.ID]
+    if !ok || current.IsDeleted() {
+        return repository.ErrNotFound
+    }
+    current.StorageClass = file.StorageClass
+    current.ArchiveStatus = file.ArchiveStatus
+    current.ArchivedAt = file.ArchivedAt
+    current.RestoreExpiresAt = file.RestoreExpiresAt
+    current.ServerSideEncryption = file.ServerSideEncryption
+    return nil
+}
+
+func (m *mockRepository) ListCold(ctx context.Context, before time.Time, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if file.IsUploaded() && !file.IsArchived() && file.StorageClass == "" && file.LastAccessedAt.Before(before) {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].LastAccessedAt.Before(files[j].LastAccessedAt) })
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+func (m *mockRepository) RecordAccess(ctx context.Context, id string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if file, ok := m.files[id]; ok && file.LastAccessedAt.Before(at) {
+        file.LastAccessedAt = at
+    }
+    return nil
+}
+
+func (m *mockRepository) ListLegacyStoragePaths(ctx context.Context, afterID string, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if file.ID > afterID && !file.IsDeleted() && file.StoragePath != "" && !strings.Contains(file.StoragePath, "/") {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+func (m *mockRepository) UpdateStoragePath(ctx context.Context, from string, file *models.File) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    updated := false
+    for _, current := range m.files {
+        if current.StoragePath == from {
+            current.StoragePath = file.StoragePath
+            current.ServerSideEncryption = file.ServerSideEncryption
+            updated = true
+        }
+    }
+    if !updated {
+        return repository.ErrNotFound
+    }
+    return nil
+}
+
+func (m *mockRepository) ListStoragePaths(ctx context.Context, afterPath, afterID string, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        after := file.StoragePath > afterPath || (file.StoragePath == afterPath && file.ID > afterID)
+        if after && !file.IsDeleted() && file.StoragePath != "" {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    sort.Slice(files, func(i, j int) bool {
+        if files[i].StoragePath != files[j].StoragePath {
+            return files[i].StoragePath < files[j].StoragePath
+        }
+        return files[i].ID < files[j].ID
+    })
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+func (m *mockRepository) StoragePathReferenced(ctx context.Context, storagePath string) (bool, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for _, file := range m.files {
+        if file.StoragePath == storagePath && !file.IsDeleted() {
+            return true, nil
+        }
+    }
+    return false, nil
+}
+
+func (m *mockRepository) SampleUploaded(ctx context.Context, limit int) ([]*models.File, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var files []*models.File
+    for _, file := range m.files {
+        if file.IsUploaded() && file.Checksum != "" && !file.IsArchived() {
+            found := *file
+            files = append(files, &found)
+        }
+    }
+    if len(files) > limit {
+        files = files[:limit]
+    }
+    return files, nil
+}
+
+// fakeDraftStorage promotes drafts without copying content
+type fakeDraftStorage struct{}
+
+func (fakeDraftStorage) PromoteDraft(ctx context.Context, file *models.File) error {
+    return file.SetStoragePath(storage.StorageKey(file.ID))
+}
+
 // TestFileUpload tests the file upload functionality
 func TestFileUpload(t *testing.T) {
     // Initialize test context and dependencies
     ctx := context.Background()
     mockStore := newMockStorage()
-    fileService, err := service.NewFileService(mockStore, service.WorkerPoolConfig{
+    fileService, err := service.NewFileService(mockStore, newMockRepository(), service.WorkerPoolConfig{
         MaxWorkers:  maxConcurrentOps,
         BufferSize: 32 * 1024,
     })
@@ -88,8 +488,7 @@ func TestFileUpload(t *testing.T) {
 
     t.Run("Successful Upload", func(t *testing.T) {
         // Prepare test data
-        content := make([]byte, testFileSize)
-        rand.Read(content)
+        content := testPDFContent()
         reader := bytes.NewReader(content)
 
         // Configure mock expectations
@@ -97,7 +496,7 @@ func TestFileUpload(t *testing.T) {
             Return(nil).Once()
 
         // Perform upload
-        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader)
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader, service.UploadOptions{})
         require.NoError(t, err)
         assert.NotEmpty(t, file.ID)
         assert.Equal(t, testFileName, file.FileName)
@@ -153,7 +552,7 @@ func TestFileUpload(t *testing.T) {
 
         for _, tc := range invalidCases {
             t.Run(tc.name, func(t *testing.T) {
-                _, err := fileService.Upload(ctx, tc.fileName, tc.contentType, tc.size, tc.reader)
+                _, err := fileService.Upload(ctx, tc.fileName, tc.contentType, tc.size, tc.reader, service.UploadOptions{})
                 if tc.expectErr {
                     assert.Error(t, err)
                 } else {
@@ -174,11 +573,10 @@ func TestFileUpload(t *testing.T) {
         go func() {
             for i := 0; i < numUploads; i++ {
                 go func(idx int) {
-                    content := make([]byte, testFileSize)
-                    rand.Read(content)
+                    content := testPDFContent()
                     reader := bytes.NewReader(content)
 
-                    _, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader)
+                    _, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader, service.UploadOptions{})
                     errChan <- err
                 }(i)
             }
@@ -196,11 +594,69 @@ func TestFileUpload(t *testing.T) {
     })
 }
 
+// fakeEscrow records escrow requests instead of calling KMS
+type fakeEscrow struct {
+    escrowed map[string][]byte
+}
+
+func (f *fakeEscrow) Escrow(ctx context.Context, fileID string, wrappedKey []byte) (string, []byte, error) {
+    f.escrowed[fileID] = wrappedKey
+    return "escrow-key", append([]byte("escrowed:"), wrappedKey...), nil
+}
+
+// TestClientEncryptedUpload tests storage of client encryption metadata and key escrow
+func TestClientEncryptedUpload(t *testing.T) {
+    ctx := context.Background()
+    mockStore := newMockStorage()
+    repo := newMockRepository()
+    escrow := &fakeEscrow{escrowed: make(map[string][]byte)}
+    fileService, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    }, service.WithClientEncryption(escrow, false))
+    require.NoError(t, err)
+
+    wrappedKey := make([]byte, 40)
+    rand.Read(wrappedKey)
+    encryption := &models.EncryptionMetadata{
+        Algorithm:  models.EncryptionAlgorithmAES256GCM,
+        WrappedKey: base64.StdEncoding.EncodeToString(wrappedKey),
+        KeyID:      "client-key-1",
+    }
+
+    t.Run("Escrowed Upload", func(t *testing.T) {
+        mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+            Return(nil).Once()
+
+        content := testPDFContent()
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, bytes.NewReader(content),
+            service.UploadOptions{Encryption: encryption, EscrowKey: true})
+        require.NoError(t, err)
+        require.True(t, file.IsClientEncrypted())
+        assert.Equal(t, encryption.WrappedKey, file.Encryption.WrappedKey)
+        assert.True(t, file.Encryption.IsEscrowed())
+        assert.Equal(t, wrappedKey, escrow.escrowed[file.ID])
+
+        stored, err := repo.GetByID(ctx, file.ID)
+        require.NoError(t, err)
+        assert.Equal(t, "escrow-key", stored.Encryption.EscrowKeyID)
+    })
+
+    t.Run("Invalid Metadata", func(t *testing.T) {
+        invalid := *encryption
+        invalid.Algorithm = "ROT13"
+
+        _, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, bytes.NewReader([]byte("test")),
+            service.UploadOptions{Encryption: &invalid})
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+    })
+}
+
 // TestFileDownload tests the file download functionality
 func TestFileDownload(t *testing.T) {
     ctx := context.Background()
     mockStore := newMockStorage()
-    fileService, err := service.NewFileService(mockStore, service.WorkerPoolConfig{
+    fileService, err := service.NewFileService(mockStore, newMockRepository(), service.WorkerPoolConfig{
         MaxWorkers:  maxConcurrentOps,
         BufferSize: 32 * 1024,
     })
@@ -208,14 +664,13 @@ func TestFileDownload(t *testing.T) {
 
     t.Run("Successful Download", func(t *testing.T) {
         // Upload a test file first
-        content := make([]byte, testFileSize)
-        rand.Read(content)
+        content := testPDFContent()
         reader := bytes.NewReader(content)
 
         mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
             Return(nil).Once()
 
-        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader)
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader, service.UploadOptions{})
         require.NoError(t, err)
 
         // Configure download expectations
@@ -237,26 +692,45 @@ func TestFileDownload(t *testing.T) {
     })
 
     t.Run("Download Non-Existent File", func(t *testing.T) {
-        mockStore.On("Download", ctx, mock.AnythingOfType("*models.File")).
-            Return(nil, storage.ErrFileNotFound).Once()
-
         _, _, err := fileService.Download(ctx, "non-existent-id")
         assert.Error(t, err)
-        assert.True(t, errors.Is(err, storage.ErrFileNotFound))
+        assert.True(t, errors.Is(err, service.ErrFileNotFound))
 
-        mockStore.AssertExpectations(t)
+        mockStore.AssertNotCalled(t, "Download", ctx, mock.MatchedBy(func(f *models.File) bool {
+            return f.ID == "non-existent-id"
+        }))
+    })
+
+    t.Run("Stat Without Content", func(t *testing.T) {
+        mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+            Return(nil).Once()
+
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{})
+        require.NoError(t, err)
+
+        stat, err := fileService.Stat(ctx, file.ID)
+        require.NoError(t, err)
+        assert.Equal(t, file.Checksum, stat.Checksum)
+        assert.False(t, stat.IsClientEncrypted())
+
+        _, err = fileService.Stat(ctx, "non-existent-id")
+        assert.True(t, errors.Is(err, service.ErrFileNotFound))
+
+        mockStore.AssertNotCalled(t, "Download", ctx, mock.MatchedBy(func(f *models.File) bool {
+            return f.ID == file.ID
+        }))
     })
 
     t.Run("Concurrent Downloads", func(t *testing.T) {
         // Upload test file
-        content := make([]byte, testFileSize)
-        rand.Read(content)
+        content := testPDFContent()
         reader := bytes.NewReader(content)
 
         mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
             Return(nil).Once()
 
-        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader)
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize, reader, service.UploadOptions{})
         require.NoError(t, err)
 
         numDownloads := 5
@@ -289,4 +763,74 @@ func TestFileDownload(t *testing.T) {
 
         mockStore.AssertExpectations(t)
     })
-}
\ No newline at end of file
+}
+
+// TestDraftUpload tests draft uploads, commit and purging of expired drafts
+func TestDraftUpload(t *testing.T) {
+    ctx := context.Background()
+    mockStore := newMockStorage()
+    repo := newMockRepository()
+    fileService, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    }, service.WithDrafts(fakeDraftStorage{}, time.Hour))
+    require.NoError(t, err)
+
+    mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+        Return(nil)
+
+    t.Run("Commit Draft", func(t *testing.T) {
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{Draft: true})
+        require.NoError(t, err)
+        assert.True(t, file.IsDraft())
+        require.NotNil(t, file.DraftExpiresAt)
+
+        listed, _, err := repo.List(ctx, 0, 10, nil)
+        require.NoError(t, err)
+        assert.Empty(t, listed)
+
+        committed, err := fileService.Commit(ctx, file.ID)
+        require.NoError(t, err)
+        assert.True(t, committed.IsUploaded())
+        assert.Nil(t, committed.DraftExpiresAt)
+
+        _, err = fileService.Commit(ctx, file.ID)
+        assert.True(t, errors.Is(err, models.ErrNotDraft))
+    })
+
+    t.Run("Purge Expired Draft", func(t *testing.T) {
+        expiring, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        }, service.WithDrafts(fakeDraftStorage{}, -time.Minute))
+        require.NoError(t, err)
+
+        file, err := expiring.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{Draft: true})
+        require.NoError(t, err)
+
+        _, err = expiring.Commit(ctx, file.ID)
+        assert.True(t, errors.Is(err, models.ErrDraftExpired))
+
+        mockStore.On("Delete", ctx, mock.AnythingOfType("*models.File"), false).Return(nil).Once()
+        purged, err := expiring.PurgeExpiredDrafts(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 1, purged)
+
+        _, err = repo.GetByID(ctx, file.ID)
+        assert.True(t, errors.Is(err, repository.ErrNotFound))
+    })
+
+    t.Run("Drafts Disabled", func(t *testing.T) {
+        plain, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        })
+        require.NoError(t, err)
+
+        _, err = plain.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{Draft: true})
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+    })
+}
@@ -0,0 +1,43 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+
+    "src/backend/file-service/pkg/i18n"
+)
+
+// TestLanguageNegotiation tests choosing a catalog from Accept-Language
+func TestLanguageNegotiation(t *testing.T) {
+    tests := []struct {
+        name   string
+        header string
+        want   string
+    }{
+        {"Empty", "", i18n.Default},
+        {"Exact", "de", "de"},
+        {"Region Falls Back To Language", "fr-CA", "fr"},
+        {"Quality Order", "de;q=0.5, es;q=0.9, en;q=0.1", "es"},
+        {"Unsupported Falls Back", "ja, zh-CN;q=0.8", i18n.Default},
+        {"Skips Unsupported", "ja, fr;q=0.8", "fr"},
+        {"Excluded Language", "de;q=0, fr;q=0.2", "fr"},
+        {"Wildcard", "*", i18n.Default},
+        {"English Preferred", "en-GB, de;q=0.9", i18n.Default},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            assert.Equal(t, tt.want, i18n.Negotiate(tt.header))
+        })
+    }
+}
+
+// TestTranslate tests catalog lookups and the English fallback
+func TestTranslate(t *testing.T) {
+    assert.Equal(t, "Datei nicht gefunden", i18n.Translate("de", "File not found"))
+    assert.Equal(t, "Fichier introuvable", i18n.Translate("fr", "File not found"))
+    assert.Equal(t, "File not found", i18n.Translate(i18n.Default, "File not found"))
+    assert.Equal(t, "File not found", i18n.Translate("ja", "File not found"))
+    assert.Equal(t, "Untranslated message", i18n.Translate("de", "Untranslated message"))
+    assert.Equal(t, []string{"de", "en", "es", "fr"}, i18n.Languages())
+}
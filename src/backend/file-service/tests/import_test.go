@@ -0,0 +1,163 @@
+package tests
+
+import (
+    "context"
+    "sort"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// fakeImportStorage holds objects written outside the service, by key
+type fakeImportStorage struct {
+    objects map[string]storage.ObjectInfo
+}
+
+func (s *fakeImportStorage) ListPrefix(ctx context.Context, prefix, startAfter string, limit int) ([]storage.StoredObject, error) {
+    keys := make([]string, 0, len(s.objects))
+    for key := range s.objects {
+        if strings.HasPrefix(key, prefix) && key > startAfter {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+    if len(keys) > limit {
+        keys = keys[:limit]
+    }
+    objects := make([]storage.StoredObject, 0, len(keys))
+    for _, key := range keys {
+        objects = append(objects, storage.StoredObject{Key: key, Size: s.objects[key].Size})
+    }
+    return objects, nil
+}
+
+func (s *fakeImportStorage) InspectObject(ctx context.Context, key string) (*storage.ObjectInfo, error) {
+    info, ok := s.objects[key]
+    if !ok {
+        return nil, storage.ErrObjectNotFound
+    }
+    return &info, nil
+}
+
+func (s *fakeImportStorage) AdoptObject(ctx context.Context, file *models.File, key string) error {
+    copied := s.objects[key]
+    copied.ContentType = file.ContentType
+    s.objects[storagekey.Default.Key(file.ID)] = copied
+    if err := file.SetStoragePath(storagekey.Default.Key(file.ID)); err != nil {
+        return err
+    }
+    return file.UpdateChecksum(sha256Hex(copied.Header))
+}
+
+func (s *fakeImportStorage) DeleteObject(ctx context.Context, key string) error {
+    delete(s.objects, key)
+    return nil
+}
+
+// TestImport tests adopting objects written to the bucket outside the service
+func TestImport(t *testing.T) {
+    ctx := context.Background()
+    newStorage := func() *fakeImportStorage {
+        return &fakeImportStorage{objects: map[string]storage.ObjectInfo{
+            "legacy/report.pdf":  {Size: 9, ContentType: "application/pdf", Header: []byte("%PDF-1.7\n")},
+            "legacy/docs/notes":  {Size: 11, ContentType: "binary/octet-stream", Header: []byte("plain notes")},
+            "legacy/docs/":       {},
+            "legacy/empty.txt":   {},
+            "unrelated/data.csv": {Size: 4, Header: []byte("a,b\n")},
+        }}
+    }
+
+    t.Run("Import", func(t *testing.T) {
+        repo := newMockRepository()
+        objects := newStorage()
+        imported := make(map[string]*models.File)
+        importer, err := service.NewImporter(repo, objects, service.ImportOptions{
+            Prefix:    "legacy/",
+            Folder:    "archive",
+            OwnerID:   "alice",
+            BatchSize: 2,
+            Imported: func(key string, file *models.File) {
+                imported[key] = file
+            },
+        })
+        require.NoError(t, err)
+
+        result, err := importer.Import(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 4, result.Scanned)
+        assert.Equal(t, 2, result.Imported)
+        assert.Equal(t, 2, result.Skipped)
+        assert.Empty(t, result.Failed)
+
+        report := imported["legacy/report.pdf"]
+        require.NotNil(t, report)
+        assert.Equal(t, "archive", report.Folder)
+        assert.Equal(t, "report.pdf", report.FileName)
+        assert.Equal(t, "application/pdf", report.ContentType)
+        assert.Equal(t, int64(9), report.Size)
+        assert.Equal(t, "alice", report.OwnerID)
+        assert.Equal(t, sha256Hex([]byte("%PDF-1.7\n")), report.Checksum)
+        assert.Equal(t, models.FileStatusUploaded, report.Status)
+
+        // Generic content types are detected from the content
+        notes := imported["legacy/docs/notes"]
+        require.NotNil(t, notes)
+        assert.Equal(t, "archive/docs", notes.Folder)
+        assert.Equal(t, "text/plain; charset=utf-8", notes.ContentType)
+
+        stored, err := repo.GetByID(ctx, report.ID)
+        require.NoError(t, err)
+        assert.Equal(t, storagekey.Default.Key(report.ID), stored.StoragePath)
+        assert.Contains(t, objects.objects, "legacy/report.pdf")
+
+        // Rerunning skips what was imported
+        result, err = importer.Import(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 0, result.Imported)
+        assert.Equal(t, 4, result.Skipped)
+    })
+
+    t.Run("Move", func(t *testing.T) {
+        repo := newMockRepository()
+        objects := newStorage()
+        importer, err := service.NewImporter(repo, objects, service.ImportOptions{
+            Prefix:    "legacy/docs",
+            Move:      true,
+            BatchSize: 10,
+        })
+        require.NoError(t, err)
+
+        result, err := importer.Import(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 1, result.Imported)
+        assert.NotContains(t, objects.objects, "legacy/docs/notes")
+
+        file, err := repo.FindByName(ctx, "docs", "notes")
+        require.NoError(t, err)
+        assert.Contains(t, objects.objects, file.StoragePath)
+    })
+
+    t.Run("Dry Run", func(t *testing.T) {
+        repo := newMockRepository()
+        objects := newStorage()
+        importer, err := service.NewImporter(repo, objects, service.ImportOptions{
+            Prefix:    "legacy/",
+            DryRun:    true,
+            BatchSize: 10,
+        })
+        require.NoError(t, err)
+
+        result, err := importer.Import(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 2, result.Imported)
+        assert.Empty(t, repo.files)
+        assert.Len(t, objects.objects, 5)
+    })
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+)
+
+// replicaCopies serves secondary copies of file content by file ID,
+// restoring them over the content of primary
+type replicaCopies struct {
+    content  map[string][]byte
+    primary  *integrityStorage
+    restored int
+}
+
+func (r *replicaCopies) ReadReplica(ctx context.Context, file *models.File) (io.ReadCloser, error) {
+    content, ok := r.content[file.ID]
+    if !ok {
+        return nil, errors.New("no replica")
+    }
+    return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (r *replicaCopies) RestoreReplica(ctx context.Context, file *models.File) error {
+    r.primary.content[file.ID] = r.content[file.ID]
+    r.restored++
+    return nil
+}
+
+// notifyingAudit hands appended audit events to the test
+type notifyingAudit struct {
+    fakeAuditRepository
+    appended chan *models.AuditEvent
+}
+
+func (a *notifyingAudit) Append(ctx context.Context, event *models.AuditEvent) error {
+    a.appended <- event
+    return nil
+}
+
+// TestIntegrityGuard tests investigating content found not to match its
+// checksum on download
+func TestIntegrityGuard(t *testing.T) {
+    ctx := context.Background()
+    original := bytes.Repeat([]byte("original content "), 4096)
+    damaged := append(bytes.Clone(original[:len(original)-1]), '!')
+
+    setup := func(t *testing.T, stored []byte, replicated bool) (*mockRepository, *integrityStorage, *models.File) {
+        repo := newMockRepository()
+        file := &models.File{
+            ID:          "file-1",
+            StoragePath: "fi/le/file-1",
+            Status:      models.FileStatusUploaded,
+            Checksum:    sha256Hex(original),
+            Size:        int64(len(original)),
+        }
+        if replicated {
+            file.ReplicationStatus = models.ReplicationReplicated
+        }
+        require.NoError(t, repo.Create(ctx, file))
+        return repo, &integrityStorage{content: map[string][]byte{"file-1": stored}}, file
+    }
+    status := func(repo *mockRepository) string {
+        file, err := repo.GetByID(ctx, "file-1")
+        require.NoError(t, err)
+        return file.Status
+    }
+
+    t.Run("Download", func(t *testing.T) {
+        repo, content, file := setup(t, damaged, false)
+        audit := &notifyingAudit{appended: make(chan *models.AuditEvent, 1)}
+        guard, err := service.NewIntegrityGuard(repo, content, nil, audit, nil)
+        require.NoError(t, err)
+
+        // Intact content reads through whole
+        intact := guard.Verify(ctx, file, io.NopCloser(bytes.NewReader(original)))
+        read, err := io.ReadAll(intact)
+        require.NoError(t, err)
+        assert.Equal(t, original, read)
+
+        // Damaged content fails before its last bytes are read
+        body := guard.Verify(ctx, file, io.NopCloser(bytes.NewReader(damaged)))
+        read, err = io.ReadAll(body)
+        assert.ErrorIs(t, err, service.ErrInvalidChecksum)
+        assert.Less(t, len(read), len(damaged))
+        assert.Equal(t, damaged[:len(read)], read)
+
+        select {
+        case event := <-audit.appended:
+            assert.Equal(t, models.AuditIntegrityIncident, event.Type)
+            assert.Equal(t, "file-1", event.FileID)
+            assert.Equal(t, models.IntegrityUnrepaired, event.Detail["outcome"])
+            assert.Equal(t, sha256Hex(damaged), event.Detail["actualChecksum"])
+            assert.Equal(t, sha256Hex(damaged), event.Detail["rereadChecksum"])
+            assert.Contains(t, event.Detail["errors"], "no secondary bucket")
+        case <-time.After(5 * time.Second):
+            t.Fatal("no integrity incident was recorded")
+        }
+        assert.Equal(t, models.FileStatusCorrupted, status(repo))
+    })
+
+    t.Run("Transient", func(t *testing.T) {
+        repo, content, file := setup(t, original, false)
+        guard, err := service.NewIntegrityGuard(repo, content, nil, nil, nil)
+        require.NoError(t, err)
+
+        incident, err := guard.Investigate(ctx, file, service.IntegrityDetectedByDownload, sha256Hex(damaged), 10)
+        require.NoError(t, err)
+        assert.Equal(t, models.IntegrityTransient, incident.Outcome)
+        assert.Equal(t, models.FileStatusUploaded, status(repo))
+    })
+
+    t.Run("Repaired From Replica", func(t *testing.T) {
+        repo, content, file := setup(t, damaged, true)
+        replica := &replicaCopies{content: map[string][]byte{"file-1": original}, primary: content}
+        guard, err := service.NewIntegrityGuard(repo, content, replica, nil, nil)
+        require.NoError(t, err)
+
+        incident, err := guard.Investigate(ctx, file, service.IntegrityDetectedByDownload, sha256Hex(damaged), 10)
+        require.NoError(t, err)
+        assert.Equal(t, models.IntegrityRepaired, incident.Outcome)
+        assert.Equal(t, sha256Hex(original), incident.Replica)
+        assert.Equal(t, 1, replica.restored)
+        assert.Equal(t, original, content.content["file-1"])
+        assert.Equal(t, models.FileStatusUploaded, status(repo))
+    })
+
+    t.Run("Damaged Replica", func(t *testing.T) {
+        repo, content, file := setup(t, damaged, true)
+        replica := &replicaCopies{content: map[string][]byte{"file-1": damaged}, primary: content}
+        guard, err := service.NewIntegrityGuard(repo, content, replica, nil, nil)
+        require.NoError(t, err)
+
+        incident, err := guard.Investigate(ctx, file, service.IntegrityDetectedByDownload, sha256Hex(damaged), 10)
+        require.NoError(t, err)
+        assert.Equal(t, models.IntegrityUnrepaired, incident.Outcome)
+        assert.Zero(t, replica.restored)
+        assert.Equal(t, models.FileStatusCorrupted, status(repo))
+
+        // A file already frozen is not investigated again
+        incident, err = guard.Investigate(ctx, file, service.IntegrityDetectedByDownload, sha256Hex(damaged), 10)
+        require.NoError(t, err)
+        assert.Nil(t, incident)
+    })
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+    "context"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/joblock"
+)
+
+// TestJobLocker tests that scheduled jobs do not overlap
+func TestJobLocker(t *testing.T) {
+    ctx := context.Background()
+
+    t.Run("Skips Overlapping Runs", func(t *testing.T) {
+        locker := joblock.NewLocal()
+        outer, inner, other := 0, 0, 0
+
+        err := locker.Run(ctx, "blob-gc", func(ctx context.Context) {
+            outer++
+            require.NoError(t, locker.Run(ctx, "blob-gc", func(ctx context.Context) { inner++ }))
+            require.NoError(t, locker.Run(ctx, "draft-purge", func(ctx context.Context) { other++ }))
+        })
+        require.NoError(t, err)
+        assert.Equal(t, 1, outer)
+        assert.Equal(t, 0, inner)
+        assert.Equal(t, 1, other)
+
+        // The lock is free again once the run returns
+        require.NoError(t, locker.Run(ctx, "blob-gc", func(ctx context.Context) { inner++ }))
+        assert.Equal(t, 1, inner)
+    })
+
+    t.Run("Keys", func(t *testing.T) {
+        assert.Equal(t, joblock.Key("blob-gc"), joblock.Key("blob-gc"))
+        assert.NotEqual(t, joblock.Key("blob-gc"), joblock.Key("draft-purge"))
+    })
+
+    t.Run("Backends", func(t *testing.T) {
+        _, err := joblock.New(joblock.BackendPostgres, nil, 0)
+        assert.Error(t, err)
+        _, err = joblock.New("redis", nil, 0)
+        assert.Error(t, err)
+        _, err = joblock.New(joblock.BackendLocal, nil, 0)
+        assert.NoError(t, err)
+    })
+}
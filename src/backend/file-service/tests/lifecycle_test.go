@@ -0,0 +1,74 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+
+    "src/backend/file-service/pkg/lifecycle"
+)
+
+// TestDrainer tests the Kubernetes probe and preStop drain semantics
+func TestDrainer(t *testing.T) {
+    status := func(handler http.Handler, path string) int {
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+        return rec.Code
+    }
+
+    preStop := func(drainer *lifecycle.Drainer, method, remoteAddr string) int {
+        req := httptest.NewRequest(method, "/prestop", nil)
+        req.RemoteAddr = remoteAddr
+        rec := httptest.NewRecorder()
+        drainer.PreStopHandler().ServeHTTP(rec, req)
+        return rec.Code
+    }
+
+    t.Run("Readiness Fails Checks", func(t *testing.T) {
+        drainer := lifecycle.NewDrainer(0, func(ctx context.Context) error {
+            return errors.New("database unavailable")
+        })
+        assert.Equal(t, http.StatusServiceUnavailable, status(drainer.ReadinessHandler(), "/readyz"))
+        assert.Equal(t, http.StatusOK, status(drainer.LivenessHandler(), "/healthz"))
+    })
+
+    t.Run("PreStop Drains", func(t *testing.T) {
+        delay := 50 * time.Millisecond
+        drainer := lifecycle.NewDrainer(delay)
+        assert.Equal(t, http.StatusOK, status(drainer.ReadinessHandler(), "/readyz"))
+
+        start := time.Now()
+        assert.Equal(t, http.StatusOK, preStop(drainer, http.MethodPost, "127.0.0.1:40000"))
+        assert.GreaterOrEqual(t, time.Since(start), delay)
+
+        assert.True(t, drainer.Draining())
+        assert.Equal(t, http.StatusServiceUnavailable, status(drainer.ReadinessHandler(), "/readyz"))
+        assert.Equal(t, http.StatusOK, status(drainer.LivenessHandler(), "/healthz"))
+
+        // The termination signal after preStop does not wait again
+        select {
+        case <-drainer.Drain():
+        default:
+            t.Fatal("drain should already be complete")
+        }
+    })
+
+    t.Run("PreStop Refused", func(t *testing.T) {
+        drainer := lifecycle.NewDrainer(0)
+
+        // Only POST from the pod itself may start the drain
+        assert.Equal(t, http.StatusMethodNotAllowed, preStop(drainer, http.MethodGet, "127.0.0.1:40000"))
+        assert.Equal(t, http.StatusForbidden, preStop(drainer, http.MethodPost, "10.0.3.7:40000"))
+        assert.Equal(t, http.StatusForbidden, preStop(drainer, http.MethodPost, "[::ffff:10.0.3.7]:40000"))
+        assert.False(t, drainer.Draining())
+        assert.Equal(t, http.StatusOK, status(drainer.ReadinessHandler(), "/readyz"))
+
+        assert.Equal(t, http.StatusOK, preStop(drainer, http.MethodPost, "[::1]:40000"))
+        assert.True(t, drainer.Draining())
+    })
+}
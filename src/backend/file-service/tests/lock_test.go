@@ -0,0 +1,112 @@
+package tests
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+)
+
+// mockLockRepository is an in-memory LockRepository
+type mockLockRepository struct {
+    mu    sync.Mutex
+    locks map[string]*models.FileLock
+}
+
+func newMockLockRepository() *mockLockRepository {
+    return &mockLockRepository{locks: make(map[string]*models.FileLock)}
+}
+
+func (m *mockLockRepository) Acquire(ctx context.Context, lock *models.FileLock) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if held, ok := m.locks[lock.FileID]; ok && !held.IsHeldBy(lock.OwnerID, lock.CreatedAt) && !held.IsExpired(lock.CreatedAt) {
+        return repository.ErrLockHeld
+    }
+    stored := *lock
+    m.locks[lock.FileID] = &stored
+    return nil
+}
+
+func (m *mockLockRepository) Get(ctx context.Context, fileID string) (*models.FileLock, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    lock, ok := m.locks[fileID]
+    if !ok {
+        return nil, repository.ErrLockNotFound
+    }
+    found := *lock
+    return &found, nil
+}
+
+func (m *mockLockRepository) Release(ctx context.Context, fileID, ownerID string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    lock, ok := m.locks[fileID]
+    if !ok || lock.OwnerID != ownerID {
+        return repository.ErrLockNotFound
+    }
+    delete(m.locks, fileID)
+    return nil
+}
+
+func (m *mockLockRepository) Break(ctx context.Context, fileID string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if _, ok := m.locks[fileID]; !ok {
+        return repository.ErrLockNotFound
+    }
+    delete(m.locks, fileID)
+    return nil
+}
+
+// TestLockService tests file checkout locks
+func TestLockService(t *testing.T) {
+    ctx := context.Background()
+    files := newMockRepository()
+    require.NoError(t, files.Create(ctx, &models.File{ID: "file-1", FileName: testFileName}))
+
+    locks, err := service.NewLockService(newMockLockRepository(), files, 15*time.Minute, time.Hour)
+    require.NoError(t, err)
+
+    t.Run("LockAndCheckWrite", func(t *testing.T) {
+        lock, err := locks.Lock(ctx, "file-1", "alice", 0)
+        require.NoError(t, err)
+        assert.Equal(t, "alice", lock.OwnerID)
+        assert.WithinDuration(t, time.Now().Add(15*time.Minute), lock.ExpiresAt, time.Minute)
+
+        held, err := locks.Lock(ctx, "file-1", "bob", 0)
+        assert.ErrorIs(t, err, service.ErrFileLocked)
+        require.NotNil(t, held)
+        assert.Equal(t, "alice", held.OwnerID)
+
+        _, err = locks.CheckWrite(ctx, "file-1", "bob")
+        assert.ErrorIs(t, err, service.ErrFileLocked)
+        _, err = locks.CheckWrite(ctx, "file-1", "alice")
+        assert.NoError(t, err)
+    })
+
+    t.Run("Unlock", func(t *testing.T) {
+        assert.ErrorIs(t, locks.Unlock(ctx, "file-1", "bob", false), service.ErrFileLocked)
+        require.NoError(t, locks.Unlock(ctx, "file-1", "bob", true))
+        assert.ErrorIs(t, locks.Unlock(ctx, "file-1", "alice", false), service.ErrLockNotFound)
+
+        _, err := locks.CheckWrite(ctx, "file-1", "bob")
+        assert.NoError(t, err)
+    })
+
+    t.Run("InvalidRequests", func(t *testing.T) {
+        _, err := locks.Lock(ctx, "file-1", "alice", 2*time.Hour)
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+
+        _, err = locks.Lock(ctx, "missing", "alice", 0)
+        assert.ErrorIs(t, err, service.ErrFileNotFound)
+    })
+}
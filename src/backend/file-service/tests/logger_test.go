@@ -0,0 +1,64 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.uber.org/zap"
+    "go.uber.org/zap/zapcore"
+    "go.uber.org/zap/zaptest/observer"
+
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/tracing"
+)
+
+// newObservedLogger creates a logger recording its entries
+func newObservedLogger() (*logger.Logger, *observer.ObservedLogs) {
+    core, logs := observer.New(zapcore.DebugLevel)
+    return logger.New(zap.New(core)), logs
+}
+
+// TestLoggerFields tests that field helpers, With and Named shape entries
+func TestLoggerFields(t *testing.T) {
+    log, logs := newObservedLogger()
+
+    log.Named("storage").With(logger.String("bucket", "files")).Warn("Upload failed",
+        logger.Int64("size", 42),
+        logger.Error(errors.New("timeout")))
+
+    require.Equal(t, 1, logs.Len())
+    entry := logs.All()[0]
+    assert.Equal(t, zapcore.WarnLevel, entry.Level)
+    assert.Equal(t, "storage", entry.LoggerName)
+    assert.Equal(t, map[string]interface{}{
+        "bucket": "files",
+        "size":   int64(42),
+        "error":  "timeout",
+    }, entry.ContextMap())
+}
+
+// TestLoggerWithContext tests that request values in the context are logged
+func TestLoggerWithContext(t *testing.T) {
+    log, logs := newObservedLogger()
+
+    ctx := requestctx.WithRequestID(context.Background(), "req-1")
+    ctx = requestctx.WithTenant(ctx, "acme")
+    ctx = requestctx.WithPrincipal(ctx, &requestctx.Principal{UserID: "user-1"})
+    ctx = tracing.ContextWithTraceID(ctx, "4bf92f3577b34da6a3ce929d0e0e4736")
+
+    log.WithContext(ctx).Info("Handled request")
+    log.WithContext(context.Background()).Info("Started")
+
+    require.Equal(t, 2, logs.Len())
+    assert.Equal(t, map[string]interface{}{
+        "requestId": "req-1",
+        "traceId":   "4bf92f3577b34da6a3ce929d0e0e4736",
+        "userId":    "user-1",
+        "tenantId":  "acme",
+    }, logs.All()[0].ContextMap())
+    assert.Empty(t, logs.All()[1].Context)
+}
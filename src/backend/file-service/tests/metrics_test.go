@@ -0,0 +1,87 @@
+package tests
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+
+    "src/backend/file-service/pkg/metrics"
+)
+
+// TestPrometheusProvider tests that instruments record to the registry and
+// that creating an instrument twice shares its series
+func TestPrometheusProvider(t *testing.T) {
+    registry := prometheus.NewRegistry()
+    provider := metrics.NewPrometheus(registry)
+    opts := metrics.Opts{Name: "test_events_total", Help: "Test events", Labels: []string{"event"}}
+
+    provider.Counter(opts).Inc("created")
+    provider.Counter(opts).Add(2, "created")
+    assert.Equal(t, 3.0, gatheredValue(t, registry, "test_events_total", "created"))
+
+    gauge := provider.Gauge(metrics.Opts{Name: "test_queue_depth", Help: "Test queue depth"})
+    gauge.Set(4)
+    gauge.Add(-1)
+    assert.Equal(t, 3.0, gatheredValue(t, registry, "test_queue_depth"))
+
+    histogram := provider.Histogram(metrics.HistogramOpts{
+        Opts:    metrics.Opts{Name: "test_duration_seconds", Help: "Test durations"},
+        Buckets: metrics.ExponentialBuckets(0.1, 10, 3),
+    })
+    histogram.Observe(context.Background(), 0.5)
+    families, err := registry.Gather()
+    assert.NoError(t, err)
+    assert.Len(t, families, 3)
+}
+
+// TestNopProvider tests that a nil provider records nothing
+func TestNopProvider(t *testing.T) {
+    provider := metrics.OrNop(nil)
+    assert.Equal(t, metrics.Nop, provider)
+    assert.NotPanics(t, func() {
+        provider.Counter(metrics.Opts{Name: "ignored_total"}).Inc("label")
+        provider.Histogram(metrics.HistogramOpts{}).Observe(context.Background(), 1)
+    })
+}
+
+// TestTenantLabels tests that tenant-aware counters label the busiest
+// tenants and count the rest as other, and that other providers drop the
+// tenant ID
+func TestTenantLabels(t *testing.T) {
+    registry := prometheus.NewRegistry()
+    limiter := metrics.NewTenantLimiter(2, 50*time.Millisecond)
+    provider := metrics.WithTenants(metrics.NewPrometheus(registry), limiter)
+    counter := provider.Counter(metrics.Opts{
+        Name:   "test_tenant_events_total",
+        Help:   "Test events by tenant",
+        Labels: []string{"event"},
+        Tenant: true,
+    })
+
+    for _, tenantID := range []string{"tenant-1", "tenant-2", "tenant-3", "tenant-1", "tenant-3", "tenant-1", ""} {
+        counter.Inc("created", tenantID)
+    }
+    assert.Equal(t, 3.0, gatheredValue(t, registry, "test_tenant_events_total", "created", "tenant-1"))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "test_tenant_events_total", "created", "tenant-2"))
+    assert.Equal(t, 2.0, gatheredValue(t, registry, "test_tenant_events_total", "created", metrics.OtherTenant))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "test_tenant_events_total", "created", metrics.NoTenant))
+
+    // The busiest tenants of the previous window keep their labels
+    time.Sleep(60 * time.Millisecond)
+    counter.Add(2, "created", "tenant-3")
+    counter.Inc("created", "tenant-2")
+    assert.Equal(t, 2.0, gatheredValue(t, registry, "test_tenant_events_total", "created", "tenant-3"))
+    assert.Equal(t, 3.0, gatheredValue(t, registry, "test_tenant_events_total", "created", metrics.OtherTenant))
+
+    plain := prometheus.NewRegistry()
+    metrics.NewPrometheus(plain).Counter(metrics.Opts{
+        Name:   "test_tenant_events_total",
+        Help:   "Test events by tenant",
+        Labels: []string{"event"},
+        Tenant: true,
+    }).Inc("created", "tenant-1")
+    assert.Equal(t, 1.0, gatheredValue(t, plain, "test_tenant_events_total", "created"))
+}
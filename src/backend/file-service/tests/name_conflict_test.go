@@ -0,0 +1,118 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+)
+
+// TestNameConflicts tests the strategies for uploads onto a name already
+// taken in their folder
+func TestNameConflicts(t *testing.T) {
+    ctx := context.Background()
+    repo := newMockRepository()
+    mockStore := newMockStorage()
+    mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+        Run(func(args mock.Arguments) {
+            file := args.Get(1).(*models.File)
+            require.NoError(t, file.SetStoragePath(file.ID))
+        }).
+        Return(nil)
+    mockStore.On("Delete", ctx, mock.AnythingOfType("*models.File"), false).Return(nil)
+
+    locks, err := service.NewLockService(newMockLockRepository(), repo, 15*time.Minute, time.Hour)
+    require.NoError(t, err)
+    fileService, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    }, service.WithNameConflicts(service.NameConflictReject, locks))
+    require.NoError(t, err)
+
+    upload := func(folder, strategy string) (*models.File, error) {
+        return fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{
+                OwnerID:      "alice",
+                Folder:       folder,
+                NameConflict: strategy,
+            })
+    }
+
+    t.Run("Reject By Default", func(t *testing.T) {
+        original, err := upload("reject", "")
+        require.NoError(t, err)
+
+        _, err = upload("reject", "")
+        var conflict *service.NameConflictError
+        require.True(t, errors.As(err, &conflict))
+        assert.ErrorIs(t, err, service.ErrNameConflict)
+        assert.Equal(t, original.ID, conflict.Existing.ID)
+
+        // Other folders do not conflict
+        _, err = upload("reject/other", "")
+        assert.NoError(t, err)
+    })
+
+    t.Run("Keep", func(t *testing.T) {
+        first, err := upload("keep", service.NameConflictKeep)
+        require.NoError(t, err)
+        second, err := upload("keep", service.NameConflictKeep)
+        require.NoError(t, err)
+        assert.NotEqual(t, first.ID, second.ID)
+        assert.Equal(t, first.FileName, second.FileName)
+    })
+
+    t.Run("Rename", func(t *testing.T) {
+        _, err := upload("rename", "")
+        require.NoError(t, err)
+
+        renamed, err := upload("rename", service.NameConflictRename)
+        require.NoError(t, err)
+        assert.Equal(t, "test-document (1).pdf", renamed.FileName)
+
+        renamed, err = upload("rename", service.NameConflictRename)
+        require.NoError(t, err)
+        assert.Equal(t, "test-document (2).pdf", renamed.FileName)
+    })
+
+    t.Run("Overwrite", func(t *testing.T) {
+        original, err := upload("overwrite", "")
+        require.NoError(t, err)
+
+        overwritten, err := upload("overwrite", service.NameConflictOverwrite)
+        require.NoError(t, err)
+        assert.Equal(t, original.ID, overwritten.ID)
+        assert.Equal(t, original.Version+1, overwritten.Version)
+        assert.NotEqual(t, original.StoragePath, overwritten.StoragePath)
+
+        stored, err := repo.GetByID(ctx, original.ID)
+        require.NoError(t, err)
+        assert.Equal(t, overwritten.StoragePath, stored.StoragePath)
+        mockStore.AssertCalled(t, "Delete", ctx, mock.MatchedBy(func(file *models.File) bool {
+            return file.StoragePath == original.StoragePath
+        }), false)
+    })
+
+    t.Run("Overwrite Locked File", func(t *testing.T) {
+        original, err := upload("locked", "")
+        require.NoError(t, err)
+        _, err = locks.Lock(ctx, original.ID, "bob", 0)
+        require.NoError(t, err)
+
+        _, err = upload("locked", service.NameConflictOverwrite)
+        assert.ErrorIs(t, err, service.ErrFileLocked)
+    })
+
+    t.Run("Unknown Strategy", func(t *testing.T) {
+        _, err := upload("unknown", "replace")
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+    })
+}
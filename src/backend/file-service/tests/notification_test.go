@@ -0,0 +1,230 @@
+package tests
+
+import (
+    "context"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/notify"
+)
+
+// mockNotificationRepository is an in-memory NotificationRepository
+type mockNotificationRepository struct {
+    mu    sync.Mutex
+    prefs map[string]*models.NotificationPreferences
+}
+
+func newMockNotificationRepository() *mockNotificationRepository {
+    return &mockNotificationRepository{prefs: make(map[string]*models.NotificationPreferences)}
+}
+
+func (m *mockNotificationRepository) Get(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    prefs, ok := m.prefs[userID]
+    if !ok {
+        return nil, repository.ErrPreferencesNotFound
+    }
+    found := *prefs
+    return &found, nil
+}
+
+func (m *mockNotificationRepository) Save(ctx context.Context, prefs *models.NotificationPreferences) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if existing, ok := m.prefs[prefs.UserID]; ok {
+        prefs.LastDigestAt = existing.LastDigestAt
+    }
+    stored := *prefs
+    m.prefs[prefs.UserID] = &stored
+    return nil
+}
+
+func (m *mockNotificationRepository) ListDigestDue(ctx context.Context, before time.Time, limit int) ([]*models.NotificationPreferences, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var due []*models.NotificationPreferences
+    for _, prefs := range m.prefs {
+        if prefs.DigestEnabled && (prefs.LastDigestAt == nil || prefs.LastDigestAt.Before(before)) && len(due) < limit {
+            found := *prefs
+            due = append(due, &found)
+        }
+    }
+    return due, nil
+}
+
+func (m *mockNotificationRepository) MarkDigestSent(ctx context.Context, userID string, at time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    prefs, ok := m.prefs[userID]
+    if !ok {
+        return repository.ErrPreferencesNotFound
+    }
+    prefs.LastDigestAt = &at
+    return nil
+}
+
+// recordingNotifier records the messages it is asked to deliver
+type recordingNotifier struct {
+    mu   sync.Mutex
+    sent []notify.Message
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    n.sent = append(n.sent, msg)
+    return nil
+}
+
+func (n *recordingNotifier) messages() []notify.Message {
+    n.mu.Lock()
+    defer n.mu.Unlock()
+    return append([]notify.Message(nil), n.sent...)
+}
+
+// TestNotificationService tests notification preferences, share alerts and digests
+func TestNotificationService(t *testing.T) {
+    ctx := context.Background()
+    files := newMockRepository()
+    email := &recordingNotifier{}
+    hooks := &recordingNotifier{}
+
+    notifications, err := service.NewNotificationService(newMockNotificationRepository(), files, email, hooks, 24*time.Hour, time.Second)
+    require.NoError(t, err)
+
+    t.Run("DefaultPreferences", func(t *testing.T) {
+        prefs, err := notifications.GetPreferences(ctx, "alice")
+        require.NoError(t, err)
+        assert.Empty(t, prefs.ShareChannels)
+        assert.False(t, prefs.DigestEnabled)
+    })
+
+    t.Run("InvalidPreferences", func(t *testing.T) {
+        _, err := notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+            UserID:        "alice",
+            ShareChannels: []string{models.ChannelWebhook},
+        })
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+
+        _, err = notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+            UserID:     "alice",
+            WebhookURL: "http://example.com/hook",
+        })
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+
+        _, err = notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+            UserID:        "alice",
+            Email:         "alice@example.com",
+            DigestEnabled: true,
+            DigestFolders: []string{"../etc"},
+        })
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+    })
+
+    _, err = notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+        UserID:        "alice",
+        Email:         "alice@example.com",
+        WebhookURL:    "https://example.com/hook",
+        ShareChannels: []string{models.ChannelEmail, models.ChannelWebhook},
+        DigestEnabled: true,
+        DigestFolders: []string{"team/shared"},
+    })
+    require.NoError(t, err)
+
+    t.Run("NotifyShare", func(t *testing.T) {
+        err := notifications.NotifyShare(ctx, service.ShareEvent{
+            FileID:     "file-1",
+            FileName:   "report.pdf",
+            SharedBy:   "bob",
+            SharedWith: "alice",
+        })
+        require.NoError(t, err)
+
+        require.Len(t, email.messages(), 1)
+        assert.Equal(t, "alice@example.com", email.messages()[0].To)
+        assert.Contains(t, email.messages()[0].Subject, "report.pdf")
+
+        require.Len(t, hooks.messages(), 1)
+        assert.Equal(t, "https://example.com/hook", hooks.messages()[0].To)
+        assert.Equal(t, service.FileSharedEvent, hooks.messages()[0].Event)
+
+        // Users without preferences are not notified
+        require.NoError(t, notifications.NotifyShare(ctx, service.ShareEvent{FileID: "file-1", SharedWith: "carol"}))
+        assert.Len(t, email.messages(), 1)
+    })
+
+    t.Run("NotifyDownload", func(t *testing.T) {
+        _, err := notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+            UserID:           "dave",
+            WebhookURL:       "https://example.com/dave",
+            DownloadChannels: []string{models.ChannelWebhook},
+            DownloadFiles:    []string{"contract"},
+            DownloadFolders:  []string{"legal"},
+        })
+        require.NoError(t, err)
+
+        download := func(fileID, folder, by string) service.DownloadEvent {
+            return service.DownloadEvent{FileID: fileID, FileName: fileID + ".pdf", Folder: folder, OwnerID: "dave",
+                DownloadedBy: by, IP: "203.0.113.7", DownloadedAt: time.Now().UTC()}
+        }
+        sent := len(hooks.messages())
+        for _, event := range []service.DownloadEvent{
+            download("contract", "", "bob"),
+            download("nda", "legal/2024", "bob"),
+            download("memo", "legalese", "bob"),
+            download("nda", "legal", "dave"),
+        } {
+            require.NoError(t, notifications.NotifyDownload(ctx, event))
+        }
+
+        // Only the watched file and the file in a watched subfolder are
+        // reported, and not the owner's own download
+        messages := hooks.messages()[sent:]
+        require.Len(t, messages, 2)
+        assert.Equal(t, service.FileDownloadedEvent, messages[0].Event)
+        assert.Equal(t, "https://example.com/dave", messages[0].To)
+        assert.Contains(t, messages[0].Body, "bob")
+        assert.Contains(t, messages[0].Body, "203.0.113.7")
+        assert.Equal(t, "nda", messages[1].Data.(service.DownloadEvent).FileID)
+
+        _, err = notifications.UpdatePreferences(ctx, &models.NotificationPreferences{
+            UserID:           "dave",
+            DownloadChannels: []string{models.ChannelWebhook},
+        })
+        assert.ErrorIs(t, err, service.ErrInvalidInput)
+    })
+
+    t.Run("SendDigests", func(t *testing.T) {
+        recent := time.Now().UTC().Add(-time.Hour)
+        for _, file := range []*models.File{
+            {ID: "f1", FileName: "plan.pdf", Folder: "team/shared", OwnerID: "bob", CreatedAt: recent},
+            {ID: "f2", FileName: "mine.pdf", Folder: "team/shared", OwnerID: "alice", CreatedAt: recent},
+            {ID: "f3", FileName: "other.pdf", Folder: "team/private", OwnerID: "bob", CreatedAt: recent},
+        } {
+            require.NoError(t, files.Create(ctx, file))
+        }
+
+        sent, err := notifications.SendDigests(ctx)
+        require.NoError(t, err)
+        assert.Equal(t, 1, sent)
+
+        digest := email.messages()[len(email.messages())-1]
+        assert.Equal(t, "alice@example.com", digest.To)
+        assert.Contains(t, digest.Body, "plan.pdf")
+        assert.NotContains(t, digest.Body, "mine.pdf")
+        assert.NotContains(t, digest.Body, "other.pdf")
+
+        // The digest is not due again until the interval has passed
+        sent, err = notifications.SendDigests(ctx)
+        require.NoError(t, err)
+        assert.Zero(t, sent)
+    })
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/storage"
+)
+
+const (
+    testRedactAccessPoint  = "arn:aws:s3-object-lambda:us-west-2:123456789012:accesspoint/redact"
+    testConvertAccessPoint = "arn:aws:s3-object-lambda:us-west-2:123456789012:accesspoint/convert"
+)
+
+// TestObjectLambdaRoutes tests choosing the access point each tenant's downloads are read through
+func TestObjectLambdaRoutes(t *testing.T) {
+    t.Run("Unconfigured", func(t *testing.T) {
+        routes, err := storage.NewObjectLambdaRoutes("", nil)
+        require.NoError(t, err)
+        assert.Nil(t, routes)
+        assert.Empty(t, routes.AccessPointFor("tenant-a"))
+    })
+
+    t.Run("Per Tenant", func(t *testing.T) {
+        routes, err := storage.NewObjectLambdaRoutes(testRedactAccessPoint, map[string]string{
+            "tenant-b": testConvertAccessPoint,
+            "tenant-c": storage.ObjectLambdaNone,
+        })
+        require.NoError(t, err)
+        assert.Equal(t, testRedactAccessPoint, routes.AccessPointFor("tenant-a"))
+        assert.Equal(t, testRedactAccessPoint, routes.AccessPointFor(""))
+        assert.Equal(t, testConvertAccessPoint, routes.AccessPointFor("tenant-b"))
+        assert.Empty(t, routes.AccessPointFor("tenant-c"))
+    })
+
+    t.Run("Tenants Only", func(t *testing.T) {
+        routes, err := storage.NewObjectLambdaRoutes("", map[string]string{"tenant-b": testConvertAccessPoint})
+        require.NoError(t, err)
+        assert.Empty(t, routes.AccessPointFor("tenant-a"))
+        assert.Equal(t, testConvertAccessPoint, routes.AccessPointFor("tenant-b"))
+    })
+
+    t.Run("Invalid Access Point", func(t *testing.T) {
+        _, err := storage.NewObjectLambdaRoutes("arn:aws:s3:us-west-2:123456789012:accesspoint/files", nil)
+        assert.Error(t, err)
+
+        _, err = storage.NewObjectLambdaRoutes("", map[string]string{"tenant-b": "redact"})
+        assert.Error(t, err)
+    })
+}
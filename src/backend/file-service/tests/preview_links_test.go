@@ -0,0 +1,118 @@
+package tests
+
+import (
+    "context"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+)
+
+// mockNonceRepository records used nonces in memory
+type mockNonceRepository struct {
+    mu     sync.Mutex
+    nonces map[string]time.Time
+}
+
+func (m *mockNonceRepository) Use(ctx context.Context, scope, nonce string, expiresAt time.Time) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if _, ok := m.nonces[scope+"/"+nonce]; ok {
+        return repository.ErrNonceUsed
+    }
+    m.nonces[scope+"/"+nonce] = expiresAt
+    return nil
+}
+
+func (m *mockNonceRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var deleted int64
+    for key, expiresAt := range m.nonces {
+        if !now.Before(expiresAt) {
+            delete(m.nonces, key)
+            deleted++
+        }
+    }
+    return deleted, nil
+}
+
+// TestPreviewLinks tests issuing and verifying preview capability tokens
+func TestPreviewLinks(t *testing.T) {
+    secret := []byte(strings.Repeat("s", 32))
+
+    t.Run("Round Trip", func(t *testing.T) {
+        links, err := service.NewPreviewLinks(secret, time.Minute)
+        require.NoError(t, err)
+
+        token, expiresAt, err := links.Issue("file-1")
+        require.NoError(t, err)
+        assert.True(t, expiresAt.After(time.Now()))
+        assert.NoError(t, links.Verify("file-1", token))
+    })
+
+    t.Run("Bound To File", func(t *testing.T) {
+        links, err := service.NewPreviewLinks(secret, time.Minute)
+        require.NoError(t, err)
+
+        token, _, err := links.Issue("file-1")
+        require.NoError(t, err)
+        assert.ErrorIs(t, links.Verify("file-2", token), service.ErrInvalidPreviewToken)
+    })
+
+    t.Run("Tampered Token", func(t *testing.T) {
+        links, err := service.NewPreviewLinks(secret, time.Minute)
+        require.NoError(t, err)
+
+        token, _, err := links.Issue("file-1")
+        require.NoError(t, err)
+        _, signature, _ := strings.Cut(token, ".")
+        forged := "9999999999." + signature
+        assert.ErrorIs(t, links.Verify("file-1", forged), service.ErrInvalidPreviewToken)
+        assert.ErrorIs(t, links.Verify("file-1", "garbage"), service.ErrInvalidPreviewToken)
+    })
+
+    t.Run("Expired Token", func(t *testing.T) {
+        links, err := service.NewPreviewLinks(secret, time.Nanosecond)
+        require.NoError(t, err)
+
+        token, _, err := links.Issue("file-1")
+        require.NoError(t, err)
+        assert.ErrorIs(t, links.Verify("file-1", token), service.ErrInvalidPreviewToken)
+    })
+
+    t.Run("Single Use", func(t *testing.T) {
+        links, err := service.NewPreviewLinks(secret, time.Minute)
+        require.NoError(t, err)
+        ctx := context.Background()
+
+        token, _, err := links.Issue("file-1")
+        require.NoError(t, err)
+        assert.NoError(t, links.Redeem(ctx, "file-1", token))
+        assert.NoError(t, links.Redeem(ctx, "file-1", token), "tokens are reusable without a replay guard")
+
+        guard, err := service.NewReplayGuard(&mockNonceRepository{nonces: make(map[string]time.Time)})
+        require.NoError(t, err)
+        links.UseReplayGuard(guard)
+
+        token, _, err = links.Issue("file-1")
+        require.NoError(t, err)
+        other, _, err := links.Issue("file-1")
+        require.NoError(t, err)
+        assert.NoError(t, links.Redeem(ctx, "file-1", token))
+        assert.ErrorIs(t, links.Redeem(ctx, "file-1", token), service.ErrTokenReplayed)
+        assert.NoError(t, links.Redeem(ctx, "file-1", other))
+        assert.ErrorIs(t, links.Redeem(ctx, "file-2", other), service.ErrInvalidPreviewToken)
+    })
+
+    t.Run("Short Secret", func(t *testing.T) {
+        _, err := service.NewPreviewLinks([]byte("short"), time.Minute)
+        assert.Error(t, err)
+    })
+}
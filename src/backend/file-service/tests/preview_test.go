@@ -0,0 +1,159 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "image/png"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/convert"
+    "src/backend/file-service/pkg/metrics"
+)
+
+// pageRenderer renders the first page of PDFs as a fixed PNG
+type pageRenderer struct {
+    page []byte
+}
+
+func (c *pageRenderer) Converts(from, to string) bool {
+    return from == "application/pdf" && to == "image/png"
+}
+
+func (c *pageRenderer) Convert(ctx context.Context, from, to string, content []byte) ([]byte, error) {
+    return c.page, nil
+}
+
+// TestDerivedPreviews tests previewing images and PDFs, which have no
+// sanitized copy, by their thumbnail and first page through preview links
+func TestDerivedPreviews(t *testing.T) {
+    ctx := context.Background()
+    page := testPNG(t, 40, 60)
+    content := &contentStorage{content: map[string][]byte{
+        "image-1": testPNG(t, 2048, 1024),
+        "doc-1":   []byte("%PDF-1.7\n"),
+        "text-1":  []byte("notes"),
+    }}
+    repo := newMockRepository()
+    for id, contentType := range map[string]string{"image-1": "image/png", "doc-1": "application/pdf", "text-1": "text/plain"} {
+        require.NoError(t, repo.Create(ctx, &models.File{
+            ID:          id,
+            FileName:    id,
+            ContentType: contentType,
+            Size:        int64(len(content.content[id])),
+            Status:      models.FileStatusUploaded,
+            Checksum:    "v1",
+            CreatedAt:   time.Now().UTC(),
+        }))
+    }
+    fileService, err := service.NewFileService(content, repo, service.WorkerPoolConfig{})
+    require.NoError(t, err)
+
+    pipeline, err := convert.NewPipeline(time.Minute, &pageRenderer{page: page})
+    require.NoError(t, err)
+    derived, err := service.NewDerivedObjectService(newMockDerivedObjectRepository(), repo, content,
+        &memoryObjectStore{objects: make(map[string][]byte)}, 1024*1024,
+        service.NewThumbnailGenerator(), service.NewConversionGenerator(pipeline))
+    require.NoError(t, err)
+    links, err := service.NewPreviewLinks([]byte("preview-secret-preview-secret-32"), time.Minute)
+    require.NoError(t, err)
+    policy := handlers.DownloadSecurityPolicy{InlinePreviewEnabled: true, PreviewCSP: "default-src 'none'; img-src 'self'; sandbox"}
+
+    // preview redeems a preview link for the file
+    preview := func(t *testing.T, previews *handlers.PreviewHandler, fileID string) *httptest.ResponseRecorder {
+        rec := httptest.NewRecorder()
+        previews.FilePreviewHandler(rec, asUser(httptest.NewRequest(http.MethodGet, "/files/"+fileID+"/preview?format=url", nil), "user-1"))
+        require.Equal(t, http.StatusOK, rec.Code)
+        var link struct {
+            URL string `json:"url"`
+        }
+        require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &link))
+
+        rec = httptest.NewRecorder()
+        previews.PreviewContentHandler(rec, httptest.NewRequest(http.MethodGet, link.URL, nil))
+        return rec
+    }
+
+    previews := handlers.NewPreviewHandler(fileService, derived, links, policy, "'self'", nil, nil)
+
+    t.Run("Image", func(t *testing.T) {
+        rec := preview(t, previews, "image-1")
+        require.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+        assert.Equal(t, "no-referrer", rec.Header().Get("Referrer-Policy"))
+        img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+        require.NoError(t, err)
+        assert.Equal(t, service.PreviewSize, img.Bounds().Dx())
+        assert.Equal(t, service.PreviewSize/2, img.Bounds().Dy())
+    })
+
+    t.Run("PDF", func(t *testing.T) {
+        rec := preview(t, previews, "doc-1")
+        require.Equal(t, http.StatusOK, rec.Code)
+        assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+        assert.Equal(t, page, rec.Body.Bytes())
+    })
+
+    t.Run("Not Previewable", func(t *testing.T) {
+        rec := preview(t, previews, "text-1")
+        assert.Equal(t, http.StatusNotFound, rec.Code)
+    })
+
+    t.Run("Without Derived Objects", func(t *testing.T) {
+        previews := handlers.NewPreviewHandler(fileService, nil, links, policy, "'self'", nil, nil)
+        rec := preview(t, previews, "image-1")
+        assert.Equal(t, http.StatusNotFound, rec.Code)
+    })
+
+    t.Run("Thumbnail Srcset", func(t *testing.T) {
+        files := handlers.NewFileHandler(fileService, metrics.NewPrometheus(prometheus.NewRegistry()), policy,
+            nil, nil, nil, nil, nil, nil, derived, nil, nil, nil, links)
+        // srcset returns the thumbnail srcset of the file's metadata
+        srcset := func(t *testing.T, fileID string) string {
+            rec := httptest.NewRecorder()
+            files.FileStatusHandler(rec, asUser(httptest.NewRequest(http.MethodGet, "/files/"+fileID, nil), "user-1"))
+            require.Equal(t, http.StatusOK, rec.Code)
+            var metadata struct {
+                ThumbnailSrcset string `json:"thumbnailSrcset"`
+            }
+            require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metadata))
+            return metadata.ThumbnailSrcset
+        }
+
+        candidates := strings.Split(srcset(t, "image-1"), ", ")
+        require.Len(t, candidates, 3)
+        for i, want := range []struct {
+            density string
+            width   int
+        }{{"1x", 128}, {"2x", 256}, {"4x", 512}} {
+            candidate, density, _ := strings.Cut(candidates[i], " ")
+            assert.Equal(t, want.density, density)
+
+            // Candidates are preview links, loaded without credentials
+            rec := httptest.NewRecorder()
+            previews.PreviewContentHandler(rec, httptest.NewRequest(http.MethodGet, candidate, nil))
+            require.Equal(t, http.StatusOK, rec.Code)
+            img, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+            require.NoError(t, err)
+            assert.Equal(t, want.width, img.Bounds().Dx())
+            assert.Equal(t, want.width/2, img.Bounds().Dy())
+        }
+
+        assert.Empty(t, srcset(t, "text-1"))
+
+        rec := httptest.NewRecorder()
+        previews.FilePreviewHandler(rec, asUser(httptest.NewRequest(http.MethodGet, "/files/image-1/preview?size=100", nil), "user-1"))
+        assert.Equal(t, http.StatusBadRequest, rec.Code)
+    })
+}
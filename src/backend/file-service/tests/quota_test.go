@@ -0,0 +1,83 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/service"
+)
+
+// channelSender records sent events on a channel
+type channelSender chan service.QuotaEvent
+
+func (c channelSender) Send(ctx context.Context, eventType string, data interface{}) error {
+    c <- data.(service.QuotaEvent)
+    return nil
+}
+
+// TestQuota tests quota enforcement and threshold events
+func TestQuota(t *testing.T) {
+    ctx := context.Background()
+    mockStore := newMockStorage()
+    repo := newMockRepository()
+    events := make(channelSender, 10)
+
+    tracker, err := service.NewQuotaTracker(repo, 4*testFileSize, []int{100, 80, 95}, events)
+    require.NoError(t, err)
+
+    fileService, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    }, service.WithQuota(tracker))
+    require.NoError(t, err)
+
+    mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+        Return(nil)
+
+    upload := func(size int64) error {
+        content := testPDFContent()[:size]
+        _, err := fileService.Upload(ctx, testFileName, testContentType, size,
+            bytes.NewReader(content), service.UploadOptions{OwnerID: "user-1"})
+        return err
+    }
+
+    t.Run("Threshold Events", func(t *testing.T) {
+        // 2.5MB of 4MB crosses no threshold, 3.5MB crosses 80%
+        require.NoError(t, upload(testFileSize))
+        require.NoError(t, upload(testFileSize))
+        require.NoError(t, upload(testFileSize/2))
+        assert.Empty(t, events)
+
+        require.NoError(t, upload(testFileSize))
+
+        select {
+        case event := <-events:
+            assert.Equal(t, "user-1", event.OwnerID)
+            assert.Equal(t, 80, event.Threshold)
+        case <-time.After(time.Second):
+            t.Fatal("expected a quota event")
+        }
+
+        usage, err := tracker.Usage(ctx, "user-1")
+        require.NoError(t, err)
+        assert.Equal(t, int64(testFileSize/2), usage.Remaining())
+    })
+
+    t.Run("Quota Exceeded", func(t *testing.T) {
+        err := upload(testFileSize)
+        assert.True(t, errors.Is(err, service.ErrQuotaExceeded))
+    })
+
+    t.Run("Unowned Uploads Are Not Charged", func(t *testing.T) {
+        _, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{})
+        require.NoError(t, err)
+    })
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "path"
+    "sort"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// fakeInventory is a bucket of objects by key
+type fakeInventory struct {
+    mu      sync.Mutex
+    objects map[string]time.Time
+}
+
+func (f *fakeInventory) ListObjects(ctx context.Context, startAfter string, limit int) ([]storage.StoredObject, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    var objects []storage.StoredObject
+    for key, modified := range f.objects {
+        if key > startAfter {
+            objects = append(objects, storage.StoredObject{Key: key, LastModified: modified})
+        }
+    }
+    sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+    if len(objects) > limit {
+        objects = objects[:limit]
+    }
+    return objects, nil
+}
+
+func (f *fakeInventory) StatUpload(ctx context.Context, file *models.File) (*storage.UploadedObject, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if _, ok := f.objects[file.StoragePath]; !ok {
+        return nil, storage.ErrObjectNotFound
+    }
+    return &storage.UploadedObject{}, nil
+}
+
+func (f *fakeInventory) QuarantineObject(ctx context.Context, key string) (string, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    quarantined := path.Join(storagekey.Quarantine, key)
+    f.objects[quarantined] = f.objects[key]
+    delete(f.objects, key)
+    return quarantined, nil
+}
+
+func (f *fakeInventory) DeleteObject(ctx context.Context, key string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if _, ok := f.objects[key]; !ok {
+        return errors.New("NoSuchKey")
+    }
+    delete(f.objects, key)
+    return nil
+}
+
+// reconcileFixture stores files sharing content, a file whose object is
+// missing, objects no file references, and a recent upload of each kind
+func reconcileFixture() (*mockRepository, *fakeInventory) {
+    old := time.Now().Add(-48 * time.Hour)
+    recent := time.Now()
+    repo := newMockRepository()
+    for _, file := range []*models.File{
+        {ID: "aabbcc01", StoragePath: storage.StorageKey("aabbcc01"), Status: models.FileStatusUploaded, UpdatedAt: old},
+        {ID: "aabbcc02", StoragePath: storage.StorageKey("aabbcc01"), Status: models.FileStatusUploaded, UpdatedAt: old},
+        {ID: "ddeeff01", StoragePath: storage.StorageKey("ddeeff01"), Status: models.FileStatusUploaded, UpdatedAt: old},
+        {ID: "ddeeff02", StoragePath: storage.StorageKey("ddeeff02"), Status: models.FileStatusUploaded, UpdatedAt: recent},
+        {ID: "ddeeff03", StoragePath: storage.StorageKey("ddeeff03"), Status: models.FileStatusPending, UpdatedAt: old},
+    } {
+        repo.files[file.ID] = file
+    }
+    inventory := &fakeInventory{objects: map[string]time.Time{
+        storage.StorageKey("aabbcc01"): old,
+        storage.StorageKey("99887766"): old,
+        storage.StorageKey("11223344"): recent,
+        "derived/aa/bb/aabbcc01/thumb": old,
+        "audit/batch-1.json":           old,
+    }}
+    return repo, inventory
+}
+
+// TestReconcileDryRun tests that reconciliation reports orphaned objects
+// and dangling files without changing anything
+func TestReconcileDryRun(t *testing.T) {
+    repo, inventory := reconcileFixture()
+    registry := prometheus.NewRegistry()
+    reconciler, err := service.NewReconciler(repo, inventory, service.ReconcileOptions{
+        Mode:       service.ReconcileDryRun,
+        MinAge:     time.Hour,
+        Exclude:    []string{"audit/"},
+        BatchSize:  2,
+        MaxActions: 10,
+        Metrics:    metrics.NewPrometheus(registry),
+    })
+    require.NoError(t, err)
+
+    result, err := reconciler.Reconcile(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, []string{storage.StorageKey("99887766")}, result.OrphanedObjects)
+    assert.Equal(t, []string{"ddeeff01"}, result.DanglingFiles)
+    assert.Equal(t, 3, result.ObjectsScanned)
+    assert.Equal(t, 5, result.FilesScanned)
+    assert.Zero(t, result.Resolved)
+
+    assert.Len(t, inventory.objects, 5)
+    assert.Equal(t, models.FileStatusUploaded, repo.files["ddeeff01"].Status)
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "reconcile_findings_total", "orphaned_object", "reported"))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "reconcile_findings_total", "dangling_file", "reported"))
+}
+
+// TestReconcileResolve tests quarantining and cleaning findings
+func TestReconcileResolve(t *testing.T) {
+    repo, inventory := reconcileFixture()
+    reconciler, err := service.NewReconciler(repo, inventory, service.ReconcileOptions{
+        Mode:       service.ReconcileQuarantine,
+        MinAge:     time.Hour,
+        Exclude:    []string{"audit/"},
+        BatchSize:  100,
+        MaxActions: 10,
+    })
+    require.NoError(t, err)
+
+    result, err := reconciler.Reconcile(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 2, result.Resolved)
+    assert.Empty(t, result.Failed)
+    assert.Contains(t, inventory.objects, "quarantine/"+storage.StorageKey("99887766"))
+    assert.NotContains(t, inventory.objects, storage.StorageKey("99887766"))
+    assert.Equal(t, models.FileStatusCorrupted, repo.files["ddeeff01"].Status)
+
+    // Quarantined objects are not reconciled again
+    result, err = reconciler.Reconcile(context.Background())
+    require.NoError(t, err)
+    assert.Zero(t, result.Orphaned)
+    assert.Zero(t, result.Dangling)
+
+    repo, inventory = reconcileFixture()
+    reconciler, err = service.NewReconciler(repo, inventory, service.ReconcileOptions{
+        Mode:       service.ReconcileClean,
+        MinAge:     time.Hour,
+        Exclude:    []string{"audit/"},
+        BatchSize:  100,
+        MaxActions: 1,
+    })
+    require.NoError(t, err)
+
+    result, err = reconciler.Reconcile(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 1, result.Resolved)
+    assert.NotContains(t, inventory.objects, storage.StorageKey("99887766"))
+    // The dangling file is over the action budget and left for the next run
+    assert.Equal(t, []string{"ddeeff01"}, result.DanglingFiles)
+    assert.False(t, repo.files["ddeeff01"].IsDeleted())
+
+    _, err = service.NewReconciler(repo, inventory, service.ReconcileOptions{Mode: "purge", BatchSize: 100})
+    assert.Error(t, err)
+}
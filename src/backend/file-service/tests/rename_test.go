@@ -0,0 +1,73 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/service"
+)
+
+// TestRenameAndMove tests versioned rename and move of logical file names
+func TestRenameAndMove(t *testing.T) {
+    ctx := context.Background()
+    mockStore := newMockStorage()
+    fileService, err := service.NewFileService(mockStore, newMockRepository(), service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    })
+    require.NoError(t, err)
+
+    mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+        Return(nil)
+
+    file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+        bytes.NewReader(testPDFContent()), service.UploadOptions{})
+    require.NoError(t, err)
+    require.Equal(t, int64(1), file.Version)
+
+    t.Run("Rename", func(t *testing.T) {
+        renamed, err := fileService.Rename(ctx, file.ID, "renamed.pdf", 1)
+        require.NoError(t, err)
+        assert.Equal(t, "renamed.pdf", renamed.FileName)
+        assert.Equal(t, int64(2), renamed.Version)
+        assert.Equal(t, file.StoragePath, renamed.StoragePath)
+    })
+
+    t.Run("Stale Version", func(t *testing.T) {
+        _, err := fileService.Rename(ctx, file.ID, "other.pdf", 1)
+        assert.True(t, errors.Is(err, service.ErrVersionConflict))
+
+        _, err = fileService.Move(ctx, file.ID, "reports", 1)
+        assert.True(t, errors.Is(err, service.ErrVersionConflict))
+    })
+
+    t.Run("Move", func(t *testing.T) {
+        moved, err := fileService.Move(ctx, file.ID, "reports/2024", 2)
+        require.NoError(t, err)
+        assert.Equal(t, "reports/2024", moved.Folder)
+        assert.Equal(t, "renamed.pdf", moved.FileName)
+        assert.Equal(t, int64(3), moved.Version)
+    })
+
+    t.Run("Invalid Names", func(t *testing.T) {
+        _, err := fileService.Rename(ctx, file.ID, "../escape.pdf", 3)
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+
+        _, err = fileService.Move(ctx, file.ID, "reports//2024", 3)
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+
+        _, err = fileService.Move(ctx, file.ID, "/reports", 3)
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+    })
+
+    t.Run("Missing File", func(t *testing.T) {
+        _, err := fileService.Rename(ctx, "missing", "renamed.pdf", 1)
+        assert.True(t, errors.Is(err, service.ErrFileNotFound))
+    })
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+)
+
+// fakeReplica records copied keys and fails those listed in failing
+type fakeReplica struct {
+    copied  []string
+    failing map[string]bool
+}
+
+func (r *fakeReplica) Replicate(ctx context.Context, key string) error {
+    if r.failing[key] {
+        return errors.New("copy failed")
+    }
+    r.copied = append(r.copied, key)
+    return nil
+}
+
+// TestReplicatePending tests copying uploaded files to the secondary bucket
+func TestReplicatePending(t *testing.T) {
+    repo := newMockRepository()
+    created := time.Now().Add(-time.Hour)
+    for i, id := range []string{"file-ok", "file-broken", "file-draft"} {
+        status := models.FileStatusUploaded
+        if id == "file-draft" {
+            status = models.FileStatusDraft
+        }
+        repo.files[id] = &models.File{
+            ID:          id,
+            Status:      status,
+            StoragePath: "files/" + id,
+            CreatedAt:   created.Add(time.Duration(i) * time.Minute),
+        }
+    }
+
+    replica := &fakeReplica{failing: map[string]bool{"files/file-broken": true}}
+    replication, err := service.NewReplicationService(repo, replica, service.ReplicationOptions{
+        BatchSize:   10,
+        MaxAttempts: 2,
+    })
+    require.NoError(t, err)
+
+    ctx := context.Background()
+    count, err := replication.ReplicatePending(ctx)
+    require.NoError(t, err)
+    assert.Equal(t, 1, count)
+    assert.Equal(t, []string{"files/file-ok"}, replica.copied)
+
+    ok := repo.files["file-ok"]
+    assert.Equal(t, models.ReplicationReplicated, ok.ReplicationStatus)
+    assert.NotNil(t, ok.ReplicatedAt)
+
+    broken := repo.files["file-broken"]
+    assert.Equal(t, models.ReplicationPending, broken.ReplicationStatus)
+    assert.Equal(t, 1, broken.ReplicationAttempts)
+    assert.Empty(t, repo.files["file-draft"].ReplicationStatus)
+
+    // The second failure exhausts the attempts and stops retrying
+    count, err = replication.ReplicatePending(ctx)
+    require.NoError(t, err)
+    assert.Equal(t, 0, count)
+    assert.Equal(t, models.ReplicationFailed, broken.ReplicationStatus)
+    assert.Equal(t, 2, broken.ReplicationAttempts)
+
+    _, err = service.NewReplicationService(repo, replica, service.ReplicationOptions{})
+    assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// TestRequestContext tests the identity and metadata carried by request contexts
+func TestRequestContext(t *testing.T) {
+    t.Run("Principal From Claims", func(t *testing.T) {
+        ctx := middleware.ContextWithClaims(context.Background(), &middleware.Claims{
+            UserID:   "user-1",
+            Email:    "user@example.com",
+            Roles:    []string{"reader"},
+            TenantID: "acme",
+        })
+
+        principal, ok := requestctx.PrincipalFrom(ctx)
+        assert.True(t, ok)
+        assert.Equal(t, "user@example.com", principal.Email)
+        assert.Equal(t, "user-1", requestctx.UserID(ctx))
+        assert.Equal(t, []string{"reader"}, requestctx.Roles(ctx))
+        assert.True(t, requestctx.HasRole(ctx, "writer", "reader"))
+        assert.False(t, requestctx.HasRole(ctx, middleware.AdminRole))
+        assert.Equal(t, "acme", requestctx.Tenant(ctx))
+        assert.Equal(t, "globex", requestctx.Tenant(requestctx.WithTenant(ctx, "globex")))
+    })
+
+    t.Run("Anonymous", func(t *testing.T) {
+        ctx := context.Background()
+        _, ok := requestctx.PrincipalFrom(ctx)
+        assert.False(t, ok)
+        assert.Empty(t, requestctx.UserID(ctx))
+        assert.Empty(t, requestctx.Tenant(ctx))
+        assert.False(t, requestctx.HasRole(ctx, "reader"))
+    })
+
+    t.Run("Request Metadata", func(t *testing.T) {
+        var requestID, clientIP string
+        handler := requestctx.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            requestID = requestctx.RequestID(r.Context())
+            clientIP = requestctx.ClientIP(r.Context())
+        }))
+        serve := func(header string) *httptest.ResponseRecorder {
+            req := httptest.NewRequest(http.MethodGet, "/files", nil)
+            req.RemoteAddr = "203.0.113.7:51234"
+            if header != "" {
+                req.Header.Set(requestctx.RequestIDHeader, header)
+            }
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, req)
+            return rec
+        }
+
+        rec := serve("req-42")
+        assert.Equal(t, "req-42", requestID)
+        assert.Equal(t, "req-42", rec.Header().Get(requestctx.RequestIDHeader))
+        assert.Equal(t, "203.0.113.7", clientIP)
+
+        for _, header := range []string{"", "has space", strings.Repeat("x", 200)} {
+            rec := serve(header)
+            assert.NotEmpty(t, requestID)
+            assert.NotEqual(t, header, requestID)
+            assert.Equal(t, requestID, rec.Header().Get(requestctx.RequestIDHeader))
+        }
+    })
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+)
+
+// TestIsRetained tests that retention lasts until its date and legal holds
+// until released
+func TestIsRetained(t *testing.T) {
+    now := time.Now()
+    until := now.Add(time.Hour)
+
+    assert.False(t, (&models.File{}).IsRetained(now))
+    assert.True(t, (&models.File{RetentionMode: "COMPLIANCE", RetainUntil: &until}).IsRetained(now))
+    assert.False(t, (&models.File{RetentionMode: "COMPLIANCE", RetainUntil: &until}).IsRetained(until.Add(time.Second)))
+    assert.True(t, (&models.File{LegalHold: true}).IsRetained(until.Add(time.Second)))
+}
+
+// TestDeleteRetainedFile tests that retained files can be archived but not
+// hard deleted
+func TestDeleteRetainedFile(t *testing.T) {
+    ctx := context.Background()
+    mockStore := newMockStorage()
+    repo := newMockRepository()
+    fileService, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    })
+    require.NoError(t, err)
+
+    mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+        Run(func(args mock.Arguments) {
+            file := args.Get(1).(*models.File)
+            until := time.Now().Add(24 * time.Hour)
+            file.RetentionMode = "COMPLIANCE"
+            file.RetainUntil = &until
+        }).
+        Return(nil)
+    mockStore.On("Delete", ctx, mock.AnythingOfType("*models.File"), true).Return(nil)
+
+    file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+        bytes.NewReader(testPDFContent()), service.UploadOptions{})
+    require.NoError(t, err)
+    require.NotNil(t, file.RetainUntil)
+
+    err = fileService.Delete(ctx, file.ID, false)
+    assert.True(t, errors.Is(err, service.ErrFileRetained))
+    mockStore.AssertNotCalled(t, "Delete", ctx, mock.Anything, false)
+
+    require.NoError(t, fileService.Delete(ctx, file.ID, true))
+    _, err = fileService.Stat(ctx, file.ID)
+    assert.True(t, errors.Is(err, service.ErrFileNotFound))
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+
+    "src/backend/file-service/internal/handlers"
+)
+
+// TestRetryHints tests the retry metadata added to error responses
+func TestRetryHints(t *testing.T) {
+    draining := false
+    hints := handlers.RetryHints(1500*time.Millisecond, func() bool { return draining })
+    respond := func(status int) http.Handler {
+        return hints(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            w.WriteHeader(status)
+        }))
+    }
+
+    t.Run("Idempotent Server Error", func(t *testing.T) {
+        rec := httptest.NewRecorder()
+        respond(http.StatusInternalServerError).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?id=1", nil))
+        assert.Equal(t, http.StatusInternalServerError, rec.Code)
+        assert.Equal(t, "true", rec.Header().Get("X-Retryable"))
+        assert.Equal(t, "true", rec.Header().Get("X-Idempotent"))
+        assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+    })
+
+    t.Run("Non-Idempotent Server Error", func(t *testing.T) {
+        rec := httptest.NewRecorder()
+        respond(http.StatusInternalServerError).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", nil))
+        assert.Equal(t, "false", rec.Header().Get("X-Retryable"))
+        assert.Equal(t, "false", rec.Header().Get("X-Idempotent"))
+        assert.Empty(t, rec.Header().Get("Retry-After"))
+    })
+
+    t.Run("Rejections Are Retryable", func(t *testing.T) {
+        rec := httptest.NewRecorder()
+        respond(http.StatusServiceUnavailable).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", nil))
+        assert.Equal(t, "true", rec.Header().Get("X-Retryable"))
+        assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+    })
+
+    t.Run("Client Errors Untouched", func(t *testing.T) {
+        rec := httptest.NewRecorder()
+        respond(http.StatusNotFound).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?id=1", nil))
+        assert.Empty(t, rec.Header().Get("X-Retryable"))
+        assert.Empty(t, rec.Header().Get("Retry-After"))
+    })
+
+    t.Run("Client Went Away", func(t *testing.T) {
+        ctx, cancel := context.WithCancel(context.Background())
+        cancel()
+        rec := httptest.NewRecorder()
+        req := httptest.NewRequest(http.MethodGet, "/download?id=1", nil).WithContext(ctx)
+        respond(http.StatusInternalServerError).ServeHTTP(rec, req)
+        assert.Equal(t, 499, rec.Code)
+    })
+
+    t.Run("Draining", func(t *testing.T) {
+        draining = true
+        defer func() { draining = false }()
+        rec := httptest.NewRecorder()
+        respond(http.StatusOK).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download?id=1", nil))
+        assert.Equal(t, "true", rec.Header().Get("X-Envoy-Immediate-Health-Check-Fail"))
+    })
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/sanitizer"
+)
+
+// TestSanitizer tests that previewable markup is stripped of active content
+func TestSanitizer(t *testing.T) {
+    s := sanitizer.New()
+
+    t.Run("Supported Types", func(t *testing.T) {
+        assert.True(t, s.Supports("text/html; charset=utf-8"))
+        assert.True(t, s.Supports("image/svg+xml"))
+        assert.False(t, s.Supports("application/pdf"))
+    })
+
+    t.Run("HTML", func(t *testing.T) {
+        out, err := s.Sanitize("text/html", strings.NewReader(
+            `<p onclick="steal()">hi <a href="http://evil.example">x</a><script>alert(1)</script></p>`))
+        require.NoError(t, err)
+
+        html := string(out)
+        assert.Contains(t, html, "hi")
+        assert.NotContains(t, html, "onclick")
+        assert.NotContains(t, html, "script")
+        assert.NotContains(t, html, "evil.example")
+    })
+
+    t.Run("SVG", func(t *testing.T) {
+        out, err := s.Sanitize("image/svg+xml", strings.NewReader(
+            `<svg xmlns="http://www.w3.org/2000/svg" onload="steal()">`+
+                `<script>alert(1)</script>`+
+                `<rect width="10" height="10" fill="url(#grad)"/>`+
+                `<image href="https://evil.example/x.png"/>`+
+                `<foreignObject><div>html</div></foreignObject>`+
+                `</svg>`))
+        require.NoError(t, err)
+
+        svg := string(out)
+        assert.Contains(t, svg, `<rect width="10" height="10" fill="url(#grad)">`)
+        assert.NotContains(t, svg, "onload")
+        assert.NotContains(t, svg, "script")
+        assert.NotContains(t, svg, "evil.example")
+        assert.NotContains(t, svg, "foreignObject")
+    })
+
+    t.Run("Malformed SVG", func(t *testing.T) {
+        _, err := s.Sanitize("image/svg+xml", strings.NewReader(`<svg><g></svg>`))
+        assert.Error(t, err)
+    })
+
+    t.Run("Unsupported Type", func(t *testing.T) {
+        _, err := s.Sanitize("application/pdf", strings.NewReader("%PDF"))
+        assert.ErrorIs(t, err, sanitizer.ErrUnsupportedType)
+    })
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "io"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/metrics"
+)
+
+// integrityStorage serves file content and the checksums S3 recorded, by
+// file ID
+type integrityStorage struct {
+    content   map[string][]byte
+    checksums map[string]string
+    downloads int
+}
+
+func (s *integrityStorage) Download(ctx context.Context, file *models.File) (io.ReadCloser, error) {
+    s.downloads++
+    return io.NopCloser(bytes.NewReader(s.content[file.ID])), nil
+}
+
+func (s *integrityStorage) StoredChecksum(ctx context.Context, file *models.File) (string, error) {
+    return s.checksums[file.ID], nil
+}
+
+func sha256Hex(content []byte) string {
+    digest := sha256.Sum256(content)
+    return hex.EncodeToString(digest[:])
+}
+
+// TestScrub tests that content no longer matching its checksum is flagged
+func TestScrub(t *testing.T) {
+    intact, original := []byte("intact content"), []byte("original content")
+    repo := newMockRepository()
+    for id, checksum := range map[string]string{
+        "file-intact":    sha256Hex(intact),
+        "file-corrupted": sha256Hex(original),
+        "file-parts":     "abcdef-3",
+    } {
+        repo.files[id] = &models.File{ID: id, Status: models.FileStatusUploaded, Checksum: checksum, Size: 16}
+    }
+    content := &integrityStorage{
+        content: map[string][]byte{
+            "file-intact":    intact,
+            "file-corrupted": []byte("corrupted content"),
+        },
+        checksums: map[string]string{"file-parts": "abcdef-3"},
+    }
+
+    registry := prometheus.NewRegistry()
+    scrubber, err := service.NewScrubber(repo, content, service.ScrubOptions{
+        Method:     service.ScrubDownload,
+        SampleSize: 10,
+        Metrics:    metrics.NewPrometheus(registry),
+    })
+    require.NoError(t, err)
+
+    result, err := scrubber.Scrub(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 3, result.Checked)
+    assert.Equal(t, []string{"file-corrupted"}, result.Corrupted)
+    assert.Equal(t, 2, content.downloads)
+    assert.Equal(t, models.FileStatusCorrupted, repo.files["file-corrupted"].Status)
+    assert.Equal(t, models.FileStatusUploaded, repo.files["file-intact"].Status)
+
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "integrity_scrub_checks_total", "download", "intact"))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "integrity_scrub_checks_total", "download", "corrupted"))
+    // Checksums of content uploaded in parts are compared with S3's
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "integrity_scrub_checks_total", "s3-checksum", "intact"))
+}
+
+// TestScrubStoredChecksum tests verifying content by the checksum S3
+// recorded, reading it back only when there is none
+func TestScrubStoredChecksum(t *testing.T) {
+    content := []byte("stored content")
+    repo := newMockRepository()
+    repo.files["file-1"] = &models.File{ID: "file-1", Status: models.FileStatusUploaded, Checksum: sha256Hex(content)}
+    repo.files["file-2"] = &models.File{ID: "file-2", Status: models.FileStatusUploaded, Checksum: sha256Hex(content)}
+    repo.files["file-3"] = &models.File{ID: "file-3", Status: models.FileStatusUploaded, Checksum: sha256Hex(content)}
+    storage := &integrityStorage{
+        content: map[string][]byte{"file-3": content},
+        checksums: map[string]string{
+            "file-1": sha256Hex(content),
+            "file-2": sha256Hex([]byte("other content")),
+        },
+    }
+
+    scrubber, err := service.NewScrubber(repo, storage, service.ScrubOptions{
+        Method:     service.ScrubStoredChecksum,
+        SampleSize: 10,
+    })
+    require.NoError(t, err)
+
+    result, err := scrubber.Scrub(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 3, result.Checked)
+    assert.Equal(t, []string{"file-2"}, result.Corrupted)
+    assert.Equal(t, 1, storage.downloads)
+
+    _, err = service.NewScrubber(repo, storage, service.ScrubOptions{Method: "md5", SampleSize: 10})
+    assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+    "context"
+    "database/sql"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "regexp"
+    "strconv"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/server"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// runTestServer starts the service with cfg and db on local listeners,
+// calls fn with its public and internal servers' URLs once it is healthy,
+// then shuts it down
+func runTestServer(t *testing.T, cfg *config.Config, db *sql.DB, fn func(public, internal string)) {
+    public, err := net.Listen("tcp", "127.0.0.1:0")
+    require.NoError(t, err)
+    internal, err := net.Listen("tcp", "127.0.0.1:0")
+    require.NoError(t, err)
+    publicURL := "http://" + public.Addr().String()
+    internalURL := "http://" + internal.Addr().String()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    done := make(chan error, 1)
+    go func() {
+        done <- server.Run(ctx, cfg, server.WithDB(db), server.WithListeners(public, internal), server.WithoutJobs())
+    }()
+
+    require.Eventually(t, func() bool {
+        resp, err := http.Get(internalURL + "/health")
+        if err != nil {
+            return false
+        }
+        resp.Body.Close()
+        return resp.StatusCode == http.StatusOK
+    }, 10*time.Second, 20*time.Millisecond)
+    fn(publicURL, internalURL)
+
+    cancel()
+    select {
+    case err := <-done:
+        require.NoError(t, err)
+    case <-time.After(10 * time.Second):
+        t.Fatal("Run did not return after its context ended")
+    }
+    _, err = http.Get(internalURL + "/health")
+    assert.Error(t, err)
+}
+
+// newTestServerConfig returns configuration for running the service against
+// s3, and a database nothing connects to while the service only starts and
+// stops
+func newTestServerConfig(t *testing.T, s3 *httptest.Server) (*config.Config, *sql.DB) {
+    setRequiredConfigEnv(t)
+    cfg, err := config.ParseConfig()
+    require.NoError(t, err)
+    cfg.S3.Endpoint = s3.URL
+    cfg.S3.ForcePathStyle = true
+    cfg.Server.DrainDelay = 0
+    cfg.Server.ShutdownTimeout = 5 * time.Second
+    cfg.Metrics.StorageStatsInterval = 0
+
+    db, err := sql.Open("postgres", "postgres://files@127.0.0.1:1/files?sslmode=disable")
+    require.NoError(t, err)
+    t.Cleanup(func() { db.Close() })
+    return cfg, db
+}
+
+// TestServerRun tests starting the service with an embedder's database and
+// listeners, shutting it down, and starting it again in the same process
+func TestServerRun(t *testing.T) {
+    s3 := httptest.NewServer(newChecksumS3())
+    defer s3.Close()
+    cfg, db := newTestServerConfig(t, s3)
+    cfg.S3.SoftDeletePrefix = "trash"
+
+    // transitions scrapes the count of pending to draft transitions
+    transitions := func(t *testing.T, internal string) float64 {
+        resp, err := http.Get(internal + cfg.Metrics.Path)
+        require.NoError(t, err)
+        defer resp.Body.Close()
+        body, err := io.ReadAll(resp.Body)
+        require.NoError(t, err)
+        match := regexp.MustCompile(`(?m)^file_status_transitions_total\{from="pending",to="draft"\} (\S+)$`).FindSubmatch(body)
+        if match == nil {
+            return 0
+        }
+        count, err := strconv.ParseFloat(string(match[1]), 64)
+        require.NoError(t, err)
+        return count
+    }
+
+    transition := func(t *testing.T) {
+        file, err := models.NewFile(testFileName, testFileSize, testContentType)
+        require.NoError(t, err)
+        require.NoError(t, file.UpdateStatus(models.FileStatusDraft))
+    }
+
+    for i := 0; i < 2; i++ {
+        runTestServer(t, cfg, db, func(public, internal string) {
+            before := transitions(t, internal)
+            transition(t)
+            // Each run's hook is removed when it returns, so the second
+            // run counts a transition once
+            assert.Equal(t, before+1, transitions(t, internal))
+        })
+        // The key layout with the soft delete prefix is dropped with the run
+        assert.NotContains(t, storagekey.Default.Prefixes, "trash")
+    }
+}
+
+// TestFileRequestUploadLimit tests that anonymous uploads through file
+// request links are limited per client address
+func TestFileRequestUploadLimit(t *testing.T) {
+    s3 := httptest.NewServer(newChecksumS3())
+    defer s3.Close()
+    cfg, db := newTestServerConfig(t, s3)
+    cfg.Request.UploadRequests = 2
+    cfg.Request.UploadWindow = time.Hour
+
+    runTestServer(t, cfg, db, func(public, internal string) {
+        upload := func() *http.Response {
+            resp, err := http.Post(public+"/file-requests/request-1/upload", "multipart/form-data; boundary=x", strings.NewReader(""))
+            require.NoError(t, err)
+            resp.Body.Close()
+            return resp
+        }
+
+        for i := 0; i < cfg.Request.UploadRequests; i++ {
+            assert.NotEqual(t, http.StatusTooManyRequests, upload().StatusCode)
+        }
+        resp := upload()
+        assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+        retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+        require.NoError(t, err)
+        assert.Greater(t, retryAfter, 0)
+        assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+    })
+}
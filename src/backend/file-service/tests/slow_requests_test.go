@@ -0,0 +1,71 @@
+package tests
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.uber.org/zap"
+    "go.uber.org/zap/zapcore"
+    "go.uber.org/zap/zaptest/observer"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/pkg/metrics"
+)
+
+// TestSlowRequests tests that slow requests and large transfers are logged
+// with the route, caller and file, and others are not
+func TestSlowRequests(t *testing.T) {
+    core, logs := observer.New(zapcore.WarnLevel)
+    restore := zap.ReplaceGlobals(zap.New(core))
+    defer restore()
+
+    registry := prometheus.NewRegistry()
+    handler := handlers.SlowRequests(metrics.NewPrometheus(registry), 50*time.Millisecond, 1024)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, err := io.ReadAll(r.Body)
+        require.NoError(t, err)
+        if r.URL.Query().Get("slow") == "true" {
+            time.Sleep(60 * time.Millisecond)
+        }
+        w.Write(body)
+    }))
+    serve := func(target, body string) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+        req = req.WithContext(middleware.ContextWithClaims(req.Context(), &middleware.Claims{UserID: "user-1"}))
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        return rec
+    }
+
+    rec := serve("/upload", "small")
+    assert.Equal(t, "small", rec.Body.String())
+    assert.Zero(t, logs.Len())
+
+    large := strings.Repeat("x", 2048)
+    rec = serve("/files/file-1/content", large)
+    assert.Equal(t, large, rec.Body.String())
+    require.Equal(t, 1, logs.Len())
+    fields := logs.All()[0].ContextMap()
+    assert.Equal(t, "/files", fields["route"])
+    assert.Equal(t, "user-1", fields["userId"])
+    assert.Equal(t, "file-1", fields["fileId"])
+    assert.Equal(t, int64(2048), fields["bytesIn"])
+    assert.Equal(t, int64(2048), fields["bytesOut"])
+
+    serve("/download?id=file-2&slow=true", "")
+    require.Equal(t, 2, logs.Len())
+    fields = logs.All()[1].ContextMap()
+    assert.Equal(t, "/download", fields["route"])
+    assert.Equal(t, "file-2", fields["fileId"])
+    assert.Equal(t, []interface{}{"duration"}, fields["reasons"])
+
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "slow_requests_total", "/files", "size"))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "slow_requests_total", "/download", "duration"))
+}
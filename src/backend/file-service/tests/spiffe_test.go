@@ -0,0 +1,119 @@
+package tests
+
+import (
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/tls"
+    "crypto/x509"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+    "time"
+
+    "github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+    "github.com/spiffe/go-spiffe/v2/spiffeid"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+// newSPIFFECA creates a self-signed CA for a trust domain
+func newSPIFFECA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    require.NoError(t, err)
+
+    template := &x509.Certificate{
+        SerialNumber:          big.NewInt(1),
+        NotBefore:             time.Now().Add(-time.Hour),
+        NotAfter:              time.Now().Add(time.Hour),
+        KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+        BasicConstraintsValid: true,
+        IsCA:                  true,
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+    require.NoError(t, err)
+    ca, err := x509.ParseCertificate(der)
+    require.NoError(t, err)
+    return ca, key
+}
+
+// newSVID issues an X.509 SVID for id signed by ca
+func newSVID(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey, id string) *x509.Certificate {
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    require.NoError(t, err)
+    uri, err := url.Parse(id)
+    require.NoError(t, err)
+
+    template := &x509.Certificate{
+        SerialNumber: big.NewInt(2),
+        NotBefore:    time.Now().Add(-time.Hour),
+        NotAfter:     time.Now().Add(time.Hour),
+        KeyUsage:     x509.KeyUsageDigitalSignature,
+        ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+        URIs:         []*url.URL{uri},
+    }
+    der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+    require.NoError(t, err)
+    svid, err := x509.ParseCertificate(der)
+    require.NoError(t, err)
+    return svid
+}
+
+// TestSPIFFEAuthentication tests that mapped SVIDs authenticate with their
+// roles and that unmapped or untrusted SVIDs are rejected
+func TestSPIFFEAuthentication(t *testing.T) {
+    ca, caKey := newSPIFFECA(t)
+    bundle := x509bundle.FromX509Authorities(spiffeid.RequireTrustDomainFromString("example.org"), []*x509.Certificate{ca})
+
+    auth, err := middleware.NewSPIFFEAuthenticator(bundle, map[string]string{
+        "spiffe://example.org/ns/billing/sa/api": "reader|writer",
+    })
+    require.NoError(t, err)
+    middleware.UseSPIFFE(auth)
+    defer middleware.UseSPIFFE(nil)
+
+    var principal *requestctx.Principal
+    handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        principal, _ = requestctx.PrincipalFrom(r.Context())
+    }))
+    serve := func(svid *x509.Certificate) int {
+        principal = nil
+        req := httptest.NewRequest(http.MethodGet, "/download", nil)
+        req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{svid}}
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        return rec.Code
+    }
+
+    t.Run("Mapped ID", func(t *testing.T) {
+        code := serve(newSVID(t, ca, caKey, "spiffe://example.org/ns/billing/sa/api"))
+        assert.Equal(t, http.StatusOK, code)
+        require.NotNil(t, principal)
+        assert.Equal(t, "spiffe://example.org/ns/billing/sa/api", principal.UserID)
+        assert.Equal(t, []string{"reader", "writer"}, principal.Roles)
+    })
+
+    t.Run("Unmapped ID", func(t *testing.T) {
+        code := serve(newSVID(t, ca, caKey, "spiffe://example.org/ns/other/sa/api"))
+        assert.Equal(t, http.StatusUnauthorized, code)
+        assert.Nil(t, principal)
+    })
+
+    t.Run("Untrusted CA", func(t *testing.T) {
+        otherCA, otherKey := newSPIFFECA(t)
+        code := serve(newSVID(t, otherCA, otherKey, "spiffe://example.org/ns/billing/sa/api"))
+        assert.Equal(t, http.StatusUnauthorized, code)
+    })
+
+    t.Run("Invalid Mappings", func(t *testing.T) {
+        _, err := middleware.ParseSPIFFERoles(map[string]string{"https://example.org/api": "reader"})
+        assert.Error(t, err)
+        _, err = middleware.ParseSPIFFERoles(map[string]string{"spiffe://example.org/api": "|"})
+        assert.Error(t, err)
+    })
+}
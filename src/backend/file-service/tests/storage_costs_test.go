@@ -0,0 +1,57 @@
+package tests
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+)
+
+// fixedRequestCounts reports fixed request counts observed over the last day
+type fixedRequestCounts map[string]int64
+
+func (f fixedRequestCounts) Counts() (map[string]int64, time.Time) {
+    return f, time.Now().Add(-24 * time.Hour)
+}
+
+// TestStorageCostEstimate tests monthly cost projection from request counts and stored bytes
+func TestStorageCostEstimate(t *testing.T) {
+    ctx := context.Background()
+    repo := newMockRepository()
+
+    file, err := models.NewFile(testFileName, 1<<30, testContentType)
+    require.NoError(t, err)
+    require.NoError(t, repo.Create(ctx, file))
+
+    estimator, err := service.NewCostEstimator(repo, fixedRequestCounts{
+        "PutObject":    1000,
+        "GetObject":    10000,
+        "DeleteObject": 500,
+    }, service.StoragePrices{
+        StorageGBMonth:           0.023,
+        Tier1RequestsPerThousand: 0.005,
+        Tier2RequestsPerThousand: 0.0004,
+    })
+    require.NoError(t, err)
+
+    report, err := estimator.Estimate(ctx)
+    require.NoError(t, err)
+
+    assert.Equal(t, int64(1<<30), report.StoredBytes)
+    assert.InDelta(t, 0.023, report.StorageCost, 1e-9)
+
+    // One day of requests projects to 30 days: 30k PUTs and 300k GETs
+    require.Len(t, report.Operations, 3)
+    assert.Equal(t, "DeleteObject", report.Operations[0].Operation)
+    assert.Zero(t, report.Operations[0].MonthlyCost)
+    assert.InDelta(t, 30*0.005+300*0.0004, report.RequestCost, 0.01)
+    assert.InDelta(t, report.StorageCost+report.RequestCost, report.TotalMonthlyCost, 1e-9)
+
+    _, err = service.NewCostEstimator(repo, fixedRequestCounts{}, service.StoragePrices{StorageGBMonth: -1})
+    assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "testing"
+    "time"
+
+    smithyhttp "github.com/aws/smithy-go/transport/http"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/storage"
+)
+
+// responseError returns the error the S3 client reports for a response with status
+func responseError(status int) error {
+    return &smithyhttp.ResponseError{
+        Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+        Err:      errors.New(http.StatusText(status)),
+    }
+}
+
+// TestStorageHealthBoard tests scoring backends by their recent requests and probes
+func TestStorageHealthBoard(t *testing.T) {
+    ctx := context.Background()
+    board := storage.NewHealthBoard(5 * time.Minute)
+
+    probeErr := errors.New("connection refused")
+    var replicaDown bool
+    board.Track(storage.Backend{Name: "files", Kind: "s3", Region: "us-west-2",
+        Probe: func(ctx context.Context) error { return nil }})
+    board.Track(storage.Backend{Name: "files-replica", Kind: "s3", Region: "eu-west-1",
+        Probe: func(ctx context.Context) error {
+            if replicaDown {
+                return probeErr
+            }
+            return nil
+        }})
+    board.Track(storage.Backend{Name: "archive", Kind: "s3"})
+
+    report := board.Report()
+    require.Len(t, report.Backends, 3)
+    for _, backend := range report.Backends {
+        assert.Equal(t, storage.HealthUnknown, backend.Status)
+    }
+
+    // Missing objects and abandoned requests are not the backend's fault,
+    // but server errors and throttling are
+    for i := 1; i <= 20; i++ {
+        board.Record("files", time.Duration(i)*time.Millisecond, nil)
+    }
+    board.Record("files", time.Millisecond, responseError(http.StatusNotFound))
+    board.Record("files", time.Millisecond, context.Canceled)
+    board.Record("archive", 10*time.Millisecond, responseError(http.StatusServiceUnavailable))
+    for i := 0; i < 10; i++ {
+        board.Record("archive", 10*time.Millisecond, nil)
+    }
+    board.Record("archive", 10*time.Millisecond, responseError(http.StatusTooManyRequests))
+    board.Record("unknown", time.Millisecond, nil)
+
+    replicaDown = true
+    board.Probe(ctx, time.Second)
+
+    report = board.Report()
+    assert.Equal(t, "5m0s", report.Window)
+    files, replica, archive := report.Backends[0], report.Backends[1], report.Backends[2]
+
+    assert.Equal(t, storage.HealthHealthy, files.Status)
+    assert.Equal(t, 21, files.Requests)
+    assert.Zero(t, files.Errors)
+    assert.Equal(t, 10.0, files.LatencyP50Ms)
+    assert.Equal(t, 19.0, files.LatencyP95Ms)
+    assert.Equal(t, 20.0, files.LatencyMaxMs)
+    assert.NotNil(t, files.LastProbeSuccessAt)
+
+    assert.Equal(t, storage.HealthDown, replica.Status)
+    assert.Equal(t, "connection refused", replica.LastProbeError)
+    assert.Nil(t, replica.LastProbeSuccessAt)
+
+    assert.Equal(t, storage.HealthDegraded, archive.Status)
+    assert.Equal(t, 2, archive.Errors)
+    assert.InDelta(t, 2.0/12, archive.ErrorRate, 0.001)
+    assert.NotNil(t, archive.LastErrorAt)
+    assert.Nil(t, archive.LastProbeAt)
+
+    // A successful probe clears the probe error
+    replicaDown = false
+    board.Probe(ctx, time.Second)
+    replica = board.Report().Backends[1]
+    assert.Equal(t, storage.HealthHealthy, replica.Status)
+    assert.Empty(t, replica.LastProbeError)
+}
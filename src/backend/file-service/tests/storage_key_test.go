@@ -0,0 +1,159 @@
+package tests
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/storagekey"
+    "src/backend/file-service/pkg/validator"
+)
+
+// TestStorageKeyPolicy tests the layout storage keys are validated against
+func TestStorageKeyPolicy(t *testing.T) {
+    const id = "3f2a9c1e-8b7d-4e6f-a5c4-1b2d3e4f5a6b"
+    policy := storagekey.Default
+
+    assert.Equal(t, "3f/2a/"+id, policy.Key(id))
+    assert.Equal(t, policy.Key(id), storage.StorageKey(id))
+    assert.Equal(t, "scratch/3f/2a/"+id, storage.ScratchKey(id))
+
+    for _, key := range []string{
+        policy.Key(id),
+        storage.ScratchKey(id),
+        "derived/3f/2a/" + id + "/thumbnail/abc123",
+        "archive/3f/2a/" + id,
+        policy.ForTenant("acme", policy.Key(id)),
+        id,
+    } {
+        assert.NoError(t, policy.Validate(key), key)
+    }
+
+    tests := map[string]error{
+        "":                               storagekey.ErrEmpty,
+        strings.Repeat("a", 1025):        storagekey.ErrTooLong,
+        "3f/2a/../" + id:                 storagekey.ErrInvalidSegment,
+        "/3f/2a/" + id:                   storagekey.ErrInvalidSegment,
+        "3f//2a/" + id:                   storagekey.ErrInvalidSegment,
+        "3f/2a/" + id + "\n":             storagekey.ErrInvalidSegment,
+        "files/" + id:                    storagekey.ErrLayout,
+        "ab/cd/" + id:                    storagekey.ErrLayout,
+        "3f/2a/" + id + "/extra":         storagekey.ErrLayout,
+        "tenants/acme":                   storagekey.ErrLayout,
+        "uploads/3f/2a/" + id + "/extra": storagekey.ErrLayout,
+    }
+    for key, want := range tests {
+        assert.ErrorIs(t, policy.Validate(key), want, key)
+    }
+
+    strict := policy
+    strict.AllowLegacy = false
+    assert.ErrorIs(t, strict.Validate(id), storagekey.ErrLegacy)
+}
+
+// TestStorageKeyID tests finding the file a key belongs to, by which
+// objects are sharded over buckets
+func TestStorageKeyID(t *testing.T) {
+    const id = "3f2a9c1e-8b7d-4e6f-a5c4-1b2d3e4f5a6b"
+    policy := storagekey.Default
+
+    for _, key := range []string{
+        policy.Key(id),
+        storage.ScratchKey(id),
+        "derived/3f/2a/" + id + "/thumbnail/abc123",
+        policy.Nested(storagekey.Quarantine, id),
+        policy.ForTenant("acme", policy.Key(id)),
+    } {
+        found, ok := policy.ID(key)
+        assert.True(t, ok, key)
+        assert.Equal(t, id, found, key)
+    }
+
+    // Legacy keys and keys of other layouts belong to no file
+    for _, key := range []string{id, "archive/" + id, "ab/cd/" + id, "audit/2026/10/16/events.json", ""} {
+        _, ok := policy.ID(key)
+        assert.False(t, ok, key)
+    }
+}
+
+// TestStorageKeyTenants tests that keys scoped to a tenant are refused for other tenants
+func TestStorageKeyTenants(t *testing.T) {
+    policy := storagekey.Default
+    key := policy.ForTenant("acme", policy.Key("abcdef"))
+    assert.Equal(t, "tenants/acme/ab/cd/abcdef", key)
+
+    assert.NoError(t, policy.ValidateTenant(key, "acme"))
+    assert.ErrorIs(t, policy.ValidateTenant(key, "globex"), storagekey.ErrTenantMismatch)
+    assert.ErrorIs(t, policy.ValidateTenant(key, ""), storagekey.ErrTenantMismatch)
+    assert.NoError(t, policy.ValidateTenant(policy.Key("abcdef"), "acme"))
+
+    assert.Equal(t, "acme", policy.Tenant(key))
+    assert.Empty(t, policy.Tenant(policy.Key("abcdef")))
+    assert.Equal(t, policy.Key("abcdef"), policy.Unscoped(key))
+
+    // Soft-deleted and quarantined copies stay within the tenant's scope,
+    // and so in the tenant's bucket
+    archived := policy.Under(storagekey.SoftDeleted, key)
+    assert.Equal(t, "tenants/acme/archive/ab/cd/abcdef", archived)
+    assert.Equal(t, "acme", policy.Tenant(archived))
+    id, ok := policy.ID(archived)
+    assert.True(t, ok)
+    assert.Equal(t, "abcdef", id)
+    assert.Equal(t, "quarantine/ab/cd/abcdef", policy.Under(storagekey.Quarantine, policy.Key("abcdef")))
+
+    policy.TenantKeys = true
+    assert.ErrorIs(t, policy.ValidateTenant(policy.Key("abcdef"), "acme"), storagekey.ErrTenantMismatch)
+
+    err := validator.ValidateTenantStoragePath(key, "globex")
+    var validationErr *validator.ValidationError
+    require.ErrorAs(t, err, &validationErr)
+    assert.Equal(t, "TENANT_MISMATCH", validationErr.Code)
+}
+
+// TestStorageKeyMigration tests mapping legacy keys to their sharded keys
+func TestStorageKeyMigration(t *testing.T) {
+    policy := storagekey.Default
+
+    canonical, legacy := policy.Canonical("abcdef")
+    assert.True(t, legacy)
+    assert.Equal(t, "ab/cd/abcdef", canonical)
+
+    canonical, legacy = policy.Canonical("tenants/acme/scratch/abcdef")
+    assert.True(t, legacy)
+    assert.Equal(t, "tenants/acme/scratch/ab/cd/abcdef", canonical)
+
+    _, legacy = policy.Canonical("ab/cd/abcdef")
+    assert.False(t, legacy)
+    _, legacy = policy.Canonical("abc")
+    assert.False(t, legacy, "too short to shard")
+
+    file := &models.File{ID: "abcdef"}
+    require.NoError(t, file.SetStoragePath("abcdef"))
+    require.NoError(t, file.SetStoragePath(canonical))
+    assert.ErrorIs(t, file.SetStoragePath("../abcdef"), models.ErrInvalidPath)
+    assert.Equal(t, canonical, file.StoragePath)
+}
+
+// TestStorageKeyPrefix tests adding a configured prefix to the layout
+func TestStorageKeyPrefix(t *testing.T) {
+    const id = "3f2a9c1e-8b7d-4e6f-a5c4-1b2d3e4f5a6b"
+    key := "trash/" + storagekey.Default.Key(id)
+    assert.ErrorIs(t, storagekey.Default.Validate(key), storagekey.ErrLayout)
+
+    policy, err := storagekey.Default.WithPrefix("trash")
+    require.NoError(t, err)
+    assert.NoError(t, policy.Validate(key))
+    found, ok := policy.ID(policy.Under("trash", policy.ForTenant("acme", policy.Key(id))))
+    assert.True(t, ok)
+    assert.Equal(t, id, found)
+    assert.NotContains(t, storagekey.Default.Prefixes, "trash")
+
+    for _, prefix := range []string{"", "a/b", "..", storagekey.TenantPrefix, "ab"} {
+        _, err := storagekey.Default.WithPrefix(prefix)
+        assert.ErrorIs(t, err, storagekey.ErrInvalidPrefix, prefix)
+    }
+}
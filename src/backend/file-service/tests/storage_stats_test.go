@@ -0,0 +1,59 @@
+package tests
+
+import (
+    "context"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/metrics"
+)
+
+// TestStorageStats tests the storage gauges by storage state and class
+func TestStorageStats(t *testing.T) {
+    ctx := context.Background()
+    expired := time.Now().Add(-time.Hour)
+    repo := newMockRepository()
+    repo.files["active-1"] = &models.File{ID: "active-1", Status: models.FileStatusUploaded, Size: 100}
+    repo.files["active-2"] = &models.File{ID: "active-2", Status: models.FileStatusUploaded, Size: 50}
+    repo.files["cold"] = &models.File{ID: "cold", Status: models.FileStatusUploaded, Size: 70,
+        StorageClass: models.StorageClassStandardIA}
+    repo.files["archived"] = &models.File{ID: "archived", Status: models.FileStatusUploaded, Size: 500,
+        StorageClass: models.StorageClassGlacier, ArchiveStatus: models.ArchiveStatusArchived}
+    repo.files["infected"] = &models.File{ID: "infected", Status: models.FileStatusInfected, Size: 30}
+    repo.files["failed"] = &models.File{ID: "failed", Status: models.FileStatusFailed, Size: 20}
+    repo.files["draft"] = &models.File{ID: "draft", Status: models.FileStatusDraft, Size: 10, DraftExpiresAt: &expired}
+    repo.files["deleted"] = &models.File{ID: "deleted", Status: models.FileStatusDeleted, Size: 1000}
+
+    registry := prometheus.NewRegistry()
+    stats, err := service.NewStorageStats(repo, metrics.NewPrometheus(registry))
+    require.NoError(t, err)
+
+    usage, err := stats.Refresh(ctx)
+    require.NoError(t, err)
+    assert.Len(t, usage, 5)
+
+    bytes := func(state, storageClass string) float64 {
+        return gatheredValue(t, registry, "storage_stored_bytes", state, storageClass)
+    }
+    assert.Equal(t, 150.0, bytes(models.StorageStateActive, models.StorageClassStandard))
+    assert.Equal(t, 2.0, gatheredValue(t, registry, "storage_stored_objects",
+        models.StorageStateActive, models.StorageClassStandard))
+    assert.Equal(t, 70.0, bytes(models.StorageStateActive, models.StorageClassStandardIA))
+    assert.Equal(t, 500.0, bytes(models.StorageStateArchived, models.StorageClassGlacier))
+    assert.Equal(t, 30.0, bytes(models.StorageStateQuarantined, models.StorageClassStandard))
+    // Failed uploads and expired drafts are reclaimed by themselves
+    assert.Equal(t, 30.0, bytes(models.StorageStatePendingPurge, models.StorageClassStandard))
+
+    // Totals that no longer hold any files are zeroed
+    delete(repo.files, "infected")
+    _, err = stats.Refresh(ctx)
+    require.NoError(t, err)
+    assert.Equal(t, 0.0, bytes(models.StorageStateQuarantined, models.StorageClassStandard))
+    assert.Equal(t, 150.0, bytes(models.StorageStateActive, models.StorageClassStandard))
+}
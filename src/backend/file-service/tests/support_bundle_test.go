@@ -0,0 +1,93 @@
+package tests
+
+import (
+    "archive/zip"
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "strings"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+    "go.uber.org/zap"
+
+    "src/backend/file-service/pkg/logger"
+    "src/backend/file-service/pkg/supportbundle"
+)
+
+// TestLogRecorder tests that the recorder keeps the latest warnings and errors
+func TestLogRecorder(t *testing.T) {
+    recorder := logger.NewRecorder(2)
+    log := logger.New(zap.New(recorder.Core())).With(logger.String("component", "test"))
+
+    log.Info("Ignored")
+    log.Warn("First")
+    log.Error("Second", logger.Int("attempt", 2))
+    log.Warn("Third")
+
+    entries := recorder.Entries()
+    require.Len(t, entries, 2)
+    assert.Equal(t, "Second", entries[0].Message)
+    assert.Equal(t, "error", entries[0].Level)
+    assert.Equal(t, "test", entries[0].Fields["component"])
+    assert.EqualValues(t, 2, entries[0].Fields["attempt"])
+    assert.Equal(t, "Third", entries[1].Message)
+}
+
+// TestSupportBundle tests the sections and manifest of a support bundle
+func TestSupportBundle(t *testing.T) {
+    recorder := logger.NewRecorder(10)
+    log := logger.New(zap.New(recorder.Core()))
+    log.Warn("Upload rejected for jane@example.com", logger.String("authorization", "Bearer abc.def"))
+
+    counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "bundle_test_total", Help: "Test counter"})
+    counter.Inc()
+    registry := prometheus.NewRegistry()
+    registry.MustRegister(counter)
+
+    bundle := supportbundle.New(
+        supportbundle.JSON("config.json", func(ctx context.Context) (interface{}, error) {
+            return map[string]string{"APP_ENV": "dev"}, nil
+        }),
+        supportbundle.JSON("health.json", func(ctx context.Context) (interface{}, error) {
+            return nil, errors.New("database unreachable")
+        }),
+        supportbundle.Logs(recorder),
+        supportbundle.Metrics(registry),
+        supportbundle.Goroutines(),
+    )
+
+    var buf bytes.Buffer
+    manifest, err := bundle.Write(context.Background(), &buf)
+    require.NoError(t, err)
+    require.Len(t, manifest.Sections, 5)
+    assert.Empty(t, manifest.Sections[0].Error)
+    assert.Equal(t, "database unreachable", manifest.Sections[1].Error)
+
+    archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+    require.NoError(t, err)
+    read := func(name string) string {
+        entry, err := archive.Open(name)
+        require.NoError(t, err)
+        defer entry.Close()
+        content, err := io.ReadAll(entry)
+        require.NoError(t, err)
+        return string(content)
+    }
+
+    assert.JSONEq(t, `{"APP_ENV": "dev"}`, read("config.json"))
+    logs := read("logs.jsonl")
+    assert.Contains(t, logs, "Upload rejected for [email]")
+    assert.Contains(t, logs, "Bearer [token]")
+    assert.NotContains(t, logs, "jane@example.com")
+    assert.Contains(t, read("metrics.txt"), "bundle_test_total 1")
+    assert.True(t, strings.HasPrefix(read("goroutines.txt"), "goroutine "))
+
+    var written supportbundle.Manifest
+    require.NoError(t, json.Unmarshal([]byte(read(supportbundle.ManifestName)), &written))
+    assert.Equal(t, manifest.Sections, written.Sections)
+}
@@ -0,0 +1,111 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "strings"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// recordingTagStorage records the tags synced to each file's object
+type recordingTagStorage struct {
+    synced map[string]models.Tags
+    err    error
+}
+
+func (s *recordingTagStorage) PutTags(ctx context.Context, file *models.File) error {
+    if s.err != nil {
+        return s.err
+    }
+    s.synced[file.StoragePath] = file.Tags
+    return nil
+}
+
+// TestTagsValidate tests that tags are checked against the S3 object tag limits
+func TestTagsValidate(t *testing.T) {
+    assert.NoError(t, models.Tags{"cost-center": "eng/42", "retention": "1 year"}.Validate())
+    assert.NoError(t, models.Tags(nil).Validate())
+
+    tooMany := models.Tags{}
+    for i := 0; i <= models.MaxTags; i++ {
+        tooMany[strings.Repeat("k", i+1)] = "v"
+    }
+    for name, tags := range map[string]models.Tags{
+        "too many":      tooMany,
+        "empty key":     {"": "v"},
+        "long key":      {strings.Repeat("k", 129): "v"},
+        "long value":    {"k": strings.Repeat("v", 257)},
+        "reserved":      {"aws:createdBy": "me"},
+        "invalid value": {"k": "a;b"},
+        "invalid key":   {"k<": "v"},
+    } {
+        assert.True(t, errors.Is(tags.Validate(), models.ErrInvalidTags), name)
+    }
+}
+
+// TestSetTags tests that tag changes are versioned and synced to the object
+func TestSetTags(t *testing.T) {
+    ctx := context.Background()
+    mockStore := newMockStorage()
+    objectTags := &recordingTagStorage{synced: make(map[string]models.Tags)}
+    fileService, err := service.NewFileService(mockStore, newMockRepository(), service.WorkerPoolConfig{
+        MaxWorkers: maxConcurrentOps,
+        BufferSize: 32 * 1024,
+    }, service.WithObjectTags(objectTags))
+    require.NoError(t, err)
+
+    mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+        Run(func(args mock.Arguments) {
+            file := args.Get(1).(*models.File)
+            require.NoError(t, file.SetStoragePath(storage.StorageKey(file.ID)))
+        }).
+        Return(nil)
+
+    file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+        bytes.NewReader(testPDFContent()), service.UploadOptions{Tags: models.Tags{"project": "apollo"}})
+    require.NoError(t, err)
+    assert.Equal(t, models.Tags{"project": "apollo"}, file.Tags)
+
+    t.Run("Replace", func(t *testing.T) {
+        tagged, err := fileService.SetTags(ctx, file.ID, models.Tags{"retention": "short"}, 1)
+        require.NoError(t, err)
+        assert.Equal(t, models.Tags{"retention": "short"}, tagged.Tags)
+        assert.Equal(t, int64(2), tagged.Version)
+        assert.Equal(t, models.Tags{"retention": "short"}, objectTags.synced[file.StoragePath])
+    })
+
+    t.Run("Stale Version", func(t *testing.T) {
+        _, err := fileService.SetTags(ctx, file.ID, models.Tags{"retention": "long"}, 1)
+        assert.True(t, errors.Is(err, service.ErrVersionConflict))
+        assert.Equal(t, models.Tags{"retention": "short"}, objectTags.synced[file.StoragePath])
+    })
+
+    t.Run("Invalid Tags", func(t *testing.T) {
+        _, err := fileService.SetTags(ctx, file.ID, models.Tags{"aws:owner": "me"}, 2)
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+    })
+
+    t.Run("Remove", func(t *testing.T) {
+        untagged, err := fileService.SetTags(ctx, file.ID, models.Tags{}, 2)
+        require.NoError(t, err)
+        assert.Nil(t, untagged.Tags)
+        assert.Nil(t, objectTags.synced[file.StoragePath])
+    })
+
+    t.Run("Sync Failure", func(t *testing.T) {
+        objectTags.err = errors.New("access denied")
+        defer func() { objectTags.err = nil }()
+
+        _, err := fileService.SetTags(ctx, file.ID, models.Tags{"retention": "long"}, 3)
+        assert.True(t, errors.Is(err, service.ErrOperationFailed))
+    })
+}
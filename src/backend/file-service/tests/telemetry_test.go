@@ -0,0 +1,169 @@
+package tests
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    dto "github.com/prometheus/client_model/go"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/metrics"
+    "src/backend/file-service/pkg/telemetry"
+)
+
+// TestOTLPProviderPush tests that registered instruments are pushed as OTLP JSON
+func TestOTLPProviderPush(t *testing.T) {
+    registry := prometheus.NewRegistry()
+    uploads := prometheus.NewCounter(prometheus.CounterOpts{
+        Name: "uploads_total",
+        Help: "Uploads",
+    })
+    latency := prometheus.NewHistogram(prometheus.HistogramOpts{
+        Name:    "upload_seconds",
+        Help:    "Upload latency",
+        Buckets: []float64{0.1, 1},
+    })
+    registry.MustRegister(uploads, latency)
+    uploads.Add(3)
+    latency.Observe(0.05)
+    latency.Observe(0.5)
+    latency.Observe(5)
+
+    var payload map[string]interface{}
+    var authorization string
+    collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        authorization = r.Header.Get("Authorization")
+        assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+        require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer collector.Close()
+
+    provider, err := telemetry.NewOTLPProvider(registry, telemetry.OTLPConfig{
+        Endpoint:    collector.URL + "/v1/metrics",
+        Headers:     map[string]string{"Authorization": "Bearer token"},
+        Interval:    time.Minute,
+        Timeout:     time.Second,
+        ServiceName: "file-service",
+    })
+    require.NoError(t, err)
+    assert.Nil(t, provider.Handler(), "push exporters must not expose a scrape endpoint")
+
+    // Shutdown flushes the current values
+    require.NoError(t, provider.Shutdown(context.Background()))
+    assert.Equal(t, "Bearer token", authorization)
+
+    resourceMetrics := payload["resourceMetrics"].([]interface{})[0].(map[string]interface{})
+    scopeMetrics := resourceMetrics["scopeMetrics"].([]interface{})[0].(map[string]interface{})
+    metrics := map[string]map[string]interface{}{}
+    for _, m := range scopeMetrics["metrics"].([]interface{}) {
+        metric := m.(map[string]interface{})
+        metrics[metric["name"].(string)] = metric
+    }
+
+    sum := metrics["uploads_total"]["sum"].(map[string]interface{})
+    assert.Equal(t, true, sum["isMonotonic"])
+    point := sum["dataPoints"].([]interface{})[0].(map[string]interface{})
+    assert.Equal(t, 3.0, point["asDouble"])
+
+    histogram := metrics["upload_seconds"]["histogram"].(map[string]interface{})
+    hp := histogram["dataPoints"].([]interface{})[0].(map[string]interface{})
+    assert.Equal(t, "3", hp["count"])
+    assert.Equal(t, []interface{}{0.1, 1.0}, hp["explicitBounds"])
+    assert.Equal(t, []interface{}{"1", "1", "1"}, hp["bucketCounts"])
+
+    _, err = telemetry.NewOTLPProvider(registry, telemetry.OTLPConfig{Interval: time.Minute, Timeout: time.Second})
+    assert.Error(t, err)
+}
+
+// gatheredValue returns the counter or gauge value of the series of the named
+// metric that has all the given label values
+func gatheredValue(t *testing.T, gatherer prometheus.Gatherer, name string, labelValues ...string) float64 {
+    families, err := gatherer.Gather()
+    require.NoError(t, err)
+    for _, family := range families {
+        if family.GetName() != name {
+            continue
+        }
+        for _, metric := range family.GetMetric() {
+            if !hasLabelValues(metric, labelValues) {
+                continue
+            }
+            if metric.GetCounter() != nil {
+                return metric.GetCounter().GetValue()
+            }
+            return metric.GetGauge().GetValue()
+        }
+    }
+    t.Fatalf("metric %s%v not found", name, labelValues)
+    return 0
+}
+
+func hasLabelValues(metric *dto.Metric, values []string) bool {
+    for _, value := range values {
+        found := false
+        for _, label := range metric.GetLabel() {
+            if label.GetValue() == value {
+                found = true
+            }
+        }
+        if !found {
+            return false
+        }
+    }
+    return true
+}
+
+// TestSLOMetrics tests that requests are counted good only when they succeed
+// within the latency target
+func TestSLOMetrics(t *testing.T) {
+    registry := prometheus.NewRegistry()
+    slos, err := telemetry.NewSLOMetrics(telemetry.SLO{
+        Operation:     "download",
+        LatencyTarget: 50 * time.Millisecond,
+        Objective:     0.99,
+    })
+    require.NoError(t, err)
+    require.NoError(t, slos.Register(registry))
+
+    serve := func(status int, delay time.Duration) {
+        handler := slos.Middleware("download", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            time.Sleep(delay)
+            w.WriteHeader(status)
+        }))
+        handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/download", nil))
+    }
+
+    serve(http.StatusOK, 0)
+    serve(http.StatusNotFound, 0)
+    serve(http.StatusInternalServerError, 0)
+    serve(http.StatusOK, 100*time.Millisecond)
+
+    assert.Equal(t, 4.0, gatheredValue(t, registry, "slo_requests_total", "download"))
+    assert.Equal(t, 2.0, gatheredValue(t, registry, "slo_requests_good_total", "download"))
+    assert.Equal(t, 0.99, gatheredValue(t, registry, "slo_objective", "download"))
+    assert.Equal(t, 0.05, gatheredValue(t, registry, "slo_latency_target_seconds", "download"))
+
+    _, err = telemetry.NewSLOMetrics(telemetry.SLO{Operation: "upload", LatencyTarget: time.Second, Objective: 1})
+    assert.Error(t, err)
+}
+
+// TestTrackJob tests that jobs count towards the queue depth until done
+func TestTrackJob(t *testing.T) {
+    registry := prometheus.NewRegistry()
+    telemetry.InstrumentJobs(metrics.NewPrometheus(registry))
+
+    done := telemetry.TrackJob(context.Background(), "test-job")
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "job_queue_depth", "test-job"))
+
+    done(errors.New("failed"))
+    assert.Equal(t, 0.0, gatheredValue(t, registry, "job_queue_depth", "test-job"))
+    assert.Equal(t, 1.0, gatheredValue(t, registry, "job_runs_total", "test-job", telemetry.JobOutcomeFailure))
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+    "context"
+    "errors"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+)
+
+// tenantKey carries the caller's tenant in test contexts
+type tenantKey struct{}
+
+// fakeTenantSettingsRepository stores tenant settings in memory and counts reads
+type fakeTenantSettingsRepository struct {
+    settings map[string]*models.TenantSettings
+    reads    int
+}
+
+func (r *fakeTenantSettingsRepository) Get(ctx context.Context, tenantID string) (*models.TenantSettings, error) {
+    r.reads++
+    settings, ok := r.settings[tenantID]
+    if !ok {
+        return nil, repository.ErrTenantSettingsNotFound
+    }
+    copied := *settings
+    return &copied, nil
+}
+
+func (r *fakeTenantSettingsRepository) List(ctx context.Context) ([]*models.TenantSettings, error) {
+    var tenants []*models.TenantSettings
+    for _, settings := range r.settings {
+        tenants = append(tenants, settings)
+    }
+    return tenants, nil
+}
+
+func (r *fakeTenantSettingsRepository) Save(ctx context.Context, settings *models.TenantSettings) error {
+    copied := *settings
+    r.settings[settings.TenantID] = &copied
+    return nil
+}
+
+func (r *fakeTenantSettingsRepository) Delete(ctx context.Context, tenantID string) error {
+    if _, ok := r.settings[tenantID]; !ok {
+        return repository.ErrTenantSettingsNotFound
+    }
+    delete(r.settings, tenantID)
+    return nil
+}
+
+// TestTenantSettings tests per-tenant overrides of quotas, content types and draft retention
+func TestTenantSettings(t *testing.T) {
+    repo := &fakeTenantSettingsRepository{settings: make(map[string]*models.TenantSettings)}
+    tenants, err := service.NewTenantSettings(repo, func(ctx context.Context) string {
+        tenantID, _ := ctx.Value(tenantKey{}).(string)
+        return tenantID
+    }, time.Minute)
+    require.NoError(t, err)
+
+    acme := context.WithValue(context.Background(), tenantKey{}, "acme")
+    other := context.WithValue(context.Background(), tenantKey{}, "other")
+
+    _, err = tenants.Update(context.Background(), &models.TenantSettings{
+        TenantID:        "acme",
+        QuotaLimit:      2 * testFileSize,
+        AllowedTypes:    []string{"image/*"},
+        DraftTTLSeconds: 3600,
+    }, "admin-1")
+    require.NoError(t, err)
+
+    t.Run("Overrides Apply To The Tenant", func(t *testing.T) {
+        assert.Equal(t, int64(2*testFileSize), tenants.QuotaLimit(acme, testFileSize))
+        assert.Equal(t, time.Hour, tenants.DraftTTL(acme, time.Minute))
+        assert.NoError(t, tenants.CheckContentType(acme, "image/png"))
+        assert.True(t, errors.Is(tenants.CheckContentType(acme, testContentType), service.ErrUploadNotPermitted))
+    })
+
+    t.Run("Other Tenants Keep The Defaults", func(t *testing.T) {
+        assert.Equal(t, int64(testFileSize), tenants.QuotaLimit(other, testFileSize))
+        assert.Equal(t, int64(testFileSize), tenants.QuotaLimit(context.Background(), testFileSize))
+        assert.NoError(t, tenants.CheckContentType(other, testContentType))
+    })
+
+    t.Run("Settings Are Cached", func(t *testing.T) {
+        reads := repo.reads
+        tenants.QuotaLimit(acme, testFileSize)
+        tenants.QuotaLimit(other, testFileSize)
+        assert.Equal(t, reads, repo.reads)
+    })
+
+    t.Run("Quota Tracker", func(t *testing.T) {
+        tracker, err := service.NewQuotaTracker(newMockRepository(), testFileSize, nil, nil)
+        require.NoError(t, err)
+        tracker.UseTenantSettings(tenants)
+
+        usage, err := tracker.Usage(acme, "user-1")
+        require.NoError(t, err)
+        assert.Equal(t, int64(2*testFileSize), usage.Limit)
+    })
+
+    t.Run("Update Invalidates The Cache", func(t *testing.T) {
+        _, err := tenants.Update(context.Background(), &models.TenantSettings{TenantID: "acme"}, "admin-1")
+        require.NoError(t, err)
+        assert.Equal(t, int64(testFileSize), tenants.QuotaLimit(acme, testFileSize))
+        assert.NoError(t, tenants.CheckContentType(acme, testContentType))
+    })
+
+    t.Run("Delete", func(t *testing.T) {
+        require.NoError(t, tenants.Delete(context.Background(), "acme", "admin-1"))
+        err := tenants.Delete(context.Background(), "acme", "admin-1")
+        assert.True(t, errors.Is(err, service.ErrTenantSettingsNotFound))
+    })
+
+    t.Run("Invalid Settings", func(t *testing.T) {
+        _, err := tenants.Update(context.Background(), &models.TenantSettings{TenantID: "acme", QuotaLimit: -1}, "admin-1")
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+    })
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+    "context"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/config"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/requestctx"
+    "src/backend/file-service/pkg/storagekey"
+)
+
+// TestStorageTenantIsolation tests that storage refuses a tenant every
+// operation on another tenant's objects, presigned downloads included, and
+// never moves content out of its tenant's scope
+func TestStorageTenantIsolation(t *testing.T) {
+    server := httptest.NewServer(newChecksumS3())
+    defer server.Close()
+    store, err := storage.NewS3Storage(&config.Config{S3: config.S3Config{
+        Region:              "us-west-2",
+        Bucket:              "files",
+        AccessKey:           "test",
+        SecretKey:           "test",
+        Endpoint:            server.URL,
+        ForcePathStyle:      true,
+        RetryMax:            1,
+        DownloadConcurrency: 1,
+        TenantIsolation:     true,
+    }}, nil)
+    require.NoError(t, err)
+
+    acme := requestctx.WithPrincipal(context.Background(), &requestctx.Principal{UserID: "alice", TenantID: "acme"})
+    tenantFile := func(tenantID, id string) *models.File {
+        return &models.File{
+            ID:          id,
+            FileName:    testFileName,
+            ContentType: testContentType,
+            Status:      models.FileStatusUploaded,
+            StoragePath: storagekey.Default.ForTenant(tenantID, storage.StorageKey(id)),
+        }
+    }
+    own := tenantFile("acme", "3f2a9c1e-8b7d-4e6f-a5c4-1b2d3e4f5a6b")
+    foreign := tenantFile("globex", "9d8c7b6a-5f4e-4d3c-b2a1-0f9e8d7c6b5a")
+
+    download, err := store.PresignDownload(acme, own, time.Minute, testContentType, "attachment")
+    require.NoError(t, err)
+    assert.Contains(t, download.URL, own.StoragePath)
+
+    _, err = store.PresignDownload(acme, foreign, time.Minute, testContentType, "attachment")
+    assert.ErrorIs(t, err, storage.ErrTenantMismatch)
+    assert.ErrorIs(t, store.Transition(acme, foreign, "GLACIER"), storage.ErrTenantMismatch)
+    _, err = store.StoredChecksum(acme, foreign)
+    assert.ErrorIs(t, err, storage.ErrTenantMismatch)
+    assert.ErrorIs(t, store.MoveContent(acme, foreign, foreign.StoragePath+"-moved"), storage.ErrTenantMismatch)
+
+    // Not even the service's own work moves content to another tenant
+    moved := *own
+    err = store.MoveContent(context.Background(), &moved, storagekey.Default.ForTenant("globex", storage.StorageKey(own.ID)))
+    assert.ErrorIs(t, err, storage.ErrTenantMismatch)
+    assert.Equal(t, own.StoragePath, moved.StoragePath)
+}
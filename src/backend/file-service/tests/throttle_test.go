@@ -0,0 +1,71 @@
+package tests
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/pkg/throttle"
+)
+
+// TestThrottle tests per-caller request limits and window resets
+func TestThrottle(t *testing.T) {
+    t.Run("Counts Per Key", func(t *testing.T) {
+        limiter := throttle.New(2, time.Hour)
+
+        first := limiter.Allow("user-1")
+        assert.True(t, first.Allowed)
+        assert.Equal(t, 1, first.Remaining)
+        assert.True(t, limiter.Allow("user-1").Allowed)
+
+        rejected := limiter.Allow("user-1")
+        assert.False(t, rejected.Allowed)
+        assert.Equal(t, 0, rejected.Remaining)
+        assert.Equal(t, first.Reset, rejected.Reset)
+
+        assert.True(t, limiter.Allow("user-2").Allowed)
+    })
+
+    t.Run("Window Resets", func(t *testing.T) {
+        limiter := throttle.New(1, 20*time.Millisecond)
+        assert.True(t, limiter.Allow("user-1").Allowed)
+        assert.False(t, limiter.Allow("user-1").Allowed)
+
+        time.Sleep(30 * time.Millisecond)
+        assert.True(t, limiter.Allow("user-1").Allowed)
+    })
+}
+
+// TestRateLimitHeaders tests that responses carry the caller's allowance and
+// that callers over their limit are rejected with Retry-After
+func TestRateLimitHeaders(t *testing.T) {
+    handler := handlers.RateLimit(throttle.New(1, time.Minute))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusOK)
+    }))
+    serve := func() *httptest.ResponseRecorder {
+        req := httptest.NewRequest(http.MethodGet, "/download?id=file-1", nil)
+        req = req.WithContext(middleware.ContextWithClaims(req.Context(), &middleware.Claims{UserID: "user-1"}))
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        return rec
+    }
+
+    rec := serve()
+    assert.Equal(t, http.StatusOK, rec.Code)
+    assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+    assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+    reset, err := strconv.ParseInt(rec.Header().Get("X-RateLimit-Reset"), 10, 64)
+    require.NoError(t, err)
+    assert.True(t, reset > time.Now().Unix())
+
+    rec = serve()
+    assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+    assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+}
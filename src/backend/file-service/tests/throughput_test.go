@@ -0,0 +1,70 @@
+package tests
+
+import (
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/pkg/throughput"
+)
+
+const mb = 1 << 20
+
+// TestThroughput tests chunk size recommendations from observed throughput
+func TestThroughput(t *testing.T) {
+    opts := throughput.Options{
+        TargetDuration: 10 * time.Second,
+        MinChunkSize:   5 * mb,
+        MaxChunkSize:   128 * mb,
+        TTL:            time.Hour,
+    }
+
+    t.Run("Recommendations", func(t *testing.T) {
+        estimator := throughput.New(opts)
+        assert.Equal(t, int64(8*mb), estimator.ChunkSize("unknown", 8*mb))
+
+        estimator.Observe("steady", 4*mb, time.Second)
+        assert.Equal(t, int64(40*mb), estimator.ChunkSize("steady", 8*mb))
+
+        // Fast clients are held to the largest chunks, slow ones to the smallest
+        estimator.Observe("fast", 64*mb, time.Second)
+        assert.Equal(t, int64(128*mb), estimator.ChunkSize("fast", 8*mb))
+        estimator.Observe("slow", 2*mb, 4*time.Second)
+        assert.Equal(t, int64(5*mb), estimator.ChunkSize("slow", 8*mb))
+    })
+
+    t.Run("Moving Average", func(t *testing.T) {
+        estimator := throughput.New(opts)
+        estimator.Observe("client", 4*mb, time.Second)
+        estimator.Observe("client", 8*mb, time.Second)
+
+        estimate, ok := estimator.Estimate("client")
+        require.True(t, ok)
+        assert.Equal(t, 2, estimate.Samples)
+        assert.InDelta(t, 5.2*mb, estimate.BytesPerSecond, 1)
+
+        // Small transfers measure latency rather than throughput
+        estimator.Observe("client", 1024, time.Millisecond)
+        estimate, _ = estimator.Estimate("client")
+        assert.Equal(t, 2, estimate.Samples)
+        estimator.Observe("", 4*mb, time.Second)
+        _, ok = estimator.Estimate("")
+        assert.False(t, ok)
+    })
+
+    t.Run("Expiry", func(t *testing.T) {
+        expiring := opts
+        expiring.TTL = 20 * time.Millisecond
+        estimator := throughput.New(expiring)
+        estimator.Observe("client", 4*mb, time.Second)
+        _, ok := estimator.Estimate("client")
+        assert.True(t, ok)
+
+        time.Sleep(30 * time.Millisecond)
+        _, ok = estimator.Estimate("client")
+        assert.False(t, ok)
+        assert.Equal(t, int64(8*mb), estimator.ChunkSize("client", 8*mb))
+    })
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "io"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+)
+
+// fakeTiers records the storage class each object was moved to
+type fakeTiers struct {
+    mu      sync.Mutex
+    classes map[string]string
+}
+
+func (f *fakeTiers) Transition(ctx context.Context, file *models.File, storageClass string) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.classes[file.StoragePath] = storageClass
+    return nil
+}
+
+func (f *fakeTiers) class(storagePath string) string {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.classes[storagePath]
+}
+
+// TestTieringDemoteCold tests moving files not accessed for the cold period to the cold class
+func TestTieringDemoteCold(t *testing.T) {
+    repo := newMockRepository()
+    now := time.Now()
+    for id, idle := range map[string]time.Duration{
+        "file-hot":  time.Hour,
+        "file-cold": 40 * 24 * time.Hour,
+    } {
+        repo.files[id] = &models.File{
+            ID:             id,
+            Status:         models.FileStatusUploaded,
+            StoragePath:    "files/" + id,
+            LastAccessedAt: now.Add(-idle),
+        }
+    }
+
+    tiers := &fakeTiers{classes: make(map[string]string)}
+    tiering, err := service.NewTieringService(repo, tiers, service.TieringOptions{
+        ColdAfter:        30 * 24 * time.Hour,
+        ColdStorageClass: models.StorageClassOneZoneIA,
+        BatchSize:        10,
+    })
+    require.NoError(t, err)
+
+    count, err := tiering.DemoteCold(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 1, count)
+    assert.Equal(t, models.StorageClassOneZoneIA, tiers.class("files/file-cold"))
+    assert.Empty(t, tiers.class("files/file-hot"))
+    assert.True(t, repo.files["file-cold"].IsCold())
+    assert.False(t, repo.files["file-hot"].IsCold())
+
+    // Cold files are not moved again
+    count, err = tiering.DemoteCold(context.Background())
+    require.NoError(t, err)
+    assert.Zero(t, count)
+
+    _, err = service.NewTieringService(repo, tiers, service.TieringOptions{
+        ColdAfter:        time.Hour,
+        ColdStorageClass: models.StorageClassGlacier,
+        BatchSize:        10,
+    })
+    assert.Error(t, err)
+}
+
+// TestTieringPromoteOnAccess tests that downloading a cold file records the
+// access and moves the file back to STANDARD
+func TestTieringPromoteOnAccess(t *testing.T) {
+    ctx := context.Background()
+    repo := newMockRepository()
+    lastAccessed := time.Now().Add(-60 * 24 * time.Hour)
+    repo.files["file-1"] = &models.File{
+        ID:             "file-1",
+        FileName:       "report.pdf",
+        ContentType:    "application/pdf",
+        Status:         models.FileStatusUploaded,
+        StoragePath:    "files/file-1",
+        StorageClass:   models.StorageClassStandardIA,
+        LastAccessedAt: lastAccessed,
+    }
+
+    tiers := &fakeTiers{classes: make(map[string]string)}
+    tiering, err := service.NewTieringService(repo, tiers, service.TieringOptions{
+        ColdAfter:        30 * 24 * time.Hour,
+        ColdStorageClass: models.StorageClassStandardIA,
+        PromoteOnAccess:  true,
+        BatchSize:        10,
+    })
+    require.NoError(t, err)
+
+    mockStore := newMockStorage()
+    fileService, err := service.NewFileService(mockStore, repo, service.WorkerPoolConfig{}, service.WithTiering(tiering))
+    require.NoError(t, err)
+
+    mockStore.files["file-1"] = []byte("%PDF-1.4")
+    mockStore.On("Download", ctx, mock.AnythingOfType("*models.File")).
+        Return(io.NopCloser(bytes.NewReader(nil)), nil).Once()
+    _, reader, err := fileService.Download(ctx, "file-1")
+    require.NoError(t, err)
+    reader.Close()
+
+    assert.Eventually(t, func() bool {
+        return tiers.class("files/file-1") == models.StorageClassStandard
+    }, time.Second, 10*time.Millisecond)
+    assert.Eventually(t, func() bool {
+        file, err := repo.GetByID(ctx, "file-1")
+        return err == nil && !file.IsCold() && file.StorageClass == ""
+    }, time.Second, 10*time.Millisecond)
+
+    file, err := repo.GetByID(ctx, "file-1")
+    require.NoError(t, err)
+    assert.True(t, file.LastAccessedAt.After(lastAccessed))
+}
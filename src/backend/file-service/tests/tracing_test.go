@@ -0,0 +1,72 @@
+package tests
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+
+    "src/backend/file-service/pkg/tracing"
+)
+
+// TestTracing tests trace ID propagation from the traceparent header
+func TestTracing(t *testing.T) {
+    t.Run("Parse Traceparent", func(t *testing.T) {
+        traceID, ok := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+        assert.True(t, ok)
+        assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+
+        for _, header := range []string{
+            "",
+            "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+            "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+            "00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+            "00-4bf92f35-00f067aa0ba902b7-01",
+        } {
+            _, ok := tracing.ParseTraceparent(header)
+            assert.False(t, ok, header)
+        }
+    })
+
+    t.Run("Middleware", func(t *testing.T) {
+        var seen string
+        handler := tracing.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            seen = tracing.TraceID(r.Context())
+        }))
+
+        req := httptest.NewRequest(http.MethodGet, "/health", nil)
+        req.Header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+        rec := httptest.NewRecorder()
+        handler.ServeHTTP(rec, req)
+        assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", seen)
+        assert.Equal(t, seen, rec.Header().Get(tracing.TraceIDHeader))
+
+        rec = httptest.NewRecorder()
+        handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+        assert.Len(t, seen, 32)
+        assert.Equal(t, seen, rec.Header().Get(tracing.TraceIDHeader))
+    })
+
+    t.Run("Background Job", func(t *testing.T) {
+        const requestTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+        parent := tracing.ContextWithTraceID(context.Background(), requestTrace)
+
+        ctx, job := tracing.StartJob(parent, "quota-notify")
+        assert.NotEmpty(t, job.ID)
+        assert.Equal(t, requestTrace, job.LinkedTraceID)
+        assert.NotEqual(t, requestTrace, job.TraceID)
+        assert.Equal(t, job.TraceID, tracing.TraceID(ctx))
+
+        running, ok := tracing.JobFromContext(ctx)
+        assert.True(t, ok)
+        assert.Equal(t, job, running)
+
+        // Outgoing calls continue the job's trace
+        traceID, ok := tracing.ParseTraceparent(tracing.Traceparent(ctx))
+        assert.True(t, ok)
+        assert.Equal(t, job.TraceID, traceID)
+        assert.Empty(t, tracing.Traceparent(context.Background()))
+    })
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "crypto/rand"
+    "errors"
+    "fmt"
+    "io"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/aws/aws-sdk-go-v2/aws"
+    "github.com/aws/aws-sdk-go-v2/service/s3"
+    "github.com/aws/aws-sdk-go-v2/service/s3/types"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/storage"
+)
+
+// rangeClient serves ranged GetObject requests for one object. Each request
+// takes latency plus the time to transfer its bytes at throughput bytes per
+// second, as a single connection to S3 would.
+type rangeClient struct {
+    content    []byte
+    latency    time.Duration
+    throughput int64
+    err        error
+}
+
+func (c *rangeClient) GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+    if c.err != nil {
+        return nil, c.err
+    }
+
+    total := int64(len(c.content))
+    start, end := int64(0), total-1
+    if input.Range != nil {
+        if _, err := fmt.Sscanf(aws.ToString(input.Range), "bytes=%d-%d", &start, &end); err != nil {
+            return nil, err
+        }
+        end = min(end, total-1)
+    }
+
+    delay := c.latency
+    if c.throughput > 0 {
+        delay += time.Duration((end - start + 1) * int64(time.Second) / c.throughput)
+    }
+    select {
+    case <-time.After(delay):
+    case <-ctx.Done():
+        return nil, ctx.Err()
+    }
+
+    if input.Range == nil {
+        return &s3.GetObjectOutput{ContentLength: total, Body: io.NopCloser(bytes.NewReader(c.content))}, nil
+    }
+    return &s3.GetObjectOutput{
+        ContentLength: end - start + 1,
+        ContentRange:  aws.String(fmt.Sprintf("bytes %d-%d/%d", start, end, total)),
+        Body:          io.NopCloser(bytes.NewReader(c.content[start : end+1])),
+    }, nil
+}
+
+// TestStreamObject tests that objects read in parallel parts stream in order
+func TestStreamObject(t *testing.T) {
+    content := make([]byte, 5*1024*1024+123)
+    _, err := rand.Read(content)
+    require.NoError(t, err)
+    client := &rangeClient{content: content}
+    input := &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")}
+
+    for _, opts := range []storage.DownloadOptions{
+        {PartSize: 1024 * 1024, Concurrency: 1},
+        {PartSize: 1024 * 1024, Concurrency: 4},
+        {PartSize: 256 * 1024, Concurrency: 16},
+    } {
+        body, err := storage.StreamObject(context.Background(), client, input, opts)
+        require.NoError(t, err)
+        got, err := io.ReadAll(body)
+        require.NoError(t, err)
+        require.NoError(t, body.Close())
+        assert.True(t, bytes.Equal(content, got), "concurrency %d", opts.Concurrency)
+    }
+
+    // Closing the stream early stops the download
+    body, err := storage.StreamObject(context.Background(), client, input,
+        storage.DownloadOptions{PartSize: 64 * 1024, Concurrency: 4})
+    require.NoError(t, err)
+    _, err = io.ReadFull(body, make([]byte, 1000))
+    require.NoError(t, err)
+    require.NoError(t, body.Close())
+
+    _, err = storage.StreamObject(context.Background(), &rangeClient{err: &types.NoSuchKey{}}, input,
+        storage.DownloadOptions{PartSize: 64 * 1024, Concurrency: 4})
+    var noSuchKey *types.NoSuchKey
+    assert.ErrorAs(t, err, &noSuchKey)
+}
+
+// BenchmarkStreamObject compares reading a 64MB object with a single request
+// against parallel ranged requests, over connections with 20ms of latency
+// and 256MB/s of throughput each
+func BenchmarkStreamObject(b *testing.B) {
+    client := &rangeClient{
+        content:    make([]byte, 64*1024*1024),
+        latency:    20 * time.Millisecond,
+        throughput: 256 * 1024 * 1024,
+    }
+    input := &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")}
+
+    for _, concurrency := range []int{1, 5} {
+        opts := storage.DownloadOptions{PartSize: 8 * 1024 * 1024, Concurrency: concurrency}
+        b.Run(fmt.Sprintf("concurrency-%d", concurrency), func(b *testing.B) {
+            b.SetBytes(int64(len(client.content)))
+            for i := 0; i < b.N; i++ {
+                body, err := storage.StreamObject(context.Background(), client, input, opts)
+                if err != nil {
+                    b.Fatal(err)
+                }
+                if _, err := io.Copy(io.Discard, body); err != nil {
+                    b.Fatal(err)
+                }
+                body.Close()
+            }
+        })
+    }
+}
+
+// copyClient records the requests of object copies, failing the copy of
+// part failPart when it is set
+type copyClient struct {
+    mu        sync.Mutex
+    copies    int
+    created   *s3.CreateMultipartUploadInput
+    ranges    map[int32]string
+    completed []types.CompletedPart
+    aborted   bool
+    failPart  int32
+}
+
+func (c *copyClient) CopyObject(ctx context.Context, input *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+    c.copies++
+    return &s3.CopyObjectOutput{}, nil
+}
+
+func (c *copyClient) CreateMultipartUpload(ctx context.Context, input *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+    c.created = input
+    c.ranges = make(map[int32]string)
+    return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (c *copyClient) UploadPartCopy(ctx context.Context, input *s3.UploadPartCopyInput, optFns ...func(*s3.Options)) (*s3.UploadPartCopyOutput, error) {
+    if input.PartNumber == c.failPart {
+        return nil, errors.New("copy part failed")
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.ranges[input.PartNumber] = aws.ToString(input.CopySourceRange)
+    etag := fmt.Sprintf(`"etag-%d"`, input.PartNumber)
+    return &s3.UploadPartCopyOutput{CopyPartResult: &types.CopyPartResult{ETag: aws.String(etag)}}, nil
+}
+
+func (c *copyClient) CompleteMultipartUpload(ctx context.Context, input *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+    c.completed = input.MultipartUpload.Parts
+    return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (c *copyClient) AbortMultipartUpload(ctx context.Context, input *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+    c.aborted = true
+    return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+// TestCopyObject tests copying objects over 5GB in parts
+func TestCopyObject(t *testing.T) {
+    const gb = 1 << 30
+    input := &s3.CopyObjectInput{
+        Bucket:            aws.String("bucket"),
+        CopySource:        aws.String("bucket/ab/cd/abcdef"),
+        Key:               aws.String("archive/ab/cd/abcdef"),
+        ContentType:       aws.String("video/mp4"),
+        Metadata:          map[string]string{"file-id": "abcdef"},
+        MetadataDirective: types.MetadataDirectiveReplace,
+        TaggingDirective:  types.TaggingDirectiveReplace,
+        Tagging:           aws.String("team=video"),
+    }
+    opts := storage.CopyOptions{PartSize: 2 * gb, Concurrency: 3}
+
+    t.Run("Single Copy", func(t *testing.T) {
+        client := &copyClient{}
+        require.NoError(t, storage.CopyObject(context.Background(), client, input, storage.MaxCopyObjectSize, opts))
+        assert.Equal(t, 1, client.copies)
+        assert.Nil(t, client.created)
+    })
+
+    t.Run("Parts", func(t *testing.T) {
+        client := &copyClient{}
+        size := int64(5*gb + 123)
+        require.NoError(t, storage.CopyObject(context.Background(), client, input, size, opts))
+        assert.Zero(t, client.copies)
+        assert.False(t, client.aborted)
+
+        require.NotNil(t, client.created)
+        assert.Equal(t, "video/mp4", aws.ToString(client.created.ContentType))
+        assert.Equal(t, "abcdef", client.created.Metadata["file-id"])
+        assert.Equal(t, "team=video", aws.ToString(client.created.Tagging))
+
+        assert.Equal(t, map[int32]string{
+            1: fmt.Sprintf("bytes=0-%d", 2*gb-1),
+            2: fmt.Sprintf("bytes=%d-%d", 2*gb, 4*gb-1),
+            3: fmt.Sprintf("bytes=%d-%d", 4*gb, size-1),
+        }, client.ranges)
+        require.Len(t, client.completed, 3)
+        for i, part := range client.completed {
+            assert.Equal(t, int32(i+1), part.PartNumber)
+            assert.Equal(t, fmt.Sprintf(`"etag-%d"`, i+1), aws.ToString(part.ETag))
+        }
+    })
+
+    t.Run("Part Size Grows To The Part Limit", func(t *testing.T) {
+        client := &copyClient{}
+        small := storage.CopyOptions{PartSize: 5 << 20, Concurrency: 8}
+        require.NoError(t, storage.CopyObject(context.Background(), client, input, 100*gb, small))
+        assert.LessOrEqual(t, len(client.completed), 10000)
+        assert.Greater(t, len(client.completed), 9000)
+    })
+
+    t.Run("Failed Part", func(t *testing.T) {
+        client := &copyClient{failPart: 2}
+        err := storage.CopyObject(context.Background(), client, input, 6*gb, opts)
+        assert.ErrorContains(t, err, "part 2")
+        assert.True(t, client.aborted)
+        assert.Nil(t, client.completed)
+    })
+}
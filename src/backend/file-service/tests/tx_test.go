@@ -0,0 +1,108 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/mock"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+)
+
+// snapshotTxManager runs units of work against the in-memory blob repository,
+// restoring its state when the unit of work fails
+type snapshotTxManager struct {
+    blobs     *mockBlobRepository
+    commits   int
+    rollbacks int
+}
+
+func (m *snapshotTxManager) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+    m.blobs.mu.Lock()
+    saved := make(map[string]*models.Blob, len(m.blobs.blobs))
+    for key, blob := range m.blobs.blobs {
+        copied := *blob
+        saved[key] = &copied
+    }
+    m.blobs.mu.Unlock()
+
+    if err := fn(ctx); err != nil {
+        m.blobs.mu.Lock()
+        m.blobs.blobs = saved
+        m.blobs.mu.Unlock()
+        m.rollbacks++
+        return err
+    }
+    m.commits++
+    return nil
+}
+
+// failingCreateRepository fails to insert file records
+type failingCreateRepository struct {
+    *mockRepository
+}
+
+func (r *failingCreateRepository) Create(ctx context.Context, file *models.File) error {
+    return errors.New("insert failed")
+}
+
+// TestUnitOfWork tests that blob references and file records are written as
+// one unit of work
+func TestUnitOfWork(t *testing.T) {
+    ctx := context.Background()
+
+    newService := func(t *testing.T, files repository.FileRepository) (service.FileService, *mockBlobRepository, *snapshotTxManager) {
+        mockStore := newMockStorage()
+        mockStore.On("Upload", ctx, mock.AnythingOfType("*models.File"), mock.AnythingOfType("*io.teeReader")).
+            Run(func(args mock.Arguments) {
+                file := args.Get(1).(*models.File)
+                io.Copy(io.Discard, args.Get(2).(io.Reader))
+                file.SetStoragePath(storage.StorageKey(file.ID))
+            }).
+            Return(nil)
+
+        blobs := newMockBlobRepository()
+        tx := &snapshotTxManager{blobs: blobs}
+        fileService, err := service.NewFileService(mockStore, files, service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        }, service.WithBlobs(blobs, &fakeObjectStore{}, time.Hour), service.WithTransactions(tx))
+        require.NoError(t, err)
+        return fileService, blobs, tx
+    }
+
+    t.Run("Commits Together", func(t *testing.T) {
+        fileService, blobs, tx := newService(t, newMockRepository())
+
+        file, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{})
+        require.NoError(t, err)
+        assert.Equal(t, 1, blobs.blobs[file.StoragePath].RefCount)
+
+        require.NoError(t, fileService.Delete(ctx, file.ID, false))
+        assert.Equal(t, 0, blobs.blobs[file.StoragePath].RefCount)
+        assert.Equal(t, 2, tx.commits)
+        assert.Equal(t, 0, tx.rollbacks)
+    })
+
+    t.Run("Rolls Back Blob Reference", func(t *testing.T) {
+        fileService, blobs, tx := newService(t, &failingCreateRepository{newMockRepository()})
+
+        _, err := fileService.Upload(ctx, testFileName, testContentType, testFileSize,
+            bytes.NewReader(testPDFContent()), service.UploadOptions{})
+        assert.ErrorIs(t, err, service.ErrOperationFailed)
+        assert.Equal(t, 1, tx.rollbacks)
+        // The blob acquired in the failed unit of work is gone entirely,
+        // rather than left behind unreferenced
+        assert.Empty(t, blobs.blobs)
+    })
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/middleware"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/validator"
+)
+
+const testPolicy = `{
+    "rules": [
+        {"roles": ["*"], "contentTypes": ["application/pdf", "image/*"], "maxSize": 104857600},
+        {"roles": ["designer"], "contentTypes": ["image/vnd.adobe.photoshop", "application/postscript"], "maxSize": 1073741824}
+    ]
+}`
+
+// TestUploadPolicy tests per-role content type and size rules
+func TestUploadPolicy(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "upload-policy.json")
+    require.NoError(t, os.WriteFile(path, []byte(testPolicy), 0o600))
+
+    policy, err := service.LoadUploadPolicy(path)
+    require.NoError(t, err)
+
+    t.Run("Shared Rule", func(t *testing.T) {
+        assert.NoError(t, policy.Check(nil, "application/pdf", testFileSize))
+        assert.NoError(t, policy.Check([]string{"viewer"}, "image/png", testFileSize))
+    })
+
+    t.Run("Role Rule", func(t *testing.T) {
+        psdSize := int64(512 * 1024 * 1024)
+        assert.NoError(t, policy.Check([]string{"Designer"}, "image/vnd.adobe.photoshop", psdSize))
+
+        err := policy.Check([]string{"viewer"}, "application/postscript", testFileSize)
+        assert.True(t, errors.Is(err, service.ErrUploadNotPermitted))
+
+        var validationErr *validator.ValidationError
+        require.True(t, errors.As(err, &validationErr))
+        assert.Equal(t, "TYPE_NOT_PERMITTED", validationErr.Code)
+    })
+
+    t.Run("Size Limit", func(t *testing.T) {
+        err := policy.Check([]string{"designer"}, "application/postscript", 2*1024*1024*1024)
+        assert.True(t, errors.Is(err, service.ErrUploadTooLarge))
+
+        // Shared image rule is limited to 100MB even for designers
+        err = policy.Check([]string{"designer"}, "image/png", 200*1024*1024)
+        assert.True(t, errors.Is(err, service.ErrUploadTooLarge))
+    })
+
+    t.Run("Rules For Roles", func(t *testing.T) {
+        assert.Len(t, policy.RulesFor(nil), 1)
+        assert.Len(t, policy.RulesFor([]string{"designer"}), 2)
+    })
+
+    t.Run("Limits", func(t *testing.T) {
+        maxSize, types := policy.Limits([]string{"designer"})
+        assert.Equal(t, int64(1073741824), maxSize)
+        assert.Equal(t, []string{"application/pdf", "image/*", "image/vnd.adobe.photoshop", "application/postscript"}, types)
+
+        maxSize, types = policy.Limits(nil)
+        assert.Equal(t, int64(104857600), maxSize)
+        assert.Len(t, types, 2)
+    })
+
+    t.Run("Invalid Policy", func(t *testing.T) {
+        invalid := &service.UploadPolicy{Rules: []service.UploadRule{{Roles: []string{"*"}, ContentTypes: []string{"application/pdf"}}}}
+        assert.Error(t, invalid.Validate())
+    })
+}
+
+// TestUploadHints tests that GET /upload/policy reports the caller's effective
+// constraints and upload modes
+func TestUploadHints(t *testing.T) {
+    policy := &service.UploadPolicy{Rules: []service.UploadRule{
+        {Roles: []string{"*"}, ContentTypes: []string{"application/pdf"}, MaxSize: 1024},
+        {Roles: []string{"editor"}, ContentTypes: []string{"image/*"}, MaxSize: 4096},
+    }}
+    quota, err := service.NewQuotaTracker(newMockRepository(), 10000, nil, nil)
+    require.NoError(t, err)
+
+    handler := handlers.NewPolicyHandler(policy, handlers.UploadHints{ChunkSize: 512, MaxChunks: 10}, quota)
+
+    req := httptest.NewRequest(http.MethodGet, "/upload/policy", nil)
+    req = req.WithContext(middleware.ContextWithClaims(req.Context(), &middleware.Claims{
+        UserID: "alice",
+        Roles:  []string{"editor"},
+    }))
+    rec := httptest.NewRecorder()
+    handler.UploadHintsHandler(rec, req)
+    require.Equal(t, http.StatusOK, rec.Code)
+
+    var hints struct {
+        MaxSize      int64    `json:"maxSize"`
+        AllowedTypes []string `json:"allowedTypes"`
+        Modes        []string `json:"modes"`
+        DirectUpload bool     `json:"directUpload"`
+        Chunked      struct {
+            ChunkSize int64 `json:"chunkSize"`
+        } `json:"chunked"`
+        Quota *struct {
+            Remaining int64 `json:"remaining"`
+        } `json:"quota"`
+    }
+    require.NoError(t, json.NewDecoder(rec.Body).Decode(&hints))
+    assert.Equal(t, int64(4096), hints.MaxSize)
+    assert.Equal(t, []string{"application/pdf", "image/*"}, hints.AllowedTypes)
+    assert.Contains(t, hints.Modes, "chunked")
+    assert.False(t, hints.DirectUpload)
+    assert.Equal(t, int64(512), hints.Chunked.ChunkSize)
+    require.NotNil(t, hints.Quota)
+    assert.Equal(t, int64(10000), hints.Quota.Remaining)
+}
@@ -0,0 +1,392 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "io"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/repository"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/internal/storage"
+    "src/backend/file-service/pkg/requestctx"
+)
+
+const testChunkSize = 5 * 1024 * 1024 // 5MB
+
+func chunkChecksum(data string) string {
+    sum := sha256.Sum256([]byte(data))
+    return hex.EncodeToString(sum[:])
+}
+
+// TestUploadSession tests chunk accounting and checksum verification of upload sessions
+func TestUploadSession(t *testing.T) {
+    totalSize := int64(2*testChunkSize + 1024)
+
+    t.Run("Chunk Layout", func(t *testing.T) {
+        session, err := models.NewUploadSession(testFileName, testContentType, totalSize, testChunkSize, time.Hour)
+        require.NoError(t, err)
+        assert.Equal(t, 3, session.ChunkCount)
+
+        size, err := session.ExpectedChunkSize(1)
+        require.NoError(t, err)
+        assert.Equal(t, int64(testChunkSize), size)
+
+        size, err = session.ExpectedChunkSize(3)
+        require.NoError(t, err)
+        assert.Equal(t, int64(1024), size)
+
+        _, err = session.ExpectedChunkSize(4)
+        assert.True(t, errors.Is(err, models.ErrInvalidChunkNumber))
+    })
+
+    t.Run("Record And Verify Parts", func(t *testing.T) {
+        session, err := models.NewUploadSession(testFileName, testContentType, totalSize, testChunkSize, time.Hour)
+        require.NoError(t, err)
+
+        // Out-of-order arrival and a re-sent chunk
+        require.NoError(t, session.RecordPart(models.UploadPart{Number: 3, Size: 1024, Checksum: chunkChecksum("c")}))
+        require.NoError(t, session.RecordPart(models.UploadPart{Number: 1, Size: testChunkSize, Checksum: chunkChecksum("x")}))
+        require.NoError(t, session.RecordPart(models.UploadPart{Number: 1, Size: testChunkSize, Checksum: chunkChecksum("a")}))
+        assert.Equal(t, []int{2}, session.MissingChunks())
+
+        err = session.VerifyChecksums([]string{chunkChecksum("a"), chunkChecksum("c")})
+        assert.True(t, errors.Is(err, models.ErrChunkMissing))
+
+        require.NoError(t, session.RecordPart(models.UploadPart{Number: 2, Size: testChunkSize, Checksum: chunkChecksum("b")}))
+        assert.Empty(t, session.MissingChunks())
+
+        err = session.VerifyChecksums([]string{chunkChecksum("a"), chunkChecksum("x"), chunkChecksum("c")})
+        assert.True(t, errors.Is(err, models.ErrChecksumMismatch))
+
+        err = session.VerifyChecksums([]string{chunkChecksum("a"), chunkChecksum("b"), chunkChecksum("c")})
+        assert.NoError(t, err)
+        assert.Regexp(t, `^[0-9a-f]{64}-3$`, session.CompositeChecksum())
+    })
+
+    t.Run("Reject Wrong Chunk Size", func(t *testing.T) {
+        session, err := models.NewUploadSession(testFileName, testContentType, totalSize, testChunkSize, time.Hour)
+        require.NoError(t, err)
+
+        err = session.RecordPart(models.UploadPart{Number: 2, Size: 1024, Checksum: chunkChecksum("b")})
+        assert.True(t, errors.Is(err, models.ErrInvalidChunkSize))
+    })
+
+    t.Run("Expired Session", func(t *testing.T) {
+        session, err := models.NewUploadSession(testFileName, testContentType, totalSize, testChunkSize, -time.Minute)
+        require.NoError(t, err)
+        assert.True(t, errors.Is(session.CheckWritable(), models.ErrSessionExpired))
+    })
+}
+
+// mockSessionRepository keeps upload sessions in memory
+type mockSessionRepository struct {
+    mu       sync.Mutex
+    sessions map[string]*models.UploadSession
+}
+
+func newMockSessionRepository() *mockSessionRepository {
+    return &mockSessionRepository{sessions: make(map[string]*models.UploadSession)}
+}
+
+func (m *mockSessionRepository) Create(ctx context.Context, session *models.UploadSession) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    stored := *session
+    m.sessions[session.ID] = &stored
+    return nil
+}
+
+func (m *mockSessionRepository) GetByID(ctx context.Context, id string) (*models.UploadSession, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    session, ok := m.sessions[id]
+    if !ok {
+        return nil, repository.ErrSessionNotFound
+    }
+    found := *session
+    found.Parts = append([]models.UploadPart(nil), session.Parts...)
+    return &found, nil
+}
+
+func (m *mockSessionRepository) SavePart(ctx context.Context, sessionID string, part *models.UploadPart) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    session, ok := m.sessions[sessionID]
+    if !ok {
+        return repository.ErrSessionNotFound
+    }
+    return session.RecordPart(*part)
+}
+
+func (m *mockSessionRepository) UpdateStatus(ctx context.Context, id string, status string) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    session, ok := m.sessions[id]
+    if !ok {
+        return repository.ErrSessionNotFound
+    }
+    session.Status = status
+    return nil
+}
+
+func (m *mockSessionRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]*models.UploadSession, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var expired []*models.UploadSession
+    for _, session := range m.sessions {
+        if session.Status == models.UploadSessionStatusOpen && session.ExpiresAt.Before(before) && len(expired) < limit {
+            found := *session
+            expired = append(expired, &found)
+        }
+    }
+    return expired, nil
+}
+
+func (m *mockSessionRepository) status(id string) string {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.sessions[id].Status
+}
+
+// mockMultipartStorage keeps multipart uploads in memory, recording the
+// uploads it aborts
+type mockMultipartStorage struct {
+    mu      sync.Mutex
+    parts   map[string]map[int][]byte
+    uploads []storage.MultipartUpload
+    aborted []string
+}
+
+func newMockMultipartStorage() *mockMultipartStorage {
+    return &mockMultipartStorage{parts: make(map[string]map[int][]byte)}
+}
+
+func (m *mockMultipartStorage) InitiateMultipart(ctx context.Context, session *models.UploadSession) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    session.StorageKey = storage.StorageKey(session.FileID)
+    session.MultipartUploadID = "upload-" + session.ID
+    m.parts[session.MultipartUploadID] = make(map[int][]byte)
+    return nil
+}
+
+func (m *mockMultipartStorage) UploadPart(ctx context.Context, session *models.UploadSession, number int, size int64,
+    reader io.Reader) (*models.UploadPart, error) {
+
+    data, err := io.ReadAll(reader)
+    if err != nil {
+        return nil, err
+    }
+    // S3 keeps no part of a request that was cancelled
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+    if int64(len(data)) != size {
+        return nil, io.ErrUnexpectedEOF
+    }
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.parts[session.MultipartUploadID][number] = data
+    return &models.UploadPart{Number: number, Size: size, Checksum: chunkChecksum(string(data))}, nil
+}
+
+func (m *mockMultipartStorage) CompleteMultipart(ctx context.Context, session *models.UploadSession, file *models.File) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    delete(m.parts, session.MultipartUploadID)
+    return nil
+}
+
+func (m *mockMultipartStorage) AbortMultipart(ctx context.Context, session *models.UploadSession) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    delete(m.parts, session.MultipartUploadID)
+    m.aborted = append(m.aborted, session.MultipartUploadID)
+    return nil
+}
+
+func (m *mockMultipartStorage) ListMultipartUploads(ctx context.Context, initiatedBefore time.Time,
+    limit int) ([]storage.MultipartUpload, error) {
+
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    var uploads []storage.MultipartUpload
+    for _, upload := range m.uploads {
+        if upload.Initiated.Before(initiatedBefore) && len(uploads) < limit {
+            uploads = append(uploads, upload)
+        }
+    }
+    return uploads, nil
+}
+
+func (m *mockMultipartStorage) AbortMultipartUpload(ctx context.Context, upload storage.MultipartUpload) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.aborted = append(m.aborted, upload.UploadID)
+    return nil
+}
+
+func (m *mockMultipartStorage) abortedUploads() []string {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return append([]string(nil), m.aborted...)
+}
+
+// asSessionOwner returns a context authenticated as userID
+func asSessionOwner(userID string) context.Context {
+    return requestctx.WithPrincipal(context.Background(), &requestctx.Principal{UserID: userID, Roles: []string{"user"}})
+}
+
+// sessionChunkSize is the chunk size of sessions in service tests
+const sessionChunkSize = 1024
+
+// sessionChunk returns chunk content of the given size, starting with a PDF
+// header so that it passes magic number checks
+func sessionChunk(size int) []byte {
+    chunk := bytes.Repeat([]byte("x"), size)
+    copy(chunk, "%PDF-1.7\n")
+    return chunk
+}
+
+// newTestSessionService returns an upload session service for sessions of
+// two sessionChunkSize chunks, expiring after an hour
+func newTestSessionService(t *testing.T) (service.UploadSessionService, *mockSessionRepository, *mockMultipartStorage) {
+    sessions := newMockSessionRepository()
+    multipart := newMockMultipartStorage()
+    svc, err := service.NewUploadSessionService(multipart, sessions, newMockRepository(), service.ChunkedUploadConfig{
+        ChunkSize:  sessionChunkSize,
+        MaxChunks:  10,
+        SessionTTL: time.Hour,
+    })
+    require.NoError(t, err)
+    return svc, sessions, multipart
+}
+
+// TestUploadSessionOwner tests that only the owner of an upload session can
+// see, continue, complete or abort it
+func TestUploadSessionOwner(t *testing.T) {
+    svc, sessions, multipart := newTestSessionService(t)
+
+    owner := asSessionOwner("user-1")
+    session, err := svc.Initiate(owner, testFileName, testContentType, 2*sessionChunkSize, "user-1", []string{"user"})
+    require.NoError(t, err)
+    first, second := sessionChunk(sessionChunkSize), sessionChunk(sessionChunkSize)
+
+    for name, ctx := range map[string]context.Context{
+        "Other User": asSessionOwner("user-2"),
+        "Anonymous":  context.Background(),
+    } {
+        t.Run(name, func(t *testing.T) {
+            _, err := svc.Get(ctx, session.ID)
+            assert.True(t, errors.Is(err, service.ErrSessionNotFound))
+
+            _, err = svc.UploadChunk(ctx, session.ID, 1, sessionChunkSize, "", bytes.NewReader(first))
+            assert.True(t, errors.Is(err, service.ErrSessionNotFound))
+
+            _, err = svc.Complete(ctx, session.ID, []string{chunkChecksum(string(first)), chunkChecksum(string(second))})
+            assert.True(t, errors.Is(err, service.ErrSessionNotFound))
+
+            err = svc.Abort(ctx, session.ID)
+            assert.True(t, errors.Is(err, service.ErrSessionNotFound))
+
+            assert.Equal(t, models.UploadSessionStatusOpen, sessions.status(session.ID))
+            assert.Empty(t, multipart.abortedUploads())
+        })
+    }
+
+    t.Run("Owner", func(t *testing.T) {
+        found, err := svc.Get(owner, session.ID)
+        require.NoError(t, err)
+        assert.Empty(t, found.Parts)
+
+        _, err = svc.UploadChunk(owner, session.ID, 1, sessionChunkSize, "", bytes.NewReader(first))
+        require.NoError(t, err)
+        _, err = svc.UploadChunk(owner, session.ID, 2, sessionChunkSize, "", bytes.NewReader(second))
+        require.NoError(t, err)
+
+        file, err := svc.Complete(owner, session.ID, []string{chunkChecksum(string(first)), chunkChecksum(string(second))})
+        require.NoError(t, err)
+        assert.Equal(t, "user-1", file.OwnerID)
+        assert.Equal(t, models.UploadSessionStatusCompleted, sessions.status(session.ID))
+    })
+}
+
+// TestUploadSessionSweep tests expiring abandoned sessions and aborting
+// multipart uploads left in the bucket
+func TestUploadSessionSweep(t *testing.T) {
+    svc, sessions, multipart := newTestSessionService(t)
+    owner := asSessionOwner("user-1")
+
+    expired, err := svc.Initiate(owner, testFileName, testContentType, 2*sessionChunkSize, "user-1", []string{"user"})
+    require.NoError(t, err)
+    active, err := svc.Initiate(owner, testFileName, testContentType, 2*sessionChunkSize, "user-1", []string{"user"})
+    require.NoError(t, err)
+    sessions.sessions[expired.ID].ExpiresAt = time.Now().UTC().Add(-time.Minute)
+
+    // Uploads are abandoned once they are older than the session TTL and
+    // the grace period after it
+    now := time.Now().UTC()
+    multipart.uploads = []storage.MultipartUpload{
+        {Key: "files/stale", UploadID: "stale", Initiated: now.Add(-3 * time.Hour)},
+        {Key: "files/recent", UploadID: "recent", Initiated: now.Add(-90 * time.Minute)},
+    }
+
+    swept, err := svc.SweepAbandoned(context.Background())
+    require.NoError(t, err)
+    assert.Equal(t, 2, swept)
+    assert.ElementsMatch(t, []string{expired.MultipartUploadID, "stale"}, multipart.abortedUploads())
+    assert.Equal(t, models.UploadSessionStatusExpired, sessions.status(expired.ID))
+    assert.Equal(t, models.UploadSessionStatusOpen, sessions.status(active.ID))
+
+    // Expired sessions are only swept once
+    multipart.uploads = nil
+    swept, err = svc.SweepAbandoned(context.Background())
+    require.NoError(t, err)
+    assert.Zero(t, swept)
+}
+
+// TestUploadSessionInterrupted tests that a chunk upload the client abandons
+// leaves the session open for the chunk to be sent again
+func TestUploadSessionInterrupted(t *testing.T) {
+    svc, sessions, multipart := newTestSessionService(t)
+    owner := asSessionOwner("user-1")
+    session, err := svc.Initiate(owner, testFileName, testContentType, 2*sessionChunkSize, "user-1", []string{"user"})
+    require.NoError(t, err)
+    chunk := sessionChunk(sessionChunkSize)
+
+    t.Run("Cancelled Request", func(t *testing.T) {
+        ctx, cancel := context.WithCancel(owner)
+        cancel()
+        _, err := svc.UploadChunk(ctx, session.ID, 1, sessionChunkSize, "", bytes.NewReader(chunk))
+        assert.True(t, errors.Is(err, service.ErrUploadInterrupted))
+    })
+
+    t.Run("Body Ended Early", func(t *testing.T) {
+        _, err := svc.UploadChunk(owner, session.ID, 1, sessionChunkSize, "", bytes.NewReader(chunk[:sessionChunkSize/2]))
+        assert.True(t, errors.Is(err, service.ErrUploadInterrupted))
+    })
+
+    found, err := svc.Get(owner, session.ID)
+    require.NoError(t, err)
+    assert.Equal(t, models.UploadSessionStatusOpen, found.Status)
+    assert.Empty(t, found.Parts)
+    assert.Empty(t, multipart.abortedUploads())
+
+    _, err = svc.UploadChunk(owner, session.ID, 1, sessionChunkSize, chunkChecksum(string(chunk)), bytes.NewReader(chunk))
+    require.NoError(t, err)
+    found, err = svc.Get(owner, session.ID)
+    require.NoError(t, err)
+    assert.Equal(t, []int{2}, found.MissingChunks())
+    assert.Equal(t, models.UploadSessionStatusOpen, sessions.status(session.ID))
+}
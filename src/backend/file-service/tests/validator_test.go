@@ -0,0 +1,88 @@
+package tests
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/validator"
+)
+
+func masqueradeCodes(findings []*validator.ValidationError) []string {
+    codes := make([]string, 0, len(findings))
+    for _, finding := range findings {
+        codes = append(codes, finding.Code)
+    }
+    return codes
+}
+
+// TestMasqueradeDetection tests detection of files disguised as another type
+func TestMasqueradeDetection(t *testing.T) {
+    testCases := []struct {
+        name     string
+        fileName string
+        header   []byte
+        expected []string
+    }{
+        {"Plain Document", "report.pdf", []byte("%PDF-1.7"), []string{}},
+        {"Compound Extension", "backup.tar.gz", nil, []string{}},
+        {"Double Extension", "invoice.pdf.exe", nil,
+            []string{validator.CodeExecutableExtension, validator.CodeDoubleExtension}},
+        {"Padded Double Extension", "invoice.pdf    .exe. ", nil,
+            []string{validator.CodeExecutableExtension, validator.CodeDoubleExtension}},
+        {"Right-To-Left Override", "invoice\u202Efdp.exe", nil,
+            []string{validator.CodeBidiControl, validator.CodeExecutableExtension}},
+        {"Renamed Executable", "report.pdf", []byte("MZ\x90\x00\x03"), []string{validator.CodeExecutableContent}},
+        {"Renamed ELF Binary", "notes.txt", []byte("\x7fELF\x02\x01"), []string{validator.CodeExecutableContent}},
+    }
+
+    for _, tc := range testCases {
+        t.Run(tc.name, func(t *testing.T) {
+            findings := validator.DetectMasquerading(tc.fileName, tc.header)
+            assert.ElementsMatch(t, tc.expected, masqueradeCodes(findings))
+        })
+    }
+
+    t.Run("Policy Overrides", func(t *testing.T) {
+        header := []byte("MZ\x90\x00")
+
+        _, err := validator.CheckMasquerading("report.pdf", header, validator.MasqueradePolicy{})
+        var validationErr *validator.ValidationError
+        require.True(t, errors.As(err, &validationErr))
+        assert.Equal(t, validator.CodeExecutableContent, validationErr.Code)
+
+        findings, err := validator.CheckMasquerading("report.pdf", header, validator.MasqueradePolicy{FlagOnly: true})
+        assert.NoError(t, err)
+        assert.Len(t, findings, 1)
+
+        findings, err = validator.CheckMasquerading("report.pdf", header, validator.MasqueradePolicy{
+            AllowedCodes: []string{validator.CodeExecutableContent},
+        })
+        assert.NoError(t, err)
+        assert.Empty(t, findings)
+    })
+
+    t.Run("Upload Rejected", func(t *testing.T) {
+        mockStore := newMockStorage()
+        fileService, err := service.NewFileService(mockStore, newMockRepository(), service.WorkerPoolConfig{
+            MaxWorkers: maxConcurrentOps,
+            BufferSize: 32 * 1024,
+        })
+        require.NoError(t, err)
+
+        content := append([]byte("MZ"), bytes.Repeat([]byte{0}, 1022)...)
+        _, err = fileService.Upload(context.Background(), testFileName, testContentType, int64(len(content)),
+            bytes.NewReader(content), service.UploadOptions{})
+        assert.True(t, errors.Is(err, service.ErrInvalidInput))
+
+        var validationErr *validator.ValidationError
+        require.True(t, errors.As(err, &validationErr))
+        assert.Equal(t, validator.CodeExecutableContent, validationErr.Code)
+        mockStore.AssertNotCalled(t, "Upload")
+    })
+}
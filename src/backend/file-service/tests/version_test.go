@@ -0,0 +1,35 @@
+package tests
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/pkg/buildinfo"
+)
+
+// TestVersionHandler tests that GET /version reports the embedded build details
+func TestVersionHandler(t *testing.T) {
+    rec := httptest.NewRecorder()
+    handlers.VersionHandler(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+    require.Equal(t, http.StatusOK, rec.Code)
+
+    var info buildinfo.Info
+    require.NoError(t, json.NewDecoder(rec.Body).Decode(&info))
+    assert.Equal(t, buildinfo.Version, info.Version)
+    assert.Equal(t, buildinfo.GitSHA, info.GitSHA)
+    assert.NotEmpty(t, info.GoVersion)
+
+    rec = httptest.NewRecorder()
+    handlers.VersionHandler(rec, httptest.NewRequest(http.MethodPost, "/version", nil))
+    assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+    var body map[string]string
+    require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+    assert.Equal(t, buildinfo.Version, body["version"], "error responses carry the version")
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+    "bytes"
+    "image"
+    "image/color"
+    "image/png"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/stretchr/testify/assert"
+    "github.com/stretchr/testify/require"
+
+    "src/backend/file-service/internal/handlers"
+    "src/backend/file-service/internal/models"
+    "src/backend/file-service/internal/service"
+    "src/backend/file-service/pkg/metrics"
+)
+
+const testWatermarkPolicy = `{
+    "default": {"text": "{email} {time}"},
+    "tenants": {
+        "acme": {"text": "ACME CONFIDENTIAL {user}", "contentTypes": ["image/*"], "opacity": 0.5},
+        "internal": null
+    }
+}`
+
+// testPNG returns a white PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+    img := image.NewRGBA(image.Rect(0, 0, width, height))
+    for y := 0; y < height; y++ {
+        for x := 0; x < width; x++ {
+            img.Set(x, y, color.White)
+        }
+    }
+
+    var buf bytes.Buffer
+    require.NoError(t, png.Encode(&buf, img))
+    return buf.Bytes()
+}
+
+// TestWatermarkPolicy tests per-tenant watermark rule resolution
+func TestWatermarkPolicy(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "watermark-policy.json")
+    require.NoError(t, os.WriteFile(path, []byte(testWatermarkPolicy), 0o600))
+
+    policy, err := service.LoadWatermarkPolicy(path)
+    require.NoError(t, err)
+
+    rule := policy.RuleFor("", "application/pdf")
+    require.NotNil(t, rule)
+    assert.Equal(t, 0.3, rule.Opacity)
+
+    assert.NotNil(t, policy.RuleFor("acme", "image/png"))
+    assert.Nil(t, policy.RuleFor("acme", "application/pdf"))
+    assert.Nil(t, policy.RuleFor("internal", "application/pdf"))
+    assert.Nil(t, policy.RuleFor("", "text/plain"))
+
+    invalid := &service.WatermarkPolicy{Default: &service.WatermarkRule{Text: "x", Opacity: 2}}
+    assert.Error(t, invalid.Validate())
+}
+
+// TestWatermarker tests stamping downloaded images
+func TestWatermarker(t *testing.T) {
+    policy := &service.WatermarkPolicy{Default: &service.WatermarkRule{Text: "{user} {file}"}}
+    require.NoError(t, policy.Validate())
+
+    content := testPNG(t, 400, 200)
+    file := &models.File{ID: "file-1", FileName: "photo.png", ContentType: "image/png", Size: int64(len(content))}
+    viewer := service.WatermarkViewer{UserID: "bob"}
+
+    t.Run("Stamps Image", func(t *testing.T) {
+        watermarker, err := service.NewWatermarker(policy, 1024*1024)
+        require.NoError(t, err)
+
+        rule := watermarker.RuleFor(file, viewer)
+        require.NotNil(t, rule)
+
+        stamped, err := watermarker.Stamp(rule, file, bytes.NewReader(content), viewer)
+        require.NoError(t, err)
+
+        img, err := png.Decode(bytes.NewReader(stamped))
+        require.NoError(t, err)
+        assert.Equal(t, image.Rect(0, 0, 400, 200), img.Bounds())
+
+        marked := false
+        for y := 0; y < 200 && !marked; y++ {
+            for x := 0; x < 400; x++ {
+                if r, _, _, _ := img.At(x, y).RGBA(); r != 0xffff {
+                    marked = true
+                    break
+                }
+            }
+        }
+        assert.True(t, marked, "expected watermark pixels")
+    })
+
+    t.Run("Too Large", func(t *testing.T) {
+        watermarker, err := service.NewWatermarker(policy, int64(len(content)-1))
+        require.NoError(t, err)
+
+        _, err = watermarker.Stamp(policy.Default, file, bytes.NewReader(content), viewer)
+        assert.ErrorIs(t, err, service.ErrWatermarkTooLarge)
+    })
+}
+
+// pdfGenerator derives PDF copies of PNG images, as the conversion pipeline
+// does, counting runs
+type pdfGenerator struct {
+    runs int
+}
+
+func (g *pdfGenerator) Kind() string { return models.DerivedKindConverted }
+
+func (g *pdfGenerator) Supports(file *models.File) bool { return file.ContentType == "image/png" }
+
+func (g *pdfGenerator) Params(requested map[string]string) (map[string]string, error) {
+    return map[string]string{"format": requested["format"]}, nil
+}
+
+func (g *pdfGenerator) Generate(file *models.File, params map[string]string, content []byte) ([]byte, string, error) {
+    g.runs++
+    return append([]byte("%PDF-1.7 "), content...), "application/pdf", nil
+}
+
+// TestWatermarkedConversions tests that files whose downloads are
+// watermarked cannot be fetched as unmarked converted copies
+func TestWatermarkedConversions(t *testing.T) {
+    repo := newMockRepository()
+    repo.files["file-1"] = &models.File{
+        ID:          "file-1",
+        FileName:    "scan.png",
+        ContentType: "image/png",
+        Size:        5,
+        Status:      models.FileStatusUploaded,
+        StoragePath: "files/file-1",
+        Checksum:    "v1",
+    }
+    content := &contentStorage{content: map[string][]byte{"file-1": []byte("notes")}}
+    fileService, err := service.NewFileService(content, repo, service.WorkerPoolConfig{})
+    require.NoError(t, err)
+
+    generator := &pdfGenerator{}
+    derived, err := service.NewDerivedObjectService(newMockDerivedObjectRepository(), repo, content,
+        &memoryObjectStore{objects: make(map[string][]byte)}, 1024, generator)
+    require.NoError(t, err)
+    watermarker, err := service.NewWatermarker(&service.WatermarkPolicy{Default: &service.WatermarkRule{Text: "{user}"}}, 1024)
+    require.NoError(t, err)
+
+    files := handlers.NewFileHandler(fileService, metrics.NewPrometheus(prometheus.NewRegistry()),
+        handlers.DownloadSecurityPolicy{}, nil, watermarker, nil, nil, nil, nil, derived, nil, nil, nil, nil)
+    derivedObjects := handlers.NewDerivedHandler(derived, fileService, handlers.DownloadSecurityPolicy{}, nil, nil)
+
+    rec := httptest.NewRecorder()
+    files.DownloadHandler(rec, httptest.NewRequest(http.MethodGet, "/download?id=file-1&format=pdf", nil))
+    assert.Equal(t, http.StatusNotFound, rec.Code)
+
+    rec = httptest.NewRecorder()
+    derivedObjects.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/file-1/derived/converted?format=pdf", nil))
+    assert.Equal(t, http.StatusNotFound, rec.Code)
+    assert.NotContains(t, rec.Body.String(), "notes")
+
+    assert.Zero(t, generator.runs)
+}